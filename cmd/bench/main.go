@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/cmd/internal/cli"
+	"soltrading/cmd/internal/output"
+	"soltrading/pkg"
+	"soltrading/pkg/config"
+	"soltrading/pkg/sol"
+)
+
+// Latency summarizes timing samples in milliseconds
+type Latency struct {
+	Samples int     `json:"samples"`
+	P50     float64 `json:"p50Ms"`
+	P90     float64 `json:"p90Ms"`
+	P99     float64 `json:"p99Ms"`
+	Max     float64 `json:"maxMs"`
+}
+
+// ProtocolBench is the benchmark result of one protocol
+type ProtocolBench struct {
+	Protocol           string  `json:"protocol"`
+	Iterations         int     `json:"iterations"`
+	Pools              int     `json:"pools"` // found in the last successful discovery
+	Discovery          Latency `json:"discovery"`
+	DiscoveryFailures  int     `json:"discoveryFailures"`
+	DiscoveryErrorRate float64 `json:"discoveryErrorRate"`
+	Quote              Latency `json:"quote"`
+	QuoteFailures      int     `json:"quoteFailures"`
+	QuoteErrorRate     float64 `json:"quoteErrorRate"`
+	LastError          string  `json:"lastError,omitempty"`
+}
+
+// BenchResponse is the bench output
+type BenchResponse struct {
+	InputMint  string          `json:"inputMint"`
+	OutputMint string          `json:"outputMint"`
+	Amount     string          `json:"amount"`
+	Iterations int             `json:"iterations"`
+	Protocols  []ProtocolBench `json:"protocols"`
+}
+
+type BenchError struct {
+	Error string `json:"error"`
+}
+
+var (
+	rpcEndpoints = flag.String("rpc", "", "Comma-separated Solana RPC endpoints (reads from .env if not specified)")
+	inputMint    = flag.String("input", "", "Input token mint address (required)")
+	outputMint   = flag.String("output", "", "Output token mint address (required)")
+	amount       = flag.String("amount", "", "Input amount in smallest units, or token units such as 1.5 (required)")
+	decimals     = flag.Int("decimals", -1, cli.DecimalsUsage)
+	iterations   = flag.Int("n", 10, "Iterations per protocol")
+	protocolList = flag.String("protocols", "", "Comma-separated protocols to benchmark (default: all)")
+	rateLimit    = flag.Int("ratelimit", 20, "RPC requests per second limit per endpoint (default: 20)")
+	useRpcPool   = flag.Bool("use-pool", true, "Use RPC pool for load balancing (default: true)")
+	outputFormat = flag.String("format", "table", "Output format: json, table or csv")
+
+	format = output.Table
+)
+
+type sampler struct {
+	durations []time.Duration
+	failures  int
+	lastError error
+}
+
+func (s *sampler) record(elapsed time.Duration, err error) {
+	if err != nil {
+		s.failures++
+		s.lastError = err
+		return
+	}
+	s.durations = append(s.durations, elapsed)
+}
+
+// latency returns nearest-rank percentiles of the successful samples
+func (s *sampler) latency() Latency {
+	sorted := append([]time.Duration(nil), s.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p int) float64 {
+		if len(sorted) == 0 {
+			return 0
+		}
+		rank := (p*len(sorted) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		return float64(sorted[rank-1].Microseconds()) / 1000
+	}
+	return Latency{
+		Samples: len(sorted),
+		P50:     percentile(50),
+		P90:     percentile(90),
+		P99:     percentile(99),
+		Max:     percentile(100),
+	}
+}
+
+func (s *sampler) errorRate() float64 {
+	total := len(s.durations) + s.failures
+	if total == 0 {
+		return 0
+	}
+	return float64(s.failures) / float64(total)
+}
+
+func main() {
+	// Load .env file
+	if err := config.LoadEnv(".env"); err != nil {
+		log.Printf("Warning: Could not load .env file: %v", err)
+	}
+
+	flag.Parse()
+
+	var err error
+	if format, err = output.Resolve(*outputFormat, false); err != nil || format == output.Text {
+		fmt.Fprintln(os.Stderr, "Error: -format must be json, table or csv")
+		os.Exit(1)
+	}
+
+	// Validate required flags
+	if *inputMint == "" || *outputMint == "" || *amount == "" {
+		fmt.Fprintln(os.Stderr, "Error: Missing required arguments")
+		fmt.Fprintln(os.Stderr, "\nUsage:")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExample:")
+		fmt.Fprintln(os.Stderr, "  bench -input So11111111111111111111111111111111111111112 \\")
+		fmt.Fprintln(os.Stderr, "        -output EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v \\")
+		fmt.Fprintln(os.Stderr, "        -amount 1000000000 -n 20")
+		os.Exit(1)
+	}
+	if *iterations < 1 {
+		outputError("Invalid -n: must be at least 1")
+		os.Exit(1)
+	}
+
+	inTokenAddr, err := solana.PublicKeyFromBase58(*inputMint)
+	if err != nil {
+		outputError(fmt.Sprintf("Invalid input mint address: %v", err))
+		os.Exit(1)
+	}
+	outTokenAddr, err := solana.PublicKeyFromBase58(*outputMint)
+	if err != nil {
+		outputError(fmt.Sprintf("Invalid output mint address: %v", err))
+		os.Exit(1)
+	}
+	ctx := context.Background()
+
+	if err := cli.ApplyCluster(); err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	endpoints, err := cli.Endpoints(*rpcEndpoints)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+	solClient, _, err := cli.NewClient(ctx, endpoints, *rateLimit, *useRpcPool)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+	amountDecimals := *decimals
+	if amountDecimals < 0 && strings.Contains(*amount, ".") {
+		amountDecimals, _ = cli.PairDecimals(ctx, solClient, inTokenAddr.String(), outTokenAddr.String())
+	}
+	amountIn, err := cli.ParseAmount(*amount, amountDecimals)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+	protocols, err := cli.Protocols(solClient, cli.SplitList(*protocolList))
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	response := BenchResponse{
+		InputMint:  inTokenAddr.String(),
+		OutputMint: outTokenAddr.String(),
+		Amount:     amountIn.String(),
+		Iterations: *iterations,
+		Protocols:  bench(ctx, solClient, protocols, inTokenAddr.String(), outTokenAddr.String(), amountIn, *iterations),
+	}
+
+	if err := output.Print(os.Stdout, format, response, benchRecords(response)); err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+}
+
+// bench runs the iterations round-robin over the protocols, so that RPC load
+// and slot changes over the run affect every protocol alike
+func bench(ctx context.Context, solClient *sol.Client, protocols []pkg.Protocol, tokenIn, tokenOut string, amountIn math.Int, iterations int) []ProtocolBench {
+	discovery := make([]sampler, len(protocols))
+	quotes := make([]sampler, len(protocols))
+	pools := make([]int, len(protocols))
+
+	for i := 0; i < iterations; i++ {
+		log.Printf("Iteration %d/%d", i+1, iterations)
+		for j, proto := range protocols {
+			start := time.Now()
+			found, err := proto.FetchPoolsByPair(ctx, tokenIn, tokenOut)
+			discovery[j].record(time.Since(start), err)
+			if err != nil {
+				continue
+			}
+			pools[j] = len(found)
+
+			for _, pool := range found {
+				start := time.Now()
+				_, err := pool.Quote(ctx, solClient, tokenIn, amountIn)
+				quotes[j].record(time.Since(start), err)
+			}
+		}
+	}
+
+	results := make([]ProtocolBench, len(protocols))
+	for j, proto := range protocols {
+		results[j] = ProtocolBench{
+			Protocol:           string(proto.ProtocolName()),
+			Iterations:         iterations,
+			Pools:              pools[j],
+			Discovery:          discovery[j].latency(),
+			DiscoveryFailures:  discovery[j].failures,
+			DiscoveryErrorRate: discovery[j].errorRate(),
+			Quote:              quotes[j].latency(),
+			QuoteFailures:      quotes[j].failures,
+			QuoteErrorRate:     quotes[j].errorRate(),
+		}
+		if err := discovery[j].lastError; err != nil {
+			results[j].LastError = err.Error()
+		} else if err := quotes[j].lastError; err != nil {
+			results[j].LastError = err.Error()
+		}
+	}
+	return results
+}
+
+// benchRecords is the table and CSV form of the results, one row per protocol
+func benchRecords(response BenchResponse) output.Records {
+	records := output.Records{Columns: []string{
+		"protocol", "pools", "discovery_p50_ms", "discovery_p90_ms", "discovery_p99_ms", "discovery_errors",
+		"quotes", "quote_p50_ms", "quote_p90_ms", "quote_p99_ms", "quote_errors",
+	}}
+	ms := func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	rate := func(v float64) string { return strconv.FormatFloat(v*100, 'f', 1, 64) + "%" }
+	for _, p := range response.Protocols {
+		records.Add(p.Protocol, strconv.Itoa(p.Pools),
+			ms(p.Discovery.P50), ms(p.Discovery.P90), ms(p.Discovery.P99), rate(p.DiscoveryErrorRate),
+			strconv.Itoa(p.Quote.Samples+p.QuoteFailures),
+			ms(p.Quote.P50), ms(p.Quote.P90), ms(p.Quote.P99), rate(p.QuoteErrorRate))
+	}
+	return records
+}
+
+func outputError(msg string) {
+	if format == output.JSON {
+		errResp := BenchError{Error: msg}
+		jsonData, _ := json.MarshalIndent(errResp, "", "  ")
+		fmt.Fprintln(os.Stderr, string(jsonData))
+	} else {
+		log.Println("Error:", msg)
+	}
+}
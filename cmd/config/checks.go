@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"soltrading/pkg/cluster"
+	"soltrading/pkg/pool/raydium"
+	"soltrading/pkg/sol"
+)
+
+// Check statuses
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// clusterGenesis maps genesis hashes to cluster names
+var clusterGenesis = map[string]string{
+	"5eykt4UsFv8P8NJdTREpY1vzqKqZKvdpKuc147dw2N9d": "mainnet-beta",
+	"EtWTRABZaYq6iMfeYKouRu166VU2xqa1wcaWoxPkrZBG": "devnet",
+	"4uhcVJyU9pJkvQyS88uRDiswHXSCkY3zQawwpjk2NsNY": "testnet",
+}
+
+// Check is the outcome of one probe against an endpoint
+type Check struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Detail    string  `json:"detail,omitempty"`
+	LatencyMs float64 `json:"latencyMs,omitempty"`
+}
+
+// EndpointReport lists the checks run against one RPC endpoint
+type EndpointReport struct {
+	Endpoint string  `json:"endpoint"`
+	Cluster  string  `json:"cluster,omitempty"`
+	Version  string  `json:"version,omitempty"`
+	Checks   []Check `json:"checks"`
+}
+
+// Status is the worst status of the endpoint's checks
+func (r EndpointReport) Status() string {
+	status := StatusOK
+	for _, c := range r.Checks {
+		switch c.Status {
+		case StatusFail:
+			return StatusFail
+		case StatusWarn:
+			status = StatusWarn
+		}
+	}
+	return status
+}
+
+// prober sends raw JSON-RPC requests to one endpoint, so checks see HTTP
+// status codes that the RPC client hides
+type prober struct {
+	endpoint   string
+	headers    map[string]string
+	httpClient *http.Client
+	transport  sol.TransportConfig
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("RPC error %d: %s", e.Code, e.Message)
+}
+
+// call sends one request and returns the result, the HTTP status and the
+// round-trip time
+func (p *prober) call(ctx context.Context, method string, params ...interface{}) (json.RawMessage, int, time.Duration, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		// The wrapped error repeats the URL, which may hold an API key
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return nil, 0, time.Since(start), err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	elapsed := time.Since(start)
+	if err != nil {
+		return nil, resp.StatusCode, elapsed, fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, elapsed, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, resp.StatusCode, elapsed, fmt.Errorf("invalid JSON-RPC response: %w", err)
+	}
+	if envelope.Error != nil {
+		return nil, resp.StatusCode, elapsed, envelope.Error
+	}
+	return envelope.Result, resp.StatusCode, elapsed, nil
+}
+
+// checkEndpoint runs every probe against the endpoint
+func checkEndpoint(ctx context.Context, p *prober, burst int) EndpointReport {
+	report := EndpointReport{Endpoint: redact(p.endpoint)}
+
+	health := p.checkHealth(ctx, &report)
+	report.Checks = append(report.Checks, health)
+	if health.Status == StatusFail {
+		// Nothing else will work if the endpoint does not answer at all
+		return report
+	}
+	report.Checks = append(report.Checks,
+		p.checkProgramAccounts(ctx),
+		p.checkWebSocket(ctx),
+		p.checkRateLimit(ctx, burst),
+	)
+	return report
+}
+
+// checkHealth calls getHealth and identifies the cluster and node version
+func (p *prober) checkHealth(ctx context.Context, report *EndpointReport) Check {
+	check := Check{Name: "health"}
+	result, _, elapsed, err := p.call(ctx, "getHealth")
+	check.LatencyMs = milliseconds(elapsed)
+	if err != nil {
+		check.Status, check.Detail = StatusFail, err.Error()
+		return check
+	}
+	var health string
+	json.Unmarshal(result, &health)
+	check.Status, check.Detail = StatusOK, health
+
+	if result, _, _, err := p.call(ctx, "getGenesisHash"); err == nil {
+		var hash string
+		json.Unmarshal(result, &hash)
+		if report.Cluster = clusterGenesis[hash]; report.Cluster == "" {
+			report.Cluster = "unknown (" + hash + ")"
+		}
+		if profile := cluster.Current().Name; profile != cluster.Custom && report.Cluster != string(profile) {
+			check.Status = StatusWarn
+			check.Detail = fmt.Sprintf("%s; endpoint is on %s but the %s program IDs are configured (set SOLANA_CLUSTER)", health, report.Cluster, profile)
+		}
+	}
+	if result, _, _, err := p.call(ctx, "getVersion"); err == nil {
+		var version struct {
+			SolanaCore string `json:"solana-core"`
+		}
+		json.Unmarshal(result, &version)
+		report.Version = version.SolanaCore
+	}
+	return check
+}
+
+// checkProgramAccounts runs a getProgramAccounts query that matches nothing.
+// Pool discovery relies on getProgramAccounts, which many free plans disable.
+func (p *prober) checkProgramAccounts(ctx context.Context) Check {
+	check := Check{Name: "getProgramAccounts"}
+	_, _, elapsed, err := p.call(ctx, "getProgramAccounts", raydium.RAYDIUM_CPMM_PROGRAM_ID.String(), map[string]interface{}{
+		"encoding":  "base64",
+		"filters":   []interface{}{map[string]interface{}{"dataSize": 1}},
+		"dataSlice": map[string]int{"offset": 0, "length": 0},
+	})
+	check.LatencyMs = milliseconds(elapsed)
+	if err != nil {
+		check.Status, check.Detail = StatusFail, err.Error()
+		return check
+	}
+	check.Status, check.Detail = StatusOK, "supported"
+	return check
+}
+
+// checkWebSocket subscribes to slot updates and waits for the first one.
+// Live pool updates in the quote service use this subscription endpoint.
+func (p *prober) checkWebSocket(ctx context.Context) Check {
+	check := Check{Name: "websocket"}
+	fail := func(format string, args ...interface{}) Check {
+		check.Status, check.Detail = StatusFail, fmt.Sprintf(format, args...)
+		return check
+	}
+
+	dialer, err := p.transport.NewWebSocketDialer()
+	if err != nil {
+		return fail("%v", err)
+	}
+	header := http.Header{}
+	for k, v := range p.headers {
+		header.Set(k, v)
+	}
+	wsURL := httpToWsURL(p.endpoint)
+
+	start := time.Now()
+	conn, _, err := dialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		return fail("failed to connect to %s: %v", redact(wsURL), err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(p.transport.RequestTimeout)
+	conn.SetReadDeadline(deadline)
+	conn.SetWriteDeadline(deadline)
+	if err := conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "slotSubscribe"}); err != nil {
+		return fail("failed to subscribe: %v", err)
+	}
+	for {
+		var msg struct {
+			Result json.RawMessage `json:"result"`
+			Error  *rpcError       `json:"error"`
+			Method string          `json:"method"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			return fail("no slot notification: %v", err)
+		}
+		if msg.Error != nil {
+			return fail("slotSubscribe rejected: %v", msg.Error)
+		}
+		if msg.Method == "slotNotification" {
+			check.LatencyMs = milliseconds(time.Since(start))
+			check.Status, check.Detail = StatusOK, "slot notifications received"
+			return check
+		}
+	}
+}
+
+// checkRateLimit sends burst concurrent getSlot requests and reports how many
+// were throttled. Throttling means -ratelimit should stay below the burst size.
+func (p *prober) checkRateLimit(ctx context.Context, burst int) Check {
+	check := Check{Name: "rate limit"}
+	if burst <= 0 {
+		check.Status, check.Detail = StatusOK, "skipped"
+		return check
+	}
+
+	var (
+		mu        sync.Mutex
+		throttled int
+		failed    int
+		lastErr   error
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < burst; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, status, _, err := p.call(ctx, "getSlot")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case status == http.StatusTooManyRequests:
+				throttled++
+			case err != nil:
+				failed++
+				lastErr = err
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	check.LatencyMs = milliseconds(elapsed)
+
+	ok := burst - throttled - failed
+	check.Detail = fmt.Sprintf("%d/%d requests in %.0fms succeeded, %d throttled", ok, burst, milliseconds(elapsed), throttled)
+	if lastErr != nil {
+		check.Detail += fmt.Sprintf(", %d failed (%v)", failed, lastErr)
+	}
+	switch {
+	case ok == 0:
+		check.Status = StatusFail
+	case throttled > 0 || failed > 0:
+		check.Status = StatusWarn
+	default:
+		check.Status = StatusOK
+	}
+	return check
+}
+
+// httpToWsURL converts an HTTP(S) RPC URL to a WebSocket URL
+func httpToWsURL(httpURL string) string {
+	wsURL := strings.Replace(httpURL, "https://", "wss://", 1)
+	return strings.Replace(wsURL, "http://", "ws://", 1)
+}
+
+// redact hides API keys passed in the endpoint's query string
+func redact(endpoint string) string {
+	if i := strings.Index(endpoint, "?"); i >= 0 {
+		return endpoint[:i] + "?***"
+	}
+	return endpoint
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"soltrading/cmd/internal/cli"
+	"soltrading/cmd/internal/output"
+	"soltrading/pkg/config"
+	"soltrading/pkg/sol"
+)
+
+// ConfigReport is the capability report of the configured endpoints
+type ConfigReport struct {
+	Endpoints []EndpointReport `json:"endpoints"`
+	Status    string           `json:"status"` // worst status over all endpoints
+}
+
+type ConfigError struct {
+	Error string `json:"error"`
+}
+
+// starterEnv is the .env written by -init
+const starterEnv = `# SolRoute configuration, read by every command from the working directory
+
+# Comma-separated Solana RPC endpoints. Pool discovery needs getProgramAccounts
+# support; the quote service also uses the endpoint's WebSocket for live updates.
+RPC_ENDPOINTS=%s
+
+# Optional per-endpoint authentication headers (JSON keyed by endpoint URL, "*" applies to all)
+# RPC_HEADERS={"https://my-node.example.com":{"x-token":"YOUR_TOKEN"}}
+
+# Cluster profile selecting the protocols' program IDs: mainnet-beta (default), devnet or custom
+# SOLANA_CLUSTER=devnet
+# Program ID overrides by protocol, required for custom
+# CLUSTER_PROGRAMS={"raydium_cpmm":"YOUR_PROGRAM_ID"}
+
+# Find pools through the Raydium, Orca and Meteora HTTP APIs when the endpoint
+# rejects or rate-limits getProgramAccounts
+# POOL_API_FALLBACK=true
+
+# Optional Yellowstone Geyser gRPC token, used with the quote service -geyser flag
+# GEYSER_TOKEN=YOUR_TOKEN
+
+# Optional bearer token protecting the quote service admin endpoints
+# ADMIN_TOKEN=YOUR_TOKEN
+`
+
+var (
+	rpcEndpoints = flag.String("rpc", "", "Comma-separated Solana RPC endpoints (reads from .env if not specified)")
+	initEnv      = flag.Bool("init", false, "Write a starter .env file instead of checking endpoints")
+	envFile      = flag.String("env", ".env", "The .env file to read, or to write with -init")
+	force        = flag.Bool("force", false, "Let -init overwrite an existing file")
+	timeout      = flag.Duration("timeout", 10*time.Second, "Timeout of each check")
+	burst        = flag.Int("burst", 20, "Concurrent requests sent to probe rate limits (0 skips the probe)")
+	outputFormat = flag.String("format", "table", "Output format: json, table or csv")
+
+	format = output.Table
+)
+
+func main() {
+	flag.Parse()
+
+	var err error
+	if format, err = output.Resolve(*outputFormat, false); err != nil || format == output.Text {
+		fmt.Fprintln(os.Stderr, "Error: -format must be json, table or csv")
+		os.Exit(1)
+	}
+
+	if *initEnv {
+		if err := writeStarterEnv(*envFile, *rpcEndpoints, *force); err != nil {
+			outputError(err.Error())
+			os.Exit(1)
+		}
+		log.Printf("Wrote %s; set RPC_ENDPOINTS and run config again to check the endpoints", *envFile)
+		return
+	}
+
+	// Load .env file
+	if err := config.LoadEnv(*envFile); err != nil {
+		log.Printf("Warning: Could not load %s file: %v", *envFile, err)
+	}
+	if *timeout <= 0 {
+		outputError("Invalid -timeout: must be positive")
+		os.Exit(1)
+	}
+
+	if err := cli.ApplyCluster(); err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	endpoints, err := cli.Endpoints(*rpcEndpoints)
+	if err != nil {
+		outputError(fmt.Sprintf("%v (run config -init to create a starter .env)", err))
+		os.Exit(1)
+	}
+	headers, err := config.GetRPCHeaders()
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	transport := sol.DefaultTransportConfig()
+	transport.DialTimeout = *timeout
+	transport.RequestTimeout = *timeout
+	httpClient, err := transport.NewHTTPClient()
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	report := ConfigReport{Status: StatusOK}
+	for _, endpoint := range endpoints {
+		log.Printf("Checking %s", redact(endpoint))
+		endpointReport := checkEndpoint(ctx, &prober{
+			endpoint:   endpoint,
+			headers:    config.HeadersForEndpoint(headers, endpoint),
+			httpClient: httpClient,
+			transport:  transport,
+		}, *burst)
+		report.Endpoints = append(report.Endpoints, endpointReport)
+		report.Status = worse(report.Status, endpointReport.Status())
+	}
+
+	if err := output.Print(os.Stdout, format, report, reportRecords(report)); err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+	if report.Status == StatusFail {
+		os.Exit(1)
+	}
+}
+
+// writeStarterEnv writes the starter configuration, with the given endpoints
+// or a placeholder
+func writeStarterEnv(path, endpoints string, overwrite bool) error {
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return fmt.Errorf("%s already exists; use -force to overwrite it", path)
+	}
+	value := strings.Join(cli.SplitList(endpoints), ",")
+	if value == "" {
+		value = "https://mainnet.helius-rpc.com/?api-key=YOUR_KEY"
+	}
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(starterEnv, value)), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// worse returns the more severe of two statuses
+func worse(a, b string) string {
+	rank := map[string]int{StatusOK: 0, StatusWarn: 1, StatusFail: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// reportRecords is the table and CSV form of the report, one row per check
+func reportRecords(report ConfigReport) output.Records {
+	records := output.Records{Columns: []string{"endpoint", "cluster", "version", "check", "status", "latency_ms", "detail"}}
+	for _, e := range report.Endpoints {
+		for _, c := range e.Checks {
+			records.Add(e.Endpoint, e.Cluster, e.Version, c.Name, c.Status, strconv.FormatFloat(c.LatencyMs, 'f', 1, 64), c.Detail)
+		}
+	}
+	return records
+}
+
+func outputError(msg string) {
+	if format == output.JSON {
+		errResp := ConfigError{Error: msg}
+		jsonData, _ := json.MarshalIndent(errResp, "", "  ")
+		fmt.Fprintln(os.Stderr, string(jsonData))
+	} else {
+		log.Println("Error:", msg)
+	}
+}
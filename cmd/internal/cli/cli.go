@@ -0,0 +1,191 @@
+// Package cli holds the RPC and protocol setup shared by the command-line tools
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg"
+	"soltrading/pkg/cluster"
+	"soltrading/pkg/config"
+	"soltrading/pkg/pool/meteora"
+	"soltrading/pkg/pool/pump"
+	"soltrading/pkg/pool/raydium"
+	"soltrading/pkg/pool/whirlpool"
+	"soltrading/pkg/poolapi"
+	"soltrading/pkg/protocol"
+	"soltrading/pkg/sol"
+)
+
+// SplitList splits a comma-separated flag value, dropping empty entries
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Endpoints returns the -rpc endpoints, or RPC_ENDPOINTS from the environment
+// when the flag is empty
+func Endpoints(rpcFlag string) ([]string, error) {
+	if endpoints := SplitList(rpcFlag); len(endpoints) > 0 {
+		return endpoints, nil
+	}
+	endpoints := config.GetRPCEndpoints()
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no RPC endpoints configured. Set RPC_ENDPOINTS in .env or use -rpc flag")
+	}
+	return endpoints, nil
+}
+
+// NewClient connects to the endpoints. With usePool and several endpoints the
+// client comes from an RPC pool, which is returned too; otherwise the pool is nil.
+func NewClient(ctx context.Context, endpoints []string, rateLimit int, usePool bool) (*sol.Client, *sol.RPCPool, error) {
+	if usePool && len(endpoints) > 1 {
+		rpcPool, err := sol.NewRPCPool(ctx, endpoints, "", rateLimit)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create RPC pool: %w", err)
+		}
+		return rpcPool.GetClient(), rpcPool, nil
+	}
+	solClient, err := sol.NewClient(ctx, endpoints[0], "", rateLimit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Solana client: %w", err)
+	}
+	return solClient, nil, nil
+}
+
+// protocolConstructors are the protocols the tools can query, by name, with
+// the program that owns their pool accounts
+var protocolConstructors = []struct {
+	name    pkg.ProtocolName
+	program *solana.PublicKey // read on use, as cluster profiles replace it
+	new     func(sol.SolClient) pkg.Protocol
+}{
+	{pkg.ProtocolNamePumpAmm, &pump.PumpSwapProgramID, func(c sol.SolClient) pkg.Protocol { return protocol.NewPumpAmm(c) }},
+	{pkg.ProtocolNameRaydiumAmm, &raydium.RAYDIUM_AMM_PROGRAM_ID, func(c sol.SolClient) pkg.Protocol { return protocol.NewRaydiumAmm(c) }},
+	{pkg.ProtocolNameRaydiumClmm, &raydium.RAYDIUM_CLMM_PROGRAM_ID, func(c sol.SolClient) pkg.Protocol { return protocol.NewRaydiumClmm(c) }},
+	{pkg.ProtocolNameRaydiumCpmm, &raydium.RAYDIUM_CPMM_PROGRAM_ID, func(c sol.SolClient) pkg.Protocol { return protocol.NewRaydiumCpmm(c) }},
+	{pkg.ProtocolNameMeteoraDlmm, &meteora.MeteoraProgramID, func(c sol.SolClient) pkg.Protocol { return protocol.NewMeteoraDlmm(c) }},
+	{pkg.ProtocolNameWhirlpool, &whirlpool.WhirlpoolProgramID, func(c sol.SolClient) pkg.Protocol { return protocol.NewWhirlpool(c) }},
+}
+
+// ApplyCluster points the protocols at the program IDs of the cluster profile
+// selected by SOLANA_CLUSTER and CLUSTER_PROGRAMS
+func ApplyCluster() error {
+	profile, err := cluster.FromEnv()
+	if err != nil {
+		return err
+	}
+	cluster.Apply(profile)
+	if profile.Name != cluster.MainnetBeta {
+		log.Printf("Using %s program IDs", profile.Name)
+	}
+	return nil
+}
+
+// ProtocolNames lists the protocols Protocols accepts
+func ProtocolNames() []string {
+	names := make([]string, len(protocolConstructors))
+	for i, c := range protocolConstructors {
+		names[i] = string(c.name)
+	}
+	return names
+}
+
+// ProtocolForProgram creates the protocol whose pools are owned by program
+func ProtocolForProgram(solClient sol.SolClient, program solana.PublicKey) (pkg.Protocol, bool) {
+	for _, c := range protocolConstructors {
+		if c.program.Equals(program) {
+			return c.new(solClient), true
+		}
+	}
+	return nil, false
+}
+
+// Protocols creates the enabled protocols on solClient; empty enables all
+func Protocols(solClient sol.SolClient, enabled []string) ([]pkg.Protocol, error) {
+	want := make(map[string]bool, len(enabled))
+	for _, name := range enabled {
+		want[name] = true
+	}
+
+	var protocols []pkg.Protocol
+	for _, c := range protocolConstructors {
+		if len(enabled) == 0 || want[string(c.name)] {
+			protocols = append(protocols, c.new(solClient))
+			delete(want, string(c.name))
+		}
+	}
+	for name := range want {
+		return nil, fmt.Errorf("%w: unknown protocol %q (known: %s)", pkg.ErrUnsupportedProtocol, name, strings.Join(ProtocolNames(), ", "))
+	}
+	if config.GetPoolAPIFallback() {
+		protocols = poolapi.WithFallback(protocols, poolapi.DefaultBackends()...)
+	}
+	return protocols, nil
+}
+
+// VaultBalances reads the token amount of each token account
+func VaultBalances(ctx context.Context, solClient sol.SolClient, vaults ...string) ([]uint64, error) {
+	data, err := accountData(ctx, solClient, vaults)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]uint64, len(vaults))
+	for i, d := range data {
+		amount, err := sol.TokenAccountAmount(d)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a token account: %w", vaults[i], err)
+		}
+		balances[i] = amount
+	}
+	return balances, nil
+}
+
+// MintDecimals reads the decimals of each mint
+func MintDecimals(ctx context.Context, solClient sol.SolClient, mints ...string) ([]uint8, error) {
+	data, err := accountData(ctx, solClient, mints)
+	if err != nil {
+		return nil, err
+	}
+	decimals := make([]uint8, len(mints))
+	for i, d := range data {
+		// Supply authority option, authority and supply precede the decimals
+		if len(d) < 45 {
+			return nil, fmt.Errorf("%s is not a mint", mints[i])
+		}
+		decimals[i] = d[44]
+	}
+	return decimals, nil
+}
+
+func accountData(ctx context.Context, solClient sol.SolClient, addresses []string) ([][]byte, error) {
+	keys := make([]solana.PublicKey, len(addresses))
+	for i, address := range addresses {
+		key, err := solana.PublicKeyFromBase58(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid account %q: %w", address, err)
+		}
+		keys[i] = key
+	}
+	result, err := solClient.GetMultipleAccountsWithOpts(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	data := make([][]byte, len(addresses))
+	for i, account := range result.Value {
+		if account == nil {
+			return nil, fmt.Errorf("account %s not found", addresses[i])
+		}
+		data[i] = account.Data.GetBinary()
+	}
+	return data, nil
+}
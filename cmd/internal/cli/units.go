@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"cosmossdk.io/math"
+	"soltrading/pkg/sol"
+)
+
+// DecimalsUsage is the usage text of the -decimals flag
+const DecimalsUsage = "Decimals of the -amount token, for amounts in token units such as 1.5 (-1 = read from the mint)"
+
+// PairDecimals returns the decimals of the input and output mints; -1 marks
+// decimals that could not be read
+func PairDecimals(ctx context.Context, solClient sol.SolClient, inputMint, outputMint string) (int, int) {
+	decimals, err := MintDecimals(ctx, solClient, inputMint, outputMint)
+	if err != nil {
+		log.Printf("Warning: Could not read mint decimals: %v", err)
+		return -1, -1
+	}
+	return int(decimals[0]), int(decimals[1])
+}
+
+// ParseAmount parses an -amount value. Integers are raw amounts in the token's
+// smallest unit; values with a decimal point, such as 1.5 or 2., are token
+// units and are scaled by decimals.
+func ParseAmount(value string, decimals int) (math.Int, error) {
+	if !strings.Contains(value, ".") {
+		amount, ok := math.NewIntFromString(value)
+		if !ok || !amount.IsPositive() {
+			return math.ZeroInt(), fmt.Errorf("invalid amount %q: must be a positive integer or a decimal token amount", value)
+		}
+		return amount, nil
+	}
+	if decimals < 0 {
+		return math.ZeroInt(), fmt.Errorf("token decimals unknown for amount %q; set -decimals or give a raw integer amount", value)
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if len(fraction) > decimals {
+		return math.ZeroInt(), fmt.Errorf("invalid amount %q: the token has only %d decimals", value, decimals)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	digits := whole + fraction + strings.Repeat("0", decimals-len(fraction))
+	if strings.ContainsAny(digits, "+-") {
+		return math.ZeroInt(), fmt.Errorf("invalid amount %q: must be positive", value)
+	}
+	amount, ok := math.NewIntFromString(digits)
+	if !ok || !amount.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("invalid amount %q: must be a positive token amount", value)
+	}
+	return amount, nil
+}
+
+// FormatUnits formats a raw amount in token units, without trailing zeros.
+// It returns "" when decimals is unknown (negative).
+func FormatUnits(amount math.Int, decimals int) string {
+	if decimals < 0 || amount.IsNil() {
+		return ""
+	}
+	digits := amount.Abs().String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if amount.IsNegative() {
+		whole = "-" + whole
+	}
+	if fraction == "" {
+		return whole
+	}
+	return whole + "." + fraction
+}
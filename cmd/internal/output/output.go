@@ -0,0 +1,110 @@
+// Package output renders command results as JSON, aligned tables or CSV so
+// every CLI command supports the same -format values
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format selects how a command prints its result
+type Format string
+
+const (
+	// Text is a command's own human-readable layout
+	Text  Format = "text"
+	JSON  Format = "json"
+	Table Format = "table"
+	CSV   Format = "csv"
+)
+
+// FlagUsage describes the -format flag
+const FlagUsage = "Output format: json, table or csv (overrides -json)"
+
+// Resolve returns the format named by the -format flag. An empty name keeps
+// the older -json flag working: JSON when it is set, Text otherwise.
+func Resolve(name string, jsonFlag bool) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case "":
+		if jsonFlag {
+			return JSON, nil
+		}
+		return Text, nil
+	case JSON:
+		return JSON, nil
+	case Table:
+		return Table, nil
+	case CSV:
+		return CSV, nil
+	case Text:
+		return Text, nil
+	}
+	return "", fmt.Errorf("unknown output format %q (use json, table or csv)", name)
+}
+
+// Records is the tabular form of a result: snake_case column names and one
+// row of values per record
+type Records struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// Add appends a row
+func (r *Records) Add(values ...string) {
+	r.Rows = append(r.Rows, values)
+}
+
+// Print writes value as indented JSON, or records as a table or CSV
+func Print(w io.Writer, format Format, value interface{}, records Records) error {
+	switch format {
+	case JSON:
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case Table:
+		return PrintTable(w, records)
+	case CSV:
+		return PrintCSV(w, records)
+	}
+	return fmt.Errorf("format %q has no generic renderer", format)
+}
+
+// PrintTable writes records as space-aligned columns under an upper-case header
+func PrintTable(w io.Writer, records Records) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := make([]string, len(records.Columns))
+	for i, column := range records.Columns {
+		header[i] = strings.ToUpper(strings.ReplaceAll(column, "_", " "))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range records.Rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			if cell == "" {
+				cell = "-"
+			}
+			cells[i] = cell
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// PrintCSV writes records as RFC 4180 CSV with a header row
+func PrintCSV(w io.Writer, records Records) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(records.Columns); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	if err := cw.WriteAll(records.Rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/cmd/internal/cli"
+	"soltrading/cmd/internal/output"
+	"soltrading/pkg"
+	"soltrading/pkg/config"
+	"soltrading/pkg/pool/meteora"
+	"soltrading/pkg/pool/raydium"
+	"soltrading/pkg/sol"
+)
+
+// Field is one named value of the decoded pool state
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PoolInfo is the pool-info output
+type PoolInfo struct {
+	Address      string  `json:"address"`
+	Program      string  `json:"program"`
+	Protocol     string  `json:"protocol"`
+	BaseMint     string  `json:"baseMint"`
+	QuoteMint    string  `json:"quoteMint"`
+	BaseVault    string  `json:"baseVault,omitempty"`
+	QuoteVault   string  `json:"quoteVault,omitempty"`
+	BaseReserve  string  `json:"baseReserve,omitempty"`
+	QuoteReserve string  `json:"quoteReserve,omitempty"`
+	Derived      []Field `json:"derived,omitempty"` // values computed from the state
+	Fields       []Field `json:"fields"`            // the decoded state
+}
+
+type PoolInfoError struct {
+	Error string `json:"error"`
+}
+
+var (
+	rpcEndpoints = flag.String("rpc", "", "Comma-separated Solana RPC endpoints (reads from .env if not specified)")
+	poolAddress  = flag.String("pool", "", "Pool account address (required; may also be given as the first argument)")
+	rateLimit    = flag.Int("ratelimit", 20, "RPC requests per second limit per endpoint (default: 20)")
+	outputFormat = flag.String("format", "table", "Output format: json, table or csv")
+
+	format = output.Table
+)
+
+func main() {
+	// Load .env file
+	if err := config.LoadEnv(".env"); err != nil {
+		log.Printf("Warning: Could not load .env file: %v", err)
+	}
+
+	flag.Parse()
+
+	var err error
+	if format, err = output.Resolve(*outputFormat, false); err != nil || format == output.Text {
+		fmt.Fprintln(os.Stderr, "Error: -format must be json, table or csv")
+		os.Exit(1)
+	}
+
+	address := *poolAddress
+	if address == "" && flag.NArg() > 0 {
+		address = flag.Arg(0)
+	}
+	if address == "" {
+		fmt.Fprintln(os.Stderr, "Error: Missing pool address")
+		fmt.Fprintln(os.Stderr, "\nUsage:")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExample:")
+		fmt.Fprintln(os.Stderr, "  pool-info 58oQChx4yWmvKdwLLZzBi4ChoCc2fqCUWBkwMihLYQo2")
+		os.Exit(1)
+	}
+
+	poolKey, err := solana.PublicKeyFromBase58(address)
+	if err != nil {
+		outputError(fmt.Sprintf("Invalid pool address: %v", err))
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if err := cli.ApplyCluster(); err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	endpoints, err := cli.Endpoints(*rpcEndpoints)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+	solClient, _, err := cli.NewClient(ctx, endpoints, *rateLimit, false)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	account, err := solClient.GetAccountInfoWithOpts(ctx, poolKey)
+	if err != nil || account == nil || account.Value == nil {
+		outputError(fmt.Sprintf("Failed to get pool account %s: %v", poolKey, err))
+		os.Exit(1)
+	}
+	program := account.Value.Owner
+	proto, ok := cli.ProtocolForProgram(solClient, program)
+	if !ok {
+		outputError(fmt.Sprintf("Account %s is owned by %s, which is not a supported pool program (supported: %s)",
+			poolKey, program, strings.Join(cli.ProtocolNames(), ", ")))
+		os.Exit(1)
+	}
+
+	pool, err := proto.FetchPoolByID(ctx, poolKey.String())
+	if err != nil {
+		outputError(fmt.Sprintf("Failed to decode %s pool: %v", proto.ProtocolName(), err))
+		os.Exit(1)
+	}
+
+	info := describe(ctx, solClient, pool, program)
+	if err := output.Print(os.Stdout, format, info, infoRecords(info)); err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+}
+
+// describe collects the pool's tokens, vault balances, derived values and
+// decoded fields
+func describe(ctx context.Context, solClient *sol.Client, pool pkg.Pool, program solana.PublicKey) PoolInfo {
+	baseMint, quoteMint := pool.GetTokens()
+	info := PoolInfo{
+		Address:   pool.GetID(),
+		Program:   program.String(),
+		Protocol:  string(pool.ProtocolName()),
+		BaseMint:  baseMint,
+		QuoteMint: quoteMint,
+		Derived:   derived(pool),
+		Fields:    fields(reflect.ValueOf(pool)),
+	}
+
+	type vaultPool interface {
+		GetBaseVault() string
+		GetQuoteVault() string
+	}
+	if vp, ok := pool.(vaultPool); ok {
+		info.BaseVault, info.QuoteVault = vp.GetBaseVault(), vp.GetQuoteVault()
+		balances, err := cli.VaultBalances(ctx, solClient, info.BaseVault, info.QuoteVault)
+		if err != nil {
+			log.Printf("Failed to read vault balances: %v", err)
+		} else {
+			info.BaseReserve = strconv.FormatUint(balances[0], 10)
+			info.QuoteReserve = strconv.FormatUint(balances[1], 10)
+		}
+	}
+	return info
+}
+
+// derived computes values that are not stored directly in the pool state
+func derived(pool pkg.Pool) []Field {
+	var out []Field
+	switch p := pool.(type) {
+	case *raydium.CLMMPool:
+		// Price of token0 in token1, adjusted for decimals
+		price := p.CurrentPrice() * math.Pow10(int(p.MintDecimals0)-int(p.MintDecimals1))
+		out = append(out,
+			Field{"price", strconv.FormatFloat(price, 'g', 10, 64)},
+			Field{"feePercent", strconv.FormatFloat(float64(p.FeeRate)/1e4, 'f', 4, 64)})
+	case *meteora.MeteoraDlmmPool:
+		for _, fee := range []struct {
+			name string
+			get  func() (*big.Int, error)
+		}{
+			{"baseFeePercent", p.GetBaseFee},
+			{"variableFeePercent", p.GetVariableFee},
+			{"totalFeePercent", p.GetTotalFee},
+		} {
+			if rate, err := fee.get(); err == nil {
+				percent, _ := new(big.Float).SetInt(rate).Float64()
+				out = append(out, Field{fee.name, strconv.FormatFloat(percent/meteora.FeePrecision*100, 'f', 4, 64)})
+			}
+		}
+	}
+	return out
+}
+
+// fields lists the exported fields of the pool state. Padding, discriminators
+// and runtime caches are left out.
+func fields(v reflect.Value) []Field {
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	var out []Field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Name == "Discriminator" || strings.HasPrefix(f.Name, "Padding") {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.Map, reflect.Ptr, reflect.Func, reflect.Chan, reflect.Interface:
+			continue
+		}
+		out = append(out, Field{f.Name, formatValue(v.Field(i).Interface())})
+	}
+	return out
+}
+
+func formatValue(value interface{}) string {
+	switch x := value.(type) {
+	case solana.PublicKey:
+		return x.String()
+	case fmt.Stringer:
+		return x.String()
+	}
+	return fmt.Sprintf("%+v", value)
+}
+
+// infoRecords is the table and CSV form of the pool info, one row per value
+func infoRecords(info PoolInfo) output.Records {
+	records := output.Records{Columns: []string{"field", "value"}}
+	records.Add("address", info.Address)
+	records.Add("program", info.Program)
+	records.Add("protocol", info.Protocol)
+	records.Add("baseMint", info.BaseMint)
+	records.Add("quoteMint", info.QuoteMint)
+	if info.BaseVault != "" {
+		records.Add("baseVault", info.BaseVault)
+		records.Add("quoteVault", info.QuoteVault)
+		records.Add("baseReserve", info.BaseReserve)
+		records.Add("quoteReserve", info.QuoteReserve)
+	}
+	for _, f := range info.Derived {
+		records.Add(f.Name, f.Value)
+	}
+	for _, f := range info.Fields {
+		records.Add(f.Name, f.Value)
+	}
+	return records
+}
+
+func outputError(msg string) {
+	if format == output.JSON {
+		errResp := PoolInfoError{Error: msg}
+		jsonData, _ := json.MarshalIndent(errResp, "", "  ")
+		fmt.Fprintln(os.Stderr, string(jsonData))
+	} else {
+		log.Println("Error:", msg)
+	}
+}
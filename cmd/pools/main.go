@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/cmd/internal/cli"
+	"soltrading/cmd/internal/output"
+	"soltrading/pkg"
+	"soltrading/pkg/config"
+	"soltrading/pkg/sol"
+)
+
+// PoolSummary describes one pool of the pair. Reserves and liquidity are in
+// whole tokens; liquidity is twice the output-side reserve, in output tokens.
+type PoolSummary struct {
+	Protocol      string   `json:"protocol"`
+	PoolID        string   `json:"poolId"`
+	FeeBps        *float64 `json:"feeBps,omitempty"`
+	InputReserve  *float64 `json:"inputReserve,omitempty"`
+	OutputReserve *float64 `json:"outputReserve,omitempty"`
+	Liquidity     *float64 `json:"liquidity,omitempty"`
+}
+
+// PoolsResponse is the pools output
+type PoolsResponse struct {
+	InputMint  string        `json:"inputMint"`
+	OutputMint string        `json:"outputMint"`
+	Pools      []PoolSummary `json:"pools"`
+}
+
+type PoolsError struct {
+	Error string `json:"error"`
+}
+
+var (
+	rpcEndpoints = flag.String("rpc", "", "Comma-separated Solana RPC endpoints (reads from .env if not specified)")
+	inputMint    = flag.String("input", "", "Input token mint address (required)")
+	outputMint   = flag.String("output", "", "Output token mint address (required)")
+	protocolList = flag.String("protocols", "", "Comma-separated protocols to query (default: all)")
+	sortBy       = flag.String("sort", "liquidity", "Sort by liquidity (highest first), fee (lowest first) or protocol")
+	limit        = flag.Int("limit", 0, "Print at most this many pools (0 = all)")
+	rateLimit    = flag.Int("ratelimit", 20, "RPC requests per second limit per endpoint (default: 20)")
+	useRpcPool   = flag.Bool("use-pool", true, "Use RPC pool for load balancing (default: true)")
+	outputFormat = flag.String("format", "table", "Output format: json, table or csv")
+
+	format = output.Table
+)
+
+func main() {
+	// Load .env file
+	if err := config.LoadEnv(".env"); err != nil {
+		log.Printf("Warning: Could not load .env file: %v", err)
+	}
+
+	flag.Parse()
+
+	var err error
+	if format, err = output.Resolve(*outputFormat, false); err != nil || format == output.Text {
+		fmt.Fprintln(os.Stderr, "Error: -format must be json, table or csv")
+		os.Exit(1)
+	}
+
+	// Validate required flags
+	if *inputMint == "" || *outputMint == "" {
+		fmt.Fprintln(os.Stderr, "Error: Missing required arguments")
+		fmt.Fprintln(os.Stderr, "\nUsage:")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExample:")
+		fmt.Fprintln(os.Stderr, "  pools -input So11111111111111111111111111111111111111112 \\")
+		fmt.Fprintln(os.Stderr, "        -output EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+		os.Exit(1)
+	}
+	if *sortBy != "liquidity" && *sortBy != "fee" && *sortBy != "protocol" {
+		outputError("Invalid -sort: use liquidity, fee or protocol")
+		os.Exit(1)
+	}
+
+	inTokenAddr, err := solana.PublicKeyFromBase58(*inputMint)
+	if err != nil {
+		outputError(fmt.Sprintf("Invalid input mint address: %v", err))
+		os.Exit(1)
+	}
+	outTokenAddr, err := solana.PublicKeyFromBase58(*outputMint)
+	if err != nil {
+		outputError(fmt.Sprintf("Invalid output mint address: %v", err))
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if err := cli.ApplyCluster(); err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	endpoints, err := cli.Endpoints(*rpcEndpoints)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+	solClient, _, err := cli.NewClient(ctx, endpoints, *rateLimit, *useRpcPool)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+	protocols, err := cli.Protocols(solClient, cli.SplitList(*protocolList))
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	var pools []pkg.Pool
+	for _, proto := range protocols {
+		found, err := proto.FetchPoolsByPair(ctx, inTokenAddr.String(), outTokenAddr.String())
+		if err != nil {
+			log.Printf("Error fetching pools from %s: %v", proto.ProtocolName(), err)
+			continue
+		}
+		pools = append(pools, found...)
+	}
+
+	summaries := summarize(ctx, solClient, pools, inTokenAddr.String(), outTokenAddr.String())
+	sortPools(summaries, *sortBy)
+	if *limit > 0 && len(summaries) > *limit {
+		summaries = summaries[:*limit]
+	}
+
+	response := PoolsResponse{
+		InputMint:  inTokenAddr.String(),
+		OutputMint: outTokenAddr.String(),
+		Pools:      summaries,
+	}
+	if err := output.Print(os.Stdout, format, response, poolRecords(response)); err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+}
+
+// summarize reads every pool's vault balances in one batch and derives fees
+// and liquidity
+func summarize(ctx context.Context, solClient *sol.Client, pools []pkg.Pool, tokenIn, tokenOut string) []PoolSummary {
+	type vaultPool interface {
+		GetBaseVault() string
+		GetQuoteVault() string
+	}
+
+	decimals := map[string]uint8{}
+	if d, err := cli.MintDecimals(ctx, solClient, tokenIn, tokenOut); err == nil {
+		decimals[tokenIn], decimals[tokenOut] = d[0], d[1]
+	} else {
+		log.Printf("Failed to read mint decimals: %v", err)
+	}
+
+	var vaults []string
+	vaultIndex := make([]int, len(pools))
+	for i, pool := range pools {
+		vaultIndex[i] = -1
+		if vp, ok := pool.(vaultPool); ok && len(decimals) == 2 {
+			vaultIndex[i] = len(vaults)
+			vaults = append(vaults, vp.GetBaseVault(), vp.GetQuoteVault())
+		}
+	}
+	var balances []uint64
+	if len(vaults) > 0 {
+		var err error
+		if balances, err = cli.VaultBalances(ctx, solClient, vaults...); err != nil {
+			log.Printf("Failed to read vault balances: %v", err)
+		}
+	}
+
+	summaries := make([]PoolSummary, len(pools))
+	for i, pool := range pools {
+		summaries[i] = PoolSummary{
+			Protocol: string(pool.ProtocolName()),
+			PoolID:   pool.GetID(),
+		}
+		fee := pool.GetFeeRate()
+		summaries[i].FeeBps = &fee
+		if vaultIndex[i] < 0 || balances == nil {
+			continue
+		}
+
+		baseReserve, quoteReserve := balances[vaultIndex[i]], balances[vaultIndex[i]+1]
+		inReserve, outReserve := baseReserve, quoteReserve
+		if baseMint, _ := pool.GetTokens(); baseMint == tokenOut {
+			inReserve, outReserve = quoteReserve, baseReserve
+		}
+		in := float64(inReserve) / math.Pow10(int(decimals[tokenIn]))
+		out := float64(outReserve) / math.Pow10(int(decimals[tokenOut]))
+		liquidity := 2 * out
+		summaries[i].InputReserve, summaries[i].OutputReserve, summaries[i].Liquidity = &in, &out, &liquidity
+	}
+	return summaries
+}
+
+// sortPools orders pools by liquidity (highest first), fee (lowest first) or
+// protocol, then liquidity
+func sortPools(pools []PoolSummary, by string) {
+	sort.SliceStable(pools, func(i, j int) bool {
+		a, b := pools[i], pools[j]
+		switch by {
+		case "fee":
+			return lessValue(a.FeeBps, b.FeeBps, true)
+		case "protocol":
+			if a.Protocol != b.Protocol {
+				return a.Protocol < b.Protocol
+			}
+		}
+		return lessValue(a.Liquidity, b.Liquidity, false)
+	})
+}
+
+// lessValue orders known values before unknown ones, ascending or descending
+func lessValue(a, b *float64, ascending bool) bool {
+	if a == nil || b == nil {
+		return a != nil
+	}
+	if ascending {
+		return *a < *b
+	}
+	return *a > *b
+}
+
+// poolRecords is the table and CSV form of the pools, one row per pool
+func poolRecords(response PoolsResponse) output.Records {
+	records := output.Records{Columns: []string{"protocol", "pool_id", "fee_bps", "input_reserve", "output_reserve", "liquidity"}}
+	number := func(v *float64, precision int) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', precision, 64)
+	}
+	for _, p := range response.Pools {
+		records.Add(p.Protocol, p.PoolID, number(p.FeeBps, 2), number(p.InputReserve, 4), number(p.OutputReserve, 4), number(p.Liquidity, 2))
+	}
+	return records
+}
+
+func outputError(msg string) {
+	if format == output.JSON {
+		errResp := PoolsError{Error: msg}
+		jsonData, _ := json.MarshalIndent(errResp, "", "  ")
+		fmt.Fprintln(os.Stderr, string(jsonData))
+	} else {
+		log.Println("Error:", msg)
+	}
+}
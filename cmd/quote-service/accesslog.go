@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// accessLogOptions configures the access log middleware
+type accessLogOptions struct {
+	// Level is the lowest level written: successful requests log at info,
+	// 4xx responses at warn and 5xx responses at error
+	Level slog.Level
+	// SampleRate is the fraction of successful requests logged; warnings and
+	// errors are always logged
+	SampleRate float64
+}
+
+// parseLogLevel accepts debug, info, warn or error
+func parseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// libraryLogger is the logger the library's components write to: text or
+// JSON lines on stderr, keeping stdout for the access log
+func libraryLogger(level slog.Level, jsonLines bool) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if jsonLines {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
+
+// statusRecorder captures the status and size of a response. It passes
+// Flush and Hijack through so streaming and WebSocket handlers keep working.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response does not support hijacking")
+	}
+	// An upgraded connection answers with 101 on the raw connection
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// accessLogMiddleware writes one JSON line per request to stdout with the
+// method, path, query, status, size, latency and client IP
+func accessLogMiddleware(next http.Handler, opts accessLogOptions) http.Handler {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: opts.Level}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		if level == slog.LevelInfo && opts.SampleRate < 1 && rand.Float64() >= opts.SampleRate {
+			return
+		}
+
+		logger.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("query", r.URL.RawQuery),
+			slog.Int("status", status),
+			slog.Int("bytes", recorder.bytes),
+			slog.Float64("latencyMs", float64(time.Since(start).Microseconds())/1000),
+			slog.String("clientIP", clientIP(r)),
+			slog.String("userAgent", r.UserAgent()),
+		)
+	})
+}
+
+// clientIP returns the first X-Forwarded-For address, or the peer address
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
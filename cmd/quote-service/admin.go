@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg"
+)
+
+// FlushQuotes drops cached quotes and their pool mappings, so the next request
+// recalculates them from freshly discovered pools. With both mints set only
+// that pair, in either direction, is flushed. It returns the number of quotes
+// dropped.
+func (qc *QuoteCache) FlushQuotes(inputMint, outputMint string) int {
+	matches := func(in, out string) bool {
+		return inputMint == "" || pairKey(in, out) == pairKey(inputMint, outputMint)
+	}
+
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	flushed := 0
+	for key, quote := range qc.cache {
+		if matches(quote.InputMint, quote.OutputMint) {
+			delete(qc.cache, key)
+			flushed++
+		}
+	}
+	for poolID, pairs := range qc.poolToQuotes {
+		kept := make([]QuotePair, 0, len(pairs))
+		for _, pair := range pairs {
+			if !matches(pair.InputMint, pair.OutputMint) {
+				kept = append(kept, pair)
+			}
+		}
+		if len(kept) == 0 {
+			delete(qc.poolToQuotes, poolID)
+		} else {
+			qc.poolToQuotes[poolID] = kept
+		}
+	}
+	if inputMint == "" {
+		qc.restoredPools = nil
+	} else {
+		delete(qc.restoredPools, pairKey(inputMint, outputMint))
+	}
+	return flushed
+}
+
+// subscribePools subscribes pools that are not yet subscribed and routes
+// their updates to quote recalculation
+func (qc *QuoteCache) subscribePools(pools []pkg.Pool) {
+	if !qc.useWebSocket || qc.subscriptionMgr == nil {
+		return
+	}
+	for _, pool := range pools {
+		poolID := pool.GetID()
+		if qc.subscriptionMgr.IsSubscribed(poolID) {
+			continue
+		}
+		if err := qc.subscriptionMgr.SubscribePool(pool); err != nil {
+			log.Printf("Warning: Failed to subscribe to pool %s: %v", poolID, err)
+			continue
+		}
+		qc.subscriptionMgr.RegisterHandler(poolID, func(updatedPoolID string, data []byte, slot uint64) {
+			qc.handlePoolUpdate(updatedPoolID, slot)
+		})
+	}
+}
+
+// DiscoveryResult summarizes a forced pool discovery
+type DiscoveryResult struct {
+	Pools    int      `json:"pools"`
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Requoted int      `json:"requoted"`
+}
+
+// Rediscover re-runs pool discovery for a pair. New pools are subscribed,
+// subscribed pools no longer found are dropped, and the pair's cached quotes
+// are recalculated.
+func (qc *QuoteCache) Rediscover(ctx context.Context, inputMint, outputMint string) (DiscoveryResult, error) {
+	var result DiscoveryResult
+
+	r := *qc.currentRouter()
+	if err := r.QueryAllPools(ctx, inputMint, outputMint); err != nil {
+		return result, fmt.Errorf("failed to query pools: %w", err)
+	}
+	if len(r.Pools) == 0 {
+		return result, fmt.Errorf("%w for this pair", pkg.ErrNoPoolsFound)
+	}
+	result.Pools = len(r.Pools)
+
+	found := make(map[string]bool, len(r.Pools))
+	for _, pool := range r.Pools {
+		found[pool.GetID()] = true
+	}
+
+	if qc.subscriptionMgr != nil {
+		for _, pool := range qc.subscriptionMgr.GetAllPools() {
+			baseMint, quoteMint := pool.GetTokens()
+			if pairKey(baseMint, quoteMint) != pairKey(inputMint, outputMint) {
+				continue
+			}
+			poolID := pool.GetID()
+			if found[poolID] {
+				delete(found, poolID)
+				continue
+			}
+			if err := qc.subscriptionMgr.UnsubscribePool(poolID); err != nil {
+				log.Printf("Warning: Failed to unsubscribe pool %s: %v", poolID, err)
+			}
+			result.Removed = append(result.Removed, poolID)
+		}
+		for poolID := range found {
+			result.Added = append(result.Added, poolID)
+		}
+		qc.subscribePools(r.Pools)
+	}
+
+	// Recalculate the pair's quotes against the new pool set
+	var pairs []QuotePair
+	qc.mu.RLock()
+	for _, quote := range qc.cache {
+		if pairKey(quote.InputMint, quote.OutputMint) == pairKey(inputMint, outputMint) {
+			pairs = append(pairs, QuotePair{InputMint: quote.InputMint, OutputMint: quote.OutputMint, Amount: quote.InAmount})
+		}
+	}
+	qc.mu.RUnlock()
+
+	qc.FlushQuotes(inputMint, outputMint)
+	for _, pair := range pairs {
+		if err := qc.UpdateQuote(ctx, pair); err != nil {
+			log.Printf("Failed to requote %s -> %s after discovery: %v", pair.InputMint, pair.OutputMint, err)
+			continue
+		}
+		result.Requoted++
+	}
+
+	log.Printf("Rediscovered %d pools for %s/%s (%d added, %d removed), requoted %d",
+		result.Pools, inputMint, outputMint, len(result.Added), len(result.Removed), result.Requoted)
+	return result, nil
+}
+
+// RefreshPool re-reads a pool from RPC. A subscribed pool's accounts are
+// applied like a stream update, recalculating the quotes that use it;
+// otherwise those quotes are recalculated from a fresh discovery. It returns
+// the number of quotes recalculated.
+func (qc *QuoteCache) RefreshPool(ctx context.Context, poolID string) (int, error) {
+	qc.mu.RLock()
+	pairs := append([]QuotePair(nil), qc.poolToQuotes[poolID]...)
+	qc.mu.RUnlock()
+
+	if qc.subscriptionMgr != nil && qc.subscriptionMgr.IsSubscribed(poolID) {
+		accounts, err := qc.subscriptionMgr.RefreshPool(ctx, qc.client(), poolID)
+		if err != nil {
+			return 0, err
+		}
+		log.Printf("Refreshed %d accounts of pool %s over RPC", accounts, poolID)
+		return len(pairs), nil
+	}
+
+	if len(pairs) == 0 {
+		return 0, fmt.Errorf("pool %s is not subscribed and backs no cached quote", poolID)
+	}
+	requoted := 0
+	for _, pair := range pairs {
+		if err := qc.UpdateQuote(ctx, pair); err != nil {
+			return requoted, fmt.Errorf("failed to requote %s -> %s: %w", pair.InputMint, pair.OutputMint, err)
+		}
+		requoted++
+	}
+	return requoted, nil
+}
+
+// handleFlushCache clears the quote cache, or one pair's quotes with ?input=&output=
+func handleFlushCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	inputMint, outputMint, ok := adminPair(w, r, false)
+	if !ok {
+		return
+	}
+
+	flushed := quoteCache.FlushQuotes(inputMint, outputMint)
+	log.Printf("Flushed %d cached quotes", flushed)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"flushed": flushed})
+}
+
+// handleDiscover re-runs pool discovery for ?input=&output=
+func handleDiscover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	inputMint, outputMint, ok := adminPair(w, r, true)
+	if !ok {
+		return
+	}
+
+	result, err := quoteCache.Rediscover(r.Context(), inputMint, outputMint)
+	if err != nil {
+		writeLibraryError(w, err.Error(), err, http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleRefreshPool force-refreshes the pool given by ?id= from RPC
+func handleRefreshPool(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	poolID := r.URL.Query().Get("id")
+	if poolID == "" {
+		writeError(w, "Missing required parameter: id", http.StatusBadRequest)
+		return
+	}
+
+	requoted, err := quoteCache.RefreshPool(r.Context(), poolID)
+	if err != nil {
+		writeLibraryError(w, err.Error(), err, http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"poolId": poolID, "requoted": requoted})
+}
+
+// adminPair reads the input and output mints of an admin request. Unless
+// required, both may be omitted; giving only one is an error.
+func adminPair(w http.ResponseWriter, r *http.Request, required bool) (string, string, bool) {
+	inputMint := r.URL.Query().Get("input")
+	outputMint := r.URL.Query().Get("output")
+	if (inputMint == "") != (outputMint == "") || (required && inputMint == "") {
+		writeError(w, "Parameters input and output must be given together", http.StatusBadRequest)
+		return "", "", false
+	}
+	for _, mint := range []string{inputMint, outputMint} {
+		if _, err := solana.PublicKeyFromBase58(mint); mint != "" && err != nil {
+			writeError(w, fmt.Sprintf("Invalid mint %s", mint), http.StatusBadRequest)
+			return "", "", false
+		}
+	}
+	return inputMint, outputMint, true
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"cosmossdk.io/math"
+)
+
+// Alert conditions
+const (
+	AlertAbove  = "above"  // outAmount rises to or above Threshold
+	AlertBelow  = "below"  // outAmount falls to or below Threshold
+	AlertChange = "change" // outAmount moves ChangePct percent from the last notified quote
+)
+
+const (
+	webhookTimeout  = 5 * time.Second
+	webhookAttempts = 3
+)
+
+// Alert is a registered price condition whose webhook is called when a quote
+// update crosses it. Prices are expressed as the pair's outAmount for Amount.
+type Alert struct {
+	ID         string    `json:"id"`
+	InputMint  string    `json:"inputMint"`
+	OutputMint string    `json:"outputMint"`
+	Amount     string    `json:"amount"`
+	Condition  string    `json:"condition"`
+	Threshold  string    `json:"threshold,omitempty"` // outAmount for above/below
+	ChangePct  float64   `json:"changePct,omitempty"` // percent move for change
+	WebhookURL string    `json:"webhookUrl"`
+	CreatedAt  time.Time `json:"createdAt"`
+
+	threshold math.Int
+	reference math.Int // last observed (above/below) or last notified (change) outAmount
+}
+
+// AlertNotification is the body POSTed to an alert's webhook
+type AlertNotification struct {
+	Alert       *Alert       `json:"alert"`
+	Quote       *CachedQuote `json:"quote"`
+	Previous    string       `json:"previousOutAmount"`
+	TriggeredAt time.Time    `json:"triggeredAt"`
+}
+
+// alertManager evaluates alerts against streamed quote updates
+type alertManager struct {
+	qc      *QuoteCache
+	watcher *quoteWatcher
+	client  *http.Client
+
+	mu     sync.Mutex
+	alerts map[string]*Alert // ID -> alert
+}
+
+func newAlertManager(qc *QuoteCache) *alertManager {
+	return &alertManager{
+		qc:      qc,
+		watcher: qc.hub.subscribe(),
+		client:  newWebhookClient(),
+		alerts:  make(map[string]*Alert),
+	}
+}
+
+// validate checks a new alert and parses its threshold
+func (a *Alert) validate() error {
+	pair := QuotePair{InputMint: a.InputMint, OutputMint: a.OutputMint, Amount: a.Amount}
+	if err := normalizePair(&pair); err != nil {
+		return err
+	}
+	if err := validWebhookURL(a.WebhookURL); err != nil {
+		return err
+	}
+
+	switch a.Condition {
+	case AlertAbove, AlertBelow:
+		threshold, ok := math.NewIntFromString(a.Threshold)
+		if !ok || !threshold.IsPositive() {
+			return fmt.Errorf("invalid threshold %q: expected a positive outAmount", a.Threshold)
+		}
+		a.threshold = threshold
+	case AlertChange:
+		if a.ChangePct <= 0 {
+			return fmt.Errorf("changePct must be positive")
+		}
+	default:
+		return fmt.Errorf("invalid condition %q: expected above, below or change", a.Condition)
+	}
+	return nil
+}
+
+// Add registers an alert and starts following its pair
+func (m *alertManager) Add(ctx context.Context, alert *Alert) error {
+	if err := alert.validate(); err != nil {
+		return err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	alert.ID = id
+	alert.CreatedAt = time.Now()
+
+	pair := QuotePair{InputMint: alert.InputMint, OutputMint: alert.OutputMint, Amount: alert.Amount}
+	quote, err := m.qc.Watch(ctx, m.watcher, pair)
+	if err != nil {
+		return fmt.Errorf("failed to quote pair: %w", err)
+	}
+	if out, ok := math.NewIntFromString(quote.OutAmount); ok {
+		alert.reference = out
+	}
+
+	m.mu.Lock()
+	m.alerts[alert.ID] = alert
+	m.mu.Unlock()
+	return nil
+}
+
+// Remove deletes an alert, reporting whether it existed. The pair stays
+// watched while other alerts use it.
+func (m *alertManager) Remove(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alert, exists := m.alerts[id]
+	if !exists {
+		return false
+	}
+	delete(m.alerts, id)
+
+	for _, other := range m.alerts {
+		if other.InputMint == alert.InputMint && other.OutputMint == alert.OutputMint && other.Amount == alert.Amount {
+			return true
+		}
+	}
+	m.qc.Unwatch(m.watcher, QuotePair{InputMint: alert.InputMint, OutputMint: alert.OutputMint, Amount: alert.Amount})
+	return true
+}
+
+// List returns the registered alerts
+func (m *alertManager) List() []*Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alerts := make([]*Alert, 0, len(m.alerts))
+	for _, alert := range m.alerts {
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// Run evaluates quote updates until ctx is done
+func (m *alertManager) Run(ctx context.Context) {
+	keepAlive := time.NewTicker(time.Minute)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.qc.hub.unsubscribe(m.watcher)
+			return
+		case <-keepAlive.C:
+			// Alerts keep their pools subscribed even when nobody requests quotes
+			m.qc.KeepWatched(m.watcher)
+		case update := <-m.watcher.C:
+			m.evaluate(ctx, update.Quote)
+		}
+	}
+}
+
+// evaluate fires the alerts of a quote's pair whose condition it crosses.
+// Above and below fire once per crossing, not on every update past the threshold.
+func (m *alertManager) evaluate(ctx context.Context, quote *CachedQuote) {
+	out, ok := math.NewIntFromString(quote.OutAmount)
+	if !ok {
+		return
+	}
+
+	var fired []AlertNotification
+	m.mu.Lock()
+	for _, alert := range m.alerts {
+		if alert.InputMint != quote.InputMint || alert.OutputMint != quote.OutputMint || alert.Amount != quote.InAmount {
+			continue
+		}
+		previous := alert.reference
+		trigger := false
+		switch alert.Condition {
+		case AlertAbove:
+			trigger = out.GTE(alert.threshold) && (previous.IsNil() || previous.LT(alert.threshold))
+			alert.reference = out
+		case AlertBelow:
+			trigger = out.LTE(alert.threshold) && (previous.IsNil() || previous.GT(alert.threshold))
+			alert.reference = out
+		case AlertChange:
+			if previous.IsNil() || previous.IsZero() {
+				alert.reference = out
+				continue
+			}
+			moveBps := out.Sub(previous).Abs().Mul(math.NewInt(10000)).Quo(previous)
+			trigger = float64(moveBps.Int64()) >= alert.ChangePct*100
+			if trigger {
+				alert.reference = out
+			}
+		}
+		if trigger {
+			previousOut := ""
+			if !previous.IsNil() {
+				previousOut = previous.String()
+			}
+			copied := *alert
+			fired = append(fired, AlertNotification{
+				Alert:       &copied,
+				Quote:       m.qc.WithSlotAge(quote),
+				Previous:    previousOut,
+				TriggeredAt: time.Now(),
+			})
+		}
+	}
+	m.mu.Unlock()
+
+	for _, notification := range fired {
+		go m.notify(ctx, notification)
+	}
+}
+
+// notify POSTs a notification to the alert's webhook, retrying with backoff
+func (m *alertManager) notify(ctx context.Context, notification AlertNotification) {
+	postWebhook(ctx, m.client, notification.Alert.WebhookURL, "Alert "+notification.Alert.ID, notification)
+}
+
+// postWebhook POSTs payload as JSON to url, retrying failed deliveries with
+// backoff. name identifies the sender in logs.
+func postWebhook(ctx context.Context, client *http.Client, url, name string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("%s: failed to encode webhook payload: %v", name, err)
+		return
+	}
+
+	delay := time.Second
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			log.Printf("%s: invalid webhook request: %v", name, err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				log.Printf("%s delivered, webhook answered %d", name, resp.StatusCode)
+				return
+			}
+			err = fmt.Errorf("status %d", resp.StatusCode)
+		}
+		log.Printf("%s webhook attempt %d failed: %v", name, attempt, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// newID returns a random identifier for alerts and orders
+func newID() (string, error) {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	return hex.EncodeToString(id[:]), nil
+}
+
+// validWebhookURL checks that a webhook URL is an absolute http(s) URL and,
+// unless -webhook-allow-private is set, that it doesn't name a loopback,
+// private or link-local host. Hostnames are checked again when dialing.
+func validWebhookURL(raw string) error {
+	target, err := url.Parse(raw)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("invalid webhookUrl %q", raw)
+	}
+	if *webhookAllowPrivate {
+		return nil
+	}
+	host := strings.ToLower(target.Hostname())
+	if addr, err := netip.ParseAddr(host); (err == nil && !publicAddr(addr)) || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("webhookUrl %q targets a non-public address (see -webhook-allow-private)", raw)
+	}
+	return nil
+}
+
+// publicAddr reports whether addr is a globally routable unicast address
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// newWebhookClient returns the client webhooks are POSTed with. Unless
+// -webhook-allow-private is set it refuses to connect to non-public
+// addresses, checked on the resolved address so a hostname can't point a
+// webhook into the service's own network.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	if !*webhookAllowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !publicAddr(addr) {
+				return fmt.Errorf("webhook target %s is not a public address", host)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect on the service's behalf, past the address check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: webhookTimeout, Transport: transport}
+}
+
+// handleAlerts lists (GET), registers (POST) or deletes (DELETE ?id=) alerts
+func handleAlerts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(alerts.List())
+	case http.MethodPost:
+		var alert Alert
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&alert); err != nil {
+			writeError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := alerts.Add(r.Context(), &alert); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Registered alert %s: %s %s for %s->%s", alert.ID, alert.Condition, alert.Threshold, alert.InputMint[:8], alert.OutputMint[:8])
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(alert)
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if !alerts.Remove(id) {
+			writeError(w, "Alert not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+const (
+	// maxBatchQuotes caps the requests accepted by one POST /quotes
+	maxBatchQuotes = 100
+	// batchWorkers is how many pairs of a batch are quoted concurrently
+	batchWorkers = 8
+)
+
+// handleBatchQuotes quotes an array of requests in one round trip. Requests
+// for the same pair are grouped so its pools are discovered once; distinct
+// pairs are quoted concurrently.
+func handleBatchQuotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requests []QuoteRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&requests); err != nil {
+		writeError(w, "Invalid request body: expected an array of quote requests", http.StatusBadRequest)
+		return
+	}
+	if len(requests) == 0 {
+		writeError(w, "No quote requests", http.StatusBadRequest)
+		return
+	}
+	if len(requests) > maxBatchQuotes {
+		writeError(w, "Too many quote requests (max 100)", http.StatusBadRequest)
+		return
+	}
+
+	results := make([]BatchQuoteResult, len(requests))
+
+	// Group request indexes by pair, preserving order within a group
+	groups := make(map[string][]int)
+	var order []string
+	for i, req := range requests {
+		if req.InputMint == "" || req.OutputMint == "" || req.Amount == "" {
+			results[i].Error = "Missing required fields: inputMint, outputMint, amount"
+			continue
+		}
+		if req.SlippageBps < 0 || req.SlippageBps > 10000 {
+			results[i].Error = "Invalid slippageBps (must be 0-10000)"
+			continue
+		}
+		key := req.InputMint + "-" + req.OutputMint
+		if _, exists := groups[key]; !exists {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	jobs := make(chan []int)
+	var wg sync.WaitGroup
+	for i := 0; i < batchWorkers && i < len(order); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for indexes := range jobs {
+				for _, i := range indexes {
+					req := requests[i]
+					quote, exists := quoteCache.GetQuote(req.InputMint, req.OutputMint, req.Amount)
+					if !exists {
+						var err error
+						quote, err = quoteCache.GetOrCalculateQuote(r.Context(), req.InputMint, req.OutputMint, req.Amount, nil, nil, 0)
+						if err != nil {
+							results[i].Error = err.Error()
+							continue
+						}
+					}
+					if req.SlippageBps != 0 {
+						quote = withSlippage(quote, req.SlippageBps)
+					}
+					results[i].Quote = quoteCache.WithSlotAge(quote)
+				}
+			}
+		}()
+	}
+	for _, key := range order {
+		jobs <- groups[key]
+	}
+	close(jobs)
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BatchQuoteResponse{Quotes: results})
+}
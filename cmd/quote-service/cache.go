@@ -4,12 +4,19 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
+	"golang.org/x/sync/singleflight"
+	"soltrading/pkg"
+	"soltrading/pkg/config"
+	"soltrading/pkg/indexer"
+	"soltrading/pkg/poolapi"
 	"soltrading/pkg/protocol"
 	"soltrading/pkg/router"
 	"soltrading/pkg/sol"
@@ -20,21 +27,49 @@ type QuoteCache struct {
 	cache           map[string]*CachedQuote
 	poolToQuotes    map[string][]QuotePair // Maps poolID to quote pairs that use it
 	mu              sync.RWMutex
+	clientMu        sync.RWMutex // guards solClient, rpcPool and router, which a reload replaces
 	solClient       *sol.Client
 	rpcPool         *sol.RPCPool
 	router          *router.SimpleRouter
+	clientOpts      sol.ClientOptions
+	endpoints       []string // RPC endpoints of solClient and rpcPool
+	rateLimit       int      // requests per second per endpoint
+	enabled         []string // enabled protocol names; empty enables all
 	subscriptionMgr *subscription.SubscriptionManager
 	refreshInterval time.Duration
 	slippageBps     int
 	useWebSocket    bool
+	streamUp        int32                 // 1 while the update stream is connected and subscribed
+	modeChanged     chan struct{}         // signalled when switching between stream and RPC-only mode
+	restoredPools   map[string][]pkg.Pool // pools reloaded from a snapshot, keyed by pairKey
+	prefetchedPools map[string][]pkg.Pool // pools discovered in a batch by Warm, keyed by pairKey
+	hub             *quoteHub             // streams quote updates to API clients
+	monitored       []QuotePair           // pairs refreshed periodically, guarded by monitoredMu
+	monitoredMu     sync.RWMutex
+	quoteTTL        time.Duration      // quotes older than this are recalculated and swept; zero keeps them
+	inflight        singleflight.Group // identical on-demand calculations in progress
+	calcSlots       chan struct{}      // bounds concurrent on-demand calculations; nil is unlimited
+	ladder          []float64          // multiples of each monitored pair's amount also cached
+	history         *quoteHistory      // recent outputs for /twap; nil when disabled
+	publisher       *quotePublisher    // broker receiving every recalculation; nil when disabled
+	health          *healthMonitor
 	ctx             context.Context
 }
 
 type QuotePair struct {
-	InputMint  string
-	OutputMint string
-	Amount     string
-	Label      string
+	InputMint  string `json:"inputMint"`
+	OutputMint string `json:"outputMint"`
+	Amount     string `json:"amount"`
+	Label      string `json:"label,omitempty"`
+
+	// RefreshInterval overrides -refresh for a monitored pair, e.g. "5s"
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+	// SlippageBps overrides -slippage for the pair's cached quotes
+	SlippageBps *int `json:"slippageBps,omitempty"`
+	// Ladder lists extra amounts cached for the pair, overriding -ladder
+	Ladder []string `json:"ladder,omitempty"`
+
+	interval time.Duration // parsed RefreshInterval
 }
 
 // httpToWsURL converts an HTTP(S) RPC URL to a WebSocket URL
@@ -44,64 +79,308 @@ func httpToWsURL(httpURL string) string {
 	return wsURL
 }
 
-func NewQuoteCache(ctx context.Context, endpoints []string, rateLimit int, refreshInterval time.Duration, slippageBps int) (*QuoteCache, error) {
-	var rpcPool *sol.RPCPool
-	var solClient *sol.Client
+// NewQuoteCache creates the cache and its RPC clients. Pool updates stream from
+// geyserEndpoint when set, otherwise from the first RPC endpoint's WebSocket.
+// While the stream is down the cache refreshes over RPC at refreshInterval.
+// streamOpts' Reconnect and Commitment apply to either stream; its dialer and
+// headers are derived from clientOpts and the endpoint configuration.
+func NewQuoteCache(ctx context.Context, endpoints []string, rateLimit int, refreshInterval time.Duration, slippageBps int, clientOpts sol.ClientOptions, geyserEndpoint string, geyserOpts subscription.GeyserOptions, streamOpts subscription.Options) (*QuoteCache, error) {
 	var subscriptionMgr *subscription.SubscriptionManager
-	var err error
 
-	if len(endpoints) > 1 {
-		rpcPool, err = sol.NewRPCPool(ctx, endpoints, "", rateLimit)
+	endpointHeaders, err := config.GetRPCHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	solClient, rpcPool, err := newRPCClients(ctx, endpoints, rateLimit, clientOpts, endpointHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	if geyserEndpoint != "" {
+		// Initialize subscription manager on a Geyser gRPC stream
+		log.Printf("Initializing Geyser gRPC stream to %s", geyserEndpoint)
+		geyserOpts.Reconnect = streamOpts.Reconnect
+		geyserOpts.Commitment = streamOpts.Commitment
+		geyserClient, err := subscription.NewGeyserClient(ctx, geyserEndpoint, geyserOpts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create RPC pool: %w", err)
+			log.Printf("Warning: Failed to connect to Geyser: %v", err)
+			log.Printf("Falling back to RPC-only mode")
+		} else {
+			subscriptionMgr = subscription.NewSubscriptionManagerWithBackend(ctx, geyserClient)
+			log.Printf("Geyser subscription manager initialized successfully")
 		}
-		solClient = rpcPool.GetClient()
-		log.Printf("Initialized RPC pool with %d endpoints", rpcPool.Size())
 	} else {
-		solClient, err = sol.NewClient(ctx, endpoints[0], "", rateLimit)
+		// Initialize WebSocket subscription manager using first endpoint
+		wsURL := httpToWsURL(endpoints[0])
+		log.Printf("Initializing WebSocket connection to %s", wsURL)
+		dialer, err := clientOpts.Transport.NewWebSocketDialer()
 		if err != nil {
-			return nil, fmt.Errorf("failed to create Solana client: %w", err)
+			return nil, fmt.Errorf("failed to build WebSocket dialer: %w", err)
+		}
+		wsHeaders := http.Header{}
+		for k, v := range config.HeadersForEndpoint(endpointHeaders, endpoints[0]) {
+			wsHeaders.Set(k, v)
+		}
+		streamOpts.Dialer = dialer
+		streamOpts.Headers = wsHeaders
+		subscriptionMgr, err = subscription.NewSubscriptionManagerWithOptions(ctx, wsURL, streamOpts)
+		if err != nil {
+			log.Printf("Warning: Failed to create WebSocket subscription manager: %v", err)
+			log.Printf("Falling back to RPC-only mode")
+			subscriptionMgr = nil
+		} else {
+			log.Printf("WebSocket subscription manager initialized successfully")
 		}
 	}
 
-	// Initialize WebSocket subscription manager using first endpoint
-	wsURL := httpToWsURL(endpoints[0])
-	log.Printf("Initializing WebSocket connection to %s", wsURL)
-	subscriptionMgr, err = subscription.NewSubscriptionManager(ctx, wsURL)
-	if err != nil {
-		log.Printf("Warning: Failed to create WebSocket subscription manager: %v", err)
-		log.Printf("Falling back to RPC-only mode")
-		subscriptionMgr = nil
-	} else {
-		log.Printf("WebSocket subscription manager initialized successfully")
-	}
-
-	// Initialize router with all protocols (only DEXs with SOL/USDC pairs)
-	r := router.NewSimpleRouter(
-		protocol.NewPumpAmm(solClient),
-		protocol.NewRaydiumAmm(solClient),
-		protocol.NewRaydiumClmm(solClient),
-		protocol.NewRaydiumCpmm(solClient),
-		protocol.NewMeteoraDlmm(solClient),
-		protocol.NewWhirlpool(solClient),
-	)
+	health := newHealthMonitor(poolClients(solClient, rpcPool))
 
 	qc := &QuoteCache{
 		cache:           make(map[string]*CachedQuote),
 		poolToQuotes:    make(map[string][]QuotePair),
 		solClient:       solClient,
 		rpcPool:         rpcPool,
-		router:          r,
+		router:          router.NewSimpleRouter(),
+		clientOpts:      clientOpts,
+		endpoints:       endpoints,
+		rateLimit:       rateLimit,
 		subscriptionMgr: subscriptionMgr,
 		refreshInterval: refreshInterval,
 		slippageBps:     slippageBps,
 		useWebSocket:    subscriptionMgr != nil,
+		modeChanged:     make(chan struct{}, 1),
+		hub:             newQuoteHub(),
+		health:          health,
 		ctx:             ctx,
 	}
 
+	// Initialize router with all protocols (only DEXs with SOL/USDC pairs).
+	// They call the current client, so a reload of the endpoints reaches them.
+	protocols, err := newProtocols(liveClient{qc}, nil)
+	if err != nil {
+		return nil, err
+	}
+	qc.router.Protocols = health.track(protocols)
+
+	if subscriptionMgr != nil {
+		qc.streamUp = 1
+		subscriptionMgr.OnConnectionStateChange(qc.handleConnectionState)
+		subscriptionMgr.OnPoolEvicted(qc.handlePoolEvicted)
+	}
+
 	return qc, nil
 }
 
+// newRPCClients creates a client for a single endpoint, or a pool and its next
+// client for several
+func newRPCClients(ctx context.Context, endpoints []string, rateLimit int, clientOpts sol.ClientOptions, endpointHeaders map[string]map[string]string) (*sol.Client, *sol.RPCPool, error) {
+	if len(endpoints) > 1 {
+		endpointConfigs := make([]sol.EndpointConfig, len(endpoints))
+		for i, endpoint := range endpoints {
+			endpointConfigs[i] = sol.EndpointConfig{
+				URL:     endpoint,
+				Headers: config.HeadersForEndpoint(endpointHeaders, endpoint),
+			}
+		}
+		rpcPool, err := sol.NewRPCPoolFromEndpoints(ctx, endpointConfigs, "", rateLimit, clientOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create RPC pool: %w", err)
+		}
+		log.Printf("Initialized RPC pool with %d endpoints", rpcPool.Size())
+		return rpcPool.GetClient(), rpcPool, nil
+	}
+
+	singleOpts := clientOpts
+	singleOpts.Headers = config.HeadersForEndpoint(endpointHeaders, endpoints[0])
+	solClient, err := sol.NewClientWithOptions(ctx, endpoints[0], "", rateLimit, singleOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Solana client: %w", err)
+	}
+	return solClient, nil, nil
+}
+
+// poolClients returns every client of rpcPool, or solClient without a pool
+func poolClients(solClient *sol.Client, rpcPool *sol.RPCPool) []*sol.Client {
+	if rpcPool != nil {
+		return rpcPool.GetAllClients()
+	}
+	return []*sol.Client{solClient}
+}
+
+// protocolConstructors are the protocols the service can route through, by name
+var protocolConstructors = []struct {
+	name pkg.ProtocolName
+	new  func(sol.SolClient) pkg.Protocol
+}{
+	{pkg.ProtocolNamePumpAmm, func(c sol.SolClient) pkg.Protocol { return protocol.NewPumpAmm(c) }},
+	{pkg.ProtocolNameRaydiumAmm, func(c sol.SolClient) pkg.Protocol { return protocol.NewRaydiumAmm(c) }},
+	{pkg.ProtocolNameRaydiumClmm, func(c sol.SolClient) pkg.Protocol { return protocol.NewRaydiumClmm(c) }},
+	{pkg.ProtocolNameRaydiumCpmm, func(c sol.SolClient) pkg.Protocol { return protocol.NewRaydiumCpmm(c) }},
+	{pkg.ProtocolNameMeteoraDlmm, func(c sol.SolClient) pkg.Protocol { return protocol.NewMeteoraDlmm(c) }},
+	{pkg.ProtocolNameWhirlpool, func(c sol.SolClient) pkg.Protocol { return protocol.NewWhirlpool(c) }},
+}
+
+// poolIndex serves pool discovery when -index is set
+var poolIndex *indexer.Index
+
+// newProtocols creates the enabled protocols on solClient; empty enables all
+func newProtocols(solClient sol.SolClient, enabled []string) ([]pkg.Protocol, error) {
+	want := make(map[string]bool, len(enabled))
+	for _, name := range enabled {
+		want[name] = true
+	}
+
+	var protocols []pkg.Protocol
+	for _, c := range protocolConstructors {
+		if len(enabled) == 0 || want[string(c.name)] {
+			protocols = append(protocols, c.new(solClient))
+			delete(want, string(c.name))
+		}
+	}
+	for name := range want {
+		return nil, fmt.Errorf("%w: unknown protocol %q", pkg.ErrUnsupportedProtocol, name)
+	}
+	if config.GetPoolAPIFallback() {
+		protocols = poolapi.WithFallback(protocols, poolapi.DefaultBackends()...)
+	}
+	if poolIndex != nil {
+		protocols = poolIndex.Wrap(protocols)
+	}
+	return protocols, nil
+}
+
+// client returns the RPC client quotes are calculated with
+func (qc *QuoteCache) client() *sol.Client {
+	qc.clientMu.RLock()
+	defer qc.clientMu.RUnlock()
+	return qc.solClient
+}
+
+// currentRouter returns the router of the enabled protocols
+func (qc *QuoteCache) currentRouter() *router.SimpleRouter {
+	qc.clientMu.RLock()
+	defer qc.clientMu.RUnlock()
+	return qc.router
+}
+
+// handleConnectionState switches to RPC-only refreshing while the update
+// stream is down or missing subscriptions and back once it has resubscribed
+func (qc *QuoteCache) handleConnectionState(state subscription.ConnectionState, err error) {
+	var up int32
+	switch state {
+	case subscription.StateDisconnected, subscription.StateFailed, subscription.StateDegraded:
+		up = 0
+	case subscription.StateResubscribed:
+		up = 1
+	default:
+		return
+	}
+	if atomic.SwapInt32(&qc.streamUp, up) == up {
+		return
+	}
+
+	if up == 1 {
+		log.Printf("Update stream restored, leaving RPC-only mode")
+	} else {
+		log.Printf("Update stream %s (%v), switching to RPC-only mode", state, err)
+	}
+	select {
+	case qc.modeChanged <- struct{}{}:
+	default:
+	}
+}
+
+// streaming reports whether quotes are currently kept fresh by the update stream
+func (qc *QuoteCache) streaming() bool {
+	return qc.useWebSocket && atomic.LoadInt32(&qc.streamUp) == 1
+}
+
+// StreamState returns the update stream's connection state, or "disabled"
+// when the cache runs without one
+func (qc *QuoteCache) StreamState() string {
+	if qc.subscriptionMgr == nil {
+		return "disabled"
+	}
+	return qc.subscriptionMgr.ConnectionState().String()
+}
+
+// refreshPeriod returns how often to refresh over RPC in the current mode
+func (qc *QuoteCache) refreshPeriod() time.Duration {
+	return qc.periodFor(qc.refreshInterval)
+}
+
+// pairPeriod is refreshPeriod for a pair with its own refresh interval
+func (qc *QuoteCache) pairPeriod(pair QuotePair) time.Duration {
+	if pair.interval > 0 {
+		return qc.periodFor(pair.interval)
+	}
+	return qc.refreshPeriod()
+}
+
+func (qc *QuoteCache) periodFor(interval time.Duration) time.Duration {
+	if qc.streaming() {
+		// When WebSocket is enabled, use much longer interval as fallback
+		return interval * 10 // e.g., 30s * 10 = 5 minutes
+	}
+	return interval
+}
+
+// pairSlippage returns the slippage of a monitored pair, or the default
+func (qc *QuoteCache) pairSlippage(inputMint, outputMint, amount string) int {
+	for _, pair := range qc.withLadders(qc.MonitoredPairs()) {
+		if pair.SlippageBps != nil && pair.InputMint == inputMint && pair.OutputMint == outputMint && pair.Amount == amount {
+			return *pair.SlippageBps
+		}
+	}
+	return qc.slippageBps
+}
+
+// SetStalePoolPenalty makes the router discount pools whose WebSocket state is
+// more than staleSlots old by penaltyBps when ranking
+func (qc *QuoteCache) SetStalePoolPenalty(staleSlots uint64, penaltyBps int64) {
+	if qc.subscriptionMgr == nil {
+		return
+	}
+	qc.router.SetFreshness(qc.subscriptionMgr, staleSlots, penaltyBps)
+}
+
+// SetCoalesceInterval limits quote recalculation to once per interval per pool
+func (qc *QuoteCache) SetCoalesceInterval(interval time.Duration) {
+	if qc.subscriptionMgr == nil {
+		return
+	}
+	qc.subscriptionMgr.SetCoalesceInterval(interval)
+}
+
+// currentSlot returns the chain tip seen over the WebSocket, zero without one
+func (qc *QuoteCache) currentSlot() uint64 {
+	if qc.subscriptionMgr == nil {
+		return 0
+	}
+	return qc.subscriptionMgr.CurrentSlot()
+}
+
+// WithSlotAge returns a copy of quote with Age set and SlotAge set relative
+// to the current slot
+func (qc *QuoteCache) WithSlotAge(quote *CachedQuote) *CachedQuote {
+	withAge := *quote
+	if !quote.LastUpdate.IsZero() {
+		withAge.Age = time.Since(quote.LastUpdate).Round(time.Millisecond).String()
+	}
+
+	current := qc.currentSlot()
+	if quote.ContextSlot == 0 || current == 0 {
+		return &withAge
+	}
+	age := uint64(0)
+	if current > quote.ContextSlot {
+		age = current - quote.ContextSlot
+	}
+	withAge.SlotAge = &age
+	return &withAge
+}
+
 func (qc *QuoteCache) getCacheKey(inputMint, outputMint, amount string) string {
 	return fmt.Sprintf("%s-%s-%s", inputMint, outputMint, amount)
 }
@@ -112,7 +391,73 @@ func (qc *QuoteCache) GetQuote(inputMint, outputMint, amount string) (*CachedQuo
 
 	key := qc.getCacheKey(inputMint, outputMint, amount)
 	quote, exists := qc.cache[key]
-	return quote, exists
+	if !exists || qc.expired(quote) {
+		return nil, false
+	}
+	qc.touchQuote(quote)
+	return quote, true
+}
+
+// touchQuote marks the pools a served quote routes through as read, so idle
+// eviction only drops pools nobody asks about
+func (qc *QuoteCache) touchQuote(quote *CachedQuote) {
+	if qc.subscriptionMgr == nil {
+		return
+	}
+	for _, leg := range quote.RoutePlan {
+		qc.subscriptionMgr.Touch(leg.PoolID)
+	}
+}
+
+// SetIdleTimeout unsubscribes pools whose quotes were not requested for longer
+// than timeout; zero keeps subscriptions forever
+func (qc *QuoteCache) SetIdleTimeout(timeout time.Duration) {
+	if qc.subscriptionMgr == nil {
+		return
+	}
+	qc.subscriptionMgr.SetIdleTimeout(timeout)
+}
+
+// SetMaxSubscriptions caps account subscriptions; pools backing cached quotes
+// are kept and the least recently requested other pools are evicted first
+func (qc *QuoteCache) SetMaxSubscriptions(max int) {
+	if qc.subscriptionMgr == nil {
+		return
+	}
+	qc.subscriptionMgr.SetMaxSubscriptions(max)
+}
+
+// StartStaleRefresh re-reads pools over RPC that received no stream update for
+// maxAge and re-subscribes their dropped accounts. Zero disables it.
+func (qc *QuoteCache) StartStaleRefresh(maxAge time.Duration) {
+	if qc.subscriptionMgr != nil && maxAge > 0 {
+		qc.subscriptionMgr.StartStaleRefresh(liveClient{qc}, maxAge, maxAge/2)
+	}
+}
+
+// SetPoolCacheLimits bounds the subscription manager's pool cache
+func (qc *QuoteCache) SetPoolCacheLimits(opts subscription.PoolCacheOptions) {
+	if qc.subscriptionMgr == nil {
+		return
+	}
+	qc.subscriptionMgr.SetPoolCacheLimits(opts)
+}
+
+// handlePoolEvicted drops quotes that were kept fresh by an evicted pool, so
+// the next request recalculates them and subscribes again
+func (qc *QuoteCache) handlePoolEvicted(poolID string, reason string) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	pairs := qc.poolToQuotes[poolID]
+	for _, pair := range pairs {
+		delete(qc.cache, qc.getCacheKey(pair.InputMint, pair.OutputMint, pair.Amount))
+	}
+	delete(qc.poolToQuotes, poolID)
+
+	if len(pairs) > 0 {
+		log.Printf("Pool %s evicted (%s), dropped %d cached quotes", poolID, reason, len(pairs))
+	}
 }
 
 // GetOrCalculateQuote gets a quote from cache or calculates it on-demand
@@ -123,13 +468,21 @@ func (qc *QuoteCache) GetOrCalculateQuote(ctx context.Context, inputMint, output
 	// Check cache again with lock (only if no filters applied)
 	if len(dexes) == 0 && len(excludeDexes) == 0 && minLiquidityUSD == 0 {
 		qc.mu.RLock()
-		if quote, exists := qc.cache[key]; exists {
+		if quote, exists := qc.cache[key]; exists && !qc.expired(quote) {
 			qc.mu.RUnlock()
+			qc.touchQuote(quote)
 			return quote, nil
 		}
 		qc.mu.RUnlock()
 	}
 
+	return qc.calculateShared(ctx, inputMint, outputMint, amount, dexes, excludeDexes, minLiquidityUSD)
+}
+
+// calculateQuote discovers the pair's pools if needed, quotes it and caches the result
+func (qc *QuoteCache) calculateQuote(ctx context.Context, inputMint, outputMint, amount string, dexes, excludeDexes []string, minLiquidityUSD float64) (*CachedQuote, error) {
+	key := qc.getCacheKey(inputMint, outputMint, amount)
+
 	// Parse inputs
 	inTokenAddr, err := solana.PublicKeyFromBase58(inputMint)
 	if err != nil {
@@ -146,6 +499,9 @@ func (qc *QuoteCache) GetOrCalculateQuote(ctx context.Context, inputMint, output
 		return nil, fmt.Errorf("invalid amount")
 	}
 
+	// A reload may replace the client and router; use one set throughout
+	solClient, r := qc.client(), qc.currentRouter()
+
 	startTime := time.Now()
 	log.Printf("💡 Calculating on-demand quote: %s -> %s, amount: %s", inputMint[:8], outputMint[:8], amount)
 
@@ -165,22 +521,26 @@ func (qc *QuoteCache) GetOrCalculateQuote(ctx context.Context, inputMint, output
 	}
 	qc.mu.RUnlock()
 
-	// If no pools for this pair, query them
-	if !hasPool {
-		err = qc.router.QueryAllPools(ctx, inTokenAddr.String(), outTokenAddr.String())
-		if err != nil {
+	// Pools restored from a snapshot stand in for the pair's first discovery
+	if pools := qc.takeRestoredPools(inputMint, outputMint); len(pools) > 0 {
+		r.Pools = pools
+	} else if !hasPool {
+		// If no pools for this pair, query them unless Warm already did
+		if pools := qc.takePrefetchedPools(inputMint, outputMint); len(pools) > 0 {
+			r.Pools = pools
+		} else if err = r.QueryAllPools(ctx, inTokenAddr.String(), outTokenAddr.String()); err != nil {
 			return nil, fmt.Errorf("failed to query pools: %w", err)
 		}
 
-		if len(qc.router.Pools) == 0 {
-			return nil, fmt.Errorf("no pools found for this pair")
+		if len(r.Pools) == 0 {
+			return nil, fmt.Errorf("%w for this pair", pkg.ErrNoPoolsFound)
 		}
 
 		// Subscribe to pools via WebSocket if enabled
 		if qc.useWebSocket && qc.subscriptionMgr != nil {
-			for _, pool := range qc.router.Pools {
+			for _, pool := range r.Pools {
 				poolID := pool.GetID()
-				if _, exists := qc.subscriptionMgr.GetPool(poolID); !exists {
+				if !qc.subscriptionMgr.IsSubscribed(poolID) {
 					if err := qc.subscriptionMgr.SubscribePool(pool); err != nil {
 						log.Printf("Warning: Failed to subscribe to pool %s: %v", poolID, err)
 					} else {
@@ -191,18 +551,19 @@ func (qc *QuoteCache) GetOrCalculateQuote(ctx context.Context, inputMint, output
 					}
 				}
 			}
-			log.Printf("Subscribed to %d pools via WebSocket", len(qc.router.Pools))
+			log.Printf("Subscribed to %d pools via WebSocket", len(r.Pools))
 		}
 	}
 
 	// Get best pool with optional filtering
-	bestPool, amountOut, err := qc.router.GetBestPoolWithFilter(ctx, qc.solClient, inTokenAddr.String(), amountIn, dexes, excludeDexes, minLiquidityUSD)
+	bestPool, amountOut, err := r.GetBestPoolWithFilter(ctx, solClient, inTokenAddr.String(), amountIn, dexes, excludeDexes, minLiquidityUSD)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get best pool: %w", err)
 	}
 
 	// Calculate minimum amount out with slippage
-	minAmountOut := amountOut.Mul(math.NewInt(int64(10000 - qc.slippageBps))).Quo(math.NewInt(10000))
+	slippageBps := qc.pairSlippage(inputMint, outputMint, amount)
+	minAmountOut := amountOut.Mul(math.NewInt(int64(10000 - slippageBps))).Quo(math.NewInt(10000))
 
 	// Get protocol name directly from the pool
 	protocolName := string(bestPool.ProtocolName())
@@ -226,10 +587,11 @@ func (qc *QuoteCache) GetOrCalculateQuote(ctx context.Context, inputMint, output
 		OutputMint:           outTokenAddr.String(),
 		InAmount:             amountIn.String(),
 		OutAmount:            amountOut.String(),
-		SlippageBps:          qc.slippageBps,
+		SlippageBps:          slippageBps,
 		OtherAmountThreshold: minAmountOut.String(),
 		LastUpdate:           time.Now(),
 		TimeTaken:            time.Since(startTime).String(),
+		ContextSlot:          qc.currentSlot(),
 		RoutePlan: []RoutePlan{
 			{
 				Protocol:     protocolName,
@@ -239,6 +601,7 @@ func (qc *QuoteCache) GetOrCalculateQuote(ctx context.Context, inputMint, output
 				OutputMint:   outTokenAddr.String(),
 				InAmount:     amountIn.String(),
 				OutAmount:    amountOut.String(),
+				Fee:          feeAmount(bestPool, amountIn),
 				ProgramID:    bestPool.GetProgramID().String(),
 				TokenASymbol: tokenASymbol,
 				TokenBSymbol: tokenBSymbol,
@@ -271,6 +634,14 @@ func (qc *QuoteCache) GetOrCalculateQuote(ctx context.Context, inputMint, output
 		qc.poolToQuotes[bestPoolID] = append(qc.poolToQuotes[bestPoolID], pair)
 	}
 	qc.mu.Unlock()
+	qc.publishQuote(key, quote)
+
+	// Pools backing cached quotes outrank the pair's other pools under the subscription cap
+	if qc.subscriptionMgr != nil {
+		qc.subscriptionMgr.SetPoolPriority(bestPoolID, 1)
+	}
+
+	qc.touchQuote(quote)
 
 	log.Printf("✓ Calculated on-demand quote: %s -> %s (took %s)",
 		amountIn.String(),
@@ -300,22 +671,25 @@ func (qc *QuoteCache) UpdateQuote(ctx context.Context, pair QuotePair) error {
 		return fmt.Errorf("invalid amount")
 	}
 
+	// A reload may replace the client and router; use one set throughout
+	solClient, r := qc.client(), qc.currentRouter()
+
 	// Query pools
-	err = qc.router.QueryAllPools(ctx, inTokenAddr.String(), outTokenAddr.String())
+	err = qc.queryPools(ctx, r, inTokenAddr.String(), outTokenAddr.String())
 	if err != nil {
 		return fmt.Errorf("failed to query pools: %w", err)
 	}
 
-	if len(qc.router.Pools) == 0 {
-		return fmt.Errorf("no pools found")
+	if len(r.Pools) == 0 {
+		return pkg.ErrNoPoolsFound
 	}
 
 	// Subscribe to pools via WebSocket if enabled
 	if qc.useWebSocket && qc.subscriptionMgr != nil {
-		for _, pool := range qc.router.Pools {
+		for _, pool := range r.Pools {
 			poolID := pool.GetID()
 			// Check if already subscribed
-			if _, exists := qc.subscriptionMgr.GetPool(poolID); !exists {
+			if !qc.subscriptionMgr.IsSubscribed(poolID) {
 				if err := qc.subscriptionMgr.SubscribePool(pool); err != nil {
 					log.Printf("Warning: Failed to subscribe to pool %s: %v", poolID, err)
 				} else {
@@ -326,17 +700,18 @@ func (qc *QuoteCache) UpdateQuote(ctx context.Context, pair QuotePair) error {
 				}
 			}
 		}
-		log.Printf("Subscribed to %d pools via WebSocket", len(qc.router.Pools))
+		log.Printf("Subscribed to %d pools via WebSocket", len(r.Pools))
 	}
 
 	// Get best pool
-	bestPool, amountOut, err := qc.router.GetBestPool(ctx, qc.solClient, inTokenAddr.String(), amountIn)
+	bestPool, amountOut, err := r.GetBestPool(ctx, solClient, inTokenAddr.String(), amountIn)
 	if err != nil {
 		return fmt.Errorf("failed to get best pool: %w", err)
 	}
 
 	// Calculate minimum amount out with slippage
-	minAmountOut := amountOut.Mul(math.NewInt(int64(10000 - qc.slippageBps))).Quo(math.NewInt(10000))
+	slippageBps := qc.pairSlippage(pair.InputMint, pair.OutputMint, pair.Amount)
+	minAmountOut := amountOut.Mul(math.NewInt(int64(10000 - slippageBps))).Quo(math.NewInt(10000))
 
 	// Get protocol name directly from the pool
 	protocolName := string(bestPool.ProtocolName())
@@ -360,10 +735,11 @@ func (qc *QuoteCache) UpdateQuote(ctx context.Context, pair QuotePair) error {
 		OutputMint:           outTokenAddr.String(),
 		InAmount:             amountIn.String(),
 		OutAmount:            amountOut.String(),
-		SlippageBps:          qc.slippageBps,
+		SlippageBps:          slippageBps,
 		OtherAmountThreshold: minAmountOut.String(),
 		LastUpdate:           time.Now(),
 		TimeTaken:            time.Since(startTime).String(),
+		ContextSlot:          qc.currentSlot(),
 		RoutePlan: []RoutePlan{
 			{
 				Protocol:     protocolName,
@@ -373,6 +749,7 @@ func (qc *QuoteCache) UpdateQuote(ctx context.Context, pair QuotePair) error {
 				OutputMint:   outTokenAddr.String(),
 				InAmount:     amountIn.String(),
 				OutAmount:    amountOut.String(),
+				Fee:          feeAmount(bestPool, amountIn),
 				ProgramID:    bestPool.GetProgramID().String(),
 				TokenASymbol: tokenASymbol,
 				TokenBSymbol: tokenBSymbol,
@@ -400,6 +777,15 @@ func (qc *QuoteCache) UpdateQuote(ctx context.Context, pair QuotePair) error {
 		qc.poolToQuotes[bestPoolID] = append(qc.poolToQuotes[bestPoolID], pair)
 	}
 	qc.mu.Unlock()
+	qc.publishQuote(key, quote)
+
+	// Pools backing cached quotes outrank the pair's other pools under the subscription cap
+	if qc.subscriptionMgr != nil {
+		qc.subscriptionMgr.SetPoolPriority(bestPoolID, 1)
+	}
+
+	// Monitored pairs stay subscribed as long as they are refreshed
+	qc.touchQuote(quote)
 
 	log.Printf("✓ Updated %s: %s %s -> %s %s (took %s)",
 		pair.Label,
@@ -426,14 +812,14 @@ func (qc *QuoteCache) handlePoolUpdate(poolID string, slot uint64) {
 
 	// Recalculate all quotes that use this pool
 	for _, pair := range quotePairs {
-		if err := qc.recalculateQuote(qc.ctx, pair, poolID); err != nil {
+		if err := qc.recalculateQuote(qc.ctx, pair, poolID, slot); err != nil {
 			log.Printf("Error recalculating quote for %s: %v", pair.Label, err)
 		}
 	}
 }
 
 // recalculateQuote recalculates a single quote using the updated pool data from cache
-func (qc *QuoteCache) recalculateQuote(ctx context.Context, pair QuotePair, poolID string) error {
+func (qc *QuoteCache) recalculateQuote(ctx context.Context, pair QuotePair, poolID string, slot uint64) error {
 	startTime := time.Now()
 
 	inTokenAddr, err := solana.PublicKeyFromBase58(pair.InputMint)
@@ -464,13 +850,14 @@ func (qc *QuoteCache) recalculateQuote(ctx context.Context, pair QuotePair, pool
 	qc.mu.RUnlock()
 
 	// Quote using the cached pool data (no RPC call needed!)
-	amountOut, err := pool.Quote(ctx, qc.solClient, inTokenAddr.String(), amountIn)
+	amountOut, err := pool.Quote(ctx, qc.client(), inTokenAddr.String(), amountIn)
 	if err != nil {
 		return fmt.Errorf("failed to quote: %w", err)
 	}
 
 	// Calculate minimum amount out with slippage
-	minAmountOut := amountOut.Mul(math.NewInt(int64(10000 - qc.slippageBps))).Quo(math.NewInt(10000))
+	slippageBps := qc.pairSlippage(pair.InputMint, pair.OutputMint, pair.Amount)
+	minAmountOut := amountOut.Mul(math.NewInt(int64(10000 - slippageBps))).Quo(math.NewInt(10000))
 
 	// Get protocol name directly from the pool
 	protocolName := string(pool.ProtocolName())
@@ -494,10 +881,11 @@ func (qc *QuoteCache) recalculateQuote(ctx context.Context, pair QuotePair, pool
 		OutputMint:           outTokenAddr.String(),
 		InAmount:             amountIn.String(),
 		OutAmount:            amountOut.String(),
-		SlippageBps:          qc.slippageBps,
+		SlippageBps:          slippageBps,
 		OtherAmountThreshold: minAmountOut.String(),
 		LastUpdate:           time.Now(),
 		TimeTaken:            time.Since(startTime).String(),
+		ContextSlot:          slot,
 		RoutePlan: []RoutePlan{
 			{
 				Protocol:     protocolName,
@@ -507,6 +895,7 @@ func (qc *QuoteCache) recalculateQuote(ctx context.Context, pair QuotePair, pool
 				OutputMint:   outTokenAddr.String(),
 				InAmount:     amountIn.String(),
 				OutAmount:    amountOut.String(),
+				Fee:          feeAmount(pool, amountIn),
 				ProgramID:    pool.GetProgramID().String(),
 				TokenASymbol: tokenASymbol,
 				TokenBSymbol: tokenBSymbol,
@@ -518,6 +907,7 @@ func (qc *QuoteCache) recalculateQuote(ctx context.Context, pair QuotePair, pool
 	qc.mu.Lock()
 	qc.cache[key] = quote
 	qc.mu.Unlock()
+	qc.publishQuote(key, quote)
 
 	// Log with price change comparison
 	if hadOldQuote {
@@ -570,6 +960,13 @@ func (qc *QuoteCache) recalculateQuote(ctx context.Context, pair QuotePair, pool
 	return nil
 }
 
+// feeAmount is the input-token fee the pool takes from amountIn at its fee rate
+func feeAmount(pool pkg.Pool, amountIn math.Int) string {
+	// Rounded to hundredths of a basis point, the precision of CLMM fee rates
+	rate := int64(pool.GetFeeRate()*100 + 0.5)
+	return amountIn.MulRaw(rate).QuoRaw(1_000_000).String()
+}
+
 func (qc *QuoteCache) RefreshAll(ctx context.Context, pairs []QuotePair) {
 	for _, pair := range pairs {
 		if err := qc.UpdateQuote(ctx, pair); err != nil {
@@ -578,25 +975,34 @@ func (qc *QuoteCache) RefreshAll(ctx context.Context, pairs []QuotePair) {
 	}
 }
 
-func (qc *QuoteCache) StartPeriodicRefresh(ctx context.Context, pairs []QuotePair) {
+// StartPeriodicRefresh keeps the monitored pairs' quotes fresh until ctx is
+// done; pairs added or removed meanwhile are picked up on the next refresh.
+// Each pair is refreshed at its own interval, see pairPeriod.
+func (qc *QuoteCache) StartPeriodicRefresh(ctx context.Context) {
+	lastRefresh := make(map[string]time.Time)
+	refreshAll := func() {
+		pairs := qc.MonitoredPairs()
+		now := time.Now()
+		for _, pair := range pairs {
+			lastRefresh[qc.getCacheKey(pair.InputMint, pair.OutputMint, pair.Amount)] = now
+		}
+		qc.RefreshAll(ctx, qc.withLadders(pairs))
+	}
+
 	// Initial refresh (always needed to populate cache and subscribe to pools)
 	log.Printf("Starting initial quote refresh...")
-	qc.RefreshAll(ctx, pairs)
+	refreshAll()
 	log.Printf("Initial refresh complete")
 
 	// Set up periodic refresh as fallback
-	var fallbackInterval time.Duration
-	if qc.useWebSocket {
-		// When WebSocket is enabled, use much longer interval as fallback
-		fallbackInterval = qc.refreshInterval * 10 // e.g., 30s * 10 = 5 minutes
+	fallbackInterval := qc.refreshPeriod()
+	if qc.streaming() {
 		log.Printf("WebSocket enabled: Using %v fallback refresh interval", fallbackInterval)
 	} else {
-		// When WebSocket is disabled, use the configured interval
-		fallbackInterval = qc.refreshInterval
 		log.Printf("WebSocket disabled: Using %v refresh interval", fallbackInterval)
 	}
 
-	ticker := time.NewTicker(fallbackInterval)
+	ticker := time.NewTicker(refreshTick)
 	defer ticker.Stop()
 
 	for {
@@ -604,18 +1010,29 @@ func (qc *QuoteCache) StartPeriodicRefresh(ctx context.Context, pairs []QuotePai
 		case <-ctx.Done():
 			log.Printf("Stopping periodic refresh")
 			return
-		case <-ticker.C:
-			if qc.useWebSocket {
-				log.Printf("Running fallback refresh (WebSocket primary)...")
-			} else {
-				log.Printf("Starting periodic refresh...")
+		case <-qc.modeChanged:
+			// Resync right away: either updates were missed while the stream
+			// was down or it just went down and quotes would go stale
+			log.Printf("Refresh interval is now %v", qc.refreshPeriod())
+			refreshAll()
+		case now := <-ticker.C:
+			var due []QuotePair
+			for _, pair := range qc.MonitoredPairs() {
+				key := qc.getCacheKey(pair.InputMint, pair.OutputMint, pair.Amount)
+				if now.Sub(lastRefresh[key]) >= qc.pairPeriod(pair) {
+					lastRefresh[key] = now
+					due = append(due, pair)
+				}
+			}
+			if len(due) == 0 {
+				continue
 			}
-			qc.RefreshAll(ctx, pairs)
-			if qc.useWebSocket {
-				log.Printf("Fallback refresh complete")
+			if qc.streaming() {
+				log.Printf("Running fallback refresh of %d pairs (WebSocket primary)...", len(due))
 			} else {
-				log.Printf("Periodic refresh complete")
+				log.Printf("Starting periodic refresh of %d pairs...", len(due))
 			}
+			qc.RefreshAll(ctx, qc.withLadders(due))
 		}
 	}
 }
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressionOptions configures the compression middleware
+type compressionOptions struct {
+	// MinSize is the smallest response body compressed, in bytes
+	MinSize int
+	// Types are the content-type prefixes compressed, e.g. "application/json"
+	// or "text/"
+	Types []string
+}
+
+// acceptedEncoding picks gzip or deflate from an Accept-Encoding header,
+// preferring gzip, or returns "" when neither is accepted
+func acceptedEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q > 0
+	}
+	switch {
+	case accepted["gzip"]:
+		return "gzip"
+	case accepted["deflate"]:
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter buffers the start of a response until it knows whether the
+// body reaches MinSize, then writes it compressed or as is. Flush commits to
+// the current decision so streamed responses are not held back, and Hijack
+// passes through for WebSocket upgrades.
+type compressWriter struct {
+	http.ResponseWriter
+	opts     compressionOptions
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser // nil when writing uncompressed
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		return w.write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.opts.MinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *compressWriter) write(b []byte) (int, error) {
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// compressible reports whether the response's status and headers allow compression
+func (w *compressWriter) compressible() bool {
+	header := w.Header()
+	if w.status < 200 || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buf)
+		header.Set("Content-Type", contentType)
+	}
+	for _, prefix := range w.opts.Types {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// decide writes the headers and buffered body, compressing when large is
+// set and the response qualifies
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if large && w.compressible() {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+
+		if w.encoding == "gzip" {
+			w.encoder = gzip.NewWriter(w.ResponseWriter)
+		} else {
+			encoder, err := flate.NewWriter(w.ResponseWriter, flate.DefaultCompression)
+			if err != nil {
+				return fmt.Errorf("failed to create deflate writer: %w", err)
+			}
+			w.encoder = encoder
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	buffered := w.buf
+	w.buf = nil
+	if len(buffered) == 0 {
+		return nil
+	}
+	_, err := w.write(buffered)
+	return err
+}
+
+// close writes a response smaller than MinSize as is and finishes a compressed one
+func (w *compressWriter) close() error {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			// Nothing written; let the server send its default response
+			return nil
+		}
+		return w.decide(false)
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(len(w.buf) >= w.opts.MinSize)
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response does not support hijacking")
+	}
+	w.decided = true
+	return hijacker.Hijack()
+}
+
+// compressionMiddleware compresses responses with gzip or deflate when the
+// client accepts it, the content type matches opts.Types and the body is at
+// least opts.MinSize bytes. WebSocket upgrades are passed through untouched.
+func compressionMiddleware(next http.Handler, opts compressionOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: w, opts: opts, encoding: encoding}
+		defer writer.close()
+		next.ServeHTTP(writer, r)
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+// RuntimeStats is the /debug/runtime view of the Go runtime
+type RuntimeStats struct {
+	GoVersion    string  `json:"goVersion"`
+	Uptime       string  `json:"uptime"`
+	Goroutines   int     `json:"goroutines"`
+	CPUs         int     `json:"cpus"`
+	GOMAXPROCS   int     `json:"gomaxprocs"`
+	HeapAlloc    uint64  `json:"heapAllocBytes"`
+	HeapInuse    uint64  `json:"heapInuseBytes"`
+	HeapIdle     uint64  `json:"heapIdleBytes"`
+	HeapReleased uint64  `json:"heapReleasedBytes"`
+	HeapObjects  uint64  `json:"heapObjects"`
+	StackInuse   uint64  `json:"stackInuseBytes"`
+	Sys          uint64  `json:"sysBytes"`
+	TotalAlloc   uint64  `json:"totalAllocBytes"`
+	Mallocs      uint64  `json:"mallocs"`
+	Frees        uint64  `json:"frees"`
+	NumGC        uint32  `json:"numGC"`
+	NextGC       uint64  `json:"nextGCBytes"`
+	LastGC       string  `json:"lastGC,omitempty"`
+	PauseTotal   string  `json:"gcPauseTotal"`
+	LastPause    string  `json:"gcLastPause,omitempty"`
+	GCCPU        float64 `json:"gcCPUFraction"`
+	GOGC         uint64  `json:"gogc"`
+	MemoryLimit  uint64  `json:"memoryLimitBytes"`
+}
+
+// registerDebug adds the pprof handlers and /debug/runtime behind requireAdmin
+func registerDebug(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", requireAdmin(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", requireAdmin(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", requireAdmin(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", requireAdmin(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", requireAdmin(pprof.Trace))
+	mux.HandleFunc("/debug/runtime", requireAdmin(handleRuntime))
+}
+
+func handleRuntime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	settings := []metrics.Sample{{Name: "/gc/gogc:percent"}, {Name: "/gc/gomemlimit:bytes"}}
+	metrics.Read(settings)
+
+	stats := RuntimeStats{
+		GoVersion:    runtime.Version(),
+		Uptime:       time.Since(startTime).Round(time.Second).String(),
+		Goroutines:   runtime.NumGoroutine(),
+		CPUs:         runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapInuse:    mem.HeapInuse,
+		HeapIdle:     mem.HeapIdle,
+		HeapReleased: mem.HeapReleased,
+		HeapObjects:  mem.HeapObjects,
+		StackInuse:   mem.StackInuse,
+		Sys:          mem.Sys,
+		TotalAlloc:   mem.TotalAlloc,
+		Mallocs:      mem.Mallocs,
+		Frees:        mem.Frees,
+		NumGC:        mem.NumGC,
+		NextGC:       mem.NextGC,
+		PauseTotal:   time.Duration(mem.PauseTotalNs).String(),
+		GCCPU:        mem.GCCPUFraction,
+		GOGC:         settings[0].Value.Uint64(),
+		MemoryLimit:  settings[1].Value.Uint64(),
+	}
+	if mem.NumGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC)).UTC().Format(time.RFC3339)
+		stats.LastPause = time.Duration(mem.PauseNs[(mem.NumGC+255)%256]).String()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg"
+)
+
+// maxDepthLevels caps the sizes of one /depth request
+const maxDepthLevels = 20
+
+// depthMultiples are the sizes quoted by /depth, relative to its amount parameter
+var depthMultiples = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DepthLevel is the output for one input size
+type DepthLevel struct {
+	InAmount  string  `json:"inAmount"`
+	OutAmount string  `json:"outAmount"`
+	Price     float64 `json:"price"`     // outAmount per inAmount, in raw units
+	ImpactBps float64 `json:"impactBps"` // price shortfall relative to the smallest size
+	Protocol  string  `json:"protocol,omitempty"`
+	PoolID    string  `json:"poolId"`
+}
+
+// DepthResponse is an order-book style view of a pair's AMM liquidity
+type DepthResponse struct {
+	InputMint   string                  `json:"inputMint"`
+	OutputMint  string                  `json:"outputMint"`
+	ContextSlot uint64                  `json:"contextSlot,omitempty"`
+	Best        []DepthLevel            `json:"best"`      // best pool per size across protocols
+	Protocols   map[string][]DepthLevel `json:"protocols"` // best pool per size within each protocol
+}
+
+// Depth quotes every known pool of a pair at each input size, from cached pool
+// state when streaming, and reports the best output per size overall and per
+// protocol. Sizes no pool can fill are left out.
+func (qc *QuoteCache) Depth(ctx context.Context, inputMint, outputMint string, amounts []math.Int) (*DepthResponse, error) {
+	pools, err := qc.pairPools(ctx, inputMint, outputMint)
+	if err != nil {
+		return nil, err
+	}
+	if len(pools) == 0 {
+		return nil, fmt.Errorf("%w for this pair", pkg.ErrNoPoolsFound)
+	}
+
+	depth := &DepthResponse{
+		InputMint:   inputMint,
+		OutputMint:  outputMint,
+		ContextSlot: qc.currentSlot(),
+		Protocols:   make(map[string][]DepthLevel),
+	}
+
+	type quoted struct {
+		pool pkg.Pool
+		out  math.Int
+	}
+	for _, amount := range amounts {
+		var best *quoted
+		byProtocol := make(map[string]*quoted)
+		for _, pool := range pools {
+			out, err := pool.Quote(ctx, qc.client(), inputMint, amount)
+			if err != nil || !out.IsPositive() {
+				continue
+			}
+			protocol := string(pool.ProtocolName())
+			if current := byProtocol[protocol]; current == nil || out.GT(current.out) {
+				byProtocol[protocol] = &quoted{pool, out}
+			}
+			if best == nil || out.GT(best.out) {
+				best = &quoted{pool, out}
+			}
+		}
+		if best == nil {
+			continue
+		}
+
+		depth.Best = append(depth.Best, depthLevel(amount, best.out, best.pool, true))
+		for protocol, q := range byProtocol {
+			depth.Protocols[protocol] = append(depth.Protocols[protocol], depthLevel(amount, q.out, q.pool, false))
+		}
+	}
+
+	setImpact(depth.Best)
+	for _, levels := range depth.Protocols {
+		setImpact(levels)
+	}
+	return depth, nil
+}
+
+func depthLevel(in, out math.Int, pool pkg.Pool, withProtocol bool) DepthLevel {
+	price, _ := out.ToLegacyDec().Quo(in.ToLegacyDec()).Float64()
+	level := DepthLevel{
+		InAmount:  in.String(),
+		OutAmount: out.String(),
+		Price:     price,
+		PoolID:    pool.GetID(),
+	}
+	if withProtocol {
+		level.Protocol = string(pool.ProtocolName())
+	}
+	return level
+}
+
+// setImpact fills in each level's price shortfall against the first level
+func setImpact(levels []DepthLevel) {
+	if len(levels) == 0 || levels[0].Price == 0 {
+		return
+	}
+	reference := levels[0].Price
+	for i := range levels {
+		levels[i].ImpactBps = (1 - levels[i].Price/reference) * 10000
+	}
+}
+
+// parseDepthAmounts reads the sizes of a /depth request: an explicit amounts
+// list, or the depthMultiples of amount
+func parseDepthAmounts(amountsParam, amountParam string) ([]math.Int, error) {
+	var amounts []math.Int
+	if amountsParam != "" {
+		for _, item := range splitList(amountsParam) {
+			amount, ok := math.NewIntFromString(item)
+			if !ok || !amount.IsPositive() {
+				return nil, fmt.Errorf("invalid amount %q", item)
+			}
+			amounts = append(amounts, amount)
+		}
+	} else {
+		base, ok := math.NewIntFromString(amountParam)
+		if !ok || !base.IsPositive() {
+			return nil, fmt.Errorf("missing or invalid amount")
+		}
+		for _, multiple := range depthMultiples {
+			amount := math.LegacyMustNewDecFromStr(strconv.FormatFloat(multiple, 'f', -1, 64)).MulInt(base).TruncateInt()
+			if amount.IsPositive() {
+				amounts = append(amounts, amount)
+			}
+		}
+	}
+	if len(amounts) > maxDepthLevels {
+		return nil, fmt.Errorf("at most %d amounts are allowed", maxDepthLevels)
+	}
+
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i].LT(amounts[j]) })
+	return amounts, nil
+}
+
+// handleDepth serves GET /depth?input=&output=&amount= (or &amounts=a,b,c)
+func handleDepth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	inputMint := query.Get("input")
+	outputMint := query.Get("output")
+	if _, err := solana.PublicKeyFromBase58(inputMint); err != nil {
+		writeError(w, "Missing or invalid input mint", http.StatusBadRequest)
+		return
+	}
+	if _, err := solana.PublicKeyFromBase58(outputMint); err != nil {
+		writeError(w, "Missing or invalid output mint", http.StatusBadRequest)
+		return
+	}
+	amounts, err := parseDepthAmounts(query.Get("amounts"), query.Get("amount"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	depth, err := quoteCache.Depth(r.Context(), inputMint, outputMint, amounts)
+	if err != nil {
+		writeLibraryError(w, fmt.Sprintf("Failed to calculate depth: %v", err), err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(depth)
+}
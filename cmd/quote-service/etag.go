@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// quoteETag identifies the version of a quote: it changes when the quote is
+// recalculated, not as its age grows. It is weak because the age fields in
+// the body still differ between responses.
+func quoteETag(quote *CachedQuote) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d|%d|%s|%s|%d", quote.ContextSlot, quote.LastUpdate.UnixNano(), quote.OutAmount, quote.OtherAmountThreshold, quote.SlippageBps)
+	for _, route := range quote.Routes {
+		fmt.Fprintf(h, "|%s:%s", route.PoolID, route.OutAmount)
+	}
+	return fmt.Sprintf(`W/"%d-%x"`, quote.ContextSlot, h.Sum64())
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// SetQuoteTTL makes quotes older than ttl count as missing, so requests
+// recalculate them instead of serving a price from a pool that stopped
+// updating, and sweeps expired quotes of unmonitored pairs. Zero disables it.
+func (qc *QuoteCache) SetQuoteTTL(ttl time.Duration) {
+	qc.mu.Lock()
+	start := qc.quoteTTL == 0 && ttl > 0
+	qc.quoteTTL = ttl
+	qc.mu.Unlock()
+
+	if start {
+		go qc.sweepExpiredLoop()
+	}
+}
+
+// expired reports whether a quote is older than the TTL. Callers hold qc.mu.
+func (qc *QuoteCache) expired(quote *CachedQuote) bool {
+	return qc.quoteTTL > 0 && time.Since(quote.LastUpdate) > qc.quoteTTL
+}
+
+// sweepExpiredLoop sweeps expired quotes at half the TTL until the cache is done
+func (qc *QuoteCache) sweepExpiredLoop() {
+	for {
+		qc.mu.RLock()
+		ttl := qc.quoteTTL
+		qc.mu.RUnlock()
+		if ttl <= 0 {
+			return
+		}
+
+		select {
+		case <-qc.ctx.Done():
+			return
+		case <-time.After(ttl / 2):
+			qc.sweepExpiredQuotes()
+		}
+	}
+}
+
+// sweepExpiredQuotes drops expired quotes of pairs that are not monitored
+// (including their ladder sizes),
+// along with their pool mappings so pool updates stop recalculating them.
+// Monitored pairs are refreshed instead.
+func (qc *QuoteCache) sweepExpiredQuotes() {
+	monitored := make(map[string]bool)
+	for _, pair := range qc.withLadders(qc.MonitoredPairs()) {
+		monitored[qc.getCacheKey(pair.InputMint, pair.OutputMint, pair.Amount)] = true
+	}
+
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	swept := make(map[string]bool)
+	for key, quote := range qc.cache {
+		if !monitored[key] && qc.expired(quote) {
+			delete(qc.cache, key)
+			swept[key] = true
+		}
+	}
+	if len(swept) == 0 {
+		return
+	}
+
+	for poolID, pairs := range qc.poolToQuotes {
+		var kept []QuotePair
+		for _, pair := range pairs {
+			if !swept[qc.getCacheKey(pair.InputMint, pair.OutputMint, pair.Amount)] {
+				kept = append(kept, pair)
+			}
+		}
+		if len(kept) == 0 {
+			delete(qc.poolToQuotes, poolID)
+		} else {
+			qc.poolToQuotes[poolID] = kept
+		}
+	}
+
+	log.Printf("Swept %d expired quotes", len(swept))
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/sol"
+)
+
+// Health statuses, from best to worst
+const (
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+)
+
+const (
+	// discoveryFailureThreshold consecutive failed discoveries mark a protocol degraded
+	discoveryFailureThreshold = 3
+	healthProbeTimeout        = 5 * time.Second
+)
+
+// componentStatus tracks the outcome of a component's recent calls
+type componentStatus struct {
+	mu          sync.Mutex
+	lastSuccess time.Time
+	lastError   string
+	lastErrorAt time.Time
+	latency     time.Duration
+	failures    int // consecutive
+
+	// Totals since startup, for /stats
+	calls        uint64
+	errors       uint64
+	totalLatency time.Duration
+	found        uint64 // pools returned by discoveries
+	lastFound    int
+}
+
+func (s *componentStatus) record(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = latency
+	s.calls++
+	s.totalLatency += latency
+	if err != nil {
+		s.errors++
+		s.lastError = err.Error()
+		s.lastErrorAt = time.Now()
+		s.failures++
+		return
+	}
+	s.lastSuccess = time.Now()
+	s.failures = 0
+}
+
+// slowerThan reports whether the last call took longer than max; zero max never is
+func (s *componentStatus) slowerThan(max time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return max > 0 && s.latency > max
+}
+
+// ComponentHealth is the /health view of an RPC endpoint or protocol
+type ComponentHealth struct {
+	Healthy     bool       `json:"healthy"`
+	Latency     string     `json:"latency,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+	Failures    int        `json:"consecutiveFailures,omitempty"`
+}
+
+func (s *componentStatus) snapshot(failureThreshold int) ComponentHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	health := ComponentHealth{
+		Healthy:   s.failures < failureThreshold,
+		LastError: s.lastError,
+		Failures:  s.failures,
+	}
+	if s.latency > 0 {
+		health.Latency = s.latency.Round(time.Millisecond).String()
+	}
+	if !s.lastSuccess.IsZero() {
+		lastSuccess := s.lastSuccess
+		health.LastSuccess = &lastSuccess
+	}
+	if !s.lastErrorAt.IsZero() {
+		lastErrorAt := s.lastErrorAt
+		health.LastErrorAt = &lastErrorAt
+	}
+	return health
+}
+
+// trackedProtocol records each pool discovery of a protocol for /health
+type trackedProtocol struct {
+	pkg.Protocol
+	status *componentStatus
+}
+
+func (p trackedProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+	start := time.Now()
+	pools, err := p.Protocol.FetchPoolsByPair(ctx, baseMint, quoteMint)
+	p.status.record(time.Since(start), err)
+	if err == nil {
+		p.status.recordFound(len(pools))
+	}
+	return pools, err
+}
+
+func (p trackedProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	start := time.Now()
+	res, err := p.Protocol.FetchPoolsByPairs(ctx, pairs)
+	p.status.record(time.Since(start), err)
+	found := 0
+	for _, pools := range res {
+		found += len(pools)
+	}
+	p.status.recordFound(found)
+	return res, err
+}
+
+func (s *componentStatus) recordFound(pools int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.found += uint64(pools)
+	s.lastFound = pools
+}
+
+// endpointProbe is the last known health of one RPC endpoint
+type endpointProbe struct {
+	client *sol.Client
+	name   string
+	status componentStatus
+}
+
+// healthMonitor probes RPC endpoints and collects component status for /health
+type healthMonitor struct {
+	mu         sync.RWMutex // guards endpoints and protocols, which a reload replaces
+	endpoints  []*endpointProbe
+	protocols  map[pkg.ProtocolName]*componentStatus
+	maxLatency time.Duration
+}
+
+func newHealthMonitor(clients []*sol.Client) *healthMonitor {
+	h := &healthMonitor{protocols: make(map[pkg.ProtocolName]*componentStatus)}
+	h.setClients(clients)
+	return h
+}
+
+// setClients replaces the probed endpoints
+func (h *healthMonitor) setClients(clients []*sol.Client) {
+	endpoints := make([]*endpointProbe, len(clients))
+	for i, client := range clients {
+		endpoints[i] = &endpointProbe{client: client, name: redactEndpoint(client.Endpoint())}
+	}
+	h.mu.Lock()
+	h.endpoints = endpoints
+	h.mu.Unlock()
+}
+
+// probes returns the probed endpoints
+func (h *healthMonitor) probes() []*endpointProbe {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.endpoints
+}
+
+// statuses returns a copy of the protocol statuses by name
+func (h *healthMonitor) statuses() map[pkg.ProtocolName]*componentStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	statuses := make(map[pkg.ProtocolName]*componentStatus, len(h.protocols))
+	for name, status := range h.protocols {
+		statuses[name] = status
+	}
+	return statuses
+}
+
+// track wraps protocols so their discoveries are recorded. Only the given
+// protocols are reported afterwards; those tracked before keep their history.
+func (h *healthMonitor) track(protocols []pkg.Protocol) []pkg.Protocol {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	statuses := make(map[pkg.ProtocolName]*componentStatus, len(protocols))
+	tracked := make([]pkg.Protocol, len(protocols))
+	for i, proto := range protocols {
+		status := h.protocols[proto.ProtocolName()]
+		if status == nil {
+			status = &componentStatus{}
+		}
+		statuses[proto.ProtocolName()] = status
+		tracked[i] = trackedProtocol{Protocol: proto, status: status}
+	}
+	h.protocols = statuses
+	return tracked
+}
+
+// redactEndpoint drops the path and query of an RPC URL, where providers put API keys
+func redactEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "invalid endpoint"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// probe measures a getSlot round trip on every endpoint
+func (h *healthMonitor) probe(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, endpoint := range h.probes() {
+		wg.Add(1)
+		go func(endpoint *endpointProbe) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+			defer cancel()
+
+			start := time.Now()
+			_, err := endpoint.client.GetSlot(probeCtx, rpc.CommitmentConfirmed)
+			endpoint.status.record(time.Since(start), err)
+			if err != nil {
+				log.Printf("Health probe of %s failed: %v", endpoint.name, err)
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+// Start probes the endpoints every interval until ctx is done
+func (h *healthMonitor) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		h.probe(ctx)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.probe(ctx)
+			}
+		}
+	}()
+}
+
+// StartHealthChecks probes the RPC endpoints every interval, flagging those
+// slower than maxLatency
+func (qc *QuoteCache) StartHealthChecks(interval, maxLatency time.Duration) {
+	qc.health.maxLatency = maxLatency
+	qc.health.Start(qc.ctx, interval)
+}
+
+// Health reports the status of the service's components. Any failing
+// component degrades the service; it is unhealthy when no RPC endpoint works.
+func (qc *QuoteCache) Health() HealthResponse {
+	allQuotes := qc.GetAllCached()
+	endpoints, protocols := qc.health.probes(), qc.health.statuses()
+
+	var lastUpdate time.Time
+	for _, quote := range allQuotes {
+		if quote.LastUpdate.After(lastUpdate) {
+			lastUpdate = quote.LastUpdate
+		}
+	}
+
+	health := HealthResponse{
+		Status:       StatusHealthy,
+		LastUpdate:   lastUpdate,
+		CachedRoutes: len(allQuotes),
+		Uptime:       time.Since(startTime).Round(time.Second).String(),
+		Mode:         "rpc-only",
+		StreamState:  qc.StreamState(),
+		Protocols:    make(map[string]ComponentHealth, len(protocols)),
+	}
+
+	healthyEndpoints := 0
+	for _, endpoint := range endpoints {
+		status := endpoint.status.snapshot(1)
+		if status.Healthy {
+			healthyEndpoints++
+		}
+		if !status.Healthy {
+			health.Degraded = append(health.Degraded, "rpc_unreachable:"+endpoint.name)
+		} else if endpoint.status.slowerThan(qc.health.maxLatency) {
+			health.Degraded = append(health.Degraded, "rpc_slow:"+endpoint.name)
+		}
+		health.RPC = append(health.RPC, EndpointHealth{Endpoint: endpoint.name, ComponentHealth: status})
+	}
+
+	if qc.streaming() {
+		health.Mode = "stream"
+	} else if qc.useWebSocket {
+		// The update stream is configured but down
+		health.Degraded = append(health.Degraded, "stream_down")
+	}
+	if qc.subscriptionMgr != nil {
+		stats := qc.subscriptionMgr.Stats()
+		health.SubscribedPools, _ = stats["subscribedPools"].(int)
+		health.Subscriptions, _ = stats["subscriptions"].(int)
+	}
+
+	names := make([]string, 0, len(protocols))
+	for name, status := range protocols {
+		snapshot := status.snapshot(discoveryFailureThreshold)
+		health.Protocols[string(name)] = snapshot
+		if !snapshot.Healthy {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		health.Degraded = append(health.Degraded, "discovery_failing:"+name)
+	}
+
+	switch {
+	case len(endpoints) > 0 && healthyEndpoints == 0:
+		health.Status = StatusUnhealthy
+	case len(health.Degraded) > 0:
+		health.Status = StatusDegraded
+	}
+	return health
+}
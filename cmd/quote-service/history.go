@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"cosmossdk.io/math"
+)
+
+// historyResolution is the spacing of stored quote samples; a pair updated
+// more often keeps only its latest quote per interval
+const historyResolution = 5 * time.Second
+
+// quoteSample is a quote's output at one point in time
+type quoteSample struct {
+	at   time.Time
+	out  math.Int
+	slot uint64
+}
+
+// quoteHistory keeps recent outputs of every cached quote for time-weighted averages
+type quoteHistory struct {
+	mu        sync.Mutex
+	retention time.Duration
+	samples   map[string][]quoteSample // cache key -> samples, oldest first
+}
+
+// SetHistoryRetention records cached quotes for this long so /twap can
+// average them. Zero disables recording.
+func (qc *QuoteCache) SetHistoryRetention(retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	qc.history = &quoteHistory{retention: retention, samples: make(map[string][]quoteSample)}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-qc.ctx.Done():
+				return
+			case now := <-ticker.C:
+				qc.history.prune(now)
+			}
+		}
+	}()
+}
+
+// record adds a quote's output, replacing the latest sample when it is
+// within historyResolution
+func (h *quoteHistory) record(key string, quote *CachedQuote) {
+	out, ok := math.NewIntFromString(quote.OutAmount)
+	if !ok {
+		return
+	}
+	sample := quoteSample{at: quote.LastUpdate, out: out, slot: quote.ContextSlot}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	samples := h.samples[key]
+	if n := len(samples); n > 0 && sample.at.Sub(samples[n-1].at) < historyResolution {
+		if !sample.at.Before(samples[n-1].at) {
+			samples[n-1] = sample
+		}
+		return
+	}
+	h.samples[key] = append(samples, sample)
+}
+
+// prune drops samples older than the retention and keys left without any
+func (h *quoteHistory) prune(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := now.Add(-h.retention)
+	for key, samples := range h.samples {
+		// Keep the last sample before the cutoff: it holds at the window start
+		i := 0
+		for i+1 < len(samples) && samples[i+1].at.Before(cutoff) {
+			i++
+		}
+		if samples[len(samples)-1].at.Before(cutoff) {
+			delete(h.samples, key)
+		} else if i > 0 {
+			h.samples[key] = append([]quoteSample(nil), samples[i:]...)
+		}
+	}
+}
+
+// TWAPResponse is the time-weighted average output of a pair over a window
+type TWAPResponse struct {
+	InputMint     string    `json:"inputMint"`
+	OutputMint    string    `json:"outputMint"`
+	InAmount      string    `json:"inAmount"`
+	Window        string    `json:"window"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	TWAPOutAmount string    `json:"twapOutAmount"`
+	MinOutAmount  string    `json:"minOutAmount"`
+	MaxOutAmount  string    `json:"maxOutAmount"`
+	LastOutAmount string    `json:"lastOutAmount"`
+	Samples       int       `json:"samples"`
+	Coverage      float64   `json:"coverage"` // fraction of the window with a known quote
+}
+
+// TWAP averages a pair's output over the window ending now, weighting each
+// recorded quote by how long it stood. Time before the first known quote is
+// left out and reported through Coverage.
+func (qc *QuoteCache) TWAP(inputMint, outputMint, amount string, window time.Duration) (*TWAPResponse, error) {
+	if qc.history == nil {
+		return nil, fmt.Errorf("quote history is disabled")
+	}
+	if window > qc.history.retention {
+		return nil, fmt.Errorf("window exceeds the %v history retention", qc.history.retention)
+	}
+
+	now := time.Now()
+	from := now.Add(-window)
+	key := qc.getCacheKey(inputMint, outputMint, amount)
+
+	qc.history.mu.Lock()
+	samples := append([]quoteSample(nil), qc.history.samples[key]...)
+	qc.history.mu.Unlock()
+
+	// Start from the quote standing at the window start, if known
+	start := 0
+	for start+1 < len(samples) && !samples[start+1].at.After(from) {
+		start++
+	}
+	samples = samples[start:]
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no quote history for this pair")
+	}
+
+	weighted := math.LegacyZeroDec()
+	covered := time.Duration(0)
+	minOut, maxOut := samples[0].out, samples[0].out
+	for i, sample := range samples {
+		begin := sample.at
+		if begin.Before(from) {
+			begin = from
+		}
+		end := now
+		if i+1 < len(samples) {
+			end = samples[i+1].at
+		}
+		if !end.After(begin) {
+			continue
+		}
+		duration := end.Sub(begin)
+		weighted = weighted.Add(sample.out.ToLegacyDec().MulInt64(duration.Milliseconds()))
+		covered += duration
+		if sample.out.LT(minOut) {
+			minOut = sample.out
+		}
+		if sample.out.GT(maxOut) {
+			maxOut = sample.out
+		}
+	}
+	if covered.Milliseconds() == 0 {
+		return nil, fmt.Errorf("no quote history for this pair")
+	}
+
+	return &TWAPResponse{
+		InputMint:     inputMint,
+		OutputMint:    outputMint,
+		InAmount:      amount,
+		Window:        window.String(),
+		From:          from,
+		To:            now,
+		TWAPOutAmount: weighted.QuoInt64(covered.Milliseconds()).TruncateInt().String(),
+		MinOutAmount:  minOut.String(),
+		MaxOutAmount:  maxOut.String(),
+		LastOutAmount: samples[len(samples)-1].out.String(),
+		Samples:       len(samples),
+		Coverage:      float64(covered) / float64(window),
+	}, nil
+}
+
+// handleTWAP serves GET /twap?input=&output=&amount=&window=5m
+func handleTWAP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	inputMint, outputMint, amount := query.Get("input"), query.Get("output"), query.Get("amount")
+	if inputMint == "" || outputMint == "" || amount == "" {
+		writeError(w, "Missing required parameters: input, output, amount", http.StatusBadRequest)
+		return
+	}
+	window := 5 * time.Minute
+	if param := query.Get("window"); param != "" {
+		parsed, err := time.ParseDuration(param)
+		if err != nil || parsed <= 0 {
+			writeError(w, "Invalid window parameter (e.g. 5m, 1h, 24h)", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	twap, err := quoteCache.TWAP(inputMint, outputMint, amount, window)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(twap)
+}
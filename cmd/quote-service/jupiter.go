@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Jupiter v6 compatible API, enabled with -jupiter-api. Request and response
+// shapes follow Jupiter's /v6/quote and /v6/swap so existing integrations can
+// switch their base URL. Only ExactIn, single-hop routes are produced.
+
+type JupiterQuoteResponse struct {
+	InputMint            string              `json:"inputMint"`
+	InAmount             string              `json:"inAmount"`
+	OutputMint           string              `json:"outputMint"`
+	OutAmount            string              `json:"outAmount"`
+	OtherAmountThreshold string              `json:"otherAmountThreshold"`
+	SwapMode             string              `json:"swapMode"`
+	SlippageBps          int                 `json:"slippageBps"`
+	PlatformFee          *JupiterPlatformFee `json:"platformFee"`
+	PriceImpactPct       string              `json:"priceImpactPct"`
+	RoutePlan            []JupiterRoutePlan  `json:"routePlan"`
+	ContextSlot          uint64              `json:"contextSlot"`
+	TimeTaken            float64             `json:"timeTaken"` // seconds
+}
+
+type JupiterPlatformFee struct {
+	Amount string `json:"amount"`
+	FeeBps int    `json:"feeBps"`
+}
+
+type JupiterRoutePlan struct {
+	SwapInfo JupiterSwapInfo `json:"swapInfo"`
+	Percent  int             `json:"percent"`
+}
+
+type JupiterSwapInfo struct {
+	AmmKey     string `json:"ammKey"`
+	Label      string `json:"label"`
+	InputMint  string `json:"inputMint"`
+	OutputMint string `json:"outputMint"`
+	InAmount   string `json:"inAmount"`
+	OutAmount  string `json:"outAmount"`
+	FeeAmount  string `json:"feeAmount"`
+	FeeMint    string `json:"feeMint"`
+}
+
+type JupiterSwapRequest struct {
+	UserPublicKey                 string               `json:"userPublicKey"`
+	QuoteResponse                 JupiterQuoteResponse `json:"quoteResponse"`
+	WrapAndUnwrapSol              *bool                `json:"wrapAndUnwrapSol,omitempty"`
+	ComputeUnitPriceMicroLamports uint64               `json:"computeUnitPriceMicroLamports,omitempty"`
+	DynamicComputeUnitLimit       bool                 `json:"dynamicComputeUnitLimit,omitempty"`
+	FeeAccount                    string               `json:"feeAccount,omitempty"`
+}
+
+type JupiterSwapResponse struct {
+	SwapTransaction      string `json:"swapTransaction"` // base64, unsigned
+	LastValidBlockHeight uint64 `json:"lastValidBlockHeight"`
+}
+
+// handleJupiterQuote serves GET /v6/quote
+func handleJupiterQuote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	inputMint := query.Get("inputMint")
+	outputMint := query.Get("outputMint")
+	amount := query.Get("amount")
+	if inputMint == "" || outputMint == "" || amount == "" {
+		writeError(w, "Missing required parameters: inputMint, outputMint, amount", http.StatusBadRequest)
+		return
+	}
+
+	if mode := query.Get("swapMode"); mode != "" && mode != "ExactIn" {
+		writeError(w, fmt.Sprintf("swapMode %s is not supported", mode), http.StatusBadRequest)
+		return
+	}
+
+	slippageBps := -1
+	if param := query.Get("slippageBps"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 0 || parsed > 10000 {
+			writeError(w, "Invalid slippageBps parameter (must be 0-10000)", http.StatusBadRequest)
+			return
+		}
+		slippageBps = parsed
+	}
+
+	platformFeeBps := 0
+	if param := query.Get("platformFeeBps"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 0 || parsed > 10000 {
+			writeError(w, "Invalid platformFeeBps parameter (must be 0-10000)", http.StatusBadRequest)
+			return
+		}
+		platformFeeBps = parsed
+	}
+
+	dexes := splitList(query.Get("dexes"))
+	excludeDexes := splitList(query.Get("excludeDexes"))
+
+	var quote *CachedQuote
+	var exists bool
+	if len(dexes) == 0 && len(excludeDexes) == 0 {
+		quote, exists = quoteCache.GetQuote(inputMint, outputMint, amount)
+	}
+	if !exists {
+		var err error
+		quote, err = quoteCache.GetOrCalculateQuote(r.Context(), inputMint, outputMint, amount, dexes, excludeDexes, 0)
+		if err != nil {
+			writeLibraryError(w, fmt.Sprintf("Failed to calculate quote: %v", err), err, http.StatusBadRequest)
+			return
+		}
+	}
+	if slippageBps < 0 {
+		slippageBps = quote.SlippageBps
+	}
+
+	response, err := toJupiterQuote(quote, slippageBps, platformFeeBps)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleJupiterSwap serves POST /v6/swap with an unsigned transaction for the
+// user to sign
+func handleJupiterSwap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req JupiterSwapRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	user, err := solana.PublicKeyFromBase58(req.UserPublicKey)
+	if err != nil {
+		writeError(w, "Invalid userPublicKey", http.StatusBadRequest)
+		return
+	}
+	if req.FeeAccount != "" {
+		writeError(w, "feeAccount is not supported: platform fees are quoted but not collected", http.StatusBadRequest)
+		return
+	}
+	if len(req.QuoteResponse.RoutePlan) != 1 {
+		writeError(w, "quoteResponse must have exactly one route step", http.StatusBadRequest)
+		return
+	}
+
+	wrapSOL := true
+	if req.WrapAndUnwrapSol != nil {
+		wrapSOL = *req.WrapAndUnwrapSol
+	}
+
+	tx, lastValid, err := quoteCache.BuildSwapTransaction(r.Context(), fromJupiterQuote(req.QuoteResponse), SwapParams{
+		User:                    user,
+		WrapSOL:                 wrapSOL,
+		ComputeUnitPrice:        req.ComputeUnitPriceMicroLamports,
+		DynamicComputeUnitLimit: req.DynamicComputeUnitLimit,
+	})
+	if err != nil {
+		writeError(w, fmt.Sprintf("Failed to build swap: %v", err), http.StatusBadRequest)
+		return
+	}
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		writeError(w, fmt.Sprintf("Failed to serialize transaction: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(JupiterSwapResponse{
+		SwapTransaction:      base64.StdEncoding.EncodeToString(data),
+		LastValidBlockHeight: lastValid,
+	})
+}
+
+// toJupiterQuote converts a cached quote to Jupiter's schema. The platform fee
+// is taken from the output, so outAmount and the threshold exclude it.
+func toJupiterQuote(quote *CachedQuote, slippageBps, platformFeeBps int) (*JupiterQuoteResponse, error) {
+	outAmount, ok := math.NewIntFromString(quote.OutAmount)
+	if !ok {
+		return nil, fmt.Errorf("invalid cached outAmount %q", quote.OutAmount)
+	}
+
+	var platformFee *JupiterPlatformFee
+	if platformFeeBps > 0 {
+		fee := outAmount.Mul(math.NewInt(int64(platformFeeBps))).Quo(math.NewInt(10000))
+		outAmount = outAmount.Sub(fee)
+		platformFee = &JupiterPlatformFee{Amount: fee.String(), FeeBps: platformFeeBps}
+	}
+	threshold := outAmount.Mul(math.NewInt(int64(10000 - slippageBps))).Quo(math.NewInt(10000))
+
+	priceImpact := quote.PriceImpact
+	if priceImpact == "" {
+		priceImpact = "0"
+	}
+
+	var timeTaken float64
+	if d, err := time.ParseDuration(quote.TimeTaken); err == nil {
+		timeTaken = d.Seconds()
+	}
+
+	response := &JupiterQuoteResponse{
+		InputMint:            quote.InputMint,
+		InAmount:             quote.InAmount,
+		OutputMint:           quote.OutputMint,
+		OutAmount:            outAmount.String(),
+		OtherAmountThreshold: threshold.String(),
+		SwapMode:             "ExactIn",
+		SlippageBps:          slippageBps,
+		PlatformFee:          platformFee,
+		PriceImpactPct:       priceImpact,
+		ContextSlot:          quote.ContextSlot,
+		TimeTaken:            timeTaken,
+	}
+	for _, leg := range quote.RoutePlan {
+		feeAmount := leg.Fee
+		if feeAmount == "" {
+			feeAmount = "0"
+		}
+		response.RoutePlan = append(response.RoutePlan, JupiterRoutePlan{
+			SwapInfo: JupiterSwapInfo{
+				AmmKey:     leg.PoolID,
+				Label:      leg.Protocol,
+				InputMint:  leg.InputMint,
+				OutputMint: leg.OutputMint,
+				InAmount:   leg.InAmount,
+				OutAmount:  leg.OutAmount,
+				FeeAmount:  feeAmount,
+				FeeMint:    leg.InputMint,
+			},
+			Percent: 100,
+		})
+	}
+	return response, nil
+}
+
+// fromJupiterQuote converts a Jupiter quote back to the fields needed to build its swap
+func fromJupiterQuote(quote JupiterQuoteResponse) *CachedQuote {
+	cached := &CachedQuote{
+		InputMint:            quote.InputMint,
+		OutputMint:           quote.OutputMint,
+		InAmount:             quote.InAmount,
+		OutAmount:            quote.OutAmount,
+		SlippageBps:          quote.SlippageBps,
+		OtherAmountThreshold: quote.OtherAmountThreshold,
+		ContextSlot:          quote.ContextSlot,
+	}
+	for _, step := range quote.RoutePlan {
+		cached.RoutePlan = append(cached.RoutePlan, RoutePlan{
+			Protocol:    step.SwapInfo.Label,
+			PoolID:      step.SwapInfo.AmmKey,
+			PoolAddress: step.SwapInfo.AmmKey,
+			InputMint:   step.SwapInfo.InputMint,
+			OutputMint:  step.SwapInfo.OutputMint,
+			InAmount:    step.SwapInfo.InAmount,
+			OutAmount:   step.SwapInfo.OutAmount,
+		})
+	}
+	return cached
+}
+
+// splitList splits a comma-separated query parameter, dropping empty entries
+func splitList(param string) []string {
+	var items []string
+	for _, item := range strings.Split(param, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cosmossdk.io/math"
+)
+
+// parseLadder parses comma-separated multiples of a pair's amount, e.g. "0.1,10,100"
+func parseLadder(list string) ([]float64, error) {
+	var multiples []float64
+	for _, item := range splitList(list) {
+		multiple, err := strconv.ParseFloat(item, 64)
+		if err != nil || multiple <= 0 {
+			return nil, fmt.Errorf("invalid ladder multiple %q", item)
+		}
+		multiples = append(multiples, multiple)
+	}
+	return multiples, nil
+}
+
+// SetLadder caches each monitored pair at these multiples of its amount as
+// well, and lets /quote serve amounts between cached sizes by interpolation
+func (qc *QuoteCache) SetLadder(multiples []float64) {
+	qc.ladder = multiples
+}
+
+// ladderPairs returns the extra sizes cached for a monitored pair: its own
+// ladder if it has one, otherwise the -ladder multiples of its amount
+func (qc *QuoteCache) ladderPairs(pair QuotePair) []QuotePair {
+	amounts := pair.Ladder
+	if len(amounts) == 0 && len(qc.ladder) > 0 {
+		base, ok := math.NewIntFromString(pair.Amount)
+		if !ok {
+			return nil
+		}
+		for _, multiple := range qc.ladder {
+			amount := math.LegacyMustNewDecFromStr(strconv.FormatFloat(multiple, 'f', -1, 64)).MulInt(base).TruncateInt()
+			if amount.IsPositive() {
+				amounts = append(amounts, amount.String())
+			}
+		}
+	}
+
+	seen := map[string]bool{pair.Amount: true}
+	var rungs []QuotePair
+	for _, amount := range amounts {
+		if seen[amount] {
+			continue
+		}
+		seen[amount] = true
+		rungs = append(rungs, QuotePair{
+			InputMint:   pair.InputMint,
+			OutputMint:  pair.OutputMint,
+			Amount:      amount,
+			Label:       fmt.Sprintf("%s [%s]", pair.Label, amount),
+			SlippageBps: pair.SlippageBps,
+		})
+	}
+	return rungs
+}
+
+// withLadders adds the ladder sizes of each pair
+func (qc *QuoteCache) withLadders(pairs []QuotePair) []QuotePair {
+	expanded := make([]QuotePair, 0, len(pairs))
+	for _, pair := range pairs {
+		expanded = append(expanded, pair)
+		expanded = append(expanded, qc.ladderPairs(pair)...)
+	}
+	return expanded
+}
+
+// laddered reports whether quotes of a token pair are cached at several sizes
+func (qc *QuoteCache) laddered(inputMint, outputMint string) bool {
+	for _, pair := range qc.MonitoredPairs() {
+		if pair.InputMint == inputMint && pair.OutputMint == outputMint && (len(pair.Ladder) > 0 || len(qc.ladder) > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// LadderQuote estimates a quote for an uncached amount from the pair's cached
+// quotes at other sizes. Between two sizes the output is interpolated
+// linearly; below the smallest it is scaled from the smallest. Both
+// underestimate the output of a constant-product curve, so the estimate is
+// conservative. Amounts above the largest cached size are not estimated.
+func (qc *QuoteCache) LadderQuote(inputMint, outputMint, amount string) (*CachedQuote, bool) {
+	amountIn, ok := math.NewIntFromString(amount)
+	if !ok || !amountIn.IsPositive() || !qc.laddered(inputMint, outputMint) {
+		return nil, false
+	}
+
+	var lower, upper *CachedQuote
+	var lowerIn, upperIn math.Int
+	qc.mu.RLock()
+	for _, quote := range qc.cache {
+		if quote.InputMint != inputMint || quote.OutputMint != outputMint || qc.expired(quote) {
+			continue
+		}
+		in, ok := math.NewIntFromString(quote.InAmount)
+		if !ok {
+			continue
+		}
+		if in.LT(amountIn) && (lower == nil || in.GT(lowerIn)) {
+			lower, lowerIn = quote, in
+		}
+		if in.GT(amountIn) && (upper == nil || in.LT(upperIn)) {
+			upper, upperIn = quote, in
+		}
+	}
+	qc.mu.RUnlock()
+
+	if upper == nil {
+		return nil, false
+	}
+	upperOut, ok := math.NewIntFromString(upper.OutAmount)
+	if !ok {
+		return nil, false
+	}
+
+	nearest := upper
+	var out math.Int
+	if lower == nil {
+		out = upperOut.Mul(amountIn).Quo(upperIn)
+	} else {
+		lowerOut, ok := math.NewIntFromString(lower.OutAmount)
+		if !ok {
+			return nil, false
+		}
+		out = lowerOut.Add(upperOut.Sub(lowerOut).Mul(amountIn.Sub(lowerIn)).Quo(upperIn.Sub(lowerIn)))
+		if amountIn.Sub(lowerIn).LT(upperIn.Sub(amountIn)) {
+			nearest = lower
+		}
+	}
+
+	// Report the route of the nearest size and the age of the older one
+	estimate := *nearest
+	estimate.InAmount = amountIn.String()
+	estimate.OutAmount = out.String()
+	estimate.Interpolated = true
+	if lower != nil && lower.LastUpdate.Before(estimate.LastUpdate) {
+		estimate.LastUpdate = lower.LastUpdate
+		estimate.ContextSlot = lower.ContextSlot
+	}
+	if upper.LastUpdate.Before(estimate.LastUpdate) {
+		estimate.LastUpdate = upper.LastUpdate
+		estimate.ContextSlot = upper.ContextSlot
+	}
+	estimate.RoutePlan = append([]RoutePlan(nil), nearest.RoutePlan...)
+	if len(estimate.RoutePlan) > 0 {
+		estimate.RoutePlan[0].InAmount = estimate.InAmount
+		estimate.RoutePlan[0].OutAmount = estimate.OutAmount
+	}
+	return withSlippage(&estimate, estimate.SlippageBps), true
+}
+
+// normalizeLadder validates a pair's explicit ladder amounts
+func normalizeLadder(pair *QuotePair) error {
+	for i, amount := range pair.Ladder {
+		amount = strings.TrimSpace(amount)
+		if parsed, ok := math.NewIntFromString(amount); !ok || !parsed.IsPositive() {
+			return fmt.Errorf("invalid ladder amount %q", amount)
+		}
+		pair.Ladder[i] = amount
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newLadderCache caches WSOL->USDC at 0.1, 1 and 10 SOL, the smallest size
+// quoted longest ago
+func newLadderCache() (*QuoteCache, time.Time) {
+	qc := newTestCache()
+	qc.SetMonitoredPairs([]QuotePair{{
+		InputMint:  WSOL.String(),
+		OutputMint: USDC.String(),
+		Amount:     ONE_SOL,
+		Ladder:     []string{"100000000", "10000000000"},
+	}})
+	now := time.Now()
+	older := now.Add(-10 * time.Second)
+	cacheQuote(qc, WSOL.String(), USDC.String(), "100000000", "15000000", 90, older)
+	cacheQuote(qc, WSOL.String(), USDC.String(), ONE_SOL, "150000000", 100, now)
+	cacheQuote(qc, WSOL.String(), USDC.String(), "10000000000", "1400000000", 100, now)
+	return qc, older
+}
+
+func TestLadderQuoteInterpolates(t *testing.T) {
+	qc, older := newLadderCache()
+	lower := *qc.cache[qc.getCacheKey(WSOL.String(), USDC.String(), "100000000")]
+
+	quote, ok := qc.LadderQuote(WSOL.String(), USDC.String(), "500000000")
+	if !ok {
+		t.Fatal("no estimate between cached sizes")
+	}
+	// 15 + (150 - 15) * (0.5 - 0.1) / (1 - 0.1) = 75 USDC
+	if quote.OutAmount != "75000000" || quote.OtherAmountThreshold != "74625000" || !quote.Interpolated {
+		t.Fatalf("got out %s threshold %s interpolated %v", quote.OutAmount, quote.OtherAmountThreshold, quote.Interpolated)
+	}
+	// 0.5 SOL is nearer 0.1 than 1 SOL, and 0.1 SOL is the older quote
+	leg := quote.RoutePlan[0]
+	if leg.PoolID != "pool-100000000" || leg.InAmount != "500000000" || leg.OutAmount != "75000000" {
+		t.Fatalf("route leg %+v", leg)
+	}
+	if !quote.LastUpdate.Equal(older) || quote.ContextSlot != 90 {
+		t.Fatalf("estimate dated %v slot %d, want the older quote's", quote.LastUpdate, quote.ContextSlot)
+	}
+	if cached := qc.cache[qc.getCacheKey(WSOL.String(), USDC.String(), "100000000")]; !reflect.DeepEqual(*cached, lower) {
+		t.Fatal("interpolation modified a cached quote")
+	}
+}
+
+func TestLadderQuoteBounds(t *testing.T) {
+	qc, _ := newLadderCache()
+
+	// Below the smallest size the smallest quote's price is scaled down
+	quote, ok := qc.LadderQuote(WSOL.String(), USDC.String(), "50000000")
+	if !ok || quote.OutAmount != "7500000" {
+		t.Fatalf("below the ladder: %v %+v", ok, quote)
+	}
+	if _, ok := qc.LadderQuote(WSOL.String(), USDC.String(), "20000000000"); ok {
+		t.Fatal("estimated an amount above the largest cached size")
+	}
+	if _, ok := qc.LadderQuote(USDC.String(), WSOL.String(), "500000000"); ok {
+		t.Fatal("estimated a pair without a ladder")
+	}
+	if _, ok := qc.LadderQuote(WSOL.String(), USDC.String(), "0"); ok {
+		t.Fatal("estimated a zero amount")
+	}
+
+	// With the 0.1 SOL quote expired, 0.5 SOL is scaled down from 1 SOL
+	qc.quoteTTL = 5 * time.Second
+	quote, ok = qc.LadderQuote(WSOL.String(), USDC.String(), "500000000")
+	if !ok || quote.RoutePlan[0].PoolID != "pool-1000000000" || quote.OutAmount != "75000000" {
+		t.Fatalf("with the smallest size expired: %v %+v", ok, quote)
+	}
+}
+
+func TestLadderPairs(t *testing.T) {
+	qc := newTestCache()
+	qc.SetLadder([]float64{0.1, 1, 10})
+	rungs := qc.ladderPairs(QuotePair{InputMint: WSOL.String(), OutputMint: USDC.String(), Amount: ONE_SOL, Label: "SOL"})
+
+	var amounts []string
+	for _, rung := range rungs {
+		amounts = append(amounts, rung.Amount)
+	}
+	// The pair's own amount is not repeated
+	if want := []string{"100000000", "10000000000"}; !reflect.DeepEqual(amounts, want) {
+		t.Fatalf("ladder amounts %v, want %v", amounts, want)
+	}
+	if rungs[0].Label != "SOL [100000000]" {
+		t.Fatalf("rung label %q", rungs[0].Label)
+	}
+
+	// A pair's own ladder overrides -ladder
+	rungs = qc.ladderPairs(QuotePair{Amount: ONE_SOL, Ladder: []string{"5"}})
+	if len(rungs) != 1 || rungs[0].Amount != "5" {
+		t.Fatalf("explicit ladder gave %+v", rungs)
+	}
+}
+
+func TestHandleQuoteServesLadderEstimate(t *testing.T) {
+	newLadderCache()
+
+	rec := getQuote(fmt.Sprintf("input=%s&output=%s&amount=500000000", WSOL, USDC), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var quote CachedQuote
+	if err := json.NewDecoder(rec.Body).Decode(&quote); err != nil {
+		t.Fatal(err)
+	}
+	if !quote.Interpolated || quote.OutAmount != "75000000" {
+		t.Fatalf("served %+v", quote)
+	}
+}
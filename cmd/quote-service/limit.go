@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SetMaxCalculations bounds how many on-demand quote calculations run at
+// once; further requests wait for a slot. Zero or less is unlimited.
+func (qc *QuoteCache) SetMaxCalculations(n int) {
+	if n <= 0 {
+		qc.calcSlots = nil
+		return
+	}
+	qc.calcSlots = make(chan struct{}, n)
+}
+
+// calculateShared calculates a quote on demand. Identical concurrent requests
+// share one calculation, which runs under the cache's context so that one
+// client going away does not fail the others; ctx only bounds the wait.
+func (qc *QuoteCache) calculateShared(ctx context.Context, inputMint, outputMint, amount string, dexes, excludeDexes []string, minLiquidityUSD float64) (*CachedQuote, error) {
+	flightKey := fmt.Sprintf("%s|%s|%s|%g", qc.getCacheKey(inputMint, outputMint, amount),
+		strings.Join(dexes, ","), strings.Join(excludeDexes, ","), minLiquidityUSD)
+
+	result := qc.inflight.DoChan(flightKey, func() (interface{}, error) {
+		if qc.calcSlots != nil {
+			select {
+			case qc.calcSlots <- struct{}{}:
+				defer func() { <-qc.calcSlots }()
+			case <-qc.ctx.Done():
+				return nil, qc.ctx.Err()
+			}
+		}
+		return qc.calculateQuote(qc.ctx, inputMint, outputMint, amount, dexes, excludeDexes, minLiquidityUSD)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("quote calculation still in progress: %w", ctx.Err())
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*CachedQuote), nil
+	}
+}
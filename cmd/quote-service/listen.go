@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+)
+
+// parseListen resolves -listen into a network and address. Empty listens on
+// port on every interface; unix:///path or unix:path selects a Unix socket.
+func parseListen(listen string, port int) (network, address string, err error) {
+	switch {
+	case listen == "":
+		return "tcp", fmt.Sprintf(":%d", port), nil
+	case strings.HasPrefix(listen, "unix://"):
+		address = strings.TrimPrefix(listen, "unix://")
+	case strings.HasPrefix(listen, "unix:"):
+		address = strings.TrimPrefix(listen, "unix:")
+	default:
+		if _, _, err := net.SplitHostPort(listen); err != nil {
+			return "", "", fmt.Errorf("invalid listen address %q: %w", listen, err)
+		}
+		return "tcp", listen, nil
+	}
+	if address == "" {
+		return "", "", fmt.Errorf("unix socket path is required")
+	}
+	return "unix", address, nil
+}
+
+// listen opens the server's listener. A socket file left behind by a previous
+// run is removed first; the socket is removed again when the listener closes.
+func listen(network, address string) (net.Listener, error) {
+	if network == "unix" {
+		if info, err := os.Stat(address); err == nil {
+			if info.Mode()&os.ModeSocket == 0 {
+				return nil, fmt.Errorf("%s exists and is not a socket", address)
+			}
+			if conn, err := net.Dial("unix", address); err == nil {
+				conn.Close()
+				return nil, fmt.Errorf("%s is in use by another process", address)
+			}
+			log.Printf("Removing stale socket %s", address)
+			if err := os.Remove(address); err != nil {
+				return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+			}
+		}
+	}
+
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	return ln, nil
+}
+
+// listenURL describes where the server listens for the startup log
+func listenURL(scheme, network, address string) string {
+	if network == "unix" {
+		return "unix://" + address
+	}
+	host, port, _ := net.SplitHostPort(address)
+	if host == "" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, port))
+}
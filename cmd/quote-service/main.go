@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -16,15 +17,81 @@ import (
 
 	"cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg"
+	"soltrading/pkg/cluster"
 	"soltrading/pkg/config"
+	"soltrading/pkg/indexer"
+	"soltrading/pkg/jupiter"
+	"soltrading/pkg/logging"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/subscription"
+	"soltrading/pkg/wallet"
 )
 
 var (
-	rpcEndpoints    = flag.String("rpc", "", "Comma-separated Solana RPC endpoints (uses default pool if empty)")
-	port            = flag.Int("port", 8080, "HTTP server port")
-	refreshInterval = flag.Int("refresh", 30, "Quote refresh interval in seconds")
-	rateLimit       = flag.Int("ratelimit", 20, "RPC requests per second per endpoint")
-	slippageBps     = flag.Int("slippage", 50, "Slippage tolerance in basis points")
+	rpcEndpoints        = flag.String("rpc", "", "Comma-separated Solana RPC endpoints (uses default pool if empty)")
+	port                = flag.Int("port", 8080, "HTTP server port")
+	listenAddr          = flag.String("listen", "", "Bind address such as 127.0.0.1:8080, or unix:///run/quote-service.sock for a Unix socket (overrides -port)")
+	refreshInterval     = flag.Int("refresh", 30, "Quote refresh interval in seconds")
+	rateLimit           = flag.Int("ratelimit", 20, "RPC requests per second per endpoint")
+	slippageBps         = flag.Int("slippage", 50, "Slippage tolerance in basis points")
+	proxyURL            = flag.String("proxy", "", "HTTP or SOCKS5 proxy for RPC and WebSocket traffic (e.g. socks5://127.0.0.1:1080)")
+	dialTimeout         = flag.Duration("dial-timeout", 10*time.Second, "Connect timeout for RPC and WebSocket endpoints")
+	requestTimeout      = flag.Duration("rpc-timeout", 30*time.Second, "Timeout for a single RPC request")
+	staleSlots          = flag.Uint64("stale-slots", 150, "Pools whose WebSocket state is older than this many slots are penalized")
+	geyserEndpoint      = flag.String("geyser", "", "Yellowstone Geyser gRPC endpoint (host:port) used instead of WebSocket for pool updates")
+	geyserToken         = flag.String("geyser-token", "", "Geyser x-token (defaults to GEYSER_TOKEN env)")
+	coalesce            = flag.Duration("coalesce", subscription.DefaultCoalesceInterval, "Minimum time between quote recalculations triggered by one pool's updates")
+	stalePenaltyBps     = flag.Int64("stale-penalty-bps", 10, "Ranking penalty in basis points for stale pools (0 disables)")
+	reconnectMax        = flag.Duration("reconnect-max-delay", subscription.DefaultReconnectMaxDelay, "Upper bound for the exponential backoff between stream reconnect attempts")
+	reconnectTries      = flag.Int("reconnect-attempts", 0, "Give up on the update stream after this many failed reconnects and stay RPC-only (0 retries forever)")
+	idleTimeout         = flag.Duration("idle-timeout", 30*time.Minute, "Unsubscribe pools whose quotes were not requested for this long (0 keeps them forever)")
+	maxSubs             = flag.Int("max-subscriptions", 0, "Maximum account subscriptions on the update stream; least recently requested pools are evicted first (0 is unlimited)")
+	poolCacheMax        = flag.Int("pool-cache-max", 0, "Maximum pools kept in the WebSocket pool cache, least recently used evicted first (0 is unlimited)")
+	poolCacheMB         = flag.Int("pool-cache-mb", 0, "Maximum raw account data in the pool cache in MiB (0 is unlimited)")
+	dropAccountData     = flag.Bool("drop-account-data", true, "Discard raw account data once applied to the decoded pool state")
+	staleRefresh        = flag.Duration("stale-refresh", 2*time.Minute, "Re-read pools over RPC that received no stream update for this long and resubscribe dropped accounts (0 disables)")
+	tlsCert             = flag.String("tls-cert", "", "TLS certificate file for serving HTTPS (defaults to TLS_CERT_FILE env)")
+	tlsKey              = flag.String("tls-key", "", "TLS private key file for serving HTTPS (defaults to TLS_KEY_FILE env)")
+	acmeDomains         = flag.String("acme-domains", "", "Comma-separated domains to obtain Let's Encrypt certificates for via ACME (defaults to ACME_DOMAINS env)")
+	acmeCache           = flag.String("acme-cache", "acme-cache", "Directory where ACME certificates are cached")
+	acmeEmail           = flag.String("acme-email", "", "Contact email for the ACME account (defaults to ACME_EMAIL env)")
+	acmeHTTPAddr        = flag.String("acme-http", ":80", "Address answering ACME HTTP-01 challenges and redirecting to HTTPS (empty disables)")
+	accessLog           = flag.Bool("access-log", true, "Write a JSON access log line per HTTP request to stdout")
+	accessLogLevel      = flag.String("access-log-level", "info", "Lowest access log level written: debug, info, warn (4xx) or error (5xx)")
+	accessLogSample     = flag.Float64("access-log-sample", 1, "Fraction of successful requests written to the access log; 4xx and 5xx are always written")
+	libLogLevel         = flag.String("log-level", "info", "Lowest level of the library's logs on stderr: debug, info, warn or error")
+	libLogJSON          = flag.Bool("log-json", false, "Write the library's logs to stderr as JSON lines instead of text")
+	quoteTTL            = flag.Duration("quote-ttl", 2*time.Minute, "Recalculate quotes older than this instead of serving them, and drop expired quotes of unmonitored pairs (0 disables)")
+	pairsFile           = flag.String("pairs", "", "JSON file with the quote pairs to refresh periodically (default SOL/USDC both ways)")
+	adminToken          = flag.String("admin-token", "", "Bearer token required by admin endpoints such as /pairs (defaults to ADMIN_TOKEN env; empty disables them)")
+	webhookAllowPrivate = flag.Bool("webhook-allow-private", false, "Let alert and order webhooks target loopback, private and link-local addresses")
+	jupiterAPI          = flag.Bool("jupiter-api", false, "Serve Jupiter v6 compatible /v6/quote and /v6/swap endpoints")
+	compareJupiter      = flag.Duration("compare-jupiter", 0, "Compare monitored pairs' quotes per protocol with Jupiter's at this interval and serve the results at /validation (0 disables)")
+	jupiterURL          = flag.String("jupiter-url", jupiter.DefaultBaseURL, "Jupiter quote API base URL used by -compare-jupiter")
+	maxDeviation        = flag.Float64("jupiter-max-deviation", 10, "Log a warning when a protocol's quote deviates from Jupiter's on the same pool by more than this many basis points")
+	lookupTables        = flag.String("lookup-tables", "", "Comma-separated address lookup tables suggested by /swap-instructions")
+	snapshotPath        = flag.String("snapshot", "", "File to save cached quotes and pools to on shutdown and warm start from on startup (empty disables)")
+	probeInterval       = flag.Duration("health-interval", 15*time.Second, "How often /health probes each RPC endpoint (0 disables probing)")
+	probeMaxLatency     = flag.Duration("health-max-latency", 2*time.Second, "RPC probe latency above which /health reports the endpoint as slow")
+	compress            = flag.Bool("compress", true, "Compress responses with gzip or deflate when the client accepts it")
+	compressMin         = flag.Int("compress-min-size", 1024, "Smallest response body compressed, in bytes")
+	compressTypes       = flag.String("compress-types", "application/json,text/plain,text/html", "Comma-separated content-type prefixes that are compressed")
+	maxCalcs            = flag.Int("max-calculations", 8, "Maximum on-demand quote calculations running at once; identical requests share one (0 is unlimited)")
+	ladderFlag          = flag.String("ladder", "", "Comma-separated multiples of each monitored pair's amount to cache as well, e.g. 0.1,10,100; /quote interpolates amounts in between (empty disables)")
+	warmFile            = flag.String("warm", "", "JSON file with popular pairs to quote on startup, in the -pairs format (empty disables)")
+	warmWorkers         = flag.Int("warm-workers", 4, "Pairs warmed concurrently on startup")
+	walletSource        = flag.String("wallet", "", "Wallet that executes limit orders (file:<path>, env:<VAR> or mnemonic:<VAR>); empty only notifies")
+	historyWindow       = flag.Duration("history", 24*time.Hour, "How long quote outputs are kept for /twap (0 disables)")
+	publishURL          = flag.String("publish", "", "Publish every quote recalculation to nats://host:port or a Kafka REST Proxy at kafka+http(s)://host:port")
+	publishTopic        = flag.String("publish-topic", "quotes", "Kafka topic, or NATS subject prefix (<prefix>.<inputMint>.<outputMint>)")
+	debugEndpoints      = flag.Bool("debug", false, "Serve /debug/pprof and /debug/runtime behind the admin token")
+	configFile          = flag.String("config", "", "JSON file with rpc, rateLimit and protocols overriding those flags; re-read with -pairs on SIGHUP or POST /admin/reload")
+	protocolList        = flag.String("protocols", "", "Comma-separated protocols to route through (empty enables all): pump_amm, raydium_amm, raydium_clmm, raydium_cpmm, meteora_dlmm, whirlpool")
+	commitment          = flag.String("commitment", subscription.CommitmentConfirmed, "Commitment for pool account subscriptions: processed (lowest latency), confirmed or finalized")
+	indexPath           = flag.String("index", "", "Database file holding a local index of every pool, rebuilt by background program scans and used for pool discovery instead of per-pair scans (empty disables)")
+	indexInterval       = flag.Duration("index-interval", indexer.DefaultInterval, "How often -index rescans the programs")
+	indexWatch          = flag.Bool("index-watch", true, "Add pools created between -index scans as the WebSocket reports them, via programSubscribe")
 )
 
 var (
@@ -40,6 +107,9 @@ var (
 
 var (
 	quoteCache *QuoteCache
+	alerts     *alertManager
+	orders     *orderBook
+	reloader   *configReloader
 	startTime  time.Time
 )
 
@@ -55,6 +125,16 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Point the protocols at the configured cluster's programs
+	profile, err := cluster.FromEnv()
+	if err != nil {
+		log.Fatalf("Invalid cluster configuration: %v", err)
+	}
+	cluster.Apply(profile)
+	if profile.Name != cluster.MainnetBeta {
+		log.Printf("Using %s program IDs", profile.Name)
+	}
+
 	// Parse RPC endpoints
 	var endpoints []string
 	if *rpcEndpoints != "" {
@@ -66,29 +146,129 @@ func main() {
 	} else {
 		// Load from .env file
 		endpoints = config.GetRPCEndpoints()
-		if len(endpoints) == 0 {
-			log.Fatalf("No RPC endpoints configured. Set RPC_ENDPOINTS in .env or use -rpc flag")
-		}
+	}
+
+	// The -config file overrides these flags now and on every reload
+	reloader = &configReloader{
+		pairsFile:  *pairsFile,
+		configFile: *configFile,
+		base:       ReloadableConfig{RPC: endpoints, RateLimit: *rateLimit, Protocols: splitList(*protocolList)},
+	}
+	settings, err := reloader.config()
+	if err != nil {
+		log.Fatalf("Invalid -config: %v", err)
+	}
+	if len(settings.RPC) == 0 {
+		log.Fatalf("No RPC endpoints configured. Set RPC_ENDPOINTS in .env or use -rpc flag")
+	}
+
+	network, address, err := parseListen(*listenAddr, *port)
+	if err != nil {
+		log.Fatalf("Invalid -listen: %v", err)
 	}
 
 	log.Printf("Starting SolRoute Quote Service")
-	log.Printf("Port: %d", *port)
+	log.Printf("Listen: %s %s", network, address)
 	log.Printf("Refresh interval: %d seconds", *refreshInterval)
-	log.Printf("RPC endpoints: %d", len(endpoints))
+	log.Printf("RPC endpoints: %d", len(settings.RPC))
 	log.Printf("Slippage: %d bps", *slippageBps)
 
+	clientOpts := sol.DefaultClientOptions()
+	clientOpts.Transport.ProxyURL = *proxyURL
+	clientOpts.Transport.DialTimeout = *dialTimeout
+	clientOpts.Transport.RequestTimeout = *requestTimeout
+
+	if _, err := subscription.ParseCommitment(*commitment); err != nil {
+		log.Fatalf("Invalid -commitment: %v", err)
+	}
+
+	logLevel, err := parseLogLevel(*accessLogLevel)
+	if err != nil {
+		log.Fatalf("Invalid -access-log-level: %v", err)
+	}
+	libLevel, err := parseLogLevel(*libLogLevel)
+	if err != nil {
+		log.Fatalf("Invalid -log-level: %v", err)
+	}
+	logging.SetDefault(libraryLogger(libLevel, *libLogJSON))
+
+	tlsOpts, err := tlsOptionsFromFlags()
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+
+	token := *geyserToken
+	if token == "" {
+		token = os.Getenv("GEYSER_TOKEN")
+	}
+
+	if *indexPath != "" {
+		if poolIndex, err = indexer.OpenIndex(*indexPath); err != nil {
+			log.Fatalf("Failed to open pool index: %v", err)
+		}
+	}
+
 	// Initialize quote cache
-	var err error
 	quoteCache, err = NewQuoteCache(
 		ctx,
-		endpoints,
-		*rateLimit,
+		settings.RPC,
+		settings.RateLimit,
 		time.Duration(*refreshInterval)*time.Second,
 		*slippageBps,
+		clientOpts,
+		*geyserEndpoint,
+		subscription.GeyserOptions{Token: token},
+		subscription.Options{
+			Commitment: *commitment,
+			Reconnect: subscription.ReconnectOptions{
+				MaxDelay:    *reconnectMax,
+				MaxAttempts: *reconnectTries,
+			},
+		},
 	)
 	if err != nil {
 		log.Fatalf("Failed to create quote cache: %v", err)
 	}
+	reloader.qc = quoteCache
+	if poolIndex != nil {
+		poolIndexer := indexer.NewIndexer(liveClient{quoteCache}, poolIndex)
+		if *indexWatch && quoteCache.subscriptionMgr != nil {
+			if err := poolIndexer.Watch(quoteCache.subscriptionMgr); err != nil {
+				log.Printf("Warning: new pools wait for the next index scan: %v", err)
+			}
+		}
+		go poolIndexer.Run(ctx, *indexInterval)
+	}
+	if _, _, err := quoteCache.Reconfigure(settings.RPC, settings.RateLimit, settings.Protocols); err != nil {
+		log.Fatalf("Invalid protocols: %v", err)
+	}
+	quoteCache.SetStalePoolPenalty(*staleSlots, *stalePenaltyBps)
+	quoteCache.SetCoalesceInterval(*coalesce)
+	quoteCache.SetIdleTimeout(*idleTimeout)
+	quoteCache.SetQuoteTTL(*quoteTTL)
+	quoteCache.SetHistoryRetention(*historyWindow)
+	if *publishURL != "" {
+		publisher, err := newQuotePublisher(*publishURL, *publishTopic)
+		if err != nil {
+			log.Fatalf("Failed to configure quote publisher: %v", err)
+		}
+		quoteCache.publisher = publisher
+		go publisher.Run(ctx)
+	}
+	quoteCache.SetMaxSubscriptions(*maxSubs)
+	quoteCache.SetMaxCalculations(*maxCalcs)
+	ladder, err := parseLadder(*ladderFlag)
+	if err != nil {
+		log.Fatalf("Invalid -ladder: %v", err)
+	}
+	quoteCache.SetLadder(ladder)
+	quoteCache.StartStaleRefresh(*staleRefresh)
+	quoteCache.StartHealthChecks(*probeInterval, *probeMaxLatency)
+	quoteCache.SetPoolCacheLimits(subscription.PoolCacheOptions{
+		MaxEntries:      *poolCacheMax,
+		MaxBytes:        *poolCacheMB << 20,
+		DropAccountData: *dropAccountData,
+	})
 
 	// Define quote pairs to monitor
 	quotePairs := []QuotePair{
@@ -105,23 +285,127 @@ func main() {
 			Label:      "USDC->SOL (10 USDC)",
 		},
 	}
+	if *pairsFile != "" {
+		quotePairs, err = loadPairs(*pairsFile)
+		if err != nil {
+			log.Fatalf("Failed to load pairs: %v", err)
+		}
+		log.Printf("Loaded %d monitored pairs from %s", len(quotePairs), *pairsFile)
+	}
+	quoteCache.SetMonitoredPairs(quotePairs)
+
+	var warmPairs []QuotePair
+	if *warmFile != "" {
+		warmPairs, err = loadPairs(*warmFile)
+		if err != nil {
+			log.Fatalf("Failed to load warm-up pairs: %v", err)
+		}
+	}
+
+	// Serve the snapshot's quotes right away and reload its pools before refreshing
+	var snapshot subscription.Snapshot
+	if *snapshotPath != "" {
+		snapshot, err = quoteCache.LoadSnapshot(*snapshotPath)
+		if err != nil {
+			log.Printf("Warning: Failed to load snapshot: %v", err)
+		}
+	}
 
 	// Start periodic refresh in background
-	go quoteCache.StartPeriodicRefresh(ctx, quotePairs)
+	go func() {
+		quoteCache.RestorePools(ctx, snapshot)
+		go quoteCache.Warm(ctx, warmPairs, *warmWorkers)
+		quoteCache.StartPeriodicRefresh(ctx)
+	}()
+
+	alerts = newAlertManager(quoteCache)
+	go alerts.Run(ctx)
+
+	var orderWallet *wallet.Wallet
+	if *walletSource != "" {
+		// Orders posted to /orders sign and send swaps with this wallet, so it
+		// is only loaded when that endpoint is protected
+		if configuredAdminToken() == "" {
+			log.Fatalf("-wallet needs -admin-token or ADMIN_TOKEN: limit orders execute with the wallet")
+		}
+		orderWallet, err = wallet.Load(*walletSource)
+		if err != nil {
+			log.Fatalf("Failed to load wallet: %v", err)
+		}
+		log.Printf("Limit orders execute with wallet %s", orderWallet.PublicKey())
+	}
+	orders = newOrderBook(quoteCache, orderWallet)
+	go orders.Run(ctx)
+
+	if *compareJupiter > 0 {
+		validation = newValidator(quoteCache, jupiter.NewClient(*jupiterURL), *compareJupiter, *maxDeviation)
+		go validation.Run(ctx)
+	}
 
 	// Setup HTTP server
 	mux := http.NewServeMux()
 	mux.HandleFunc("/quote", handleQuote)
+	mux.HandleFunc("/quotes", handleBatchQuotes)
+	mux.HandleFunc("/swap", handleSwap)
+	mux.HandleFunc("/swap-instructions", handleSwapInstructions)
+	mux.HandleFunc("/depth", handleDepth)
+	mux.HandleFunc("/twap", handleTWAP)
 	mux.HandleFunc("/health", handleHealth)
+	mux.HandleFunc("/stats", handleStats)
+	if *debugEndpoints {
+		registerDebug(mux)
+	}
+	mux.HandleFunc("/ws", handleWebSocket)
+	mux.HandleFunc("/pairs", requireAdmin(handlePairs))
+	mux.HandleFunc("/alerts", requireAdmin(handleAlerts))
+	mux.HandleFunc("/orders", requireAdmin(handleOrders))
+	mux.HandleFunc("/admin/cache/flush", requireAdmin(handleFlushCache))
+	mux.HandleFunc("/admin/discover", requireAdmin(handleDiscover))
+	mux.HandleFunc("/admin/pools/refresh", requireAdmin(handleRefreshPool))
+	mux.HandleFunc("/admin/reload", requireAdmin(handleReload))
+	mux.HandleFunc("/stream", handleStream)
 	mux.HandleFunc("/", handleRoot)
+	if *jupiterAPI {
+		mux.HandleFunc("/v6/quote", handleJupiterQuote)
+		mux.HandleFunc("/v6/swap", handleJupiterSwap)
+	}
+	if validation != nil {
+		mux.HandleFunc("/validation", handleValidation)
+	}
+
+	var handler http.Handler = corsMiddleware(mux)
+	if *compress {
+		handler = compressionMiddleware(handler, compressionOptions{MinSize: *compressMin, Types: splitList(*compressTypes)})
+	}
+	if *accessLog {
+		handler = accessLogMiddleware(handler, accessLogOptions{Level: logLevel, SampleRate: *accessLogSample})
+	}
 
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", *port),
-		Handler: corsMiddleware(mux),
+		Addr:    address,
+		Handler: handler,
 	}
 
+	// Reload the -pairs and -config files on SIGHUP
+	go func() {
+		hupChan := make(chan os.Signal, 1)
+		signal.Notify(hupChan, syscall.SIGHUP)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hupChan:
+				result, err := reloader.Reload(ctx)
+				logReload("SIGHUP", result, err)
+			}
+		}
+	}()
+
 	// Graceful shutdown
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
+
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 		<-sigChan
@@ -133,18 +417,68 @@ func main() {
 		if err := server.Shutdown(shutdownCtx); err != nil {
 			log.Printf("Server shutdown error: %v", err)
 		}
+		if *snapshotPath != "" {
+			if err := quoteCache.SaveSnapshot(*snapshotPath); err != nil {
+				log.Printf("Failed to save snapshot: %v", err)
+			}
+		}
 		cancel()
+		if poolIndex != nil {
+			// Waits for a scan writing to the index to commit
+			if err := poolIndex.Close(); err != nil {
+				log.Printf("Failed to close pool index: %v", err)
+			}
+		}
 	}()
 
-	log.Printf("Server listening on http://localhost:%d", *port)
+	scheme := "http"
+	for _, address := range splitList(*lookupTables) {
+		if _, err := solana.PublicKeyFromBase58(address); err != nil {
+			log.Fatalf("Invalid -lookup-tables address %s: %v", address, err)
+		}
+	}
+
+	if tlsOpts.enabled() {
+		scheme = "https"
+	}
+	ln, err := listen(network, address)
+	if err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+	log.Printf("Server listening on %s", listenURL(scheme, network, address))
 	log.Printf("Endpoints:")
-	log.Printf("  GET  /quote?input=<mint>&output=<mint>&amount=<amount>&slippageBps=<bps>&dexes=<comma-separated>&excludeDexes=<comma-separated>&minLiquidity=<usd>")
+	log.Printf("  GET  /quote?input=<mint>&output=<mint>&amount=<amount>&slippageBps=<bps>&dexes=<comma-separated>&excludeDexes=<comma-separated>&minLiquidity=<usd>&routes=<n>")
+	log.Printf("  POST /quotes (batch of quote requests)")
+	log.Printf("  POST /swap (unsigned swap transaction)")
+	log.Printf("  POST /swap-instructions (swap instructions for custom transactions)")
+	log.Printf("  GET  /depth?input=<mint>&output=<mint>&amount=<amount> (liquidity depth per protocol)")
+	log.Printf("  GET  /twap?input=<mint>&output=<mint>&amount=<amount>&window=<duration> (time-weighted average)")
 	log.Printf("  GET  /health")
+	log.Printf("  GET  /stats (subscription, pool cache, RPC and discovery counters)")
+	if *debugEndpoints {
+		log.Printf("  GET  /debug/pprof/, /debug/runtime (profiling and runtime stats, admin)")
+	}
+	log.Printf("  GET  /ws (WebSocket quote stream)")
+	if configuredAdminToken() == "" {
+		log.Printf("  Admin endpoints are disabled; set -admin-token or ADMIN_TOKEN to enable them")
+	}
+	log.Printf("  GET|POST|DELETE /pairs (monitored pairs, admin)")
+	log.Printf("  GET|POST|DELETE /alerts (price alert webhooks, admin)")
+	log.Printf("  GET|POST|DELETE /orders (limit orders, admin)")
+	log.Printf("  POST /admin/cache/flush, /admin/discover, /admin/pools/refresh, /admin/reload (admin)")
+	log.Printf("  GET  /stream?pairs=<input>:<output>:<amount>,... (Server-Sent Events)")
 	log.Printf("  GET  /")
+	if *jupiterAPI {
+		log.Printf("  GET  /v6/quote, POST /v6/swap (Jupiter v6 compatible)")
+	}
+	if validation != nil {
+		log.Printf("  GET  /validation (Jupiter comparison every %s)", *compareJupiter)
+	}
 
-	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+	if err := serve(server, ln, tlsOpts); err != http.ErrServerClosed {
 		log.Fatalf("Server error: %v", err)
 	}
+	<-shutdownDone
 
 	log.Println("Server stopped")
 }
@@ -163,7 +497,12 @@ func handleRoot(w http.ResponseWriter, r *http.Request) {
 		"quotes":       allQuotes,
 		"endpoints": map[string]string{
 			"quote":  "/quote?input=<mint>&output=<mint>&amount=<amount>",
+			"swap":   "POST /swap",
+			"depth":  "/depth?input=<mint>&output=<mint>&amount=<amount>",
 			"health": "/health",
+			"stats":  "/stats",
+			"ws":     "/ws",
+			"stream": "/stream?pairs=<input>:<output>:<amount>,...",
 		},
 	}
 
@@ -184,6 +523,7 @@ func handleQuote(w http.ResponseWriter, r *http.Request) {
 	dexesParam := r.URL.Query().Get("dexes")
 	excludeDexesParam := r.URL.Query().Get("excludeDexes")
 	minLiquidityParam := r.URL.Query().Get("minLiquidity")
+	routesParam := r.URL.Query().Get("routes")
 
 	if inputMint == "" || outputMint == "" || amount == "" {
 		writeError(w, "Missing required parameters: input, output, amount", http.StatusBadRequest)
@@ -216,11 +556,24 @@ func handleQuote(w http.ResponseWriter, r *http.Request) {
 		minLiquidityUSD = parsedLiquidity
 	}
 
+	routeCount := 0
+	if routesParam != "" {
+		parsed, err := strconv.Atoi(routesParam)
+		if err != nil || parsed < 1 || parsed > maxRouteOptions {
+			writeError(w, fmt.Sprintf("Invalid routes parameter (must be 1-%d)", maxRouteOptions), http.StatusBadRequest)
+			return
+		}
+		routeCount = parsed
+	}
+
 	// Try to get from cache first (only if no filters applied)
 	var quote *CachedQuote
 	var exists bool
 	if len(dexes) == 0 && len(excludeDexes) == 0 && minLiquidityUSD == 0 {
 		quote, exists = quoteCache.GetQuote(inputMint, outputMint, amount)
+		if !exists {
+			quote, exists = quoteCache.LadderQuote(inputMint, outputMint, amount)
+		}
 	}
 
 	// If not in cache or filters applied, calculate on-demand using pool data
@@ -228,7 +581,7 @@ func handleQuote(w http.ResponseWriter, r *http.Request) {
 		var err error
 		quote, err = quoteCache.GetOrCalculateQuote(r.Context(), inputMint, outputMint, amount, dexes, excludeDexes, minLiquidityUSD)
 		if err != nil {
-			writeError(w, fmt.Sprintf("Failed to calculate quote: %v", err), http.StatusInternalServerError)
+			writeLibraryError(w, fmt.Sprintf("Failed to calculate quote: %v", err), err, http.StatusInternalServerError)
 			return
 		}
 	}
@@ -241,41 +594,55 @@ func handleQuote(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Recalculate threshold with custom slippage
-		outAmount, ok := math.NewIntFromString(quote.OutAmount)
-		if ok {
-			minAmountOut := outAmount.Mul(math.NewInt(int64(10000 - customSlippage))).Quo(math.NewInt(10000))
+		quote = withSlippage(quote, customSlippage)
+	}
 
-			// Create a copy of the quote with updated slippage
-			modifiedQuote := *quote
-			modifiedQuote.SlippageBps = customSlippage
-			modifiedQuote.OtherAmountThreshold = minAmountOut.String()
-			quote = &modifiedQuote
+	if routeCount > 0 {
+		routes, err := quoteCache.GetRoutes(r.Context(), inputMint, outputMint, amount, dexes, excludeDexes, minLiquidityUSD, quote.SlippageBps, routeCount)
+		if err != nil {
+			writeLibraryError(w, fmt.Sprintf("Failed to calculate routes: %v", err), err, http.StatusInternalServerError)
+			return
 		}
+		withRoutes := *quote
+		withRoutes.Routes = routes
+		quote = &withRoutes
+	}
+
+	// Pollers get a 304 until the quote is recalculated
+	etag := quoteETag(quote)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(quote)
+	json.NewEncoder(w).Encode(quoteCache.WithSlotAge(quote))
 }
 
-func handleHealth(w http.ResponseWriter, r *http.Request) {
-	allQuotes := quoteCache.GetAllCached()
-
-	var lastUpdate time.Time
-	for _, quote := range allQuotes {
-		if quote.LastUpdate.After(lastUpdate) {
-			lastUpdate = quote.LastUpdate
-		}
+// withSlippage returns a copy of quote whose threshold uses slippageBps
+func withSlippage(quote *CachedQuote, slippageBps int) *CachedQuote {
+	outAmount, ok := math.NewIntFromString(quote.OutAmount)
+	if !ok {
+		return quote
 	}
+	minAmountOut := outAmount.Mul(math.NewInt(int64(10000 - slippageBps))).Quo(math.NewInt(10000))
 
-	health := HealthResponse{
-		Status:       "healthy",
-		LastUpdate:   lastUpdate,
-		CachedRoutes: len(allQuotes),
-		Uptime:       time.Since(startTime).Round(time.Second).String(),
-	}
+	// Create a copy of the quote with updated slippage
+	modifiedQuote := *quote
+	modifiedQuote.SlippageBps = slippageBps
+	modifiedQuote.OtherAmountThreshold = minAmountOut.String()
+	return &modifiedQuote
+}
+
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	health := quoteCache.Health()
 
 	w.Header().Set("Content-Type", "application/json")
+	if health.Status == StatusUnhealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
 	json.NewEncoder(w).Encode(health)
 }
 
@@ -285,11 +652,38 @@ func writeError(w http.ResponseWriter, message string, statusCode int) {
 	json.NewEncoder(w).Encode(QuoteError{Error: message})
 }
 
+// writeLibraryError writes an error returned by the library with the status
+// errorStatus maps it to, telling clients when to retry retryable errors
+func writeLibraryError(w http.ResponseWriter, message string, err error, fallback int) {
+	if pkg.Retryable(err) {
+		w.Header().Set("Retry-After", "1")
+	}
+	writeError(w, message, errorStatus(err, fallback))
+}
+
+// errorStatus maps the library's error sentinels to HTTP status codes, and
+// other errors to fallback. Rate limiting is checked first so a pair whose
+// discovery was throttled isn't reported as having no pools.
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, pkg.ErrRateLimited), errors.Is(err, pkg.ErrStalePoolData):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, pkg.ErrNoPoolsFound):
+		return http.StatusNotFound
+	case errors.Is(err, pkg.ErrInsufficientLiquidity):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, pkg.ErrUnsupportedProtocol):
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
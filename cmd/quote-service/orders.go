@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/wallet"
+)
+
+// Limit order statuses
+const (
+	OrderOpen      = "open"      // waiting for the limit to be reachable
+	OrderTriggered = "triggered" // reachable; being executed or notified
+	OrderNotified  = "notified"  // reachable and the webhook was called, not executed
+	OrderFilled    = "filled"    // executed with the service wallet
+	OrderFailed    = "failed"    // execution failed
+	OrderExpired   = "expired"   // expired before becoming reachable
+	OrderCancelled = "cancelled"
+)
+
+// orderRetention is how long finished orders stay listed
+const orderRetention = time.Hour
+
+// LimitOrder is a trade to make once a quote reaches its limit
+type LimitOrder struct {
+	ID           string    `json:"id"`
+	InputMint    string    `json:"inputMint"`
+	OutputMint   string    `json:"outputMint"`
+	Amount       string    `json:"amount"`
+	MinOutAmount string    `json:"minOutAmount"` // limit: the least output accepted for Amount
+	ExpiresAt    time.Time `json:"expiresAt"`
+	WebhookURL   string    `json:"webhookUrl,omitempty"`
+	Execute      bool      `json:"execute"` // swap with the service wallet when reachable
+
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	TriggeredAt *time.Time `json:"triggeredAt,omitempty"`
+	OutAmount   string     `json:"outAmount,omitempty"` // quoted output when triggered
+	Signature   string     `json:"signature,omitempty"`
+	Error       string     `json:"error,omitempty"`
+
+	minOut math.Int
+}
+
+func (o *LimitOrder) pair() QuotePair {
+	return QuotePair{InputMint: o.InputMint, OutputMint: o.OutputMint, Amount: o.Amount}
+}
+
+// OrderNotification is the body POSTed to an order's webhook
+type OrderNotification struct {
+	Order *LimitOrder  `json:"order"`
+	Quote *CachedQuote `json:"quote"`
+}
+
+// orderBook watches streamed quotes for limit orders that became reachable
+type orderBook struct {
+	qc      *QuoteCache
+	wallet  *wallet.Wallet // executes orders; nil only notifies
+	watcher *quoteWatcher
+	client  *http.Client
+
+	mu     sync.Mutex
+	orders map[string]*LimitOrder // ID -> order
+}
+
+func newOrderBook(qc *QuoteCache, w *wallet.Wallet) *orderBook {
+	return &orderBook{
+		qc:      qc,
+		wallet:  w,
+		watcher: qc.hub.subscribe(),
+		client:  newWebhookClient(),
+		orders:  make(map[string]*LimitOrder),
+	}
+}
+
+// validate checks a new order and parses its limit
+func (b *orderBook) validate(order *LimitOrder) error {
+	pair := order.pair()
+	if err := normalizePair(&pair); err != nil {
+		return err
+	}
+	minOut, ok := math.NewIntFromString(order.MinOutAmount)
+	if !ok || !minOut.IsPositive() {
+		return fmt.Errorf("invalid minOutAmount %q", order.MinOutAmount)
+	}
+	order.minOut = minOut
+
+	if !order.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("expiresAt must be in the future")
+	}
+	if order.Execute && b.wallet == nil {
+		return fmt.Errorf("execution requires the service to run with -wallet")
+	}
+	if !order.Execute && order.WebhookURL == "" {
+		return fmt.Errorf("an order needs a webhookUrl, execute or both")
+	}
+	if order.WebhookURL != "" {
+		return validWebhookURL(order.WebhookURL)
+	}
+	return nil
+}
+
+// Add registers an order and starts following its pair. An order whose limit
+// is already reachable triggers right away.
+func (b *orderBook) Add(ctx context.Context, order *LimitOrder) error {
+	if err := b.validate(order); err != nil {
+		return err
+	}
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	order.ID = id
+	order.Status = OrderOpen
+	order.CreatedAt = time.Now()
+
+	quote, err := b.qc.Watch(ctx, b.watcher, order.pair())
+	if err != nil {
+		return fmt.Errorf("failed to quote pair: %w", err)
+	}
+
+	b.mu.Lock()
+	b.orders[order.ID] = order
+	b.mu.Unlock()
+
+	b.evaluate(quote)
+	return nil
+}
+
+// Cancel cancels an open order, reporting whether there was one
+func (b *orderBook) Cancel(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	order, exists := b.orders[id]
+	if !exists || order.Status != OrderOpen {
+		return false
+	}
+	order.Status = OrderCancelled
+	b.release(order)
+	return true
+}
+
+// release stops watching an order's pair unless other open orders use it.
+// Callers hold b.mu.
+func (b *orderBook) release(order *LimitOrder) {
+	for _, other := range b.orders {
+		if other.Status == OrderOpen && samePair(other.pair(), order.pair()) {
+			return
+		}
+	}
+	b.qc.Unwatch(b.watcher, order.pair())
+}
+
+// List returns copies of the registered orders
+func (b *orderBook) List() []LimitOrder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	orders := make([]LimitOrder, 0, len(b.orders))
+	for _, order := range b.orders {
+		orders = append(orders, *order)
+	}
+	return orders
+}
+
+// Run evaluates quote updates and expires orders until ctx is done
+func (b *orderBook) Run(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.qc.hub.unsubscribe(b.watcher)
+			return
+		case <-ticker.C:
+			b.expire()
+			// Open orders keep their pools subscribed even when nobody requests quotes
+			b.qc.KeepWatched(b.watcher)
+		case update := <-b.watcher.C:
+			b.evaluate(update.Quote)
+		}
+	}
+}
+
+// expire closes orders past their expiry and forgets finished ones
+func (b *orderBook) expire() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	for id, order := range b.orders {
+		switch {
+		case order.Status == OrderOpen && now.After(order.ExpiresAt):
+			order.Status = OrderExpired
+			b.release(order)
+			log.Printf("Limit order %s expired", id)
+		case order.Status != OrderOpen && order.Status != OrderTriggered && now.Sub(order.ExpiresAt) > orderRetention:
+			delete(b.orders, id)
+		}
+	}
+}
+
+// evaluate triggers the open orders of a quote's pair whose limit it reaches
+func (b *orderBook) evaluate(quote *CachedQuote) {
+	out, ok := math.NewIntFromString(quote.OutAmount)
+	if !ok {
+		return
+	}
+
+	var triggered []*LimitOrder
+	b.mu.Lock()
+	now := time.Now()
+	for _, order := range b.orders {
+		if order.Status != OrderOpen || order.InputMint != quote.InputMint || order.OutputMint != quote.OutputMint || order.Amount != quote.InAmount {
+			continue
+		}
+		if now.After(order.ExpiresAt) || out.LT(order.minOut) {
+			continue
+		}
+		order.Status = OrderTriggered
+		order.TriggeredAt = &now
+		order.OutAmount = quote.OutAmount
+		b.release(order)
+		triggered = append(triggered, order)
+	}
+	b.mu.Unlock()
+
+	for _, order := range triggered {
+		go b.fill(order, quote)
+	}
+}
+
+// fill executes a triggered order when asked to and calls its webhook
+func (b *orderBook) fill(order *LimitOrder, quote *CachedQuote) {
+	ctx := b.qc.ctx
+	status, signature, errMsg := OrderNotified, "", ""
+	if order.Execute {
+		sig, err := b.execute(ctx, order, quote)
+		if err != nil {
+			status, errMsg = OrderFailed, err.Error()
+			log.Printf("Limit order %s failed: %v", order.ID, err)
+		} else {
+			status, signature = OrderFilled, sig
+			log.Printf("Limit order %s filled: %s", order.ID, sig)
+		}
+	}
+
+	b.mu.Lock()
+	order.Status = status
+	order.Signature = signature
+	order.Error = errMsg
+	notification := OrderNotification{Order: copyOrder(order), Quote: b.qc.WithSlotAge(quote)}
+	b.mu.Unlock()
+
+	if order.WebhookURL != "" {
+		postWebhook(ctx, b.client, order.WebhookURL, "Limit order "+order.ID, notification)
+	}
+}
+
+func copyOrder(order *LimitOrder) *LimitOrder {
+	copied := *order
+	return &copied
+}
+
+// execute swaps the order with the service wallet. The limit is the swap's
+// minimum output, so the transaction fails rather than fill below it.
+func (b *orderBook) execute(ctx context.Context, order *LimitOrder, quote *CachedQuote) (string, error) {
+	limited := *quote
+	limited.OtherAmountThreshold = order.MinOutAmount
+
+	tx, _, err := b.qc.BuildSwapTransaction(ctx, &limited, SwapParams{User: b.wallet.PublicKey(), WrapSOL: true})
+	if err != nil {
+		return "", err
+	}
+	if err := b.wallet.SignTransaction(tx); err != nil {
+		return "", err
+	}
+	sig, err := b.qc.client().SendTransaction(ctx, tx, sol.SendOptions{PreflightCommitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return "", err
+	}
+	return sig.String(), nil
+}
+
+// handleOrders lists (GET), places (POST) or cancels (DELETE ?id=) limit orders
+func handleOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(orders.List())
+	case http.MethodPost:
+		var order LimitOrder
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&order); err != nil {
+			writeError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := orders.Add(r.Context(), &order); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Placed limit order %s: %s %s -> at least %s %s", order.ID, order.Amount, order.InputMint[:8], order.MinOutAmount, order.OutputMint[:8])
+
+		orders.mu.Lock()
+		placed := copyOrder(&order)
+		orders.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(placed)
+	case http.MethodDelete:
+		if !orders.Cancel(r.URL.Query().Get("id")) {
+			writeError(w, "Open order not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmossdk.io/math"
+)
+
+// addOrder registers an order without quoting its pair
+func addOrder(b *orderBook, id, amount, minOut, status string, expiresAt time.Time) *LimitOrder {
+	limit, _ := math.NewIntFromString(minOut)
+	order := &LimitOrder{
+		ID:           id,
+		InputMint:    WSOL.String(),
+		OutputMint:   USDC.String(),
+		Amount:       amount,
+		MinOutAmount: minOut,
+		ExpiresAt:    expiresAt,
+		Status:       status,
+		minOut:       limit,
+	}
+	b.orders[id] = order
+	return order
+}
+
+// orderStatus reads an order's status once fill has had time to finish
+func orderStatus(t *testing.T, b *orderBook, id, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		b.mu.Lock()
+		status := b.orders[id].Status
+		b.mu.Unlock()
+		if status == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("order %s is %s, want %s", id, status, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOrderBookEvaluate(t *testing.T) {
+	qc := newTestCache()
+	b := newOrderBook(qc, nil)
+	later := time.Now().Add(time.Hour)
+	reachable := addOrder(b, "reachable", ONE_SOL, "140000000", OrderOpen, later)
+	exact := addOrder(b, "exact", ONE_SOL, "150000000", OrderOpen, later)
+	addOrder(b, "above", ONE_SOL, "150000001", OrderOpen, later)
+	addOrder(b, "other-size", "2000000000", "1", OrderOpen, later)
+	addOrder(b, "expired", ONE_SOL, "1", OrderOpen, time.Now().Add(-time.Minute))
+	addOrder(b, "cancelled", ONE_SOL, "1", OrderCancelled, later)
+
+	quote := cacheQuote(qc, WSOL.String(), USDC.String(), ONE_SOL, "150000000", 100, time.Now())
+	b.evaluate(quote)
+
+	// Notify-only orders finish as notified once triggered
+	orderStatus(t, b, "reachable", OrderNotified)
+	orderStatus(t, b, "exact", OrderNotified)
+	b.mu.Lock()
+	if reachable.OutAmount != "150000000" || reachable.TriggeredAt == nil || exact.TriggeredAt == nil {
+		t.Errorf("triggered order %+v", reachable)
+	}
+	for id, want := range map[string]string{"above": OrderOpen, "other-size": OrderOpen, "expired": OrderOpen, "cancelled": OrderCancelled} {
+		if got := b.orders[id].Status; got != want {
+			t.Errorf("order %s is %s, want %s", id, got, want)
+		}
+	}
+	b.mu.Unlock()
+
+	// Reverse quotes, non-numeric outputs and quotes below the limit trigger nothing
+	b.evaluate(&CachedQuote{InputMint: USDC.String(), OutputMint: WSOL.String(), InAmount: ONE_SOL, OutAmount: "999999999999"})
+	b.evaluate(&CachedQuote{InputMint: WSOL.String(), OutputMint: USDC.String(), InAmount: ONE_SOL, OutAmount: "n/a"})
+	b.evaluate(&CachedQuote{InputMint: WSOL.String(), OutputMint: USDC.String(), InAmount: ONE_SOL, OutAmount: "150000000"})
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if got := b.orders["above"].Status; got != OrderOpen {
+		t.Fatalf("order above the quote is %s", got)
+	}
+}
+
+func TestHandleOrders(t *testing.T) {
+	qc := newTestCache()
+	orders = newOrderBook(qc, nil)
+	addOrder(orders, "open", ONE_SOL, "1", OrderOpen, time.Now().Add(time.Hour))
+
+	rec := httptest.NewRecorder()
+	handleOrders(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	var listed []LimitOrder
+	if err := json.NewDecoder(rec.Body).Decode(&listed); err != nil || len(listed) != 1 || listed[0].ID != "open" {
+		t.Fatalf("listed %+v, %v", listed, err)
+	}
+
+	// Without -wallet an order must name a webhook
+	rec = httptest.NewRecorder()
+	body := `{"inputMint":"` + WSOL.String() + `","outputMint":"` + USDC.String() + `","amount":"1000000000","minOutAmount":"1","expiresAt":"2100-01-01T00:00:00Z","execute":true}`
+	handleOrders(rec, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("execute without a wallet: status %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handleOrders(rec, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("{")))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body: status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleOrders(rec, httptest.NewRequest(http.MethodDelete, "/orders?id=open", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("cancel: status %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handleOrders(rec, httptest.NewRequest(http.MethodDelete, "/orders?id=open", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("second cancel: status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleOrders(rec, httptest.NewRequest(http.MethodPut, "/orders", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("PUT: status %d", rec.Code)
+	}
+}
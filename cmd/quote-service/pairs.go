@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+)
+
+// refreshTick is how often monitored pairs are checked for a due refresh,
+// and so the shortest per-pair refresh interval
+const refreshTick = time.Second
+
+// loadPairs reads a JSON array of monitored pairs, e.g.
+// [{"inputMint": "...", "outputMint": "...", "amount": "1000000000", "label": "SOL->USDC",
+// "refreshInterval": "5s", "slippageBps": 30}]
+func loadPairs(path string) ([]QuotePair, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pairs file: %w", err)
+	}
+	var pairs []QuotePair
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return nil, fmt.Errorf("failed to parse pairs file %s: %w", path, err)
+	}
+	for i := range pairs {
+		if err := normalizePair(&pairs[i]); err != nil {
+			return nil, fmt.Errorf("pair %d in %s: %w", i, path, err)
+		}
+	}
+	return pairs, nil
+}
+
+// normalizePair validates a pair and fills in a default label
+func normalizePair(pair *QuotePair) error {
+	if _, err := solana.PublicKeyFromBase58(pair.InputMint); err != nil {
+		return fmt.Errorf("invalid inputMint %q: %w", pair.InputMint, err)
+	}
+	if _, err := solana.PublicKeyFromBase58(pair.OutputMint); err != nil {
+		return fmt.Errorf("invalid outputMint %q: %w", pair.OutputMint, err)
+	}
+	if amount, ok := math.NewIntFromString(pair.Amount); !ok || !amount.IsPositive() {
+		return fmt.Errorf("invalid amount %q", pair.Amount)
+	}
+	if pair.Label == "" {
+		pair.Label = fmt.Sprintf("%s->%s (%s)", pair.InputMint[:8], pair.OutputMint[:8], pair.Amount)
+	}
+	if pair.RefreshInterval != "" {
+		interval, err := time.ParseDuration(pair.RefreshInterval)
+		if err != nil || interval < refreshTick {
+			return fmt.Errorf("invalid refreshInterval %q: expected a duration of at least %v", pair.RefreshInterval, refreshTick)
+		}
+		pair.interval = interval
+	}
+	if pair.SlippageBps != nil && (*pair.SlippageBps < 0 || *pair.SlippageBps > 10000) {
+		return fmt.Errorf("invalid slippageBps %d (must be 0-10000)", *pair.SlippageBps)
+	}
+	return normalizeLadder(pair)
+}
+
+func samePair(a, b QuotePair) bool {
+	return a.InputMint == b.InputMint && a.OutputMint == b.OutputMint && a.Amount == b.Amount
+}
+
+// SetMonitoredPairs replaces the pairs refreshed periodically
+func (qc *QuoteCache) SetMonitoredPairs(pairs []QuotePair) {
+	qc.monitoredMu.Lock()
+	defer qc.monitoredMu.Unlock()
+	qc.monitored = append([]QuotePair(nil), pairs...)
+}
+
+// MonitoredPairs returns a copy of the pairs refreshed periodically
+func (qc *QuoteCache) MonitoredPairs() []QuotePair {
+	qc.monitoredMu.RLock()
+	defer qc.monitoredMu.RUnlock()
+	return append([]QuotePair(nil), qc.monitored...)
+}
+
+// AddMonitoredPair starts refreshing a pair and quotes it right away in the
+// background. An already monitored pair takes the new label, refresh interval,
+// slippage and ladder. It reports false if the pair was already monitored unchanged.
+func (qc *QuoteCache) AddMonitoredPair(ctx context.Context, pair QuotePair) bool {
+	qc.monitoredMu.Lock()
+	for i, existing := range qc.monitored {
+		if samePair(existing, pair) {
+			changed := existing.Label != pair.Label || existing.interval != pair.interval ||
+				(existing.SlippageBps == nil) != (pair.SlippageBps == nil) ||
+				(pair.SlippageBps != nil && *existing.SlippageBps != *pair.SlippageBps) ||
+				strings.Join(existing.Ladder, ",") != strings.Join(pair.Ladder, ",")
+			qc.monitored[i] = pair
+			qc.monitoredMu.Unlock()
+			return changed
+		}
+	}
+	qc.monitored = append(qc.monitored, pair)
+	qc.monitoredMu.Unlock()
+
+	go qc.RefreshAll(ctx, qc.withLadders([]QuotePair{pair}))
+	return true
+}
+
+// RemoveMonitoredPair stops refreshing a pair. Its cached quote stays until
+// its pools are evicted. It reports false if the pair was not monitored.
+func (qc *QuoteCache) RemoveMonitoredPair(pair QuotePair) bool {
+	qc.monitoredMu.Lock()
+	defer qc.monitoredMu.Unlock()
+	for i, existing := range qc.monitored {
+		if samePair(existing, pair) {
+			qc.monitored = append(qc.monitored[:i], qc.monitored[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// handlePairs lists (GET), adds (POST) or removes (DELETE) monitored pairs.
+// POST and DELETE take a pair object or an array of them. Changes last until
+// restart or the next reload; edit the -pairs file to keep them.
+func handlePairs(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(quoteCache.MonitoredPairs())
+		return
+	}
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pairs, err := decodePairs(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changed := 0
+	for _, pair := range pairs {
+		if r.Method == http.MethodPost {
+			if quoteCache.AddMonitoredPair(quoteCache.ctx, pair) {
+				log.Printf("Monitoring pair %s", pair.Label)
+				changed++
+			}
+		} else if quoteCache.RemoveMonitoredPair(pair) {
+			log.Printf("Stopped monitoring pair %s", pair.Label)
+			changed++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"changed": changed,
+		"pairs":   quoteCache.MonitoredPairs(),
+	})
+}
+
+// decodePairs reads a pair object or an array of pairs from a request body
+func decodePairs(body io.Reader) ([]QuotePair, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	var pairs []QuotePair
+	if err := json.Unmarshal(raw, &pairs); err != nil {
+		var pair QuotePair
+		if err := json.Unmarshal(raw, &pair); err != nil {
+			return nil, fmt.Errorf("invalid request body: expected a pair or an array of pairs")
+		}
+		pairs = []QuotePair{pair}
+	}
+	for i := range pairs {
+		if err := normalizePair(&pairs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return pairs, nil
+}
+
+// configuredAdminToken returns -admin-token, or ADMIN_TOKEN when the flag is empty
+func configuredAdminToken() string {
+	if *adminToken != "" {
+		return *adminToken
+	}
+	return os.Getenv("ADMIN_TOKEN")
+}
+
+// requireAdmin rejects requests without the admin bearer token. Without a
+// configured token admin endpoints are disabled rather than left open.
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := configuredAdminToken()
+		if token == "" {
+			writeError(w, "Admin endpoints are disabled: start the service with -admin-token or ADMIN_TOKEN", http.StatusForbidden)
+			return
+		}
+		got := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+token)) != 1 {
+			writeError(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
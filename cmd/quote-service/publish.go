@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	publishBuffer  = 1024 // quote events queued before new ones are dropped
+	publishBatch   = 100  // most events sent in one write or request
+	publishTimeout = 5 * time.Second
+)
+
+// QuoteEvent is the message published for every quote recalculation
+type QuoteEvent struct {
+	InputMint            string      `json:"inputMint"`
+	OutputMint           string      `json:"outputMint"`
+	InAmount             string      `json:"inAmount"`
+	OutAmount            string      `json:"outAmount"`
+	OtherAmountThreshold string      `json:"otherAmountThreshold"`
+	SlippageBps          int         `json:"slippageBps"`
+	PriceImpact          string      `json:"priceImpact,omitempty"`
+	RoutePlan            []RoutePlan `json:"routePlan"`
+	ContextSlot          uint64      `json:"contextSlot,omitempty"`
+	Timestamp            time.Time   `json:"timestamp"`
+}
+
+// quoteSink delivers a batch of quote events to a message broker
+type quoteSink interface {
+	send(ctx context.Context, events []QuoteEvent) error
+	close() error
+}
+
+// quotePublisher queues quote recalculations and forwards them to a broker
+// without ever blocking the refresh path
+type quotePublisher struct {
+	sink    quoteSink
+	target  string // broker URL with credentials removed, for logs
+	events  chan QuoteEvent
+	dropped uint64
+}
+
+// newQuotePublisher creates a publisher for a nats:// URL or a Kafka REST
+// Proxy at kafka+http:// or kafka+https://. The topic is the Kafka topic or
+// the NATS subject prefix.
+func newQuotePublisher(rawURL, topic string) (*quotePublisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid publish URL: %w", err)
+	}
+	if topic == "" {
+		return nil, fmt.Errorf("publish topic is required")
+	}
+
+	var sink quoteSink
+	switch u.Scheme {
+	case "nats":
+		sink = newNATSSink(u, topic)
+	case "kafka+http", "kafka+https":
+		sink = newKafkaRESTSink(u, topic)
+	default:
+		return nil, fmt.Errorf("unsupported publish scheme %q (use nats, kafka+http or kafka+https)", u.Scheme)
+	}
+
+	target := *u
+	target.User = nil
+	return &quotePublisher{
+		sink:   sink,
+		target: target.String(),
+		events: make(chan QuoteEvent, publishBuffer),
+	}, nil
+}
+
+// publish queues a quote, dropping it when the broker has fallen behind
+func (p *quotePublisher) publish(quote *CachedQuote) {
+	event := QuoteEvent{
+		InputMint:            quote.InputMint,
+		OutputMint:           quote.OutputMint,
+		InAmount:             quote.InAmount,
+		OutAmount:            quote.OutAmount,
+		OtherAmountThreshold: quote.OtherAmountThreshold,
+		SlippageBps:          quote.SlippageBps,
+		PriceImpact:          quote.PriceImpact,
+		RoutePlan:            quote.RoutePlan,
+		ContextSlot:          quote.ContextSlot,
+		Timestamp:            quote.LastUpdate,
+	}
+	select {
+	case p.events <- event:
+	default:
+		atomic.AddUint64(&p.dropped, 1)
+	}
+}
+
+// Run sends queued events in batches until ctx is cancelled
+func (p *quotePublisher) Run(ctx context.Context) {
+	defer p.sink.close()
+	log.Printf("Publishing quote updates to %s", p.target)
+
+	failing := false
+	batch := make([]QuoteEvent, 0, publishBatch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-p.events:
+			batch = append(batch[:0], event)
+		}
+	drain:
+		for len(batch) < publishBatch {
+			select {
+			case event := <-p.events:
+				batch = append(batch, event)
+			default:
+				break drain
+			}
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+		err := p.sink.send(sendCtx, batch)
+		cancel()
+		switch {
+		case err != nil && !failing:
+			log.Printf("Failed to publish %d quote updates to %s: %v", len(batch), p.target, err)
+			failing = true
+		case err == nil && failing:
+			log.Printf("Publishing to %s recovered (%d updates dropped so far)", p.target, atomic.LoadUint64(&p.dropped))
+			failing = false
+		}
+	}
+}
+
+// natsSink publishes each event to <prefix>.<inputMint>.<outputMint> over the
+// NATS client protocol, reconnecting on the next send after a failure
+type natsSink struct {
+	addr   string
+	user   *url.Userinfo
+	prefix string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newNATSSink(u *url.URL, prefix string) *natsSink {
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &natsSink{addr: addr, user: u.User, prefix: prefix}
+}
+
+func (s *natsSink) send(ctx context.Context, events []QuoteEvent) error {
+	var buf bytes.Buffer
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode quote event: %w", err)
+		}
+		fmt.Fprintf(&buf, "PUB %s.%s.%s %d\r\n", s.prefix, event.InputMint, event.OutputMint, len(payload))
+		buf.Write(payload)
+		buf.WriteString("\r\n")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	}
+	if _, err := s.conn.Write(buf.Bytes()); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("failed to write to NATS: %w", err)
+	}
+	return nil
+}
+
+// connect dials the server and completes the INFO/CONNECT handshake; s.mu
+// must be held
+func (s *natsSink) connect(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected NATS greeting %q: %v", strings.TrimSpace(line), err)
+	}
+
+	options := map[string]interface{}{"verbose": false, "pedantic": false, "name": "quote-service", "lang": "go"}
+	if s.user != nil {
+		if password, ok := s.user.Password(); ok {
+			options["user"] = s.user.Username()
+			options["pass"] = password
+		} else {
+			options["auth_token"] = s.user.Username()
+		}
+	}
+	connect, _ := json.Marshal(options)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to send NATS handshake: %w", err)
+	}
+	line, err = reader.ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "PONG" {
+		conn.Close()
+		return fmt.Errorf("NATS handshake rejected %q: %v", strings.TrimSpace(line), err)
+	}
+	conn.SetDeadline(time.Time{})
+
+	s.conn = conn
+	go s.serve(conn, reader)
+	return nil
+}
+
+// serve answers server PINGs and reports server errors until conn closes
+func (s *natsSink) serve(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			s.mu.Lock()
+			if s.conn == conn {
+				conn.Close()
+				s.conn = nil
+			}
+			s.mu.Unlock()
+			return
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PING":
+			s.mu.Lock()
+			conn.Write([]byte("PONG\r\n"))
+			s.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.Printf("NATS server error: %s", line)
+		}
+	}
+}
+
+func (s *natsSink) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// kafkaRESTSink produces events to a Kafka topic through a Confluent-compatible
+// REST Proxy, keyed by pair so each pair's updates stay in one partition
+type kafkaRESTSink struct {
+	endpoint string
+	client   *http.Client
+	user     *url.Userinfo
+}
+
+func newKafkaRESTSink(u *url.URL, topic string) *kafkaRESTSink {
+	base := *u
+	base.Scheme = strings.TrimPrefix(u.Scheme, "kafka+")
+	base.User = nil
+	return &kafkaRESTSink{
+		endpoint: strings.TrimSuffix(base.String(), "/") + "/topics/" + url.PathEscape(topic),
+		client:   &http.Client{Timeout: publishTimeout},
+		user:     u.User,
+	}
+}
+
+func (s *kafkaRESTSink) send(ctx context.Context, events []QuoteEvent) error {
+	type record struct {
+		Key   string     `json:"key"`
+		Value QuoteEvent `json:"value"`
+	}
+	records := make([]record, len(events))
+	for i, event := range events {
+		records[i] = record{Key: event.InputMint + "/" + event.OutputMint, Value: event}
+	}
+	body, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return fmt.Errorf("failed to encode quote events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid Kafka REST request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	if s.user != nil {
+		password, _ := s.user.Password()
+		req.SetBasicAuth(s.user.Username(), password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Kafka REST Proxy: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Kafka REST Proxy answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *kafkaRESTSink) close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"soltrading/pkg"
+	"soltrading/pkg/router"
+)
+
+// stubProtocol finds no pools, failing every lookup with err when it is set
+type stubProtocol struct{ err error }
+
+func (p stubProtocol) ProtocolName() pkg.ProtocolName { return "stub" }
+
+func (p stubProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+	return nil, p.err
+}
+
+func (p stubProtocol) FetchPoolByID(ctx context.Context, poolID string) (pkg.Pool, error) {
+	return nil, p.err
+}
+
+func (p stubProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	return nil, p.err
+}
+
+func (p stubProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	return pkg.PoolPage{}, p.err
+}
+
+// newTestCache returns a cache without RPC or update stream that discovers
+// pools through protocols, and installs it as the handlers' cache
+func newTestCache(protocols ...pkg.Protocol) *QuoteCache {
+	quoteCache = &QuoteCache{
+		cache:        make(map[string]*CachedQuote),
+		poolToQuotes: make(map[string][]QuotePair),
+		router:       router.NewSimpleRouter(protocols...),
+		slippageBps:  50,
+		hub:          newQuoteHub(),
+		ctx:          context.Background(),
+	}
+	return quoteCache
+}
+
+// cacheQuote stores a quote as if it had been calculated at lastUpdate
+func cacheQuote(qc *QuoteCache, inputMint, outputMint, inAmount, outAmount string, slot uint64, lastUpdate time.Time) *CachedQuote {
+	quote := &CachedQuote{
+		InputMint:   inputMint,
+		OutputMint:  outputMint,
+		InAmount:    inAmount,
+		OutAmount:   outAmount,
+		SlippageBps: 50,
+		LastUpdate:  lastUpdate,
+		ContextSlot: slot,
+		RoutePlan: []RoutePlan{{
+			Protocol:   "stub",
+			PoolID:     "pool-" + inAmount,
+			InputMint:  inputMint,
+			OutputMint: outputMint,
+			InAmount:   inAmount,
+			OutAmount:  outAmount,
+		}},
+	}
+	quote = withSlippage(quote, quote.SlippageBps)
+	qc.cache[qc.getCacheKey(inputMint, outputMint, inAmount)] = quote
+	return quote
+}
+
+func getQuote(query string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/quote?"+query, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	handleQuote(rec, req)
+	return rec
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("fetch: %w", pkg.ErrRateLimited), http.StatusServiceUnavailable},
+		{pkg.ErrStalePoolData, http.StatusServiceUnavailable},
+		{fmt.Errorf("%w for this pair", pkg.ErrNoPoolsFound), http.StatusNotFound},
+		// Throttled discovery also finds no pools; the retryable status wins
+		{fmt.Errorf("%w: %w", pkg.ErrNoPoolsFound, pkg.ErrRateLimited), http.StatusServiceUnavailable},
+		{pkg.ErrInsufficientLiquidity, http.StatusUnprocessableEntity},
+		{pkg.ErrUnsupportedProtocol, http.StatusBadRequest},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := errorStatus(tt.err, http.StatusInternalServerError); got != tt.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWithSlippage(t *testing.T) {
+	quote := &CachedQuote{OutAmount: "1000000", SlippageBps: 50, OtherAmountThreshold: "995000"}
+
+	adjusted := withSlippage(quote, 100)
+	if adjusted.SlippageBps != 100 || adjusted.OtherAmountThreshold != "990000" {
+		t.Fatalf("got slippage %d threshold %s, want 100 and 990000", adjusted.SlippageBps, adjusted.OtherAmountThreshold)
+	}
+	if quote.SlippageBps != 50 || quote.OtherAmountThreshold != "995000" {
+		t.Fatal("withSlippage modified the cached quote")
+	}
+	if zero := withSlippage(quote, 10000); zero.OtherAmountThreshold != "0" {
+		t.Fatalf("full slippage threshold %s, want 0", zero.OtherAmountThreshold)
+	}
+
+	invalid := &CachedQuote{OutAmount: "not a number"}
+	if withSlippage(invalid, 100) != invalid {
+		t.Fatal("a quote without a numeric output should be returned unchanged")
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `W/"100-abc"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{etag, true},
+		{`"100-abc"`, true}, // weak comparison ignores W/
+		{`"100-def", W/"100-abc"`, true},
+		{`  W/"100-abc"  `, true},
+		{"*", true},
+		{`W/"100-def"`, false},
+		{`"100-ab"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestHandleQuoteCached(t *testing.T) {
+	qc := newTestCache()
+	cacheQuote(qc, WSOL.String(), USDC.String(), ONE_SOL, "150000000", 100, time.Now())
+	query := fmt.Sprintf("input=%s&output=%s&amount=%s", WSOL, USDC, ONE_SOL)
+
+	rec := getQuote(query, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var quote CachedQuote
+	if err := json.NewDecoder(rec.Body).Decode(&quote); err != nil {
+		t.Fatal(err)
+	}
+	if quote.OutAmount != "150000000" || quote.OtherAmountThreshold != "149250000" || quote.Age == "" {
+		t.Fatalf("unexpected quote %+v", quote)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+
+	// An unchanged quote is not sent again
+	rec = getQuote(query, http.Header{"If-None-Match": {etag}})
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("revalidation got status %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+
+	// Custom slippage is a different representation
+	rec = getQuote(query+"&slippageBps=100", http.Header{"If-None-Match": {etag}})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("slippage request got status %d, ETag %s", rec.Code, rec.Header().Get("ETag"))
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&quote); err != nil {
+		t.Fatal(err)
+	}
+	if quote.SlippageBps != 100 || quote.OtherAmountThreshold != "148500000" {
+		t.Fatalf("got slippage %d threshold %s", quote.SlippageBps, quote.OtherAmountThreshold)
+	}
+}
+
+func TestHandleQuoteInvalidRequests(t *testing.T) {
+	qc := newTestCache()
+	cacheQuote(qc, WSOL.String(), USDC.String(), ONE_SOL, "150000000", 100, time.Now())
+	pair := fmt.Sprintf("input=%s&output=%s&amount=%s", WSOL, USDC, ONE_SOL)
+
+	for _, query := range []string{
+		fmt.Sprintf("input=%s&output=%s", WSOL, USDC),
+		pair + "&slippageBps=10001",
+		pair + "&slippageBps=abc",
+		pair + "&minLiquidity=-1",
+		pair + "&routes=0",
+		pair + fmt.Sprintf("&routes=%d", maxRouteOptions+1),
+	} {
+		if rec := getQuote(query, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handleQuote(rec, httptest.NewRequest(http.MethodPost, "/quote?"+pair, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST: status %d, want 405", rec.Code)
+	}
+}
+
+func TestHandleQuoteLibraryErrors(t *testing.T) {
+	query := fmt.Sprintf("input=%s&output=%s&amount=%s", WSOL, USDC, ONE_SOL)
+
+	newTestCache(stubProtocol{})
+	rec := getQuote(query, nil)
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Retry-After") != "" {
+		t.Fatalf("no pools: status %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	newTestCache(stubProtocol{err: fmt.Errorf("getProgramAccounts: %w", pkg.ErrRateLimited)})
+	rec = getQuote(query, nil)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("rate limited: status %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	var body QuoteError
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error == "" {
+		t.Fatalf("error body %+v, %v", body, err)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/config"
+	"soltrading/pkg/sol"
+)
+
+// liveClient calls whichever RPC client the cache uses at the time, so
+// long-lived users such as protocols and the stale refresher follow reloads
+type liveClient struct {
+	qc *QuoteCache
+}
+
+var _ sol.SolClient = liveClient{}
+
+func (c liveClient) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	return c.qc.client().GetAccountInfoWithOpts(ctx, account)
+}
+
+func (c liveClient) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey) (*rpc.GetMultipleAccountsResult, error) {
+	return c.qc.client().GetMultipleAccountsWithOpts(ctx, accounts)
+}
+
+func (c liveClient) GetProgramAccountsWithOpts(ctx context.Context, programID solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (rpc.GetProgramAccountsResult, error) {
+	return c.qc.client().GetProgramAccountsWithOpts(ctx, programID, opts)
+}
+
+func (c liveClient) GetTokenAccountsByOwner(ctx context.Context, owner solana.PublicKey, cfg *rpc.GetTokenAccountsConfig, opts *rpc.GetTokenAccountsOpts) (*rpc.GetTokenAccountsResult, error) {
+	return c.qc.client().GetTokenAccountsByOwner(ctx, owner, cfg, opts)
+}
+
+func (c liveClient) GetTokenAccountBalance(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetTokenAccountBalanceResult, error) {
+	return c.qc.client().GetTokenAccountBalance(ctx, account, commitment)
+}
+
+func (c liveClient) GetBalance(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetBalanceResult, error) {
+	return c.qc.client().GetBalance(ctx, account, commitment)
+}
+
+func (c liveClient) GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error) {
+	return c.qc.client().GetLatestBlockhash(ctx, commitment)
+}
+
+func (c liveClient) GetClock(ctx context.Context) (*sol.Clock, error) {
+	return c.qc.client().GetClock(ctx)
+}
+
+// ReloadableConfig is the -config file: settings applied at startup and again
+// on every reload. Empty fields keep the value given by flags.
+type ReloadableConfig struct {
+	RPC       []string `json:"rpc,omitempty"`
+	RateLimit int      `json:"rateLimit,omitempty"`
+	Protocols []string `json:"protocols,omitempty"` // enabled protocols; empty enables all
+}
+
+func loadReloadableConfig(path string) (ReloadableConfig, error) {
+	var cfg ReloadableConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if cfg.RateLimit < 0 {
+		return cfg, fmt.Errorf("invalid rateLimit %d", cfg.RateLimit)
+	}
+	return cfg, nil
+}
+
+// ReloadResult reports what a reload changed
+type ReloadResult struct {
+	PairsAdded       int      `json:"pairsAdded"`
+	PairsUpdated     int      `json:"pairsUpdated"`
+	PairsRemoved     int      `json:"pairsRemoved"`
+	RPCEndpoints     int      `json:"rpcEndpoints"`
+	RPCChanged       bool     `json:"rpcChanged"`
+	RateLimit        int      `json:"rateLimit"`
+	Protocols        []string `json:"protocols"`
+	ProtocolsChanged bool     `json:"protocolsChanged"`
+}
+
+// sameSet reports whether a and b hold the same strings in any order
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+// Reconfigure switches to new RPC endpoints, rate limit and enabled protocols.
+// Calculations in flight finish on the clients they started with; cached
+// quotes, subscriptions and the update stream are kept. It reports whether the
+// endpoints and the protocols changed.
+func (qc *QuoteCache) Reconfigure(endpoints []string, rateLimit int, enabled []string) (rpcChanged, protocolsChanged bool, err error) {
+	if len(endpoints) == 0 {
+		return false, false, fmt.Errorf("no RPC endpoints configured")
+	}
+
+	qc.clientMu.RLock()
+	solClient, rpcPool := qc.solClient, qc.rpcPool
+	rpcChanged = !sameSet(endpoints, qc.endpoints)
+	rateChanged := rateLimit != qc.rateLimit
+	protocolsChanged = !sameSet(enabled, qc.enabled)
+	qc.clientMu.RUnlock()
+
+	if rpcChanged {
+		headers, err := config.GetRPCHeaders()
+		if err != nil {
+			return false, false, err
+		}
+		solClient, rpcPool, err = newRPCClients(qc.ctx, endpoints, rateLimit, qc.clientOpts, headers)
+		if err != nil {
+			return false, false, err
+		}
+	} else if rateChanged {
+		for _, client := range poolClients(solClient, rpcPool) {
+			client.SetRateLimit(rateLimit)
+		}
+	}
+
+	var protocols []pkg.Protocol
+	if protocolsChanged {
+		protocols, err = newProtocols(liveClient{qc}, enabled)
+		if err != nil {
+			return false, false, err
+		}
+		protocols = qc.health.track(protocols)
+	}
+
+	qc.clientMu.Lock()
+	qc.solClient, qc.rpcPool = solClient, rpcPool
+	qc.endpoints, qc.rateLimit = endpoints, rateLimit
+	if protocolsChanged {
+		r := *qc.router
+		r.Protocols = protocols
+		qc.router = &r
+		qc.enabled = enabled
+	}
+	qc.clientMu.Unlock()
+
+	if rpcChanged {
+		qc.health.setClients(poolClients(solClient, rpcPool))
+	}
+	return rpcChanged, protocolsChanged, nil
+}
+
+// syncPairs makes the monitored pairs match pairs. Pairs that stay keep
+// their quotes and subscriptions.
+func (qc *QuoteCache) syncPairs(ctx context.Context, pairs []QuotePair) (added, updated, removed int) {
+	current := qc.MonitoredPairs()
+	contains := func(list []QuotePair, pair QuotePair) bool {
+		for _, p := range list {
+			if samePair(p, pair) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, pair := range current {
+		if !contains(pairs, pair) && qc.RemoveMonitoredPair(pair) {
+			removed++
+		}
+	}
+	for _, pair := range pairs {
+		if !qc.AddMonitoredPair(ctx, pair) {
+			continue
+		}
+		if contains(current, pair) {
+			updated++
+		} else {
+			added++
+		}
+	}
+	return added, updated, removed
+}
+
+// configReloader re-reads the -pairs and -config files and applies them
+type configReloader struct {
+	mu         sync.Mutex
+	qc         *QuoteCache
+	pairsFile  string
+	configFile string
+	base       ReloadableConfig // from flags and the environment
+}
+
+// config returns the base settings overridden by the -config file
+func (r *configReloader) config() (ReloadableConfig, error) {
+	cfg := r.base
+	if r.configFile == "" {
+		return cfg, nil
+	}
+	file, err := loadReloadableConfig(r.configFile)
+	if err != nil {
+		return cfg, err
+	}
+	if len(file.RPC) > 0 {
+		cfg.RPC = file.RPC
+	}
+	if file.RateLimit > 0 {
+		cfg.RateLimit = file.RateLimit
+	}
+	if len(file.Protocols) > 0 {
+		cfg.Protocols = file.Protocols
+	}
+	return cfg, nil
+}
+
+// Reload applies the current files. Both are read and validated before
+// anything changes, so a broken file leaves the running configuration alone.
+func (r *configReloader) Reload(ctx context.Context) (ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result ReloadResult
+	cfg, err := r.config()
+	if err != nil {
+		return result, err
+	}
+	var pairs []QuotePair
+	if r.pairsFile != "" {
+		if pairs, err = loadPairs(r.pairsFile); err != nil {
+			return result, err
+		}
+	}
+
+	result.RPCChanged, result.ProtocolsChanged, err = r.qc.Reconfigure(cfg.RPC, cfg.RateLimit, cfg.Protocols)
+	if err != nil {
+		return result, err
+	}
+	result.RPCEndpoints = len(cfg.RPC)
+	result.RateLimit = cfg.RateLimit
+	for _, proto := range r.qc.currentRouter().Protocols {
+		result.Protocols = append(result.Protocols, string(proto.ProtocolName()))
+	}
+
+	if r.pairsFile != "" {
+		result.PairsAdded, result.PairsUpdated, result.PairsRemoved = r.qc.syncPairs(ctx, pairs)
+	}
+	return result, nil
+}
+
+// logReload reports the outcome of a reload
+func logReload(trigger string, result ReloadResult, err error) {
+	if err != nil {
+		log.Printf("Reload (%s) failed, keeping the running configuration: %v", trigger, err)
+		return
+	}
+	log.Printf("Reloaded configuration (%s): pairs +%d ~%d -%d, %d RPC endpoints (changed: %v), rate limit %d/s, protocols %s",
+		trigger, result.PairsAdded, result.PairsUpdated, result.PairsRemoved,
+		result.RPCEndpoints, result.RPCChanged, result.RateLimit, strings.Join(result.Protocols, ","))
+}
+
+// handleReload re-reads the -pairs and -config files (POST)
+func handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result, err := reloader.Reload(quoteCache.ctx)
+	logReload("admin", result, err)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
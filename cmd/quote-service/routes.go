@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"soltrading/pkg"
+)
+
+// maxRouteOptions caps the routes=N parameter
+const maxRouteOptions = 10
+
+// pairPools returns the known pools of a token pair: the subscribed pools when
+// streaming, otherwise freshly discovered ones
+func (qc *QuoteCache) pairPools(ctx context.Context, inputMint, outputMint string) ([]pkg.Pool, error) {
+	if qc.subscriptionMgr != nil {
+		var pools []pkg.Pool
+		for _, pool := range qc.subscriptionMgr.GetAllPools() {
+			baseMint, quoteMint := pool.GetTokens()
+			if pairKey(baseMint, quoteMint) == pairKey(inputMint, outputMint) {
+				pools = append(pools, pool)
+			}
+		}
+		if len(pools) > 0 {
+			return pools, nil
+		}
+	}
+
+	// Discover on a copy so the shared router's pools are left alone
+	r := *qc.currentRouter()
+	if err := r.QueryAllPools(ctx, inputMint, outputMint); err != nil {
+		return nil, fmt.Errorf("failed to query pools: %w", err)
+	}
+	return r.Pools, nil
+}
+
+// GetRoutes quotes the best route of up to n protocols for a pair, best first,
+// with thresholds at slippageBps
+func (qc *QuoteCache) GetRoutes(ctx context.Context, inputMint, outputMint, amount string, dexes, excludeDexes []string, minLiquidityUSD float64, slippageBps, n int) ([]RouteOption, error) {
+	amountIn, ok := math.NewIntFromString(amount)
+	if !ok || !amountIn.IsPositive() {
+		return nil, fmt.Errorf("invalid amount")
+	}
+
+	pools, err := qc.pairPools(ctx, inputMint, outputMint)
+	if err != nil {
+		return nil, err
+	}
+	r := *qc.currentRouter()
+	r.Pools = pools
+
+	routes, err := r.GetBestRoutesWithFilter(ctx, qc.client(), inputMint, amountIn, dexes, excludeDexes, minLiquidityUSD, n)
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]RouteOption, 0, len(routes))
+	for _, route := range routes {
+		minAmountOut := route.OutAmount.Mul(math.NewInt(int64(10000 - slippageBps))).Quo(math.NewInt(10000))
+		options = append(options, RouteOption{
+			Protocol:             string(route.Pool.ProtocolName()),
+			PoolID:               route.Pool.GetID(),
+			ProgramID:            route.Pool.GetProgramID().String(),
+			OutAmount:            route.OutAmount.String(),
+			OtherAmountThreshold: minAmountOut.String(),
+		})
+	}
+	return options, nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"soltrading/pkg"
+	"soltrading/pkg/router"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/subscription"
+)
+
+// cacheSnapshotVersion is bumped when the snapshot file format changes
+const cacheSnapshotVersion = 1
+
+// cacheSnapshot is the state persisted by -snapshot: the cached quotes, the
+// pools backing them and the subscribed pools needed to recompute them
+type cacheSnapshot struct {
+	Version      int                     `json:"version"`
+	Quotes       map[string]*CachedQuote `json:"quotes"`
+	PoolToQuotes map[string][]QuotePair  `json:"poolToQuotes"`
+	Pools        subscription.Snapshot   `json:"pools"`
+}
+
+// pairKey identifies a token pair regardless of direction
+func pairKey(mintA, mintB string) string {
+	if mintA > mintB {
+		mintA, mintB = mintB, mintA
+	}
+	return mintA + ":" + mintB
+}
+
+// snapshotRecordTimeout bounds re-reading the subscribed pools' accounts
+// when a snapshot is saved
+const snapshotRecordTimeout = 30 * time.Second
+
+// SaveSnapshot writes the cached quotes and subscribed pools, with the
+// accounts they decode from, to path
+func (qc *QuoteCache) SaveSnapshot(path string) error {
+	snap := cacheSnapshot{
+		Version:      cacheSnapshotVersion,
+		Quotes:       make(map[string]*CachedQuote),
+		PoolToQuotes: make(map[string][]QuotePair),
+	}
+
+	qc.mu.RLock()
+	for key, quote := range qc.cache {
+		snap.Quotes[key] = quote
+	}
+	for poolID, pairs := range qc.poolToQuotes {
+		snap.PoolToQuotes[poolID] = append([]QuotePair(nil), pairs...)
+	}
+	qc.mu.RUnlock()
+
+	if qc.subscriptionMgr != nil {
+		snap.Pools = qc.subscriptionMgr.Snapshot()
+		ctx, cancel := context.WithTimeout(context.Background(), snapshotRecordTimeout)
+		recorded := subscription.RecordAccounts(ctx, &snap.Pools, liveClient{qc}, qc.protocolLoader, 0)
+		cancel()
+		if recorded < len(snap.Pools.Pools) {
+			log.Printf("Recorded accounts for %d of %d pools; the rest are fetched on restore", recorded, len(snap.Pools.Pools))
+		}
+	}
+
+	if err := subscription.WriteSnapshotFile(path, snap); err != nil {
+		return err
+	}
+	log.Printf("Saved snapshot with %d quotes and %d pools to %s", len(snap.Quotes), len(snap.Pools.Pools), path)
+	return nil
+}
+
+// LoadSnapshot restores the cached quotes from path so they can be served
+// immediately, and returns the pools to reload with RestorePools. A missing
+// file is not an error.
+func (qc *QuoteCache) LoadSnapshot(path string) (subscription.Snapshot, error) {
+	var snap cacheSnapshot
+	if err := subscription.ReadSnapshotFile(path, &snap); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("No snapshot at %s, starting cold", path)
+			return subscription.Snapshot{}, nil
+		}
+		return subscription.Snapshot{}, err
+	}
+	if snap.Version != cacheSnapshotVersion {
+		return subscription.Snapshot{}, fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+
+	qc.mu.Lock()
+	for key, quote := range snap.Quotes {
+		if _, exists := qc.cache[key]; !exists {
+			qc.cache[key] = quote
+		}
+	}
+	for poolID, pairs := range snap.PoolToQuotes {
+		if _, exists := qc.poolToQuotes[poolID]; !exists {
+			qc.poolToQuotes[poolID] = pairs
+		}
+	}
+	qc.mu.Unlock()
+
+	log.Printf("Loaded %d quotes from snapshot %s (slot %d)", len(snap.Quotes), path, snap.Pools.Slot)
+	return snap.Pools, nil
+}
+
+// RestorePools decodes the snapshot's pools from their recorded accounts,
+// fetching by ID only the accounts it lacks, and subscribes them. Each pair's
+// restored pools replace pool discovery on its first quote.
+func (qc *QuoteCache) RestorePools(ctx context.Context, snap subscription.Snapshot) {
+	if qc.subscriptionMgr == nil || len(snap.Pools) == 0 {
+		return
+	}
+
+	client := subscription.NewSnapshotClient(snap, liveClient{qc})
+	pools := qc.subscriptionMgr.RestoreSnapshot(ctx, snap, qc.protocolLoader(client), 0)
+	log.Printf("Restored %d pools from snapshot; %d account reads went to RPC", len(pools), client.Misses())
+
+	restored := make(map[string][]pkg.Pool)
+	for _, pool := range pools {
+		poolID := pool.GetID()
+		if qc.subscriptionMgr.IsSubscribed(poolID) {
+			qc.subscriptionMgr.RegisterHandler(poolID, func(updatedPoolID string, data []byte, slot uint64) {
+				qc.handlePoolUpdate(updatedPoolID, slot)
+			})
+		}
+		baseMint, quoteMint := pool.GetTokens()
+		key := pairKey(baseMint, quoteMint)
+		restored[key] = append(restored[key], pool)
+	}
+
+	qc.mu.Lock()
+	qc.restoredPools = restored
+	qc.mu.Unlock()
+}
+
+// loadPool fetches a pool by ID through the router's protocol of that name
+func (qc *QuoteCache) loadPool(ctx context.Context, name pkg.ProtocolName, poolID string) (pkg.Pool, error) {
+	for _, proto := range qc.currentRouter().Protocols {
+		if proto.ProtocolName() == name {
+			return proto.FetchPoolByID(ctx, poolID)
+		}
+	}
+	return nil, fmt.Errorf("protocol %s is not enabled", name)
+}
+
+// protocolLoader loads pools by ID with the enabled protocols created on
+// client, so their account reads can be recorded or replayed
+func (qc *QuoteCache) protocolLoader(client sol.SolClient) subscription.PoolLoader {
+	qc.clientMu.RLock()
+	protocols, err := newProtocols(client, qc.enabled)
+	qc.clientMu.RUnlock()
+
+	return func(ctx context.Context, name pkg.ProtocolName, poolID string) (pkg.Pool, error) {
+		if err != nil {
+			return nil, err
+		}
+		for _, proto := range protocols {
+			if proto.ProtocolName() == name {
+				return proto.FetchPoolByID(ctx, poolID)
+			}
+		}
+		return nil, fmt.Errorf("protocol %s is not enabled", name)
+	}
+}
+
+// takeRestoredPools returns and forgets the restored pools for a pair
+func (qc *QuoteCache) takeRestoredPools(inputMint, outputMint string) []pkg.Pool {
+	key := pairKey(inputMint, outputMint)
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	pools := qc.restoredPools[key]
+	delete(qc.restoredPools, key)
+	return pools
+}
+
+// queryPools loads the pair's pools into r, using pools restored from a
+// snapshot in place of the pair's first discovery
+func (qc *QuoteCache) queryPools(ctx context.Context, r *router.SimpleRouter, inputMint, outputMint string) error {
+	if pools := qc.takeRestoredPools(inputMint, outputMint); len(pools) > 0 {
+		r.Pools = pools
+		return nil
+	}
+	return r.QueryAllPools(ctx, inputMint, outputMint)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sseHeartbeatInterval keeps idle connections open through proxies
+const sseHeartbeatInterval = 15 * time.Second
+
+// handleStream emits Server-Sent Events with the cached quote of each
+// requested pair whenever it changes. Pairs are given as
+// pairs=<input>:<output>:<amount>,... or as input, output and amount.
+// Event IDs are update sequence numbers; a reconnecting client sending
+// Last-Event-ID only receives quotes that changed since that event.
+func handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	pairs, err := parseStreamPairs(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var lastEventID uint64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		lastEventID, err = strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			writeError(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	watcher := quoteCache.hub.subscribe()
+	defer quoteCache.hub.unsubscribe(watcher)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// sent tracks the last sequence number written per pair so quotes
+	// published while subscribing are not sent twice
+	sent := make(map[string]uint64)
+	send := func(update quoteUpdate) bool {
+		if update.Seq <= sent[update.Key] {
+			return true
+		}
+		data, err := json.Marshal(quoteCache.WithSlotAge(update.Quote))
+		if err != nil {
+			return true
+		}
+		sent[update.Key] = update.Seq
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: quote\ndata: %s\n\n", update.Seq, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	for _, pair := range pairs {
+		quote, err := quoteCache.Watch(r.Context(), watcher, pair)
+		if err != nil {
+			data, _ := json.Marshal(QuoteError{Error: fmt.Sprintf("%s: %v", pair.Label, err)})
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+			flusher.Flush()
+			continue
+		}
+		key := quoteCache.getCacheKey(pair.InputMint, pair.OutputMint, pair.Amount)
+		seq := quoteCache.hub.version(key)
+		if seq > lastEventID && !send(quoteUpdate{Seq: seq, Key: key, Quote: quote}) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update := <-watcher.C:
+			if !send(update) {
+				return
+			}
+		case <-heartbeat.C:
+			quoteCache.KeepWatched(watcher)
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// parseStreamPairs reads the pairs requested from /stream
+func parseStreamPairs(r *http.Request) ([]QuotePair, error) {
+	query := r.URL.Query()
+	var pairs []QuotePair
+
+	if param := query.Get("pairs"); param != "" {
+		for _, spec := range strings.Split(param, ",") {
+			parts := strings.Split(strings.TrimSpace(spec), ":")
+			if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+				return nil, fmt.Errorf("invalid pair %q, expected <input>:<output>:<amount>", spec)
+			}
+			pairs = append(pairs, QuotePair{InputMint: parts[0], OutputMint: parts[1], Amount: parts[2], Label: spec})
+		}
+	}
+
+	input, output, amount := query.Get("input"), query.Get("output"), query.Get("amount")
+	if input != "" || output != "" || amount != "" {
+		if input == "" || output == "" || amount == "" {
+			return nil, fmt.Errorf("missing required parameters: input, output, amount")
+		}
+		pairs = append(pairs, QuotePair{InputMint: input, OutputMint: output, Amount: amount, Label: input + ":" + output + ":" + amount})
+	}
+
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no pairs requested")
+	}
+	return pairs, nil
+}
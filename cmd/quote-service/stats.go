@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// DiscoveryStats counts a protocol's pool discoveries since startup
+type DiscoveryStats struct {
+	Discoveries   uint64 `json:"discoveries"`
+	Failures      uint64 `json:"failures"`
+	PoolsFound    uint64 `json:"poolsFound"`
+	LastPoolCount int    `json:"lastPoolCount"`
+	AvgLatency    string `json:"avgLatency,omitempty"`
+	LastLatency   string `json:"lastLatency,omitempty"`
+	LastError     string `json:"lastError,omitempty"`
+}
+
+func (s *componentStatus) discoveryStats() DiscoveryStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := DiscoveryStats{
+		Discoveries:   s.calls,
+		Failures:      s.errors,
+		PoolsFound:    s.found,
+		LastPoolCount: s.lastFound,
+		LastError:     s.lastError,
+	}
+	if s.calls > 0 {
+		stats.AvgLatency = (s.totalLatency / time.Duration(s.calls)).Round(time.Millisecond).String()
+		stats.LastLatency = s.latency.Round(time.Millisecond).String()
+	}
+	return stats
+}
+
+// QuoteStats summarizes the quote cache and its consumers
+type QuoteStats struct {
+	CachedQuotes     int    `json:"cachedQuotes"`
+	MonitoredPairs   int    `json:"monitoredPairs"`
+	StreamClients    int    `json:"streamClients"`
+	StreamDropped    uint64 `json:"streamDropped"`
+	PublishDropped   uint64 `json:"publishDropped,omitempty"`
+	HistorySeries    int    `json:"historySeries,omitempty"`
+	HistoryRetention string `json:"historyRetention,omitempty"`
+}
+
+// StatsResponse is the /stats document
+type StatsResponse struct {
+	Timestamp    time.Time                 `json:"timestamp"`
+	Uptime       string                    `json:"uptime"`
+	Quotes       QuoteStats                `json:"quotes"`
+	Subscription map[string]interface{}    `json:"subscription,omitempty"`
+	PoolCache    map[string]interface{}    `json:"poolCache,omitempty"`
+	RPC          map[string]interface{}    `json:"rpc"`
+	Protocols    map[string]DiscoveryStats `json:"protocols"`
+}
+
+// Stats collects the counters of the quote cache, subscription manager, pool
+// cache, RPC pool and protocol discoveries
+func (qc *QuoteCache) Stats() StatsResponse {
+	stats := StatsResponse{
+		Timestamp: time.Now(),
+		Uptime:    time.Since(startTime).Round(time.Second).String(),
+		Quotes: QuoteStats{
+			CachedQuotes:   len(qc.GetAllCached()),
+			MonitoredPairs: len(qc.MonitoredPairs()),
+			StreamClients:  qc.hub.clients(),
+			StreamDropped:  atomic.LoadUint64(&qc.hub.dropped),
+		},
+	}
+	if qc.publisher != nil {
+		stats.Quotes.PublishDropped = atomic.LoadUint64(&qc.publisher.dropped)
+	}
+	if qc.history != nil {
+		qc.history.mu.Lock()
+		stats.Quotes.HistorySeries = len(qc.history.samples)
+		qc.history.mu.Unlock()
+		stats.Quotes.HistoryRetention = qc.history.retention.String()
+	}
+
+	if qc.subscriptionMgr != nil {
+		stats.Subscription = qc.subscriptionMgr.Stats()
+		stats.PoolCache = qc.subscriptionMgr.PoolCacheStats()
+	}
+
+	qc.clientMu.RLock()
+	rpcPool := qc.rpcPool
+	qc.clientMu.RUnlock()
+	if rpcPool != nil {
+		stats.RPC = rpcPool.Stats()
+	} else {
+		stats.RPC = map[string]interface{}{"endpoints": 1}
+	}
+
+	protocols := qc.health.statuses()
+	stats.Protocols = make(map[string]DiscoveryStats, len(protocols))
+	for name, status := range protocols {
+		stats.Protocols[string(name)] = status.discoveryStats()
+	}
+	return stats
+}
+
+func handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quoteCache.Stats())
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// quoteStreamBuffer is how many updates a slow streaming client may fall behind
+// before further updates for it are dropped
+const quoteStreamBuffer = 64
+
+// quoteHub fans cached quote updates out to streaming API clients
+type quoteHub struct {
+	mu       sync.RWMutex
+	subs     map[*quoteWatcher]struct{}
+	seq      uint64
+	versions map[string]uint64 // cache key -> sequence number of its latest quote
+	dropped  uint64
+}
+
+// quoteUpdate is a newly cached quote. Seq increases with every update across
+// all pairs, so clients can resume a stream from the last update they saw.
+type quoteUpdate struct {
+	Seq   uint64
+	Key   string
+	Quote *CachedQuote
+}
+
+// quoteWatcher receives the updates for the pairs it watches
+type quoteWatcher struct {
+	C chan quoteUpdate
+
+	mu    sync.RWMutex
+	pairs map[string]QuotePair // cache key -> pair
+}
+
+func newQuoteHub() *quoteHub {
+	return &quoteHub{
+		subs:     make(map[*quoteWatcher]struct{}),
+		versions: make(map[string]uint64),
+	}
+}
+
+// subscribe registers a watcher with no pairs
+func (h *quoteHub) subscribe() *quoteWatcher {
+	w := &quoteWatcher{
+		C:     make(chan quoteUpdate, quoteStreamBuffer),
+		pairs: make(map[string]QuotePair),
+	}
+	h.mu.Lock()
+	h.subs[w] = struct{}{}
+	h.mu.Unlock()
+	return w
+}
+
+// unsubscribe removes a watcher; its channel is not closed
+func (h *quoteHub) unsubscribe(w *quoteWatcher) {
+	h.mu.Lock()
+	delete(h.subs, w)
+	h.mu.Unlock()
+}
+
+// publish delivers a quote to every watcher of its pair without blocking
+func (h *quoteHub) publish(key string, quote *CachedQuote) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	h.versions[key] = h.seq
+	update := quoteUpdate{Seq: h.seq, Key: key, Quote: quote}
+	for w := range h.subs {
+		if !w.watching(key) {
+			continue
+		}
+		select {
+		case w.C <- update:
+		default:
+			atomic.AddUint64(&h.dropped, 1)
+		}
+	}
+}
+
+// version returns the sequence number of a pair's latest quote, zero if none
+// was published
+func (h *quoteHub) version(key string) uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.versions[key]
+}
+
+// clients returns the number of connected watchers
+func (h *quoteHub) clients() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+func (w *quoteWatcher) watch(key string, pair QuotePair) {
+	w.mu.Lock()
+	w.pairs[key] = pair
+	w.mu.Unlock()
+}
+
+func (w *quoteWatcher) unwatch(key string) {
+	w.mu.Lock()
+	delete(w.pairs, key)
+	w.mu.Unlock()
+}
+
+func (w *quoteWatcher) watching(key string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	_, ok := w.pairs[key]
+	return ok
+}
+
+// watched returns a copy of the watched pairs
+func (w *quoteWatcher) watched() []QuotePair {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	pairs := make([]QuotePair, 0, len(w.pairs))
+	for _, pair := range w.pairs {
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+// Watch starts streaming a pair's quote updates to w. The current quote is
+// computed if it is not cached yet, which also makes the cache follow the
+// pair's pools so later pool updates are pushed.
+func (qc *QuoteCache) Watch(ctx context.Context, w *quoteWatcher, pair QuotePair) (*CachedQuote, error) {
+	key := qc.getCacheKey(pair.InputMint, pair.OutputMint, pair.Amount)
+	w.watch(key, pair)
+
+	quote, err := qc.GetOrCalculateQuote(ctx, pair.InputMint, pair.OutputMint, pair.Amount, nil, nil, 0)
+	if err != nil {
+		w.unwatch(key)
+		return nil, err
+	}
+	return quote, nil
+}
+
+// Unwatch stops streaming a pair's updates to w
+func (qc *QuoteCache) Unwatch(w *quoteWatcher, pair QuotePair) {
+	w.unwatch(qc.getCacheKey(pair.InputMint, pair.OutputMint, pair.Amount))
+}
+
+// KeepWatched marks the pools behind a watcher's pairs as read, so quiet
+// pools are not evicted as idle while a client is streaming them
+func (qc *QuoteCache) KeepWatched(w *quoteWatcher) {
+	for _, pair := range w.watched() {
+		qc.GetQuote(pair.InputMint, pair.OutputMint, pair.Amount)
+	}
+}
+
+// publishQuote pushes a newly cached quote to streaming clients and the
+// message broker, and records it in the quote history
+func (qc *QuoteCache) publishQuote(key string, quote *CachedQuote) {
+	if qc.history != nil {
+		qc.history.record(key, quote)
+	}
+	if qc.publisher != nil {
+		qc.publisher.publish(quote)
+	}
+	qc.hub.publish(key, quote)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/swap"
+)
+
+// SwapParams controls the transaction built for a quote
+type SwapParams struct {
+	User solana.PublicKey
+	// WrapSOL wraps native SOL input and unwraps WSOL output
+	WrapSOL bool
+	// ComputeUnitPrice is the priority fee in micro-lamports per compute unit;
+	// zero with DynamicComputeUnitLimit uses the fee market
+	ComputeUnitPrice uint64
+	// DynamicComputeUnitLimit sets the compute unit limit from a simulation
+	DynamicComputeUnitLimit bool
+}
+
+// findPool returns the pool a quote was routed through, from the pool cache
+// when subscribed, otherwise fetched by ID
+func (qc *QuoteCache) findPool(ctx context.Context, protocolName, poolID string) (pkg.Pool, error) {
+	if qc.subscriptionMgr != nil {
+		if pool, exists := qc.subscriptionMgr.GetPool(poolID); exists {
+			return pool, nil
+		}
+	}
+	pool, err := qc.loadPool(ctx, pkg.ProtocolName(protocolName), poolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pool %s: %w", poolID, err)
+	}
+	return pool, nil
+}
+
+// BuildSwapInstructions builds the instructions executing a quote for the
+// user: token account creation, SOL wrapping, compute budget and the swap,
+// with the quote's slippage threshold as the minimum output
+func (qc *QuoteCache) BuildSwapInstructions(ctx context.Context, quote *CachedQuote, params SwapParams) ([]solana.Instruction, error) {
+	instructions, _, err := qc.buildSwap(ctx, quote, params)
+	return instructions, err
+}
+
+// buildSwap builds a quote's swap instructions and returns the pool it swaps through
+func (qc *QuoteCache) buildSwap(ctx context.Context, quote *CachedQuote, params SwapParams) ([]solana.Instruction, pkg.Pool, error) {
+	if len(quote.RoutePlan) == 0 {
+		return nil, nil, fmt.Errorf("quote has no route")
+	}
+	leg := quote.RoutePlan[0]
+
+	amountIn, ok := math.NewIntFromString(quote.InAmount)
+	if !ok || !amountIn.IsPositive() {
+		return nil, nil, fmt.Errorf("invalid quote inAmount %q", quote.InAmount)
+	}
+	minOut, ok := math.NewIntFromString(quote.OtherAmountThreshold)
+	if !ok || minOut.IsNegative() {
+		return nil, nil, fmt.Errorf("invalid quote otherAmountThreshold %q", quote.OtherAmountThreshold)
+	}
+
+	pool, err := qc.findPool(ctx, leg.Protocol, leg.PoolID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	builder := swap.NewBuilder(qc.client(), swap.Options{
+		WrapSOL:    params.WrapSOL,
+		CreateATAs: true,
+		ComputeBudget: swap.ComputeBudgetOptions{
+			Enabled:          params.DynamicComputeUnitLimit || params.ComputeUnitPrice > 0,
+			ComputeUnitPrice: params.ComputeUnitPrice,
+		},
+	})
+	instructions, err := builder.BuildSwapInstructions(ctx, pool, params.User, quote.InputMint, amountIn, minOut, solana.PublicKey{}, solana.PublicKey{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return instructions, pool, nil
+}
+
+// BuildSwapTransaction builds an unsigned transaction paid by the user that
+// executes a quote, and the last block height at which it is valid
+func (qc *QuoteCache) BuildSwapTransaction(ctx context.Context, quote *CachedQuote, params SwapParams) (*solana.Transaction, uint64, error) {
+	instructions, err := qc.BuildSwapInstructions(ctx, quote, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	blockhash, err := qc.client().GetLatestBlockhash(ctx, rpc.CommitmentConfirmed)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get latest blockhash: %w", err)
+	}
+
+	tx, err := solana.NewTransaction(instructions, blockhash.Value.Blockhash, solana.TransactionPayer(params.User))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build transaction: %w", err)
+	}
+	return tx, blockhash.Value.LastValidBlockHeight, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// SwapRequest asks for a transaction executing either a previously returned
+// quote or a fresh quote for the given parameters
+type SwapRequest struct {
+	UserPublicKey string `json:"userPublicKey"`
+	// Quote is a response from /quote; when absent the pair fields are quoted
+	Quote       *CachedQuote `json:"quote,omitempty"`
+	InputMint   string       `json:"inputMint,omitempty"`
+	OutputMint  string       `json:"outputMint,omitempty"`
+	Amount      string       `json:"amount,omitempty"`
+	SlippageBps *int         `json:"slippageBps,omitempty"`
+	// WrapAndUnwrapSol wraps native SOL input and unwraps WSOL output (default true)
+	WrapAndUnwrapSol *bool `json:"wrapAndUnwrapSol,omitempty"`
+	// ComputeUnitPrice is the priority fee in micro-lamports per compute unit
+	ComputeUnitPrice uint64 `json:"computeUnitPrice,omitempty"`
+	// DynamicComputeUnitLimit sets the compute unit limit from a simulation
+	DynamicComputeUnitLimit bool `json:"dynamicComputeUnitLimit,omitempty"`
+}
+
+type SwapResponse struct {
+	SwapTransaction      string       `json:"swapTransaction"` // base64, unsigned
+	LastValidBlockHeight uint64       `json:"lastValidBlockHeight"`
+	Quote                *CachedQuote `json:"quote"`
+}
+
+// decodeSwapRequest parses a swap request and resolves the quote it executes
+func decodeSwapRequest(w http.ResponseWriter, r *http.Request) (*CachedQuote, SwapParams, int, error) {
+	var req SwapRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		return nil, SwapParams{}, http.StatusBadRequest, fmt.Errorf("invalid request body")
+	}
+	user, err := solana.PublicKeyFromBase58(req.UserPublicKey)
+	if err != nil {
+		return nil, SwapParams{}, http.StatusBadRequest, fmt.Errorf("invalid userPublicKey")
+	}
+
+	quote := req.Quote
+	if quote == nil {
+		if req.InputMint == "" || req.OutputMint == "" || req.Amount == "" {
+			return nil, SwapParams{}, http.StatusBadRequest, fmt.Errorf("either quote or inputMint, outputMint and amount are required")
+		}
+		quote, err = quoteCache.GetOrCalculateQuote(r.Context(), req.InputMint, req.OutputMint, req.Amount, nil, nil, 0)
+		if err != nil {
+			return nil, SwapParams{}, http.StatusInternalServerError, fmt.Errorf("failed to calculate quote: %w", err)
+		}
+	}
+	if req.SlippageBps != nil {
+		if *req.SlippageBps < 0 || *req.SlippageBps > 10000 {
+			return nil, SwapParams{}, http.StatusBadRequest, fmt.Errorf("invalid slippageBps (must be 0-10000)")
+		}
+		quote = withSlippage(quote, *req.SlippageBps)
+	}
+
+	params := SwapParams{
+		User:                    user,
+		WrapSOL:                 req.WrapAndUnwrapSol == nil || *req.WrapAndUnwrapSol,
+		ComputeUnitPrice:        req.ComputeUnitPrice,
+		DynamicComputeUnitLimit: req.DynamicComputeUnitLimit,
+	}
+	return quote, params, 0, nil
+}
+
+// handleSwap returns an unsigned transaction executing a quote, including
+// token account creation, SOL wrapping and compute budget instructions
+func handleSwap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	quote, params, status, err := decodeSwapRequest(w, r)
+	if err != nil {
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	tx, lastValid, err := quoteCache.BuildSwapTransaction(r.Context(), quote, params)
+	if err != nil {
+		writeError(w, fmt.Sprintf("Failed to build swap: %v", err), http.StatusBadRequest)
+		return
+	}
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		writeError(w, fmt.Sprintf("Failed to serialize transaction: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SwapResponse{
+		SwapTransaction:      base64.StdEncoding.EncodeToString(data),
+		LastValidBlockHeight: lastValid,
+		Quote:                quoteCache.WithSlotAge(quote),
+	})
+}
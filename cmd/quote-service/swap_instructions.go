@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// computeBudgetProgramID owns the SetComputeUnitLimit/SetComputeUnitPrice instructions
+var computeBudgetProgramID = solana.MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
+
+// InstructionJSON is a transaction instruction in a form clients can rebuild
+type InstructionJSON struct {
+	ProgramID string        `json:"programId"`
+	Accounts  []AccountJSON `json:"accounts"`
+	Data      string        `json:"data"` // base64
+}
+
+type AccountJSON struct {
+	Pubkey     string `json:"pubkey"`
+	IsSigner   bool   `json:"isSigner"`
+	IsWritable bool   `json:"isWritable"`
+}
+
+// SwapInstructionsResponse groups a swap's instructions the way integrators
+// compose them into their own transactions
+type SwapInstructionsResponse struct {
+	ComputeBudgetInstructions []InstructionJSON `json:"computeBudgetInstructions"`
+	SetupInstructions         []InstructionJSON `json:"setupInstructions"`
+	SwapInstructions          []InstructionJSON `json:"swapInstructions"`
+	CleanupInstructions       []InstructionJSON `json:"cleanupInstructions"`
+	// AddressLookupTableAddresses are the operator-provided tables from -lookup-tables
+	AddressLookupTableAddresses []string     `json:"addressLookupTableAddresses"`
+	Quote                       *CachedQuote `json:"quote"`
+}
+
+// handleSwapInstructions returns the instructions of a swap instead of a
+// serialized transaction. It accepts the same body as POST /swap.
+func handleSwapInstructions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	quote, params, status, err := decodeSwapRequest(w, r)
+	if err != nil {
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	instructions, pool, err := quoteCache.buildSwap(r.Context(), quote, params)
+	if err != nil {
+		writeError(w, fmt.Sprintf("Failed to build swap: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := SwapInstructionsResponse{
+		ComputeBudgetInstructions:   []InstructionJSON{},
+		SetupInstructions:           []InstructionJSON{},
+		SwapInstructions:            []InstructionJSON{},
+		CleanupInstructions:         []InstructionJSON{},
+		AddressLookupTableAddresses: lookupTableAddresses(),
+		Quote:                       quoteCache.WithSlotAge(quote),
+	}
+
+	// Instructions before the pool's program are setup, those after are cleanup
+	swapProgram := pool.GetProgramID()
+	swapped := false
+	for _, instruction := range instructions {
+		encoded, err := encodeInstruction(instruction)
+		if err != nil {
+			writeError(w, fmt.Sprintf("Failed to encode instruction: %v", err), http.StatusInternalServerError)
+			return
+		}
+		switch {
+		case instruction.ProgramID().Equals(computeBudgetProgramID):
+			response.ComputeBudgetInstructions = append(response.ComputeBudgetInstructions, encoded)
+		case instruction.ProgramID().Equals(swapProgram):
+			response.SwapInstructions = append(response.SwapInstructions, encoded)
+			swapped = true
+		case swapped:
+			response.CleanupInstructions = append(response.CleanupInstructions, encoded)
+		default:
+			response.SetupInstructions = append(response.SetupInstructions, encoded)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func encodeInstruction(instruction solana.Instruction) (InstructionJSON, error) {
+	data, err := instruction.Data()
+	if err != nil {
+		return InstructionJSON{}, err
+	}
+	encoded := InstructionJSON{
+		ProgramID: instruction.ProgramID().String(),
+		Accounts:  make([]AccountJSON, 0, len(instruction.Accounts())),
+		Data:      base64.StdEncoding.EncodeToString(data),
+	}
+	for _, account := range instruction.Accounts() {
+		encoded.Accounts = append(encoded.Accounts, AccountJSON{
+			Pubkey:     account.PublicKey.String(),
+			IsSigner:   account.IsSigner,
+			IsWritable: account.IsWritable,
+		})
+	}
+	return encoded, nil
+}
+
+// lookupTableAddresses returns the address lookup tables configured with -lookup-tables
+func lookupTableAddresses() []string {
+	addresses := splitList(*lookupTables)
+	if addresses == nil {
+		return []string{}
+	}
+	return addresses
+}
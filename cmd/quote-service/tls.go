@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsOptions selects how the HTTP server terminates TLS. Certificate files take
+// precedence over ACME; with neither the server speaks plain HTTP.
+type tlsOptions struct {
+	CertFile     string
+	KeyFile      string
+	ACMEDomains  []string
+	ACMECacheDir string
+	ACMEEmail    string
+	// ChallengeAddr serves ACME HTTP-01 challenges and redirects other
+	// requests to HTTPS; empty disables it and relies on TLS-ALPN-01
+	ChallengeAddr string
+}
+
+// tlsOptionsFromFlags merges the TLS flags with their environment fallbacks
+func tlsOptionsFromFlags() (tlsOptions, error) {
+	opts := tlsOptions{
+		CertFile:      flagOrEnv(*tlsCert, "TLS_CERT_FILE"),
+		KeyFile:       flagOrEnv(*tlsKey, "TLS_KEY_FILE"),
+		ACMECacheDir:  *acmeCache,
+		ACMEEmail:     flagOrEnv(*acmeEmail, "ACME_EMAIL"),
+		ChallengeAddr: *acmeHTTPAddr,
+	}
+	for _, domain := range strings.Split(flagOrEnv(*acmeDomains, "ACME_DOMAINS"), ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			opts.ACMEDomains = append(opts.ACMEDomains, domain)
+		}
+	}
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return opts, fmt.Errorf("both -tls-cert and -tls-key are required")
+	}
+	return opts, nil
+}
+
+func flagOrEnv(value, env string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
+
+// enabled reports whether the server should serve HTTPS
+func (o tlsOptions) enabled() bool {
+	return o.CertFile != "" || len(o.ACMEDomains) > 0
+}
+
+// serve runs server on ln over HTTPS or plain HTTP according to opts
+func serve(server *http.Server, ln net.Listener, opts tlsOptions) error {
+	if opts.CertFile != "" {
+		log.Printf("Serving HTTPS with certificate %s", opts.CertFile)
+		return server.ServeTLS(ln, opts.CertFile, opts.KeyFile)
+	}
+
+	if len(opts.ACMEDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.ACMEDomains...),
+			Cache:      autocert.DirCache(opts.ACMECacheDir),
+			Email:      opts.ACMEEmail,
+		}
+		server.TLSConfig = manager.TLSConfig()
+		server.TLSConfig.MinVersion = tls.VersionTLS12
+
+		if opts.ChallengeAddr != "" {
+			go func() {
+				if err := http.ListenAndServe(opts.ChallengeAddr, manager.HTTPHandler(nil)); err != nil {
+					log.Printf("ACME challenge listener on %s stopped: %v", opts.ChallengeAddr, err)
+				}
+			}()
+		}
+
+		log.Printf("Serving HTTPS with ACME certificates for %s", strings.Join(opts.ACMEDomains, ", "))
+		return server.ServeTLS(ln, "", "")
+	}
+
+	return server.Serve(ln)
+}
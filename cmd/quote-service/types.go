@@ -15,6 +15,25 @@ type CachedQuote struct {
 	OtherAmountThreshold string      `json:"otherAmountThreshold"`
 	LastUpdate           time.Time   `json:"lastUpdate"`
 	TimeTaken            string      `json:"timeTaken"`
+	// ContextSlot is the slot of the pool state the quote was computed from
+	ContextSlot uint64 `json:"contextSlot,omitempty"`
+	// SlotAge is how many slots behind the chain tip ContextSlot is when served
+	SlotAge *uint64 `json:"slotAge,omitempty"`
+	// Age is how long ago the quote was calculated when served
+	Age string `json:"age,omitempty"`
+	// Routes lists the best route per protocol when requested with routes=N
+	Routes []RouteOption `json:"routes,omitempty"`
+	// Interpolated marks an estimate from the pair's quotes at other sizes
+	Interpolated bool `json:"interpolated,omitempty"`
+}
+
+// RouteOption is an alternative single-pool route for a quote
+type RouteOption struct {
+	Protocol             string `json:"protocol"`
+	PoolID               string `json:"poolId"`
+	ProgramID            string `json:"programId"`
+	OutAmount            string `json:"outAmount"`
+	OtherAmountThreshold string `json:"otherAmountThreshold"`
 }
 
 type RoutePlan struct {
@@ -38,13 +57,35 @@ type QuoteRequest struct {
 	SlippageBps int    `json:"slippageBps,omitempty"`
 }
 
+// BatchQuoteResult is one entry of a POST /quotes response, in request order
+type BatchQuoteResult struct {
+	Quote *CachedQuote `json:"quote,omitempty"`
+	Error string       `json:"error,omitempty"`
+}
+
+type BatchQuoteResponse struct {
+	Quotes []BatchQuoteResult `json:"quotes"`
+}
+
 type QuoteError struct {
 	Error string `json:"error"`
 }
 
 type HealthResponse struct {
-	Status       string    `json:"status"`
-	LastUpdate   time.Time `json:"lastUpdate"`
-	CachedRoutes int       `json:"cachedRoutes"`
-	Uptime       string    `json:"uptime"`
+	Status          string                     `json:"status"` // "healthy", "degraded" or "unhealthy"
+	Degraded        []string                   `json:"degraded,omitempty"`
+	LastUpdate      time.Time                  `json:"lastUpdate"`
+	CachedRoutes    int                        `json:"cachedRoutes"`
+	Uptime          string                     `json:"uptime"`
+	Mode            string                     `json:"mode"`        // "stream" or "rpc-only"
+	StreamState     string                     `json:"streamState"` // connection state of the update stream
+	SubscribedPools int                        `json:"subscribedPools"`
+	Subscriptions   int                        `json:"subscriptions"`
+	RPC             []EndpointHealth           `json:"rpc"`
+	Protocols       map[string]ComponentHealth `json:"protocols"` // pool discovery per protocol
+}
+
+type EndpointHealth struct {
+	Endpoint string `json:"endpoint"`
+	ComponentHealth
 }
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"cosmossdk.io/math"
+	"soltrading/pkg/jupiter"
+)
+
+// PairValidation is the latest Jupiter comparison of one monitored pair
+type PairValidation struct {
+	Label       string               `json:"label,omitempty"`
+	InputMint   string               `json:"inputMint"`
+	OutputMint  string               `json:"outputMint"`
+	Amount      string               `json:"amount"`
+	CheckedAt   time.Time            `json:"checkedAt"`
+	Error       string               `json:"error,omitempty"`
+	Comparisons []jupiter.Comparison `json:"comparisons,omitempty"`
+}
+
+// validator periodically compares the monitored pairs' local quotes, overall
+// and per protocol, with Jupiter's and logs protocols that drift
+type validator struct {
+	qc              *QuoteCache
+	client          *jupiter.Client
+	interval        time.Duration
+	maxDeviationBps float64
+
+	mu      sync.RWMutex
+	results map[string]*PairValidation
+}
+
+var validation *validator
+
+func newValidator(qc *QuoteCache, client *jupiter.Client, interval time.Duration, maxDeviationBps float64) *validator {
+	return &validator{
+		qc:              qc,
+		client:          client,
+		interval:        interval,
+		maxDeviationBps: maxDeviationBps,
+		results:         make(map[string]*PairValidation),
+	}
+}
+
+// Run validates every monitored pair each interval until ctx is done
+func (v *validator) Run(ctx context.Context) {
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, pair := range v.qc.MonitoredPairs() {
+				if ctx.Err() != nil {
+					return
+				}
+				v.validate(ctx, pair)
+			}
+		}
+	}
+}
+
+// validate compares one pair's best route and best pool per protocol, quoted
+// from cached pool state, with Jupiter
+func (v *validator) validate(ctx context.Context, pair QuotePair) {
+	result := &PairValidation{
+		Label:      pair.Label,
+		InputMint:  pair.InputMint,
+		OutputMint: pair.OutputMint,
+		Amount:     pair.Amount,
+		CheckedAt:  time.Now(),
+	}
+	defer func() {
+		v.mu.Lock()
+		v.results[v.qc.getCacheKey(pair.InputMint, pair.OutputMint, pair.Amount)] = result
+		v.mu.Unlock()
+	}()
+
+	amountIn, ok := math.NewIntFromString(pair.Amount)
+	if !ok || !amountIn.IsPositive() {
+		result.Error = "invalid amount"
+		return
+	}
+	depth, err := v.qc.Depth(ctx, pair.InputMint, pair.OutputMint, []math.Int{amountIn})
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	if len(depth.Best) == 0 {
+		result.Error = "no pool can fill the amount"
+		return
+	}
+
+	localQuote := func(protocol string, level DepthLevel) jupiter.LocalQuote {
+		out, _ := math.NewIntFromString(level.OutAmount)
+		return jupiter.LocalQuote{Protocol: protocol, PoolID: level.PoolID, OutAmount: out}
+	}
+	quotes := []jupiter.LocalQuote{localQuote(jupiter.AllProtocols, depth.Best[0])}
+	protocols := make([]string, 0, len(depth.Protocols))
+	for protocol := range depth.Protocols {
+		protocols = append(protocols, protocol)
+	}
+	sort.Strings(protocols)
+	for _, protocol := range protocols {
+		quotes = append(quotes, localQuote(protocol, depth.Protocols[protocol][0]))
+	}
+
+	result.Comparisons = v.client.Compare(ctx, pair.InputMint, pair.OutputMint, amountIn, quotes)
+	for _, c := range result.Comparisons {
+		if c.Error == "" && c.SamePool && (c.DeviationBps > v.maxDeviationBps || c.DeviationBps < -v.maxDeviationBps) {
+			log.Printf("⚠️  %s quote for %s deviates %.2f bps from Jupiter on pool %s (local %s, Jupiter %s)",
+				c.Protocol, pair.Label, c.DeviationBps, c.PoolID, c.LocalOut, c.JupiterOut)
+		}
+	}
+}
+
+// Results returns the latest validation of every pair checked so far
+func (v *validator) Results() []*PairValidation {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	results := make([]*PairValidation, 0, len(v.results))
+	for _, result := range v.results {
+		results = append(results, result)
+	}
+	return results
+}
+
+// handleValidation serves GET /validation with the latest Jupiter comparisons
+func handleValidation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"interval":        validation.interval.String(),
+		"maxDeviationBps": validation.maxDeviationBps,
+		"pairs":           validation.Results(),
+	})
+}
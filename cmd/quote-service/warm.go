@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"soltrading/pkg"
+)
+
+// Warm quotes popular pairs ahead of the first requests for them, at most
+// workers at a time. Their pools are discovered and subscribed like an
+// on-demand quote's, and pairs already cached are skipped. Unlike monitored
+// pairs they are not refreshed periodically; the update stream keeps them
+// fresh until they go idle.
+func (qc *QuoteCache) Warm(ctx context.Context, pairs []QuotePair, workers int) {
+	if len(pairs) == 0 {
+		return
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	start := time.Now()
+	log.Printf("Warming cache with %d pairs (%d workers)...", len(pairs), workers)
+	qc.prefetchPools(ctx, pairs)
+
+	var failed int32
+	jobs := make(chan QuotePair)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pair := range jobs {
+				if _, err := qc.GetOrCalculateQuote(ctx, pair.InputMint, pair.OutputMint, pair.Amount, nil, nil, 0); err != nil {
+					log.Printf("Failed to warm %s: %v", pair.Label, err)
+					atomic.AddInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, pair := range pairs {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- pair:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	log.Printf("Cache warm-up complete: %d of %d pairs in %s", len(pairs)-int(failed), len(pairs), time.Since(start).Round(time.Millisecond))
+}
+
+// prefetchPools discovers the pools of all pairs with one batched lookup per
+// protocol, so warming a pair starts from its pools instead of a discovery of
+// its own. Pairs a protocol failed on are left to on-demand discovery.
+func (qc *QuoteCache) prefetchPools(ctx context.Context, pairs []QuotePair) {
+	var lookups []pkg.Pair
+	seen := make(map[string]bool)
+	for _, pair := range pairs {
+		key := pairKey(pair.InputMint, pair.OutputMint)
+		if !seen[key] {
+			seen[key] = true
+			lookups = append(lookups, pkg.Pair{BaseMint: pair.InputMint, QuoteMint: pair.OutputMint})
+		}
+	}
+
+	found := make(map[string][]pkg.Pool, len(lookups))
+	failed := make(map[string]bool)
+	for _, proto := range qc.currentRouter().Protocols {
+		res, err := proto.FetchPoolsByPairs(ctx, lookups)
+		if err != nil {
+			log.Printf("Batched pool discovery on %s failed for some pairs: %v", proto.ProtocolName(), err)
+		}
+		for _, pair := range lookups {
+			key := pairKey(pair.BaseMint, pair.QuoteMint)
+			pools, ok := res[pair]
+			if !ok {
+				failed[key] = true
+				continue
+			}
+			found[key] = append(found[key], pools...)
+		}
+	}
+
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	if qc.prefetchedPools == nil {
+		qc.prefetchedPools = make(map[string][]pkg.Pool)
+	}
+	for key, pools := range found {
+		if !failed[key] && len(pools) > 0 {
+			qc.prefetchedPools[key] = pools
+		}
+	}
+}
+
+// takePrefetchedPools returns and forgets the pools Warm found for a pair
+func (qc *QuoteCache) takePrefetchedPools(inputMint, outputMint string) []pkg.Pool {
+	key := pairKey(inputMint, outputMint)
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	pools := qc.prefetchedPools[key]
+	delete(qc.prefetchedPools, key)
+	return pools
+}
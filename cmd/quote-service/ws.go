@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPingInterval = 30 * time.Second
+	wsPongTimeout  = 2 * wsPingInterval
+)
+
+var wsUpgrader = websocket.Upgrader{
+	// The API is public and CORS is open, so accept any origin
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsRequest is a message from a /ws client
+type wsRequest struct {
+	Type  string         `json:"type"` // "subscribe" or "unsubscribe"
+	Pairs []QuoteRequest `json:"pairs"`
+}
+
+// wsMessage is a message pushed to a /ws client
+type wsMessage struct {
+	Type  string        `json:"type"` // "quote", "subscribed", "unsubscribed" or "error"
+	Quote *CachedQuote  `json:"quote,omitempty"`
+	Pair  *QuoteRequest `json:"pair,omitempty"`
+	Error string        `json:"error,omitempty"`
+}
+
+// handleWebSocket streams quote updates for the pairs a client subscribes to.
+// Updates are pushed whenever a pool behind a subscribed quote changes.
+func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	watcher := quoteCache.hub.subscribe()
+	defer quoteCache.hub.unsubscribe(watcher)
+
+	// Replies from the reader go through the writer, the connection's only writer
+	replies := make(chan wsMessage, quoteStreamBuffer)
+	go readWebSocket(ctx, cancel, conn, watcher, replies)
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		var msg wsMessage
+		select {
+		case <-ctx.Done():
+			return
+		case msg = <-replies:
+		case update := <-watcher.C:
+			msg = wsMessage{Type: "quote", Quote: quoteCache.WithSlotAge(update.Quote)}
+		case <-ping.C:
+			quoteCache.KeepWatched(watcher)
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			continue
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := conn.WriteJSON(msg); err != nil {
+			log.Printf("WebSocket client write failed: %v", err)
+			return
+		}
+	}
+}
+
+// readWebSocket applies a client's subscribe and unsubscribe requests until
+// the connection closes
+func readWebSocket(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, watcher *quoteWatcher, replies chan<- wsMessage) {
+	defer cancel()
+
+	conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	reply := func(msg wsMessage) {
+		select {
+		case replies <- msg:
+		case <-ctx.Done():
+		}
+	}
+
+	for {
+		var req wsRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("WebSocket client read failed: %v", err)
+			}
+			return
+		}
+
+		for i := range req.Pairs {
+			pairReq := req.Pairs[i]
+			if pairReq.InputMint == "" || pairReq.OutputMint == "" || pairReq.Amount == "" {
+				reply(wsMessage{Type: "error", Pair: &pairReq, Error: "inputMint, outputMint and amount are required"})
+				continue
+			}
+			pair := QuotePair{
+				InputMint:  pairReq.InputMint,
+				OutputMint: pairReq.OutputMint,
+				Amount:     pairReq.Amount,
+			}
+
+			switch req.Type {
+			case "subscribe":
+				quote, err := quoteCache.Watch(ctx, watcher, pair)
+				if err != nil {
+					reply(wsMessage{Type: "error", Pair: &pairReq, Error: err.Error()})
+					continue
+				}
+				reply(wsMessage{Type: "subscribed", Pair: &pairReq})
+				reply(wsMessage{Type: "quote", Quote: quoteCache.WithSlotAge(quote)})
+			case "unsubscribe":
+				quoteCache.Unwatch(watcher, pair)
+				reply(wsMessage{Type: "unsubscribed", Pair: &pairReq})
+			default:
+				reply(wsMessage{Type: "error", Error: "unknown message type " + req.Type})
+			}
+		}
+	}
+}
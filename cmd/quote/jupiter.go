@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"cosmossdk.io/math"
+	"soltrading/cmd/internal/output"
+	"soltrading/pkg"
+	"soltrading/pkg/jupiter"
+	"soltrading/pkg/router"
+	"soltrading/pkg/sol"
+)
+
+// compareJupiter checks the best route overall and the best pool of each
+// protocol against Jupiter's quote for the same input
+func compareJupiter(ctx context.Context, solClient sol.SolClient, r *router.SimpleRouter, tokenIn, tokenOut string, amountIn math.Int, bestPool pkg.Pool, bestOut math.Int) []jupiter.Comparison {
+	quotes := []jupiter.LocalQuote{{Protocol: jupiter.AllProtocols, PoolID: bestPool.GetID(), OutAmount: bestOut}}
+
+	seen := make(map[pkg.ProtocolName]bool)
+	for _, pool := range r.Pools {
+		name := pool.ProtocolName()
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		protocolPool, out, err := r.GetBestPoolWithFilter(ctx, solClient, tokenIn, amountIn, []string{string(name)}, nil, 0)
+		if err != nil {
+			log.Printf("No %s quote to compare: %v", name, err)
+			continue
+		}
+		quotes = append(quotes, jupiter.LocalQuote{Protocol: string(name), PoolID: protocolPool.GetID(), OutAmount: out})
+	}
+
+	return jupiter.NewClient(*jupiterURL).Compare(ctx, tokenIn, tokenOut, amountIn, quotes)
+}
+
+// comparisonRecords is the table and CSV form of the Jupiter comparison, one
+// row per protocol
+func comparisonRecords(comparisons []jupiter.Comparison) output.Records {
+	records := output.Records{Columns: []string{
+		"protocol", "pool_id", "local_out", "jupiter_out", "jupiter_pool_id", "same_pool", "deviation_bps", "error",
+	}}
+	for _, c := range comparisons {
+		deviation := ""
+		if c.JupiterOut != "" {
+			deviation = strconv.FormatFloat(c.DeviationBps, 'f', 2, 64)
+		}
+		records.Add(c.Protocol, c.PoolID, c.LocalOut, c.JupiterOut, c.JupiterPoolID,
+			strconv.FormatBool(c.SamePool), deviation, c.Error)
+	}
+	return records
+}
@@ -8,24 +8,38 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
+	"soltrading/cmd/internal/cli"
+	"soltrading/cmd/internal/output"
+	"soltrading/pkg"
 	"soltrading/pkg/config"
+	"soltrading/pkg/jupiter"
 	"soltrading/pkg/protocol"
 	"soltrading/pkg/router"
 	"soltrading/pkg/sol"
 )
 
+// QuoteResponse is the quote output. OtherAmountThreshold is the minimum
+// output for ExactIn quotes and the maximum input for ExactOut quotes.
 type QuoteResponse struct {
 	InputMint            string      `json:"inputMint"`
 	OutputMint           string      `json:"outputMint"`
 	InAmount             string      `json:"inAmount"`
 	OutAmount            string      `json:"outAmount"`
+	InAmountUI           string      `json:"inAmountUi,omitempty"`
+	OutAmountUI          string      `json:"outAmountUi,omitempty"`
 	PriceImpact          string      `json:"priceImpact,omitempty"`
 	RoutePlan            []RoutePlan `json:"routePlan"`
 	SlippageBps          int         `json:"slippageBps"`
+	SwapMode             string      `json:"swapMode"`
 	OtherAmountThreshold string      `json:"otherAmountThreshold"`
+	// Routes lists the top routes, best first, with -routes
+	Routes []RouteOption `json:"routes,omitempty"`
+	// Jupiter compares the quotes with Jupiter's, with -compare-jupiter
+	Jupiter []jupiter.Comparison `json:"jupiter,omitempty"`
 }
 
 type RoutePlan struct {
@@ -45,11 +59,23 @@ var (
 	rpcEndpoints = flag.String("rpc", "", "Comma-separated Solana RPC endpoints (reads from .env if not specified)")
 	inputMint    = flag.String("input", "", "Input token mint address (required)")
 	outputMint   = flag.String("output", "", "Output token mint address (required)")
-	amount       = flag.String("amount", "", "Input amount in smallest units (required)")
+	amount       = flag.String("amount", "", "Input amount, or the output amount with -exact-out: smallest units, or token units such as 1.5 (required)")
+	decimals     = flag.Int("decimals", -1, cli.DecimalsUsage)
+	exactOut     = flag.Bool("exact-out", false, "Quote the input needed to receive exactly -amount of the output token")
+	routeCount   = flag.Int("routes", 0, "Also print the top N routes with their output and price impact (0 = best route only)")
 	slippageBps  = flag.Int("slippage", 50, "Slippage tolerance in basis points (default: 50 = 0.5%)")
 	rateLimit    = flag.Int("ratelimit", 20, "RPC requests per second limit per endpoint (default: 20)")
 	jsonOutput   = flag.Bool("json", true, "Output as JSON (default: true)")
+	outputFormat = flag.String("format", "", output.FlagUsage)
 	useRpcPool   = flag.Bool("use-pool", true, "Use RPC pool for load balancing (default: true)")
+	timeout      = flag.Duration("timeout", 30*time.Second, "Timeout for each RPC request attempt")
+	retries      = flag.Int("retries", 2, "Retries for RPC requests that time out, fail to connect or get a 429 or 5xx response")
+	maxAccounts  = flag.Int("max-accounts", 0, "Only select routes whose swap transaction uses at most this many accounts (0 = no limit)")
+	maxTxBytes   = flag.Int("max-tx-bytes", 0, "Only select routes whose swap transaction fits in this many bytes (0 = no limit)")
+	compareJup   = flag.Bool("compare-jupiter", false, "Compare the best route and each protocol's best pool with Jupiter's quote")
+	jupiterURL   = flag.String("jupiter-url", jupiter.DefaultBaseURL, "Jupiter quote API base URL used by -compare-jupiter")
+
+	format = output.JSON
 )
 
 func main() {
@@ -60,6 +86,12 @@ func main() {
 
 	flag.Parse()
 
+	var err error
+	if format, err = output.Resolve(*outputFormat, *jsonOutput); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+
 	// Validate required flags
 	if *inputMint == "" || *outputMint == "" || *amount == "" {
 		fmt.Fprintln(os.Stderr, "Error: Missing required arguments")
@@ -73,6 +105,27 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *routeCount < 0 {
+		outputError("Invalid -routes: must not be negative")
+		os.Exit(1)
+	}
+	if *routeCount > 0 && *exactOut {
+		outputError("-routes cannot be combined with -exact-out")
+		os.Exit(1)
+	}
+	if *timeout <= 0 {
+		outputError("Invalid -timeout: must be positive")
+		os.Exit(1)
+	}
+	if *retries < 0 {
+		outputError("Invalid -retries: must not be negative")
+		os.Exit(1)
+	}
+	if *compareJup && *exactOut {
+		outputError("-compare-jupiter cannot be combined with -exact-out")
+		os.Exit(1)
+	}
+
 	// Parse and validate addresses
 	inTokenAddr, err := solana.PublicKeyFromBase58(*inputMint)
 	if err != nil {
@@ -86,15 +139,13 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Parse amount
-	amountIn, ok := math.NewIntFromString(*amount)
-	if !ok || amountIn.LTE(math.ZeroInt()) {
-		outputError("Invalid amount: must be a positive integer")
+	ctx := context.Background()
+
+	if err := cli.ApplyCluster(); err != nil {
+		outputError(err.Error())
 		os.Exit(1)
 	}
 
-	ctx := context.Background()
-
 	// Parse RPC endpoints
 	var endpoints []string
 	if *rpcEndpoints != "" {
@@ -112,30 +163,50 @@ func main() {
 		}
 	}
 
+	// Bound and retry each RPC request so a slow endpoint cannot hang the command
+	clientOpts := sol.DefaultClientOptions()
+	clientOpts.Transport.RequestTimeout = *timeout
+	clientOpts.Transport.Retries = *retries
+
 	// Initialize RPC pool or single client
 	var rpcPool *sol.RPCPool
 	var solClient *sol.Client
 
 	if *useRpcPool && len(endpoints) > 1 {
 		// Use RPC pool for load balancing
-		rpcPool, err = sol.NewRPCPool(ctx, endpoints, "", *rateLimit)
+		rpcPool, err = sol.NewRPCPoolWithOptions(ctx, endpoints, "", *rateLimit, clientOpts)
 		if err != nil {
 			outputError(fmt.Sprintf("Failed to create RPC pool: %v", err))
 			os.Exit(1)
 		}
 		solClient = rpcPool.GetClient()
-		if !*jsonOutput {
+		if format != output.JSON {
 			log.Printf("Using RPC pool with %d endpoints", rpcPool.Size())
 		}
 	} else {
 		// Use single client
-		solClient, err = sol.NewClient(ctx, endpoints[0], "", *rateLimit)
+		solClient, err = sol.NewClientWithOptions(ctx, endpoints[0], "", *rateLimit, clientOpts)
 		if err != nil {
 			outputError(fmt.Sprintf("Failed to create Solana client: %v", err))
 			os.Exit(1)
 		}
 	}
 
+	// Parse amount, in token units when it has a decimal point
+	inDecimals, outDecimals := cli.PairDecimals(ctx, solClient, inTokenAddr.String(), outTokenAddr.String())
+	amountDecimals := &inDecimals
+	if *exactOut {
+		amountDecimals = &outDecimals
+	}
+	if *decimals >= 0 {
+		*amountDecimals = *decimals
+	}
+	parsedAmount, err := cli.ParseAmount(*amount, *amountDecimals)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
 	// Initialize router with all protocols
 	r := router.NewSimpleRouter(
 		protocol.NewPumpAmm(solClient),
@@ -144,9 +215,10 @@ func main() {
 		protocol.NewRaydiumCpmm(solClient),
 		protocol.NewMeteoraDlmm(solClient),
 	)
+	r.SetTxConstraints(*maxAccounts, *maxTxBytes)
 
 	// Query available pools
-	if !*jsonOutput {
+	if format != output.JSON {
 		log.Printf("Querying available pools for %s -> %s...", *inputMint, *outputMint)
 	}
 
@@ -161,39 +233,58 @@ func main() {
 		os.Exit(1)
 	}
 
-	if !*jsonOutput {
+	if format != output.JSON {
 		log.Printf("Found %d pools", len(r.Pools))
 	}
 
 	// Get best pool and quote
-	bestPool, amountOut, err := r.GetBestPool(ctx, solClient, inTokenAddr.String(), amountIn)
+	var bestPool pkg.Pool
+	var amountIn, amountOut, threshold math.Int
+	var routes []RouteOption
+	swapMode := "ExactIn"
+	if *exactOut {
+		swapMode = "ExactOut"
+		amountOut = parsedAmount
+		bestPool, amountIn, err = r.GetBestPoolExactOut(ctx, solClient, inTokenAddr.String(), outTokenAddr.String(), amountOut)
+	} else if *routeCount > 0 {
+		amountIn = parsedAmount
+		var top []router.Route
+		top, err = r.GetTopRoutesWithFilter(ctx, solClient, inTokenAddr.String(), amountIn, nil, nil, 0, *routeCount)
+		if err == nil {
+			bestPool, amountOut = top[0].Pool, top[0].OutAmount
+			routes = routeOptions(ctx, solClient, top, inTokenAddr.String(), amountIn, *slippageBps, outDecimals)
+		}
+	} else {
+		amountIn = parsedAmount
+		bestPool, amountOut, err = r.GetBestPool(ctx, solClient, inTokenAddr.String(), amountIn)
+	}
 	if err != nil {
 		outputError(fmt.Sprintf("Failed to get best pool: %v", err))
 		os.Exit(1)
 	}
 
-	// Calculate minimum amount out with slippage
-	minAmountOut := amountOut.Mul(math.NewInt(int64(10000 - *slippageBps))).Quo(math.NewInt(10000))
-
-	// Get protocol name from pool
-	protocolName := "unknown"
-	for _, p := range r.Protocols {
-		for _, pool := range r.Pools {
-			if pool.GetID() == bestPool.GetID() {
-				protocolName = string(p.ProtocolName())
-				break
-			}
-		}
+	if *exactOut {
+		// Calculate maximum amount in with slippage, rounded up
+		threshold = amountIn.Mul(math.NewInt(int64(10000 + *slippageBps))).AddRaw(9999).Quo(math.NewInt(10000))
+	} else {
+		// Calculate minimum amount out with slippage
+		threshold = amountOut.Mul(math.NewInt(int64(10000 - *slippageBps))).Quo(math.NewInt(10000))
 	}
 
+	protocolName := string(bestPool.ProtocolName())
+
 	// Build response
 	response := QuoteResponse{
 		InputMint:            inTokenAddr.String(),
 		OutputMint:           outTokenAddr.String(),
 		InAmount:             amountIn.String(),
 		OutAmount:            amountOut.String(),
+		InAmountUI:           cli.FormatUnits(amountIn, inDecimals),
+		OutAmountUI:          cli.FormatUnits(amountOut, outDecimals),
 		SlippageBps:          *slippageBps,
-		OtherAmountThreshold: minAmountOut.String(),
+		SwapMode:             swapMode,
+		OtherAmountThreshold: threshold.String(),
+		Routes:               routes,
 		RoutePlan: []RoutePlan{
 			{
 				Protocol:   protocolName,
@@ -205,27 +296,58 @@ func main() {
 			},
 		},
 	}
+	if len(routes) > 0 {
+		response.PriceImpact = routes[0].PriceImpact
+	}
+	if *compareJup {
+		if format != output.JSON {
+			log.Printf("Comparing with Jupiter...")
+		}
+		response.Jupiter = compareJupiter(ctx, solClient, r, inTokenAddr.String(), outTokenAddr.String(), amountIn, bestPool, amountOut)
+	}
 
 	// Output result
-	if *jsonOutput {
-		jsonData, err := json.MarshalIndent(response, "", "  ")
-		if err != nil {
-			outputError(fmt.Sprintf("Failed to marshal JSON: %v", err))
+	if format != output.Text {
+		records := quoteRecords(response)
+		if *compareJup {
+			records = comparisonRecords(response.Jupiter)
+		}
+		if err := output.Print(os.Stdout, format, response, records); err != nil {
+			outputError(err.Error())
 			os.Exit(1)
 		}
-		fmt.Println(string(jsonData))
 	} else {
 		fmt.Printf("\n=== Quote Results ===\n")
 		fmt.Printf("Route: %s\n", protocolName)
 		fmt.Printf("Pool ID: %s\n", bestPool.GetID())
-		fmt.Printf("Input: %s %s\n", amountIn.String(), *inputMint)
-		fmt.Printf("Output: %s %s\n", amountOut.String(), *outputMint)
-		fmt.Printf("Minimum Output (with %d bps slippage): %s\n", *slippageBps, minAmountOut.String())
+		fmt.Printf("Input: %s %s\n", withUnits(amountIn, inDecimals), *inputMint)
+		fmt.Printf("Output: %s %s\n", withUnits(amountOut, outDecimals), *outputMint)
+		if *exactOut {
+			fmt.Printf("Maximum Input (with %d bps slippage): %s\n", *slippageBps, withUnits(threshold, inDecimals))
+		} else {
+			fmt.Printf("Minimum Output (with %d bps slippage): %s\n", *slippageBps, withUnits(threshold, outDecimals))
+		}
+		if len(routes) > 0 {
+			fmt.Printf("\n=== Top %d Routes ===\n", len(routes))
+			printRouteTable(routes)
+		}
+		if *compareJup {
+			fmt.Printf("\n=== Jupiter Comparison ===\n")
+			output.PrintTable(os.Stdout, comparisonRecords(response.Jupiter))
+		}
+	}
+}
+
+// withUnits formats a raw amount followed by its token units, when known
+func withUnits(amount math.Int, decimals int) string {
+	if units := cli.FormatUnits(amount, decimals); units != "" {
+		return fmt.Sprintf("%s (%s)", amount, units)
 	}
+	return amount.String()
 }
 
 func outputError(msg string) {
-	if *jsonOutput {
+	if format == output.JSON {
 		errResp := QuoteError{Error: msg}
 		jsonData, _ := json.MarshalIndent(errResp, "", "  ")
 		fmt.Fprintln(os.Stderr, string(jsonData))
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"cosmossdk.io/math"
+	"soltrading/cmd/internal/cli"
+	"soltrading/cmd/internal/output"
+	"soltrading/pkg/router"
+	"soltrading/pkg/sol"
+)
+
+// RouteOption is one of the top routes printed with -routes
+type RouteOption struct {
+	Protocol             string `json:"protocol"`
+	PoolID               string `json:"poolId"`
+	OutAmount            string `json:"outAmount"`
+	OutAmountUI          string `json:"outAmountUi,omitempty"`
+	OtherAmountThreshold string `json:"otherAmountThreshold"`
+	PriceImpact          string `json:"priceImpact,omitempty"` // percent
+}
+
+// routeOptions describes routes with their minimum output and price impact
+func routeOptions(ctx context.Context, solClient sol.SolClient, routes []router.Route, tokenIn string, amountIn math.Int, slippageBps, outDecimals int) []RouteOption {
+	options := make([]RouteOption, len(routes))
+	for i, route := range routes {
+		options[i] = RouteOption{
+			Protocol:             string(route.Pool.ProtocolName()),
+			PoolID:               route.Pool.GetID(),
+			OutAmount:            route.OutAmount.String(),
+			OutAmountUI:          cli.FormatUnits(route.OutAmount, outDecimals),
+			OtherAmountThreshold: route.OutAmount.Mul(math.NewInt(int64(10000 - slippageBps))).Quo(math.NewInt(10000)).String(),
+		}
+		if impact, err := router.PriceImpact(ctx, solClient, route.Pool, tokenIn, amountIn, route.OutAmount); err == nil {
+			options[i].PriceImpact = strconv.FormatFloat(impact*100, 'f', 4, 64)
+		}
+	}
+	return options
+}
+
+// printRouteTable prints the routes as an aligned table
+func printRouteTable(options []RouteOption) {
+	records := output.Records{Columns: []string{"#", "protocol", "pool", "out_amount", "out_units", "min_out", "price_impact"}}
+	for i, option := range options {
+		impact := ""
+		if option.PriceImpact != "" {
+			impact = option.PriceImpact + "%"
+		}
+		records.Add(strconv.Itoa(i+1), option.Protocol, option.PoolID, option.OutAmount, option.OutAmountUI, option.OtherAmountThreshold, impact)
+	}
+	output.PrintTable(os.Stdout, records)
+}
+
+// quoteRecords is the table and CSV form of a quote: one row for the best
+// route, or one per route with -routes
+func quoteRecords(response QuoteResponse) output.Records {
+	records := output.Records{Columns: []string{
+		"rank", "protocol", "pool_id", "input_mint", "output_mint", "swap_mode",
+		"in_amount", "out_amount", "in_amount_ui", "out_amount_ui", "other_amount_threshold", "slippage_bps", "price_impact",
+	}}
+	row := func(rank int, protocol, poolID, outAmount, outAmountUI, threshold, impact string) {
+		records.Add(strconv.Itoa(rank), protocol, poolID, response.InputMint, response.OutputMint, response.SwapMode,
+			response.InAmount, outAmount, response.InAmountUI, outAmountUI, threshold, strconv.Itoa(response.SlippageBps), impact)
+	}
+
+	if len(response.Routes) == 0 {
+		best := response.RoutePlan[0]
+		row(1, best.Protocol, best.PoolID, response.OutAmount, response.OutAmountUI, response.OtherAmountThreshold, response.PriceImpact)
+		return records
+	}
+	for i, route := range response.Routes {
+		row(i+1, route.Protocol, route.PoolID, route.OutAmount, route.OutAmountUI, route.OtherAmountThreshold, route.PriceImpact)
+	}
+	return records
+}
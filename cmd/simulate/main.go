@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/cmd/internal/cli"
+	"soltrading/cmd/internal/output"
+	"soltrading/pkg"
+	"soltrading/pkg/config"
+	"soltrading/pkg/router"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/swap"
+	"soltrading/pkg/wallet"
+)
+
+// SimulateResponse is the simulate output
+type SimulateResponse struct {
+	Success        bool        `json:"success"`
+	TxError        interface{} `json:"txError,omitempty"`
+	Wallet         string      `json:"wallet"`
+	InputMint      string      `json:"inputMint"`
+	OutputMint     string      `json:"outputMint"`
+	Protocol       string      `json:"protocol"`
+	PoolID         string      `json:"poolId"`
+	AmountIn       string      `json:"amountIn"`
+	QuotedOut      string      `json:"quotedOut"`
+	MinOut         string      `json:"minOut"`
+	SimulatedOut   string      `json:"simulatedOut"`
+	AmountInUI     string      `json:"amountInUi,omitempty"`
+	QuotedOutUI    string      `json:"quotedOutUi,omitempty"`
+	SimulatedOutUI string      `json:"simulatedOutUi,omitempty"`
+	DiffBps        int64       `json:"diffBps"` // simulated vs quoted output
+	UnitsConsumed  uint64      `json:"unitsConsumed"`
+	Logs           []string    `json:"logs"`
+}
+
+type SimulateError struct {
+	Error string `json:"error"`
+}
+
+var (
+	rpcEndpoints = flag.String("rpc", "", "Comma-separated Solana RPC endpoints (reads from .env if not specified)")
+	inputMint    = flag.String("input", "", "Input token mint address (required)")
+	outputMint   = flag.String("output", "", "Output token mint address (required)")
+	amount       = flag.String("amount", "", "Input amount, or the output amount with -exact-out: smallest units, or token units such as 1.5 (required)")
+	decimals     = flag.Int("decimals", -1, cli.DecimalsUsage)
+	exactOut     = flag.Bool("exact-out", false, "Simulate the input needed to receive -amount of the output token")
+	walletKey    = flag.String("wallet", "", "Public key of the wallet to simulate for (required unless -keypair is set)")
+	keypair      = flag.String("keypair", "", "Wallet keypair to take the public key from: file path, file:<path>, env:<VAR> or mnemonic:<VAR>")
+	protocolList = flag.String("protocols", "", "Comma-separated protocols to route through (default: all)")
+	slippageBps  = flag.Int("slippage", 50, "Slippage tolerance in basis points (default: 50 = 0.5%)")
+	rateLimit    = flag.Int("ratelimit", 20, "RPC requests per second limit per endpoint (default: 20)")
+	jsonOutput   = flag.Bool("json", true, "Output as JSON (default: true)")
+	outputFormat = flag.String("format", "", output.FlagUsage)
+	useRpcPool   = flag.Bool("use-pool", true, "Use RPC pool for load balancing (default: true)")
+	maxAccounts  = flag.Int("max-accounts", 0, "Only select routes whose swap transaction uses at most this many accounts (0 = no limit)")
+	maxTxBytes   = flag.Int("max-tx-bytes", 0, "Only select routes whose swap transaction fits in this many bytes (0 = no limit)")
+	cuLimit      = flag.Uint("cu-limit", 0, "Compute unit limit (0 = the 1.4M maximum)")
+	cuPrice      = flag.Uint64("cu-price", 0, "Compute unit price in micro-lamports (0 = recent fee market)")
+
+	format = output.JSON
+)
+
+func main() {
+	// Load .env file
+	if err := config.LoadEnv(".env"); err != nil {
+		log.Printf("Warning: Could not load .env file: %v", err)
+	}
+
+	flag.Parse()
+
+	var err error
+	if format, err = output.Resolve(*outputFormat, *jsonOutput); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+
+	// Validate required flags
+	if *inputMint == "" || *outputMint == "" || *amount == "" || (*walletKey == "" && *keypair == "") {
+		fmt.Fprintln(os.Stderr, "Error: Missing required arguments")
+		fmt.Fprintln(os.Stderr, "\nUsage:")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExample:")
+		fmt.Fprintln(os.Stderr, "  simulate -input So11111111111111111111111111111111111111112 \\")
+		fmt.Fprintln(os.Stderr, "           -output EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v \\")
+		fmt.Fprintln(os.Stderr, "           -amount 10000000 -wallet <public key>")
+		os.Exit(1)
+	}
+
+	if *slippageBps < 0 || *slippageBps > 10000 {
+		outputError("Invalid -slippage: must be between 0 and 10000")
+		os.Exit(1)
+	}
+
+	inTokenAddr, err := solana.PublicKeyFromBase58(*inputMint)
+	if err != nil {
+		outputError(fmt.Sprintf("Invalid input mint address: %v", err))
+		os.Exit(1)
+	}
+	outTokenAddr, err := solana.PublicKeyFromBase58(*outputMint)
+	if err != nil {
+		outputError(fmt.Sprintf("Invalid output mint address: %v", err))
+		os.Exit(1)
+	}
+
+	// Signatures are not verified by simulation, so only the public key is needed
+	var user solana.PublicKey
+	if *walletKey != "" {
+		if user, err = solana.PublicKeyFromBase58(*walletKey); err != nil {
+			outputError(fmt.Sprintf("Invalid wallet address: %v", err))
+			os.Exit(1)
+		}
+	} else {
+		w, err := wallet.Load(*keypair)
+		if err != nil {
+			outputError(fmt.Sprintf("Failed to load wallet: %v", err))
+			os.Exit(1)
+		}
+		user = w.PublicKey()
+	}
+
+	ctx := context.Background()
+
+	if err := cli.ApplyCluster(); err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	endpoints, err := cli.Endpoints(*rpcEndpoints)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+	solClient, _, err := cli.NewClient(ctx, endpoints, *rateLimit, *useRpcPool)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+	protocols, err := cli.Protocols(solClient, cli.SplitList(*protocolList))
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	// Parse amount, in token units when it has a decimal point
+	inDecimals, outDecimals := cli.PairDecimals(ctx, solClient, inTokenAddr.String(), outTokenAddr.String())
+	amountDecimals := &inDecimals
+	if *exactOut {
+		amountDecimals = &outDecimals
+	}
+	if *decimals >= 0 {
+		*amountDecimals = *decimals
+	}
+	parsedAmount, err := cli.ParseAmount(*amount, *amountDecimals)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+
+	r := router.NewSimpleRouter(protocols...)
+	r.SetTxConstraints(*maxAccounts, *maxTxBytes)
+
+	if format != output.JSON {
+		log.Printf("Querying available pools for %s -> %s...", *inputMint, *outputMint)
+	}
+	if err := r.QueryAllPools(ctx, inTokenAddr.String(), outTokenAddr.String()); err != nil {
+		outputError(fmt.Sprintf("Failed to query pools: %v", err))
+		os.Exit(1)
+	}
+	if len(r.Pools) == 0 {
+		outputError("No pools found for this token pair")
+		os.Exit(1)
+	}
+
+	var bestPool pkg.Pool
+	var amountIn, quotedOut math.Int
+	if *exactOut {
+		quotedOut = parsedAmount
+		bestPool, amountIn, err = r.GetBestPoolExactOut(ctx, solClient, inTokenAddr.String(), outTokenAddr.String(), quotedOut)
+	} else {
+		amountIn = parsedAmount
+		bestPool, quotedOut, err = r.GetBestPool(ctx, solClient, inTokenAddr.String(), amountIn)
+	}
+	if err != nil {
+		outputError(fmt.Sprintf("Failed to get best pool: %v", err))
+		os.Exit(1)
+	}
+	if !amountIn.IsUint64() {
+		outputError("Invalid amount: input exceeds the token amount range")
+		os.Exit(1)
+	}
+
+	response, err := simulate(ctx, solClient, user, bestPool, inTokenAddr, outTokenAddr, amountIn, quotedOut)
+	if err != nil {
+		outputError(err.Error())
+		os.Exit(1)
+	}
+	response.AmountInUI = cli.FormatUnits(amountIn, inDecimals)
+	response.QuotedOutUI = cli.FormatUnits(quotedOut, outDecimals)
+	if simulatedOut, ok := math.NewIntFromString(response.SimulatedOut); ok {
+		response.SimulatedOutUI = cli.FormatUnits(simulatedOut, outDecimals)
+	}
+
+	if format != output.Text {
+		if err := output.Print(os.Stdout, format, response, simulateRecords(response)); err != nil {
+			outputError(err.Error())
+			os.Exit(1)
+		}
+	} else {
+		fmt.Printf("\n=== Simulation Results ===\n")
+		if response.Success {
+			fmt.Printf("Status: success\n")
+		} else {
+			fmt.Printf("Status: failed (%v)\n", response.TxError)
+		}
+		fmt.Printf("Wallet: %s\n", response.Wallet)
+		fmt.Printf("Route: %s\n", response.Protocol)
+		fmt.Printf("Pool ID: %s\n", response.PoolID)
+		fmt.Printf("Quoted: %s %s -> %s %s\n", withUnits(response.AmountIn, response.AmountInUI), response.InputMint,
+			withUnits(response.QuotedOut, response.QuotedOutUI), response.OutputMint)
+		fmt.Printf("Minimum Output (with %d bps slippage): %s\n", *slippageBps, response.MinOut)
+		fmt.Printf("Simulated Output: %s, %+d bps vs quote\n", withUnits(response.SimulatedOut, response.SimulatedOutUI), response.DiffBps)
+		fmt.Printf("Compute Units: %d\n", response.UnitsConsumed)
+		fmt.Printf("\nLogs:\n")
+		for _, line := range response.Logs {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	if !response.Success {
+		os.Exit(1)
+	}
+}
+
+// simulate builds the swap transaction for the pool with blank signatures and
+// runs it through simulateTransaction, watching the wallet's output account
+func simulate(ctx context.Context, solClient *sol.Client, user solana.PublicKey, pool pkg.Pool, inTokenAddr, outTokenAddr solana.PublicKey, amountIn, quotedOut math.Int) (*SimulateResponse, error) {
+	minOut := quotedOut.Mul(math.NewInt(int64(10000 - *slippageBps))).Quo(math.NewInt(10000))
+
+	limit := uint32(*cuLimit)
+	if limit == 0 {
+		// A fixed limit avoids a second simulation inside the builder, which
+		// would fail before the logs of a failing swap could be shown
+		limit = swap.MaxComputeUnitLimit
+	}
+	builder := swap.NewBuilder(solClient, swap.Options{
+		ComputeBudget: swap.ComputeBudgetOptions{
+			Enabled:          true,
+			ComputeUnitLimit: limit,
+			ComputeUnitPrice: *cuPrice,
+		},
+		WrapSOL:    true,
+		CreateATAs: true,
+	})
+	instructions, err := builder.BuildSwapInstructions(ctx, pool, user, inTokenAddr.String(), amountIn, minOut, solana.PublicKey{}, solana.PublicKey{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build swap instructions: %w", err)
+	}
+
+	tx, err := solana.NewTransaction(instructions, solana.Hash{}, solana.TransactionPayer(user))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+
+	watched, err := outputAccount(ctx, solClient, user, outTokenAddr)
+	if err != nil {
+		return nil, err
+	}
+	result, err := solClient.Simulate(ctx, tx, watched)
+	if err != nil {
+		return nil, err
+	}
+
+	simulatedOut := math.NewIntFromUint64(result.ExpectedOut)
+	response := &SimulateResponse{
+		Success:       result.Success,
+		TxError:       result.Err,
+		Wallet:        user.String(),
+		InputMint:     inTokenAddr.String(),
+		OutputMint:    outTokenAddr.String(),
+		Protocol:      string(pool.ProtocolName()),
+		PoolID:        pool.GetID(),
+		AmountIn:      amountIn.String(),
+		QuotedOut:     quotedOut.String(),
+		MinOut:        minOut.String(),
+		SimulatedOut:  simulatedOut.String(),
+		UnitsConsumed: result.UnitsConsumed,
+		Logs:          result.Logs,
+	}
+	if result.Success && quotedOut.IsPositive() {
+		response.DiffBps = simulatedOut.Sub(quotedOut).MulRaw(10000).Quo(quotedOut).Int64()
+	}
+	return response, nil
+}
+
+// outputAccount is the account whose balance change is the swap output: the
+// wallet itself for SOL, which is unwrapped at the end of the swap, and the
+// wallet's associated token account otherwise
+func outputAccount(ctx context.Context, solClient *sol.Client, user, mint solana.PublicKey) (solana.PublicKey, error) {
+	if mint.Equals(sol.WSOL) {
+		return user, nil
+	}
+	tokenProgram, err := sol.MintTokenProgram(ctx, solClient, mint)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	ata, _, err := solana.FindProgramAddress([][]byte{user[:], tokenProgram[:], mint[:]}, solana.SPLAssociatedTokenAccountProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive output token account: %w", err)
+	}
+	return ata, nil
+}
+
+// simulateRecords is the table and CSV form of the simulation, one row per
+// value followed by one row per log line
+func simulateRecords(response *SimulateResponse) output.Records {
+	records := output.Records{Columns: []string{"field", "value"}}
+	status := "success"
+	if !response.Success {
+		status = fmt.Sprintf("failed: %v", response.TxError)
+	}
+	records.Add("status", status)
+	records.Add("wallet", response.Wallet)
+	records.Add("protocol", response.Protocol)
+	records.Add("pool_id", response.PoolID)
+	records.Add("input_mint", response.InputMint)
+	records.Add("output_mint", response.OutputMint)
+	records.Add("amount_in", response.AmountIn)
+	records.Add("quoted_out", response.QuotedOut)
+	records.Add("min_out", response.MinOut)
+	records.Add("simulated_out", response.SimulatedOut)
+	records.Add("amount_in_ui", response.AmountInUI)
+	records.Add("quoted_out_ui", response.QuotedOutUI)
+	records.Add("simulated_out_ui", response.SimulatedOutUI)
+	records.Add("diff_bps", strconv.FormatInt(response.DiffBps, 10))
+	records.Add("units_consumed", strconv.FormatUint(response.UnitsConsumed, 10))
+	for _, line := range response.Logs {
+		records.Add("log", strings.TrimSpace(line))
+	}
+	return records
+}
+
+// withUnits formats a raw amount followed by its token units, when known
+func withUnits(amount, units string) string {
+	if units != "" {
+		return fmt.Sprintf("%s (%s)", amount, units)
+	}
+	return amount
+}
+
+func outputError(msg string) {
+	if format == output.JSON {
+		errResp := SimulateError{Error: msg}
+		jsonData, _ := json.MarshalIndent(errResp, "", "  ")
+		fmt.Fprintln(os.Stderr, string(jsonData))
+	} else {
+		log.Println("Error:", msg)
+	}
+}
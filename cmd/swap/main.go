@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/cmd/internal/cli"
+	"soltrading/cmd/internal/output"
+	"soltrading/pkg"
+	"soltrading/pkg/config"
+	"soltrading/pkg/executor"
+	"soltrading/pkg/jito"
+	"soltrading/pkg/protocol"
+	"soltrading/pkg/router"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/wallet"
+)
+
+// SwapResult is the swap output: the executor report with its amounts also in
+// token units
+type SwapResult struct {
+	*executor.Report
+	AmountInUI    string `json:"amountInUi,omitempty"`
+	QuotedOutUI   string `json:"quotedOutUi,omitempty"`
+	MinOutUI      string `json:"minOutUi,omitempty"`
+	RealizedInUI  string `json:"realizedInUi,omitempty"`
+	RealizedOutUI string `json:"realizedOutUi,omitempty"`
+}
+
+type SwapError struct {
+	Error     string `json:"error"`
+	Signature string `json:"signature,omitempty"`
+}
+
+var (
+	rpcEndpoints = flag.String("rpc", "", "Comma-separated Solana RPC endpoints (reads from .env if not specified)")
+	inputMint    = flag.String("input", "", "Input token mint address (required)")
+	outputMint   = flag.String("output", "", "Output token mint address (required)")
+	amount       = flag.String("amount", "", "Input amount, or the output amount with -exact-out: smallest units, or token units such as 1.5 (required)")
+	decimals     = flag.Int("decimals", -1, cli.DecimalsUsage)
+	exactOut     = flag.Bool("exact-out", false, "Swap the input needed to receive -amount of the output token")
+	keypair      = flag.String("keypair", "", "Wallet to sign with: keypair file path, file:<path>, env:<VAR> or mnemonic:<VAR> (required)")
+	slippageBps  = flag.Int("slippage", 50, "Slippage tolerance in basis points (default: 50 = 0.5%)")
+	rateLimit    = flag.Int("ratelimit", 20, "RPC requests per second limit per endpoint (default: 20)")
+	jsonOutput   = flag.Bool("json", true, "Output as JSON (default: true)")
+	outputFormat = flag.String("format", "", output.FlagUsage)
+	useRpcPool   = flag.Bool("use-pool", true, "Use RPC pool for load balancing and broadcasting (default: true)")
+	maxAccounts  = flag.Int("max-accounts", 0, "Only select routes whose swap transaction uses at most this many accounts (0 = no limit)")
+	maxTxBytes   = flag.Int("max-tx-bytes", 0, "Only select routes whose swap transaction fits in this many bytes (0 = no limit)")
+	cuLimit      = flag.Uint("cu-limit", 0, "Compute unit limit (0 = simulate and add a 20% margin)")
+	cuPrice      = flag.Uint64("cu-price", 0, "Compute unit price in micro-lamports (0 = recent fee market)")
+	feePercent   = flag.Int("fee-percentile", 75, "Fee-market percentile used when -cu-price is 0")
+	maxCUPrice   = flag.Uint64("max-cu-price", 0, "Cap on the fee-market compute unit price (0 = no cap)")
+	useJito      = flag.Bool("jito", false, "Send the swap as a Jito bundle with a tip instead of through RPC")
+	jitoRegion   = flag.String("jito-region", "mainnet", "Jito block engine region, or \"fastest\" to probe all regions")
+	jitoTip      = flag.Uint64("jito-tip", jito.DefaultTipLamports, "Jito tip in lamports")
+	timeout      = flag.Duration("timeout", 90*time.Second, "Time to wait for the swap to confirm")
+	skipChecks   = flag.Bool("skip-validation", false, "Skip the balance and token account checks before sending")
+
+	format = output.JSON
+)
+
+func main() {
+	// Load .env file
+	if err := config.LoadEnv(".env"); err != nil {
+		log.Printf("Warning: Could not load .env file: %v", err)
+	}
+
+	flag.Parse()
+
+	var err error
+	if format, err = output.Resolve(*outputFormat, *jsonOutput); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+
+	// Validate required flags
+	if *inputMint == "" || *outputMint == "" || *amount == "" || *keypair == "" {
+		fmt.Fprintln(os.Stderr, "Error: Missing required arguments")
+		fmt.Fprintln(os.Stderr, "\nUsage:")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExample:")
+		fmt.Fprintln(os.Stderr, "  swap -input So11111111111111111111111111111111111111112 \\")
+		fmt.Fprintln(os.Stderr, "       -output EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v \\")
+		fmt.Fprintln(os.Stderr, "       -amount 10000000 -keypair ~/.config/solana/id.json")
+		fmt.Fprintln(os.Stderr, "\nNote: Uses default Helius RPC pool if -rpc not specified")
+		os.Exit(1)
+	}
+
+	if *slippageBps < 0 || *slippageBps > 10000 {
+		outputError("Invalid -slippage: must be between 0 and 10000", "")
+		os.Exit(1)
+	}
+
+	// Parse and validate addresses
+	inTokenAddr, err := solana.PublicKeyFromBase58(*inputMint)
+	if err != nil {
+		outputError(fmt.Sprintf("Invalid input mint address: %v", err), "")
+		os.Exit(1)
+	}
+
+	outTokenAddr, err := solana.PublicKeyFromBase58(*outputMint)
+	if err != nil {
+		outputError(fmt.Sprintf("Invalid output mint address: %v", err), "")
+		os.Exit(1)
+	}
+
+	w, err := wallet.Load(*keypair)
+	if err != nil {
+		outputError(fmt.Sprintf("Failed to load wallet: %v", err), "")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if err := cli.ApplyCluster(); err != nil {
+		outputError(err.Error(), "")
+		os.Exit(1)
+	}
+
+	// Parse RPC endpoints
+	var endpoints []string
+	if *rpcEndpoints != "" {
+		endpoints = strings.Split(*rpcEndpoints, ",")
+		for i := range endpoints {
+			endpoints[i] = strings.TrimSpace(endpoints[i])
+		}
+	} else {
+		endpoints = config.GetRPCEndpoints()
+		if len(endpoints) == 0 {
+			outputError("No RPC endpoints configured. Set RPC_ENDPOINTS in .env or use -rpc flag", "")
+			os.Exit(1)
+		}
+	}
+
+	// Initialize RPC pool or single client
+	var rpcPool *sol.RPCPool
+	var solClient *sol.Client
+
+	if *useRpcPool && len(endpoints) > 1 {
+		rpcPool, err = sol.NewRPCPool(ctx, endpoints, "", *rateLimit)
+		if err != nil {
+			outputError(fmt.Sprintf("Failed to create RPC pool: %v", err), "")
+			os.Exit(1)
+		}
+		solClient = rpcPool.GetClient()
+		if format != output.JSON {
+			log.Printf("Using RPC pool with %d endpoints", rpcPool.Size())
+		}
+	} else {
+		solClient, err = sol.NewClient(ctx, endpoints[0], "", *rateLimit)
+		if err != nil {
+			outputError(fmt.Sprintf("Failed to create Solana client: %v", err), "")
+			os.Exit(1)
+		}
+	}
+
+	// Parse amount, in token units when it has a decimal point
+	inDecimals, outDecimals := cli.PairDecimals(ctx, solClient, inTokenAddr.String(), outTokenAddr.String())
+	amountDecimals := &inDecimals
+	if *exactOut {
+		amountDecimals = &outDecimals
+	}
+	if *decimals >= 0 {
+		*amountDecimals = *decimals
+	}
+	parsedAmount, err := cli.ParseAmount(*amount, *amountDecimals)
+	if err != nil {
+		outputError(err.Error(), "")
+		os.Exit(1)
+	}
+
+	// Initialize router with all protocols
+	r := router.NewSimpleRouter(
+		protocol.NewPumpAmm(solClient),
+		protocol.NewRaydiumAmm(solClient),
+		protocol.NewRaydiumClmm(solClient),
+		protocol.NewRaydiumCpmm(solClient),
+		protocol.NewMeteoraDlmm(solClient),
+	)
+	r.SetTxConstraints(*maxAccounts, *maxTxBytes)
+
+	if format != output.JSON {
+		log.Printf("Querying available pools for %s -> %s...", *inputMint, *outputMint)
+	}
+
+	err = r.QueryAllPools(ctx, inTokenAddr.String(), outTokenAddr.String())
+	if err != nil {
+		outputError(fmt.Sprintf("Failed to query pools: %v", err), "")
+		os.Exit(1)
+	}
+
+	if len(r.Pools) == 0 {
+		outputError("No pools found for this token pair", "")
+		os.Exit(1)
+	}
+
+	// Get best pool and quote. Pools only swap exact-in, so an exact-out swap
+	// spends the input found to deliver -amount, and -amount is the quoted output.
+	var bestPool pkg.Pool
+	var amountIn, quotedOut math.Int
+	if *exactOut {
+		quotedOut = parsedAmount
+		bestPool, amountIn, err = r.GetBestPoolExactOut(ctx, solClient, inTokenAddr.String(), outTokenAddr.String(), quotedOut)
+	} else {
+		amountIn = parsedAmount
+		bestPool, quotedOut, err = r.GetBestPool(ctx, solClient, inTokenAddr.String(), amountIn)
+	}
+	if err != nil {
+		outputError(fmt.Sprintf("Failed to get best pool: %v", err), "")
+		os.Exit(1)
+	}
+	if !amountIn.IsUint64() {
+		outputError("Invalid amount: input exceeds the token amount range", "")
+		os.Exit(1)
+	}
+
+	if format != output.JSON {
+		log.Printf("Swapping %s via %s pool %s, quoted output %s", withUnits(amountIn.String(), inDecimals), bestPool.ProtocolName(), bestPool.GetID(), withUnits(quotedOut.String(), outDecimals))
+	}
+
+	cfg := executor.DefaultConfig()
+	cfg.SlippageBps = *slippageBps
+	cfg.SkipValidation = *skipChecks
+	cfg.JitoTipLamports = *jitoTip
+	cfg.ConfirmTimeout = *timeout
+	cfg.Swap.ComputeBudget.ComputeUnitLimit = uint32(*cuLimit)
+	cfg.Swap.ComputeBudget.ComputeUnitPrice = *cuPrice
+	cfg.Swap.ComputeBudget.PriorityFeePercentile = *feePercent
+	cfg.Swap.ComputeBudget.MaxComputeUnitPrice = *maxCUPrice
+
+	exec := executor.New(solClient, w, cfg).WithAnalytics(executor.NewAnalytics())
+	if rpcPool != nil {
+		exec.WithRPCPool(rpcPool)
+	}
+	if *useJito {
+		region := jito.Region(*jitoRegion)
+		if *jitoRegion == "fastest" {
+			region = jito.SelectFastestRegion(ctx, nil)
+		}
+		jitoClient, err := jito.NewClient(ctx, jito.Config{Region: region})
+		if err != nil {
+			outputError(fmt.Sprintf("Failed to create Jito client: %v", err), "")
+			os.Exit(1)
+		}
+		exec.WithJito(jitoClient)
+		if format != output.JSON {
+			log.Printf("Sending through Jito block engine %s", jitoClient.Endpoint())
+		}
+	}
+
+	report, err := exec.Execute(ctx, executor.Request{
+		Pool:       bestPool,
+		InputMint:  inTokenAddr.String(),
+		OutputMint: outTokenAddr.String(),
+		AmountIn:   amountIn,
+		QuotedOut:  quotedOut,
+	})
+	if err != nil {
+		outputError(fmt.Sprintf("Swap failed: %v", err), report.Signature)
+		os.Exit(1)
+	}
+
+	result := SwapResult{
+		Report:      report,
+		AmountInUI:  units(report.AmountIn, inDecimals),
+		QuotedOutUI: units(report.QuotedOut, outDecimals),
+		MinOutUI:    units(report.MinOut, outDecimals),
+	}
+	if report.Metrics != nil {
+		result.RealizedInUI = units(report.Metrics.RealizedIn, inDecimals)
+		result.RealizedOutUI = units(report.Metrics.RealizedOut, outDecimals)
+	}
+
+	// Output result
+	if format != output.Text {
+		if err := output.Print(os.Stdout, format, result, reportRecords(result)); err != nil {
+			outputError(err.Error(), report.Signature)
+			os.Exit(1)
+		}
+	} else {
+		fmt.Printf("\n=== Swap Results ===\n")
+		fmt.Printf("Signature: %s\n", report.Signature)
+		if report.BundleID != "" {
+			fmt.Printf("Bundle ID: %s\n", report.BundleID)
+		}
+		fmt.Printf("Slot: %d\n", report.Slot)
+		fmt.Printf("Route: %s\n", report.Protocol)
+		fmt.Printf("Pool ID: %s\n", report.PoolID)
+		fmt.Printf("Quoted: %s %s -> %s %s\n", withUnits(report.AmountIn, inDecimals), report.InputMint, withUnits(report.QuotedOut, outDecimals), report.OutputMint)
+		fmt.Printf("Minimum Output (with %d bps slippage): %s\n", *slippageBps, withUnits(report.MinOut, outDecimals))
+		if report.Metrics != nil {
+			fmt.Printf("Realized Input: %s %s\n", withUnits(report.Metrics.RealizedIn, inDecimals), report.InputMint)
+			fmt.Printf("Realized Output: %s %s\n", withUnits(report.Metrics.RealizedOut, outDecimals), report.OutputMint)
+			fmt.Printf("Slippage vs Quote: %d bps\n", report.Metrics.SlippageBps)
+			fmt.Printf("Fee: %d lamports, %d compute units\n", report.Metrics.FeeLamports, report.Metrics.ComputeUnits)
+		}
+		fmt.Printf("Duration: %s\n", report.Duration.Round(time.Millisecond))
+	}
+}
+
+// reportRecords is the table and CSV form of a swap report
+func reportRecords(result SwapResult) output.Records {
+	report := result.Report
+	records := output.Records{Columns: []string{
+		"status", "signature", "bundle_id", "slot", "protocol", "pool_id", "input_mint", "output_mint",
+		"amount_in", "quoted_out", "min_out", "realized_in", "realized_out",
+		"amount_in_ui", "quoted_out_ui", "min_out_ui", "realized_in_ui", "realized_out_ui", "slippage_bps",
+		"fee_lamports", "compute_units", "duration_ms",
+	}}
+	var realizedIn, realizedOut, slippage, fee, units string
+	if m := report.Metrics; m != nil {
+		realizedIn, realizedOut = m.RealizedIn, m.RealizedOut
+		slippage = strconv.FormatInt(m.SlippageBps, 10)
+		fee = strconv.FormatUint(m.FeeLamports, 10)
+		units = strconv.FormatUint(m.ComputeUnits, 10)
+	}
+	records.Add(report.Status, report.Signature, report.BundleID, strconv.FormatUint(report.Slot, 10),
+		report.Protocol, report.PoolID, report.InputMint, report.OutputMint,
+		report.AmountIn, report.QuotedOut, report.MinOut, realizedIn, realizedOut,
+		result.AmountInUI, result.QuotedOutUI, result.MinOutUI, result.RealizedInUI, result.RealizedOutUI, slippage,
+		fee, units, strconv.FormatInt(report.Duration.Milliseconds(), 10))
+	return records
+}
+
+// units formats a raw amount string in token units; "" when unknown
+func units(amount string, decimals int) string {
+	raw, ok := math.NewIntFromString(amount)
+	if !ok {
+		return ""
+	}
+	return cli.FormatUnits(raw, decimals)
+}
+
+// withUnits formats a raw amount string followed by its token units, when known
+func withUnits(amount string, decimals int) string {
+	if u := units(amount, decimals); u != "" {
+		return fmt.Sprintf("%s (%s)", amount, u)
+	}
+	return amount
+}
+
+func outputError(msg, signature string) {
+	if format == output.JSON {
+		errResp := SwapError{Error: msg, Signature: signature}
+		jsonData, _ := json.MarshalIndent(errResp, "", "  ")
+		fmt.Fprintln(os.Stderr, string(jsonData))
+	} else {
+		log.Println("Error:", msg)
+		if signature != "" {
+			log.Println("Signature:", signature)
+		}
+	}
+}
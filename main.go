@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"log"
+	"time"
 
 	"cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/jito"
 	"soltrading/pkg/protocol"
 	"soltrading/pkg/router"
 	"soltrading/pkg/sol"
+	"soltrading/pkg/swap"
 )
 
 var (
@@ -24,6 +27,7 @@ var (
 	solDecimal      = float64(1e9)
 	slippageBps     = 100 // 1% slippage
 	useJito         = false
+	jitoTipLamports = uint64(1000000)
 	isSimulate      = true
 )
 
@@ -85,7 +89,10 @@ func main() {
 	log.Printf("Selected best pool: %v, amountOut: %v", bestPool.GetID(), amountOut)
 
 	minAmountOut := amountOut.Mul(math.NewInt(int64(10000 - slippageBps))).Quo(math.NewInt(10000))
-	instructionsBuy, err := bestPool.BuildSwapInstructions(ctx, solClient,
+	builder := swap.NewBuilder(solClient, swap.Options{
+		ComputeBudget: swap.ComputeBudgetOptions{Enabled: true},
+	})
+	instructionsBuy, err := builder.BuildSwapInstructions(ctx, bestPool,
 		privateKey.PublicKey(), inTokenAddr.String(), amountIn, minAmountOut, inTokenAccount, outTokenAccount)
 	if err != nil {
 		log.Fatalf("Failed to build swap instructions: %v", err)
@@ -93,21 +100,40 @@ func main() {
 	signers = append(signers, privateKey)
 	instructions = append(instructions, instructionsBuy...)
 
+	var jitoClient *jito.Client
+	if useJito {
+		jitoClient, err = jito.NewClient(ctx, jito.Config{Endpoint: jitoRpc})
+		if err != nil {
+			log.Fatalf("Failed to create jito client: %v", err)
+		}
+		instructions = append(instructions, jitoClient.TipInstruction(privateKey.PublicKey(), jitoTipLamports))
+	}
+
 	tx, err := solClient.SignTransaction(ctx, signers, instructions...)
 	if err != nil {
 		log.Fatalf("Failed to SendTx: %v", err)
 	}
 
 	if isSimulate {
-		if _, err := solClient.SimulateTransaction(ctx, tx); err != nil {
+		simResult, err := solClient.Simulate(ctx, tx, outTokenAccount)
+		if err != nil {
 			log.Fatalf("Failed to simulate transaction: %v", err)
 		}
+		if !simResult.Success {
+			log.Fatalf("Simulation failed: %v", simResult.Err)
+		}
+		log.Printf("Simulation ok: expected out %d, compute units %d", simResult.ExpectedOut, simResult.UnitsConsumed)
 	}
 	if useJito {
-		_, err = solClient.SendTxWithJito(ctx, 1000000, signers, tx)
+		bundle, err := jito.NewBundle(tx)
+		if err != nil {
+			log.Fatalf("Failed to build bundle: %v", err)
+		}
+		status, err := jitoClient.SendAndWait(ctx, bundle, 60*time.Second)
 		if err != nil {
-			log.Fatalf("Failed to SendTxWithJito: %v", err)
+			log.Fatalf("Failed to land bundle: %v", err)
 		}
+		log.Printf("Bundle landed in slot %d: https://solscan.io/tx/%v", status.Slot, tx.Signatures[0])
 	} else {
 		sig, err := solClient.SendTx(ctx, tx)
 		if err != nil {
@@ -1,12 +1,60 @@
 package anchor
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 )
 
+// ErrAccountMismatch matches every AccountMismatchError with errors.Is
+var ErrAccountMismatch = errors.New("account data does not match the expected account")
+
+// AccountMismatchError reports account data that is not the expected Anchor
+// account, such as a pool address that points at another account type
+type AccountMismatchError struct {
+	Account string // the expected account
+
+	// Set when the discriminator differs
+	Want, Got []byte
+
+	// Set when the data is shorter than the account layout
+	MinSize, Size int
+}
+
+func (e *AccountMismatchError) Error() string {
+	if e.Want != nil {
+		return fmt.Sprintf("account data is not a %s: discriminator %v, expected %v", e.Account, e.Got, e.Want)
+	}
+	return fmt.Sprintf("account data is not a %s: expected at least %d bytes, got %d", e.Account, e.MinSize, e.Size)
+}
+
+func (e *AccountMismatchError) Is(target error) bool {
+	return target == ErrAccountMismatch
+}
+
 func GetDiscriminator(namespace string, name string) []byte {
 	preimage := fmt.Sprintf("%s:%s", namespace, name)
 	hash := sha256.Sum256([]byte(preimage))
 	return hash[:8]
 }
+
+// VerifyAccount checks that data starts with the discriminator of the named
+// Anchor account and holds at least minSize bytes, discriminator included
+func VerifyAccount(name string, data []byte, minSize int) error {
+	return verifyAccount(name, GetDiscriminator("account", name), data, minSize)
+}
+
+func verifyAccount(name string, discriminator, data []byte, minSize int) error {
+	if len(data) >= len(discriminator) && !bytes.Equal(data[:len(discriminator)], discriminator) {
+		return &AccountMismatchError{
+			Account: name,
+			Want:    discriminator,
+			Got:     append([]byte(nil), data[:len(discriminator)]...),
+		}
+	}
+	if len(data) < max(minSize, len(discriminator)) {
+		return &AccountMismatchError{Account: name, MinSize: max(minSize, len(discriminator)), Size: len(data)}
+	}
+	return nil
+}
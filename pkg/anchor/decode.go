@@ -0,0 +1,441 @@
+package anchor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+
+	cosmath "cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"lukechampine.com/uint128"
+)
+
+// Struct is a decoded struct, keyed by IDL field name
+type Struct map[string]interface{}
+
+// Enum is a decoded enum value. Fields is nil for variants without fields.
+type Enum struct {
+	Variant string
+	Index   int
+	Fields  Struct
+}
+
+// DecodeAccount decodes data as the named account after checking its
+// discriminator and, for fixed-size layouts, its size. Mismatches return an
+// *AccountMismatchError. Integers decode to their Go types, 128-bit integers to
+// *big.Int, public keys to solana.PublicKey, u8 arrays and bytes to []byte,
+// other arrays and vecs to []interface{}, and absent options to nil.
+func (idl *IDL) DecodeAccount(name string, data []byte) (Struct, error) {
+	account, err := idl.Account(name)
+	if err != nil {
+		return nil, err
+	}
+	body, err := idl.layout(name)
+	if err != nil {
+		return nil, err
+	}
+	size, _ := idl.bodySize(body)
+	if err := verifyAccount(name, account.Discriminator, data, len(account.Discriminator)+size); err != nil {
+		return nil, err
+	}
+
+	d := &decoder{idl: idl, data: data, pos: len(account.Discriminator)}
+	value, err := d.body(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	s, ok := value.(Struct)
+	if !ok {
+		return nil, fmt.Errorf("account %s is not a struct", name)
+	}
+	return s, nil
+}
+
+// DecodeAccountInto decodes data as the named account into the struct v
+// points to. Fields match by an `idl:"name"` tag, or else by name ignoring
+// case and underscores, so fee_rate fills FeeRate. Fields of v missing from
+// the IDL are left alone, as are IDL fields v does not have.
+func (idl *IDL) DecodeAccountInto(name string, data []byte, v interface{}) error {
+	decoded, err := idl.DecodeAccount(name, data)
+	if err != nil {
+		return err
+	}
+	dst := reflect.ValueOf(v)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() {
+		return fmt.Errorf("DecodeAccountInto needs a non-nil pointer, got %T", v)
+	}
+	return assign(dst.Elem(), decoded, name)
+}
+
+type decoder struct {
+	idl  *IDL
+	data []byte
+	pos  int
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if d.pos+n > len(d.data) {
+		return nil, fmt.Errorf("data too short: need %d bytes at offset %d, have %d", n, d.pos, len(d.data))
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) body(body *IDLTypeDefBody) (interface{}, error) {
+	if body.Kind == "enum" {
+		b, err := d.next(1)
+		if err != nil {
+			return nil, err
+		}
+		index := int(b[0])
+		if index >= len(body.Variants) {
+			return nil, fmt.Errorf("enum variant %d out of range", index)
+		}
+		variant := body.Variants[index]
+		e := Enum{Variant: variant.Name, Index: index}
+		if len(variant.Fields) > 0 {
+			if e.Fields, err = d.fields(variant.Fields); err != nil {
+				return nil, err
+			}
+		}
+		return e, nil
+	}
+	return d.fields(body.Fields)
+}
+
+func (d *decoder) fields(fields IDLFields) (Struct, error) {
+	s := make(Struct, len(fields))
+	for _, f := range fields {
+		value, err := d.value(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		s[f.Name] = value
+	}
+	return s, nil
+}
+
+func (d *decoder) value(t IDLType) (interface{}, error) {
+	switch {
+	case t.Vec != nil:
+		b, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.sequence(*t.Vec, int(binary.LittleEndian.Uint32(b)))
+	case t.Array != nil:
+		return d.sequence(*t.Array, t.Len)
+	case t.Option != nil:
+		b, err := d.next(1)
+		if err != nil || b[0] == 0 {
+			return nil, err
+		}
+		return d.value(*t.Option)
+	case t.COption != nil:
+		b, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.value(*t.COption)
+		if err != nil || binary.LittleEndian.Uint32(b) == 0 {
+			return nil, err
+		}
+		return value, nil
+	case t.Defined != "":
+		body, err := d.idl.layout(t.Defined)
+		if err != nil {
+			return nil, err
+		}
+		return d.body(body)
+	}
+
+	size, fixed := d.idl.Size(t)
+	if !fixed {
+		// string and bytes carry a u32 length
+		b, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.next(int(binary.LittleEndian.Uint32(b)))
+		if err != nil {
+			return nil, err
+		}
+		switch t.Primitive {
+		case "string":
+			return string(raw), nil
+		case "bytes":
+			return append([]byte(nil), raw...), nil
+		}
+		return nil, fmt.Errorf("unsupported type %q", t.Primitive)
+	}
+	b, err := d.next(size)
+	if err != nil {
+		return nil, err
+	}
+	switch t.Primitive {
+	case "bool":
+		return b[0] != 0, nil
+	case "u8":
+		return b[0], nil
+	case "i8":
+		return int8(b[0]), nil
+	case "u16":
+		return binary.LittleEndian.Uint16(b), nil
+	case "i16":
+		return int16(binary.LittleEndian.Uint16(b)), nil
+	case "u32":
+		return binary.LittleEndian.Uint32(b), nil
+	case "i32":
+		return int32(binary.LittleEndian.Uint32(b)), nil
+	case "u64":
+		return binary.LittleEndian.Uint64(b), nil
+	case "i64":
+		return int64(binary.LittleEndian.Uint64(b)), nil
+	case "f32":
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case "f64":
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case "u128", "i128":
+		n := new(big.Int).SetBytes(reversed(b))
+		if t.Primitive == "i128" && b[15]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), 128))
+		}
+		return n, nil
+	case "pubkey":
+		return solana.PublicKeyFromBytes(b), nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", t.Primitive)
+}
+
+func (d *decoder) sequence(elem IDLType, n int) (interface{}, error) {
+	if elem.Primitive == "u8" {
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	}
+	values := make([]interface{}, n)
+	for i := range values {
+		value, err := d.value(elem)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func reversed(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+var (
+	publicKeyType = reflect.TypeOf(solana.PublicKey{})
+	uint128Type   = reflect.TypeOf(uint128.Uint128{})
+	bigIntType    = reflect.TypeOf(big.Int{})
+	cosmathType   = reflect.TypeOf(cosmath.Int{})
+)
+
+// normalize makes IDL and Go field names comparable
+func normalize(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// assign stores a decoded value in dst, converting to dst's type
+func assign(dst reflect.Value, value interface{}, path string) error {
+	if value == nil {
+		return nil
+	}
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), value, path)
+	}
+	mismatch := func() error {
+		return fmt.Errorf("%s: cannot store %T in %s", path, value, dst.Type())
+	}
+
+	switch dst.Type() {
+	case publicKeyType:
+		key, ok := value.(solana.PublicKey)
+		if !ok {
+			return mismatch()
+		}
+		dst.Set(reflect.ValueOf(key))
+		return nil
+	case uint128Type, bigIntType, cosmathType:
+		n, ok := value.(*big.Int)
+		if !ok {
+			n = toBig(value)
+		}
+		if n == nil {
+			return mismatch()
+		}
+		switch dst.Type() {
+		case uint128Type:
+			if n.Sign() < 0 || n.BitLen() > 128 {
+				return fmt.Errorf("%s: %s does not fit in uint128", path, n)
+			}
+			dst.Set(reflect.ValueOf(uint128.FromBig(n)))
+		case bigIntType:
+			dst.Addr().Interface().(*big.Int).Set(n)
+		default:
+			dst.Set(reflect.ValueOf(cosmath.NewIntFromBigInt(n)))
+		}
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		dst.Set(reflect.ValueOf(value))
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return mismatch()
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := toBig(value)
+		if e, ok := value.(Enum); ok {
+			n = big.NewInt(int64(e.Index))
+		}
+		if n == nil || !n.IsInt64() || dst.OverflowInt(n.Int64()) {
+			return mismatch()
+		}
+		dst.SetInt(n.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n := toBig(value)
+		if e, ok := value.(Enum); ok {
+			n = big.NewInt(int64(e.Index))
+		}
+		if n == nil || !n.IsUint64() || dst.OverflowUint(n.Uint64()) {
+			return mismatch()
+		}
+		dst.SetUint(n.Uint64())
+	case reflect.Float32, reflect.Float64:
+		switch f := value.(type) {
+		case float32:
+			dst.SetFloat(float64(f))
+		case float64:
+			dst.SetFloat(f)
+		default:
+			return mismatch()
+		}
+	case reflect.String:
+		switch s := value.(type) {
+		case string:
+			dst.SetString(s)
+		case Enum:
+			dst.SetString(s.Variant)
+		default:
+			return mismatch()
+		}
+	case reflect.Array, reflect.Slice:
+		return assignSequence(dst, value, path)
+	case reflect.Struct:
+		s, ok := value.(Struct)
+		if e, isEnum := value.(Enum); isEnum {
+			s, ok = e.Fields, true
+		}
+		if !ok {
+			return mismatch()
+		}
+		return assignStruct(dst, s, path)
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+func assignSequence(dst reflect.Value, value interface{}, path string) error {
+	var items []interface{}
+	switch v := value.(type) {
+	case []byte:
+		items = make([]interface{}, len(v))
+		for i, b := range v {
+			items[i] = b
+		}
+	case []interface{}:
+		items = v
+	default:
+		return fmt.Errorf("%s: cannot store %T in %s", path, value, dst.Type())
+	}
+
+	if dst.Kind() == reflect.Array {
+		if dst.Len() != len(items) {
+			return fmt.Errorf("%s: cannot store %d elements in %s", path, len(items), dst.Type())
+		}
+	} else {
+		dst.Set(reflect.MakeSlice(dst.Type(), len(items), len(items)))
+	}
+	for i, item := range items {
+		if err := assign(dst.Index(i), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func assignStruct(dst reflect.Value, s Struct, path string) error {
+	byName := make(map[string]interface{}, len(s))
+	for name, value := range s {
+		byName[normalize(name)] = value
+	}
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Tag.Get("idl")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		value, ok := byName[normalize(name)]
+		if !ok {
+			continue
+		}
+		if err := assign(dst.Field(i), value, path+"."+f.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toBig converts a decoded integer to *big.Int, or returns nil
+func toBig(value interface{}) *big.Int {
+	switch n := value.(type) {
+	case *big.Int:
+		return n
+	case uint8:
+		return new(big.Int).SetUint64(uint64(n))
+	case uint16:
+		return new(big.Int).SetUint64(uint64(n))
+	case uint32:
+		return new(big.Int).SetUint64(uint64(n))
+	case uint64:
+		return new(big.Int).SetUint64(n)
+	case int8:
+		return big.NewInt(int64(n))
+	case int16:
+		return big.NewInt(int64(n))
+	case int32:
+		return big.NewInt(int64(n))
+	case int64:
+		return big.NewInt(n)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package anchor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// IDL is an Anchor program's interface description. Both the 0.30+ format,
+// where accounts carry their discriminator and their layout lives in Types,
+// and the legacy format with inline account layouts are accepted.
+type IDL struct {
+	Address  string       `json:"address"`
+	Name     string       `json:"name"`
+	Accounts []IDLAccount `json:"accounts"`
+	Types    []IDLTypeDef `json:"types"`
+
+	types map[string]*IDLTypeDefBody
+}
+
+// IDLAccount is an account declared by the program
+type IDLAccount struct {
+	Name          string          `json:"name"`
+	Discriminator []byte          `json:"discriminator,omitempty"`
+	Type          *IDLTypeDefBody `json:"type,omitempty"` // legacy inline layout
+}
+
+// IDLTypeDef is a named struct or enum
+type IDLTypeDef struct {
+	Name string         `json:"name"`
+	Type IDLTypeDefBody `json:"type"`
+}
+
+// IDLTypeDefBody is the layout of a struct or enum
+type IDLTypeDefBody struct {
+	Kind     string       `json:"kind"` // "struct" or "enum"
+	Fields   IDLFields    `json:"fields,omitempty"`
+	Variants []IDLVariant `json:"variants,omitempty"`
+}
+
+// IDLVariant is one enum variant, with named or tuple fields
+type IDLVariant struct {
+	Name   string    `json:"name"`
+	Fields IDLFields `json:"fields,omitempty"`
+}
+
+// IDLField is a named field. Tuple fields are named by their index.
+type IDLField struct {
+	Name string  `json:"name"`
+	Type IDLType `json:"type"`
+}
+
+// IDLFields accepts both named fields and tuple field types
+type IDLFields []IDLField
+
+func (f *IDLFields) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	fields := make(IDLFields, len(raw))
+	for i, r := range raw {
+		var named struct {
+			Name string   `json:"name"`
+			Type *IDLType `json:"type"`
+		}
+		if json.Unmarshal(r, &named) == nil && named.Name != "" && named.Type != nil {
+			fields[i] = IDLField{Name: named.Name, Type: *named.Type}
+			continue
+		}
+		fields[i].Name = strconv.Itoa(i)
+		if err := json.Unmarshal(r, &fields[i].Type); err != nil {
+			return err
+		}
+	}
+	*f = fields
+	return nil
+}
+
+// IDLType is a field type: a primitive such as "u64" or "pubkey", or a vec,
+// option, array or defined type
+type IDLType struct {
+	Primitive string
+	Vec       *IDLType
+	Option    *IDLType
+	COption   *IDLType
+	Array     *IDLType
+	Len       int
+	Defined   string
+}
+
+func (t *IDLType) UnmarshalJSON(data []byte) error {
+	var primitive string
+	if json.Unmarshal(data, &primitive) == nil {
+		if primitive == "publicKey" {
+			primitive = "pubkey"
+		}
+		t.Primitive = primitive
+		return nil
+	}
+
+	var composite struct {
+		Vec     *IDLType          `json:"vec"`
+		Option  *IDLType          `json:"option"`
+		COption *IDLType          `json:"coption"`
+		Array   []json.RawMessage `json:"array"`
+		Defined json.RawMessage   `json:"defined"`
+	}
+	if err := json.Unmarshal(data, &composite); err != nil {
+		return fmt.Errorf("invalid IDL type %s: %w", data, err)
+	}
+	switch {
+	case composite.Vec != nil:
+		t.Vec = composite.Vec
+	case composite.Option != nil:
+		t.Option = composite.Option
+	case composite.COption != nil:
+		t.COption = composite.COption
+	case len(composite.Array) == 2:
+		t.Array = new(IDLType)
+		if err := json.Unmarshal(composite.Array[0], t.Array); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(composite.Array[1], &t.Len); err != nil {
+			return fmt.Errorf("unsupported array length %s", composite.Array[1])
+		}
+	case len(composite.Defined) > 0:
+		// "defined" is a name in legacy IDLs and {"name": ...} since 0.30
+		if json.Unmarshal(composite.Defined, &t.Defined) != nil {
+			var named struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(composite.Defined, &named); err != nil {
+				return fmt.Errorf("invalid defined type %s: %w", composite.Defined, err)
+			}
+			t.Defined = named.Name
+		}
+	default:
+		return fmt.Errorf("unsupported IDL type %s", data)
+	}
+	return nil
+}
+
+// ParseIDL parses an IDL JSON document
+func ParseIDL(data []byte) (*IDL, error) {
+	var idl IDL
+	if err := json.Unmarshal(data, &idl); err != nil {
+		return nil, fmt.Errorf("failed to parse IDL: %w", err)
+	}
+	idl.types = make(map[string]*IDLTypeDefBody, len(idl.Types)+len(idl.Accounts))
+	for i := range idl.Types {
+		idl.types[idl.Types[i].Name] = &idl.Types[i].Type
+	}
+	for i := range idl.Accounts {
+		account := &idl.Accounts[i]
+		if account.Type != nil {
+			idl.types[account.Name] = account.Type
+		}
+		if len(account.Discriminator) == 0 {
+			account.Discriminator = GetDiscriminator("account", account.Name)
+		}
+	}
+	return &idl, nil
+}
+
+// LoadIDL reads and parses an IDL file
+func LoadIDL(path string) (*IDL, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IDL: %w", err)
+	}
+	return ParseIDL(data)
+}
+
+// Account returns the named account
+func (idl *IDL) Account(name string) (*IDLAccount, error) {
+	for i := range idl.Accounts {
+		if idl.Accounts[i].Name == name {
+			return &idl.Accounts[i], nil
+		}
+	}
+	return nil, fmt.Errorf("IDL has no account %q", name)
+}
+
+// AccountFor returns the account whose discriminator starts data
+func (idl *IDL) AccountFor(data []byte) (*IDLAccount, error) {
+	for i := range idl.Accounts {
+		if d := idl.Accounts[i].Discriminator; len(data) >= len(d) && bytes.Equal(data[:len(d)], d) {
+			return &idl.Accounts[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no IDL account matches discriminator %v", data[:min(8, len(data))])
+}
+
+// layout returns the struct layout of the named account or type
+func (idl *IDL) layout(name string) (*IDLTypeDefBody, error) {
+	body, ok := idl.types[name]
+	if !ok {
+		return nil, fmt.Errorf("IDL has no type %q", name)
+	}
+	return body, nil
+}
+
+// Size returns the encoded size of t, or false when it varies with the data
+func (idl *IDL) Size(t IDLType) (int, bool) {
+	switch {
+	case t.Vec != nil, t.Option != nil:
+		return 0, false
+	case t.COption != nil:
+		size, ok := idl.Size(*t.COption)
+		return 4 + size, ok
+	case t.Array != nil:
+		size, ok := idl.Size(*t.Array)
+		return size * t.Len, ok
+	case t.Defined != "":
+		body, err := idl.layout(t.Defined)
+		if err != nil {
+			return 0, false
+		}
+		return idl.bodySize(body)
+	}
+	switch t.Primitive {
+	case "bool", "u8", "i8":
+		return 1, true
+	case "u16", "i16":
+		return 2, true
+	case "u32", "i32", "f32":
+		return 4, true
+	case "u64", "i64", "f64":
+		return 8, true
+	case "u128", "i128":
+		return 16, true
+	case "pubkey":
+		return 32, true
+	}
+	return 0, false
+}
+
+func (idl *IDL) bodySize(body *IDLTypeDefBody) (int, bool) {
+	if body.Kind == "enum" {
+		// Borsh enums are a variant byte and the variant's fields
+		size := -1
+		for _, v := range body.Variants {
+			vs, ok := idl.fieldsSize(v.Fields)
+			if !ok || (size >= 0 && vs != size) {
+				return 0, false
+			}
+			size = vs
+		}
+		return 1 + max(size, 0), true
+	}
+	return idl.fieldsSize(body.Fields)
+}
+
+func (idl *IDL) fieldsSize(fields IDLFields) (int, bool) {
+	total := 0
+	for _, f := range fields {
+		size, ok := idl.Size(f.Type)
+		if !ok {
+			return 0, false
+		}
+		total += size
+	}
+	return total, true
+}
+
+// FieldOffset returns the byte offset of a field in the account data,
+// including the discriminator, for memcmp filters. path names nested struct
+// fields with dots, such as "fee_config.fee_rate". Every field before it must
+// have a fixed size.
+func (idl *IDL) FieldOffset(account, path string) (int, error) {
+	acc, err := idl.Account(account)
+	if err != nil {
+		return 0, err
+	}
+	body, err := idl.layout(account)
+	if err != nil {
+		return 0, err
+	}
+
+	offset := len(acc.Discriminator)
+	names := strings.Split(path, ".")
+	for depth, name := range names {
+		found := false
+		for _, f := range body.Fields {
+			if f.Name == name {
+				found = true
+				if depth < len(names)-1 {
+					if f.Type.Defined == "" {
+						return 0, fmt.Errorf("field %q of %s is not a struct", name, account)
+					}
+					if body, err = idl.layout(f.Type.Defined); err != nil {
+						return 0, err
+					}
+				}
+				break
+			}
+			size, ok := idl.Size(f.Type)
+			if !ok {
+				return 0, fmt.Errorf("field %q of %s follows variable-size field %q", name, account, f.Name)
+			}
+			offset += size
+		}
+		if !found {
+			return 0, fmt.Errorf("%s has no field %q", account, path)
+		}
+	}
+	return offset, nil
+}
@@ -2,6 +2,10 @@ package pkg
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
 
 	"cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
@@ -12,11 +16,24 @@ import (
 type ProtocolName string
 
 const (
-	ProtocolNameRaydiumAmm  ProtocolName = "raydium_amm"
-	ProtocolNameRaydiumClmm ProtocolName = "raydium_clmm"
-	ProtocolNameRaydiumCpmm ProtocolName = "raydium_cpmm"
-	ProtocolNameMeteoraDlmm ProtocolName = "meteora_dlmm"
-	ProtocolNamePumpAmm     ProtocolName = "pump_amm"
+	ProtocolNameRaydiumAmm    ProtocolName = "raydium_amm"
+	ProtocolNameRaydiumClmm   ProtocolName = "raydium_clmm"
+	ProtocolNameRaydiumCpmm   ProtocolName = "raydium_cpmm"
+	ProtocolNameMeteoraDlmm   ProtocolName = "meteora_dlmm"
+	ProtocolNamePumpAmm       ProtocolName = "pump_amm"
+	ProtocolNameWhirlpool     ProtocolName = "whirlpool"
+	ProtocolNameOrca          ProtocolName = "orca"
+	ProtocolNameSplTokenSwap  ProtocolName = "spl_token_swap"
+	ProtocolNameAldrin        ProtocolName = "aldrin"
+	ProtocolNameSaros         ProtocolName = "saros"
+	ProtocolNameFluxbeam      ProtocolName = "fluxbeam"
+	ProtocolNameGooseFX       ProtocolName = "goosefx"
+	ProtocolNameSaber         ProtocolName = "saber"
+	ProtocolNameLifinity      ProtocolName = "lifinity"
+	ProtocolNameWooFi         ProtocolName = "woofi"
+	ProtocolNameByreal        ProtocolName = "byreal"
+	ProtocolNameMeteoraDBC    ProtocolName = "meteoradbc"
+	ProtocolNamePancakeSwapV3 ProtocolName = "pancakeswapv3"
 )
 
 type Pool interface {
@@ -24,10 +41,14 @@ type Pool interface {
 	GetProgramID() solana.PublicKey
 	GetID() string
 	GetTokens() (baseMint, quoteMint string)
-	Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error)
+	// GetFeeRate returns the swap fee the pool's quote applies, in basis points
+	GetFeeRate() float64
+	// GetReserves returns the pool's token amounts as last fetched or updated
+	GetReserves() Reserves
+	Quote(ctx context.Context, solClient sol.SolClient, inputMint string, inputAmount math.Int) (math.Int, error)
 	BuildSwapInstructions(
 		ctx context.Context,
-		solClient *sol.Client,
+		solClient sol.SolClient,
 		user solana.PublicKey,
 		inputMint string,
 		inputAmount math.Int,
@@ -37,8 +58,151 @@ type Pool interface {
 	) ([]solana.Instruction, error)
 }
 
+// Reserves are a pool's amounts of its base and quote token in raw units,
+// ordered like GetTokens
+type Reserves struct {
+	Base  math.Int
+	Quote math.Int
+	// Virtual marks amounts derived from the active liquidity and price of a
+	// concentrated liquidity pool rather than vault balances
+	Virtual bool
+	// Slot is the slot of the last update the amounts reflect, 0 when unknown
+	Slot uint64
+}
+
+// NewReserves returns vault reserves, treating unset amounts as zero
+func NewReserves(base, quote math.Int, slot uint64) Reserves {
+	if base.IsNil() {
+		base = math.ZeroInt()
+	}
+	if quote.IsNil() {
+		quote = math.ZeroInt()
+	}
+	return Reserves{Base: base, Quote: quote, Slot: slot}
+}
+
+// VirtualReserves returns the reserves a constant-product pool would need to
+// match a concentrated liquidity pool's active liquidity at its Q64.64 square
+// root price: base = L / sqrtP and quote = L * sqrtP
+func VirtualReserves(liquidity, sqrtPriceX64 *big.Int, slot uint64) Reserves {
+	reserves := Reserves{Base: math.ZeroInt(), Quote: math.ZeroInt(), Virtual: true, Slot: slot}
+	if sqrtPriceX64.Sign() <= 0 {
+		return reserves
+	}
+	base := new(big.Int).Lsh(liquidity, 64)
+	reserves.Base = math.NewIntFromBigInt(base.Quo(base, sqrtPriceX64))
+	quote := new(big.Int).Mul(liquidity, sqrtPriceX64)
+	reserves.Quote = math.NewIntFromBigInt(quote.Rsh(quote, 64))
+	return reserves
+}
+
 type Protocol interface {
 	ProtocolName() ProtocolName
 	FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]Pool, error)
 	FetchPoolByID(ctx context.Context, poolID string) (Pool, error)
+	// FetchPoolsByPairs fetches the pools of many pairs at once, sharing the
+	// RPC work between them where the protocol can. Pairs whose lookup failed
+	// are missing from the result and their errors are joined.
+	FetchPoolsByPairs(ctx context.Context, pairs []Pair) (map[Pair][]Pool, error)
+	// FetchAllPools returns one page of every pool of the protocol's program.
+	// Pools are decoded from their accounts only; load them with
+	// FetchPoolByID before quoting.
+	FetchAllPools(ctx context.Context, opts FetchAllOptions) (PoolPage, error)
+}
+
+// PoolShards is the number of shards FetchAllPools splits a program into, by
+// the first byte of each pool's first mint
+const PoolShards = 256
+
+// FetchAllOptions selects a page of FetchAllPools
+type FetchAllOptions struct {
+	// Cursor continues from a previous page's NextCursor; empty starts over
+	Cursor string
+	// PageShards is how many shards one page scans, each with its own
+	// getProgramAccounts call; default 16
+	PageShards int
+}
+
+// PoolPage is one page of a program's pools
+type PoolPage struct {
+	Pools []Pool
+	// NextCursor fetches the next page; empty after the last one
+	NextCursor string
+}
+
+// Pair is a token pair to look pools up for. The pools trade its mints in
+// either order.
+type Pair struct {
+	BaseMint  string
+	QuoteMint string
+}
+
+// Key returns the pair regardless of direction
+func (p Pair) Key() Pair {
+	if p.BaseMint > p.QuoteMint {
+		return Pair{p.QuoteMint, p.BaseMint}
+	}
+	return p
+}
+
+// DefaultPairConcurrency is how many pairs FetchEachPair looks up at once
+const DefaultPairConcurrency = 4
+
+// FetchEachPair implements FetchPoolsByPairs with a per-pair lookup: each
+// distinct pair, in either direction, is fetched once, at most concurrency
+// at a time. It shares no work between pairs; the protocols discovering pools
+// with getProgramAccounts use it only for pairs sharing no mint with another,
+// and scan once per shared mint for the rest.
+func FetchEachPair(ctx context.Context, pairs []Pair, concurrency int, fetch func(ctx context.Context, baseMint, quoteMint string) ([]Pool, error)) (map[Pair][]Pool, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultPairConcurrency
+	}
+
+	type result struct {
+		pools []Pool
+		err   error
+	}
+	var (
+		results = make(map[Pair]*result)
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+	)
+	for _, pair := range pairs {
+		key := pair.Key()
+		if _, ok := results[key]; ok {
+			continue
+		}
+		res := &result{}
+		results[key] = res
+
+		wg.Add(1)
+		go func(pair Pair, res *result) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				res.err = ctx.Err()
+				return
+			}
+			res.pools, res.err = fetch(ctx, pair.BaseMint, pair.QuoteMint)
+		}(pair, res)
+	}
+	wg.Wait()
+
+	pools := make(map[Pair][]Pool, len(pairs))
+	var errs []error
+	for _, pair := range pairs {
+		res := results[pair.Key()]
+		if res.err != nil {
+			continue
+		}
+		pools[pair] = res.pools
+	}
+	for key, res := range results {
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", key.BaseMint, key.QuoteMint, res.err))
+		}
+	}
+	return pools, errors.Join(errs...)
 }
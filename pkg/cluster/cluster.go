@@ -0,0 +1,180 @@
+// Package cluster selects the program IDs the protocols use, so the library
+// can run against devnet or custom deployments instead of mainnet-beta
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg"
+	"soltrading/pkg/config"
+	"soltrading/pkg/pool/aldrin"
+	"soltrading/pkg/pool/byreal"
+	"soltrading/pkg/pool/fluxbeam"
+	"soltrading/pkg/pool/goosefx"
+	"soltrading/pkg/pool/lifinity"
+	"soltrading/pkg/pool/meteora"
+	"soltrading/pkg/pool/meteoradbc"
+	"soltrading/pkg/pool/orca"
+	"soltrading/pkg/pool/pump"
+	"soltrading/pkg/pool/raydium"
+	"soltrading/pkg/pool/saber"
+	"soltrading/pkg/pool/saros"
+	"soltrading/pkg/pool/splswap"
+	"soltrading/pkg/pool/whirlpool"
+	"soltrading/pkg/pool/woofi"
+	"soltrading/pkg/txparser"
+)
+
+// Name identifies a cluster profile
+type Name string
+
+const (
+	MainnetBeta Name = "mainnet-beta"
+	Devnet      Name = "devnet"
+	// Custom starts from the mainnet-beta IDs and takes its deployments from
+	// overrides
+	Custom Name = "custom"
+)
+
+// Profile maps each protocol to the program that owns its pools on one cluster
+type Profile struct {
+	Name     Name
+	Programs map[pkg.ProtocolName]solana.PublicKey
+}
+
+// programVars are the package variables each protocol reads its program ID from
+var programVars = []struct {
+	protocol pkg.ProtocolName
+	id       *solana.PublicKey
+}{
+	{pkg.ProtocolNamePumpAmm, &pump.PumpSwapProgramID},
+	{pkg.ProtocolNameRaydiumAmm, &raydium.RAYDIUM_AMM_PROGRAM_ID},
+	{pkg.ProtocolNameRaydiumClmm, &raydium.RAYDIUM_CLMM_PROGRAM_ID},
+	{pkg.ProtocolNameRaydiumCpmm, &raydium.RAYDIUM_CPMM_PROGRAM_ID},
+	{pkg.ProtocolNameMeteoraDlmm, &meteora.MeteoraProgramID},
+	{pkg.ProtocolNameWhirlpool, &whirlpool.WhirlpoolProgramID},
+	{pkg.ProtocolNameOrca, &orca.OrcaAmmProgramID},
+	{pkg.ProtocolNameSplTokenSwap, &splswap.SplTokenSwapProgramID},
+	{pkg.ProtocolNameAldrin, &aldrin.AldrinAmmProgramID},
+	{pkg.ProtocolNameSaros, &saros.SarosProgramID},
+	{pkg.ProtocolNameFluxbeam, &fluxbeam.FluxbeamProgramID},
+	{pkg.ProtocolNameGooseFX, &goosefx.GooseFXProgramID},
+	{pkg.ProtocolNameSaber, &saber.SaberSwapProgramID},
+	{pkg.ProtocolNameLifinity, &lifinity.LifinityProgramID},
+	{pkg.ProtocolNameWooFi, &woofi.WooFiProgramID},
+	{pkg.ProtocolNameByreal, &byreal.ByrealProgramID},
+	{pkg.ProtocolNameMeteoraDBC, &meteoradbc.MeteoraDBCProgramID},
+}
+
+// mainnetPrograms holds the program IDs the pool packages are compiled with
+var mainnetPrograms = func() map[pkg.ProtocolName]solana.PublicKey {
+	programs := make(map[pkg.ProtocolName]solana.PublicKey, len(programVars))
+	for _, v := range programVars {
+		programs[v.protocol] = *v.id
+	}
+	return programs
+}()
+
+// devnetPrograms are the devnet deployments that differ from mainnet-beta.
+// Pump AMM, Meteora DLMM and Whirlpool use the same program ID on both.
+var devnetPrograms = map[pkg.ProtocolName]solana.PublicKey{
+	pkg.ProtocolNameRaydiumAmm:  solana.MustPublicKeyFromBase58("HWy1jotHpo6UqeQxx49dpYYdQB8wj9Qk9MdxwjLvDHB8"),
+	pkg.ProtocolNameRaydiumClmm: solana.MustPublicKeyFromBase58("devi51mZmdwUJGU9hjN27vEz64Gps7uUefqxg27EAtH"),
+	pkg.ProtocolNameRaydiumCpmm: solana.MustPublicKeyFromBase58("CPMDWBwJDtYax9qW7AyRuVC19Cc4L4Vcy4n2BHAbHkCW"),
+}
+
+var current = Profile{Name: MainnetBeta, Programs: mainnetPrograms}
+
+// Get returns the named built-in profile
+func Get(name Name) (Profile, error) {
+	profile := Profile{Name: name, Programs: make(map[pkg.ProtocolName]solana.PublicKey, len(mainnetPrograms))}
+	for protocol, id := range mainnetPrograms {
+		profile.Programs[protocol] = id
+	}
+
+	switch name {
+	case MainnetBeta, Custom:
+	case Devnet:
+		for protocol, id := range devnetPrograms {
+			profile.Programs[protocol] = id
+		}
+	default:
+		return Profile{}, fmt.Errorf("unknown cluster %q (known: %s, %s, %s)", name, MainnetBeta, Devnet, Custom)
+	}
+	return profile, nil
+}
+
+// WithOverrides returns a copy of the profile with the given protocols'
+// program IDs replaced
+func (p Profile) WithOverrides(overrides map[string]string) (Profile, error) {
+	out := Profile{Name: p.Name, Programs: make(map[pkg.ProtocolName]solana.PublicKey, len(p.Programs))}
+	for protocol, id := range p.Programs {
+		out.Programs[protocol] = id
+	}
+	for protocol, address := range overrides {
+		if _, ok := mainnetPrograms[pkg.ProtocolName(protocol)]; !ok {
+			return Profile{}, fmt.Errorf("%w: unknown protocol %q (known: %s)", pkg.ErrUnsupportedProtocol, protocol, strings.Join(Protocols(), ", "))
+		}
+		id, err := solana.PublicKeyFromBase58(address)
+		if err != nil {
+			return Profile{}, fmt.Errorf("invalid program ID for %s: %w", protocol, err)
+		}
+		out.Programs[pkg.ProtocolName(protocol)] = id
+	}
+	return out, nil
+}
+
+// FromEnv returns the profile named by SOLANA_CLUSTER with the
+// CLUSTER_PROGRAMS overrides applied
+func FromEnv() (Profile, error) {
+	profile, err := Get(Name(config.GetCluster()))
+	if err != nil {
+		return Profile{}, err
+	}
+	overrides, err := config.GetClusterPrograms()
+	if err != nil {
+		return Profile{}, err
+	}
+	if profile.Name == Custom && len(overrides) == 0 {
+		return Profile{}, fmt.Errorf("cluster %q needs program IDs in CLUSTER_PROGRAMS", Custom)
+	}
+	return profile.WithOverrides(overrides)
+}
+
+// Apply points every protocol at the profile's program IDs by overwriting the
+// pool packages' program ID variables and the transaction parser's program
+// table in place, without synchronization. It must be called once at startup,
+// before any protocol, pool, router or parser is constructed or any goroutine
+// that may use them is started; calling it later is a data race.
+func Apply(profile Profile) {
+	for _, v := range programVars {
+		id, ok := profile.Programs[v.protocol]
+		if !ok || id.Equals(*v.id) {
+			continue
+		}
+		if dex, ok := txparser.KnownPrograms[*v.id]; ok {
+			delete(txparser.KnownPrograms, *v.id)
+			txparser.KnownPrograms[id] = dex
+		}
+		*v.id = id
+	}
+	current = profile
+}
+
+// Current returns the profile last applied, mainnet-beta by default
+func Current() Profile {
+	return current
+}
+
+// Protocols lists the protocols a profile can map
+func Protocols() []string {
+	names := make([]string, len(programVars))
+	for i, v := range programVars {
+		names[i] = string(v.protocol)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"soltrading/pkg/logging"
+)
+
+// Config holds the runtime settings of the RPC clients, the router and the
+// subscription manager. Each component's ApplyConfig takes its section.
+type Config struct {
+	RPC          RPCConfig          `json:"rpc"`
+	Router       RouterConfig       `json:"router"`
+	Subscription SubscriptionConfig `json:"subscription"`
+}
+
+// RPCConfig tunes sol.Client and sol.RPCPool. Zero values leave the current
+// settings unchanged.
+type RPCConfig struct {
+	// RateLimit is requests per second per endpoint
+	RateLimit int `json:"rateLimit,omitempty"`
+}
+
+// RouterConfig tunes router.SimpleRouter. Applying it replaces the previous
+// router settings.
+type RouterConfig struct {
+	// MaxConcurrency caps the pools quoted at once; zero quotes all at once
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+	// ExcludeDexes are protocols never routed through, on top of each call's filters
+	ExcludeDexes []string `json:"excludeDexes,omitempty"`
+	// MinLiquidityUSD applies when a call passes no minimum of its own
+	MinLiquidityUSD float64 `json:"minLiquidityUSD,omitempty"`
+}
+
+// SubscriptionConfig tunes subscription.SubscriptionManager. Zero values leave
+// the current settings unchanged.
+type SubscriptionConfig struct {
+	CoalesceInterval Duration `json:"coalesceInterval,omitempty"`
+	IdleTimeout      Duration `json:"idleTimeout,omitempty"`
+	MaxSubscriptions int      `json:"maxSubscriptions,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "250ms" in JSON
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Validate rejects negative limits and durations
+func (c Config) Validate() error {
+	switch {
+	case c.RPC.RateLimit < 0:
+		return fmt.Errorf("invalid rpc.rateLimit %d", c.RPC.RateLimit)
+	case c.Router.MaxConcurrency < 0:
+		return fmt.Errorf("invalid router.maxConcurrency %d", c.Router.MaxConcurrency)
+	case c.Router.MinLiquidityUSD < 0:
+		return fmt.Errorf("invalid router.minLiquidityUSD %g", c.Router.MinLiquidityUSD)
+	case c.Subscription.CoalesceInterval < 0:
+		return fmt.Errorf("invalid subscription.coalesceInterval %s", time.Duration(c.Subscription.CoalesceInterval))
+	case c.Subscription.IdleTimeout < 0:
+		return fmt.Errorf("invalid subscription.idleTimeout %s", time.Duration(c.Subscription.IdleTimeout))
+	case c.Subscription.MaxSubscriptions < 0:
+		return fmt.Errorf("invalid subscription.maxSubscriptions %d", c.Subscription.MaxSubscriptions)
+	}
+	return nil
+}
+
+// LoadConfig reads and validates a JSON Config file
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Watcher keeps a Config file loaded and reloads it when the file changes
+type Watcher struct {
+	path     string
+	interval time.Duration
+
+	mu        sync.Mutex
+	current   Config
+	modTime   time.Time
+	size      int64
+	listeners []func(Config)
+}
+
+// NewWatcher loads path. Run polls the file every interval (default 5s).
+func NewWatcher(path string, interval time.Duration) (*Watcher, error) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	w := &Watcher{path: path, interval: interval}
+	if _, err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Current returns the configuration last loaded
+func (w *Watcher) Current() Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// OnChange registers fn, calls it with the current configuration and again
+// after every successful reload. Listeners run in registration order.
+func (w *Watcher) OnChange(fn func(Config)) {
+	w.mu.Lock()
+	w.listeners = append(w.listeners, fn)
+	cfg := w.current
+	w.mu.Unlock()
+	fn(cfg)
+}
+
+// Reload re-reads the file and notifies the listeners. An invalid file
+// leaves the current configuration in place and returns the error; Run
+// retries once the file changes again.
+func (w *Watcher) Reload() (Config, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return w.Current(), fmt.Errorf("failed to stat config file: %w", err)
+	}
+	cfg, err := LoadConfig(w.path)
+
+	w.mu.Lock()
+	w.modTime, w.size = info.ModTime(), info.Size()
+	if err != nil {
+		cfg = w.current
+		w.mu.Unlock()
+		return cfg, err
+	}
+	w.current = cfg
+	listeners := make([]func(Config), len(w.listeners))
+	copy(listeners, w.listeners)
+	w.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(cfg)
+	}
+	return cfg, nil
+}
+
+// changed reports whether the file's modification time or size differ from
+// the last load
+func (w *Watcher) changed() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+}
+
+// Run reloads the file whenever it changes until ctx is done
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !w.changed() {
+				continue
+			}
+			if _, err := w.Reload(); err != nil {
+				logging.Component(nil, "config").Warn("config reload failed, keeping the running configuration", "path", w.path, "error", err)
+				continue
+			}
+			logging.Component(nil, "config").Info("reloaded config", "path", w.path)
+		}
+	}
+}
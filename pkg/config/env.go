@@ -2,7 +2,10 @@ package config
 
 import (
 	"bufio"
+	"encoding/json"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -60,3 +63,71 @@ func GetRPCEndpoints() []string {
 
 	return result
 }
+
+// GetRPCHeaders returns per-endpoint HTTP headers from RPC_HEADERS.
+// The variable holds a JSON object keyed by endpoint URL, for example
+// {"https://my-node.example.com":{"x-token":"secret"}}. A "*" key applies to
+// every endpoint; endpoint-specific entries take precedence.
+func GetRPCHeaders() (map[string]map[string]string, error) {
+	raw := os.Getenv("RPC_HEADERS")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var headers map[string]map[string]string
+	if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+		return nil, fmt.Errorf("failed to parse RPC_HEADERS: %w", err)
+	}
+	return headers, nil
+}
+
+// HeadersForEndpoint merges the "*" headers with those configured for endpoint
+func HeadersForEndpoint(headers map[string]map[string]string, endpoint string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]string)
+	for k, v := range headers["*"] {
+		merged[k] = v
+	}
+	for k, v := range headers[endpoint] {
+		merged[k] = v
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// GetCluster returns the cluster profile named by SOLANA_CLUSTER, or
+// "mainnet-beta" when unset
+func GetCluster() string {
+	if cluster := strings.TrimSpace(os.Getenv("SOLANA_CLUSTER")); cluster != "" {
+		return cluster
+	}
+	return "mainnet-beta"
+}
+
+// GetClusterPrograms returns the program ID overrides from CLUSTER_PROGRAMS.
+// The variable holds a JSON object keyed by protocol name, for example
+// {"raydium_cpmm":"CPMDWBwJDtYax9qW7AyRuVC19Cc4L4Vcy4n2BHAbHkCW"}.
+func GetClusterPrograms() (map[string]string, error) {
+	raw := os.Getenv("CLUSTER_PROGRAMS")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var programs map[string]string
+	if err := json.Unmarshal([]byte(raw), &programs); err != nil {
+		return nil, fmt.Errorf("failed to parse CLUSTER_PROGRAMS: %w", err)
+	}
+	return programs, nil
+}
+
+// GetPoolAPIFallback reports whether POOL_API_FALLBACK enables discovering
+// pools through the DEXes' HTTP APIs when getProgramAccounts fails
+func GetPoolAPIFallback() bool {
+	enabled, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("POOL_API_FALLBACK")))
+	return enabled
+}
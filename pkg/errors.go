@@ -0,0 +1,33 @@
+package pkg
+
+import (
+	"errors"
+
+	"soltrading/pkg/sol"
+)
+
+// Errors returned across the library. They are wrapped with context, so
+// match them with errors.Is.
+var (
+	// ErrNoPoolsFound is returned when no pool serves a pair, or none is left
+	// after filtering
+	ErrNoPoolsFound = errors.New("no pools found")
+	// ErrInsufficientLiquidity is returned when pools cannot fill the amount
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+	// ErrStalePoolData is returned when cached pool state is missing, out of
+	// date or older than the state already applied
+	ErrStalePoolData = errors.New("stale pool data")
+	// ErrRateLimited is returned when an RPC endpoint or pool API rejected a
+	// request for exceeding its rate limit. It is sol.ErrRateLimited.
+	ErrRateLimited = sol.ErrRateLimited
+	// ErrUnsupportedProtocol is returned for a protocol name the library or
+	// the operation doesn't support
+	ErrUnsupportedProtocol = errors.New("unsupported protocol")
+)
+
+// Retryable reports whether err may go away if the operation is retried
+// later: rate limiting and stale pool data are, missing pools and
+// insufficient liquidity are not
+func Retryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrStalePoolData)
+}
@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg/sol"
+)
+
+// ExecutionMetrics are derived from the landed transaction
+type ExecutionMetrics struct {
+	// RealizedIn is how much of the input token the user actually spent
+	RealizedIn string `json:"realizedIn"`
+	// RealizedOut is how much of the output token the user actually received
+	RealizedOut string `json:"realizedOut"`
+	// SlippageBps is (quoted - realized) / quoted; negative means better than quoted
+	SlippageBps int64 `json:"slippageBps"`
+	// FeeLamports is the transaction fee (base plus priority)
+	FeeLamports  uint64 `json:"feeLamports"`
+	ComputeUnits uint64 `json:"computeUnits"`
+}
+
+// analyze fetches the landed transaction and fills in realized metrics
+func (e *Executor) analyze(ctx context.Context, report *Report) error {
+	sig, err := solana.SignatureFromBase58(report.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	var tx *rpc.GetTransactionResult
+	// The transaction may take a moment to become available at confirmed
+	for attempt := 0; attempt < 5; attempt++ {
+		tx, err = e.client.GetTransaction(ctx, sig)
+		if err == nil && tx != nil && tx.Meta != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+	if tx == nil || tx.Meta == nil {
+		return fmt.Errorf("transaction %s has no metadata", report.Signature)
+	}
+
+	metrics := &ExecutionMetrics{FeeLamports: tx.Meta.Fee}
+	if tx.Meta.ComputeUnitsConsumed != nil {
+		metrics.ComputeUnits = *tx.Meta.ComputeUnitsConsumed
+	}
+
+	metrics.RealizedIn = realizedInput(tx.Meta, e.wallet.PublicKey(), report.InputMint).String()
+	realized := realizedOutput(tx.Meta, e.wallet.PublicKey(), report.OutputMint)
+	metrics.RealizedOut = realized.String()
+
+	quoted, ok := math.NewIntFromString(report.QuotedOut)
+	if ok && quoted.IsPositive() {
+		metrics.SlippageBps = quoted.Sub(realized).MulRaw(10000).Quo(quoted).Int64()
+	}
+
+	report.Metrics = metrics
+	return nil
+}
+
+// realizedOutput returns the user's balance increase of outputMint. For WSOL
+// outputs that were unwrapped, the native balance change plus fee is used.
+func realizedOutput(meta *rpc.TransactionMeta, user solana.PublicKey, outputMint string) math.Int {
+	tokenDelta := tokenBalanceOf(meta.PostTokenBalances, user, outputMint).
+		Sub(tokenBalanceOf(meta.PreTokenBalances, user, outputMint))
+	if tokenDelta.IsPositive() || outputMint != sol.WSOL.String() {
+		return tokenDelta
+	}
+
+	// The fee payer is always account index 0
+	if len(meta.PreBalances) == 0 || len(meta.PostBalances) == 0 {
+		return math.ZeroInt()
+	}
+	nativeDelta := math.NewIntFromUint64(meta.PostBalances[0]).
+		Sub(math.NewIntFromUint64(meta.PreBalances[0])).
+		Add(math.NewIntFromUint64(meta.Fee))
+	return nativeDelta
+}
+
+// realizedInput returns the user's balance decrease of inputMint. For WSOL
+// inputs wrapped from native SOL, the native balance change less the fee is
+// used, which also counts rent for created accounts and any Jito tip.
+func realizedInput(meta *rpc.TransactionMeta, user solana.PublicKey, inputMint string) math.Int {
+	tokenDelta := tokenBalanceOf(meta.PreTokenBalances, user, inputMint).
+		Sub(tokenBalanceOf(meta.PostTokenBalances, user, inputMint))
+	if tokenDelta.IsPositive() || inputMint != sol.WSOL.String() {
+		return tokenDelta
+	}
+
+	if len(meta.PreBalances) == 0 || len(meta.PostBalances) == 0 {
+		return math.ZeroInt()
+	}
+	return math.NewIntFromUint64(meta.PreBalances[0]).
+		Sub(math.NewIntFromUint64(meta.PostBalances[0])).
+		Sub(math.NewIntFromUint64(meta.Fee))
+}
+
+func tokenBalanceOf(balances []rpc.TokenBalance, owner solana.PublicKey, mint string) math.Int {
+	total := math.ZeroInt()
+	for _, balance := range balances {
+		if balance.Owner == nil || !balance.Owner.Equals(owner) || balance.Mint.String() != mint || balance.UiTokenAmount == nil {
+			continue
+		}
+		amount, err := strconv.ParseUint(balance.UiTokenAmount.Amount, 10, 64)
+		if err != nil {
+			continue
+		}
+		total = total.Add(math.NewIntFromUint64(amount))
+	}
+	return total
+}
+
+// ProtocolStats aggregates executions for one protocol
+type ProtocolStats struct {
+	Executions     int     `json:"executions"`
+	Landed         int     `json:"landed"`
+	Failed         int     `json:"failed"`
+	LandRate       float64 `json:"landRate"`
+	AvgSlippageBps float64 `json:"avgSlippageBps"`
+	AvgLatencyMs   float64 `json:"avgLatencyMs"`
+	AvgFeeLamports float64 `json:"avgFeeLamports"`
+
+	slippageSamples int
+	totalSlippage   int64
+	totalLatency    time.Duration
+	totalFees       uint64
+}
+
+// Analytics aggregates execution reports per protocol
+type Analytics struct {
+	mu    sync.RWMutex
+	stats map[string]*ProtocolStats
+}
+
+// NewAnalytics creates an empty aggregator
+func NewAnalytics() *Analytics {
+	return &Analytics{stats: make(map[string]*ProtocolStats)}
+}
+
+// Record adds a report to the aggregate
+func (a *Analytics) Record(report *Report) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats, ok := a.stats[report.Protocol]
+	if !ok {
+		stats = &ProtocolStats{}
+		a.stats[report.Protocol] = stats
+	}
+
+	stats.Executions++
+	stats.totalLatency += report.Duration
+	if report.Status == StatusLanded {
+		stats.Landed++
+	} else {
+		stats.Failed++
+	}
+	if report.Metrics != nil {
+		stats.slippageSamples++
+		stats.totalSlippage += report.Metrics.SlippageBps
+		stats.totalFees += report.Metrics.FeeLamports
+	}
+
+	stats.LandRate = float64(stats.Landed) / float64(stats.Executions)
+	stats.AvgLatencyMs = float64(stats.totalLatency.Milliseconds()) / float64(stats.Executions)
+	if stats.slippageSamples > 0 {
+		stats.AvgSlippageBps = float64(stats.totalSlippage) / float64(stats.slippageSamples)
+		stats.AvgFeeLamports = float64(stats.totalFees) / float64(stats.slippageSamples)
+	}
+}
+
+// Summary returns a copy of the per-protocol statistics
+func (a *Analytics) Summary() map[string]ProtocolStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	summary := make(map[string]ProtocolStats, len(a.stats))
+	for protocol, stats := range a.stats {
+		summary[protocol] = *stats
+	}
+	return summary
+}
@@ -0,0 +1,289 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/jito"
+	"soltrading/pkg/logging"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/swap"
+	"soltrading/pkg/wallet"
+)
+
+// Config controls how swaps are executed
+type Config struct {
+	// SlippageBps is applied to the quoted output to derive the minimum output
+	SlippageBps int
+	// Swap configures compute budget, SOL wrapping and ATA creation
+	Swap swap.Options
+	// SkipValidation disables the pre-swap balance and account checks
+	SkipValidation bool
+	// JitoTipLamports is the tip attached when a Jito client is configured
+	JitoTipLamports uint64
+	// Sender configures RPC submission and confirmation
+	Sender sol.SenderOptions
+	// ConfirmTimeout bounds the whole send-and-confirm phase
+	ConfirmTimeout time.Duration
+}
+
+// DefaultConfig returns sensible execution defaults
+func DefaultConfig() Config {
+	return Config{
+		SlippageBps: 50,
+		Swap: swap.Options{
+			ComputeBudget: swap.ComputeBudgetOptions{Enabled: true},
+			WrapSOL:       true,
+			CreateATAs:    true,
+		},
+		JitoTipLamports: jito.DefaultTipLamports,
+		Sender:          sol.DefaultSenderOptions(),
+		ConfirmTimeout:  90 * time.Second,
+	}
+}
+
+// Request describes a quoted swap to execute
+type Request struct {
+	Pool       pkg.Pool
+	InputMint  string
+	OutputMint string
+	AmountIn   math.Int
+	QuotedOut  math.Int
+	// Optional user token accounts; zero values resolve to the user's ATAs
+	InputAccount  solana.PublicKey
+	OutputAccount solana.PublicKey
+}
+
+// Execution statuses
+const (
+	StatusLanded = "landed"
+	StatusFailed = "failed"
+)
+
+// Report summarises an executed swap
+type Report struct {
+	Status     string        `json:"status"`
+	Signature  string        `json:"signature,omitempty"`
+	BundleID   string        `json:"bundleId,omitempty"`
+	Slot       uint64        `json:"slot,omitempty"`
+	PoolID     string        `json:"poolId"`
+	Protocol   string        `json:"protocol"`
+	InputMint  string        `json:"inputMint"`
+	OutputMint string        `json:"outputMint"`
+	AmountIn   string        `json:"amountIn"`
+	QuotedOut  string        `json:"quotedOut"`
+	MinOut     string        `json:"minOut"`
+	Resigns    int           `json:"resigns"`
+	TxError    interface{}   `json:"txError,omitempty"`
+	Duration   time.Duration `json:"duration"`
+
+	// Metrics are filled in after landing when analytics are enabled
+	Metrics *ExecutionMetrics `json:"metrics,omitempty"`
+}
+
+// Executor turns quotes into landed transactions
+type Executor struct {
+	client    *sol.Client
+	rpcPool   *sol.RPCPool
+	wallet    *wallet.Wallet
+	builder   *swap.Builder
+	jito      *jito.Client
+	tpu       sol.TPUSender
+	analytics *Analytics
+	cfg       Config
+}
+
+// New creates an executor that signs with w and talks to the chain through client
+func New(client *sol.Client, w *wallet.Wallet, cfg Config) *Executor {
+	return &Executor{
+		client:  client,
+		wallet:  w,
+		builder: swap.NewBuilder(client, cfg.Swap),
+		cfg:     cfg,
+	}
+}
+
+// WithRPCPool sends through an RPC pool so transactions can be broadcast
+func (e *Executor) WithRPCPool(pool *sol.RPCPool) *Executor {
+	e.rpcPool = pool
+	return e
+}
+
+// WithJito submits swaps as Jito bundles with a tip instead of through RPC
+func (e *Executor) WithJito(client *jito.Client) *Executor {
+	e.jito = client
+	return e
+}
+
+// WithTPU also sends RPC-path swaps directly to upcoming leaders, e.g. with a *tpu.Client
+func (e *Executor) WithTPU(tpu sol.TPUSender) *Executor {
+	e.tpu = tpu
+	return e
+}
+
+// WithAnalytics records realized output, slippage and fees of every execution
+func (e *Executor) WithAnalytics(analytics *Analytics) *Executor {
+	e.analytics = analytics
+	return e
+}
+
+// Execute validates, builds, signs, sends and confirms a swap. With analytics
+// enabled, landed swaps are re-fetched to measure realized output.
+func (e *Executor) Execute(ctx context.Context, req Request) (*Report, error) {
+	report, err := e.execute(ctx, req)
+	if e.analytics != nil && report.Status != "" {
+		if report.Status == StatusLanded {
+			if analyzeErr := e.analyze(ctx, report); analyzeErr != nil {
+				logging.Component(nil, "executor").Warn("failed to analyze execution", "signature", report.Signature, "error", analyzeErr)
+			}
+		}
+		e.analytics.Record(report)
+	}
+	return report, err
+}
+
+func (e *Executor) execute(ctx context.Context, req Request) (*Report, error) {
+	start := time.Now()
+	minOut := req.QuotedOut.Mul(math.NewInt(int64(10000 - e.cfg.SlippageBps))).Quo(math.NewInt(10000))
+
+	report := &Report{
+		PoolID:     req.Pool.GetID(),
+		Protocol:   string(req.Pool.ProtocolName()),
+		InputMint:  req.InputMint,
+		OutputMint: req.OutputMint,
+		AmountIn:   req.AmountIn.String(),
+		QuotedOut:  req.QuotedOut.String(),
+		MinOut:     minOut.String(),
+	}
+	defer func() { report.Duration = time.Since(start) }()
+
+	user := e.wallet.PublicKey()
+	if !e.cfg.SkipValidation {
+		inputMint, err := solana.PublicKeyFromBase58(req.InputMint)
+		if err != nil {
+			return report, fmt.Errorf("invalid input mint: %w", err)
+		}
+		outputMint, err := solana.PublicKeyFromBase58(req.OutputMint)
+		if err != nil {
+			return report, fmt.Errorf("invalid output mint: %w", err)
+		}
+		if err := swap.Validate(ctx, e.client, swap.ValidationParams{
+			User:          user,
+			InputMint:     inputMint,
+			AmountIn:      req.AmountIn.Uint64(),
+			InputAccount:  req.InputAccount,
+			OutputMint:    outputMint,
+			OutputAccount: req.OutputAccount,
+			WrapSOL:       e.cfg.Swap.WrapSOL,
+		}); err != nil {
+			return report, err
+		}
+	}
+
+	baseMint, _ := req.Pool.GetTokens()
+	userBaseAccount, userQuoteAccount := req.OutputAccount, req.InputAccount
+	if req.InputMint == baseMint {
+		userBaseAccount, userQuoteAccount = req.InputAccount, req.OutputAccount
+	}
+
+	instructions, err := e.builder.BuildSwapInstructions(ctx, req.Pool, user, req.InputMint, req.AmountIn, minOut, userBaseAccount, userQuoteAccount)
+	if err != nil {
+		return report, err
+	}
+
+	sendCtx := ctx
+	if e.cfg.ConfirmTimeout > 0 {
+		var cancel context.CancelFunc
+		sendCtx, cancel = context.WithTimeout(ctx, e.cfg.ConfirmTimeout)
+		defer cancel()
+	}
+
+	if e.jito != nil {
+		return e.executeWithJito(sendCtx, instructions, report)
+	}
+	return e.executeWithRPC(sendCtx, instructions, report)
+}
+
+func (e *Executor) executeWithRPC(ctx context.Context, instructions []solana.Instruction, report *Report) (*Report, error) {
+	sender := sol.NewSender(e.client, e.cfg.Sender)
+	if e.rpcPool != nil {
+		sender.WithPool(e.rpcPool)
+	}
+	if e.tpu != nil {
+		sender.WithTPU(e.tpu)
+	}
+
+	result, err := sender.Send(ctx, func(ctx context.Context, blockhash solana.Hash) (*solana.Transaction, error) {
+		return e.wallet.NewSignedTransaction(instructions, blockhash)
+	})
+	if result != nil {
+		report.Signature = result.Signature.String()
+		report.Slot = result.Slot
+		report.Resigns = result.Resigns
+		report.TxError = result.Err
+	}
+	if err != nil {
+		report.Status = StatusFailed
+		return report, err
+	}
+	if result.Err != nil {
+		report.Status = StatusFailed
+		return report, fmt.Errorf("swap transaction failed on-chain: %v", result.Err)
+	}
+
+	report.Status = StatusLanded
+	logging.Component(nil, "executor").Info("swap landed", "slot", report.Slot, "signature", report.Signature)
+	return report, nil
+}
+
+func (e *Executor) executeWithJito(ctx context.Context, instructions []solana.Instruction, report *Report) (*Report, error) {
+	tipLamports := e.cfg.JitoTipLamports
+	if tipLamports == 0 {
+		tipLamports = jito.DefaultTipLamports
+	}
+	withTip := append(instructions, e.jito.TipInstruction(e.wallet.PublicKey(), tipLamports))
+
+	blockhash, err := e.client.GetLatestBlockhash(ctx, rpc.CommitmentConfirmed)
+	if err != nil {
+		return report, fmt.Errorf("failed to get blockhash: %w", err)
+	}
+	tx, err := e.wallet.NewSignedTransaction(withTip, blockhash.Value.Blockhash)
+	if err != nil {
+		return report, err
+	}
+	report.Signature = tx.Signatures[0].String()
+
+	bundle, err := jito.NewBundle(tx)
+	if err != nil {
+		return report, err
+	}
+	bundleID, err := e.jito.SendBundle(ctx, bundle)
+	if err != nil {
+		report.Status = StatusFailed
+		return report, err
+	}
+	report.BundleID = bundleID
+
+	timeout := e.cfg.ConfirmTimeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	status, err := e.jito.WaitForBundle(ctx, bundleID, 2*time.Second, timeout)
+	if status != nil {
+		report.Slot = status.Slot
+		report.TxError = status.Err
+	}
+	if err != nil {
+		report.Status = StatusFailed
+		return report, err
+	}
+
+	report.Status = StatusLanded
+	logging.Component(nil, "executor").Info("swap bundle landed", "bundle", bundleID, "slot", report.Slot, "signature", report.Signature)
+	return report, nil
+}
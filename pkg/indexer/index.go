@@ -0,0 +1,429 @@
+// Package indexer keeps a local index of every pool of the enabled programs,
+// refreshed by periodic scans and kept current between them by program
+// subscriptions, so pair lookups read the index instead of scanning the
+// programs with getProgramAccounts on each request.
+//
+// The index lives in a bbolt database: a bucket per protocol maps each pool
+// address to its two mints, and writes touch only the pools that changed. It
+// is also held in memory, keyed by pair, since an entry is only an address
+// and two mints and lookups sit on the quoting path.
+package indexer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	bolt "go.etcd.io/bbolt"
+	"soltrading/pkg"
+	"soltrading/pkg/logging"
+)
+
+// indexVersion is bumped when the database layout changes
+const indexVersion = 2
+
+// Buckets of the index database besides one per protocol, named after it
+var (
+	metaBucket    = []byte("meta")    // "version" -> indexVersion
+	scannedBucket = []byte("scanned") // protocol -> unix nanoseconds of its last scan
+)
+
+// openTimeout bounds the wait for another process holding the database
+const openTimeout = 5 * time.Second
+
+// Entry is the metadata of one indexed pool
+type Entry struct {
+	Pool  string `json:"pool"`
+	MintA string `json:"mintA"`
+	MintB string `json:"mintB"`
+}
+
+// protocolIndex holds one protocol's pools, keyed by address and by pair
+type protocolIndex struct {
+	scannedAt time.Time
+	pools     map[string]Entry
+	byPair    map[string]map[string]bool
+}
+
+func newProtocolIndex(scannedAt time.Time) *protocolIndex {
+	return &protocolIndex{scannedAt: scannedAt, pools: make(map[string]Entry), byPair: make(map[string]map[string]bool)}
+}
+
+func (p *protocolIndex) add(entry Entry) {
+	p.remove(entry.Pool)
+	p.pools[entry.Pool] = entry
+	key := pairKey(entry.MintA, entry.MintB)
+	if p.byPair[key] == nil {
+		p.byPair[key] = make(map[string]bool)
+	}
+	p.byPair[key][entry.Pool] = true
+}
+
+func (p *protocolIndex) remove(pool string) {
+	entry, ok := p.pools[pool]
+	if !ok {
+		return
+	}
+	delete(p.pools, pool)
+	key := pairKey(entry.MintA, entry.MintB)
+	delete(p.byPair[key], pool)
+	if len(p.byPair[key]) == 0 {
+		delete(p.byPair, key)
+	}
+}
+
+// Index maps token pairs to pool addresses per protocol. A protocol is
+// covered once a scan of its program completed; until then lookups fall
+// through to the protocol itself.
+type Index struct {
+	db *bolt.DB // nil keeps the index in memory only
+
+	mu        sync.RWMutex
+	protocols map[pkg.ProtocolName]*protocolIndex
+}
+
+// OpenIndex opens the index database at path, creating it when missing. An
+// empty path keeps the index in memory only. Close releases the database.
+func OpenIndex(path string) (*Index, error) {
+	index := &Index{protocols: make(map[pkg.ProtocolName]*protocolIndex)}
+	if path == "" {
+		return index, nil
+	}
+
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pool index %s: %w", path, err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		if version := meta.Get([]byte("version")); version == nil {
+			if err := meta.Put([]byte("version"), binary.BigEndian.AppendUint64(nil, indexVersion)); err != nil {
+				return err
+			}
+		} else if v := binary.BigEndian.Uint64(version); v != indexVersion {
+			return fmt.Errorf("unsupported index version %d", v)
+		}
+		if _, err := tx.CreateBucketIfNotExists(scannedBucket); err != nil {
+			return err
+		}
+		return index.load(tx)
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load pool index %s: %w", path, err)
+	}
+	index.db = db
+	return index, nil
+}
+
+// load reads every scanned protocol's pools into memory
+func (i *Index) load(tx *bolt.Tx) error {
+	return tx.Bucket(scannedBucket).ForEach(func(name, scanned []byte) error {
+		p := newProtocolIndex(time.Unix(0, int64(binary.BigEndian.Uint64(scanned))))
+		if pools := tx.Bucket(name); pools != nil {
+			if err := pools.ForEach(func(pool, mints []byte) error {
+				entry, err := decodeEntry(pool, mints)
+				if err != nil {
+					return err
+				}
+				p.add(entry)
+				return nil
+			}); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		i.protocols[pkg.ProtocolName(name)] = p
+		return nil
+	})
+}
+
+// encodeEntry stores a pool as its 32-byte address mapped to its two mints
+func encodeEntry(entry Entry) (key, value []byte, err error) {
+	pool, err := solana.PublicKeyFromBase58(entry.Pool)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid pool address %q: %w", entry.Pool, err)
+	}
+	mintA, err := solana.PublicKeyFromBase58(entry.MintA)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid mint %q of pool %s: %w", entry.MintA, entry.Pool, err)
+	}
+	mintB, err := solana.PublicKeyFromBase58(entry.MintB)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid mint %q of pool %s: %w", entry.MintB, entry.Pool, err)
+	}
+	return pool.Bytes(), append(mintA.Bytes(), mintB.Bytes()...), nil
+}
+
+func decodeEntry(key, value []byte) (Entry, error) {
+	if len(key) != solana.PublicKeyLength || len(value) != 2*solana.PublicKeyLength {
+		return Entry{}, fmt.Errorf("malformed entry of %d and %d bytes", len(key), len(value))
+	}
+	return Entry{
+		Pool:  solana.PublicKeyFromBytes(key).String(),
+		MintA: solana.PublicKeyFromBytes(value[:solana.PublicKeyLength]).String(),
+		MintB: solana.PublicKeyFromBytes(value[solana.PublicKeyLength:]).String(),
+	}, nil
+}
+
+// Close releases the index database
+func (i *Index) Close() error {
+	if i.db == nil {
+		return nil
+	}
+	return i.db.Close()
+}
+
+// pairKey identifies a token pair regardless of direction
+func pairKey(mintA, mintB string) string {
+	if mintA > mintB {
+		mintA, mintB = mintB, mintA
+	}
+	return mintA + ":" + mintB
+}
+
+// Replace swaps a protocol's pools for the result of a new scan, so pools
+// that were closed drop out
+func (i *Index) Replace(protocol pkg.ProtocolName, entries []Entry) error {
+	p := newProtocolIndex(time.Now())
+	for _, entry := range entries {
+		p.add(entry)
+	}
+
+	if i.db != nil {
+		if err := i.db.Update(func(tx *bolt.Tx) error {
+			name := []byte(protocol)
+			if tx.Bucket(name) != nil {
+				if err := tx.DeleteBucket(name); err != nil {
+					return err
+				}
+			}
+			pools, err := tx.CreateBucket(name)
+			if err != nil {
+				return err
+			}
+			for _, entry := range p.pools {
+				key, value, err := encodeEntry(entry)
+				if err != nil {
+					return err
+				}
+				if err := pools.Put(key, value); err != nil {
+					return err
+				}
+			}
+			return tx.Bucket(scannedBucket).Put(name, binary.BigEndian.AppendUint64(nil, uint64(p.scannedAt.UnixNano())))
+		}); err != nil {
+			return fmt.Errorf("failed to save %s pools: %w", protocol, err)
+		}
+	}
+
+	i.mu.Lock()
+	i.protocols[protocol] = p
+	i.mu.Unlock()
+	return nil
+}
+
+// Add records a pool found after the protocol's last scan. Protocols not
+// scanned yet are left alone, since their first scan finds the pool.
+func (i *Index) Add(protocol pkg.ProtocolName, entry Entry) error {
+	i.mu.RLock()
+	p, covered := i.protocols[protocol]
+	known := covered && p.pools[entry.Pool] == entry
+	i.mu.RUnlock()
+	if !covered || known {
+		return nil
+	}
+
+	if i.db != nil {
+		key, value, err := encodeEntry(entry)
+		if err != nil {
+			return err
+		}
+		if err := i.db.Update(func(tx *bolt.Tx) error {
+			pools, err := tx.CreateBucketIfNotExists([]byte(protocol))
+			if err != nil {
+				return err
+			}
+			return pools.Put(key, value)
+		}); err != nil {
+			return fmt.Errorf("failed to save %s pool %s: %w", protocol, entry.Pool, err)
+		}
+	}
+
+	i.mu.Lock()
+	if p := i.protocols[protocol]; p != nil {
+		p.add(entry)
+	}
+	i.mu.Unlock()
+	return nil
+}
+
+// Remove drops a closed pool
+func (i *Index) Remove(protocol pkg.ProtocolName, pool string) error {
+	i.mu.RLock()
+	p, covered := i.protocols[protocol]
+	var known bool
+	if covered {
+		_, known = p.pools[pool]
+	}
+	i.mu.RUnlock()
+	if !covered || !known {
+		return nil
+	}
+
+	if i.db != nil {
+		key, err := solana.PublicKeyFromBase58(pool)
+		if err != nil {
+			return fmt.Errorf("invalid pool address %q: %w", pool, err)
+		}
+		if err := i.db.Update(func(tx *bolt.Tx) error {
+			if pools := tx.Bucket([]byte(protocol)); pools != nil {
+				return pools.Delete(key.Bytes())
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to remove %s pool %s: %w", protocol, pool, err)
+		}
+	}
+
+	i.mu.Lock()
+	if p := i.protocols[protocol]; p != nil {
+		p.remove(pool)
+	}
+	i.mu.Unlock()
+	return nil
+}
+
+// Covers reports whether the protocol's program has been scanned
+func (i *Index) Covers(protocol pkg.ProtocolName) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	_, ok := i.protocols[protocol]
+	return ok
+}
+
+// ScannedAt returns when the protocol was last scanned, zero if never
+func (i *Index) ScannedAt(protocol pkg.ProtocolName) time.Time {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if p, ok := i.protocols[protocol]; ok {
+		return p.scannedAt
+	}
+	return time.Time{}
+}
+
+// PoolIDs returns the addresses of the protocol's pools trading the two
+// mints, in either order
+func (i *Index) PoolIDs(protocol pkg.ProtocolName, mintA, mintB string) []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	p, ok := i.protocols[protocol]
+	if !ok {
+		return nil
+	}
+	ids := make([]string, 0, len(p.byPair[pairKey(mintA, mintB)]))
+	for id := range p.byPair[pairKey(mintA, mintB)] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Len returns the number of pools indexed for the protocol
+func (i *Index) Len(protocol pkg.ProtocolName) int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if p, ok := i.protocols[protocol]; ok {
+		return len(p.pools)
+	}
+	return 0
+}
+
+// Wrap returns the protocols with FetchPoolsByPair served from the index
+func (i *Index) Wrap(protocols []pkg.Protocol) []pkg.Protocol {
+	wrapped := make([]pkg.Protocol, len(protocols))
+	for n, protocol := range protocols {
+		wrapped[n] = &indexedProtocol{Protocol: protocol, index: i}
+	}
+	return wrapped
+}
+
+// indexedProtocol looks pools up in the index and loads each with the
+// protocol's FetchPoolByID
+type indexedProtocol struct {
+	pkg.Protocol
+	index *Index
+}
+
+func (p *indexedProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+	name := p.ProtocolName()
+	if !p.index.Covers(name) {
+		return p.Protocol.FetchPoolsByPair(ctx, baseMint, quoteMint)
+	}
+	ids := p.index.PoolIDs(name, baseMint, quoteMint)
+	loaded := make(map[string]pkg.Pool)
+	if err := p.load(ctx, ids, loaded); err != nil {
+		return nil, err
+	}
+	return p.collect(ids, loaded), nil
+}
+
+// FetchPoolsByPairs loads each indexed pool once, however many of the pairs
+// it trades
+func (p *indexedProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	name := p.ProtocolName()
+	if !p.index.Covers(name) {
+		return p.Protocol.FetchPoolsByPairs(ctx, pairs)
+	}
+	ids := make([][]string, len(pairs))
+	var all []string
+	for i, pair := range pairs {
+		ids[i] = p.index.PoolIDs(name, pair.BaseMint, pair.QuoteMint)
+		all = append(all, ids[i]...)
+	}
+	loaded := make(map[string]pkg.Pool)
+	if err := p.load(ctx, all, loaded); err != nil {
+		return nil, err
+	}
+	res := make(map[pkg.Pair][]pkg.Pool, len(pairs))
+	for i, pair := range pairs {
+		res[pair] = p.collect(ids[i], loaded)
+	}
+	return res, nil
+}
+
+// load fetches the pools not yet in loaded. Pools that fail to load are
+// logged and skipped.
+func (p *indexedProtocol) load(ctx context.Context, ids []string, loaded map[string]pkg.Pool) error {
+	for _, id := range ids {
+		if _, ok := loaded[id]; ok {
+			continue
+		}
+		pool, err := p.FetchPoolByID(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logging.Component(nil, "indexer").Warn("skipping indexed pool", "protocol", p.ProtocolName(), "pool", id, "error", err)
+			loaded[id] = nil
+			continue
+		}
+		loaded[id] = pool
+	}
+	return nil
+}
+
+func (p *indexedProtocol) collect(ids []string, loaded map[string]pkg.Pool) []pkg.Pool {
+	pools := make([]pkg.Pool, 0, len(ids))
+	for _, id := range ids {
+		if pool := loaded[id]; pool != nil {
+			pools = append(pools, pool)
+		}
+	}
+	return pools
+}
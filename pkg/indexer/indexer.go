@@ -0,0 +1,218 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/logging"
+	"soltrading/pkg/pool/aldrin"
+	"soltrading/pkg/pool/fluxbeam"
+	"soltrading/pkg/pool/goosefx"
+	"soltrading/pkg/pool/meteora"
+	"soltrading/pkg/pool/orca"
+	"soltrading/pkg/pool/pump"
+	"soltrading/pkg/pool/raydium"
+	"soltrading/pkg/pool/saros"
+	"soltrading/pkg/pool/splswap"
+	"soltrading/pkg/pool/whirlpool"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/subscription"
+)
+
+// DefaultInterval is how often Run rescans the programs
+const DefaultInterval = 30 * time.Minute
+
+// Source describes where a protocol's pool accounts live and where their
+// mints sit in the account data
+type Source struct {
+	Protocol pkg.ProtocolName
+	Program  *solana.PublicKey // read on use, as cluster profiles replace it
+	DataSize uint64            // size of a pool account; zero matches any size
+	MintA    uint64            // offset of the first mint
+	MintB    uint64            // offset of the second mint
+}
+
+// Slice returns the part of each pool account a scan downloads: the range
+// from the first mint to the end of the second, a tenth of a typical pool
+func (s Source) Slice() sol.DataSlice {
+	return sol.PubkeySlice(s.MintA, s.MintB)
+}
+
+// DefaultSources returns the sources of the built-in protocols that discover
+// pools by scanning their program. The hand-decoded layouts without a fixed
+// account size use the mint offsets of their protocol's pair scans.
+func DefaultSources() []Source {
+	var (
+		amm  raydium.AMMPool
+		clmm raydium.CLMMPool
+		cpmm raydium.CPMMPool
+		dlmm meteora.MeteoraDlmmPool
+		pamm pump.PumpAMMPool
+	)
+	return []Source{
+		{pkg.ProtocolNamePumpAmm, &pump.PumpSwapProgramID, pamm.Span(), pamm.Offset("BaseMint"), pamm.Offset("QuoteMint")},
+		{pkg.ProtocolNameRaydiumAmm, &raydium.RAYDIUM_AMM_PROGRAM_ID, amm.Span(), amm.Offset("BaseMint"), amm.Offset("QuoteMint")},
+		{pkg.ProtocolNameRaydiumClmm, &raydium.RAYDIUM_CLMM_PROGRAM_ID, clmm.Span(), clmm.Offset("TokenMint0"), clmm.Offset("TokenMint1")},
+		{pkg.ProtocolNameRaydiumCpmm, &raydium.RAYDIUM_CPMM_PROGRAM_ID, raydium.CPMMPoolSize, cpmm.Offset("Token0Mint"), cpmm.Offset("Token1Mint")},
+		{pkg.ProtocolNameMeteoraDlmm, &meteora.MeteoraProgramID, meteora.LbPairSize, dlmm.Offset("TokenXMint"), dlmm.Offset("TokenYMint")},
+		{pkg.ProtocolNameWhirlpool, &whirlpool.WhirlpoolProgramID, whirlpool.WhirlpoolSize, whirlpool.TokenMintAOffset, whirlpool.TokenMintBOffset},
+		{pkg.ProtocolNameOrca, &orca.OrcaAmmProgramID, 0, 104, 136},
+		{pkg.ProtocolNameSplTokenSwap, &splswap.SplTokenSwapProgramID, 0, 99, 131},
+		{pkg.ProtocolNameAldrin, &aldrin.AldrinAmmProgramID, 0, 8, 40},
+		{pkg.ProtocolNameSaros, &saros.SarosProgramID, 0, 8, 40},
+		{pkg.ProtocolNameFluxbeam, &fluxbeam.FluxbeamProgramID, 0, 8, 40},
+		{pkg.ProtocolNameGooseFX, &goosefx.GooseFXProgramID, 0, 8, 40},
+	}
+}
+
+// Indexer scans the sources' programs into an Index
+type Indexer struct {
+	client  sol.SolClient
+	index   *Index
+	sources []Source
+}
+
+// NewIndexer scans the given sources, or DefaultSources when none are given
+func NewIndexer(client sol.SolClient, index *Index, sources ...Source) *Indexer {
+	if len(sources) == 0 {
+		sources = DefaultSources()
+	}
+	return &Indexer{client: client, index: index, sources: sources}
+}
+
+// Index returns the index the scans write to
+func (x *Indexer) Index() *Index {
+	return x.index
+}
+
+// Scan reads every pool of each source's program and replaces the source's
+// entries. A source whose scan fails keeps its previous entries.
+func (x *Indexer) Scan(ctx context.Context) error {
+	var errs []error
+	for _, source := range x.sources {
+		start := time.Now()
+		entries, err := x.scanSource(ctx, source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Protocol, err))
+			continue
+		}
+		if err := x.index.Replace(source.Protocol, entries); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save %s index: %w", source.Protocol, err))
+			continue
+		}
+		logging.Component(nil, "indexer").Info("indexed pools", "protocol", source.Protocol, "pools", len(entries), "duration", time.Since(start).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
+
+func (x *Indexer) scanSource(ctx context.Context, source Source) ([]Entry, error) {
+	// Shard the scan by the first byte of the first mint, like
+	// FetchAllPools, so no single call has to return the whole program
+	slice := source.Slice()
+	var entries []Entry
+	for shard := 0; shard < pkg.PoolShards; shard++ {
+		filters := []rpc.RPCFilter{
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: source.MintA, Bytes: solana.Base58{byte(shard)}}},
+		}
+		if source.DataSize > 0 {
+			filters = append(filters, rpc.RPCFilter{DataSize: source.DataSize})
+		}
+		accounts, err := sol.GetProgramAccountsSliced(ctx, x.client, *source.Program, filters, slice)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shard %d of program %s: %w", shard, source.Program, err)
+		}
+		for _, account := range accounts {
+			data := account.Account.Data.GetBinary()
+			mintA, okA := slice.Pubkey(data, source.MintA)
+			mintB, okB := slice.Pubkey(data, source.MintB)
+			if !okA || !okB {
+				continue
+			}
+			entries = append(entries, Entry{Pool: account.Pubkey.String(), MintA: mintA.String(), MintB: mintB.String()})
+		}
+	}
+	return entries, nil
+}
+
+// ProgramSubscriber streams the changes of a program's accounts, as
+// subscription.SubscriptionManager does over a WebSocket
+type ProgramSubscriber interface {
+	SubscribeProgram(program string, opts subscription.ProgramOptions, handler subscription.AccountUpdateHandler) (uint64, error)
+}
+
+// Watch subscribes to each source's program so pools created between scans
+// enter the index as soon as the node reports them, and closed ones leave
+// it. Only the mint range of each account is streamed, but every change of
+// a pool account is, swaps included. The subscriptions follow the
+// subscriber's reconnects; pools created while it is disconnected wait for
+// the next scan.
+func (x *Indexer) Watch(subscriber ProgramSubscriber) error {
+	var errs []error
+	for _, source := range x.sources {
+		opts := subscription.ProgramOptions{DataSize: source.DataSize, Slice: source.Slice()}
+		if _, err := subscriber.SubscribeProgram(source.Program.String(), opts, func(pool string, data []byte, _ uint64) {
+			x.observe(source, pool, data)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Protocol, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// observe records a change of one of source's accounts: its mints add the
+// pool, and empty data means the account was closed
+func (x *Indexer) observe(source Source, pool string, data []byte) {
+	var err error
+	if len(data) == 0 {
+		err = x.index.Remove(source.Protocol, pool)
+	} else {
+		slice := source.Slice()
+		mintA, okA := slice.Pubkey(data, source.MintA)
+		mintB, okB := slice.Pubkey(data, source.MintB)
+		if !okA || !okB {
+			return
+		}
+		err = x.index.Add(source.Protocol, Entry{Pool: pool, MintA: mintA.String(), MintB: mintB.String()})
+	}
+	if err != nil {
+		logging.Component(nil, "indexer").Warn("failed to update pool index", "protocol", source.Protocol, "pool", pool, "error", err)
+	}
+}
+
+// Run scans the programs whose index is missing or older than interval at
+// once, then rescans every interval until ctx is done
+func (x *Indexer) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	stale := x.sources[:0:0]
+	for _, source := range x.sources {
+		if time.Since(x.index.ScannedAt(source.Protocol)) >= interval {
+			stale = append(stale, source)
+		}
+	}
+	if len(stale) > 0 {
+		if err := (&Indexer{client: x.client, index: x.index, sources: stale}).Scan(ctx); err != nil {
+			logging.Component(nil, "indexer").Warn("pool index scan failed", "error", err)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := x.Scan(ctx); err != nil {
+				logging.Component(nil, "indexer").Warn("pool index scan failed", "error", err)
+			}
+		}
+	}
+}
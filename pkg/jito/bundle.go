@@ -0,0 +1,67 @@
+package jito
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// MaxBundleTransactions is the block engine's limit on transactions per bundle
+const MaxBundleTransactions = 5
+
+// Bundle is an ordered group of signed transactions executed atomically
+type Bundle struct {
+	Transactions []*solana.Transaction
+}
+
+// NewBundle creates a bundle from signed transactions
+func NewBundle(txs ...*solana.Transaction) (*Bundle, error) {
+	b := &Bundle{}
+	for _, tx := range txs {
+		if err := b.Add(tx); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Add appends a signed transaction to the bundle
+func (b *Bundle) Add(tx *solana.Transaction) error {
+	if tx == nil {
+		return fmt.Errorf("nil transaction")
+	}
+	if len(b.Transactions) >= MaxBundleTransactions {
+		return fmt.Errorf("bundle already holds the maximum of %d transactions", MaxBundleTransactions)
+	}
+	if len(tx.Signatures) == 0 {
+		return fmt.Errorf("transaction must be signed before bundling")
+	}
+	b.Transactions = append(b.Transactions, tx)
+	return nil
+}
+
+// Signatures returns the first signature of each transaction in bundle order
+func (b *Bundle) Signatures() []solana.Signature {
+	sigs := make([]solana.Signature, 0, len(b.Transactions))
+	for _, tx := range b.Transactions {
+		sigs = append(sigs, tx.Signatures[0])
+	}
+	return sigs
+}
+
+// encode serializes the bundle as base64 transactions
+func (b *Bundle) encode() ([]string, error) {
+	if len(b.Transactions) == 0 {
+		return nil, fmt.Errorf("empty bundle")
+	}
+	encoded := make([]string, 0, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize transaction %d: %w", i, err)
+		}
+		encoded = append(encoded, base64.StdEncoding.EncodeToString(raw))
+	}
+	return encoded, nil
+}
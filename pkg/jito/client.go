@@ -0,0 +1,356 @@
+package jito
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	jitorpc "github.com/jito-labs/jito-go-rpc"
+
+	"soltrading/pkg/logging"
+)
+
+// Bundle confirmation states reported by getBundleStatuses
+const (
+	StatusProcessed = "processed"
+	StatusConfirmed = "confirmed"
+	StatusFinalized = "finalized"
+)
+
+// Bundle states reported by getInflightBundleStatuses for the last five minutes
+const (
+	InflightInvalid = "Invalid" // unknown to the block engine, e.g. dropped before any leader saw it
+	InflightPending = "Pending"
+	InflightFailed  = "Failed" // rejected by every region, e.g. a transaction failed simulation
+	InflightLanded  = "Landed"
+)
+
+var (
+	// ErrBundleFailed is returned when a bundle landed with an error or the
+	// block engine reports it failed
+	ErrBundleFailed = errors.New("bundle failed")
+	// ErrBundleDropped is returned when the block engine no longer knows a bundle
+	ErrBundleDropped = errors.New("bundle dropped")
+)
+
+// Config configures a block engine client
+type Config struct {
+	// Region selects the block engine; ignored when Endpoint is set
+	Region Region
+	// Endpoint overrides the region's JSON-RPC base URL
+	Endpoint string
+	// UUID is the optional Jito auth key for higher rate limits
+	UUID string
+	// Logger receives the client's logs; nil uses logging.Default()
+	Logger *slog.Logger
+}
+
+// Client submits bundles to a Jito block engine
+type Client struct {
+	rpcClient *jitorpc.JitoJsonRpcClient
+	endpoint  string
+	logger    *slog.Logger
+
+	mu          sync.RWMutex // guards tipAccounts, replaced by RefreshTipAccounts
+	tipAccounts []solana.PublicKey
+}
+
+// BundleStatus is the landed state of a bundle
+type BundleStatus struct {
+	BundleID           string
+	ConfirmationStatus string
+	Slot               uint64
+	Transactions       []string
+	Err                interface{} // the bundle's error, nil when it succeeded
+}
+
+// InflightStatus is the state of a recently sent bundle
+type InflightStatus struct {
+	BundleID   string  `json:"bundle_id"`
+	Status     string  `json:"status"`
+	LandedSlot *uint64 `json:"landed_slot"`
+}
+
+// Landed reports whether the bundle reached at least confirmed commitment
+func (s *BundleStatus) Landed() bool {
+	return s.ConfirmationStatus == StatusConfirmed || s.ConfirmationStatus == StatusFinalized
+}
+
+// NewClient creates a block engine client and loads the current tip accounts
+func NewClient(ctx context.Context, cfg Config) (*Client, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = cfg.Region.Endpoint()
+	}
+
+	c := &Client{
+		rpcClient: jitorpc.NewJitoJsonRpcClient(endpoint, cfg.UUID),
+		endpoint:  endpoint,
+		logger:    logging.Component(cfg.Logger, "jito"),
+	}
+	if err := c.RefreshTipAccounts(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// log returns the client's logger; clients built without NewClient use the default
+func (c *Client) log() *slog.Logger {
+	if c.logger == nil {
+		return logging.Component(nil, "jito")
+	}
+	return c.logger
+}
+
+// Endpoint returns the block engine URL in use
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+// RefreshTipAccounts reloads the tip accounts from the block engine
+func (c *Client) RefreshTipAccounts(ctx context.Context) error {
+	raw, err := c.rpcClient.GetTipAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to get tip accounts: %w", err)
+	}
+
+	var addresses []string
+	if err := json.Unmarshal(raw, &addresses); err != nil {
+		return fmt.Errorf("failed to unmarshal tip accounts: %w", err)
+	}
+	if len(addresses) == 0 {
+		return fmt.Errorf("no tip accounts available")
+	}
+
+	accounts := make([]solana.PublicKey, 0, len(addresses))
+	for _, address := range addresses {
+		key, err := solana.PublicKeyFromBase58(address)
+		if err != nil {
+			return fmt.Errorf("invalid tip account %s: %w", address, err)
+		}
+		accounts = append(accounts, key)
+	}
+	c.mu.Lock()
+	c.tipAccounts = accounts
+	c.mu.Unlock()
+	return nil
+}
+
+// RandomTipAccount picks one of the tip accounts to spread write-lock contention
+func (c *Client) RandomTipAccount() solana.PublicKey {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tipAccounts[rand.Intn(len(c.tipAccounts))]
+}
+
+// TipInstruction builds a tip transfer to a random tip account
+func (c *Client) TipInstruction(payer solana.PublicKey, lamports uint64) solana.Instruction {
+	return NewTipInstruction(payer, c.RandomTipAccount(), lamports)
+}
+
+// SendBundle submits a bundle and returns its bundle ID
+func (c *Client) SendBundle(ctx context.Context, bundle *Bundle) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	encoded, err := bundle.encode()
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := c.rpcClient.SendBundle([][]string{encoded})
+	if err != nil {
+		return "", fmt.Errorf("failed to send bundle: %w", err)
+	}
+
+	var bundleID string
+	if err := json.Unmarshal(raw, &bundleID); err != nil {
+		return "", fmt.Errorf("failed to unmarshal bundle ID: %w", err)
+	}
+	return bundleID, nil
+}
+
+// GetBundleStatus returns the status of a bundle, or nil if it is not yet known
+func (c *Client) GetBundleStatus(ctx context.Context, bundleID string) (*BundleStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// jito-go-rpc's response type keeps only the Ok side of err, so the
+	// status is decoded here
+	var resp struct {
+		Value []*struct {
+			BundleID           string                     `json:"bundle_id"`
+			Transactions       []string                   `json:"transactions"`
+			Slot               uint64                     `json:"slot"`
+			ConfirmationStatus string                     `json:"confirmation_status"`
+			Err                map[string]json.RawMessage `json:"err"`
+		} `json:"value"`
+	}
+	if err := c.call(ctx, "/getBundleStatuses", "getBundleStatuses", [][]string{{bundleID}}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get bundle status: %w", err)
+	}
+	if len(resp.Value) == 0 || resp.Value[0] == nil {
+		return nil, nil
+	}
+
+	v := resp.Value[0]
+	return &BundleStatus{
+		BundleID:           v.BundleID,
+		ConfirmationStatus: v.ConfirmationStatus,
+		Slot:               v.Slot,
+		Transactions:       v.Transactions,
+		Err:                resultErr(v.Err),
+	}, nil
+}
+
+// GetInflightBundleStatus returns the state of a bundle sent in the last five
+// minutes
+func (c *Client) GetInflightBundleStatus(ctx context.Context, bundleID string) (*InflightStatus, error) {
+	var resp struct {
+		Value []*InflightStatus `json:"value"`
+	}
+	if err := c.call(ctx, "/getInflightBundleStatuses", "getInflightBundleStatuses", [][]string{{bundleID}}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get inflight bundle status: %w", err)
+	}
+	if len(resp.Value) == 0 || resp.Value[0] == nil {
+		return &InflightStatus{BundleID: bundleID, Status: InflightInvalid}, nil
+	}
+	return resp.Value[0], nil
+}
+
+// resultErr returns the error of a Rust Result serialized as {"Ok": ...} or
+// {"Err": ...}, nil for Ok
+func resultErr(result map[string]json.RawMessage) interface{} {
+	raw, ok := result["Err"]
+	if !ok {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil || decoded == nil {
+		return string(raw)
+	}
+	return decoded
+}
+
+// call sends a JSON-RPC request to the block engine path and decodes its
+// result into out
+func (c *Client) call(ctx context.Context, path, method string, params, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.rpcClient.UUID != "" {
+		req.Header.Set("x-jito-auth", c.rpcClient.UUID)
+	}
+
+	resp, err := c.rpcClient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &rpcResp); err != nil {
+		return fmt.Errorf("status %d: %w", resp.StatusCode, err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("RPC error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+	}
+	return json.Unmarshal(rpcResp.Result, out)
+}
+
+// WaitForBundle polls until the bundle lands, the context ends, or the timeout
+// elapses. A bundle that landed with an error, or that the block engine reports
+// failed, returns ErrBundleFailed; one it reports invalid on two polls in a row
+// returns ErrBundleDropped.
+func (c *Client) WaitForBundle(ctx context.Context, bundleID string, pollInterval, timeout time.Duration) (*BundleStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	logger := c.log()
+	invalid := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("bundle %s not landed: %w", bundleID, ctx.Err())
+		case <-ticker.C:
+			status, err := c.GetBundleStatus(ctx, bundleID)
+			if err != nil {
+				logger.Warn("failed to get bundle status", "bundle", bundleID, "error", err)
+				continue
+			}
+			if status != nil {
+				if status.Err != nil {
+					return status, fmt.Errorf("bundle %s %w: %v", bundleID, ErrBundleFailed, status.Err)
+				}
+				if status.Landed() {
+					return status, nil
+				}
+				continue
+			}
+
+			// Not landed yet: the inflight status tells a pending bundle from
+			// one that will never land
+			inflight, err := c.GetInflightBundleStatus(ctx, bundleID)
+			if err != nil {
+				logger.Warn("failed to get inflight bundle status", "bundle", bundleID, "error", err)
+				continue
+			}
+			switch inflight.Status {
+			case InflightFailed:
+				return nil, fmt.Errorf("bundle %s %w: rejected by the block engine", bundleID, ErrBundleFailed)
+			case InflightInvalid:
+				// The block engine may not have indexed a just-sent bundle yet
+				if invalid++; invalid >= 2 {
+					return nil, fmt.Errorf("bundle %s %w: unknown to the block engine", bundleID, ErrBundleDropped)
+				}
+			default:
+				invalid = 0
+			}
+		}
+	}
+}
+
+// SendAndWait submits a bundle and waits for it to land
+func (c *Client) SendAndWait(ctx context.Context, bundle *Bundle, timeout time.Duration) (*BundleStatus, error) {
+	bundleID, err := c.SendBundle(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+	c.log().Info("bundle sent", "bundle", bundleID)
+	return c.WaitForBundle(ctx, bundleID, 2*time.Second, timeout)
+}
@@ -0,0 +1,89 @@
+package jito
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Region identifies a Jito block engine location
+type Region string
+
+const (
+	RegionMainnet   Region = "mainnet"
+	RegionAmsterdam Region = "amsterdam"
+	RegionFrankfurt Region = "frankfurt"
+	RegionLondon    Region = "london"
+	RegionNewYork   Region = "ny"
+	RegionSaltLake  Region = "slc"
+	RegionTokyo     Region = "tokyo"
+	RegionSingapore Region = "singapore"
+)
+
+// AllRegions lists every known block engine region
+var AllRegions = []Region{
+	RegionMainnet,
+	RegionAmsterdam,
+	RegionFrankfurt,
+	RegionLondon,
+	RegionNewYork,
+	RegionSaltLake,
+	RegionTokyo,
+	RegionSingapore,
+}
+
+// Endpoint returns the JSON-RPC base URL of the region's block engine.
+// Refer to: https://docs.jito.wtf/lowlatencytxnsend/
+func (r Region) Endpoint() string {
+	if r == RegionMainnet || r == "" {
+		return "https://mainnet.block-engine.jito.wtf/api/v1"
+	}
+	return fmt.Sprintf("https://%s.mainnet.block-engine.jito.wtf/api/v1", r)
+}
+
+// SelectFastestRegion probes each region concurrently and returns the one that
+// answered first. It falls back to RegionMainnet when none respond.
+func SelectFastestRegion(ctx context.Context, regions []Region) Region {
+	if len(regions) == 0 {
+		regions = AllRegions
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	type probe struct {
+		region  Region
+		latency time.Duration
+	}
+	results := make(chan probe, len(regions))
+
+	var wg sync.WaitGroup
+	for _, region := range regions {
+		wg.Add(1)
+		go func(region Region) {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(probeCtx, http.MethodGet, region.Endpoint()+"/bundles", nil)
+			if err != nil {
+				return
+			}
+			start := time.Now()
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+			results <- probe{region: region, latency: time.Since(start)}
+		}(region)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	if first, ok := <-results; ok {
+		return first.region
+	}
+	return RegionMainnet
+}
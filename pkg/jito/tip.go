@@ -0,0 +1,40 @@
+package jito
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// DefaultTipLamports is a conservative tip when none is configured
+const DefaultTipLamports uint64 = 10_000
+
+// NewTipInstruction builds a SOL transfer from payer to a Jito tip account.
+// Appending it to the last transaction of a bundle makes the bundle eligible
+// for the auction.
+func NewTipInstruction(payer, tipAccount solana.PublicKey, lamports uint64) solana.Instruction {
+	return system.NewTransferInstruction(lamports, payer, tipAccount).Build()
+}
+
+// NewTipTransaction builds and signs a standalone tip transaction
+func NewTipTransaction(payer solana.PrivateKey, tipAccount solana.PublicKey, lamports uint64, recentBlockhash solana.Hash) (*solana.Transaction, error) {
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{NewTipInstruction(payer.PublicKey(), tipAccount, lamports)},
+		recentBlockhash,
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tip transaction: %w", err)
+	}
+
+	if _, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if payer.PublicKey().Equals(key) {
+			return &payer
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to sign tip transaction: %w", err)
+	}
+	return tx, nil
+}
@@ -0,0 +1,194 @@
+// Package jupiter fetches reference quotes from Jupiter's public swap API to
+// validate the local quote implementations against
+package jupiter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"cosmossdk.io/math"
+	"soltrading/pkg"
+)
+
+// DefaultBaseURL is Jupiter's keyless quote API
+const DefaultBaseURL = "https://lite-api.jup.ag/swap/v1"
+
+// AllProtocols marks a comparison of the best route across every protocol
+const AllProtocols = "all"
+
+// DexLabels maps protocol names to the AMM labels Jupiter's dexes parameter takes
+var DexLabels = map[pkg.ProtocolName]string{
+	pkg.ProtocolNamePumpAmm:     "Pump.fun Amm",
+	pkg.ProtocolNameRaydiumAmm:  "Raydium",
+	pkg.ProtocolNameRaydiumClmm: "Raydium CLMM",
+	pkg.ProtocolNameRaydiumCpmm: "Raydium CP",
+	pkg.ProtocolNameMeteoraDlmm: "Meteora DLMM",
+	pkg.ProtocolNameWhirlpool:   "Whirlpool",
+}
+
+// QuoteResponse is the part of Jupiter's quote response used for comparisons
+type QuoteResponse struct {
+	InputMint      string      `json:"inputMint"`
+	InAmount       string      `json:"inAmount"`
+	OutputMint     string      `json:"outputMint"`
+	OutAmount      string      `json:"outAmount"`
+	SwapMode       string      `json:"swapMode"`
+	PriceImpactPct string      `json:"priceImpactPct"`
+	RoutePlan      []RoutePlan `json:"routePlan"`
+	ContextSlot    uint64      `json:"contextSlot"`
+}
+
+type RoutePlan struct {
+	SwapInfo SwapInfo `json:"swapInfo"`
+	Percent  int      `json:"percent"`
+}
+
+type SwapInfo struct {
+	AmmKey     string `json:"ammKey"`
+	Label      string `json:"label"`
+	InputMint  string `json:"inputMint"`
+	OutputMint string `json:"outputMint"`
+	InAmount   string `json:"inAmount"`
+	OutAmount  string `json:"outAmount"`
+}
+
+// Client queries the Jupiter quote API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for baseURL, or DefaultBaseURL when empty
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Quote fetches an ExactIn quote restricted to direct routes, so it is
+// comparable with the single-hop local router. Non-empty dexes limits the
+// route to those Jupiter AMM labels.
+func (c *Client) Quote(ctx context.Context, inputMint, outputMint string, amount math.Int, dexes ...string) (*QuoteResponse, error) {
+	params := url.Values{}
+	params.Set("inputMint", inputMint)
+	params.Set("outputMint", outputMint)
+	params.Set("amount", amount.String())
+	params.Set("swapMode", "ExactIn")
+	params.Set("onlyDirectRoutes", "true")
+	if len(dexes) > 0 {
+		params.Set("dexes", strings.Join(dexes, ","))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/quote?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Jupiter request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Jupiter quote: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Jupiter response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return nil, fmt.Errorf("jupiter quote failed (%d): %s", resp.StatusCode, apiErr.Error)
+		}
+		return nil, fmt.Errorf("jupiter quote failed with status %d", resp.StatusCode)
+	}
+
+	var quote QuoteResponse
+	if err := json.Unmarshal(body, &quote); err != nil {
+		return nil, fmt.Errorf("failed to decode Jupiter quote: %w", err)
+	}
+	return &quote, nil
+}
+
+// LocalQuote is a locally computed quote to check against Jupiter. Protocol is
+// AllProtocols for the best route over every protocol.
+type LocalQuote struct {
+	Protocol  string
+	PoolID    string
+	OutAmount math.Int
+}
+
+// Comparison reports how far a local quote is from Jupiter's for the same
+// input. DeviationBps is positive when the local quote promises more.
+type Comparison struct {
+	Protocol      string  `json:"protocol"`
+	PoolID        string  `json:"poolId"`
+	LocalOut      string  `json:"localOut"`
+	JupiterOut    string  `json:"jupiterOut,omitempty"`
+	JupiterPoolID string  `json:"jupiterPoolId,omitempty"`
+	SamePool      bool    `json:"samePool"`
+	DeviationBps  float64 `json:"deviationBps"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// Compare fetches Jupiter's quote for each local quote, restricted to the
+// same protocol, and reports the deviation. Deviations are only meaningful
+// when SamePool is set; otherwise Jupiter found a different pool.
+func (c *Client) Compare(ctx context.Context, inputMint, outputMint string, amountIn math.Int, quotes []LocalQuote) []Comparison {
+	comparisons := make([]Comparison, len(quotes))
+	for i, local := range quotes {
+		comparisons[i] = Comparison{
+			Protocol: local.Protocol,
+			PoolID:   local.PoolID,
+			LocalOut: local.OutAmount.String(),
+		}
+
+		var dexes []string
+		if local.Protocol != AllProtocols {
+			label, ok := DexLabels[pkg.ProtocolName(local.Protocol)]
+			if !ok {
+				comparisons[i].Error = fmt.Sprintf("no Jupiter label for protocol %s", local.Protocol)
+				continue
+			}
+			dexes = []string{label}
+		}
+
+		quote, err := c.Quote(ctx, inputMint, outputMint, amountIn, dexes...)
+		if err != nil {
+			comparisons[i].Error = err.Error()
+			continue
+		}
+		jupiterOut, ok := math.NewIntFromString(quote.OutAmount)
+		if !ok || !jupiterOut.IsPositive() {
+			comparisons[i].Error = fmt.Sprintf("invalid Jupiter outAmount %q", quote.OutAmount)
+			continue
+		}
+
+		comparisons[i].JupiterOut = jupiterOut.String()
+		if len(quote.RoutePlan) > 0 {
+			comparisons[i].JupiterPoolID = quote.RoutePlan[0].SwapInfo.AmmKey
+			comparisons[i].SamePool = len(quote.RoutePlan) == 1 && comparisons[i].JupiterPoolID == local.PoolID
+		}
+		comparisons[i].DeviationBps = DeviationBps(local.OutAmount, jupiterOut)
+	}
+	return comparisons
+}
+
+// DeviationBps returns (local - reference) / reference in basis points
+func DeviationBps(local, reference math.Int) float64 {
+	if !reference.IsPositive() {
+		return 0
+	}
+	deviation, _ := local.Sub(reference).ToLegacyDec().Quo(reference.ToLegacyDec()).Float64()
+	return deviation * 10000
+}
@@ -0,0 +1,44 @@
+// Package logging holds the structured logger the library writes to. Library
+// code logs with log/slog, tagging each record with the component that wrote
+// it; embedders can pass their own logger to the router, sol client and
+// subscription constructors, or replace the default for everything else.
+package logging
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// ComponentKey is the attribute naming the part of the library a record came from
+const ComponentKey = "component"
+
+var defaultLogger atomic.Pointer[slog.Logger]
+
+// SetDefault sets the logger used by library code that wasn't given one. nil
+// restores slog.Default(). Components built before the call keep the logger
+// they were built with.
+func SetDefault(logger *slog.Logger) {
+	defaultLogger.Store(logger)
+}
+
+// Default returns the logger set with SetDefault, or slog.Default()
+func Default() *slog.Logger {
+	if logger := defaultLogger.Load(); logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Discard returns a logger that drops every record, to silence the library
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// Component returns logger, or Default() when it is nil, with the component
+// attribute set to name
+func Component(logger *slog.Logger, name string) *slog.Logger {
+	if logger == nil {
+		logger = Default()
+	}
+	return logger.With(ComponentKey, name)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"time"
 
 	cosmath "cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
@@ -23,10 +24,17 @@ type AldrinPool struct {
 	FeeDenominator uint64
 
 	PoolId solana.PublicKey
+
+	// Vault balances cached from RPC or WebSocket updates
+	reserveA        cosmath.Int
+	reserveB        cosmath.Int
+	lastCacheUpdate time.Time
+	cacheDataFresh  bool
+	lastSlot        uint64
 }
 
 func (p *AldrinPool) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("aldrin")
+	return pkg.ProtocolNameAldrin
 }
 
 func (p *AldrinPool) GetProgramID() solana.PublicKey {
@@ -41,6 +49,66 @@ func (p *AldrinPool) GetTokens() (string, string) {
 	return p.TokenA.String(), p.TokenB.String()
 }
 
+// GetFeeRate returns the trade fee in basis points
+func (p *AldrinPool) GetFeeRate() float64 {
+	if p.FeeDenominator == 0 {
+		return 0
+	}
+	return float64(p.FeeNumerator) / float64(p.FeeDenominator) * 10000
+}
+
+// GetReserves returns the vault balances last fetched by Quote or a vault update
+func (p *AldrinPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(p.reserveA, p.reserveB, p.lastSlot)
+}
+
+// SetLastSlot records the slot of an account update applied to the pool
+func (p *AldrinPool) SetLastSlot(slot uint64) {
+	if slot > p.lastSlot {
+		p.lastSlot = slot
+	}
+}
+
+// GetBaseVault returns the token A vault address
+func (p *AldrinPool) GetBaseVault() string {
+	return p.TokenVaultA.String()
+}
+
+// GetQuoteVault returns the token B vault address
+func (p *AldrinPool) GetQuoteVault() string {
+	return p.TokenVaultB.String()
+}
+
+// UpdateFromAccountData implements the PoolStateUpdater interface
+func (p *AldrinPool) UpdateFromAccountData(accountID string, data []byte) error {
+	switch accountID {
+	case p.PoolId.String():
+		// Pool state only carries vault addresses and fees; reserves stay cached
+		if err := p.Decode(data); err != nil {
+			return fmt.Errorf("failed to decode pool data: %w", err)
+		}
+		return nil
+	case p.TokenVaultA.String(), p.TokenVaultB.String():
+		amount, err := sol.TokenAccountAmount(data)
+		if err != nil {
+			return fmt.Errorf("invalid vault data: %w", err)
+		}
+		balance := cosmath.NewIntFromUint64(amount)
+		if accountID == p.TokenVaultA.String() {
+			p.reserveA = balance
+		} else {
+			p.reserveB = balance
+		}
+		// Both reserves must be known before quoting from cache
+		if !p.reserveA.IsNil() && !p.reserveB.IsNil() {
+			p.lastCacheUpdate = time.Now()
+			p.cacheDataFresh = true
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown account ID for pool: %s", accountID)
+}
+
 func (p *AldrinPool) Decode(data []byte) error {
 	if len(data) < 200 {
 		return fmt.Errorf("data too short for Aldrin pool: got %d bytes", len(data))
@@ -73,30 +141,38 @@ func (p *AldrinPool) Decode(data []byte) error {
 	return nil
 }
 
-func (p *AldrinPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
-	// Fetch vault balances to get current reserves
-	accounts := []solana.PublicKey{p.TokenVaultA, p.TokenVaultB}
-	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
-	if err != nil {
-		return cosmath.ZeroInt(), fmt.Errorf("failed to fetch vault balances: %w", err)
-	}
-
-	var reserveA, reserveB cosmath.Int
-	for i, result := range results.Value {
-		if result == nil {
-			return cosmath.ZeroInt(), fmt.Errorf("vault account %s not found", accounts[i])
+func (p *AldrinPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
+	// Only fetch from RPC if cache is not fresh (older than 5 seconds or never updated)
+	cacheTooOld := time.Since(p.lastCacheUpdate) > 5*time.Second
+	if !p.cacheDataFresh || cacheTooOld {
+		// Fetch vault balances to get current reserves
+		accounts := []solana.PublicKey{p.TokenVaultA, p.TokenVaultB}
+		results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+		if err != nil {
+			return cosmath.ZeroInt(), fmt.Errorf("failed to fetch vault balances: %w", err)
 		}
 
-		// Extract balance from token account (offset 64, 8 bytes)
-		amountBytes := result.Data.GetBinary()[64:72]
-		balance := binary.LittleEndian.Uint64(amountBytes)
-
-		if accounts[i].Equals(p.TokenVaultA) {
-			reserveA = cosmath.NewIntFromUint64(balance)
-		} else {
-			reserveB = cosmath.NewIntFromUint64(balance)
+		for i, result := range results.Value {
+			if result == nil {
+				return cosmath.ZeroInt(), fmt.Errorf("vault account %s not found", accounts[i])
+			}
+
+			balance, err := sol.TokenAccountAmount(result.Data.GetBinary())
+			if err != nil {
+				return cosmath.ZeroInt(), fmt.Errorf("invalid vault account %s: %w", accounts[i], err)
+			}
+
+			if accounts[i].Equals(p.TokenVaultA) {
+				p.reserveA = cosmath.NewIntFromUint64(balance)
+			} else {
+				p.reserveB = cosmath.NewIntFromUint64(balance)
+			}
 		}
+		p.lastCacheUpdate = time.Now()
+		p.lastSlot = results.Context.Slot
+		p.cacheDataFresh = true
 	}
+	reserveA, reserveB := p.reserveA, p.reserveB
 
 	// Determine swap direction
 	var reserveIn, reserveOut cosmath.Int
@@ -129,7 +205,7 @@ func (p *AldrinPool) Quote(ctx context.Context, solClient *sol.Client, inputMint
 
 func (p *AldrinPool) BuildSwapInstructions(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	user solana.PublicKey,
 	inputMint string,
 	inputAmount cosmath.Int,
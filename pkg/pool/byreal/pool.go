@@ -22,7 +22,7 @@ type ByrealPool struct {
 }
 
 func (p *ByrealPool) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("byreal")
+	return pkg.ProtocolNameByreal
 }
 
 func (p *ByrealPool) GetProgramID() solana.PublicKey {
@@ -37,15 +37,25 @@ func (p *ByrealPool) GetTokens() (string, string) {
 	return p.TokenMintA.String(), p.TokenMintB.String()
 }
 
+// GetFeeRate returns 0 until Byreal pool decoding is implemented
+func (p *ByrealPool) GetFeeRate() float64 {
+	return 0
+}
+
+// GetReserves returns zero reserves until Byreal pool decoding is implemented
+func (p *ByrealPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(cosmath.Int{}, cosmath.Int{}, 0)
+}
+
 func (p *ByrealPool) Decode(data []byte) error {
 	return fmt.Errorf("byreal decode not yet implemented")
 }
 
-func (p *ByrealPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
+func (p *ByrealPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
 	// TODO: Implement Byreal CLMM quote calculation
 	return cosmath.ZeroInt(), fmt.Errorf("byreal quote not yet implemented")
 }
 
-func (p *ByrealPool) BuildSwapInstructions(ctx context.Context, solClient *sol.Client, user solana.PublicKey, inputMint string, inputAmount cosmath.Int, minOutputAmount cosmath.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
+func (p *ByrealPool) BuildSwapInstructions(ctx context.Context, solClient sol.SolClient, user solana.PublicKey, inputMint string, inputAmount cosmath.Int, minOutputAmount cosmath.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
 	return nil, fmt.Errorf("byreal swap not yet implemented")
 }
@@ -20,7 +20,7 @@ type LifinityPool struct {
 }
 
 func (p *LifinityPool) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("lifinity")
+	return pkg.ProtocolNameLifinity
 }
 
 func (p *LifinityPool) GetProgramID() solana.PublicKey {
@@ -35,15 +35,25 @@ func (p *LifinityPool) GetTokens() (string, string) {
 	return p.TokenMintA.String(), p.TokenMintB.String()
 }
 
+// GetFeeRate returns 0 until Lifinity pool decoding is implemented
+func (p *LifinityPool) GetFeeRate() float64 {
+	return 0
+}
+
+// GetReserves returns zero reserves until Lifinity pool decoding is implemented
+func (p *LifinityPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(cosmath.Int{}, cosmath.Int{}, 0)
+}
+
 func (p *LifinityPool) Decode(data []byte) error {
 	return fmt.Errorf("lifinity decode not yet implemented")
 }
 
-func (p *LifinityPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
+func (p *LifinityPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
 	// TODO: Implement Lifinity's oracle-based quote calculation
 	return cosmath.ZeroInt(), fmt.Errorf("lifinity quote not yet implemented")
 }
 
-func (p *LifinityPool) BuildSwapInstructions(ctx context.Context, solClient *sol.Client, user solana.PublicKey, inputMint string, inputAmount cosmath.Int, minOutputAmount cosmath.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
+func (p *LifinityPool) BuildSwapInstructions(ctx context.Context, solClient sol.SolClient, user solana.PublicKey, inputMint string, inputAmount cosmath.Int, minOutputAmount cosmath.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
 	return nil, fmt.Errorf("lifinity swap not yet implemented")
 }
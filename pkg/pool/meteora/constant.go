@@ -16,6 +16,9 @@ const (
 	ExtensionTickArrayBitmapSize = 14
 	BinArrayBitmapSize           = 512
 	ExtensionBinArrayBitmapSize  = 12
+
+	// LbPairSize is the size of an LbPair account, discriminator included
+	LbPairSize = 904
 )
 
 // Tick and bin ID range constants
@@ -7,8 +7,10 @@ import (
 	"time"
 	"unsafe"
 
+	cosmath "cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
 	"soltrading/pkg"
+	"soltrading/pkg/anchor"
 	"soltrading/pkg/sol"
 )
 
@@ -88,11 +90,11 @@ type MeteoraDlmmPool struct {
 	BitmapExtensionKey solana.PublicKey
 	bitmapExtension    *BinArrayBitmapExtension
 	Clock              sol.Clock
-	orgActiveId        int32
 
 	// Cache tracking for WebSocket updates
 	lastCacheUpdate time.Time
 	cacheDataFresh  bool
+	lastSlot        uint64
 }
 
 func (pool *MeteoraDlmmPool) ProtocolName() pkg.ProtocolName {
@@ -113,6 +115,36 @@ func (pool *MeteoraDlmmPool) GetTokens() (string, string) {
 	return pool.TokenXMint.String(), pool.TokenYMint.String()
 }
 
+// GetFeeRate returns the current base plus variable fee in basis points
+func (pool *MeteoraDlmmPool) GetFeeRate() float64 {
+	rate, err := pool.GetTotalFee()
+	if err != nil {
+		return 0
+	}
+	fee, _ := new(big.Float).SetInt(rate).Float64()
+	return fee / FeePrecision * 10000
+}
+
+// GetReserves returns the token amounts held in the cached bin arrays, which
+// cover the bins around the active one
+func (pool *MeteoraDlmmPool) GetReserves() pkg.Reserves {
+	amountX, amountY := new(big.Int), new(big.Int)
+	for _, binArray := range pool.BinArrays {
+		for _, bin := range binArray.bins {
+			amountX.Add(amountX, new(big.Int).SetUint64(bin.amountX))
+			amountY.Add(amountY, new(big.Int).SetUint64(bin.amountY))
+		}
+	}
+	return pkg.NewReserves(cosmath.NewIntFromBigInt(amountX), cosmath.NewIntFromBigInt(amountY), pool.lastSlot)
+}
+
+// SetLastSlot records the slot of an account update applied to the pool
+func (pool *MeteoraDlmmPool) SetLastSlot(slot uint64) {
+	if slot > pool.lastSlot {
+		pool.lastSlot = slot
+	}
+}
+
 // GetBaseVault returns the base reserve address (reserveX)
 func (pool *MeteoraDlmmPool) GetBaseVault() string {
 	return pool.reserveX.String()
@@ -123,6 +155,36 @@ func (pool *MeteoraDlmmPool) GetQuoteVault() string {
 	return pool.reserveY.String()
 }
 
+// GetAuxiliaryAccounts returns the bin arrays a swap in either direction would
+// traverse, plus any bin arrays already cached, which quotes depend on. The
+// oracle is left out: it only records price observations, and every swap that
+// writes it also writes the pool, whose update carries the new volatility
+// parameters.
+func (pool *MeteoraDlmmPool) GetAuxiliaryAccounts() []string {
+	seen := make(map[string]bool)
+	var accounts []string
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			accounts = append(accounts, key)
+		}
+	}
+
+	for _, swapForY := range []bool{true, false} {
+		pubkeys, err := pool.GetBinArrayPubkeysForSwap(swapForY, 4)
+		if err != nil {
+			continue
+		}
+		for _, pubkey := range pubkeys {
+			add(pubkey.String())
+		}
+	}
+	for key := range pool.BinArrays {
+		add(key)
+	}
+	return accounts
+}
+
 // UpdateFromAccountData implements the PoolStateUpdater interface
 func (pool *MeteoraDlmmPool) UpdateFromAccountData(accountID string, data []byte) error {
 	// Check if this is a reserve update (token account)
@@ -135,13 +197,25 @@ func (pool *MeteoraDlmmPool) UpdateFromAccountData(accountID string, data []byte
 
 	// Check if this is a pool state update
 	if accountID == pool.PoolId.String() {
-		// Would need to decode full pool state here
+		// Decode only touches on-chain fields, so PoolId, bin arrays,
+		// bitmap extension and clock survive the refresh
+		if err := pool.Decode(data); err != nil {
+			return fmt.Errorf("failed to decode pool data: %w", err)
+		}
 		pool.lastCacheUpdate = time.Now()
 		pool.cacheDataFresh = true
 		return nil
 	}
 
-	// Could be bin array or bitmap extension update
+	// Otherwise this is one of the subscribed bin arrays
+	binArray, err := ParseBinArray(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse bin array %s: %w", accountID, err)
+	}
+	if pool.BinArrays == nil {
+		pool.BinArrays = make(map[string]BinArray)
+	}
+	pool.BinArrays[accountID] = binArray
 	pool.lastCacheUpdate = time.Now()
 	pool.cacheDataFresh = true
 	return nil
@@ -164,8 +238,14 @@ func (pool *MeteoraDlmmPool) Offset(field string) uint64 {
 	}
 }
 
-// Decode deserializes binary data into the pool structure
+// Decode deserializes binary data into the pool structure. Data that is not
+// an LbPair account returns an *anchor.AccountMismatchError.
 func (pool *MeteoraDlmmPool) Decode(data []byte) error {
+	if err := anchor.VerifyAccount("LbPair", data, LbPairSize); err != nil {
+		return err
+	}
+	copy(pool.Discriminator[:], data[:8])
+
 	// Manual parsing for first few fields
 	offset := 8 // Skip discriminator
 	pool.parameters.baseFactor = uint16(data[offset]) | uint16(data[offset+1])<<8
@@ -414,7 +494,7 @@ func (pool *MeteoraDlmmPool) ComputeFee(amount uint64) (uint64, error) {
 }
 
 // UpdateClock fetches and updates the current clock information
-func (pool *MeteoraDlmmPool) UpdateClock(ctx context.Context, client *sol.Client) error {
+func (pool *MeteoraDlmmPool) UpdateClock(ctx context.Context, client sol.SolClient) error {
 	clock, err := client.GetClock(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get clock: %w", err)
@@ -424,7 +504,7 @@ func (pool *MeteoraDlmmPool) UpdateClock(ctx context.Context, client *sol.Client
 }
 
 // GetBinArrayForSwap retrieves bin arrays needed for swap operations
-func (pool *MeteoraDlmmPool) GetBinArrayForSwap(ctx context.Context, client *sol.Client) error {
+func (pool *MeteoraDlmmPool) GetBinArrayForSwap(ctx context.Context, client sol.SolClient) error {
 	// Only fetch from RPC if cache is not fresh (older than 5 seconds or never updated)
 	cacheTooOld := time.Since(pool.lastCacheUpdate) > 5*time.Second
 	if pool.cacheDataFresh && !cacheTooOld && pool.BinArrays != nil {
@@ -3,6 +3,8 @@ package meteora
 import (
 	"fmt"
 	"math/big"
+
+	"soltrading/pkg"
 )
 
 // BinArrayBitmapExtension represents an extension of the bin array bitmap
@@ -41,7 +43,7 @@ func (extension *BinArrayBitmapExtension) NextBinArrayIndexWithLiquidity(swapFor
 			if value != nil {
 				return *value, true, nil
 			}
-			return 0, false, fmt.Errorf("cannot find non zero liquidity bin array id")
+			return 0, false, fmt.Errorf("%w: cannot find non zero liquidity bin array id", pkg.ErrInsufficientLiquidity)
 		}
 	} else {
 		if swapForY {
@@ -52,7 +54,7 @@ func (extension *BinArrayBitmapExtension) NextBinArrayIndexWithLiquidity(swapFor
 			if value != nil {
 				return *value, true, nil
 			}
-			return 0, false, fmt.Errorf("cannot find non zero liquidity bin array id")
+			return 0, false, fmt.Errorf("%w: cannot find non zero liquidity bin array id", pkg.ErrInsufficientLiquidity)
 		} else {
 			value, err := extension.IterBitmap(startIndex, -BinArrayBitmapSize-1)
 			if err != nil {
@@ -11,16 +11,54 @@ import (
 	cosmosmath "cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
 	"lukechampine.com/uint128"
+	"soltrading/pkg"
 	"soltrading/pkg/sol"
 )
 
+// SwapQuote is a quote with the fees it pays. Fee is charged on the input and
+// includes ProtocolFee, the share the protocol keeps; BaseFee and VariableFee
+// split Fee by the rates in force in each bin crossed.
+type SwapQuote struct {
+	AmountOut   cosmosmath.Int
+	Fee         cosmosmath.Int
+	ProtocolFee cosmosmath.Int
+	BaseFee     cosmosmath.Int
+	VariableFee cosmosmath.Int
+}
+
 // Quote calculates the output amount for a given input amount and token
-func (pool *MeteoraDlmmPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount cosmosmath.Int) (cosmosmath.Int, error) {
-	pool.orgActiveId = pool.activeId
-	totalAmountOut := cosmosmath.ZeroInt()
+func (pool *MeteoraDlmmPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, inputAmount cosmosmath.Int) (cosmosmath.Int, error) {
+	quote, err := pool.QuoteWithFees(ctx, solClient, inputMint, inputAmount)
+	if err != nil {
+		return cosmosmath.ZeroInt(), err
+	}
+	return quote.AmountOut, nil
+}
+
+// QuoteWithFees simulates the swap bin by bin like the program: references
+// decay with the time since the pool's last swap, the volatility accumulator
+// grows with each bin crossed and each bin charges the base plus variable fee
+// at that point. The simulation runs on a copy of the pool and swaps against
+// copies of its bin arrays, so the pool's state is left as it was and
+// concurrent quotes don't interfere.
+func (pool *MeteoraDlmmPool) QuoteWithFees(ctx context.Context, solClient sol.SolClient, inputMint string, inputAmount cosmosmath.Int) (SwapQuote, error) {
+	sim := *pool
+	return sim.simulateSwap(inputMint, inputAmount)
+}
+
+// simulateSwap runs a quote, moving pool's active bin and volatility
+// parameters as the swap would; QuoteWithFees calls it on a copy of the pool
+func (pool *MeteoraDlmmPool) simulateSwap(inputMint string, inputAmount cosmosmath.Int) (SwapQuote, error) {
+	quote := SwapQuote{
+		AmountOut:   cosmosmath.ZeroInt(),
+		Fee:         cosmosmath.ZeroInt(),
+		ProtocolFee: cosmosmath.ZeroInt(),
+		BaseFee:     cosmosmath.ZeroInt(),
+		VariableFee: cosmosmath.ZeroInt(),
+	}
 
 	if err := pool.validateSwapActivation(); err != nil {
-		return cosmosmath.ZeroInt(), fmt.Errorf("swap activation validation failed: %w", err)
+		return quote, fmt.Errorf("swap activation validation failed: %w", err)
 	}
 	pool.UpdateReferences()
 
@@ -29,32 +67,32 @@ func (pool *MeteoraDlmmPool) Quote(ctx context.Context, solClient *sol.Client, i
 
 	// Process active bin arrays
 	for amountLeft.IsPositive() {
-		// Get the current active bin array
+		// A copy of the cached bin array: the swap below drains its bins
 		activeBinArray, err := pool.getCurrentActiveBinArray(swapForY)
 		if err != nil {
-			return cosmosmath.ZeroInt(), err
+			return quote, err
 		}
 
 		// Process active bins
 		for {
 			withinRange, err := activeBinArray.IsBinIDWithinRange(pool.activeId)
 			if err != nil {
-				return cosmosmath.ZeroInt(), fmt.Errorf("failed to check bin ID range: %w", err)
+				return quote, fmt.Errorf("failed to check bin ID range: %w", err)
 			}
 			if !withinRange || inputAmount.IsZero() {
 				if err := pool.AdvanceActiveBin(swapForY); err != nil {
-					return cosmosmath.ZeroInt(), fmt.Errorf("failed to advance active bin: %w", err)
+					return quote, fmt.Errorf("failed to advance active bin: %w", err)
 				}
 				break
 			} else {
 				// Update volatility accumulator
 				if err := pool.UpdateVolatilityAccumulator(); err != nil {
-					return cosmosmath.ZeroInt(), fmt.Errorf("failed to update volatility accumulator: %w", err)
+					return quote, fmt.Errorf("failed to update volatility accumulator: %w", err)
 				}
 
 				activeBin, err := activeBinArray.GetBinMut(pool.activeId)
 				if err != nil {
-					return cosmosmath.ZeroInt(), fmt.Errorf("failed to get active bin: %w", err)
+					return quote, fmt.Errorf("failed to get active bin: %w", err)
 				}
 
 				if !activeBin.IsEmpty(!swapForY) {
@@ -64,26 +102,58 @@ func (pool *MeteoraDlmmPool) Quote(ctx context.Context, solClient *sol.Client, i
 						swapForY,
 					)
 					if err != nil {
-						return cosmosmath.ZeroInt(), fmt.Errorf("swap failed: %w", err)
+						return quote, fmt.Errorf("swap failed: %w", err)
+					}
+					amountLeft = amountLeft.Sub(cosmosmath.NewIntFromUint64(swapResult.amountInWithFees))
+					quote.AmountOut = quote.AmountOut.Add(cosmosmath.NewIntFromUint64(swapResult.amountOut))
+					if err := pool.addFees(&quote, swapResult); err != nil {
+						return quote, err
 					}
-					amountLeft = amountLeft.Sub(cosmosmath.NewInt(int64(swapResult.amountInWithFees)))
-					totalAmountOut = totalAmountOut.Add(cosmosmath.NewInt(int64(swapResult.amountOut)))
 				}
 				if err := pool.AdvanceActiveBin(swapForY); err != nil {
-					return cosmosmath.ZeroInt(), fmt.Errorf("failed to advance active bin: %w", err)
+					return quote, fmt.Errorf("failed to advance active bin: %w", err)
 				}
 			}
 		}
 	}
+	return quote, nil
+}
+
+// addFees adds one bin's fees to quote, splitting them by the bin's base and
+// total fee rates
+func (pool *MeteoraDlmmPool) addFees(quote *SwapQuote, result *SwapResult) error {
+	fee := cosmosmath.NewIntFromUint64(result.fee)
+	quote.Fee = quote.Fee.Add(fee)
+	quote.ProtocolFee = quote.ProtocolFee.Add(cosmosmath.NewIntFromUint64(result.protocolFee))
+
+	baseRate, err := pool.GetBaseFee()
+	if err != nil {
+		return err
+	}
+	totalRate, err := pool.GetTotalFee()
+	if err != nil {
+		return err
+	}
+	baseFee := fee
+	if totalRate.Sign() > 0 && baseRate.Cmp(totalRate) < 0 {
+		baseFee = fee.Mul(cosmosmath.NewIntFromBigInt(baseRate)).Quo(cosmosmath.NewIntFromBigInt(totalRate))
+	}
+	quote.BaseFee = quote.BaseFee.Add(baseFee)
+	quote.VariableFee = quote.VariableFee.Add(fee.Sub(baseFee))
+	return nil
+}
 
-	pool.activeId = pool.orgActiveId
-	return totalAmountOut, nil
+// now returns the time and slot the program would see: the wall clock, or
+// the pool's Clock and last update slot when they are later
+func (pool *MeteoraDlmmPool) now() (int64, uint64) {
+	timestamp := max(time.Now().Unix(), int64(pool.Clock.UnixTimestamp))
+	return timestamp, max(pool.Clock.Slot, pool.lastSlot)
 }
 
 // validateSwapActivation checks if the swap is allowed based on pair status and activation conditions
 func (pool *MeteoraDlmmPool) validateSwapActivation() error {
-	currentTimestamp := uint64(time.Now().Unix())
-	currentSlot := uint64(pool.Clock.Slot)
+	timestamp, currentSlot := pool.now()
+	currentTimestamp := uint64(timestamp)
 
 	// Check pair status
 	if pool.status != uint8(PairStatusEnabled) {
@@ -109,9 +179,11 @@ func (pool *MeteoraDlmmPool) validateSwapActivation() error {
 	return nil
 }
 
-// UpdateReferences updates the volatility reference parameters based on elapsed time
+// UpdateReferences updates the volatility reference parameters based on the
+// time elapsed since the pool's last swap
 func (pool *MeteoraDlmmPool) UpdateReferences() {
-	elapsed := int64(pool.Clock.UnixTimestamp) - pool.vParameters.lastUpdateTimestamp
+	timestamp, _ := pool.now()
+	elapsed := timestamp - pool.vParameters.lastUpdateTimestamp
 	if elapsed >= int64(pool.parameters.filterPeriod) {
 		pool.vParameters.indexReference = pool.activeId
 		if elapsed < int64(pool.parameters.decayPeriod) {
@@ -201,13 +273,13 @@ func (pool *MeteoraDlmmPool) Swap(bin *Bin, amountIn uint64, swapForY bool) (*Sw
 	if swapForY {
 		bin.amountX += amountIntoBin
 		if bin.amountY < amountOut {
-			return nil, fmt.Errorf("insufficient Y amount")
+			return nil, fmt.Errorf("%w: insufficient Y amount", pkg.ErrInsufficientLiquidity)
 		}
 		bin.amountY -= amountOut
 	} else {
 		bin.amountY += amountIntoBin
 		if bin.amountX < amountOut {
-			return nil, fmt.Errorf("insufficient X amount")
+			return nil, fmt.Errorf("%w: insufficient X amount", pkg.ErrInsufficientLiquidity)
 		}
 		bin.amountX -= amountOut
 	}
@@ -434,8 +506,8 @@ func (pool *MeteoraDlmmPool) AdvanceActiveBin(swapForY bool) error {
 
 	// Check if new bin ID is within valid range
 	if nextActiveBinID < MinBinID || nextActiveBinID > MaxBinID {
-		return fmt.Errorf("insufficient liquidity: bin id %d out of range [%d, %d]",
-			nextActiveBinID, MinBinID, MaxBinID)
+		return fmt.Errorf("%w: bin id %d out of range [%d, %d]",
+			pkg.ErrInsufficientLiquidity, nextActiveBinID, MinBinID, MaxBinID)
 	}
 
 	// Update active bin ID
@@ -493,7 +565,8 @@ func (pool *MeteoraDlmmPool) GetBinArrayPubkeysForSwap(swapForY bool, takeCount
 	return binArrayPubkeys, nil
 }
 
-// getCurrentActiveBinArray retrieves the current active bin array for swap operations
+// getCurrentActiveBinArray returns a copy of the cached bin array holding the
+// active bin, which a simulated swap can change without touching the cache
 func (pool *MeteoraDlmmPool) getCurrentActiveBinArray(swapForY bool) (BinArray, error) {
 	startBinArrayIdx := BinIDToBinArrayIndex(pool.activeId)
 
@@ -537,7 +610,7 @@ func (pool *MeteoraDlmmPool) getCurrentActiveBinArray(swapForY bool) (BinArray,
 
 	binArray, exists := pool.BinArrays[pda.String()]
 	if !exists {
-		return BinArray{}, errors.New("active bin array not found")
+		return BinArray{}, fmt.Errorf("%w: active bin array not found", pkg.ErrStalePoolData)
 	}
 	return binArray, nil
 }
@@ -5,18 +5,18 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
-	"log"
 
 	"cosmossdk.io/math"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/logging"
 	"soltrading/pkg/sol"
 )
 
 // BuildSwapInstructions creates Solana instructions for performing a swap operation
 func (pool *MeteoraDlmmPool) BuildSwapInstructions(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	user solana.PublicKey,
 	inputMint string,
 	inputAmount math.Int,
@@ -28,7 +28,7 @@ func (pool *MeteoraDlmmPool) BuildSwapInstructions(
 
 	var userInTokenAccount solana.PublicKey
 	var userOutTokenAccount solana.PublicKey
-	log.Printf("inputMint: %v, pool.TokenXMint: %v,if:%v", inputMint, pool.TokenXMint.String(), inputMint == pool.TokenXMint.String())
+	logging.Component(nil, "meteora").Debug("building swap", "input_mint", inputMint, "token_x_mint", pool.TokenXMint.String(), "input_is_x", inputMint == pool.TokenXMint.String())
 	if inputMint == pool.TokenXMint.String() {
 		userInTokenAccount = userBaseAccount
 		userOutTokenAccount = userQuoteAccount
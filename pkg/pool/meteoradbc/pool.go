@@ -28,7 +28,7 @@ type DBCBin struct {
 }
 
 func (p *MeteoraDBCPool) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("meteoradbc")
+	return pkg.ProtocolNameMeteoraDBC
 }
 
 func (p *MeteoraDBCPool) GetProgramID() solana.PublicKey {
@@ -43,15 +43,25 @@ func (p *MeteoraDBCPool) GetTokens() (string, string) {
 	return p.TokenMintA.String(), p.TokenMintB.String()
 }
 
+// GetFeeRate returns 0 until Meteora DBC pool decoding is implemented
+func (p *MeteoraDBCPool) GetFeeRate() float64 {
+	return 0
+}
+
+// GetReserves returns zero reserves until Meteora DBC pool decoding is implemented
+func (p *MeteoraDBCPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(cosmath.Int{}, cosmath.Int{}, 0)
+}
+
 func (p *MeteoraDBCPool) Decode(data []byte) error {
 	return fmt.Errorf("meteoradbc decode not yet implemented")
 }
 
-func (p *MeteoraDBCPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
+func (p *MeteoraDBCPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
 	// TODO: Implement Meteora DBC quote calculation across bins
 	return cosmath.ZeroInt(), fmt.Errorf("meteoradbc quote not yet implemented")
 }
 
-func (p *MeteoraDBCPool) BuildSwapInstructions(ctx context.Context, solClient *sol.Client, user solana.PublicKey, inputMint string, inputAmount cosmath.Int, minOutputAmount cosmath.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
+func (p *MeteoraDBCPool) BuildSwapInstructions(ctx context.Context, solClient sol.SolClient, user solana.PublicKey, inputMint string, inputAmount cosmath.Int, minOutputAmount cosmath.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
 	return nil, fmt.Errorf("meteoradbc swap not yet implemented")
 }
@@ -2,8 +2,8 @@ package orca
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
+	"time"
 
 	cosmath "cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
@@ -21,10 +21,17 @@ type OrcaPool struct {
 	FeeDenominator uint64
 
 	PoolId solana.PublicKey
+
+	// Vault balances cached from RPC or WebSocket updates
+	reserveA        cosmath.Int
+	reserveB        cosmath.Int
+	lastCacheUpdate time.Time
+	cacheDataFresh  bool
+	lastSlot        uint64
 }
 
 func (p *OrcaPool) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("orca")
+	return pkg.ProtocolNameOrca
 }
 
 func (p *OrcaPool) GetProgramID() solana.PublicKey {
@@ -39,6 +46,66 @@ func (p *OrcaPool) GetTokens() (string, string) {
 	return p.TokenMintA.String(), p.TokenMintB.String()
 }
 
+// GetFeeRate returns the trade fee in basis points
+func (p *OrcaPool) GetFeeRate() float64 {
+	if p.FeeDenominator == 0 {
+		return 0
+	}
+	return float64(p.FeeNumerator) / float64(p.FeeDenominator) * 10000
+}
+
+// GetReserves returns the vault balances last fetched by Quote or a vault update
+func (p *OrcaPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(p.reserveA, p.reserveB, p.lastSlot)
+}
+
+// SetLastSlot records the slot of an account update applied to the pool
+func (p *OrcaPool) SetLastSlot(slot uint64) {
+	if slot > p.lastSlot {
+		p.lastSlot = slot
+	}
+}
+
+// GetBaseVault returns the token A vault address
+func (p *OrcaPool) GetBaseVault() string {
+	return p.TokenAccountA.String()
+}
+
+// GetQuoteVault returns the token B vault address
+func (p *OrcaPool) GetQuoteVault() string {
+	return p.TokenAccountB.String()
+}
+
+// UpdateFromAccountData implements the PoolStateUpdater interface
+func (p *OrcaPool) UpdateFromAccountData(accountID string, data []byte) error {
+	switch accountID {
+	case p.PoolId.String():
+		// Pool state only carries vault addresses and fees; reserves stay cached
+		if err := p.Decode(data); err != nil {
+			return fmt.Errorf("failed to decode pool data: %w", err)
+		}
+		return nil
+	case p.TokenAccountA.String(), p.TokenAccountB.String():
+		amount, err := sol.TokenAccountAmount(data)
+		if err != nil {
+			return fmt.Errorf("invalid vault data: %w", err)
+		}
+		balance := cosmath.NewIntFromUint64(amount)
+		if accountID == p.TokenAccountA.String() {
+			p.reserveA = balance
+		} else {
+			p.reserveB = balance
+		}
+		// Both reserves must be known before quoting from cache
+		if !p.reserveA.IsNil() && !p.reserveB.IsNil() {
+			p.lastCacheUpdate = time.Now()
+			p.cacheDataFresh = true
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown account ID for pool: %s", accountID)
+}
+
 func (p *OrcaPool) Decode(data []byte) error {
 	if len(data) < 256 {
 		return fmt.Errorf("data too short for Orca pool: got %d bytes", len(data))
@@ -69,29 +136,38 @@ func (p *OrcaPool) Decode(data []byte) error {
 	return nil
 }
 
-func (p *OrcaPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
-	// Fetch vault balances
-	accounts := []solana.PublicKey{p.TokenAccountA, p.TokenAccountB}
-	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
-	if err != nil {
-		return cosmath.ZeroInt(), fmt.Errorf("failed to fetch vault balances: %w", err)
-	}
-
-	var reserveA, reserveB cosmath.Int
-	for i, result := range results.Value {
-		if result == nil {
-			return cosmath.ZeroInt(), fmt.Errorf("vault account %s not found", accounts[i])
+func (p *OrcaPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
+	// Only fetch from RPC if cache is not fresh (older than 5 seconds or never updated)
+	cacheTooOld := time.Since(p.lastCacheUpdate) > 5*time.Second
+	if !p.cacheDataFresh || cacheTooOld {
+		// Fetch vault balances
+		accounts := []solana.PublicKey{p.TokenAccountA, p.TokenAccountB}
+		results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+		if err != nil {
+			return cosmath.ZeroInt(), fmt.Errorf("failed to fetch vault balances: %w", err)
 		}
 
-		amountBytes := result.Data.GetBinary()[64:72]
-		balance := binary.LittleEndian.Uint64(amountBytes)
-
-		if accounts[i].Equals(p.TokenAccountA) {
-			reserveA = cosmath.NewIntFromUint64(balance)
-		} else {
-			reserveB = cosmath.NewIntFromUint64(balance)
+		for i, result := range results.Value {
+			if result == nil {
+				return cosmath.ZeroInt(), fmt.Errorf("vault account %s not found", accounts[i])
+			}
+
+			balance, err := sol.TokenAccountAmount(result.Data.GetBinary())
+			if err != nil {
+				return cosmath.ZeroInt(), fmt.Errorf("invalid vault account %s: %w", accounts[i], err)
+			}
+
+			if accounts[i].Equals(p.TokenAccountA) {
+				p.reserveA = cosmath.NewIntFromUint64(balance)
+			} else {
+				p.reserveB = cosmath.NewIntFromUint64(balance)
+			}
 		}
+		p.lastCacheUpdate = time.Now()
+		p.lastSlot = results.Context.Slot
+		p.cacheDataFresh = true
 	}
+	reserveA, reserveB := p.reserveA, p.reserveB
 
 	// Determine swap direction
 	var reserveIn, reserveOut cosmath.Int
@@ -124,7 +200,7 @@ func (p *OrcaPool) Quote(ctx context.Context, solClient *sol.Client, inputMint s
 
 func (p *OrcaPool) BuildSwapInstructions(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	user solana.PublicKey,
 	inputMint string,
 	inputAmount cosmath.Int,
@@ -22,7 +22,7 @@ type PancakeSwapV3Pool struct {
 }
 
 func (p *PancakeSwapV3Pool) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("pancakeswapv3")
+	return pkg.ProtocolNamePancakeSwapV3
 }
 
 func (p *PancakeSwapV3Pool) GetProgramID() solana.PublicKey {
@@ -37,15 +37,25 @@ func (p *PancakeSwapV3Pool) GetTokens() (string, string) {
 	return p.TokenMintA.String(), p.TokenMintB.String()
 }
 
+// GetFeeRate returns 0 until PancakeSwap V3 pool decoding is implemented
+func (p *PancakeSwapV3Pool) GetFeeRate() float64 {
+	return 0
+}
+
+// GetReserves returns zero reserves until PancakeSwap V3 pool decoding is implemented
+func (p *PancakeSwapV3Pool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(cosmath.Int{}, cosmath.Int{}, 0)
+}
+
 func (p *PancakeSwapV3Pool) Decode(data []byte) error {
 	return fmt.Errorf("pancakeswapv3 decode not yet implemented")
 }
 
-func (p *PancakeSwapV3Pool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
+func (p *PancakeSwapV3Pool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
 	// TODO: Implement PancakeSwap V3 CLMM quote calculation (similar to Uniswap V3)
 	return cosmath.ZeroInt(), fmt.Errorf("pancakeswapv3 quote not yet implemented")
 }
 
-func (p *PancakeSwapV3Pool) BuildSwapInstructions(ctx context.Context, solClient *sol.Client, user solana.PublicKey, inputMint string, inputAmount cosmath.Int, minOutputAmount cosmath.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
+func (p *PancakeSwapV3Pool) BuildSwapInstructions(ctx context.Context, solClient sol.SolClient, user solana.PublicKey, inputMint string, inputAmount cosmath.Int, minOutputAmount cosmath.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
 	return nil, fmt.Errorf("pancakeswapv3 swap not yet implemented")
 }
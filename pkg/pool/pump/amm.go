@@ -19,6 +19,10 @@ const (
 	// PoolDataSize represents the expected size of pool data in bytes
 	PoolDataSize = 211
 
+	// CoinCreatorEnd is the end of the coin creator, the last field Decode
+	// reads, which pools created before creator fees lack
+	CoinCreatorEnd = PoolDataSize + 32
+
 	// DefaultSpan represents the default span value for the pool
 	DefaultSpan = 300
 
@@ -28,7 +32,8 @@ const (
 	// QuoteMintOffset represents the offset for QuoteMint in the pool data
 	QuoteMintOffset = BaseMintOffset + 32
 
-	// DefaultFeeRate represents the default fee rate for swaps (0.25%)
+	// DefaultFeeRate represents the default fee rate for swaps (0.25%), the
+	// total of DefaultFees for a pool without a coin creator
 	DefaultFeeRate = 0.00250
 )
 
@@ -49,10 +54,13 @@ type PumpAMMPool struct {
 	PoolId      solana.PublicKey
 	BaseAmount  math.Int
 	QuoteAmount math.Int
+	// Fees are the swap fee rates; zero uses DefaultFees
+	Fees Fees
 
 	// Cache tracking for WebSocket updates
 	lastCacheUpdate time.Time
 	cacheDataFresh  bool
+	lastSlot        uint64
 }
 
 func (pool *PumpAMMPool) ProtocolName() pkg.ProtocolName {
@@ -80,25 +88,15 @@ func (p *PumpAMMPool) Offset(value string) uint64 {
 	}
 }
 
-// Decode decodes the pool data from bytes
-func (p *PumpAMMPool) Decode(data []byte) error {
-	if len(data) < PoolDataSize {
-		return fmt.Errorf("data too short: expected %d bytes, got %d", PoolDataSize, len(data))
-	}
-	dec := bin.NewBinDecoder(data)
-	return dec.Decode(p)
-}
-
-// ParsePoolData parses the raw pool data into a PumpAMMPool struct
-func ParsePoolData(data []byte) (*PumpAMMPool, error) {
-	if len(data) < PoolDataSize {
-		return nil, fmt.Errorf("data too short: expected %d bytes, got %d", PoolDataSize, len(data))
+// Decode decodes the on-chain pool state, leaving PoolId and the cached
+// vault amounts alone. Data that is not a Pool account returns an
+// *anchor.AccountMismatchError.
+func (layout *PumpAMMPool) Decode(data []byte) error {
+	if err := anchor.VerifyAccount("Pool", data, PoolDataSize); err != nil {
+		return err
 	}
 
-	layout := &PumpAMMPool{}
-	// Parse structure
-	discriminator := [8]byte{}
-	copy(discriminator[:], data[:8])
+	copy(layout.Discriminator[:], data[:8])
 	layout.PoolBump = uint8(data[8])
 	layout.Index = binary.LittleEndian.Uint16(data[9:11])
 
@@ -117,12 +115,21 @@ func ParsePoolData(data []byte) (*PumpAMMPool, error) {
 	offset += 32
 	layout.LpSupply = binary.LittleEndian.Uint64(data[offset : offset+8])
 	offset += 8
-	if len(data[offset:]) > 32 {
+	if len(data) >= CoinCreatorEnd {
 		layout.CoinCreator = solana.PublicKeyFromBytes(data[offset : offset+32])
 	} else {
 		layout.CoinCreator = solana.MustPublicKeyFromBase58("11111111111111111111111111111111")
 	}
 
+	return nil
+}
+
+// ParsePoolData parses the raw pool data into a PumpAMMPool struct
+func ParsePoolData(data []byte) (*PumpAMMPool, error) {
+	layout := &PumpAMMPool{}
+	if err := layout.Decode(data); err != nil {
+		return nil, err
+	}
 	return layout, nil
 }
 
@@ -134,6 +141,41 @@ func (l *PumpAMMPool) GetTokens() (string, string) {
 	return l.BaseMint.String(), l.QuoteMint.String()
 }
 
+// GetFeeRate returns the swap fee in basis points
+func (l *PumpAMMPool) GetFeeRate() float64 {
+	return float64(l.fees().TotalBps())
+}
+
+// fees returns the pool's fee rates, or DefaultFees when unset. The coin
+// creator fee only applies when the pool has a coin creator.
+func (l *PumpAMMPool) fees() Fees {
+	fees := l.Fees
+	if fees == (Fees{}) {
+		fees = DefaultFees
+	}
+	if !l.HasCoinCreator() {
+		fees.CoinCreatorFeeBps = 0
+	}
+	return fees
+}
+
+// HasCoinCreator reports whether the pool pays a coin creator fee
+func (l *PumpAMMPool) HasCoinCreator() bool {
+	return !l.CoinCreator.IsZero()
+}
+
+// GetReserves returns the vault balances last fetched by Quote or a vault update
+func (l *PumpAMMPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(l.BaseAmount, l.QuoteAmount, l.lastSlot)
+}
+
+// SetLastSlot records the slot of an account update applied to the pool
+func (l *PumpAMMPool) SetLastSlot(slot uint64) {
+	if slot > l.lastSlot {
+		l.lastSlot = slot
+	}
+}
+
 // GetBaseVault returns the base vault address
 func (p *PumpAMMPool) GetBaseVault() string {
 	return p.PoolBaseTokenAccount.String()
@@ -148,12 +190,10 @@ func (p *PumpAMMPool) GetQuoteVault() string {
 func (p *PumpAMMPool) UpdateFromAccountData(accountID string, data []byte) error {
 	// Check if this is a vault update (token account)
 	if accountID == p.PoolBaseTokenAccount.String() || accountID == p.PoolQuoteTokenAccount.String() {
-		if len(data) < 72 {
-			return fmt.Errorf("insufficient data for token account: %d bytes", len(data))
+		amountUint, err := sol.TokenAccountAmount(data)
+		if err != nil {
+			return fmt.Errorf("invalid token account: %w", err)
 		}
-
-		amountBytes := data[64:72]
-		amountUint := binary.LittleEndian.Uint64(amountBytes)
 		amount := math.NewIntFromUint64(amountUint)
 
 		if accountID == p.PoolBaseTokenAccount.String() {
@@ -177,7 +217,7 @@ func (p *PumpAMMPool) UpdateFromAccountData(accountID string, data []byte) error
 
 func (s *PumpAMMPool) BuildSwapInstructions(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	user solana.PublicKey,
 	inputMint string,
 	inputAmount math.Int,
@@ -185,13 +225,39 @@ func (s *PumpAMMPool) BuildSwapInstructions(
 	userBaseAccount solana.PublicKey,
 	userQuoteAccount solana.PublicKey,
 ) ([]solana.Instruction, error) {
+	feeRecipient, feeRecipientAccount, err := s.protocolFeeAccounts(ctx, solClient)
+	if err != nil {
+		return nil, err
+	}
 	if inputMint == s.BaseMint.String() {
-		return s.buyInAMMPool(user, s, inputAmount, minOut, userBaseAccount, userQuoteAccount)
+		return s.buyInAMMPool(user, s, inputAmount, minOut, userBaseAccount, userQuoteAccount, feeRecipient, feeRecipientAccount)
 	} else {
-		return s.sellInAMMPool(user, s, inputAmount, minOut, userBaseAccount, userQuoteAccount)
+		return s.sellInAMMPool(user, s, inputAmount, minOut, userBaseAccount, userQuoteAccount, feeRecipient, feeRecipientAccount)
 	}
 }
 
+// protocolFeeAccounts returns the protocol fee recipient from the GlobalConfig
+// and its quote token account, or the built-in recipient when the config
+// cannot be read
+func (s *PumpAMMPool) protocolFeeAccounts(ctx context.Context, solClient sol.SolClient) (solana.PublicKey, solana.PublicKey, error) {
+	config, err := GetGlobalConfig(ctx, solClient)
+	if err != nil {
+		return PumpProtocolFeeRecipient, PumpProtocolFeeRecipientTokenAccount, nil
+	}
+	recipient := config.ProtocolFeeRecipient()
+	if recipient.IsZero() {
+		return solana.PublicKey{}, solana.PublicKey{}, fmt.Errorf("PumpSwap global config has no protocol fee recipient")
+	}
+	if recipient.Equals(PumpProtocolFeeRecipient) {
+		return PumpProtocolFeeRecipient, PumpProtocolFeeRecipientTokenAccount, nil
+	}
+	account, _, err := solana.FindAssociatedTokenAddress(recipient, s.QuoteMint)
+	if err != nil {
+		return solana.PublicKey{}, solana.PublicKey{}, fmt.Errorf("failed to derive protocol fee token account: %w", err)
+	}
+	return recipient, account, nil
+}
+
 func (s *PumpAMMPool) buyInAMMPool(
 	userAddr solana.PublicKey,
 	pool *PumpAMMPool,
@@ -199,6 +265,8 @@ func (s *PumpAMMPool) buyInAMMPool(
 	outAmountWithDecimals math.Int,
 	userBaseAccount solana.PublicKey,
 	userQuoteAccount solana.PublicKey,
+	feeRecipient solana.PublicKey,
+	feeRecipientAccount solana.PublicKey,
 ) ([]solana.Instruction, error) {
 	// Initialize instruction array
 	instrs := []solana.Instruction{}
@@ -207,7 +275,7 @@ func (s *PumpAMMPool) buyInAMMPool(
 		BaseAmountOut:    outAmountWithDecimals.Uint64(),
 		MaxQuoteAmountIn: maxInputAmountWithDecimals.Uint64(),
 	}
-	if pool.CoinCreator == solana.MustPublicKeyFromBase58("11111111111111111111111111111111") {
+	if !pool.HasCoinCreator() {
 		inst.AccountMetaSlice = make(solana.AccountMetaSlice, 17)
 	} else {
 		inst.AccountMetaSlice = make(solana.AccountMetaSlice, 19)
@@ -226,8 +294,8 @@ func (s *PumpAMMPool) buyInAMMPool(
 	inst.AccountMetaSlice[6] = solana.NewAccountMeta(userQuoteAccount, true, false)
 	inst.AccountMetaSlice[7] = solana.NewAccountMeta(pool.PoolBaseTokenAccount, true, false)
 	inst.AccountMetaSlice[8] = solana.NewAccountMeta(pool.PoolQuoteTokenAccount, true, false)
-	inst.AccountMetaSlice[9] = solana.NewAccountMeta(PumpProtocolFeeRecipient, false, false)
-	inst.AccountMetaSlice[10] = solana.NewAccountMeta(PumpProtocolFeeRecipientTokenAccount, true, false)
+	inst.AccountMetaSlice[9] = solana.NewAccountMeta(feeRecipient, false, false)
+	inst.AccountMetaSlice[10] = solana.NewAccountMeta(feeRecipientAccount, true, false)
 	tokenProgramID := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
 	inst.AccountMetaSlice[11] = solana.NewAccountMeta(tokenProgramID, false, false)
 	inst.AccountMetaSlice[12] = solana.NewAccountMeta(tokenProgramID, false, false)
@@ -235,7 +303,7 @@ func (s *PumpAMMPool) buyInAMMPool(
 	inst.AccountMetaSlice[14] = solana.NewAccountMeta(solana.MustPublicKeyFromBase58("ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL"), false, false)
 	inst.AccountMetaSlice[15] = solana.NewAccountMeta(solana.MustPublicKeyFromBase58("GS4CU59F31iL7aR2Q8zVS8DRrcRnXX1yjQ66TqNVQnaR"), false, false)
 	inst.AccountMetaSlice[16] = solana.NewAccountMeta(PumpSwapProgramID, false, false)
-	if pool.CoinCreator != solana.MustPublicKeyFromBase58("11111111111111111111111111111111") {
+	if pool.HasCoinCreator() {
 		ata, err := GetCoinCreatorVaultATA(pool.CoinCreator)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get coin creator vault ata: %w", err)
@@ -259,6 +327,8 @@ func (s *PumpAMMPool) sellInAMMPool(
 	minQuoteAmountOut math.Int,
 	userBaseAccount solana.PublicKey,
 	userQuoteAccount solana.PublicKey,
+	feeRecipient solana.PublicKey,
+	feeRecipientAccount solana.PublicKey,
 ) ([]solana.Instruction, error) {
 	instrs := []solana.Instruction{}
 
@@ -266,7 +336,7 @@ func (s *PumpAMMPool) sellInAMMPool(
 		BaseAmountIn:      baseAmountIn.Uint64(),
 		MinQuoteAmountOut: minQuoteAmountOut.Uint64(),
 	}
-	if pool.CoinCreator == solana.MustPublicKeyFromBase58("11111111111111111111111111111111") {
+	if !pool.HasCoinCreator() {
 		inst.AccountMetaSlice = make(solana.AccountMetaSlice, 17)
 	} else {
 		inst.AccountMetaSlice = make(solana.AccountMetaSlice, 19)
@@ -283,8 +353,8 @@ func (s *PumpAMMPool) sellInAMMPool(
 	inst.AccountMetaSlice[6] = solana.NewAccountMeta(userQuoteAccount, true, false)
 	inst.AccountMetaSlice[7] = solana.NewAccountMeta(pool.PoolBaseTokenAccount, true, false)
 	inst.AccountMetaSlice[8] = solana.NewAccountMeta(pool.PoolQuoteTokenAccount, true, false)
-	inst.AccountMetaSlice[9] = solana.NewAccountMeta(PumpProtocolFeeRecipient, false, false)
-	inst.AccountMetaSlice[10] = solana.NewAccountMeta(PumpProtocolFeeRecipientTokenAccount, true, false)
+	inst.AccountMetaSlice[9] = solana.NewAccountMeta(feeRecipient, false, false)
+	inst.AccountMetaSlice[10] = solana.NewAccountMeta(feeRecipientAccount, true, false)
 	tokenProgramID := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
 	inst.AccountMetaSlice[11] = solana.NewAccountMeta(tokenProgramID, false, false)
 	inst.AccountMetaSlice[12] = solana.NewAccountMeta(tokenProgramID, false, false)
@@ -292,7 +362,7 @@ func (s *PumpAMMPool) sellInAMMPool(
 	inst.AccountMetaSlice[14] = solana.NewAccountMeta(solana.MustPublicKeyFromBase58("ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL"), false, false)
 	inst.AccountMetaSlice[15] = solana.NewAccountMeta(solana.MustPublicKeyFromBase58("GS4CU59F31iL7aR2Q8zVS8DRrcRnXX1yjQ66TqNVQnaR"), false, false)
 	inst.AccountMetaSlice[16] = solana.NewAccountMeta(PumpSwapProgramID, false, false)
-	if pool.CoinCreator != solana.MustPublicKeyFromBase58("11111111111111111111111111111111") {
+	if pool.HasCoinCreator() {
 		ata, err := GetCoinCreatorVaultATA(pool.CoinCreator)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get coin creator vault ata: %w", err)
@@ -388,7 +458,7 @@ func (inst *SellSwapInstruction) Data() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func (pool *PumpAMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+func (pool *PumpAMMPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, inputAmount math.Int) (math.Int, error) {
 	// Only fetch from RPC if cache is not fresh (older than 5 seconds or never updated)
 	cacheTooOld := time.Since(pool.lastCacheUpdate) > 5*time.Second
 	if !pool.cacheDataFresh || cacheTooOld {
@@ -404,43 +474,37 @@ func (pool *PumpAMMPool) Quote(ctx context.Context, solClient *sol.Client, input
 			if result == nil {
 				return math.NewInt(0), fmt.Errorf("result is nil, account: %v", accounts[i].String())
 			}
-			accountKey := accounts[i].String()
-			if pool.PoolBaseTokenAccount.String() == accountKey {
-				amountBytes := result.Data.GetBinary()[64:72]
-				amountUint := binary.LittleEndian.Uint64(amountBytes)
-				amount := math.NewIntFromUint64(amountUint)
+			amountUint, err := sol.TokenAccountAmount(result.Data.GetBinary())
+			if err != nil {
+				return math.NewInt(0), fmt.Errorf("invalid vault account %s: %w", accounts[i], err)
+			}
+			amount := math.NewIntFromUint64(amountUint)
+			if pool.PoolBaseTokenAccount.Equals(accounts[i]) {
 				pool.BaseAmount = amount
 			} else {
-				amountBytes := result.Data.GetBinary()[64:72]
-				amountUint := binary.LittleEndian.Uint64(amountBytes)
-				amount := math.NewIntFromUint64(amountUint)
 				pool.QuoteAmount = amount
 			}
 		}
 		pool.lastCacheUpdate = time.Now()
+		pool.lastSlot = results.Context.Slot
 		pool.cacheDataFresh = true
 	}
 	// else: use cached data from WebSocket updates
 
-	feeRate := 1 - DefaultFeeRate
-	feeMultiplier := math.NewInt(int64(feeRate * float64(BaseDecimalInt)))
+	// Fees follow the GlobalConfig; when it can't be read the last known
+	// rates stay in use
+	if config, err := GetGlobalConfig(ctx, solClient); err == nil {
+		pool.Fees = config.Fees()
+	}
 
-	// Calculate k = baseAmount * quoteAmount
-	k := pool.BaseAmount.Mul(pool.QuoteAmount)
+	if pool.BaseAmount.IsNil() || pool.QuoteAmount.IsNil() {
+		return math.ZeroInt(), fmt.Errorf("%w: pool %s has no reserves", pkg.ErrStalePoolData, pool.PoolId)
+	}
 
+	// Selling base pays fees out of the quote received; buying pays them on
+	// top of the quote swapped
 	if inputMint == pool.BaseMint.String() {
-		// Calculate newBase = baseAmount + amountWithFee
-		newBase := pool.BaseAmount.Add(inputAmount.Mul(feeMultiplier).Quo(BaseDecimal))
-		// Calculate newQuote = k / newBase
-		newQuote := k.Quo(newBase)
-		priceBaseToQuote := pool.QuoteAmount.Sub(newQuote)
-		return priceBaseToQuote, nil
-	} else {
-		// Calculate newQuote = quoteAmount + amountWithFee
-		newQuote := pool.QuoteAmount.Add(inputAmount.Mul(feeMultiplier).Quo(BaseDecimal))
-		// Calculate newBase = k / newQuote
-		newBase := k.Quo(newQuote)
-		priceQuoteToBase := pool.BaseAmount.Sub(newBase)
-		return priceQuoteToBase, nil
+		return SellQuote(inputAmount, pool.BaseAmount, pool.QuoteAmount, pool.fees()), nil
 	}
+	return BuyQuote(inputAmount, pool.BaseAmount, pool.QuoteAmount, pool.fees()), nil
 }
@@ -0,0 +1,76 @@
+package pump
+
+import (
+	"cosmossdk.io/math"
+)
+
+const (
+	// DefaultLpFeeBps is the GlobalConfig LP fee in basis points
+	DefaultLpFeeBps = 20
+
+	// DefaultProtocolFeeBps is the GlobalConfig protocol fee in basis points
+	DefaultProtocolFeeBps = 5
+
+	// DefaultCoinCreatorFeeBps is the GlobalConfig coin creator fee in basis
+	// points, charged only by pools with a coin creator
+	DefaultCoinCreatorFeeBps = 5
+)
+
+// Fees are the fee rates of a Pump AMM swap, in basis points of its quote
+// token amount
+type Fees struct {
+	LpFeeBps          uint64
+	ProtocolFeeBps    uint64
+	CoinCreatorFeeBps uint64
+}
+
+// DefaultFees are used until a pool is given the on-chain rates
+var DefaultFees = Fees{
+	LpFeeBps:          DefaultLpFeeBps,
+	ProtocolFeeBps:    DefaultProtocolFeeBps,
+	CoinCreatorFeeBps: DefaultCoinCreatorFeeBps,
+}
+
+// TotalBps returns the sum of the fee rates
+func (f Fees) TotalBps() uint64 {
+	return f.LpFeeBps + f.ProtocolFeeBps + f.CoinCreatorFeeBps
+}
+
+// feeAmount is the program's fee on amount, rounded up: ceil(amount * bps / 10000)
+func feeAmount(amount math.Int, bps uint64) math.Int {
+	fee := amount.Mul(math.NewIntFromUint64(bps))
+	return fee.Add(math.NewInt(9999)).QuoRaw(10000)
+}
+
+// SellQuote returns the quote tokens received for selling baseIn, matching
+// the program's integer math: the constant-product output rounded down, less
+// each fee rounded up
+func SellQuote(baseIn, baseReserve, quoteReserve math.Int, fees Fees) math.Int {
+	denominator := baseReserve.Add(baseIn)
+	if !baseIn.IsPositive() || !denominator.IsPositive() {
+		return math.ZeroInt()
+	}
+	quoteOut := quoteReserve.Mul(baseIn).Quo(denominator)
+	out := quoteOut.Sub(feeAmount(quoteOut, fees.LpFeeBps)).
+		Sub(feeAmount(quoteOut, fees.ProtocolFeeBps)).
+		Sub(feeAmount(quoteOut, fees.CoinCreatorFeeBps))
+	if out.IsNegative() {
+		return math.ZeroInt()
+	}
+	return out
+}
+
+// BuyQuote returns the base tokens bought with quoteIn, fees included. Like
+// the program's buy, the fees are charged on top of the quote amount swapped,
+// so quoteIn * 10000 / (10000 + fees) goes into the pool.
+func BuyQuote(quoteIn, baseReserve, quoteReserve math.Int, fees Fees) math.Int {
+	if !quoteIn.IsPositive() {
+		return math.ZeroInt()
+	}
+	effectiveQuote := quoteIn.MulRaw(10000).Quo(math.NewIntFromUint64(10000 + fees.TotalBps()))
+	denominator := quoteReserve.Add(effectiveQuote)
+	if !denominator.IsPositive() {
+		return math.ZeroInt()
+	}
+	return baseReserve.Mul(effectiveQuote).Quo(denominator)
+}
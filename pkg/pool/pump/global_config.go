@@ -0,0 +1,108 @@
+package pump
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/sol"
+)
+
+const (
+	// GlobalConfigMinSize is the size of a GlobalConfig account up to its
+	// protocol fee recipients, discriminator included
+	GlobalConfigMinSize = 8 + 32 + 8 + 8 + 1 + 8*32
+
+	// GlobalConfigTTL is how long a fetched GlobalConfig is used before it is
+	// read again
+	GlobalConfigTTL = time.Minute
+)
+
+// GlobalConfig is the PumpSwap account holding the swap fees and the accounts
+// protocol fees are paid to
+type GlobalConfig struct {
+	Admin                 solana.PublicKey
+	LpFeeBps              uint64
+	ProtocolFeeBps        uint64
+	DisableFlags          uint8
+	ProtocolFeeRecipients [8]solana.PublicKey
+	// CoinCreatorFeeBps is zero on configs created before creator fees
+	CoinCreatorFeeBps uint64
+}
+
+// DecodeGlobalConfig decodes GlobalConfig account data
+func DecodeGlobalConfig(data []byte) (*GlobalConfig, error) {
+	if err := anchor.VerifyAccount("GlobalConfig", data, GlobalConfigMinSize); err != nil {
+		return nil, err
+	}
+
+	config := &GlobalConfig{}
+	offset := 8
+	config.Admin = solana.PublicKeyFromBytes(data[offset : offset+32])
+	offset += 32
+	config.LpFeeBps = binary.LittleEndian.Uint64(data[offset : offset+8])
+	offset += 8
+	config.ProtocolFeeBps = binary.LittleEndian.Uint64(data[offset : offset+8])
+	offset += 8
+	config.DisableFlags = data[offset]
+	offset += 1
+	for i := range config.ProtocolFeeRecipients {
+		config.ProtocolFeeRecipients[i] = solana.PublicKeyFromBytes(data[offset : offset+32])
+		offset += 32
+	}
+	if len(data) >= offset+8 {
+		config.CoinCreatorFeeBps = binary.LittleEndian.Uint64(data[offset : offset+8])
+	}
+	return config, nil
+}
+
+// Fees returns the swap fee rates. Pools without a coin creator don't charge
+// CoinCreatorFeeBps.
+func (c *GlobalConfig) Fees() Fees {
+	return Fees{LpFeeBps: c.LpFeeBps, ProtocolFeeBps: c.ProtocolFeeBps, CoinCreatorFeeBps: c.CoinCreatorFeeBps}
+}
+
+// ProtocolFeeRecipient returns the first configured protocol fee recipient,
+// or the zero key when none is set
+func (c *GlobalConfig) ProtocolFeeRecipient() solana.PublicKey {
+	for _, recipient := range c.ProtocolFeeRecipients {
+		if !recipient.IsZero() {
+			return recipient
+		}
+	}
+	return solana.PublicKey{}
+}
+
+// globalConfigs caches the GlobalConfig account
+var globalConfigs = sol.NewAccountCache(GlobalConfigTTL)
+
+// GetGlobalConfig returns the PumpSwap GlobalConfig, read from the chain at
+// most once per GlobalConfigTTL. If a refresh fails the previous config is
+// returned.
+func GetGlobalConfig(ctx context.Context, solClient sol.SolClient) (*GlobalConfig, error) {
+	data, err := globalConfigs.Get(ctx, solClient, PumpGlobalConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PumpSwap global config: %w", err)
+	}
+	config, err := DecodeGlobalConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PumpSwap global config: %w", err)
+	}
+	return config, nil
+}
+
+// FetchGlobalConfig reads the GlobalConfig account without caching
+func FetchGlobalConfig(ctx context.Context, solClient sol.SolClient) (*GlobalConfig, error) {
+	account, err := solClient.GetAccountInfoWithOpts(ctx, PumpGlobalConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PumpSwap global config %s: %w", PumpGlobalConfig, err)
+	}
+	config, err := DecodeGlobalConfig(account.Value.Data.GetBinary())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PumpSwap global config: %w", err)
+	}
+	return config, nil
+}
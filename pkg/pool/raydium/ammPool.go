@@ -8,7 +8,6 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"log"
 	"reflect"
 	"time"
 	"unsafe"
@@ -19,6 +18,7 @@ import (
 	"github.com/gagliardetto/solana-go"
 	"lukechampine.com/uint128"
 	"soltrading/pkg"
+	"soltrading/pkg/logging"
 	"soltrading/pkg/sol"
 )
 
@@ -103,6 +103,7 @@ type AMMPool struct {
 	// Cache tracking for WebSocket updates
 	lastCacheUpdate time.Time
 	cacheDataFresh  bool
+	lastSlot        uint64
 }
 
 func (pool *AMMPool) ProtocolName() pkg.ProtocolName {
@@ -312,10 +313,10 @@ func (l *MarketStateLayoutV3) Offset(value string) uint64 {
 func (l *MarketStateLayoutV3) Print() {
 	poolInfo, err := json.MarshalIndent(l, "", "  ")
 	if err != nil {
-		log.Printf("Failed to marshal pool info: %v", err)
+		logging.Component(nil, "raydium").Error("failed to marshal pool info", "error", err)
 		return
 	}
-	log.Printf("Pool Information:\n%s", string(poolInfo))
+	logging.Component(nil, "raydium").Info("pool information", "market", string(poolInfo))
 }
 
 // GetID returns the pool ID
@@ -328,6 +329,24 @@ func (p *AMMPool) GetTokens() (baseMint, quoteMint string) {
 	return p.BaseMint.String(), p.QuoteMint.String()
 }
 
+// GetFeeRate returns the swap fee in basis points
+func (p *AMMPool) GetFeeRate() float64 {
+	return float64(LIQUIDITY_FEES_NUMERATOR.Int64()) / float64(LIQUIDITY_FEES_DENOMINATOR.Int64()) * 10000
+}
+
+// GetReserves returns the vault balances net of pending PnL, as last fetched by Quote or a
+// vault update
+func (p *AMMPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(p.BaseReserve, p.QuoteReserve, p.lastSlot)
+}
+
+// SetLastSlot records the slot of an account update applied to the pool
+func (p *AMMPool) SetLastSlot(slot uint64) {
+	if slot > p.lastSlot {
+		p.lastSlot = slot
+	}
+}
+
 // GetBaseVault returns the base vault address
 func (p *AMMPool) GetBaseVault() string {
 	return p.BaseVault.String()
@@ -338,14 +357,37 @@ func (p *AMMPool) GetQuoteVault() string {
 	return p.QuoteVault.String()
 }
 
+// Tradable reports whether the pool's status allows swaps at all. Disabled,
+// withdraw-only, liquidity-only and order-book-only pools reject them.
+func (p *AMMPool) Tradable() bool {
+	switch p.Status {
+	case AmmStatusInitialized, AmmStatusSwapOnly, AmmStatusWaitingTrade:
+		return true
+	}
+	return false
+}
+
+// SwapEnabled reports whether the program accepts swaps against the pool at
+// now: its status allows them and, for a waiting pool, it has opened
+func (p *AMMPool) SwapEnabled(now time.Time) bool {
+	if p.Status == AmmStatusWaitingTrade {
+		return now.Unix() >= int64(p.PoolOpenTime)
+	}
+	return p.Tradable()
+}
+
 // Quote calculates the expected output amount for a given input amount
 // It takes into account the current pool reserves and fees
 func (p *AMMPool) Quote(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	inputMint string,
 	inputAmount cosmath.Int,
 ) (cosmath.Int, error) {
+	if !p.SwapEnabled(time.Now()) {
+		return math.ZeroInt(), fmt.Errorf("pool %s does not accept swaps (status %d)", p.PoolId, p.Status)
+	}
+
 	// Only fetch from RPC if cache is not fresh (older than 5 seconds or never updated)
 	cacheTooOld := time.Since(p.lastCacheUpdate) > 5*time.Second
 	if !p.cacheDataFresh || cacheTooOld {
@@ -361,20 +403,19 @@ func (p *AMMPool) Quote(
 			if result == nil {
 				return math.NewInt(0), fmt.Errorf("result is nil, account: %v", accounts[i].String())
 			}
-			accountKey := accounts[i].String()
-			if p.BaseVault.String() == accountKey {
-				amountBytes := result.Data.GetBinary()[64:72]
-				amountUint := binary.LittleEndian.Uint64(amountBytes)
-				amount := math.NewIntFromUint64(amountUint)
+			amountUint, err := sol.TokenAccountAmount(result.Data.GetBinary())
+			if err != nil {
+				return math.NewInt(0), fmt.Errorf("invalid vault account %s: %w", accounts[i], err)
+			}
+			amount := math.NewIntFromUint64(amountUint)
+			if p.BaseVault.Equals(accounts[i]) {
 				p.BaseAmount = amount
 			} else {
-				amountBytes := result.Data.GetBinary()[64:72]
-				amountUint := binary.LittleEndian.Uint64(amountBytes)
-				amount := math.NewIntFromUint64(amountUint)
 				p.QuoteAmount = amount
 			}
 		}
 		p.lastCacheUpdate = time.Now()
+		p.lastSlot = results.Context.Slot
 		p.cacheDataFresh = true
 	}
 	// else: use cached data from WebSocket updates
@@ -419,7 +460,7 @@ func (p *AMMPool) Quote(
 // It handles both base-to-quote and quote-to-base swaps
 func (pool *AMMPool) BuildSwapInstructions(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	user solana.PublicKey,
 	inputMint string,
 	inputAmount cosmath.Int,
@@ -497,12 +538,10 @@ func (p *AMMPool) UpdateFromAccountData(accountID string, data []byte) error {
 
 	// Check if this is a vault account
 	if accountID == p.BaseVault.String() {
-		// Decode SPL token account data (amount is at offset 64, 8 bytes)
-		if len(data) < 72 {
-			return fmt.Errorf("invalid base vault data length: %d", len(data))
+		amountUint, err := sol.TokenAccountAmount(data)
+		if err != nil {
+			return fmt.Errorf("invalid base vault data: %w", err)
 		}
-		amountBytes := data[64:72]
-		amountUint := binary.LittleEndian.Uint64(amountBytes)
 		p.BaseAmount = math.NewIntFromUint64(amountUint)
 
 		// Recalculate base reserve
@@ -513,12 +552,10 @@ func (p *AMMPool) UpdateFromAccountData(accountID string, data []byte) error {
 	}
 
 	if accountID == p.QuoteVault.String() {
-		// Decode SPL token account data (amount is at offset 64, 8 bytes)
-		if len(data) < 72 {
-			return fmt.Errorf("invalid quote vault data length: %d", len(data))
+		amountUint, err := sol.TokenAccountAmount(data)
+		if err != nil {
+			return fmt.Errorf("invalid quote vault data: %w", err)
 		}
-		amountBytes := data[64:72]
-		amountUint := binary.LittleEndian.Uint64(amountBytes)
 		p.QuoteAmount = math.NewIntFromUint64(amountUint)
 
 		// Recalculate quote reserve
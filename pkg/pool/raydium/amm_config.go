@@ -0,0 +1,104 @@
+package raydium
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/sol"
+)
+
+const (
+	// CLMMAmmConfigSize is the size of a CLMM AmmConfig account, discriminator included
+	CLMMAmmConfigSize = 117
+
+	// CPMMAmmConfigSize is the size of a CPMM AmmConfig account, discriminator included
+	CPMMAmmConfigSize = 236
+
+	// AmmConfigTTL is how long a fetched AmmConfig is used before it is read again
+	AmmConfigTTL = 5 * time.Minute
+
+	// DefaultCPMMTradeFeeRate is the 0.25% fee tier, in FEE_RATE_DENOMINATOR
+	// units, used until a CPMM pool's AmmConfig is read
+	DefaultCPMMTradeFeeRate = 2500
+)
+
+// CLMMAmmConfig is the fee tier a CLMM pool references. Rates are in
+// FEE_RATE_DENOMINATOR units; the protocol and fund fees are shares of the
+// trade fee, so only TradeFeeRate changes a swap's output.
+type CLMMAmmConfig struct {
+	Bump            uint8
+	Index           uint16
+	Owner           solana.PublicKey
+	ProtocolFeeRate uint32
+	TradeFeeRate    uint32
+	TickSpacing     uint16
+	FundFeeRate     uint32
+	PaddingU32      uint32
+	FundOwner       solana.PublicKey
+	Padding         [3]uint64
+}
+
+// CPMMAmmConfig is the fee tier a CPMM pool references, with rates in
+// FEE_RATE_DENOMINATOR units
+type CPMMAmmConfig struct {
+	Bump              uint8
+	DisableCreatePool bool
+	Index             uint16
+	TradeFeeRate      uint64
+	ProtocolFeeRate   uint64
+	FundFeeRate       uint64
+	CreatePoolFee     uint64
+	ProtocolOwner     solana.PublicKey
+	FundOwner         solana.PublicKey
+	Padding           [16]uint64
+}
+
+// DecodeCLMMAmmConfig decodes a CLMM AmmConfig account
+func DecodeCLMMAmmConfig(data []byte) (*CLMMAmmConfig, error) {
+	if err := anchor.VerifyAccount("AmmConfig", data, CLMMAmmConfigSize); err != nil {
+		return nil, err
+	}
+	config := &CLMMAmmConfig{}
+	if err := bin.NewBinDecoder(data[8:]).Decode(config); err != nil {
+		return nil, fmt.Errorf("failed to decode amm config: %w", err)
+	}
+	return config, nil
+}
+
+// DecodeCPMMAmmConfig decodes a CPMM AmmConfig account
+func DecodeCPMMAmmConfig(data []byte) (*CPMMAmmConfig, error) {
+	if err := anchor.VerifyAccount("AmmConfig", data, CPMMAmmConfigSize); err != nil {
+		return nil, err
+	}
+	config := &CPMMAmmConfig{}
+	if err := bin.NewBinDecoder(data[8:]).Decode(config); err != nil {
+		return nil, fmt.Errorf("failed to decode amm config: %w", err)
+	}
+	return config, nil
+}
+
+// ammConfigs caches AmmConfig accounts. Pools share a few fee tiers, so each
+// is read once per AmmConfigTTL however many pools use it.
+var ammConfigs = sol.NewAccountCache(AmmConfigTTL)
+
+// GetCLMMAmmConfig returns the CLMM AmmConfig at address, cached for AmmConfigTTL
+func GetCLMMAmmConfig(ctx context.Context, solClient sol.SolClient, address solana.PublicKey) (*CLMMAmmConfig, error) {
+	data, err := ammConfigs.Get(ctx, solClient, address)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeCLMMAmmConfig(data)
+}
+
+// GetCPMMAmmConfig returns the CPMM AmmConfig at address, cached for AmmConfigTTL
+func GetCPMMAmmConfig(ctx context.Context, solClient sol.SolClient, address solana.PublicKey) (*CPMMAmmConfig, error) {
+	data, err := ammConfigs.Get(ctx, solClient, address)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeCPMMAmmConfig(data)
+}
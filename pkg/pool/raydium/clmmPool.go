@@ -6,7 +6,6 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"log"
 	"math"
 	"math/big"
 	"strconv"
@@ -17,6 +16,8 @@ import (
 	"github.com/gagliardetto/solana-go"
 	"lukechampine.com/uint128"
 	"soltrading/pkg"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/logging"
 	"soltrading/pkg/sol"
 )
 
@@ -77,6 +78,7 @@ type CLMMPool struct {
 	// Cache tracking for WebSocket updates
 	lastCacheUpdate time.Time
 	cacheDataFresh  bool
+	lastSlot        uint64
 }
 
 type RewardInfo struct {
@@ -101,11 +103,14 @@ func (pool *CLMMPool) GetProgramID() solana.PublicKey {
 	return RAYDIUM_CLMM_PROGRAM_ID
 }
 
+// Decode decodes the PoolState account. Data that is not a PoolState
+// account returns an *anchor.AccountMismatchError.
 func (l *CLMMPool) Decode(data []byte) error {
-	// Skip 8 bytes discriminator if present
-	if len(data) > 8 {
-		data = data[8:]
+	if err := anchor.VerifyAccount("PoolState", data, int(l.Span())); err != nil {
+		return err
 	}
+	copy(l.Discriminator[:], data[:8])
+	data = data[8:]
 
 	offset := 0
 
@@ -291,7 +296,7 @@ func (l *CLMMPool) CurrentPrice() float64 {
 
 func (p *CLMMPool) BuildSwapInstructions(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	userAddr solana.PublicKey,
 	inputMint string,
 	amountIn cosmath.Int,
@@ -305,11 +310,11 @@ func (p *CLMMPool) BuildSwapInstructions(
 	var inputValueMint solana.PublicKey
 	var outputValueMint solana.PublicKey
 	if inputMint == p.TokenMint0.String() {
-		log.Printf("inputMint: %v, p.TokenMint0: %v", inputMint, p.TokenMint0.String())
+		logging.Component(nil, "raydium").Debug("swap input is token 0", "input_mint", inputMint, "token_mint_0", p.TokenMint0.String())
 		inputValueMint = p.TokenMint0
 		outputValueMint = p.TokenMint1
 	} else {
-		log.Printf("inputMint: %v, p.TokenMint1: %v", inputMint, p.TokenMint1.String())
+		logging.Component(nil, "raydium").Debug("swap input is token 1", "input_mint", inputMint, "token_mint_1", p.TokenMint1.String())
 		inputValueMint = p.TokenMint1
 		outputValueMint = p.TokenMint0
 	}
@@ -351,7 +356,7 @@ func (p *CLMMPool) BuildSwapInstructions(
 	// Add bitmap extension as remaining account if it exists
 	exBitmapAddress, _, err := GetPdaExBitmapAccount(RAYDIUM_CLMM_PROGRAM_ID, p.PoolId)
 	if err != nil {
-		log.Printf("get pda address error: %v", err)
+		logging.Component(nil, "raydium").Error("failed to derive PDA address", "error", err)
 		return nil, fmt.Errorf("get pda address error: %v", err)
 	}
 	inst.AccountMetaSlice[13] = solana.NewAccountMeta(exBitmapAddress, true, false) // exTickArrayBitmap (is_writable = true, is_signer = false)
@@ -359,7 +364,7 @@ func (p *CLMMPool) BuildSwapInstructions(
 	// Add tick arrays as remaining accounts
 	remainingAccounts, err := p.GetRemainAccounts(ctx, solClient, inputValueMint.String())
 	if err != nil {
-		log.Printf("GetRemainAccounts error: %v", err)
+		logging.Component(nil, "raydium").Error("failed to get remaining accounts", "error", err)
 		return nil, err
 	}
 	inst.AccountMetaSlice[14] = solana.NewAccountMeta(remainingAccounts[0], true, false)
@@ -436,6 +441,24 @@ func (pool *CLMMPool) GetTokens() (baseMint, quoteMint string) {
 	return pool.TokenMint0.String(), pool.TokenMint1.String()
 }
 
+// GetFeeRate returns the AmmConfig trade fee in basis points
+func (pool *CLMMPool) GetFeeRate() float64 {
+	return float64(pool.FeeRate) / 100
+}
+
+// GetReserves returns the virtual reserves of the active liquidity at the
+// current price
+func (pool *CLMMPool) GetReserves() pkg.Reserves {
+	return pkg.VirtualReserves(pool.Liquidity.Big(), pool.SqrtPriceX64.Big(), pool.lastSlot)
+}
+
+// SetLastSlot records the slot of an account update applied to the pool
+func (pool *CLMMPool) SetLastSlot(slot uint64) {
+	if slot > pool.lastSlot {
+		pool.lastSlot = slot
+	}
+}
+
 // GetBaseVault returns the base vault address (TokenVault0)
 func (pool *CLMMPool) GetBaseVault() string {
 	return pool.TokenVault0.String()
@@ -446,6 +469,23 @@ func (pool *CLMMPool) GetQuoteVault() string {
 	return pool.TokenVault1.String()
 }
 
+// GetAuxiliaryAccounts returns the tick arrays around the current price and the
+// tick array bitmap extension, which quotes depend on besides the pool state
+func (pool *CLMMPool) GetAuxiliaryAccounts() []string {
+	var accounts []string
+	if !pool.ExBitmapAddress.IsZero() {
+		accounts = append(accounts, pool.ExBitmapAddress.String())
+	}
+	tickArrayAddresses, err := pool.GetTickArrayAddresses()
+	if err != nil {
+		return accounts
+	}
+	for _, address := range tickArrayAddresses {
+		accounts = append(accounts, address.String())
+	}
+	return accounts
+}
+
 // UpdateFromAccountData implements the PoolStateUpdater interface
 func (pool *CLMMPool) UpdateFromAccountData(accountID string, data []byte) error {
 	// Check if this is a vault update (token account) - CLMM doesn't need vault updates for quotes
@@ -463,14 +503,34 @@ func (pool *CLMMPool) UpdateFromAccountData(accountID string, data []byte) error
 		return pool.Decode(data)
 	}
 
-	// Check if this is a tick array or bitmap update
-	// For now, we'll mark cache as fresh but not decode as tick arrays need special handling
+	if accountID == pool.ExBitmapAddress.String() {
+		if len(data) < 8+32+2*EXTENSION_TICKARRAY_BITMAP_SIZE*64 {
+			return fmt.Errorf("tick array bitmap extension too short: %d bytes", len(data))
+		}
+		pool.ParseExBitmapInfo(data)
+		pool.lastCacheUpdate = time.Now()
+		pool.cacheDataFresh = true
+		return nil
+	}
+
+	// Otherwise this is one of the subscribed tick arrays
+	tickArray := &TickArray{}
+	if err := tickArray.Decode(data); err != nil {
+		return fmt.Errorf("failed to decode tick array %s: %w", accountID, err)
+	}
+	if !tickArray.PoolId.Equals(pool.PoolId) {
+		return fmt.Errorf("tick array %s belongs to pool %s", accountID, tickArray.PoolId)
+	}
+	if pool.TickArrayCache == nil {
+		pool.TickArrayCache = make(map[string]TickArray)
+	}
+	pool.TickArrayCache[strconv.FormatInt(int64(tickArray.StartTickIndex), 10)] = *tickArray
 	pool.lastCacheUpdate = time.Now()
 	pool.cacheDataFresh = true
 	return nil
 }
 
-func (pool *CLMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount cosmath.Int) (cosmath.Int, error) {
+func (pool *CLMMPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, inputAmount cosmath.Int) (cosmath.Int, error) {
 	// Only fetch from RPC if cache is not fresh (older than 5 seconds or never updated)
 	cacheTooOld := time.Since(pool.lastCacheUpdate) > 5*time.Second
 	if !pool.cacheDataFresh || cacheTooOld {
@@ -489,7 +549,7 @@ func (pool *CLMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMin
 		}
 		results, err = solClient.GetMultipleAccountsWithOpts(ctx, tickArrayAddresses)
 		if err != nil {
-			log.Printf("batch request failed: %v", err)
+			logging.Component(nil, "raydium").Warn("tick array batch request failed", "pool", pool.PoolId, "error", err)
 			return cosmath.Int{}, fmt.Errorf("batch request failed: %v", err)
 		}
 		for _, result := range results.Value {
@@ -508,6 +568,12 @@ func (pool *CLMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMin
 	}
 	// else: use cached tick array data from WebSocket updates
 
+	// The fee tier can change; when its AmmConfig can't be read the last
+	// known rate stays in use
+	if config, err := GetCLMMAmmConfig(ctx, solClient, pool.AmmConfig); err == nil {
+		pool.FeeRate = config.TradeFeeRate
+	}
+
 	if inputMint == pool.TokenMint0.String() {
 		priceBaseToQuote, err := pool.ComputeAmountOutFormat(pool.TokenMint0.String(), inputAmount)
 		if err != nil {
@@ -623,7 +689,7 @@ func (pool *CLMMPool) swapCompute(
 				return cosmath.Int{}, fmt.Errorf("failed to get next initialized tick array: %w", err)
 			}
 			if !isExist {
-				return cosmath.Int{}, errors.New("insufficient liquidity")
+				return cosmath.Int{}, pkg.ErrInsufficientLiquidity
 			}
 
 			tickAarrayStartIndex := nextInitTickArrayIndex
@@ -722,7 +788,7 @@ func (pool *CLMMPool) swapCompute(
 // GetRemainAccounts returns the remaining accounts needed for the swap
 func (pool *CLMMPool) GetRemainAccounts(
 	ctx context.Context,
-	client *sol.Client,
+	client sol.SolClient,
 	inputTokenMint string,
 ) ([]solana.PublicKey, error) {
 	// Determine swap direction
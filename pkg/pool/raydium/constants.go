@@ -37,6 +37,19 @@ var (
 	FEE_RATE_DENOMINATOR  = math.NewInt(int64(1000000))
 )
 
+// AMM v4 pool statuses, the AMMPool Status field
+const (
+	AmmStatusUninitialized uint64 = iota
+	AmmStatusInitialized
+	AmmStatusDisabled
+	AmmStatusWithdrawOnly
+	AmmStatusLiquidityOnly
+	AmmStatusOrderBookOnly
+	AmmStatusSwapOnly
+	// AmmStatusWaitingTrade pools accept swaps from PoolOpenTime
+	AmmStatusWaitingTrade
+)
+
 // Liquidity Constants
 var (
 	LIQUIDITY_FEES_NUMERATOR   = math.NewInt(25)
@@ -11,9 +11,14 @@ import (
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"soltrading/pkg"
+	"soltrading/pkg/anchor"
 	"soltrading/pkg/sol"
 )
 
+// CPMMPoolSize is the size of a PoolState account in bytes; Decode reads the
+// first Span of them
+const CPMMPoolSize = 637
+
 // CPMMPool represents the on-chain pool state
 type CPMMPool struct {
 	AmmConfig          solana.PublicKey // 32 bytes
@@ -49,10 +54,14 @@ type CPMMPool struct {
 	QuoteDecimal     uint64
 	BaseNeedTakePnl  uint64
 	QuoteNeedTakePnl uint64
+	// TradeFeeRate is the AmmConfig trade fee in FEE_RATE_DENOMINATOR units;
+	// zero uses DefaultCPMMTradeFeeRate
+	TradeFeeRate uint64 `bin:"skip"`
 
 	// Cache tracking for WebSocket updates
 	lastCacheUpdate time.Time
 	cacheDataFresh  bool
+	lastSlot        uint64
 }
 
 func (pool *CPMMPool) ProtocolName() pkg.ProtocolName {
@@ -63,12 +72,14 @@ func (pool *CPMMPool) GetProgramID() solana.PublicKey {
 	return RAYDIUM_CPMM_PROGRAM_ID
 }
 
+// Decode decodes the PoolState account. Data that is not a PoolState
+// account returns an *anchor.AccountMismatchError.
 func (p *CPMMPool) Decode(data []byte) error {
-	if len(data) > 8 {
-		data = data[8:]
+	if err := anchor.VerifyAccount("PoolState", data, int(p.Span())); err != nil {
+		return err
 	}
 
-	dec := bin.NewBinDecoder(data)
+	dec := bin.NewBinDecoder(data[8:])
 	return dec.Decode(p)
 }
 
@@ -95,9 +106,61 @@ func (pool *CPMMPool) GetTokens() (string, string) {
 	return pool.Token0Mint.String(), pool.Token1Mint.String()
 }
 
+// GetFeeRate returns the AmmConfig trade fee in basis points
+func (pool *CPMMPool) GetFeeRate() float64 {
+	return float64(pool.tradeFeeRate()) / 100
+}
+
+// tradeFeeRate returns the pool's trade fee rate, or the default tier when
+// its AmmConfig hasn't been read
+func (pool *CPMMPool) tradeFeeRate() uint64 {
+	if pool.TradeFeeRate == 0 {
+		return DefaultCPMMTradeFeeRate
+	}
+	return pool.TradeFeeRate
+}
+
+// tokenProgram returns the token program of one of the pool's mints: the
+// classic SPL Token program or Token-2022
+func (pool *CPMMPool) tokenProgram(mint solana.PublicKey) solana.PublicKey {
+	program := pool.Token0Program
+	if mint.Equals(pool.Token1Mint) {
+		program = pool.Token1Program
+	}
+	if program.IsZero() {
+		return solana.TokenProgramID
+	}
+	return program
+}
+
+// transferFee returns the Token-2022 transfer fee withheld when amount of
+// mint moves into or out of the pool
+func (pool *CPMMPool) transferFee(ctx context.Context, solClient sol.SolClient, mint solana.PublicKey, amount math.Int) (math.Int, error) {
+	if !pool.tokenProgram(mint).Equals(solana.Token2022ProgramID) || !amount.IsPositive() || !amount.IsUint64() {
+		return math.ZeroInt(), nil
+	}
+	fee, err := sol.TransferFeeAt(ctx, solClient, mint, amount.Uint64())
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("failed to get transfer fee of %s: %w", mint, err)
+	}
+	return math.NewIntFromUint64(fee), nil
+}
+
+// GetReserves returns the vault balances last fetched by Quote or a vault update
+func (pool *CPMMPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(pool.BaseAmount, pool.QuoteAmount, pool.lastSlot)
+}
+
+// SetLastSlot records the slot of an account update applied to the pool
+func (pool *CPMMPool) SetLastSlot(slot uint64) {
+	if slot > pool.lastSlot {
+		pool.lastSlot = slot
+	}
+}
+
 func (pool *CPMMPool) BuildSwapInstructions(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	userAddr solana.PublicKey,
 	inputMint string,
 	amountIn math.Int,
@@ -108,11 +171,11 @@ func (pool *CPMMPool) BuildSwapInstructions(
 
 	instrs := []solana.Instruction{}
 
-	var inputValueMint solana.PublicKey
+	var inputValueMint, outputValueMint solana.PublicKey
 	if inputMint == pool.Token0Mint.String() {
-		inputValueMint = pool.Token0Mint
+		inputValueMint, outputValueMint = pool.Token0Mint, pool.Token1Mint
 	} else {
-		inputValueMint = pool.Token1Mint
+		inputValueMint, outputValueMint = pool.Token1Mint, pool.Token0Mint
 	}
 
 	swapInst := CPMMSwapInstruction{
@@ -150,9 +213,9 @@ func (pool *CPMMPool) BuildSwapInstructions(
 		swapInst.AccountMetaSlice[11] = solana.NewAccountMeta(pool.Token0Mint, false, false) // output_token_mint
 
 	}
-	swapInst.AccountMetaSlice[8] = solana.NewAccountMeta(solana.TokenProgramID, false, false) // input_token_program
-	swapInst.AccountMetaSlice[9] = solana.NewAccountMeta(solana.TokenProgramID, false, false) // output_token_program
-	swapInst.AccountMetaSlice[12] = solana.NewAccountMeta(pool.ObservationKey, true, false)   // observation_state
+	swapInst.AccountMetaSlice[8] = solana.NewAccountMeta(pool.tokenProgram(inputValueMint), false, false)  // input_token_program
+	swapInst.AccountMetaSlice[9] = solana.NewAccountMeta(pool.tokenProgram(outputValueMint), false, false) // output_token_program
+	swapInst.AccountMetaSlice[12] = solana.NewAccountMeta(pool.ObservationKey, true, false)                // observation_state
 	instrs = append(instrs, &swapInst)
 
 	return instrs, nil
@@ -202,7 +265,7 @@ func getAuthorityPDA() (solana.PublicKey, uint8, error) {
 	return authority, bump, nil
 }
 
-func (pool *CPMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+func (pool *CPMMPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, inputAmount math.Int) (math.Int, error) {
 	// Only fetch from RPC if cache is not fresh (older than 5 seconds or never updated)
 	cacheTooOld := time.Since(pool.lastCacheUpdate) > 5*time.Second
 	if !pool.cacheDataFresh || cacheTooOld {
@@ -218,24 +281,29 @@ func (pool *CPMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMin
 			if result == nil {
 				return math.NewInt(0), fmt.Errorf("result is nil, account: %v", accounts[i].String())
 			}
-			accountKey := accounts[i].String()
-			if pool.Token0Vault.String() == accountKey {
-				amountBytes := result.Data.GetBinary()[64:72]
-				amountUint := binary.LittleEndian.Uint64(amountBytes)
-				amount := math.NewIntFromUint64(amountUint)
+			amountUint, err := sol.TokenAccountAmount(result.Data.GetBinary())
+			if err != nil {
+				return math.NewInt(0), fmt.Errorf("invalid vault account %s: %w", accounts[i], err)
+			}
+			amount := math.NewIntFromUint64(amountUint)
+			if pool.Token0Vault.Equals(accounts[i]) {
 				pool.BaseAmount = amount
 			} else {
-				amountBytes := result.Data.GetBinary()[64:72]
-				amountUint := binary.LittleEndian.Uint64(amountBytes)
-				amount := math.NewIntFromUint64(amountUint)
 				pool.QuoteAmount = amount
 			}
 		}
 		pool.lastCacheUpdate = time.Now()
+		pool.lastSlot = results.Context.Slot
 		pool.cacheDataFresh = true
 	}
 	// else: use cached data from WebSocket updates
 
+	// The fee tier can change; when its AmmConfig can't be read the last
+	// known rate stays in use
+	if config, err := GetCPMMAmmConfig(ctx, solClient, pool.AmmConfig); err == nil {
+		pool.TradeFeeRate = config.TradeFeeRate
+	}
+
 	pool.BaseReserve = pool.BaseAmount.Sub(math.NewInt(int64(pool.BaseNeedTakePnl)))
 	pool.QuoteReserve = pool.QuoteAmount.Sub(math.NewInt(int64(pool.QuoteNeedTakePnl)))
 
@@ -250,11 +318,21 @@ func (pool *CPMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMin
 	}
 
 	// If input is quote, reverse reserves and decimals
+	inputValueMint, outputValueMint := pool.Token0Mint, pool.Token1Mint
 	if input == "quote" {
 		reserves[0], reserves[1] = reserves[1], reserves[0]
 		mintDecimals[0], mintDecimals[1] = mintDecimals[1], mintDecimals[0]
+		inputValueMint, outputValueMint = outputValueMint, inputValueMint
 	}
 
+	// Token-2022 transfer fees are withheld from the amount the vault
+	// receives and from the amount the user receives
+	inputTransferFee, err := pool.transferFee(ctx, solClient, inputValueMint, inputAmount)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	inputAmount = inputAmount.Sub(inputTransferFee)
+
 	reserveIn := reserves[0]
 	reserveOut := reserves[1]
 
@@ -264,8 +342,10 @@ func (pool *CPMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMin
 
 	// If amountIn is not zero, calculate amountOut
 	if !inputAmount.IsZero() {
-		// Calculate fee
-		feeRaw = inputAmount.Mul(LIQUIDITY_FEES_NUMERATOR).Quo(LIQUIDITY_FEES_DENOMINATOR)
+		// Calculate the trade fee, rounded up like the program
+		feeRaw = inputAmount.Mul(math.NewIntFromUint64(pool.tradeFeeRate())).
+			Add(FEE_RATE_DENOMINATOR.SubRaw(1)).
+			Quo(FEE_RATE_DENOMINATOR)
 
 		// Calculate amountInWithFee
 		amountInWithFee := inputAmount.Sub(feeRaw)
@@ -274,7 +354,11 @@ func (pool *CPMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMin
 		denominator := reserveIn.Add(amountInWithFee)
 		amountOutRaw = reserveOut.Mul(amountInWithFee).Quo(denominator)
 	}
-	return amountOutRaw, nil
+	outputTransferFee, err := pool.transferFee(ctx, solClient, outputValueMint, amountOutRaw)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	return amountOutRaw.Sub(outputTransferFee), nil
 }
 
 // GetBaseVault returns the base vault address (Token0Vault)
@@ -291,13 +375,10 @@ func (p *CPMMPool) GetQuoteVault() string {
 func (p *CPMMPool) UpdateFromAccountData(accountID string, data []byte) error {
 	// Check if this is a vault update (token account)
 	if accountID == p.Token0Vault.String() || accountID == p.Token1Vault.String() {
-		// Token account data: extract balance from bytes 64-72
-		if len(data) < 72 {
-			return fmt.Errorf("insufficient data for token account: %d bytes", len(data))
+		amountUint, err := sol.TokenAccountAmount(data)
+		if err != nil {
+			return fmt.Errorf("invalid token account: %w", err)
 		}
-
-		amountBytes := data[64:72]
-		amountUint := binary.LittleEndian.Uint64(amountBytes)
 		amount := math.NewIntFromUint64(amountUint)
 
 		if accountID == p.Token0Vault.String() {
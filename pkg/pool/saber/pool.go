@@ -39,7 +39,7 @@ type Fees struct {
 }
 
 func (p *SaberPool) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("saber")
+	return pkg.ProtocolNameSaber
 }
 
 func (p *SaberPool) GetProgramID() solana.PublicKey {
@@ -54,12 +54,25 @@ func (p *SaberPool) GetTokens() (string, string) {
 	return p.TokenMintA.String(), p.TokenMintB.String()
 }
 
+// GetFeeRate returns the trade fee in basis points
+func (p *SaberPool) GetFeeRate() float64 {
+	if p.Fees.TradeFeeDenominator == 0 {
+		return 0
+	}
+	return float64(p.Fees.TradeFeeNumerator) / float64(p.Fees.TradeFeeDenominator) * 10000
+}
+
+// GetReserves returns zero reserves until Saber pool decoding is implemented
+func (p *SaberPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(cosmath.Int{}, cosmath.Int{}, 0)
+}
+
 func (p *SaberPool) Decode(data []byte) error {
 	// TODO: Implement Saber StableSwap account decoding
 	return fmt.Errorf("saber decode not yet implemented")
 }
 
-func (p *SaberPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
+func (p *SaberPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
 	// TODO: Implement Curve StableSwap formula
 	// Uses: A * sum(x_i) * n^n + D = A * D * n^n + D^(n+1) / (n^n * prod(x_i))
 	return cosmath.ZeroInt(), fmt.Errorf("saber stableswap quote not yet implemented")
@@ -67,7 +80,7 @@ func (p *SaberPool) Quote(ctx context.Context, solClient *sol.Client, inputMint
 
 func (p *SaberPool) BuildSwapInstructions(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	user solana.PublicKey,
 	inputMint string,
 	inputAmount cosmath.Int,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"time"
 
 	cosmath "cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
@@ -38,10 +39,15 @@ type SplSwapPool struct {
 	// Pool reserves (fetched from token accounts)
 	ReserveA cosmath.Int
 	ReserveB cosmath.Int
+
+	// Cache management for WebSocket-driven updates
+	lastCacheUpdate time.Time
+	cacheDataFresh  bool
+	lastSlot        uint64
 }
 
 func (p *SplSwapPool) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("spl_token_swap")
+	return pkg.ProtocolNameSplTokenSwap
 }
 
 func (p *SplSwapPool) GetProgramID() solana.PublicKey {
@@ -56,6 +62,66 @@ func (p *SplSwapPool) GetTokens() (string, string) {
 	return p.MintA.String(), p.MintB.String()
 }
 
+// GetFeeRate returns the trade fee in basis points
+func (p *SplSwapPool) GetFeeRate() float64 {
+	if p.TradeFeeDenominator == 0 {
+		return 0
+	}
+	return float64(p.TradeFeeNumerator) / float64(p.TradeFeeDenominator) * 10000
+}
+
+// GetReserves returns the vault balances last fetched by Quote or a vault update
+func (p *SplSwapPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(p.ReserveA, p.ReserveB, p.lastSlot)
+}
+
+// SetLastSlot records the slot of an account update applied to the pool
+func (p *SplSwapPool) SetLastSlot(slot uint64) {
+	if slot > p.lastSlot {
+		p.lastSlot = slot
+	}
+}
+
+// GetBaseVault returns the token A vault address
+func (p *SplSwapPool) GetBaseVault() string {
+	return p.TokenAccountA.String()
+}
+
+// GetQuoteVault returns the token B vault address
+func (p *SplSwapPool) GetQuoteVault() string {
+	return p.TokenAccountB.String()
+}
+
+// UpdateFromAccountData implements the PoolStateUpdater interface
+func (p *SplSwapPool) UpdateFromAccountData(accountID string, data []byte) error {
+	switch accountID {
+	case p.PoolId.String():
+		// Pool state only carries vault addresses and fees; reserves stay cached
+		if err := p.Decode(data); err != nil {
+			return fmt.Errorf("failed to decode pool data: %w", err)
+		}
+		return nil
+	case p.TokenAccountA.String(), p.TokenAccountB.String():
+		amount, err := sol.TokenAccountAmount(data)
+		if err != nil {
+			return fmt.Errorf("invalid vault data: %w", err)
+		}
+		balance := cosmath.NewIntFromUint64(amount)
+		if accountID == p.TokenAccountA.String() {
+			p.ReserveA = balance
+		} else {
+			p.ReserveB = balance
+		}
+		// Both reserves must be known before quoting from cache
+		if !p.ReserveA.IsNil() && !p.ReserveB.IsNil() {
+			p.lastCacheUpdate = time.Now()
+			p.cacheDataFresh = true
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown account ID for pool: %s", accountID)
+}
+
 func (p *SplSwapPool) Decode(data []byte) error {
 	if len(data) < 324 {
 		return fmt.Errorf("data too short for SPL Token Swap pool: got %d bytes", len(data))
@@ -121,28 +187,36 @@ func (p *SplSwapPool) Decode(data []byte) error {
 	return nil
 }
 
-func (p *SplSwapPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
-	// Fetch vault balances
-	accounts := []solana.PublicKey{p.TokenAccountA, p.TokenAccountB}
-	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
-	if err != nil {
-		return cosmath.ZeroInt(), fmt.Errorf("failed to fetch vault balances: %w", err)
-	}
-
-	for i, result := range results.Value {
-		if result == nil {
-			return cosmath.ZeroInt(), fmt.Errorf("vault account %s not found", accounts[i])
+func (p *SplSwapPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
+	// Only fetch from RPC if cache is not fresh (older than 5 seconds or never updated)
+	cacheTooOld := time.Since(p.lastCacheUpdate) > 5*time.Second
+	if !p.cacheDataFresh || cacheTooOld {
+		// Fetch vault balances
+		accounts := []solana.PublicKey{p.TokenAccountA, p.TokenAccountB}
+		results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+		if err != nil {
+			return cosmath.ZeroInt(), fmt.Errorf("failed to fetch vault balances: %w", err)
 		}
 
-		// Extract balance from token account (offset 64, 8 bytes)
-		amountBytes := result.Data.GetBinary()[64:72]
-		balance := binary.LittleEndian.Uint64(amountBytes)
+		for i, result := range results.Value {
+			if result == nil {
+				return cosmath.ZeroInt(), fmt.Errorf("vault account %s not found", accounts[i])
+			}
 
-		if accounts[i].Equals(p.TokenAccountA) {
-			p.ReserveA = cosmath.NewIntFromUint64(balance)
-		} else {
-			p.ReserveB = cosmath.NewIntFromUint64(balance)
+			balance, err := sol.TokenAccountAmount(result.Data.GetBinary())
+			if err != nil {
+				return cosmath.ZeroInt(), fmt.Errorf("invalid vault account %s: %w", accounts[i], err)
+			}
+
+			if accounts[i].Equals(p.TokenAccountA) {
+				p.ReserveA = cosmath.NewIntFromUint64(balance)
+			} else {
+				p.ReserveB = cosmath.NewIntFromUint64(balance)
+			}
 		}
+		p.lastCacheUpdate = time.Now()
+		p.lastSlot = results.Context.Slot
+		p.cacheDataFresh = true
 	}
 
 	// Determine swap direction
@@ -176,7 +250,7 @@ func (p *SplSwapPool) Quote(ctx context.Context, solClient *sol.Client, inputMin
 
 func (p *SplSwapPool) BuildSwapInstructions(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	user solana.PublicKey,
 	inputMint string,
 	inputAmount cosmath.Int,
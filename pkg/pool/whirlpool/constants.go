@@ -12,6 +12,15 @@ var (
 	WhirlpoolProgramID = solana.MustPublicKeyFromBase58(WHIRLPOOL_PROGRAM_ID)
 )
 
+// Whirlpool account layout
+const (
+	// WhirlpoolSize is the size of a Whirlpool account in bytes
+	WhirlpoolSize = 653
+	// TokenMintAOffset and TokenMintBOffset locate the pool's two mints
+	TokenMintAOffset = 101
+	TokenMintBOffset = 181
+)
+
 // Whirlpool account discriminators
 const (
 	WHIRLPOOL_ACCOUNT_DISCRIMINATOR = "63M5OOj1XoGJ2nM" // First 8 bytes of account in base58
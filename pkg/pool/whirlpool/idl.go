@@ -0,0 +1,21 @@
+package whirlpool
+
+import (
+	_ "embed"
+
+	"soltrading/pkg/anchor"
+)
+
+// whirlpoolIDLJSON is the subset of the Whirlpool program IDL describing the
+// accounts this package decodes
+//
+//go:embed whirlpool_idl.json
+var whirlpoolIDLJSON []byte
+
+var whirlpoolIDL = func() *anchor.IDL {
+	idl, err := anchor.ParseIDL(whirlpoolIDLJSON)
+	if err != nil {
+		panic(err)
+	}
+	return idl
+}()
@@ -0,0 +1,62 @@
+package whirlpool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// WhirlpoolsConfigs are the configs pools are derived under, by default
+// Orca's mainnet-beta config. Deployments with their own configs replace it.
+var WhirlpoolsConfigs = []solana.PublicKey{
+	solana.MustPublicKeyFromBase58("2LecshUwdy9xi7meFgHtFJQNSKk4KdTrcpvaB56dP2NQ"),
+}
+
+// TickSpacings are the tick spacings of the fee tiers pools can be opened
+// with. 32896 is the splash pool tier.
+var TickSpacings = []uint16{1, 2, 4, 8, 16, 64, 96, 128, 256, 32896}
+
+// SortMints orders two mints the way the program requires, token A first
+func SortMints(x, y solana.PublicKey) (solana.PublicKey, solana.PublicKey) {
+	if bytes.Compare(x[:], y[:]) > 0 {
+		return y, x
+	}
+	return x, y
+}
+
+// DeriveWhirlpoolAddress derives the Whirlpool PDA from its config, sorted
+// mints and tick spacing
+func DeriveWhirlpoolAddress(config, mintA, mintB solana.PublicKey, tickSpacing uint16) (solana.PublicKey, error) {
+	spacing := make([]byte, 2)
+	binary.LittleEndian.PutUint16(spacing, tickSpacing)
+	pda, _, err := solana.FindProgramAddress([][]byte{
+		[]byte("whirlpool"),
+		config.Bytes(),
+		mintA.Bytes(),
+		mintB.Bytes(),
+		spacing,
+	}, WhirlpoolProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive whirlpool address: %w", err)
+	}
+	return pda, nil
+}
+
+// PairAddresses derives the address of every pool a pair could have, one per
+// config and tick spacing. Most of them don't exist.
+func PairAddresses(mintX, mintY solana.PublicKey) ([]solana.PublicKey, error) {
+	mintA, mintB := SortMints(mintX, mintY)
+	addresses := make([]solana.PublicKey, 0, len(WhirlpoolsConfigs)*len(TickSpacings))
+	for _, config := range WhirlpoolsConfigs {
+		for _, tickSpacing := range TickSpacings {
+			address, err := DeriveWhirlpoolAddress(config, mintA, mintB, tickSpacing)
+			if err != nil {
+				return nil, err
+			}
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses, nil
+}
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	cosmath "cosmossdk.io/math"
-	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"lukechampine.com/uint128"
 	"soltrading/pkg"
@@ -53,6 +52,7 @@ type WhirlpoolPool struct {
 	// Cache tracking for WebSocket updates
 	lastCacheUpdate time.Time
 	cacheDataFresh  bool
+	lastSlot        uint64
 }
 
 type RewardInfo struct {
@@ -79,7 +79,7 @@ type Tick struct {
 }
 
 func (pool *WhirlpoolPool) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("whirlpool")
+	return pkg.ProtocolNameWhirlpool
 }
 
 func (pool *WhirlpoolPool) GetProgramID() solana.PublicKey {
@@ -94,6 +94,25 @@ func (pool *WhirlpoolPool) GetTokens() (string, string) {
 	return pool.TokenMintA.String(), pool.TokenMintB.String()
 }
 
+// GetFeeRate returns the swap fee in basis points. FeeRate is in hundredths
+// of a basis point.
+func (pool *WhirlpoolPool) GetFeeRate() float64 {
+	return float64(pool.FeeRate) / 100
+}
+
+// GetReserves returns the virtual reserves of the active liquidity at the
+// current price
+func (pool *WhirlpoolPool) GetReserves() pkg.Reserves {
+	return pkg.VirtualReserves(pool.Liquidity.Big(), pool.SqrtPrice.Big(), pool.lastSlot)
+}
+
+// SetLastSlot records the slot of an account update applied to the pool
+func (pool *WhirlpoolPool) SetLastSlot(slot uint64) {
+	if slot > pool.lastSlot {
+		pool.lastSlot = slot
+	}
+}
+
 // GetBaseVault returns the base vault address (TokenVaultA)
 func (pool *WhirlpoolPool) GetBaseVault() string {
 	return pool.TokenVaultA.String()
@@ -125,93 +144,23 @@ func (pool *WhirlpoolPool) UpdateFromAccountData(accountID string, data []byte)
 	return nil
 }
 
+// Decode reads the Whirlpool account layout described by the embedded IDL,
+// from the official Orca Whirlpool structure:
+// https://github.com/orca-so/whirlpools/blob/main/programs/whirlpool/src/state/whirlpool.rs
+// Data that is not a 653-byte Whirlpool account returns an
+// *anchor.AccountMismatchError.
 func (pool *WhirlpoolPool) Decode(data []byte) error {
-	if len(data) < 653 {
-		return fmt.Errorf("insufficient data: expected 653 bytes, got %d", len(data))
+	if err := whirlpoolIDL.DecodeAccountInto("Whirlpool", data, pool); err != nil {
+		return err
 	}
-
-	// Based on official Orca Whirlpool structure from:
-	// https://github.com/orca-so/whirlpools/blob/main/programs/whirlpool/src/state/whirlpool.rs
-
-	// Read discriminator (8 bytes at offset 0)
 	copy(pool.Discriminator[:], data[0:8])
 
-	// Read WhirlpoolsConfig (32 bytes at offset 8)
-	pool.WhirlpoolsConfig = solana.PublicKeyFromBytes(data[8:40])
-
-	// Read WhirlpoolBump (1 byte at offset 40)
-	pool.WhirlpoolBump[0] = data[40]
-
-	// Read TickSpacing (2 bytes at offset 41)
-	decoder := bin.NewBinDecoder(data[41:43])
-	decoder.Decode(&pool.TickSpacing)
-
-	// Read TickSpacingSeed (2 bytes at offset 43)
-	decoder = bin.NewBinDecoder(data[43:45])
-	decoder.Decode(&pool.TickSpacingSeed)
-
-	// Read FeeRate (2 bytes at offset 45)
-	decoder = bin.NewBinDecoder(data[45:47])
-	decoder.Decode(&pool.FeeRate)
-
-	// Read ProtocolFeeRate (2 bytes at offset 47)
-	decoder = bin.NewBinDecoder(data[47:49])
-	decoder.Decode(&pool.ProtocolFeeRate)
-
-	// Read Liquidity (16 bytes at offset 49)
-	decoder = bin.NewBinDecoder(data[49:65])
-	decoder.Decode(&pool.Liquidity)
-
-	// Read SqrtPrice (16 bytes at offset 65)
-	decoder = bin.NewBinDecoder(data[65:81])
-	decoder.Decode(&pool.SqrtPrice)
-
-	// Read TickCurrentIndex (4 bytes at offset 81)
-	decoder = bin.NewBinDecoder(data[81:85])
-	decoder.Decode(&pool.TickCurrentIndex)
-
-	// Read ProtocolFeeOwedA (8 bytes at offset 85)
-	decoder = bin.NewBinDecoder(data[85:93])
-	decoder.Decode(&pool.ProtocolFeeOwedA)
-
-	// Read ProtocolFeeOwedB (8 bytes at offset 93)
-	decoder = bin.NewBinDecoder(data[93:101])
-	decoder.Decode(&pool.ProtocolFeeOwedB)
-
-	// Read TokenMintA (32 bytes at offset 101)
-	pool.TokenMintA = solana.PublicKeyFromBytes(data[101:133])
-
-	// Read TokenVaultA (32 bytes at offset 133)
-	pool.TokenVaultA = solana.PublicKeyFromBytes(data[133:165])
-
-	// Read FeeGrowthGlobalA (16 bytes at offset 165)
-	decoder = bin.NewBinDecoder(data[165:181])
-	decoder.Decode(&pool.FeeGrowthGlobalA)
-
-	// Read TokenMintB (32 bytes at offset 181)
-	pool.TokenMintB = solana.PublicKeyFromBytes(data[181:213])
-
-	// Read TokenVaultB (32 bytes at offset 213)
-	pool.TokenVaultB = solana.PublicKeyFromBytes(data[213:245])
-
-	// Read FeeGrowthGlobalB (16 bytes at offset 245)
-	decoder = bin.NewBinDecoder(data[245:261])
-	decoder.Decode(&pool.FeeGrowthGlobalB)
-
-	// Read RewardLastUpdatedTimestamp (8 bytes at offset 261)
-	decoder = bin.NewBinDecoder(data[261:269])
-	decoder.Decode(&pool.RewardLastUpdatedTimestamp)
-
-	// Read RewardInfos (384 bytes at offset 269)
-	decoder = bin.NewBinDecoder(data[269:653])
-	decoder.Decode(&pool.RewardInfos)
-
 	pool.TickArrayCache = make(map[string]*TickArray)
 
 	return nil
 }
 
-func (pool *WhirlpoolPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
+func (pool *WhirlpoolPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
 	// Simplified CLMM quote - uses current pool liquidity without tick array traversal
 	// Good approximation for swaps that don't cross many ticks
 
@@ -235,7 +184,7 @@ func (pool *WhirlpoolPool) Quote(ctx context.Context, solClient *sol.Client, inp
 	q64BigInt := new(big.Int).Lsh(big.NewInt(1), 64)
 
 	if liquidity.IsZero() {
-		return cosmath.ZeroInt(), fmt.Errorf("pool has zero liquidity")
+		return cosmath.ZeroInt(), fmt.Errorf("%w: pool has zero liquidity", pkg.ErrInsufficientLiquidity)
 	}
 
 	if zeroForOne {
@@ -283,7 +232,7 @@ func (pool *WhirlpoolPool) Quote(ctx context.Context, solClient *sol.Client, inp
 
 func (pool *WhirlpoolPool) BuildSwapInstructions(
 	ctx context.Context,
-	solClient *sol.Client,
+	solClient sol.SolClient,
 	user solana.PublicKey,
 	inputMint string,
 	inputAmount cosmath.Int,
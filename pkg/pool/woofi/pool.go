@@ -17,7 +17,7 @@ type WooFiPool struct {
 }
 
 func (p *WooFiPool) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("woofi")
+	return pkg.ProtocolNameWooFi
 }
 
 func (p *WooFiPool) GetProgramID() solana.PublicKey {
@@ -32,14 +32,24 @@ func (p *WooFiPool) GetTokens() (string, string) {
 	return p.TokenMintA.String(), p.TokenMintB.String()
 }
 
+// GetFeeRate returns 0 until WooFi pool decoding is implemented
+func (p *WooFiPool) GetFeeRate() float64 {
+	return 0
+}
+
+// GetReserves returns zero reserves until WooFi pool decoding is implemented
+func (p *WooFiPool) GetReserves() pkg.Reserves {
+	return pkg.NewReserves(cosmath.Int{}, cosmath.Int{}, 0)
+}
+
 func (p *WooFiPool) Decode(data []byte) error {
 	return fmt.Errorf("woofi decode not yet implemented")
 }
 
-func (p *WooFiPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
+func (p *WooFiPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, amount cosmath.Int) (cosmath.Int, error) {
 	return cosmath.ZeroInt(), fmt.Errorf("woofi quote not yet implemented")
 }
 
-func (p *WooFiPool) BuildSwapInstructions(ctx context.Context, solClient *sol.Client, user solana.PublicKey, inputMint string, inputAmount cosmath.Int, minOutputAmount cosmath.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
+func (p *WooFiPool) BuildSwapInstructions(ctx context.Context, solClient sol.SolClient, user solana.PublicKey, inputMint string, inputAmount cosmath.Int, minOutputAmount cosmath.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
 	return nil, fmt.Errorf("woofi swap not yet implemented")
 }
@@ -0,0 +1,72 @@
+package poolapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"soltrading/pkg"
+)
+
+// DefaultMeteoraURL is Meteora's public DLMM API
+const DefaultMeteoraURL = "https://dlmm-api.meteora.ag"
+
+// Meteora lists DLMM pairs from the Meteora API
+type Meteora struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewMeteora creates a backend for baseURL, or DefaultMeteoraURL when empty
+func NewMeteora(baseURL string) *Meteora {
+	if baseURL == "" {
+		baseURL = DefaultMeteoraURL
+	}
+	return &Meteora{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+func (m *Meteora) Name() string {
+	return "meteora"
+}
+
+func (m *Meteora) Supports(protocol pkg.ProtocolName) bool {
+	return protocol == pkg.ProtocolNameMeteoraDlmm
+}
+
+type meteoraGroupsResponse struct {
+	Groups []struct {
+		Pairs []struct {
+			Address string `json:"address"`
+			MintX   string `json:"mint_x"`
+			MintY   string `json:"mint_y"`
+		} `json:"pairs"`
+	} `json:"groups"`
+}
+
+// PoolAddresses returns the DLMM pairs of the two mints
+func (m *Meteora) PoolAddresses(ctx context.Context, protocol pkg.ProtocolName, mintA, mintB string) ([]string, error) {
+	if !m.Supports(protocol) {
+		return nil, errUnsupported
+	}
+
+	params := url.Values{}
+	params.Set("include_pool_token_pairs", mintA+"-"+mintB+","+mintB+"-"+mintA)
+	params.Set("limit", "100")
+
+	var resp meteoraGroupsResponse
+	if err := getJSON(ctx, m.httpClient, m.baseURL+"/pair/all_by_groups?"+params.Encode(), &resp); err != nil {
+		return nil, fmt.Errorf("meteora API: %w", err)
+	}
+
+	var addresses []string
+	for _, group := range resp.Groups {
+		for _, p := range group.Pairs {
+			if samePair(p.MintX, p.MintY, mintA, mintB) {
+				addresses = append(addresses, p.Address)
+			}
+		}
+	}
+	return addresses, nil
+}
@@ -0,0 +1,68 @@
+package poolapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"soltrading/pkg"
+)
+
+// DefaultOrcaURL is Orca's public v2 API
+const DefaultOrcaURL = "https://api.orca.so/v2/solana"
+
+// Orca lists Whirlpools from the Orca API
+type Orca struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOrca creates a backend for baseURL, or DefaultOrcaURL when empty
+func NewOrca(baseURL string) *Orca {
+	if baseURL == "" {
+		baseURL = DefaultOrcaURL
+	}
+	return &Orca{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+func (o *Orca) Name() string {
+	return "orca"
+}
+
+func (o *Orca) Supports(protocol pkg.ProtocolName) bool {
+	return protocol == pkg.ProtocolNameWhirlpool
+}
+
+type orcaPoolsResponse struct {
+	Data []struct {
+		Address    string `json:"address"`
+		TokenMintA string `json:"tokenMintA"`
+		TokenMintB string `json:"tokenMintB"`
+	} `json:"data"`
+}
+
+// PoolAddresses returns the Whirlpools holding both mints
+func (o *Orca) PoolAddresses(ctx context.Context, protocol pkg.ProtocolName, mintA, mintB string) ([]string, error) {
+	if !o.Supports(protocol) {
+		return nil, errUnsupported
+	}
+
+	params := url.Values{}
+	params.Set("tokensBothOf", mintA+","+mintB)
+	params.Set("size", "100")
+
+	var resp orcaPoolsResponse
+	if err := getJSON(ctx, o.httpClient, o.baseURL+"/pools?"+params.Encode(), &resp); err != nil {
+		return nil, fmt.Errorf("orca API: %w", err)
+	}
+
+	var addresses []string
+	for _, p := range resp.Data {
+		if samePair(p.TokenMintA, p.TokenMintB, mintA, mintB) {
+			addresses = append(addresses, p.Address)
+		}
+	}
+	return addresses, nil
+}
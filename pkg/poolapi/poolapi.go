@@ -0,0 +1,175 @@
+// Package poolapi finds a pair's pools through the DEXes' own HTTP APIs, a
+// fallback for RPC endpoints that block or rate-limit getProgramAccounts.
+// The APIs only supply addresses; pool state is always read on-chain.
+package poolapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"soltrading/pkg"
+	"soltrading/pkg/logging"
+)
+
+// Backend lists pool addresses from an off-chain API
+type Backend interface {
+	Name() string
+	// Supports reports whether the API lists the protocol's pools
+	Supports(protocol pkg.ProtocolName) bool
+	// PoolAddresses returns the addresses of the protocol's pools trading
+	// the two mints, in either order
+	PoolAddresses(ctx context.Context, protocol pkg.ProtocolName, mintA, mintB string) ([]string, error)
+}
+
+// DefaultBackends returns the Raydium, Orca and Meteora backends on their
+// public endpoints
+func DefaultBackends() []Backend {
+	return []Backend{NewRaydium(""), NewOrca(""), NewMeteora("")}
+}
+
+// Fallback is a Protocol that discovers pools through its backends when the
+// wrapped protocol's on-chain discovery fails. Pools found through an API are
+// loaded with the protocol's FetchPoolByID.
+type Fallback struct {
+	pkg.Protocol
+	backends []Backend
+}
+
+// NewFallback wraps protocol with the backends that support it
+func NewFallback(protocol pkg.Protocol, backends ...Backend) *Fallback {
+	f := &Fallback{Protocol: protocol}
+	for _, backend := range backends {
+		if backend.Supports(protocol.ProtocolName()) {
+			f.backends = append(f.backends, backend)
+		}
+	}
+	return f
+}
+
+// WithFallback wraps each protocol some backend supports and returns the
+// others unchanged
+func WithFallback(protocols []pkg.Protocol, backends ...Backend) []pkg.Protocol {
+	wrapped := make([]pkg.Protocol, len(protocols))
+	for i, protocol := range protocols {
+		if f := NewFallback(protocol, backends...); len(f.backends) > 0 {
+			wrapped[i] = f
+		} else {
+			wrapped[i] = protocol
+		}
+	}
+	return wrapped
+}
+
+// FetchPoolsByPair returns the wrapped protocol's pools, or when its lookup
+// fails, the pools the backends list. The original error is returned when
+// no backend finds a pool either.
+func (f *Fallback) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+	pools, err := f.Protocol.FetchPoolsByPair(ctx, baseMint, quoteMint)
+	if err == nil || len(f.backends) == 0 || ctx.Err() != nil {
+		return pools, err
+	}
+	return f.fromBackends(ctx, baseMint, quoteMint, err)
+}
+
+// FetchPoolsByPairs batches the lookup through the wrapped protocol and
+// falls back to the backends for the pairs it failed on
+func (f *Fallback) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	res, err := f.Protocol.FetchPoolsByPairs(ctx, pairs)
+	if err == nil || len(f.backends) == 0 || ctx.Err() != nil {
+		return res, err
+	}
+	if res == nil {
+		res = make(map[pkg.Pair][]pkg.Pool, len(pairs))
+	}
+
+	var errs []error
+	for _, pair := range pairs {
+		if _, ok := res[pair]; ok {
+			continue
+		}
+		pools, pairErr := f.fromBackends(ctx, pair.BaseMint, pair.QuoteMint, err)
+		if pairErr != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", pair.BaseMint, pair.QuoteMint, pairErr))
+			continue
+		}
+		res[pair] = pools
+	}
+	return res, errors.Join(errs...)
+}
+
+// fromBackends returns the pair's pools the backends list, or err when they
+// find none
+func (f *Fallback) fromBackends(ctx context.Context, baseMint, quoteMint string, err error) ([]pkg.Pool, error) {
+	name := f.ProtocolName()
+	seen := make(map[string]bool)
+	var pools []pkg.Pool
+	for _, backend := range f.backends {
+		addresses, apiErr := backend.PoolAddresses(ctx, name, baseMint, quoteMint)
+		if apiErr != nil {
+			logging.Component(nil, "poolapi").Warn("pool API lookup failed", "backend", backend.Name(), "protocol", name, "base", baseMint, "quote", quoteMint, "error", apiErr)
+			continue
+		}
+		for _, address := range addresses {
+			if seen[address] {
+				continue
+			}
+			seen[address] = true
+			pool, poolErr := f.FetchPoolByID(ctx, address)
+			if poolErr != nil {
+				logging.Component(nil, "poolapi").Warn("skipping pool from the API", "backend", backend.Name(), "protocol", name, "pool", address, "error", poolErr)
+				continue
+			}
+			pools = append(pools, pool)
+		}
+	}
+	if len(pools) == 0 {
+		return nil, err
+	}
+	logging.Component(nil, "poolapi").Info("found pools through the API fallback", "protocol", name, "base", baseMint, "quote", quoteMint, "pools", len(pools), "error", err)
+	return pools, nil
+}
+
+// httpClient is shared by the backends
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// getJSON fetches url and decodes its JSON body into out
+func getJSON(ctx context.Context, client *http.Client, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("%w: request failed with status %d", pkg.ErrRateLimited, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// errUnsupported is returned for protocols a backend doesn't list
+var errUnsupported = fmt.Errorf("%w by the pool API", pkg.ErrUnsupportedProtocol)
+
+// samePair reports whether x/y are the mints a/b in either order
+func samePair(x, y, a, b string) bool {
+	return (x == a && y == b) || (x == b && y == a)
+}
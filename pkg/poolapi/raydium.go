@@ -0,0 +1,104 @@
+package poolapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg"
+	"soltrading/pkg/pool/raydium"
+)
+
+// DefaultRaydiumURL is Raydium's public v3 API
+const DefaultRaydiumURL = "https://api-v3.raydium.io"
+
+// Raydium lists AMM v4, CLMM and CPMM pools from the Raydium API
+type Raydium struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewRaydium creates a backend for baseURL, or DefaultRaydiumURL when empty
+func NewRaydium(baseURL string) *Raydium {
+	if baseURL == "" {
+		baseURL = DefaultRaydiumURL
+	}
+	return &Raydium{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+func (r *Raydium) Name() string {
+	return "raydium"
+}
+
+// program returns the program owning the protocol's pools, read on use as
+// cluster profiles replace it
+func (r *Raydium) program(protocol pkg.ProtocolName) (solana.PublicKey, bool) {
+	switch protocol {
+	case pkg.ProtocolNameRaydiumAmm:
+		return raydium.RAYDIUM_AMM_PROGRAM_ID, true
+	case pkg.ProtocolNameRaydiumClmm:
+		return raydium.RAYDIUM_CLMM_PROGRAM_ID, true
+	case pkg.ProtocolNameRaydiumCpmm:
+		return raydium.RAYDIUM_CPMM_PROGRAM_ID, true
+	}
+	return solana.PublicKey{}, false
+}
+
+func (r *Raydium) Supports(protocol pkg.ProtocolName) bool {
+	_, ok := r.program(protocol)
+	return ok
+}
+
+type raydiumPoolsResponse struct {
+	Success bool   `json:"success"`
+	Msg     string `json:"msg"`
+	Data    struct {
+		Data []struct {
+			ID        string `json:"id"`
+			ProgramID string `json:"programId"`
+			MintA     struct {
+				Address string `json:"address"`
+			} `json:"mintA"`
+			MintB struct {
+				Address string `json:"address"`
+			} `json:"mintB"`
+		} `json:"data"`
+	} `json:"data"`
+}
+
+// PoolAddresses returns the first page of the pair's pools, the largest by
+// liquidity, owned by the protocol's program
+func (r *Raydium) PoolAddresses(ctx context.Context, protocol pkg.ProtocolName, mintA, mintB string) ([]string, error) {
+	program, ok := r.program(protocol)
+	if !ok {
+		return nil, errUnsupported
+	}
+
+	params := url.Values{}
+	params.Set("mint1", mintA)
+	params.Set("mint2", mintB)
+	params.Set("poolType", "all")
+	params.Set("poolSortField", "liquidity")
+	params.Set("sortType", "desc")
+	params.Set("pageSize", "100")
+	params.Set("page", "1")
+
+	var resp raydiumPoolsResponse
+	if err := getJSON(ctx, r.httpClient, r.baseURL+"/pools/info/mint?"+params.Encode(), &resp); err != nil {
+		return nil, fmt.Errorf("raydium API: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("raydium API: %s", resp.Msg)
+	}
+
+	var addresses []string
+	for _, p := range resp.Data.Data {
+		if p.ProgramID == program.String() && samePair(p.MintA.Address, p.MintB.Address, mintA, mintB) {
+			addresses = append(addresses, p.ID)
+		}
+	}
+	return addresses, nil
+}
@@ -11,76 +11,38 @@ import (
 	"soltrading/pkg/sol"
 )
 
+// fluxbeamPoolSlice is the part of a pool account scans download: FluxbeamPool.Decode reads the first 200 bytes
+var fluxbeamPoolSlice = poolSlice(200)
+
 type FluxbeamProtocol struct {
-	SolClient *sol.Client
+	SolClient sol.SolClient
 }
 
-func NewFluxbeam(solClient *sol.Client) *FluxbeamProtocol {
+func NewFluxbeam(solClient sol.SolClient) *FluxbeamProtocol {
 	return &FluxbeamProtocol{
 		SolClient: solClient,
 	}
 }
 
 func (p *FluxbeamProtocol) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("fluxbeam")
+	return pkg.ProtocolNameFluxbeam
 }
 
 func (p *FluxbeamProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
-	baseMintPubkey, err := solana.PublicKeyFromBase58(baseMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid base mint address: %w", err)
-	}
-	quoteMintPubkey, err := solana.PublicKeyFromBase58(quoteMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid quote mint address: %w", err)
-	}
-
-	// Fetch pools with TokenMintA = baseMint and TokenMintB = quoteMint
-	filters := []rpc.RPCFilter{
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 8, // After discriminator
-				Bytes:  baseMintPubkey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 40, // After TokenMintA
-				Bytes:  quoteMintPubkey.Bytes(),
-			},
-		},
-	}
-
-	programAccounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, fluxbeam.FluxbeamProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: filters,
-	})
+	programAccounts, err := scanPair(ctx, p.SolClient, p.scan(), baseMint, quoteMint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch Fluxbeam pools: %w", err)
 	}
+	return p.decodePools(ctx, programAccounts)
+}
 
-	// Also try reverse pair
-	filtersReverse := []rpc.RPCFilter{
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 8,
-				Bytes:  quoteMintPubkey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 40,
-				Bytes:  baseMintPubkey.Bytes(),
-			},
-		},
-	}
-
-	reverseAccounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, fluxbeam.FluxbeamProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: filtersReverse,
-	})
-	if err == nil {
-		programAccounts = append(programAccounts, reverseAccounts...)
-	}
+// scan finds pools by the mints they hold at offsets 8 and 40, in either order
+func (p *FluxbeamProtocol) scan() mintScan {
+	return mintScan{program: fluxbeam.FluxbeamProgramID, slice: fluxbeamPoolSlice, mintAOffset: 8, mintBOffset: 40}
+}
 
+// decodePools decodes the pools among scanned program accounts
+func (p *FluxbeamProtocol) decodePools(ctx context.Context, programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, error) {
 	res := make([]pkg.Pool, 0)
 	for _, v := range programAccounts {
 		pool := &fluxbeam.FluxbeamPool{}
@@ -111,3 +73,20 @@ func (p *FluxbeamProtocol) FetchPoolByID(ctx context.Context, poolId string) (pk
 	pool.PoolId = poolPubkey
 	return pool, nil
 }
+
+// FetchPoolsByPairs scans once per mint shared between pairs; see fetchPairsByMint
+func (p *FluxbeamProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	return fetchPairsByMint(ctx, p.SolClient, p.scan(), pairs, p.FetchPoolsByPair, p.decodePools)
+}
+
+// FetchAllPools enumerates the program's pools a page of shards at a time
+func (p *FluxbeamProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	return fetchPoolPage(ctx, p.SolClient, fluxbeam.FluxbeamProgramID, fluxbeamPoolSlice, nil, 8, opts, func(account *rpc.KeyedAccount) (pkg.Pool, error) {
+		pool := &fluxbeam.FluxbeamPool{}
+		if err := pool.Decode(account.Account.Data.GetBinary()); err != nil {
+			return nil, err
+		}
+		pool.PoolId = account.Pubkey
+		return pool, nil
+	})
+}
@@ -11,76 +11,38 @@ import (
 	"soltrading/pkg/sol"
 )
 
+// goosefxPoolSlice is the part of a pool account scans download: GooseFXPool.Decode reads the first 200 bytes
+var goosefxPoolSlice = poolSlice(200)
+
 type GooseFXProtocol struct {
-	SolClient *sol.Client
+	SolClient sol.SolClient
 }
 
-func NewGooseFX(solClient *sol.Client) *GooseFXProtocol {
+func NewGooseFX(solClient sol.SolClient) *GooseFXProtocol {
 	return &GooseFXProtocol{
 		SolClient: solClient,
 	}
 }
 
 func (p *GooseFXProtocol) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("goosefx")
+	return pkg.ProtocolNameGooseFX
 }
 
 func (p *GooseFXProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
-	baseMintPubkey, err := solana.PublicKeyFromBase58(baseMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid base mint address: %w", err)
-	}
-	quoteMintPubkey, err := solana.PublicKeyFromBase58(quoteMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid quote mint address: %w", err)
-	}
-
-	// Fetch pools with TokenMintA = baseMint and TokenMintB = quoteMint
-	filters := []rpc.RPCFilter{
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 8, // After discriminator
-				Bytes:  baseMintPubkey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 40, // After TokenMintA
-				Bytes:  quoteMintPubkey.Bytes(),
-			},
-		},
-	}
-
-	programAccounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, goosefx.GooseFXProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: filters,
-	})
+	programAccounts, err := scanPair(ctx, p.SolClient, p.scan(), baseMint, quoteMint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch GooseFX pools: %w", err)
 	}
+	return p.decodePools(ctx, programAccounts)
+}
 
-	// Also try reverse pair
-	filtersReverse := []rpc.RPCFilter{
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 8,
-				Bytes:  quoteMintPubkey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 40,
-				Bytes:  baseMintPubkey.Bytes(),
-			},
-		},
-	}
-
-	reverseAccounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, goosefx.GooseFXProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: filtersReverse,
-	})
-	if err == nil {
-		programAccounts = append(programAccounts, reverseAccounts...)
-	}
+// scan finds pools by the mints they hold at offsets 8 and 40, in either order
+func (p *GooseFXProtocol) scan() mintScan {
+	return mintScan{program: goosefx.GooseFXProgramID, slice: goosefxPoolSlice, mintAOffset: 8, mintBOffset: 40}
+}
 
+// decodePools decodes the pools among scanned program accounts
+func (p *GooseFXProtocol) decodePools(ctx context.Context, programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, error) {
 	res := make([]pkg.Pool, 0)
 	for _, v := range programAccounts {
 		pool := &goosefx.GooseFXPool{}
@@ -111,3 +73,20 @@ func (p *GooseFXProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg
 	pool.PoolId = poolPubkey
 	return pool, nil
 }
+
+// FetchPoolsByPairs scans once per mint shared between pairs; see fetchPairsByMint
+func (p *GooseFXProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	return fetchPairsByMint(ctx, p.SolClient, p.scan(), pairs, p.FetchPoolsByPair, p.decodePools)
+}
+
+// FetchAllPools enumerates the program's pools a page of shards at a time
+func (p *GooseFXProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	return fetchPoolPage(ctx, p.SolClient, goosefx.GooseFXProgramID, goosefxPoolSlice, nil, 8, opts, func(account *rpc.KeyedAccount) (pkg.Pool, error) {
+		pool := &goosefx.GooseFXPool{}
+		if err := pool.Decode(account.Account.Data.GetBinary()); err != nil {
+			return nil, err
+		}
+		pool.PoolId = account.Pubkey
+		return pool, nil
+	})
+}
@@ -13,12 +13,15 @@ import (
 )
 
 // MeteoraDlmmProtocol handles interactions with Meteora DLMM (Dynamic Liquidity Market Maker) pools
+// meteoraDlmmPoolSlice is the part of a pool account scans download: MeteoraDlmmPool.Decode reads the whole LbPair account
+var meteoraDlmmPoolSlice = poolSlice(meteora.LbPairSize)
+
 type MeteoraDlmmProtocol struct {
-	SolClient *sol.Client
+	SolClient sol.SolClient
 }
 
 // NewMeteoraDlmm creates a new MeteoraDlmmProtocol instance
-func NewMeteoraDlmm(solClient *sol.Client) *MeteoraDlmmProtocol {
+func NewMeteoraDlmm(solClient sol.SolClient) *MeteoraDlmmProtocol {
 	return &MeteoraDlmmProtocol{
 		SolClient: solClient,
 	}
@@ -30,15 +33,17 @@ func (protocol *MeteoraDlmmProtocol) ProtocolName() pkg.ProtocolName {
 
 // FetchPoolsByPair retrieves all Meteora DLMM pools for a given token pair
 func (protocol *MeteoraDlmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
-	programAccounts := rpc.GetProgramAccountsResult{}
-
-	// Fetch pools with baseMint as TokenX and quoteMint as TokenY
-	baseQuotePools, err := protocol.getMeteoraDlmmPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
+	// Fetch pools holding the pair as TokenX and TokenY in either order
+	programAccounts, err := protocol.getMeteoraDlmmPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pools with baseMint as TokenX: %w", err)
+		return nil, fmt.Errorf("failed to fetch pools for %s/%s: %w", baseMint, quoteMint, err)
 	}
-	programAccounts = append(programAccounts, baseQuotePools...)
+	return protocol.decodePools(ctx, programAccounts)
+}
 
+// decodePools decodes the pools among scanned program accounts and loads the
+// bin arrays their swaps need
+func (protocol *MeteoraDlmmProtocol) decodePools(ctx context.Context, programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, error) {
 	pools := make([]pkg.Pool, 0, len(programAccounts))
 	for _, account := range programAccounts {
 		poolData := &meteora.MeteoraDlmmPool{}
@@ -61,36 +66,33 @@ func (protocol *MeteoraDlmmProtocol) FetchPoolsByPair(ctx context.Context, baseM
 
 // getMeteoraDlmmPoolAccountsByTokenPair retrieves pool accounts for a specific token pair configuration
 func (protocol *MeteoraDlmmProtocol) getMeteoraDlmmPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string) (rpc.GetProgramAccountsResult, error) {
-	var poolLayout meteora.MeteoraDlmmPool
-	result, err := protocol.SolClient.GetProgramAccountsWithOpts(ctx, meteora.MeteoraProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: []rpc.RPCFilter{
-			{
-				DataSize: 904, // Meteora DLMM pool account size
-			},
-			{
-				Memcmp: &rpc.RPCFilterMemcmp{
-					Offset: poolLayout.Offset("TokenXMint"),
-					Bytes:  solana.MustPublicKeyFromBase58(baseMint).Bytes(),
-				},
-			},
-			{
-				Memcmp: &rpc.RPCFilterMemcmp{
-					Offset: poolLayout.Offset("TokenYMint"),
-					Bytes:  solana.MustPublicKeyFromBase58(quoteMint).Bytes(),
-				},
-			},
-		},
-	})
+	result, err := scanPair(ctx, protocol.SolClient, protocol.scan(), baseMint, quoteMint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get program accounts: %w", err)
 	}
 	return result, nil
 }
 
+// scan finds pools by their TokenX and TokenY mints, in either order
+func (protocol *MeteoraDlmmProtocol) scan() mintScan {
+	var poolLayout meteora.MeteoraDlmmPool
+	return mintScan{
+		program:     meteora.MeteoraProgramID,
+		slice:       meteoraDlmmPoolSlice,
+		mintAOffset: poolLayout.Offset("TokenXMint"),
+		mintBOffset: poolLayout.Offset("TokenYMint"),
+		filters:     []rpc.RPCFilter{{DataSize: meteora.LbPairSize}},
+	}
+}
+
 // FetchPoolByID retrieves a specific Meteora DLMM pool by its ID
 func (protocol *MeteoraDlmmProtocol) FetchPoolByID(ctx context.Context, poolID string) (pkg.Pool, error) {
+	poolKey, err := solana.PublicKeyFromBase58(poolID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
 	poolData := &meteora.MeteoraDlmmPool{}
-	account, err := protocol.SolClient.GetAccountInfoWithOpts(ctx, solana.MustPublicKeyFromBase58(poolID))
+	account, err := protocol.SolClient.GetAccountInfoWithOpts(ctx, poolKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pool account: %w", err)
 	}
@@ -98,6 +100,7 @@ func (protocol *MeteoraDlmmProtocol) FetchPoolByID(ctx context.Context, poolID s
 	if err := poolData.Decode(account.Value.Data.GetBinary()); err != nil {
 		return nil, fmt.Errorf("failed to decode pool data: %w", err)
 	}
+	poolData.PoolId = poolKey
 
 	if err := poolData.GetBinArrayForSwap(ctx, protocol.SolClient); err != nil {
 		return nil, fmt.Errorf("failed to get bin array for swap: %w", err)
@@ -107,3 +110,24 @@ func (protocol *MeteoraDlmmProtocol) FetchPoolByID(ctx context.Context, poolID s
 	poolData.BitmapExtensionKey = bitmapExtensionKey
 	return poolData, nil
 }
+
+// FetchPoolsByPairs scans once per mint shared between pairs; see fetchPairsByMint
+func (protocol *MeteoraDlmmProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	return fetchPairsByMint(ctx, protocol.SolClient, protocol.scan(), pairs, protocol.FetchPoolsByPair, protocol.decodePools)
+}
+
+// FetchAllPools enumerates the program's pools a page of shards at a time.
+// The pools have no bin arrays loaded.
+func (protocol *MeteoraDlmmProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	var layout meteora.MeteoraDlmmPool
+	filters := []rpc.RPCFilter{{DataSize: meteora.LbPairSize}}
+	return fetchPoolPage(ctx, protocol.SolClient, meteora.MeteoraProgramID, meteoraDlmmPoolSlice, filters, layout.Offset("TokenXMint"), opts, func(account *rpc.KeyedAccount) (pkg.Pool, error) {
+		pool := &meteora.MeteoraDlmmPool{}
+		if err := pool.Decode(account.Account.Data.GetBinary()); err != nil {
+			return nil, err
+		}
+		pool.PoolId = account.Pubkey
+		pool.BitmapExtensionKey, _ = meteora.DeriveBinArrayBitmapExtension(pool.PoolId)
+		return pool, nil
+	})
+}
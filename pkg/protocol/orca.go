@@ -11,76 +11,38 @@ import (
 	"soltrading/pkg/sol"
 )
 
+// orcaPoolSlice is the part of a pool account scans download: OrcaPool.Decode reads the first 256 bytes
+var orcaPoolSlice = poolSlice(256)
+
 type OrcaProtocol struct {
-	SolClient *sol.Client
+	SolClient sol.SolClient
 }
 
-func NewOrca(solClient *sol.Client) *OrcaProtocol {
+func NewOrca(solClient sol.SolClient) *OrcaProtocol {
 	return &OrcaProtocol{
 		SolClient: solClient,
 	}
 }
 
 func (p *OrcaProtocol) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("orca")
+	return pkg.ProtocolNameOrca
 }
 
 func (p *OrcaProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
-	baseMintPubkey, err := solana.PublicKeyFromBase58(baseMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid base mint address: %w", err)
-	}
-	quoteMintPubkey, err := solana.PublicKeyFromBase58(quoteMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid quote mint address: %w", err)
-	}
-
-	// Fetch pools with TokenMintA = baseMint and TokenMintB = quoteMint
-	filters := []rpc.RPCFilter{
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 104, // After discriminator + vaults + pool mint
-				Bytes:  baseMintPubkey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 136, // After TokenMintA
-				Bytes:  quoteMintPubkey.Bytes(),
-			},
-		},
-	}
-
-	programAccounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, orca.OrcaAmmProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: filters,
-	})
+	programAccounts, err := scanPair(ctx, p.SolClient, p.scan(), baseMint, quoteMint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch Orca pools: %w", err)
 	}
+	return p.decodePools(ctx, programAccounts)
+}
 
-	// Also try reverse pair
-	filtersReverse := []rpc.RPCFilter{
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 104,
-				Bytes:  quoteMintPubkey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 136,
-				Bytes:  baseMintPubkey.Bytes(),
-			},
-		},
-	}
-
-	reverseAccounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, orca.OrcaAmmProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: filtersReverse,
-	})
-	if err == nil {
-		programAccounts = append(programAccounts, reverseAccounts...)
-	}
+// scan finds pools by the mints they hold at offsets 104 and 136, in either order
+func (p *OrcaProtocol) scan() mintScan {
+	return mintScan{program: orca.OrcaAmmProgramID, slice: orcaPoolSlice, mintAOffset: 104, mintBOffset: 136}
+}
 
+// decodePools decodes the pools among scanned program accounts
+func (p *OrcaProtocol) decodePools(ctx context.Context, programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, error) {
 	res := make([]pkg.Pool, 0)
 	for _, v := range programAccounts {
 		pool := &orca.OrcaPool{}
@@ -111,3 +73,20 @@ func (p *OrcaProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Po
 	pool.PoolId = poolPubkey
 	return pool, nil
 }
+
+// FetchPoolsByPairs scans once per mint shared between pairs; see fetchPairsByMint
+func (p *OrcaProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	return fetchPairsByMint(ctx, p.SolClient, p.scan(), pairs, p.FetchPoolsByPair, p.decodePools)
+}
+
+// FetchAllPools enumerates the program's pools a page of shards at a time
+func (p *OrcaProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	return fetchPoolPage(ctx, p.SolClient, orca.OrcaAmmProgramID, orcaPoolSlice, nil, 104, opts, func(account *rpc.KeyedAccount) (pkg.Pool, error) {
+		pool := &orca.OrcaPool{}
+		if err := pool.Decode(account.Account.Data.GetBinary()); err != nil {
+			return nil, err
+		}
+		pool.PoolId = account.Pubkey
+		return pool, nil
+	})
+}
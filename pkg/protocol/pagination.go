@@ -0,0 +1,56 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/sol"
+)
+
+// defaultPageShards is the shards a FetchAllPools page scans by default
+const defaultPageShards = 16
+
+// fetchPoolPage scans one page of shards of program, each shard being the
+// accounts matching filters whose byte at shardOffset has one value, and
+// decodes the accounts, cut to slice. Accounts that don't decode are skipped.
+func fetchPoolPage(ctx context.Context, client sol.SolClient, program solana.PublicKey, slice sol.DataSlice, filters []rpc.RPCFilter, shardOffset uint64, opts pkg.FetchAllOptions, decode func(*rpc.KeyedAccount) (pkg.Pool, error)) (pkg.PoolPage, error) {
+	first := 0
+	if opts.Cursor != "" {
+		shard, err := strconv.Atoi(opts.Cursor)
+		if err != nil || shard < 0 || shard >= pkg.PoolShards {
+			return pkg.PoolPage{}, fmt.Errorf("invalid cursor %q", opts.Cursor)
+		}
+		first = shard
+	}
+	pageShards := opts.PageShards
+	if pageShards <= 0 {
+		pageShards = defaultPageShards
+	}
+	last := min(first+pageShards, pkg.PoolShards)
+
+	page := pkg.PoolPage{Pools: make([]pkg.Pool, 0)}
+	for shard := first; shard < last; shard++ {
+		shardFilters := append(append([]rpc.RPCFilter(nil), filters...), rpc.RPCFilter{
+			Memcmp: &rpc.RPCFilterMemcmp{Offset: shardOffset, Bytes: solana.Base58{byte(shard)}},
+		})
+		accounts, err := sol.GetProgramAccountsSliced(ctx, client, program, shardFilters, slice)
+		if err != nil {
+			return pkg.PoolPage{}, fmt.Errorf("failed to scan shard %d of %s: %w", shard, program, err)
+		}
+		for _, account := range accounts {
+			pool, err := decode(account)
+			if err != nil {
+				continue
+			}
+			page.Pools = append(page.Pools, pool)
+		}
+	}
+	if last < pkg.PoolShards {
+		page.NextCursor = strconv.Itoa(last)
+	}
+	return page, nil
+}
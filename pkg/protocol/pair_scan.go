@@ -0,0 +1,284 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/logging"
+	"soltrading/pkg/sol"
+)
+
+// poolSlice is the prefix of a program's pool accounts its pool decoder
+// reads. Each protocol declares one for the scans whose every match is
+// decoded, pair lookups and FetchAllPools pages, which download only that much
+// of each account. For most layouts that is nearly the whole account; the
+// scans that select among the pools they find read just the mints instead.
+func poolSlice(length uint64) sol.DataSlice {
+	return sol.DataSlice{Length: length}
+}
+
+// mintScan is how a program's pools are found by mint: the offsets of the
+// two mints in its pool accounts, the filters every scan adds and the slice
+// downloaded of each account
+type mintScan struct {
+	program     solana.PublicKey
+	slice       sol.DataSlice
+	mintAOffset uint64
+	mintBOffset uint64
+	filters     []rpc.RPCFilter
+}
+
+// memcmp matches the accounts holding mint at offset
+func memcmp(offset uint64, mint solana.PublicKey) rpc.RPCFilter {
+	return rpc.RPCFilter{Memcmp: &rpc.RPCFilterMemcmp{Offset: offset, Bytes: mint.Bytes()}}
+}
+
+// scanPair returns the program accounts of a pair's pools whatever the order
+// of the mints in them. The pools storing baseMint at mintAOffset and those
+// storing it at mintBOffset are fetched concurrently.
+func scanPair(ctx context.Context, client sol.SolClient, scan mintScan, baseMint, quoteMint string) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	orders := [][]rpc.RPCFilter{
+		{memcmp(scan.mintAOffset, baseKey), memcmp(scan.mintBOffset, quoteKey)},
+		{memcmp(scan.mintAOffset, quoteKey), memcmp(scan.mintBOffset, baseKey)},
+	}
+	if baseKey.Equals(quoteKey) {
+		orders = orders[:1]
+	}
+	return scanAll(ctx, client, scan, orders)
+}
+
+// scanMint returns the program accounts of every pool holding mint, on
+// either side
+func scanMint(ctx context.Context, client sol.SolClient, scan mintScan, mint solana.PublicKey) (rpc.GetProgramAccountsResult, error) {
+	return scanAll(ctx, client, scan, [][]rpc.RPCFilter{
+		{memcmp(scan.mintAOffset, mint)},
+		{memcmp(scan.mintBOffset, mint)},
+	})
+}
+
+// scanAll runs one scan per set of filters concurrently, each on top of the
+// scan's own filters and cut to its slice, and returns every account found
+// once. A failed scan is logged and the others' accounts returned; the error
+// is returned only when all fail.
+func scanAll(ctx context.Context, client sol.SolClient, scan mintScan, sets [][]rpc.RPCFilter) (rpc.GetProgramAccountsResult, error) {
+	results := make([]rpc.GetProgramAccountsResult, len(sets))
+	errs := make([]error, len(sets))
+	var wg sync.WaitGroup
+	for i, set := range sets {
+		wg.Add(1)
+		go func(i int, set []rpc.RPCFilter) {
+			defer wg.Done()
+			filters := append(append([]rpc.RPCFilter(nil), scan.filters...), set...)
+			results[i], errs[i] = sol.GetProgramAccountsSliced(ctx, client, scan.program, filters, scan.slice)
+		}(i, set)
+	}
+	wg.Wait()
+
+	var accounts rpc.GetProgramAccountsResult
+	seen := make(map[solana.PublicKey]bool)
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			first := solana.PublicKeyFromBytes(sets[i][0].Memcmp.Bytes)
+			logging.Component(nil, "protocol").Warn("pool scan failed", "program", scan.program, "mint", first, "error", err)
+			continue
+		}
+		for _, account := range results[i] {
+			if account != nil && !seen[account.Pubkey] {
+				seen[account.Pubkey] = true
+				accounts = append(accounts, account)
+			}
+		}
+	}
+	if failed == len(sets) {
+		return nil, errs[0]
+	}
+	return accounts, nil
+}
+
+// fetchPairsByMint implements FetchPoolsByPairs for the protocols that find
+// pools by scanning their program. Pairs sharing a mint are grouped, the mint
+// shared by the most pairs first, and each group costs the two scans of that
+// mint rather than two per pair; the pools found are split between the
+// group's pairs by their other mint and decoded with decode. The mint scans
+// download only the two mint fields of each pool, which is all the split
+// reads, and the pools of the group's pairs are then fetched whole with
+// getMultipleAccounts, so the pools of pairs not asked for cost 64 bytes
+// each rather than their full state. A mint shared by
+// many pairs is usually SOL or USDC, whose scans match a large part of the
+// program, so pairs sharing no mint keep the narrower lookup of fetchPair.
+func fetchPairsByMint(ctx context.Context, client sol.SolClient, scan mintScan, pairs []pkg.Pair,
+	fetchPair func(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error),
+	decode func(ctx context.Context, accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, error)) (map[pkg.Pair][]pkg.Pool, error) {
+	groups, single := groupByMint(pairs)
+
+	found := make(map[pkg.Pair][]pkg.Pool)
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, pkg.DefaultPairConcurrency)
+	)
+	fail := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	for mint, group := range groups {
+		wg.Add(1)
+		go func(mint string, group []pkg.Pair) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			mintKey, err := solana.PublicKeyFromBase58(mint)
+			if err != nil {
+				fail(fmt.Errorf("invalid mint address %s: %w", mint, err))
+				return
+			}
+			mints := scan
+			mints.slice = sol.PubkeySlice(scan.mintAOffset, scan.mintBOffset)
+			accounts, err := scanMint(ctx, client, mints, mintKey)
+			if err != nil {
+				fail(fmt.Errorf("pools of %s: %w", mint, err))
+				return
+			}
+
+			wanted := make(map[pkg.Pair]bool, len(group))
+			for _, pair := range group {
+				wanted[pair] = true
+			}
+			pairOf := make(map[solana.PublicKey]pkg.Pair)
+			var selected []solana.PublicKey
+			for _, account := range accounts {
+				data := account.Account.Data.GetBinary()
+				mintA, okA := mints.slice.Pubkey(data, scan.mintAOffset)
+				mintB, okB := mints.slice.Pubkey(data, scan.mintBOffset)
+				if !okA || !okB {
+					continue
+				}
+				if pair := (pkg.Pair{BaseMint: mintA.String(), QuoteMint: mintB.String()}).Key(); wanted[pair] {
+					pairOf[account.Pubkey] = pair
+					selected = append(selected, account.Pubkey)
+				}
+			}
+			full, err := fetchAccounts(ctx, client, selected)
+			if err != nil {
+				fail(fmt.Errorf("pools of %s: %w", mint, err))
+				return
+			}
+
+			byPair := make(map[pkg.Pair]rpc.GetProgramAccountsResult, len(group))
+			for _, pair := range group {
+				byPair[pair] = nil
+			}
+			for _, account := range full {
+				pair := pairOf[account.Pubkey]
+				byPair[pair] = append(byPair[pair], account)
+			}
+
+			for pair, matched := range byPair {
+				pools, err := decode(ctx, matched)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s/%s: %w", pair.BaseMint, pair.QuoteMint, err))
+				} else {
+					found[pair] = pools
+				}
+				mu.Unlock()
+			}
+		}(mint, group)
+	}
+	wg.Wait()
+
+	rest, err := pkg.FetchEachPair(ctx, single, pkg.DefaultPairConcurrency, fetchPair)
+	errs = append(errs, err)
+
+	res := make(map[pkg.Pair][]pkg.Pool, len(pairs))
+	for _, pair := range pairs {
+		if pools, ok := found[pair.Key()]; ok {
+			res[pair] = pools
+		} else if pools, ok := rest[pair.Key()]; ok {
+			res[pair] = pools
+		}
+	}
+	return res, errors.Join(errs...)
+}
+
+// fetchAccounts loads the full state of accounts with getMultipleAccounts, a
+// batch at a time, skipping those that no longer exist
+func fetchAccounts(ctx context.Context, client sol.SolClient, accounts []solana.PublicKey) (rpc.GetProgramAccountsResult, error) {
+	var res rpc.GetProgramAccountsResult
+	for start := 0; start < len(accounts); start += maxMultipleAccounts {
+		batch := accounts[start:min(start+maxMultipleAccounts, len(accounts))]
+		results, err := client.GetMultipleAccountsWithOpts(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %d pool accounts: %w", len(batch), err)
+		}
+		for i, account := range results.Value {
+			if i < len(batch) && account != nil && account.Data != nil {
+				res = append(res, &rpc.KeyedAccount{Pubkey: batch[i], Account: account})
+			}
+		}
+	}
+	return res, nil
+}
+
+// groupByMint assigns the distinct pairs, in either direction, to the mints
+// they share with other pairs, taking the mint shared by the most pairs
+// first. Pairs sharing no mint are returned on their own.
+func groupByMint(pairs []pkg.Pair) (map[string][]pkg.Pair, []pkg.Pair) {
+	var remaining []pkg.Pair
+	seen := make(map[pkg.Pair]bool)
+	for _, pair := range pairs {
+		if key := pair.Key(); !seen[key] {
+			seen[key] = true
+			remaining = append(remaining, key)
+		}
+	}
+
+	groups := make(map[string][]pkg.Pair)
+	for {
+		counts := make(map[string]int)
+		for _, pair := range remaining {
+			counts[pair.BaseMint]++
+			if pair.QuoteMint != pair.BaseMint {
+				counts[pair.QuoteMint]++
+			}
+		}
+		best := ""
+		for mint, count := range counts {
+			if count > counts[best] || (count == counts[best] && mint < best) {
+				best = mint
+			}
+		}
+		if counts[best] < 2 {
+			return groups, remaining
+		}
+
+		kept := remaining[:0]
+		for _, pair := range remaining {
+			if pair.BaseMint == best || pair.QuoteMint == best {
+				groups[best] = append(groups[best], pair)
+			} else {
+				kept = append(kept, pair)
+			}
+		}
+		remaining = kept
+	}
+}
@@ -11,11 +11,14 @@ import (
 	"soltrading/pkg/sol"
 )
 
+// pumpAmmPoolSlice is the part of a pool account scans download: PumpAMMPool.Decode reads up to the coin creator, not the flags after it
+var pumpAmmPoolSlice = poolSlice(pump.CoinCreatorEnd)
+
 type PumpAmmProtocol struct {
-	SolClient *sol.Client
+	SolClient sol.SolClient
 }
 
-func NewPumpAmm(solClient *sol.Client) *PumpAmmProtocol {
+func NewPumpAmm(solClient sol.SolClient) *PumpAmmProtocol {
 	return &PumpAmmProtocol{
 		SolClient: solClient,
 	}
@@ -26,13 +29,15 @@ func (p *PumpAmmProtocol) ProtocolName() pkg.ProtocolName {
 }
 
 func (p *PumpAmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
-	programAccounts := rpc.GetProgramAccountsResult{}
-	data, err := p.getPumpAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
+	programAccounts, err := p.getPumpAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+		return nil, fmt.Errorf("failed to fetch pools for %s/%s: %w", baseMint, quoteMint, err)
 	}
-	programAccounts = append(programAccounts, data...)
+	return p.decodePools(ctx, programAccounts)
+}
 
+// decodePools decodes the pools among scanned program accounts
+func (p *PumpAmmProtocol) decodePools(ctx context.Context, programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, error) {
 	res := make([]pkg.Pool, 0)
 	for _, v := range programAccounts {
 		layout, err := pump.ParsePoolData(v.Account.Data.GetBinary())
@@ -46,35 +51,19 @@ func (p *PumpAmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint string,
 }
 
 func (p *PumpAmmProtocol) getPumpAMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string) (rpc.GetProgramAccountsResult, error) {
+	return scanPair(ctx, p.SolClient, p.scan(), baseMint, quoteMint)
+}
+
+// scan finds pools by their base and quote mints, in either order
+func (p *PumpAmmProtocol) scan() mintScan {
 	var layout pump.PumpAMMPool
-	baseMintPubkey, err := solana.PublicKeyFromBase58(baseMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	return mintScan{
+		program:     pump.PumpSwapProgramID,
+		slice:       pumpAmmPoolSlice,
+		mintAOffset: layout.Offset("BaseMint"),
+		mintBOffset: layout.Offset("QuoteMint"),
+		filters:     []rpc.RPCFilter{{DataSize: layout.Span()}},
 	}
-	quoteMintPubkey, err := solana.PublicKeyFromBase58(quoteMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid quote mint address: %w", err)
-	}
-
-	return p.SolClient.GetProgramAccountsWithOpts(ctx, pump.PumpSwapProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: []rpc.RPCFilter{
-			{
-				DataSize: layout.Span(),
-			},
-			{
-				Memcmp: &rpc.RPCFilterMemcmp{
-					Offset: layout.Offset("BaseMint"),
-					Bytes:  baseMintPubkey.Bytes(),
-				},
-			},
-			{
-				Memcmp: &rpc.RPCFilterMemcmp{
-					Offset: layout.Offset("QuoteMint"),
-					Bytes:  quoteMintPubkey.Bytes(),
-				},
-			},
-		},
-	})
 }
 
 func (p *PumpAmmProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
@@ -95,3 +84,22 @@ func (p *PumpAmmProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg
 	layout.PoolId = poolPubkey
 	return layout, nil
 }
+
+// FetchPoolsByPairs scans once per mint shared between pairs; see fetchPairsByMint
+func (p *PumpAmmProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	return fetchPairsByMint(ctx, p.SolClient, p.scan(), pairs, p.FetchPoolsByPair, p.decodePools)
+}
+
+// FetchAllPools enumerates the program's pools a page of shards at a time
+func (p *PumpAmmProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	var layout pump.PumpAMMPool
+	filters := []rpc.RPCFilter{{DataSize: layout.Span()}}
+	return fetchPoolPage(ctx, p.SolClient, pump.PumpSwapProgramID, pumpAmmPoolSlice, filters, layout.Offset("BaseMint"), opts, func(account *rpc.KeyedAccount) (pkg.Pool, error) {
+		pool, err := pump.ParsePoolData(account.Account.Data.GetBinary())
+		if err != nil {
+			return nil, err
+		}
+		pool.PoolId = account.Pubkey
+		return pool, nil
+	})
+}
@@ -14,11 +14,14 @@ import (
 	"soltrading/pkg/sol"
 )
 
+// raydiumAmmPoolSlice is the part of a pool account scans download: AMMPool.Decode reads the whole 752-byte account
+var raydiumAmmPoolSlice = poolSlice(752)
+
 type RaydiumAMMProtocol struct {
-	SolClient *sol.Client
+	SolClient sol.SolClient
 }
 
-func NewRaydiumAmm(solClient *sol.Client) *RaydiumAMMProtocol {
+func NewRaydiumAmm(solClient sol.SolClient) *RaydiumAMMProtocol {
 	return &RaydiumAMMProtocol{
 		SolClient: solClient,
 	}
@@ -29,13 +32,16 @@ func (p *RaydiumAMMProtocol) ProtocolName() pkg.ProtocolName {
 }
 
 func (p *RaydiumAMMProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
-	accounts := make([]*rpc.KeyedAccount, 0)
 	programAccounts, err := p.getAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+		return nil, fmt.Errorf("failed to fetch pools for %s/%s: %w", baseMint, quoteMint, err)
 	}
-	accounts = append(accounts, programAccounts...)
+	return p.decodePools(ctx, programAccounts)
+}
 
+// decodePools decodes the tradable pools among scanned program accounts and
+// loads their market state
+func (p *RaydiumAMMProtocol) decodePools(ctx context.Context, accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, error) {
 	res := make([]pkg.Pool, 0)
 	for _, v := range accounts {
 		layout := &raydium.AMMPool{}
@@ -43,6 +49,11 @@ func (p *RaydiumAMMProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quo
 			continue
 		}
 		layout.PoolId = v.Pubkey
+		// Swaps against disabled or withdraw-only pools revert on-chain.
+		// Pools waiting to open are kept; Quote rejects them until then.
+		if !layout.Tradable() {
+			continue
+		}
 		if err := p.processAMMPool(ctx, layout); err != nil {
 			return nil, fmt.Errorf("failed to process AMM pool %s: %w", v.Pubkey.String(), err)
 		}
@@ -52,35 +63,19 @@ func (p *RaydiumAMMProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quo
 }
 
 func (p *RaydiumAMMProtocol) getAMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string) (rpc.GetProgramAccountsResult, error) {
+	return scanPair(ctx, p.SolClient, p.scan(), baseMint, quoteMint)
+}
+
+// scan finds pools by their base and quote mints, in either order
+func (p *RaydiumAMMProtocol) scan() mintScan {
 	var layout raydium.AMMPool
-	baseMintPubkey, err := solana.PublicKeyFromBase58(baseMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid base mint address: %w", err)
-	}
-	quoteMintPubkey, err := solana.PublicKeyFromBase58(quoteMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	return mintScan{
+		program:     raydium.RAYDIUM_AMM_PROGRAM_ID,
+		slice:       raydiumAmmPoolSlice,
+		mintAOffset: layout.Offset("BaseMint"),
+		mintBOffset: layout.Offset("QuoteMint"),
+		filters:     []rpc.RPCFilter{{DataSize: layout.Span()}},
 	}
-
-	return p.SolClient.GetProgramAccountsWithOpts(ctx, raydium.RAYDIUM_AMM_PROGRAM_ID, &rpc.GetProgramAccountsOpts{
-		Filters: []rpc.RPCFilter{
-			{
-				DataSize: layout.Span(),
-			},
-			{
-				Memcmp: &rpc.RPCFilterMemcmp{
-					Offset: layout.Offset("BaseMint"),
-					Bytes:  baseMintPubkey.Bytes(),
-				},
-			},
-			{
-				Memcmp: &rpc.RPCFilterMemcmp{
-					Offset: layout.Offset("QuoteMint"),
-					Bytes:  quoteMintPubkey.Bytes(),
-				},
-			},
-		},
-	})
 }
 
 // FetchPoolByID fetches a specific pool by its ID
@@ -157,3 +152,23 @@ func (p *RaydiumAMMProtocol) processAMMPool(ctx context.Context, layout *raydium
 	layout.MarketAuthority = marketAuthority
 	return nil
 }
+
+// FetchPoolsByPairs scans once per mint shared between pairs; see fetchPairsByMint
+func (p *RaydiumAMMProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	return fetchPairsByMint(ctx, p.SolClient, p.scan(), pairs, p.FetchPoolsByPair, p.decodePools)
+}
+
+// FetchAllPools enumerates the program's pools a page of shards at a time.
+// Pools of every status are returned, without their market accounts.
+func (p *RaydiumAMMProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	var layout raydium.AMMPool
+	filters := []rpc.RPCFilter{{DataSize: layout.Span()}}
+	return fetchPoolPage(ctx, p.SolClient, raydium.RAYDIUM_AMM_PROGRAM_ID, raydiumAmmPoolSlice, filters, layout.Offset("BaseMint"), opts, func(account *rpc.KeyedAccount) (pkg.Pool, error) {
+		pool := &raydium.AMMPool{}
+		if err := pool.Decode(account.Account.Data.GetBinary()); err != nil {
+			return nil, err
+		}
+		pool.PoolId = account.Pubkey
+		return pool, nil
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 	"soltrading/pkg"
@@ -12,11 +11,14 @@ import (
 	"soltrading/pkg/sol"
 )
 
+// raydiumClmmPoolSlice is the part of a pool account scans download: CLMMPool.Decode reads the whole 1544-byte account
+var raydiumClmmPoolSlice = poolSlice(1544)
+
 type RaydiumClmmProtocol struct {
-	SolClient *sol.Client
+	SolClient sol.SolClient
 }
 
-func NewRaydiumClmm(solClient *sol.Client) *RaydiumClmmProtocol {
+func NewRaydiumClmm(solClient sol.SolClient) *RaydiumClmmProtocol {
 	return &RaydiumClmmProtocol{
 		SolClient: solClient,
 	}
@@ -27,13 +29,16 @@ func (p *RaydiumClmmProtocol) ProtocolName() pkg.ProtocolName {
 }
 
 func (p *RaydiumClmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
-	accounts := make([]*rpc.KeyedAccount, 0)
 	programAccounts, err := p.getCLMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+		return nil, fmt.Errorf("failed to fetch pools for %s/%s: %w", baseMint, quoteMint, err)
 	}
-	accounts = append(accounts, programAccounts...)
+	return p.decodePools(ctx, programAccounts)
+}
 
+// decodePools decodes the pools among scanned program accounts and sets the
+// fee rate of their AmmConfig
+func (p *RaydiumClmmProtocol) decodePools(ctx context.Context, accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, error) {
 	res := make([]pkg.Pool, 0)
 	for _, v := range accounts {
 		data := v.Account.Data.GetBinary()
@@ -43,15 +48,11 @@ func (p *RaydiumClmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint str
 		}
 		layout.PoolId = v.Pubkey
 
-		ammConfigData, err := p.SolClient.GetAccountInfoWithOpts(ctx, layout.AmmConfig)
+		ammConfig, err := raydium.GetCLMMAmmConfig(ctx, p.SolClient, layout.AmmConfig)
 		if err != nil {
 			continue
 		}
-		feeRate, err := parseAmmConfig(ammConfigData.Value.Data.GetBinary())
-		if err != nil {
-			continue
-		}
-		layout.FeeRate = feeRate
+		layout.FeeRate = ammConfig.TradeFeeRate
 
 		exBitmapAddress, _, err := raydium.GetPdaExBitmapAccount(raydium.RAYDIUM_CLMM_PROGRAM_ID, layout.PoolId)
 		if err != nil {
@@ -65,35 +66,7 @@ func (p *RaydiumClmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint str
 }
 
 func (p *RaydiumClmmProtocol) getCLMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string) (rpc.GetProgramAccountsResult, error) {
-	baseKey, err := solana.PublicKeyFromBase58(baseMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid base mint address: %w", err)
-	}
-	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid quote mint address: %w", err)
-	}
-
-	var knownPoolLayout raydium.CLMMPool
-	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, raydium.RAYDIUM_CLMM_PROGRAM_ID, &rpc.GetProgramAccountsOpts{
-		Filters: []rpc.RPCFilter{
-			{
-				DataSize: uint64(knownPoolLayout.Span()),
-			},
-			{
-				Memcmp: &rpc.RPCFilterMemcmp{
-					Offset: knownPoolLayout.Offset("TokenMint0"),
-					Bytes:  baseKey.Bytes(),
-				},
-			},
-			{
-				Memcmp: &rpc.RPCFilterMemcmp{
-					Offset: knownPoolLayout.Offset("TokenMint1"),
-					Bytes:  quoteKey.Bytes(),
-				},
-			},
-		},
-	})
+	result, err := scanPair(ctx, p.SolClient, p.scan(), baseMint, quoteMint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pools: %w", err)
 	}
@@ -101,6 +74,18 @@ func (p *RaydiumClmmProtocol) getCLMMPoolAccountsByTokenPair(ctx context.Context
 	return result, nil
 }
 
+// scan finds pools by their TokenMint0 and TokenMint1, in either order
+func (p *RaydiumClmmProtocol) scan() mintScan {
+	var knownPoolLayout raydium.CLMMPool
+	return mintScan{
+		program:     raydium.RAYDIUM_CLMM_PROGRAM_ID,
+		slice:       raydiumClmmPoolSlice,
+		mintAOffset: knownPoolLayout.Offset("TokenMint0"),
+		mintBOffset: knownPoolLayout.Offset("TokenMint1"),
+		filters:     []rpc.RPCFilter{{DataSize: uint64(knownPoolLayout.Span())}},
+	}
+}
+
 func (r *RaydiumClmmProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
 	poolIdKey, err := solana.PublicKeyFromBase58(poolId)
 	if err != nil {
@@ -116,36 +101,35 @@ func (r *RaydiumClmmProtocol) FetchPoolByID(ctx context.Context, poolId string)
 	if err := layout.Decode(data); err != nil {
 		return nil, fmt.Errorf("failed to decode pool data for %s: %w", poolId, err)
 	}
-	return layout, nil
-}
+	layout.PoolId = poolIdKey
 
-func parseAmmConfig(data []byte) (uint32, error) {
-	var ammConfig AmmConfig
-	if err := ammConfig.Decode(data); err != nil {
-		return 0, fmt.Errorf("failed to decode amm config: %w", err)
+	ammConfig, err := raydium.GetCLMMAmmConfig(ctx, r.SolClient, layout.AmmConfig)
+	if err != nil {
+		return nil, err
 	}
-	return ammConfig.TradeFeeRate, nil
-}
+	layout.FeeRate = ammConfig.TradeFeeRate
 
-type AmmConfig struct {
-	Bump            uint8
-	Index           uint16
-	Owner           solana.PublicKey
-	ProtocolFeeRate uint32
-	TradeFeeRate    uint32
-	TickSpacing     uint16
-	FundFeeRate     uint32
-	PaddingU32      uint32
-	FundOwner       solana.PublicKey
-	Padding         [3]uint64
+	if layout.ExBitmapAddress, _, err = raydium.GetPdaExBitmapAccount(raydium.RAYDIUM_CLMM_PROGRAM_ID, layout.PoolId); err != nil {
+		return nil, fmt.Errorf("failed to derive bitmap extension for %s: %w", poolId, err)
+	}
+	return layout, nil
 }
 
-func (l *AmmConfig) Decode(data []byte) error {
-	// Skip 8 bytes discriminator if present
-	if len(data) > 8 {
-		data = data[8:]
-	}
+// FetchPoolsByPairs scans once per mint shared between pairs; see fetchPairsByMint
+func (p *RaydiumClmmProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	return fetchPairsByMint(ctx, p.SolClient, p.scan(), pairs, p.FetchPoolsByPair, p.decodePools)
+}
 
-	dec := bin.NewBinDecoder(data)
-	return dec.Decode(l)
+// FetchAllPools enumerates the program's pools a page of shards at a time
+func (p *RaydiumClmmProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	var layout raydium.CLMMPool
+	filters := []rpc.RPCFilter{{DataSize: uint64(layout.Span())}}
+	return fetchPoolPage(ctx, p.SolClient, raydium.RAYDIUM_CLMM_PROGRAM_ID, raydiumClmmPoolSlice, filters, layout.Offset("TokenMint0"), opts, func(account *rpc.KeyedAccount) (pkg.Pool, error) {
+		pool := &raydium.CLMMPool{}
+		if err := pool.Decode(account.Account.Data.GetBinary()); err != nil {
+			return nil, err
+		}
+		pool.PoolId = account.Pubkey
+		return pool, nil
+	})
 }
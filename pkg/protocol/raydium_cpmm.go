@@ -12,12 +12,15 @@ import (
 )
 
 // RaydiumCpmmProtocol represents the Raydium CPMM protocol implementation
+// raydiumCpmmPoolSlice is the part of a pool account scans download: CPMMPool.Decode reads the first 584 bytes of the 637-byte account
+var raydiumCpmmPoolSlice = poolSlice(584)
+
 type RaydiumCpmmProtocol struct {
-	SolClient *sol.Client
+	SolClient sol.SolClient
 }
 
 // NewRaydiumCpmm creates a new instance of RaydiumCpmmProtocol
-func NewRaydiumCpmm(solClient *sol.Client) *RaydiumCpmmProtocol {
+func NewRaydiumCpmm(solClient sol.SolClient) *RaydiumCpmmProtocol {
 	return &RaydiumCpmmProtocol{
 		SolClient: solClient,
 	}
@@ -29,12 +32,17 @@ func (p *RaydiumCpmmProtocol) ProtocolName() pkg.ProtocolName {
 
 // FetchPoolsByPair retrieves all pools for a given token pair
 func (p *RaydiumCpmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
-	// Fetch pools with baseMint as token0
+	// Fetch pools holding the pair as token0 and token1 in either order
 	programAccounts, err := p.getCPMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+		return nil, fmt.Errorf("failed to fetch pools for %s/%s: %w", baseMint, quoteMint, err)
 	}
+	return p.decodePools(ctx, programAccounts)
+}
 
+// decodePools decodes the pools among scanned program accounts and sets the
+// fee rate of their AmmConfig
+func (p *RaydiumCpmmProtocol) decodePools(ctx context.Context, programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, error) {
 	pools := make([]pkg.Pool, 0)
 	for _, account := range programAccounts {
 		data := account.Account.Data.GetBinary()
@@ -43,6 +51,11 @@ func (p *RaydiumCpmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint str
 			continue
 		}
 		pool.PoolId = account.Pubkey
+		ammConfig, err := raydium.GetCPMMAmmConfig(ctx, p.SolClient, pool.AmmConfig)
+		if err != nil {
+			continue
+		}
+		pool.TradeFeeRate = ammConfig.TradeFeeRate
 		pools = append(pools, pool)
 	}
 
@@ -51,48 +64,33 @@ func (p *RaydiumCpmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint str
 
 // getCPMMPoolAccountsByTokenPair retrieves CPMM pool accounts for a given token pair
 func (p *RaydiumCpmmProtocol) getCPMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string) (rpc.GetProgramAccountsResult, error) {
-	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	result, err := scanPair(ctx, p.SolClient, p.scan(), baseMint, quoteMint)
 	if err != nil {
-		return nil, fmt.Errorf("invalid base mint address: %w", err)
+		return nil, fmt.Errorf("failed to get pools: %w", err)
 	}
 
-	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid quote mint address: %w", err)
-	}
+	return result, nil
+}
 
+// scan finds pools by their token0 and token1 mints, in either order
+func (p *RaydiumCpmmProtocol) scan() mintScan {
 	var layout raydium.CPMMPool
-	filters := []rpc.RPCFilter{
-		{
-			DataSize: 637,
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: layout.Offset("Token0Mint"),
-				Bytes:  baseKey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: layout.Offset("Token1Mint"),
-				Bytes:  quoteKey.Bytes(),
-			},
-		},
+	return mintScan{
+		program:     raydium.RAYDIUM_CPMM_PROGRAM_ID,
+		slice:       raydiumCpmmPoolSlice,
+		mintAOffset: layout.Offset("Token0Mint"),
+		mintBOffset: layout.Offset("Token1Mint"),
+		filters:     []rpc.RPCFilter{{DataSize: raydium.CPMMPoolSize}},
 	}
-
-	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, raydium.RAYDIUM_CPMM_PROGRAM_ID, &rpc.GetProgramAccountsOpts{
-		Filters: filters,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get pools: %w", err)
-	}
-
-	return result, nil
 }
 
 // FetchPoolByID retrieves a CPMM pool by its ID
 func (p *RaydiumCpmmProtocol) FetchPoolByID(ctx context.Context, poolID string) (pkg.Pool, error) {
-	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, solana.MustPublicKeyFromBase58(poolID))
+	poolKey, err := solana.PublicKeyFromBase58(poolID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pool account %s: %w", poolID, err)
 	}
@@ -101,7 +99,32 @@ func (p *RaydiumCpmmProtocol) FetchPoolByID(ctx context.Context, poolID string)
 	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
 		return nil, fmt.Errorf("failed to decode pool data for %s: %w", poolID, err)
 	}
-	pool.PoolId = solana.MustPublicKeyFromBase58(poolID)
+	pool.PoolId = poolKey
+
+	ammConfig, err := raydium.GetCPMMAmmConfig(ctx, p.SolClient, pool.AmmConfig)
+	if err != nil {
+		return nil, err
+	}
+	pool.TradeFeeRate = ammConfig.TradeFeeRate
 
 	return pool, nil
 }
+
+// FetchPoolsByPairs scans once per mint shared between pairs; see fetchPairsByMint
+func (p *RaydiumCpmmProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	return fetchPairsByMint(ctx, p.SolClient, p.scan(), pairs, p.FetchPoolsByPair, p.decodePools)
+}
+
+// FetchAllPools enumerates the program's pools a page of shards at a time
+func (p *RaydiumCpmmProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	var layout raydium.CPMMPool
+	filters := []rpc.RPCFilter{{DataSize: raydium.CPMMPoolSize}}
+	return fetchPoolPage(ctx, p.SolClient, raydium.RAYDIUM_CPMM_PROGRAM_ID, raydiumCpmmPoolSlice, filters, layout.Offset("Token0Mint"), opts, func(account *rpc.KeyedAccount) (pkg.Pool, error) {
+		pool := &raydium.CPMMPool{}
+		if err := pool.Decode(account.Account.Data.GetBinary()); err != nil {
+			return nil, err
+		}
+		pool.PoolId = account.Pubkey
+		return pool, nil
+	})
+}
@@ -11,76 +11,38 @@ import (
 	"soltrading/pkg/sol"
 )
 
+// splSwapPoolSlice is the part of a pool account scans download: SplSwapPool.Decode reads the whole 324-byte account
+var splSwapPoolSlice = poolSlice(324)
+
 type SplTokenSwapProtocol struct {
-	SolClient *sol.Client
+	SolClient sol.SolClient
 }
 
-func NewSplTokenSwap(solClient *sol.Client) *SplTokenSwapProtocol {
+func NewSplTokenSwap(solClient sol.SolClient) *SplTokenSwapProtocol {
 	return &SplTokenSwapProtocol{
 		SolClient: solClient,
 	}
 }
 
 func (p *SplTokenSwapProtocol) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("spl_token_swap")
+	return pkg.ProtocolNameSplTokenSwap
 }
 
 func (p *SplTokenSwapProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
-	baseMintPubkey, err := solana.PublicKeyFromBase58(baseMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid base mint address: %w", err)
-	}
-	quoteMintPubkey, err := solana.PublicKeyFromBase58(quoteMint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid quote mint address: %w", err)
-	}
-
-	// Fetch pools with MintA = baseMint and MintB = quoteMint
-	filters := []rpc.RPCFilter{
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 99, // Offset to MintA field
-				Bytes:  baseMintPubkey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 131, // Offset to MintB field
-				Bytes:  quoteMintPubkey.Bytes(),
-			},
-		},
-	}
-
-	programAccounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, splswap.SplTokenSwapProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: filters,
-	})
+	programAccounts, err := scanPair(ctx, p.SolClient, p.scan(), baseMint, quoteMint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch SPL Token Swap pools: %w", err)
 	}
+	return p.decodePools(ctx, programAccounts)
+}
 
-	// Also try reverse pair
-	filtersReverse := []rpc.RPCFilter{
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 99,
-				Bytes:  quoteMintPubkey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 131,
-				Bytes:  baseMintPubkey.Bytes(),
-			},
-		},
-	}
-
-	reverseAccounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, splswap.SplTokenSwapProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: filtersReverse,
-	})
-	if err == nil {
-		programAccounts = append(programAccounts, reverseAccounts...)
-	}
+// scan finds pools by the mints they hold at offsets 99 and 131, in either order
+func (p *SplTokenSwapProtocol) scan() mintScan {
+	return mintScan{program: splswap.SplTokenSwapProgramID, slice: splSwapPoolSlice, mintAOffset: 99, mintBOffset: 131}
+}
 
+// decodePools decodes the pools among scanned program accounts
+func (p *SplTokenSwapProtocol) decodePools(ctx context.Context, programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, error) {
 	res := make([]pkg.Pool, 0)
 	for _, v := range programAccounts {
 		pool := &splswap.SplSwapPool{}
@@ -111,3 +73,20 @@ func (p *SplTokenSwapProtocol) FetchPoolByID(ctx context.Context, poolId string)
 	pool.PoolId = poolPubkey
 	return pool, nil
 }
+
+// FetchPoolsByPairs scans once per mint shared between pairs; see fetchPairsByMint
+func (p *SplTokenSwapProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	return fetchPairsByMint(ctx, p.SolClient, p.scan(), pairs, p.FetchPoolsByPair, p.decodePools)
+}
+
+// FetchAllPools enumerates the program's pools a page of shards at a time
+func (p *SplTokenSwapProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	return fetchPoolPage(ctx, p.SolClient, splswap.SplTokenSwapProgramID, splSwapPoolSlice, nil, 99, opts, func(account *rpc.KeyedAccount) (pkg.Pool, error) {
+		pool := &splswap.SplSwapPool{}
+		if err := pool.Decode(account.Account.Data.GetBinary()); err != nil {
+			return nil, err
+		}
+		pool.PoolId = account.Pubkey
+		return pool, nil
+	})
+}
@@ -2,27 +2,35 @@ package protocol
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 	"soltrading/pkg"
+	"soltrading/pkg/logging"
 	"soltrading/pkg/pool/whirlpool"
 	"soltrading/pkg/sol"
 )
 
+// maxMultipleAccounts is the getMultipleAccounts limit per request
+const maxMultipleAccounts = 100
+
+// whirlpoolPoolSlice is the part of a pool account scans download: WhirlpoolPool.Decode reads the whole 653-byte account
+var whirlpoolPoolSlice = poolSlice(whirlpool.WhirlpoolSize)
+
 type WhirlpoolProtocol struct {
-	SolClient *sol.Client
+	SolClient sol.SolClient
 }
 
-func NewWhirlpool(solClient *sol.Client) *WhirlpoolProtocol {
+func NewWhirlpool(solClient sol.SolClient) *WhirlpoolProtocol {
 	return &WhirlpoolProtocol{
 		SolClient: solClient,
 	}
 }
 
 func (p *WhirlpoolProtocol) ProtocolName() pkg.ProtocolName {
-	return pkg.ProtocolName("whirlpool")
+	return pkg.ProtocolNameWhirlpool
 }
 
 func (p *WhirlpoolProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
@@ -35,53 +43,138 @@ func (p *WhirlpoolProtocol) FetchPoolsByPair(ctx context.Context, baseMint strin
 		return nil, fmt.Errorf("invalid quote mint address: %w", err)
 	}
 
-	// Whirlpool uses 8-byte discriminator for account type
-	// discriminator for Whirlpool account is first 8 bytes
+	// Pools are PDAs of (config, mintA, mintB, tickSpacing), so one
+	// getMultipleAccounts finds them without a getProgramAccounts scan
+	pools, err := p.fetchPoolsByPDA(ctx, baseMintPubkey, quoteMintPubkey)
+	if err == nil && len(pools) > 0 {
+		return pools, nil
+	}
+	if err != nil {
+		logging.Component(nil, "protocol").Warn("Whirlpool PDA lookup failed, scanning program accounts", "base", baseMint, "quote", quoteMint, "error", err)
+	}
+	return p.fetchPoolsByProgramAccounts(ctx, baseMintPubkey, quoteMintPubkey)
+}
 
-	// Fetch pools with TokenMintA = baseMint and TokenMintB = quoteMint
-	filters := []rpc.RPCFilter{
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 101, // TokenMintA offset in Whirlpool account
-				Bytes:  baseMintPubkey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 181, // TokenMintB offset in Whirlpool account
-				Bytes:  quoteMintPubkey.Bytes(),
-			},
-		},
+// fetchPoolsByPDA fetches the pair's pools at every known config and tick
+// spacing
+func (p *WhirlpoolProtocol) fetchPoolsByPDA(ctx context.Context, baseMint, quoteMint solana.PublicKey) ([]pkg.Pool, error) {
+	addresses, err := whirlpool.PairAddresses(baseMint, quoteMint)
+	if err != nil {
+		return nil, err
 	}
+	found, err := p.fetchPoolsAt(ctx, addresses)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]pkg.Pool, 0, len(found))
+	for _, address := range addresses {
+		if pool, ok := found[address]; ok {
+			res = append(res, pool)
+		}
+	}
+	return res, nil
+}
 
-	programAccounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, whirlpool.WhirlpoolProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: filters,
-	})
+// fetchPoolsAt loads the Whirlpools at addresses, maxMultipleAccounts per
+// request. Addresses without a pool are left out.
+func (p *WhirlpoolProtocol) fetchPoolsAt(ctx context.Context, addresses []solana.PublicKey) (map[solana.PublicKey]*whirlpool.WhirlpoolPool, error) {
+	res := make(map[solana.PublicKey]*whirlpool.WhirlpoolPool)
+	for start := 0; start < len(addresses); start += maxMultipleAccounts {
+		batch := addresses[start:min(start+maxMultipleAccounts, len(addresses))]
+		results, err := p.SolClient.GetMultipleAccountsWithOpts(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch Whirlpool accounts: %w", err)
+		}
+		for i, account := range results.Value {
+			if account == nil || i >= len(batch) {
+				continue
+			}
+			pool := &whirlpool.WhirlpoolPool{}
+			if err := pool.Decode(account.Data.GetBinary()); err != nil {
+				continue
+			}
+			pool.PoolId = batch[i]
+			pool.SetLastSlot(results.Context.Slot)
+			res[batch[i]] = pool
+		}
+	}
+	return res, nil
+}
+
+// FetchPoolsByPairs derives the candidate addresses of every pair and loads
+// them together, ten pairs per getMultipleAccounts. Pairs with no pool at a
+// known address are scanned for like FetchPoolsByPair does.
+func (p *WhirlpoolProtocol) FetchPoolsByPairs(ctx context.Context, pairs []pkg.Pair) (map[pkg.Pair][]pkg.Pool, error) {
+	pairAddresses := make([][]solana.PublicKey, len(pairs))
+	var addresses []solana.PublicKey
+	seen := make(map[solana.PublicKey]bool)
+	var errs []error
+	for i, pair := range pairs {
+		baseMint, err := solana.PublicKeyFromBase58(pair.BaseMint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid base mint address %q: %w", pair.BaseMint, err))
+			continue
+		}
+		quoteMint, err := solana.PublicKeyFromBase58(pair.QuoteMint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid quote mint address %q: %w", pair.QuoteMint, err))
+			continue
+		}
+		if pairAddresses[i], err = whirlpool.PairAddresses(baseMint, quoteMint); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, address := range pairAddresses[i] {
+			if !seen[address] {
+				seen[address] = true
+				addresses = append(addresses, address)
+			}
+		}
+	}
+
+	found, err := p.fetchPoolsAt(ctx, addresses)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch Whirlpool pools: %w", err)
+		logging.Component(nil, "protocol").Warn("Whirlpool PDA lookup failed, scanning program accounts", "pairs", len(pairs), "error", err)
 	}
 
-	// Also try reverse pair (TokenMintA = quoteMint, TokenMintB = baseMint)
-	filtersReverse := []rpc.RPCFilter{
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 101,
-				Bytes:  quoteMintPubkey.Bytes(),
-			},
-		},
-		{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Offset: 181,
-				Bytes:  baseMintPubkey.Bytes(),
-			},
-		},
-	}
-
-	reverseAccounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, whirlpool.WhirlpoolProgramID, &rpc.GetProgramAccountsOpts{
-		Filters: filtersReverse,
-	})
-	if err == nil {
-		programAccounts = append(programAccounts, reverseAccounts...)
+	res := make(map[pkg.Pair][]pkg.Pool, len(pairs))
+	var missing []pkg.Pair
+	for i, pair := range pairs {
+		if pairAddresses[i] == nil {
+			continue
+		}
+		var pools []pkg.Pool
+		for _, address := range pairAddresses[i] {
+			if pool, ok := found[address]; ok {
+				pools = append(pools, pool)
+			}
+		}
+		if len(pools) == 0 {
+			missing = append(missing, pair)
+			continue
+		}
+		res[pair] = pools
+	}
+
+	if len(missing) > 0 {
+		scanned, err := pkg.FetchEachPair(ctx, missing, pkg.DefaultPairConcurrency, func(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+			return p.fetchPoolsByProgramAccounts(ctx, solana.MustPublicKeyFromBase58(baseMint), solana.MustPublicKeyFromBase58(quoteMint))
+		})
+		for pair, pools := range scanned {
+			res[pair] = pools
+		}
+		errs = append(errs, err)
+	}
+	return res, errors.Join(errs...)
+}
+
+// fetchPoolsByProgramAccounts scans the program for the pair's pools, which
+// also finds pools under configs missing from whirlpool.WhirlpoolsConfigs
+func (p *WhirlpoolProtocol) fetchPoolsByProgramAccounts(ctx context.Context, baseMintPubkey, quoteMintPubkey solana.PublicKey) ([]pkg.Pool, error) {
+	scan := mintScan{program: whirlpool.WhirlpoolProgramID, slice: whirlpoolPoolSlice, mintAOffset: whirlpool.TokenMintAOffset, mintBOffset: whirlpool.TokenMintBOffset}
+	programAccounts, err := scanPair(ctx, p.SolClient, scan, baseMintPubkey.String(), quoteMintPubkey.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Whirlpool pools: %w", err)
 	}
 
 	res := make([]pkg.Pool, 0)
@@ -114,3 +207,16 @@ func (p *WhirlpoolProtocol) FetchPoolByID(ctx context.Context, poolId string) (p
 	pool.PoolId = poolPubkey
 	return pool, nil
 }
+
+// FetchAllPools enumerates the program's pools a page of shards at a time
+func (p *WhirlpoolProtocol) FetchAllPools(ctx context.Context, opts pkg.FetchAllOptions) (pkg.PoolPage, error) {
+	filters := []rpc.RPCFilter{{DataSize: whirlpool.WhirlpoolSize}}
+	return fetchPoolPage(ctx, p.SolClient, whirlpool.WhirlpoolProgramID, whirlpoolPoolSlice, filters, whirlpool.TokenMintAOffset, opts, func(account *rpc.KeyedAccount) (pkg.Pool, error) {
+		pool := &whirlpool.WhirlpoolPool{}
+		if err := pool.Decode(account.Account.Data.GetBinary()); err != nil {
+			return nil, err
+		}
+		pool.PoolId = account.Pubkey
+		return pool, nil
+	})
+}
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"cosmossdk.io/math"
+	"soltrading/pkg"
+	"soltrading/pkg/sol"
+)
+
+// maxExactOutQuotes bounds the quotes spent searching one pool's input
+const maxExactOutQuotes = 48
+
+type exactOutResult struct {
+	pool     pkg.Pool
+	amountIn math.Int
+	err      error
+}
+
+// GetBestPoolExactOut returns the pool that needs the least tokenIn to deliver
+// at least amountOut of tokenOut, and that input. Pools only quote exact-in, so
+// each pool's input is searched for; the returned input always yields amountOut.
+func (r *SimpleRouter) GetBestPoolExactOut(ctx context.Context, solClient sol.SolClient, tokenIn, tokenOut string, amountOut math.Int) (pkg.Pool, math.Int, error) {
+	if !amountOut.IsPositive() {
+		return nil, math.ZeroInt(), fmt.Errorf("output amount must be positive")
+	}
+	tokenIn, tokenOut = PoolMint(tokenIn), PoolMint(tokenOut)
+	pools := r.filterPools(nil, nil, 0, tokenIn)
+	if len(pools) == 0 {
+		return nil, math.ZeroInt(), fmt.Errorf("%w after filtering", pkg.ErrNoPoolsFound)
+	}
+
+	results := make([]exactOutResult, len(pools))
+	var wg sync.WaitGroup
+	for i, pool := range pools {
+		wg.Add(1)
+		go func(i int, p pkg.Pool) {
+			defer wg.Done()
+			amountIn, err := requiredInput(ctx, solClient, p, tokenIn, tokenOut, amountOut)
+			results[i] = exactOutResult{pool: p, amountIn: amountIn, err: err}
+		}(i, pool)
+	}
+	wg.Wait()
+
+	var candidates []exactOutResult
+	var errs []error
+	for _, result := range results {
+		if result.err != nil {
+			r.log().Warn("failed to quote pool exact-out", "pool", result.pool.GetID(), "error", result.err)
+			errs = append(errs, fmt.Errorf("pool %s: %w", result.pool.GetID(), result.err))
+			continue
+		}
+		candidates = append(candidates, result)
+	}
+	if len(candidates) == 0 {
+		return nil, math.ZeroInt(), fmt.Errorf("no pool can deliver %s out: %w", amountOut, errors.Join(errs...))
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].amountIn.LT(candidates[j].amountIn)
+	})
+
+	for _, candidate := range candidates {
+		if r.fits(ctx, solClient, candidate.pool, tokenIn, candidate.amountIn) {
+			return candidate.pool, candidate.amountIn, nil
+		}
+	}
+	return nil, math.ZeroInt(), fmt.Errorf("no route found")
+}
+
+// requiredInput finds the smallest tokenIn amount the pool turns into at
+// least amountOut. The reverse quote gives a starting estimate, which is
+// widened until it suffices and then narrowed by interpolation and bisection.
+func requiredInput(ctx context.Context, solClient sol.SolClient, pool pkg.Pool, tokenIn, tokenOut string, amountOut math.Int) (math.Int, error) {
+	quotes := 0
+	quote := func(amountIn math.Int) (math.Int, error) {
+		quotes++
+		return pool.Quote(ctx, solClient, tokenIn, amountIn)
+	}
+
+	estimate, err := pool.Quote(ctx, solClient, tokenOut, amountOut)
+	if err != nil || !estimate.IsPositive() {
+		estimate = amountOut
+	}
+
+	// Bracket the answer: out(lo) < amountOut <= out(hi)
+	lo, outLo := math.ZeroInt(), math.ZeroInt()
+	hi := estimate.Add(estimate.QuoRaw(100)).AddRaw(1)
+	outHi, err := quote(hi)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	for outHi.LT(amountOut) {
+		if quotes >= maxExactOutQuotes || (outHi.IsPositive() && outHi.LTE(outLo)) {
+			return math.ZeroInt(), fmt.Errorf("%w for %s out", pkg.ErrInsufficientLiquidity, amountOut)
+		}
+		lo, outLo = hi, outHi
+		hi = hi.MulRaw(2)
+		if outHi, err = quote(hi); err != nil {
+			return math.ZeroInt(), err
+		}
+	}
+
+	for step := 0; hi.Sub(lo).GT(math.OneInt()) && quotes < maxExactOutQuotes; step++ {
+		var next math.Int
+		if step%2 == 0 && outHi.GT(outLo) {
+			// The output is close to linear in the input over a narrow range
+			next = lo.Add(amountOut.Sub(outLo).Mul(hi.Sub(lo)).Quo(outHi.Sub(outLo)))
+		} else {
+			next = lo.Add(hi).QuoRaw(2)
+		}
+		if next.LTE(lo) {
+			next = lo.AddRaw(1)
+		} else if next.GTE(hi) {
+			next = hi.SubRaw(1)
+		}
+
+		out, err := quote(next)
+		if err != nil {
+			return math.ZeroInt(), err
+		}
+		if out.GTE(amountOut) {
+			hi, outHi = next, out
+		} else {
+			lo, outLo = next, out
+		}
+	}
+	return hi, nil
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"soltrading/pkg"
+	"soltrading/pkg/sol"
+)
+
+// impactReferenceDivisor sizes the small trade whose rate stands in for the
+// pool's spot price
+const impactReferenceDivisor = 1000
+
+// minImpactReference is the smallest reference input; below it rounding in
+// the pool math would distort the spot rate
+var minImpactReference = math.NewInt(1000)
+
+// PriceImpact estimates how much worse the rate of swapping amountIn for
+// amountOut through pool is than its spot rate, as a fraction (0.01 is 1%).
+// The spot rate is taken from a trade a thousand times smaller.
+func PriceImpact(ctx context.Context, solClient sol.SolClient, pool pkg.Pool, tokenIn string, amountIn, amountOut math.Int) (float64, error) {
+	reference := amountIn.QuoRaw(impactReferenceDivisor)
+	if reference.LT(minImpactReference) {
+		return 0, fmt.Errorf("amount too small to measure price impact")
+	}
+	referenceOut, err := pool.Quote(ctx, solClient, PoolMint(tokenIn), reference)
+	if err != nil {
+		return 0, fmt.Errorf("failed to quote reference amount: %w", err)
+	}
+	if !referenceOut.IsPositive() {
+		return 0, fmt.Errorf("reference amount quoted no output")
+	}
+
+	// 1 - (amountOut/amountIn) / (referenceOut/reference)
+	rate := math.LegacyNewDecFromInt(amountOut).MulInt(reference)
+	spot := math.LegacyNewDecFromInt(referenceOut).MulInt(amountIn)
+	impact := math.LegacyOneDec().Sub(rate.Quo(spot))
+	if impact.IsNegative() {
+		impact = math.LegacyZeroDec()
+	}
+	return impact.Float64()
+}
@@ -0,0 +1,46 @@
+package router
+
+import (
+	"soltrading/pkg"
+	"soltrading/pkg/sol"
+)
+
+// IsNativeSOL reports whether mint is the native SOL sentinel, the system
+// program address callers pass for SOL held outside a token account
+func IsNativeSOL(mint string) bool {
+	return mint == sol.NativeSOL.String()
+}
+
+// PoolMint returns the mint pools trade for mint: WSOL for native SOL, mint
+// itself otherwise
+func PoolMint(mint string) string {
+	if IsNativeSOL(mint) {
+		return sol.WSOL.String()
+	}
+	return mint
+}
+
+// NativeLegs reports which legs of a swap of tokenIn through pool are native
+// SOL: the input when tokenIn is native SOL, and the output when the pools
+// were queried for native SOL and the pool pays out WSOL
+func (r *SimpleRouter) NativeLegs(pool pkg.Pool, tokenIn string) (wrapInput, unwrapOutput bool) {
+	if IsNativeSOL(tokenIn) {
+		return true, false
+	}
+	if !r.nativeSOL {
+		return false, false
+	}
+	baseMint, quoteMint := pool.GetTokens()
+	outputMint := baseMint
+	if tokenIn == baseMint {
+		outputMint = quoteMint
+	}
+	return false, outputMint == sol.WSOL.String()
+}
+
+// route is the Route through a ranked pool, with its native SOL legs marked
+func (r *SimpleRouter) route(candidate quoteResult, tokenIn string) Route {
+	route := Route{Pool: candidate.pool, OutAmount: candidate.outAmount}
+	route.WrapInput, route.UnwrapOutput = r.NativeLegs(candidate.pool, tokenIn)
+	return route
+}
@@ -2,74 +2,258 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math/big"
+	"sort"
 	"sync"
+	"sync/atomic"
 
 	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
 	"soltrading/pkg"
-	"soltrading/pkg/pool/pump"
-	"soltrading/pkg/pool/raydium"
+	"soltrading/pkg/config"
+	"soltrading/pkg/logging"
 	"soltrading/pkg/sol"
 )
 
 type SimpleRouter struct {
 	Protocols []pkg.Protocol
 	Pools     []pkg.Pool
+
+	// MaxAccounts and MaxTxBytes, when set, reject routes whose swap
+	// transaction would not fit within the limits
+	MaxAccounts int
+	MaxTxBytes  int
+
+	// Freshness reports the slot age of pool state. Pools older than
+	// StaleSlots have their quotes discounted by StalePenaltyBps when ranking.
+	Freshness       PoolFreshness
+	StaleSlots      uint64
+	StalePenaltyBps int64
+
+	settings  atomic.Value // config.RouterConfig, replaced by ApplyConfig
+	nativeSOL bool         // the pools were queried with native SOL as one mint
+	logger    *slog.Logger
+}
+
+// PoolFreshness reports how many slots old a pool's cached state is. ok is
+// false when the age is unknown, e.g. *subscription.SubscriptionManager.
+type PoolFreshness interface {
+	PoolSlotAge(poolID string) (age uint64, ok bool)
+}
+
+// SetFreshness ranks pools whose state is more than staleSlots old as if their
+// output were penaltyBps lower, so fresher pools win close calls
+func (r *SimpleRouter) SetFreshness(freshness PoolFreshness, staleSlots uint64, penaltyBps int64) {
+	r.Freshness = freshness
+	r.StaleSlots = staleSlots
+	r.StalePenaltyBps = penaltyBps
+}
+
+// rankedOutput discounts the quoted output of a stale pool for ranking purposes
+func (r *SimpleRouter) rankedOutput(pool pkg.Pool, out math.Int) math.Int {
+	if r.Freshness == nil || r.StalePenaltyBps <= 0 {
+		return out
+	}
+	age, ok := r.Freshness.PoolSlotAge(pool.GetID())
+	if !ok || age <= r.StaleSlots {
+		return out
+	}
+	return out.Mul(math.NewInt(10000 - r.StalePenaltyBps)).Quo(math.NewInt(10000))
+}
+
+// SetTxConstraints limits selected routes to those that fit in a single transaction
+// with at most maxAccounts accounts and maxTxBytes serialized bytes. Zero disables
+// the respective check.
+func (r *SimpleRouter) SetTxConstraints(maxAccounts, maxTxBytes int) {
+	r.MaxAccounts = maxAccounts
+	r.MaxTxBytes = maxTxBytes
+}
+
+// ApplyConfig applies the runtime router settings. It is safe to call while
+// routes are being computed; calls in flight keep the settings they started with.
+func (r *SimpleRouter) ApplyConfig(cfg config.RouterConfig) {
+	r.settings.Store(cfg)
+}
+
+// config returns the settings last applied
+func (r *SimpleRouter) config() config.RouterConfig {
+	cfg, _ := r.settings.Load().(config.RouterConfig)
+	return cfg
 }
 
 func NewSimpleRouter(protocols ...pkg.Protocol) *SimpleRouter {
+	return NewSimpleRouterWithLogger(nil, protocols...)
+}
+
+// NewSimpleRouterWithLogger creates a router that logs to logger; nil uses
+// logging.Default()
+func NewSimpleRouterWithLogger(logger *slog.Logger, protocols ...pkg.Protocol) *SimpleRouter {
 	return &SimpleRouter{
 		Protocols: protocols,
 		Pools:     []pkg.Pool{},
+		logger:    logging.Component(logger, "router"),
+	}
+}
+
+// log returns the router's logger, falling back to the default for a router
+// not built by a constructor
+func (r *SimpleRouter) log() *slog.Logger {
+	if r.logger == nil {
+		return logging.Component(nil, "router")
 	}
+	return r.logger
 }
 
+// QueryAllPools loads the pools of a pair from every protocol. Native SOL is
+// looked up as WSOL, and routes out of the pools then unwrap their WSOL output.
+// A protocol that fails is skipped; when no pools are found at all the error
+// wraps pkg.ErrNoPoolsFound and the protocols' errors.
 func (r *SimpleRouter) QueryAllPools(ctx context.Context, baseMint, quoteMint string) error {
 	var allPools []pkg.Pool
+	r.nativeSOL = IsNativeSOL(baseMint) || IsNativeSOL(quoteMint)
+	baseMint, quoteMint = PoolMint(baseMint), PoolMint(quoteMint)
 
 	// Loop through each protocol sequentially
+	var errs []error
 	for _, proto := range r.Protocols {
-		log.Printf("😈Fetching pools from protocol: %v", proto.ProtocolName())
+		r.log().Debug("fetching pools", "protocol", proto.ProtocolName(), "base", baseMint, "quote", quoteMint)
 		pools, err := proto.FetchPoolsByPair(ctx, baseMint, quoteMint)
 		if err != nil {
-			log.Printf("error fetching pools from protocol: %v", err)
+			r.log().Warn("failed to fetch pools", "protocol", proto.ProtocolName(), "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", proto.ProtocolName(), err))
 			continue
 		}
 		allPools = append(allPools, pools...)
 	}
 
 	r.Pools = allPools
+	if len(allPools) == 0 {
+		return fmt.Errorf("%w for %s/%s: %w", pkg.ErrNoPoolsFound, baseMint, quoteMint, errors.Join(errs...))
+	}
 	return nil
 }
 
-func (r *SimpleRouter) GetBestPool(ctx context.Context, solClient *sol.Client, tokenIn string, amountIn math.Int) (pkg.Pool, math.Int, error) {
+func (r *SimpleRouter) GetBestPool(ctx context.Context, solClient sol.SolClient, tokenIn string, amountIn math.Int) (pkg.Pool, math.Int, error) {
 	return r.GetBestPoolWithFilter(ctx, solClient, tokenIn, amountIn, nil, nil, 0)
 }
 
-func (r *SimpleRouter) GetBestPoolWithFilter(ctx context.Context, solClient *sol.Client, tokenIn string, amountIn math.Int, dexes, excludeDexes []string, minLiquidityUSD float64) (pkg.Pool, math.Int, error) {
+// Route is a single-pool route and its quoted output
+type Route struct {
+	Pool      pkg.Pool
+	OutAmount math.Int
+	// WrapInput and UnwrapOutput mark a native SOL input or output that the
+	// pool trades as WSOL, so the swap builder wraps it before the swap or
+	// unwraps it after
+	WrapInput    bool
+	UnwrapOutput bool
+}
+
+type quoteResult struct {
+	pool      pkg.Pool
+	outAmount math.Int
+	ranked    math.Int
+	err       error
+}
+
+func (r *SimpleRouter) GetBestPoolWithFilter(ctx context.Context, solClient sol.SolClient, tokenIn string, amountIn math.Int, dexes, excludeDexes []string, minLiquidityUSD float64) (pkg.Pool, math.Int, error) {
+	candidates, err := r.rankPools(ctx, solClient, tokenIn, amountIn, dexes, excludeDexes, minLiquidityUSD)
+	if err != nil {
+		return nil, math.ZeroInt(), err
+	}
+
+	for _, candidate := range candidates {
+		if r.fits(ctx, solClient, candidate.pool, tokenIn, amountIn) {
+			return candidate.pool, candidate.outAmount, nil
+		}
+	}
+
+	return nil, math.ZeroInt(), fmt.Errorf("no route found")
+}
+
+// GetBestRoutesWithFilter returns up to n routes, best first, keeping only the
+// best pool of each protocol so callers can compare venues
+func (r *SimpleRouter) GetBestRoutesWithFilter(ctx context.Context, solClient sol.SolClient, tokenIn string, amountIn math.Int, dexes, excludeDexes []string, minLiquidityUSD float64, n int) ([]Route, error) {
+	candidates, err := r.rankPools(ctx, solClient, tokenIn, amountIn, dexes, excludeDexes, minLiquidityUSD)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []Route
+	seen := make(map[pkg.ProtocolName]bool)
+	for _, candidate := range candidates {
+		if len(routes) >= n {
+			break
+		}
+		protocol := candidate.pool.ProtocolName()
+		if seen[protocol] || !r.fits(ctx, solClient, candidate.pool, tokenIn, amountIn) {
+			continue
+		}
+		seen[protocol] = true
+		routes = append(routes, r.route(candidate, tokenIn))
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no route found")
+	}
+	return routes, nil
+}
+
+// GetTopRoutesWithFilter returns up to n routes, best first, across all pools,
+// including several pools of the same protocol
+func (r *SimpleRouter) GetTopRoutesWithFilter(ctx context.Context, solClient sol.SolClient, tokenIn string, amountIn math.Int, dexes, excludeDexes []string, minLiquidityUSD float64, n int) ([]Route, error) {
+	candidates, err := r.rankPools(ctx, solClient, tokenIn, amountIn, dexes, excludeDexes, minLiquidityUSD)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []Route
+	for _, candidate := range candidates {
+		if len(routes) >= n {
+			break
+		}
+		if r.fits(ctx, solClient, candidate.pool, tokenIn, amountIn) {
+			routes = append(routes, r.route(candidate, tokenIn))
+		}
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no route found")
+	}
+	return routes, nil
+}
+
+// rankPools quotes the filtered pools concurrently and returns those with
+// output, best ranked first
+func (r *SimpleRouter) rankPools(ctx context.Context, solClient sol.SolClient, tokenIn string, amountIn math.Int, dexes, excludeDexes []string, minLiquidityUSD float64) ([]quoteResult, error) {
+	tokenIn = PoolMint(tokenIn)
 	// Filter pools based on protocol names and liquidity
 	filteredPools := r.filterPools(dexes, excludeDexes, minLiquidityUSD, tokenIn)
 
 	if len(filteredPools) == 0 {
-		return nil, math.ZeroInt(), fmt.Errorf("no pools found after filtering")
-	}
-
-	type quoteResult struct {
-		pool      pkg.Pool
-		outAmount math.Int
-		err       error
+		return nil, fmt.Errorf("%w after filtering", pkg.ErrNoPoolsFound)
 	}
 
 	// Create a channel to collect results
 	resultChan := make(chan quoteResult, len(filteredPools))
 	var wg sync.WaitGroup
 
+	// Limit the quotes in flight when configured
+	var slots chan struct{}
+	if limit := r.config().MaxConcurrency; limit > 0 {
+		slots = make(chan struct{}, limit)
+	}
+
 	// Launch goroutines for each pool
 	for _, pool := range filteredPools {
 		wg.Add(1)
 		go func(p pkg.Pool) {
 			defer wg.Done()
+			if slots != nil {
+				slots <- struct{}{}
+				defer func() { <-slots }()
+			}
 			outAmount, err := p.Quote(ctx, solClient, tokenIn, amountIn)
 			resultChan <- quoteResult{
 				pool:      p,
@@ -85,25 +269,80 @@ func (r *SimpleRouter) GetBestPoolWithFilter(ctx context.Context, solClient *sol
 		close(resultChan)
 	}()
 
-	// Collect results and find the best one
-	var best pkg.Pool
-	maxOut := math.NewInt(0)
-
+	// Collect results ordered by output amount
+	var candidates []quoteResult
+	var errs []error
 	for result := range resultChan {
 		if result.err != nil {
-			log.Printf("error quoting pool %s: %v", result.pool.GetID(), result.err)
+			r.log().Warn("failed to quote pool", "pool", result.pool.GetID(), "error", result.err)
+			errs = append(errs, fmt.Errorf("pool %s: %w", result.pool.GetID(), result.err))
 			continue
 		}
-		if result.outAmount.GT(maxOut) {
-			maxOut = result.outAmount
-			best = result.pool
+		if result.outAmount.IsPositive() {
+			result.ranked = r.rankedOutput(result.pool, result.outAmount)
+			candidates = append(candidates, result)
 		}
 	}
+	if len(candidates) == 0 {
+		// Pools that quoted nothing had no liquidity for the trade
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("no pool quoted output: %w", pkg.ErrInsufficientLiquidity)
+		}
+		return nil, fmt.Errorf("no pool quoted output: %w", errors.Join(errs...))
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ranked.GT(candidates[j].ranked)
+	})
+	return candidates, nil
+}
 
-	if best == nil {
-		return nil, math.ZeroInt(), fmt.Errorf("no route found")
+// fits reports whether a route through pool satisfies the transaction constraints
+func (r *SimpleRouter) fits(ctx context.Context, solClient sol.SolClient, pool pkg.Pool, tokenIn string, amountIn math.Int) bool {
+	if r.MaxAccounts <= 0 && r.MaxTxBytes <= 0 {
+		return true
 	}
-	return best, maxOut, nil
+	estimate, err := r.EstimateRouteSize(ctx, solClient, pool, PoolMint(tokenIn), amountIn)
+	if err != nil {
+		r.log().Warn("skipping pool: cannot estimate transaction size", "pool", pool.GetID(), "error", err)
+		return false
+	}
+	if !estimate.Fits(r.MaxAccounts, r.MaxTxBytes) {
+		r.log().Debug("skipping pool: transaction too large", "pool", pool.GetID(),
+			"accounts", estimate.Accounts, "bytes", estimate.Bytes)
+		return false
+	}
+	return true
+}
+
+// Placeholder accounts used to build swap instructions for size estimation
+var (
+	estimateUser         = solana.PublicKeyFromBytes(bytesOf(1))
+	estimateBaseAccount  = solana.PublicKeyFromBytes(bytesOf(2))
+	estimateQuoteAccount = solana.PublicKeyFromBytes(bytesOf(3))
+)
+
+func bytesOf(b byte) []byte {
+	out := make([]byte, solana.PublicKeyLength)
+	for i := range out {
+		out[i] = b
+	}
+	return out
+}
+
+// EstimateRouteSize builds the pool's swap instructions with placeholder user
+// accounts and estimates the resulting transaction size. Two compute budget
+// instructions are included since executed swaps normally carry them.
+func (r *SimpleRouter) EstimateRouteSize(ctx context.Context, solClient sol.SolClient, pool pkg.Pool, tokenIn string, amountIn math.Int) (sol.TxSizeEstimate, error) {
+	instructions, err := pool.BuildSwapInstructions(ctx, solClient, estimateUser, tokenIn, amountIn, math.ZeroInt(), estimateBaseAccount, estimateQuoteAccount)
+	if err != nil {
+		return sol.TxSizeEstimate{}, err
+	}
+
+	budget := []solana.Instruction{
+		computebudget.NewSetComputeUnitLimitInstruction(0).Build(),
+		computebudget.NewSetComputeUnitPriceInstruction(0).Build(),
+	}
+	return sol.EstimateTransactionSize(estimateUser, append(budget, instructions...))
 }
 
 // getPoolLiquidity estimates the pool liquidity in USD based on reserves
@@ -111,54 +350,34 @@ func (r *SimpleRouter) GetBestPoolWithFilter(ctx context.Context, solClient *sol
 // This works well for WSOL/USDC pairs where USDC ≈ $1
 func getPoolLiquidity(pool pkg.Pool, tokenIn string) float64 {
 	tokenA, _ := pool.GetTokens()
+	reserves := pool.GetReserves()
 
-	// Determine which reserve to check (the output token side)
-	var liquidityRaw math.Int
-
-	// Try to extract reserves based on pool type
-	switch p := pool.(type) {
-	case *raydium.AMMPool:
-		// Raydium AMM pools
-		if tokenA == tokenIn {
-			// Input is tokenA, check tokenB reserve (quote)
-			liquidityRaw = p.QuoteReserve
-		} else {
-			// Input is tokenB, check tokenA reserve (base)
-			liquidityRaw = p.BaseReserve
-		}
-	case *raydium.CPMMPool:
-		// Raydium CPMM pools (same structure as AMM)
-		if tokenA == tokenIn {
-			liquidityRaw = p.QuoteReserve
-		} else {
-			liquidityRaw = p.BaseReserve
-		}
-	case *pump.PumpAMMPool:
-		// Pump AMM pools
-		if tokenA == tokenIn {
-			// Input is tokenA (base), check tokenB (quote)
-			liquidityRaw = p.QuoteAmount
-		} else {
-			// Input is tokenB (quote), check tokenA (base)
-			liquidityRaw = p.BaseAmount
-		}
-	default:
-		// For CLMM/DLMM/Whirlpool pools, we can't easily extract reserves without context
-		// Return a high value to not filter them out for now
-		return 1000000.0
+	// The output token side: quote when the input is the base token
+	liquidityRaw := reserves.Base
+	if tokenA == tokenIn {
+		liquidityRaw = reserves.Quote
 	}
-
 	if liquidityRaw.IsNil() || liquidityRaw.IsZero() {
 		return 0
 	}
 
 	// Convert to float with decimals adjustment (assume 6 decimals for stables/SOL)
-	liquidityFloat := float64(liquidityRaw.Int64()) / float64(1e6)
-	return liquidityFloat
+	liquidityFloat, _ := new(big.Float).SetInt(liquidityRaw.BigInt()).Float64()
+	return liquidityFloat / 1e6
 }
 
-// filterPools filters the pools based on dexes, excludeDexes, and minimum liquidity
+// filterPools filters the pools based on dexes, excludeDexes, and minimum
+// liquidity. The configured exclusions always apply; the configured minimum
+// liquidity applies when minLiquidityUSD is zero.
 func (r *SimpleRouter) filterPools(dexes, excludeDexes []string, minLiquidityUSD float64, tokenIn string) []pkg.Pool {
+	settings := r.config()
+	if len(settings.ExcludeDexes) > 0 {
+		excludeDexes = append(append([]string(nil), excludeDexes...), settings.ExcludeDexes...)
+	}
+	if minLiquidityUSD == 0 {
+		minLiquidityUSD = settings.MinLiquidityUSD
+	}
+
 	// If no filters provided, return all pools
 	if len(dexes) == 0 && len(excludeDexes) == 0 && minLiquidityUSD == 0 {
 		return r.Pools
@@ -201,7 +420,7 @@ func (r *SimpleRouter) filterPools(dexes, excludeDexes []string, minLiquidityUSD
 		if minLiquidityUSD > 0 {
 			liquidity := getPoolLiquidity(pool, tokenIn)
 			if liquidity < minLiquidityUSD {
-				log.Printf("Filtering out pool %s with low liquidity: $%.2f < $%.2f", pool.GetID()[:8], liquidity, minLiquidityUSD)
+				r.log().Debug("filtering out pool with low liquidity", "pool", pool.GetID(), "liquidity_usd", liquidity, "min_liquidity_usd", minLiquidityUSD)
 				continue
 			}
 		}
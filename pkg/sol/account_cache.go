@@ -0,0 +1,155 @@
+package sol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	"soltrading/pkg/logging"
+)
+
+// maxRetryInterval caps how long an AccountCache remembers a failed fetch
+const maxRetryInterval = 10 * time.Second
+
+// AccountCache keeps the data of accounts that rarely change, such as
+// protocol configs and fee tiers, so each is read once per TTL however many
+// pools use it. Concurrent reads of an expired account share one fetch.
+type AccountCache struct {
+	ttl   time.Duration
+	retry time.Duration
+
+	mu      sync.Mutex
+	entries map[solana.PublicKey]*accountCacheEntry
+}
+
+// AccountObserver is implemented by clients that must see every account read
+// through them, such as a snapshot recorder. An AccountCache passes it the
+// data it serves without calling the client.
+type AccountObserver interface {
+	ObserveAccount(address solana.PublicKey, data []byte)
+}
+
+type accountCacheEntry struct {
+	data    []byte
+	err     error
+	fetched time.Time
+	// loading is closed when the fetch in progress finishes
+	loading chan struct{}
+}
+
+// NewAccountCache returns a cache that refetches an account once it is older
+// than ttl. A failed fetch is retried after ttl or 10s, whichever is shorter.
+func NewAccountCache(ttl time.Duration) *AccountCache {
+	return &AccountCache{
+		ttl:     ttl,
+		retry:   min(ttl, maxRetryInterval),
+		entries: make(map[solana.PublicKey]*accountCacheEntry),
+	}
+}
+
+// Get returns the account's data. When a refetch fails and an earlier fetch
+// succeeded, the earlier data is returned and the error logged.
+func (c *AccountCache) Get(ctx context.Context, client SolClient, address solana.PublicKey) ([]byte, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[address]
+	if ok && entry.loading != nil {
+		loading := entry.loading
+		c.mu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return c.Get(ctx, client, address)
+	}
+	if ok && c.fresh(entry) {
+		c.mu.Unlock()
+		if entry.data == nil {
+			return nil, entry.err
+		}
+		observe(client, address, entry.data)
+		return entry.data, nil
+	}
+	loading := make(chan struct{})
+	if !ok {
+		entry = &accountCacheEntry{}
+		c.entries[address] = entry
+	}
+	entry.loading = loading
+	c.mu.Unlock()
+
+	data, err := fetchAccountData(ctx, client, address)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(loading)
+	entry.loading = nil
+	entry.fetched = time.Now()
+	entry.err = err
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up; the next Get shouldn't inherit its failure
+		entry.fetched = time.Time{}
+	}
+	if err != nil {
+		if entry.data != nil {
+			logging.Component(nil, "sol").Warn("failed to refresh account, keeping the cached data", "account", address, "error", err)
+			observe(client, address, entry.data)
+			return entry.data, nil
+		}
+		return nil, err
+	}
+	entry.data = data
+	return data, nil
+}
+
+// fresh reports whether entry can be served without a fetch. c.mu must be held.
+func (c *AccountCache) fresh(entry *accountCacheEntry) bool {
+	age := time.Since(entry.fetched)
+	if entry.err != nil {
+		return age < c.retry
+	}
+	return age < c.ttl
+}
+
+// Set stores data for address as if it had just been fetched, for callers
+// that receive account updates another way
+func (c *AccountCache) Set(address solana.PublicKey, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[address]
+	if !ok {
+		entry = &accountCacheEntry{}
+		c.entries[address] = entry
+	}
+	entry.data, entry.err, entry.fetched = data, nil, time.Now()
+}
+
+// Invalidate makes the next Get of address fetch it again
+func (c *AccountCache) Invalidate(address solana.PublicKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[address]; ok && entry.loading == nil {
+		delete(c.entries, address)
+	}
+}
+
+// observe shows cached data to a client that observes accounts
+func observe(client SolClient, address solana.PublicKey, data []byte) {
+	if observer, ok := client.(AccountObserver); ok {
+		observer.ObserveAccount(address, data)
+	}
+}
+
+func fetchAccountData(ctx context.Context, client SolClient, address solana.PublicKey) ([]byte, error) {
+	info, err := client.GetAccountInfoWithOpts(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account %s: %w", address, err)
+	}
+	if info == nil || info.Value == nil {
+		return nil, fmt.Errorf("account %s not found", address)
+	}
+	return info.Value.Data.GetBinary(), nil
+}
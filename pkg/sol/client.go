@@ -2,29 +2,109 @@ package sol
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"soltrading/pkg/config"
+	"soltrading/pkg/logging"
 )
 
 // Client represents a Solana client that handles both RPC and WebSocket connections
 type Client struct {
+	endpoint    string
 	rpcClient   *rpc.Client
 	jitoClient  *JitoClient
 	rateLimiter *RateLimiter
+	logger      *slog.Logger
+}
+
+// ClientOptions configures how a Client connects to its endpoint
+type ClientOptions struct {
+	Transport TransportConfig
+	// Headers are added to every RPC request, e.g. for providers that
+	// authenticate with a token header instead of a URL key
+	Headers map[string]string
+	// Logger receives the client's logs; nil uses logging.Default()
+	Logger *slog.Logger
+}
+
+// DefaultClientOptions returns the options used by NewClient
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		Transport: DefaultTransportConfig(),
+	}
 }
 
 // NewClient creates a new Solana client with custom rate limiting
 func NewClient(ctx context.Context, endpoint, jitoEndpoint string, reqLimitPerSecond int) (*Client, error) {
 	c := &Client{
+		endpoint:    endpoint,
 		rpcClient:   rpc.New(endpoint),
 		rateLimiter: NewRateLimiter(reqLimitPerSecond),
+		logger:      logging.Component(nil, "sol"),
+	}
+
+	c.attachJito(ctx, jitoEndpoint)
+	return c, nil
+}
+
+// NewClientWithOptions creates a new Solana client using a custom transport
+func NewClientWithOptions(ctx context.Context, endpoint, jitoEndpoint string, reqLimitPerSecond int, opts ClientOptions) (*Client, error) {
+	httpClient, err := opts.Transport.NewHTTPClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build HTTP client: %w", err)
+	}
+
+	rpcClient := jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+		HTTPClient:    httpClient,
+		CustomHeaders: opts.Headers,
+	})
+
+	c := &Client{
+		endpoint:    endpoint,
+		rpcClient:   rpc.NewWithCustomRPCClient(rpcClient),
+		rateLimiter: NewRateLimiter(reqLimitPerSecond),
+		logger:      logging.Component(opts.Logger, "sol"),
 	}
 
+	c.attachJito(ctx, jitoEndpoint)
+	return c, nil
+}
+
+func (c *Client) attachJito(ctx context.Context, jitoEndpoint string) {
 	if jitoEndpoint != "" {
 		jitoClient, err := NewJitoClient(ctx, jitoEndpoint)
 		if err == nil {
+			jitoClient.logger = c.logger
 			c.jitoClient = jitoClient
 		}
 	}
-	return c, nil
+}
+
+// log returns the client's logger, falling back to the default for a client
+// not built by a constructor
+func (c *Client) log() *slog.Logger {
+	if c.logger == nil {
+		return logging.Component(nil, "sol")
+	}
+	return c.logger
+}
+
+// Endpoint returns the RPC endpoint URL this client talks to
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+// ApplyConfig applies the runtime RPC settings
+func (c *Client) ApplyConfig(cfg config.RPCConfig) {
+	if cfg.RateLimit > 0 {
+		c.SetRateLimit(cfg.RateLimit)
+	}
+}
+
+// SetRateLimit changes the client's requests per second
+func (c *Client) SetRateLimit(requestsPerSecond int) {
+	c.rateLimiter.SetRate(requestsPerSecond)
 }
@@ -0,0 +1,68 @@
+package sol
+
+import (
+	"context"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// DataSlice is the byte range of each account a getProgramAccounts scan
+// returns. Filters still match the full account.
+type DataSlice struct {
+	Offset uint64
+	Length uint64
+}
+
+// SliceOf returns the smallest slice holding the fields at the given account
+// offsets, each size bytes long
+func SliceOf(size uint64, offsets ...uint64) DataSlice {
+	if len(offsets) == 0 {
+		return DataSlice{}
+	}
+	first, last := offsets[0], offsets[0]
+	for _, offset := range offsets[1:] {
+		first, last = min(first, offset), max(last, offset)
+	}
+	return DataSlice{Offset: first, Length: last + size - first}
+}
+
+// PubkeySlice returns the slice holding the public keys at the given offsets
+func PubkeySlice(offsets ...uint64) DataSlice {
+	return SliceOf(solana.PublicKeyLength, offsets...)
+}
+
+// Opts returns the slice in the form the RPC options take
+func (s DataSlice) Opts() *rpc.DataSlice {
+	offset, length := s.Offset, s.Length
+	return &rpc.DataSlice{Offset: &offset, Length: &length}
+}
+
+// Field returns the size bytes at account offset from data returned with
+// the slice, or false when the slice doesn't cover them
+func (s DataSlice) Field(data []byte, offset, size uint64) ([]byte, bool) {
+	if offset < s.Offset || offset+size > s.Offset+uint64(len(data)) {
+		return nil, false
+	}
+	start := offset - s.Offset
+	return data[start : start+size], true
+}
+
+// Pubkey returns the public key at account offset from data returned with
+// the slice
+func (s DataSlice) Pubkey(data []byte, offset uint64) (solana.PublicKey, bool) {
+	field, ok := s.Field(data, offset, solana.PublicKeyLength)
+	if !ok {
+		return solana.PublicKey{}, false
+	}
+	return solana.PublicKeyFromBytes(field), true
+}
+
+// GetProgramAccountsSliced scans program for the accounts matching filters
+// and downloads only the slice of each
+func GetProgramAccountsSliced(ctx context.Context, client SolClient, program solana.PublicKey, filters []rpc.RPCFilter, slice DataSlice) (rpc.GetProgramAccountsResult, error) {
+	return client.GetProgramAccountsWithOpts(ctx, program, &rpc.GetProgramAccountsOpts{
+		Filters:   filters,
+		DataSlice: slice.Opts(),
+	})
+}
@@ -4,17 +4,20 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/programs/system"
 	jitorpc "github.com/jito-labs/jito-go-rpc"
+
+	"soltrading/pkg/logging"
 )
 
 type JitoClient struct {
 	rpcClient  *jitorpc.JitoJsonRpcClient
 	tipAccount solana.PublicKey
+	logger     *slog.Logger
 }
 
 // Jito endpoint refer to: https://docs.jito.wtf/lowlatencytxnsend/
@@ -28,6 +31,7 @@ func NewJitoClient(ctx context.Context, endpoint string) (*JitoClient, error) {
 	return &JitoClient{
 		rpcClient:  rpcClient,
 		tipAccount: tipAccountPublicKey,
+		logger:     logging.Component(nil, "sol"),
 	}, nil
 }
 
@@ -65,12 +69,12 @@ func createTipTransaction(privateKey solana.PrivateKey, amount uint64, recentBlo
 	return tx, nil
 }
 
-func encodeTransaction(tx *solana.Transaction) string {
+func encodeTransaction(tx *solana.Transaction) (string, error) {
 	serializedTx, err := tx.MarshalBinary()
 	if err != nil {
-		log.Fatalf("Failed to serialize transaction: %v", err)
+		return "", fmt.Errorf("failed to serialize transaction: %w", err)
 	}
-	return base64.StdEncoding.EncodeToString(serializedTx)
+	return base64.StdEncoding.EncodeToString(serializedTx), nil
 }
 
 func (c *JitoClient) CheckBundleStatus(bundleId string) {
@@ -82,41 +86,35 @@ func (c *JitoClient) CheckBundleStatus(bundleId string) {
 
 		statusResponse, err := c.rpcClient.GetBundleStatuses([]string{bundleId})
 		if err != nil {
-			log.Printf("Attempt %d: Failed to get bundle status: %v", attempt, err)
+			c.logger.Warn("failed to get bundle status", "bundle", bundleId, "attempt", attempt, "error", err)
 			continue
 		}
 
 		if len(statusResponse.Value) == 0 {
-			log.Printf("Attempt %d: No bundle status available", attempt)
+			c.logger.Debug("no bundle status available", "bundle", bundleId, "attempt", attempt)
 			continue
 		}
 
 		bundleStatus := statusResponse.Value[0]
-		log.Printf("Attempt %d: Bundle status: %s", attempt, bundleStatus.ConfirmationStatus)
+		c.logger.Info("bundle status", "bundle", bundleId, "attempt", attempt, "status", bundleStatus.ConfirmationStatus)
 
 		switch bundleStatus.ConfirmationStatus {
-		case "processed":
-			fmt.Println("Bundle has been processed by the cluster. Continuing to poll...")
-		case "confirmed":
-			fmt.Println("Bundle has been confirmed by the cluster. Continuing to poll...")
+		case "processed", "confirmed":
+			continue
 		case "finalized":
-			fmt.Printf("Bundle has been finalized by the cluster in slot %d.\n", bundleStatus.Slot)
-			if bundleStatus.Err.Ok == nil {
-				fmt.Println("Bundle executed successfully.")
-				fmt.Println("Transaction URLs:")
-				for _, txID := range bundleStatus.Transactions {
-					solscanURL := fmt.Sprintf("https://solscan.io/tx/%s", txID)
-					fmt.Printf("- %s\n", solscanURL)
-				}
-			} else {
-				fmt.Printf("Bundle execution failed with error: %v\n", bundleStatus.Err.Ok)
+			// jito-go-rpc drops the bundle's error, so this can't tell a failed
+			// bundle from a successful one; pkg/jito can
+			urls := make([]string, len(bundleStatus.Transactions))
+			for i, txID := range bundleStatus.Transactions {
+				urls[i] = "https://solscan.io/tx/" + txID
 			}
+			c.logger.Info("bundle finalized", "bundle", bundleId, "slot", bundleStatus.Slot, "transactions", urls)
 			return
 		default:
-			fmt.Printf("Unexpected status: %s. Please check the bundle manually.\n", bundleStatus.ConfirmationStatus)
+			c.logger.Warn("unexpected bundle status, check the bundle manually", "bundle", bundleId, "status", bundleStatus.ConfirmationStatus)
 			return
 		}
 	}
 
-	log.Printf("Maximum polling attempts reached. Final status unknown.")
+	c.logger.Warn("maximum polling attempts reached, final bundle status unknown", "bundle", bundleId)
 }
@@ -0,0 +1,106 @@
+package sol
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// maxPrioritizationFeeAccounts is the most accounts getRecentPrioritizationFees accepts
+const maxPrioritizationFeeAccounts = 128
+
+// PriorityFeeEstimate holds percentile compute unit prices (micro-lamports per CU)
+// observed over the recent slots returned by getRecentPrioritizationFees
+type PriorityFeeEstimate struct {
+	Min     uint64 `json:"min"`
+	P25     uint64 `json:"p25"`
+	Median  uint64 `json:"median"`
+	P75     uint64 `json:"p75"`
+	P90     uint64 `json:"p90"`
+	Max     uint64 `json:"max"`
+	Samples int    `json:"samples"`
+}
+
+// Percentile returns the estimate closest to the requested percentile (0-100)
+func (e PriorityFeeEstimate) Percentile(p int) uint64 {
+	switch {
+	case p <= 0:
+		return e.Min
+	case p <= 25:
+		return e.P25
+	case p <= 50:
+		return e.Median
+	case p <= 75:
+		return e.P75
+	case p <= 90:
+		return e.P90
+	default:
+		return e.Max
+	}
+}
+
+// GetRecentPrioritizationFees wraps the RPC call with rate limiting
+func (c *Client) GetRecentPrioritizationFees(ctx context.Context, accounts []solana.PublicKey) ([]rpc.PriorizationFeeResult, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	if len(accounts) > maxPrioritizationFeeAccounts {
+		accounts = accounts[:maxPrioritizationFeeAccounts]
+	}
+	return c.rpcClient.GetRecentPrioritizationFees(ctx, solana.PublicKeySlice(accounts))
+}
+
+// SuggestPriorityFee fetches recent prioritization fees for the given writable
+// accounts and computes percentile-based compute unit prices
+func (c *Client) SuggestPriorityFee(ctx context.Context, writableAccounts []solana.PublicKey) (*PriorityFeeEstimate, error) {
+	fees, err := c.GetRecentPrioritizationFees(ctx, writableAccounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent prioritization fees: %w", err)
+	}
+
+	samples := make([]uint64, 0, len(fees))
+	for _, fee := range fees {
+		samples = append(samples, fee.PrioritizationFee)
+	}
+	return computePriorityFeeEstimate(samples), nil
+}
+
+// WritableAccounts collects the unique writable accounts referenced by a set of
+// instructions, which is what priority fee markets are keyed on
+func WritableAccounts(instructions []solana.Instruction) []solana.PublicKey {
+	seen := make(map[solana.PublicKey]bool)
+	var accounts []solana.PublicKey
+	for _, instr := range instructions {
+		for _, meta := range instr.Accounts() {
+			if meta.IsWritable && !seen[meta.PublicKey] {
+				seen[meta.PublicKey] = true
+				accounts = append(accounts, meta.PublicKey)
+			}
+		}
+	}
+	return accounts
+}
+
+func computePriorityFeeEstimate(samples []uint64) *PriorityFeeEstimate {
+	estimate := &PriorityFeeEstimate{Samples: len(samples)}
+	if len(samples) == 0 {
+		return estimate
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	at := func(p int) uint64 {
+		idx := (len(samples) - 1) * p / 100
+		return samples[idx]
+	}
+
+	estimate.Min = samples[0]
+	estimate.P25 = at(25)
+	estimate.Median = at(50)
+	estimate.P75 = at(75)
+	estimate.P90 = at(90)
+	estimate.Max = samples[len(samples)-1]
+	return estimate
+}
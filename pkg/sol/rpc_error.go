@@ -0,0 +1,66 @@
+package sol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// ErrRateLimited is returned when the RPC endpoint rejected a request for
+// exceeding its rate limit, after any transport retries
+var ErrRateLimited = errors.New("rate limited")
+
+// rpcNodeUnhealthy is the JSON-RPC error code of a node that is behind
+const rpcNodeUnhealthy = -32005
+
+// rpcError marks err as ErrRateLimited when the endpoint answered with HTTP
+// 429 or a JSON-RPC rate limit error, and returns other errors unchanged
+func rpcError(err error) error {
+	if err == nil || errors.Is(err, ErrRateLimited) {
+		return err
+	}
+	var httpErr *jsonrpc.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Code == http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) && (rpcErr.Code == http.StatusTooManyRequests || isRateLimitMessage(rpcErr.Message)) {
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+	return err
+}
+
+// isRateLimitMessage matches the messages providers send with rate limit
+// errors, which don't share an error code
+func isRateLimitMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests")
+}
+
+// isEndpointFailure reports whether err shows the endpoint itself failing: the
+// request never got an answer, was rate limited, got HTTP 5xx or the node
+// said it is unhealthy. Errors about the request, such as a failed preflight
+// simulation, and cancelled requests are not the endpoint's fault.
+func isEndpointFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var httpErr *jsonrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code >= http.StatusInternalServerError
+	}
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == rpcNodeUnhealthy
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
@@ -2,32 +2,96 @@ package sol
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/config"
 )
 
+// DefaultUnhealthyDuration is how long a failing endpoint is left out of
+// broadcasts before it is tried again
+const DefaultUnhealthyDuration = 30 * time.Second
+
 // RPCPool manages multiple RPC endpoints and distributes requests across them
 type RPCPool struct {
-	endpoints []string
-	clients   []*Client
-	index     uint64
-	mu        sync.RWMutex
+	endpoints    []string
+	clients      []*Client
+	unhealthy    map[*Client]time.Time // client -> time it may be tried again
+	unhealthyFor time.Duration
+	index        uint64
+	mu           sync.RWMutex
 }
 
 // NewRPCPool creates a new RPC pool with the given endpoints
 func NewRPCPool(ctx context.Context, endpoints []string, jitoRpc string, reqLimitPerSecond int) (*RPCPool, error) {
+	return newRPCPool(endpoints, func(endpoint string) (*Client, error) {
+		return NewClient(ctx, endpoint, jitoRpc, reqLimitPerSecond)
+	})
+}
+
+// NewRPCPoolWithOptions creates a new RPC pool whose clients share the given options
+func NewRPCPoolWithOptions(ctx context.Context, endpoints []string, jitoRpc string, reqLimitPerSecond int, opts ClientOptions) (*RPCPool, error) {
+	return newRPCPool(endpoints, func(endpoint string) (*Client, error) {
+		return NewClientWithOptions(ctx, endpoint, jitoRpc, reqLimitPerSecond, opts)
+	})
+}
+
+// EndpointConfig describes a single RPC endpoint and the headers it requires
+type EndpointConfig struct {
+	URL     string
+	Headers map[string]string
+}
+
+// NewRPCPoolFromEndpoints creates a new RPC pool where each endpoint may carry its
+// own authentication headers. Headers in opts are applied to every endpoint and
+// are overridden by endpoint-specific ones.
+func NewRPCPoolFromEndpoints(ctx context.Context, endpoints []EndpointConfig, jitoRpc string, reqLimitPerSecond int, opts ClientOptions) (*RPCPool, error) {
+	urls := make([]string, len(endpoints))
+	headersByURL := make(map[string]map[string]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		urls[i] = endpoint.URL
+		headersByURL[endpoint.URL] = endpoint.Headers
+	}
+
+	return newRPCPool(urls, func(endpoint string) (*Client, error) {
+		endpointOpts := opts
+		endpointOpts.Headers = mergeHeaders(opts.Headers, headersByURL[endpoint])
+		return NewClientWithOptions(ctx, endpoint, jitoRpc, reqLimitPerSecond, endpointOpts)
+	})
+}
+
+func mergeHeaders(base, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+func newRPCPool(endpoints []string, newClient func(endpoint string) (*Client, error)) (*RPCPool, error) {
 	if len(endpoints) == 0 {
 		return nil, nil
 	}
 
 	pool := &RPCPool{
-		endpoints: endpoints,
-		clients:   make([]*Client, 0, len(endpoints)),
+		endpoints:    endpoints,
+		clients:      make([]*Client, 0, len(endpoints)),
+		unhealthy:    make(map[*Client]time.Time),
+		unhealthyFor: DefaultUnhealthyDuration,
 	}
 
 	// Create a client for each endpoint
 	for _, endpoint := range endpoints {
-		client, err := NewClient(ctx, endpoint, jitoRpc, reqLimitPerSecond)
+		client, err := newClient(endpoint)
 		if err != nil {
 			return nil, err
 		}
@@ -37,6 +101,13 @@ func NewRPCPool(ctx context.Context, endpoints []string, jitoRpc string, reqLimi
 	return pool, nil
 }
 
+// ApplyConfig applies the runtime RPC settings to every client
+func (p *RPCPool) ApplyConfig(cfg config.RPCConfig) {
+	for _, client := range p.clients {
+		client.ApplyConfig(cfg)
+	}
+}
+
 // GetClient returns the next client in round-robin fashion
 func (p *RPCPool) GetClient() *Client {
 	if len(p.clients) == 0 {
@@ -62,3 +133,116 @@ func (p *RPCPool) GetAllClients() []*Client {
 func (p *RPCPool) Size() int {
 	return len(p.clients)
 }
+
+// SetUnhealthyDuration sets how long MarkUnhealthy excludes a client; marks
+// already made keep their expiry
+func (p *RPCPool) SetUnhealthyDuration(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unhealthyFor = d
+}
+
+// MarkUnhealthy excludes a client from broadcasts until it is marked healthy
+// again or the unhealthy duration passes, after which the next broadcast
+// tries it again
+func (p *RPCPool) MarkUnhealthy(client *Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unhealthy[client] = time.Now().Add(p.unhealthyFor)
+}
+
+// MarkHealthy returns a client to the set used for broadcasts
+func (p *RPCPool) MarkHealthy(client *Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.unhealthy, client)
+}
+
+// isUnhealthy reports whether client's unhealthy mark has yet to expire
+func (p *RPCPool) isUnhealthy(client *Client, now time.Time) bool {
+	until, ok := p.unhealthy[client]
+	return ok && now.Before(until)
+}
+
+// HealthyClients returns the clients that are not currently marked unhealthy.
+// If every client is unhealthy, all clients are returned so callers always have
+// somewhere to send.
+func (p *RPCPool) HealthyClients() []*Client {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	now := time.Now()
+	healthy := make([]*Client, 0, len(p.clients))
+	for _, client := range p.clients {
+		if !p.isUnhealthy(client, now) {
+			healthy = append(healthy, client)
+		}
+	}
+	if len(healthy) == 0 {
+		return p.clients
+	}
+	return healthy
+}
+
+// Stats returns endpoint health and round-robin counters for monitoring
+func (p *RPCPool) Stats() map[string]interface{} {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	now := time.Now()
+	unhealthy := 0
+	for _, client := range p.clients {
+		if p.isUnhealthy(client, now) {
+			unhealthy++
+		}
+	}
+	return map[string]interface{}{
+		"endpoints":          len(p.clients),
+		"unhealthyEndpoints": unhealthy,
+		"clientSelections":   atomic.LoadUint64(&p.index),
+	}
+}
+
+// SendTransaction sends a signed transaction through the pool. With opts.Broadcast
+// set, the transaction is sent to every healthy endpoint concurrently and the first
+// successful signature is returned; endpoints that fail to answer, rate limit or
+// return a server error are marked unhealthy, while those rejecting the
+// transaction itself are not. Otherwise the next client in round-robin order is used.
+func (p *RPCPool) SendTransaction(ctx context.Context, tx *solana.Transaction, opts SendOptions) (solana.Signature, error) {
+	if len(p.clients) == 0 {
+		return solana.Signature{}, fmt.Errorf("rpc pool has no clients")
+	}
+	if !opts.Broadcast {
+		return p.GetClient().SendTransaction(ctx, tx, opts)
+	}
+
+	clients := p.HealthyClients()
+	type sendResult struct {
+		client *Client
+		sig    solana.Signature
+		err    error
+	}
+	results := make(chan sendResult, len(clients))
+	for _, client := range clients {
+		go func(client *Client) {
+			sig, err := client.SendTransaction(ctx, tx, opts)
+			results <- sendResult{client: client, sig: sig, err: err}
+		}(client)
+	}
+
+	var errs []error
+	for range clients {
+		res := <-results
+		if res.err != nil {
+			if isEndpointFailure(res.err) {
+				p.MarkUnhealthy(res.client)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", res.client.Endpoint(), res.err))
+			continue
+		}
+		p.MarkHealthy(res.client)
+		return res.sig, nil
+	}
+
+	return solana.Signature{}, fmt.Errorf("broadcast failed on all %d endpoints: %v", len(clients), errs)
+}
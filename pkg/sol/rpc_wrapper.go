@@ -7,7 +7,8 @@ import (
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
-// RPC wrapper methods with rate limiting
+// RPC wrapper methods with rate limiting. Rate limit errors from the
+// endpoint are returned as ErrRateLimited.
 
 // GetAccountInfoWithOpts wraps the RPC call with rate limiting
 func (c *Client) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
@@ -17,7 +18,8 @@ func (c *Client) GetAccountInfoWithOpts(ctx context.Context, account solana.Publ
 	opts := &rpc.GetAccountInfoOpts{
 		Commitment: rpc.CommitmentProcessed,
 	}
-	return c.rpcClient.GetAccountInfoWithOpts(ctx, account, opts)
+	result, err := c.rpcClient.GetAccountInfoWithOpts(ctx, account, opts)
+	return result, rpcError(err)
 }
 
 // GetMultipleAccountsWithOpts wraps the RPC call with rate limiting
@@ -28,7 +30,8 @@ func (c *Client) GetMultipleAccountsWithOpts(ctx context.Context, accounts []sol
 	opts := &rpc.GetMultipleAccountsOpts{
 		Commitment: rpc.CommitmentProcessed,
 	}
-	return c.rpcClient.GetMultipleAccountsWithOpts(ctx, accounts, opts)
+	result, err := c.rpcClient.GetMultipleAccountsWithOpts(ctx, accounts, opts)
+	return result, rpcError(err)
 }
 
 // GetProgramAccountsWithOpts wraps the RPC call with rate limiting
@@ -36,7 +39,8 @@ func (c *Client) GetProgramAccountsWithOpts(ctx context.Context, programID solan
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
-	return c.rpcClient.GetProgramAccountsWithOpts(ctx, programID, opts)
+	result, err := c.rpcClient.GetProgramAccountsWithOpts(ctx, programID, opts)
+	return result, rpcError(err)
 }
 
 // GetTokenAccountsByOwner wraps the RPC call with rate limiting
@@ -44,7 +48,8 @@ func (c *Client) GetTokenAccountsByOwner(ctx context.Context, owner solana.Publi
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
-	return c.rpcClient.GetTokenAccountsByOwner(ctx, owner, config, opts)
+	result, err := c.rpcClient.GetTokenAccountsByOwner(ctx, owner, config, opts)
+	return result, rpcError(err)
 }
 
 // GetTokenAccountBalance wraps the RPC call with rate limiting
@@ -52,7 +57,8 @@ func (c *Client) GetTokenAccountBalance(ctx context.Context, account solana.Publ
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
-	return c.rpcClient.GetTokenAccountBalance(ctx, account, commitment)
+	result, err := c.rpcClient.GetTokenAccountBalance(ctx, account, commitment)
+	return result, rpcError(err)
 }
 
 // GetBalance wraps the RPC call with rate limiting
@@ -60,7 +66,8 @@ func (c *Client) GetBalance(ctx context.Context, account solana.PublicKey, commi
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
-	return c.rpcClient.GetBalance(ctx, account, commitment)
+	result, err := c.rpcClient.GetBalance(ctx, account, commitment)
+	return result, rpcError(err)
 }
 
 // GetLatestBlockhash wraps the RPC call with rate limiting
@@ -68,7 +75,8 @@ func (c *Client) GetLatestBlockhash(ctx context.Context, commitment rpc.Commitme
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
-	return c.rpcClient.GetLatestBlockhash(ctx, commitment)
+	result, err := c.rpcClient.GetLatestBlockhash(ctx, commitment)
+	return result, rpcError(err)
 }
 
 // SimulateTransaction wraps the RPC call with rate limiting
@@ -76,7 +84,8 @@ func (c *Client) SimulateTransaction(ctx context.Context, tx *solana.Transaction
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
-	return c.rpcClient.SimulateTransaction(ctx, tx)
+	result, err := c.rpcClient.SimulateTransaction(ctx, tx)
+	return result, rpcError(err)
 }
 
 // SendTransactionWithOpts wraps the RPC call with rate limiting
@@ -84,5 +93,66 @@ func (c *Client) SendTransactionWithOpts(ctx context.Context, tx *solana.Transac
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return solana.Signature{}, err
 	}
-	return c.rpcClient.SendTransactionWithOpts(ctx, tx, opts)
+	result, err := c.rpcClient.SendTransactionWithOpts(ctx, tx, opts)
+	return result, rpcError(err)
+}
+
+// GetSignatureStatuses wraps the RPC call with rate limiting
+func (c *Client) GetSignatureStatuses(ctx context.Context, searchTransactionHistory bool, signatures ...solana.Signature) (*rpc.GetSignatureStatusesResult, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	result, err := c.rpcClient.GetSignatureStatuses(ctx, searchTransactionHistory, signatures...)
+	return result, rpcError(err)
+}
+
+// GetBlockHeight wraps the RPC call with rate limiting
+func (c *Client) GetBlockHeight(ctx context.Context, commitment rpc.CommitmentType) (uint64, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return 0, err
+	}
+	result, err := c.rpcClient.GetBlockHeight(ctx, commitment)
+	return result, rpcError(err)
+}
+
+// GetTransaction wraps the RPC call with rate limiting, fetching a confirmed
+// transaction with versioned transaction support
+func (c *Client) GetTransaction(ctx context.Context, signature solana.Signature) (*rpc.GetTransactionResult, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	maxVersion := uint64(0)
+	result, err := c.rpcClient.GetTransaction(ctx, signature, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	return result, rpcError(err)
+}
+
+// GetSlot wraps the RPC call with rate limiting
+func (c *Client) GetSlot(ctx context.Context, commitment rpc.CommitmentType) (uint64, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return 0, err
+	}
+	result, err := c.rpcClient.GetSlot(ctx, commitment)
+	return result, rpcError(err)
+}
+
+// GetSlotLeaders wraps the RPC call with rate limiting
+func (c *Client) GetSlotLeaders(ctx context.Context, start uint64, limit uint64) ([]solana.PublicKey, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	result, err := c.rpcClient.GetSlotLeaders(ctx, start, limit)
+	return result, rpcError(err)
+}
+
+// GetClusterNodes wraps the RPC call with rate limiting
+func (c *Client) GetClusterNodes(ctx context.Context) ([]*rpc.GetClusterNodesResult, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	result, err := c.rpcClient.GetClusterNodes(ctx)
+	return result, rpcError(err)
 }
@@ -4,54 +4,98 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
-func (c *Client) SendTx(ctx context.Context, tx *solana.Transaction) (solana.Signature, error) {
-	// Send transaction with optimized options
-	sig, err := c.SendTransactionWithOpts(
-		ctx, tx,
-		rpc.TransactionOpts{
-			SkipPreflight:       true,
-			PreflightCommitment: rpc.CommitmentProcessed,
-		},
-	)
+// SendOptions controls how a signed transaction is submitted
+type SendOptions struct {
+	// SkipPreflight disables the RPC node's simulation before forwarding
+	SkipPreflight bool
+	// PreflightCommitment is the commitment used for the preflight simulation
+	PreflightCommitment rpc.CommitmentType
+	// MaxRetries is the number of times the RPC node retries forwarding to the leader.
+	// Zero leaves the node's default in place.
+	MaxRetries uint
+	// Broadcast sends the transaction to every healthy endpoint of an RPCPool
+	Broadcast bool
+}
+
+// DefaultSendOptions returns the options used by SendTx
+func DefaultSendOptions() SendOptions {
+	return SendOptions{
+		SkipPreflight:       true,
+		PreflightCommitment: rpc.CommitmentProcessed,
+	}
+}
+
+// SendTransaction sends a signed transaction using the given options.
+// Broadcast has no effect on a single client; use RPCPool.SendTransaction for that.
+func (c *Client) SendTransaction(ctx context.Context, tx *solana.Transaction, opts SendOptions) (solana.Signature, error) {
+	txOpts := rpc.TransactionOpts{
+		SkipPreflight:       opts.SkipPreflight,
+		PreflightCommitment: opts.PreflightCommitment,
+	}
+	if opts.MaxRetries > 0 {
+		maxRetries := opts.MaxRetries
+		txOpts.MaxRetries = &maxRetries
+	}
+	sig, err := c.SendTransactionWithOpts(ctx, tx, txOpts)
 	if err != nil {
 		return solana.Signature{}, fmt.Errorf("failed to send transaction: %w", err)
 	}
 	return sig, nil
 }
 
+// SendTx sends a transaction with preflight checks skipped
+func (c *Client) SendTx(ctx context.Context, tx *solana.Transaction) (solana.Signature, error) {
+	return c.SendTransaction(ctx, tx, DefaultSendOptions())
+}
+
+// SendTxWithJito sends the transaction together with a separate tip transaction as a bundle.
+//
+// Deprecated: use the pkg/jito client, which returns errors instead of exiting and
+// supports region selection and tip instructions inside the swap transaction.
 func (c *Client) SendTxWithJito(ctx context.Context, jitoTipAmount uint64, signers []solana.PrivateKey, mainTx *solana.Transaction) (string, error) {
 
+	if c.jitoClient == nil {
+		return "", fmt.Errorf("no Jito endpoint configured")
+	}
+	if len(signers) == 0 {
+		return "", fmt.Errorf("at least one signer is required")
+	}
+
 	res, err := c.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
 	if err != nil {
-		log.Fatalf("Failed to get blockhash: %v", err)
+		return "", fmt.Errorf("failed to get blockhash: %w", err)
 	}
 
 	tipTx, err := createTipTransaction(signers[0], jitoTipAmount, res.Value.Blockhash, c.jitoClient.tipAccount.String())
 	if err != nil {
-		log.Fatalf("Failed to create tip transaction: %v", err)
+		return "", fmt.Errorf("failed to create tip transaction: %w", err)
 	}
 
-	bundleRequest := [][]string{{
-		encodeTransaction(mainTx),
-		encodeTransaction(tipTx),
-	}}
+	encodedMain, err := encodeTransaction(mainTx)
+	if err != nil {
+		return "", err
+	}
+	encodedTip, err := encodeTransaction(tipTx)
+	if err != nil {
+		return "", err
+	}
+	bundleRequest := [][]string{{encodedMain, encodedTip}}
 
 	bundleIdRaw, err := c.jitoClient.rpcClient.SendBundle(bundleRequest)
 	if err != nil {
-		log.Fatalf("Failed to send bundle: %v", err)
+		return "", fmt.Errorf("failed to send bundle: %w", err)
 	}
 	var bundleId string
 	if err := json.Unmarshal(bundleIdRaw, &bundleId); err != nil {
-		log.Fatalf("Failed to unmarshal bundle ID: %v", err)
+		return "", fmt.Errorf("failed to decode bundle ID: %w", err)
 	}
 
-	fmt.Printf("Bundle sent successfully. Bundle ID: %s\n", bundleId)
+	c.log().Info("bundle sent", "bundle", bundleId)
 	c.jitoClient.CheckBundleStatus(bundleId)
 
 	return bundleId, nil
@@ -0,0 +1,223 @@
+package sol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ErrBlockhashExpired is returned when a transaction did not land before its
+// blockhash expired and no re-signs were left
+var ErrBlockhashExpired = errors.New("transaction blockhash expired before confirmation")
+
+// TxBuilder builds and signs a transaction for the given recent blockhash
+type TxBuilder func(ctx context.Context, blockhash solana.Hash) (*solana.Transaction, error)
+
+// TPUSender submits transactions directly to leaders, bypassing RPC forwarding
+type TPUSender interface {
+	SendTransaction(ctx context.Context, tx *solana.Transaction) error
+}
+
+// SenderOptions configures a Sender
+type SenderOptions struct {
+	Send SendOptions
+	// Commitment the transaction must reach to count as landed
+	Commitment rpc.ConfirmationStatusType
+	// RebroadcastInterval is how often the transaction is re-sent while pending
+	RebroadcastInterval time.Duration
+	// MaxResigns is how many times to fetch a new blockhash and rebuild after expiry
+	MaxResigns int
+}
+
+// DefaultSenderOptions returns options suitable for swaps
+func DefaultSenderOptions() SenderOptions {
+	return SenderOptions{
+		Send: SendOptions{
+			SkipPreflight: true,
+			MaxRetries:    0,
+		},
+		Commitment:          rpc.ConfirmationStatusConfirmed,
+		RebroadcastInterval: 2 * time.Second,
+		MaxResigns:          2,
+	}
+}
+
+// SendResult is the outcome of Sender.Send
+type SendResult struct {
+	Signature solana.Signature
+	// Slot the transaction landed in, zero if it did not land
+	Slot uint64
+	// Err is the on-chain execution error, nil when the transaction succeeded
+	Err interface{}
+	// Broadcasts counts how many times the transaction was sent
+	Broadcasts int
+	// Resigns counts how many times the transaction was rebuilt with a new blockhash
+	Resigns int
+}
+
+// Sender submits transactions, rebroadcasting until they land or their blockhash
+// expires, and optionally rebuilding them with a fresh blockhash on expiry
+type Sender struct {
+	client *Client
+	pool   *RPCPool
+	tpu    TPUSender
+	opts   SenderOptions
+}
+
+// NewSender creates a sender using client for blockhash and status queries
+func NewSender(client *Client, opts SenderOptions) *Sender {
+	return &Sender{client: client, opts: opts}
+}
+
+// WithPool routes sends through an RPC pool, enabling Broadcast in SendOptions
+func (s *Sender) WithPool(pool *RPCPool) *Sender {
+	s.pool = pool
+	return s
+}
+
+// WithTPU additionally submits every broadcast directly to upcoming leaders
+func (s *Sender) WithTPU(tpu TPUSender) *Sender {
+	s.tpu = tpu
+	return s
+}
+
+// Send builds the transaction with a fresh blockhash and drives it to the
+// configured commitment. An on-chain failure is reported in SendResult.Err with
+// a nil error; the error return covers RPC failures and expiry.
+func (s *Sender) Send(ctx context.Context, build TxBuilder) (*SendResult, error) {
+	result := &SendResult{}
+
+	for {
+		blockhash, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentConfirmed)
+		if err != nil {
+			return result, fmt.Errorf("failed to get blockhash: %w", err)
+		}
+
+		tx, err := build(ctx, blockhash.Value.Blockhash)
+		if err != nil {
+			return result, fmt.Errorf("failed to build transaction: %w", err)
+		}
+		if len(tx.Signatures) == 0 {
+			return result, fmt.Errorf("transaction is not signed")
+		}
+		result.Signature = tx.Signatures[0]
+
+		landed, err := s.sendUntilExpired(ctx, tx, blockhash.Value.LastValidBlockHeight, result)
+		if err != nil || landed {
+			return result, err
+		}
+
+		if result.Resigns >= s.opts.MaxResigns {
+			return result, ErrBlockhashExpired
+		}
+		result.Resigns++
+		s.client.log().Info("blockhash expired, re-signing", "signature", result.Signature, "resign", result.Resigns, "max_resigns", s.opts.MaxResigns)
+	}
+}
+
+// sendUntilExpired rebroadcasts tx until it lands or the block height passes
+// lastValidBlockHeight. It reports false only when the signature is unknown
+// after expiry, so the transaction can no longer land and may be re-signed.
+func (s *Sender) sendUntilExpired(ctx context.Context, tx *solana.Transaction, lastValidBlockHeight uint64, result *SendResult) (bool, error) {
+	interval := s.opts.RebroadcastInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.send(ctx, tx); err != nil {
+			s.client.log().Warn("broadcast failed", "signature", result.Signature, "error", err)
+		} else {
+			result.Broadcasts++
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-ticker.C:
+		}
+
+		statuses, err := s.client.GetSignatureStatuses(ctx, false, result.Signature)
+		if err == nil && len(statuses.Value) > 0 && statuses.Value[0] != nil {
+			status := statuses.Value[0]
+			if status.Err != nil {
+				result.Slot = status.Slot
+				result.Err = status.Err
+				return true, nil
+			}
+			if commitmentReached(status.ConfirmationStatus, s.opts.Commitment) {
+				result.Slot = status.Slot
+				return true, nil
+			}
+		}
+
+		height, err := s.client.GetBlockHeight(ctx, rpc.CommitmentConfirmed)
+		if err == nil && height > lastValidBlockHeight {
+			return s.awaitExpired(ctx, ticker, result)
+		}
+	}
+}
+
+// awaitExpired settles a transaction whose blockhash has expired. It may have
+// landed between the last status poll and expiry, or aged out of the recent
+// status cache, so the full history is searched before re-signing: a known
+// signature is polled until it reaches the commitment or fails, and re-signing
+// it would risk executing the swap twice.
+func (s *Sender) awaitExpired(ctx context.Context, ticker *time.Ticker, result *SendResult) (bool, error) {
+	for {
+		statuses, err := s.client.GetSignatureStatuses(ctx, true, result.Signature)
+		if err != nil {
+			return false, fmt.Errorf("failed to check signature %s after blockhash expiry: %w", result.Signature, err)
+		}
+		if len(statuses.Value) == 0 || statuses.Value[0] == nil {
+			return false, nil
+		}
+		status := statuses.Value[0]
+		if status.Err != nil {
+			result.Slot = status.Slot
+			result.Err = status.Err
+			return true, nil
+		}
+		if commitmentReached(status.ConfirmationStatus, s.opts.Commitment) {
+			result.Slot = status.Slot
+			return true, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Sender) send(ctx context.Context, tx *solana.Transaction) (solana.Signature, error) {
+	if s.tpu != nil {
+		if err := s.tpu.SendTransaction(ctx, tx); err != nil {
+			s.client.log().Warn("TPU send failed", "signature", tx.Signatures[0], "error", err)
+		}
+	}
+	if s.pool != nil {
+		return s.pool.SendTransaction(ctx, tx, s.opts.Send)
+	}
+	return s.client.SendTransaction(ctx, tx, s.opts.Send)
+}
+
+// commitmentReached reports whether status is at least the wanted commitment
+func commitmentReached(status, wanted rpc.ConfirmationStatusType) bool {
+	rank := map[rpc.ConfirmationStatusType]int{
+		rpc.ConfirmationStatusProcessed: 1,
+		rpc.ConfirmationStatusConfirmed: 2,
+		rpc.ConfirmationStatusFinalized: 3,
+	}
+	if wanted == "" {
+		wanted = rpc.ConfirmationStatusConfirmed
+	}
+	return rank[status] >= rank[wanted]
+}
@@ -3,7 +3,6 @@ package sol
 import (
 	"context"
 	"fmt"
-	"log"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
@@ -17,7 +16,7 @@ func (c *Client) SignTransaction(ctx context.Context, signers []solana.PrivateKe
 
 	res, err := c.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
 	if err != nil {
-		log.Fatalf("Failed to get blockhash: %v", err)
+		return nil, fmt.Errorf("failed to get blockhash: %w", err)
 	}
 
 	// Create new transaction with all instructions
@@ -0,0 +1,115 @@
+package sol
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// SimulationResult summarises a simulateTransaction run for a swap
+type SimulationResult struct {
+	Success       bool
+	Err           interface{}
+	Logs          []string
+	UnitsConsumed uint64
+	// ReturnData holds the last "Program return:" payload seen in the logs, if any
+	ReturnProgram solana.PublicKey
+	ReturnData    []byte
+	// ExpectedOut is the increase in the output token account balance, when an
+	// output account was supplied. For a system account it is the increase in
+	// lamports, net of the transaction fee when that account pays it.
+	ExpectedOut uint64
+}
+
+// Simulate runs a signed swap transaction through simulateTransaction and reports
+// the compute units consumed and, when outputTokenAccount is non-zero, how many
+// tokens that account would receive. A wallet (system account) may be passed
+// instead to measure native SOL received. The recent blockhash is replaced by the node
+// so stale transactions can still be validated.
+func (c *Client) Simulate(ctx context.Context, tx *solana.Transaction, outputTokenAccount solana.PublicKey) (*SimulationResult, error) {
+	var preAmount uint64
+	var native bool
+	opts := &rpc.SimulateTransactionOpts{
+		Commitment:             rpc.CommitmentProcessed,
+		ReplaceRecentBlockhash: true,
+	}
+
+	if !outputTokenAccount.IsZero() {
+		info, err := c.GetAccountInfoWithOpts(ctx, outputTokenAccount)
+		if err == nil && info != nil && info.Value != nil {
+			native = info.Value.Owner.Equals(solana.SystemProgramID)
+			if native {
+				preAmount = info.Value.Lamports
+			} else {
+				preAmount, _ = TokenAccountAmount(info.Value.Data.GetBinary())
+			}
+		}
+		opts.Accounts = &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: []solana.PublicKey{outputTokenAccount},
+		}
+	}
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := c.rpcClient.SimulateTransactionWithOpts(ctx, tx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate transaction: %w", rpcError(err))
+	}
+	if resp == nil || resp.Value == nil {
+		return nil, fmt.Errorf("empty simulation response")
+	}
+
+	result := &SimulationResult{
+		Success: resp.Value.Err == nil,
+		Err:     resp.Value.Err,
+		Logs:    resp.Value.Logs,
+	}
+	if resp.Value.UnitsConsumed != nil {
+		result.UnitsConsumed = *resp.Value.UnitsConsumed
+	}
+	result.ReturnProgram, result.ReturnData = parseReturnData(resp.Value.Logs)
+
+	if !outputTokenAccount.IsZero() && len(resp.Value.Accounts) > 0 && resp.Value.Accounts[0] != nil {
+		account := resp.Value.Accounts[0]
+		postAmount := account.Lamports
+		var err error
+		if !native {
+			postAmount, err = TokenAccountAmount(account.Data.GetBinary())
+		}
+		if err == nil && postAmount > preAmount {
+			result.ExpectedOut = postAmount - preAmount
+		}
+	}
+
+	return result, nil
+}
+
+// parseReturnData extracts the last "Program return: <program> <base64>" log line
+func parseReturnData(logs []string) (solana.PublicKey, []byte) {
+	const prefix = "Program return: "
+	for i := len(logs) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(logs[i], prefix) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(logs[i], prefix))
+		if len(fields) != 2 {
+			continue
+		}
+		program, err := solana.PublicKeyFromBase58(fields[0])
+		if err != nil {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			continue
+		}
+		return program, data
+	}
+	return solana.PublicKey{}, nil
+}
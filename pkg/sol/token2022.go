@@ -0,0 +1,154 @@
+package sol
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const (
+	// MintSize is the size of a classic SPL Token mint
+	MintSize = 82
+
+	// token2022AccountTypeOffset is where Token-2022 stores the account type,
+	// after a mint padded to the size of a token account, followed by the
+	// TLV-encoded extensions
+	token2022AccountTypeOffset = 165
+	token2022AccountTypeMint   = 1
+
+	// extensionTransferFeeConfig is the TransferFeeConfig extension type
+	extensionTransferFeeConfig = 1
+	transferFeeConfigSize      = 108
+
+	// MintCacheTTL is how long mint accounts read for their extensions are cached
+	MintCacheTTL = 5 * time.Minute
+)
+
+// TransferFee is one Token-2022 transfer fee schedule, in force from Epoch
+type TransferFee struct {
+	Epoch       uint64
+	MaximumFee  uint64
+	BasisPoints uint16
+}
+
+// Fee returns the fee withheld from a transfer of amount: amount * bps /
+// 10000 rounded up, capped at MaximumFee
+func (f TransferFee) Fee(amount uint64) uint64 {
+	if f.BasisPoints == 0 || amount == 0 {
+		return 0
+	}
+	// The product needs 128 bits; Token-2022 caps the rate at 100%
+	hi, lo := bits.Mul64(amount, min(uint64(f.BasisPoints), 10000))
+	lo, carry := bits.Add64(lo, 9999, 0)
+	fee, _ := bits.Div64(hi+carry, lo, 10000)
+	return min(fee, f.MaximumFee)
+}
+
+// TransferFeeConfig is a Token-2022 mint's TransferFeeConfig extension. The
+// newer schedule replaces the older one from its epoch.
+type TransferFeeConfig struct {
+	WithheldAmount uint64
+	Older          TransferFee
+	Newer          TransferFee
+}
+
+// For returns the schedule in force at epoch
+func (c *TransferFeeConfig) For(epoch uint64) TransferFee {
+	if epoch >= c.Newer.Epoch {
+		return c.Newer
+	}
+	return c.Older
+}
+
+// Fee returns the fee withheld from a transfer of amount at epoch
+func (c *TransferFeeConfig) Fee(epoch, amount uint64) uint64 {
+	return c.For(epoch).Fee(amount)
+}
+
+// mintExtension returns the value of a Token-2022 mint extension, or nil when
+// the mint doesn't have it
+func mintExtension(data []byte, extensionType uint16) []byte {
+	if len(data) <= token2022AccountTypeOffset || data[token2022AccountTypeOffset] != token2022AccountTypeMint {
+		return nil
+	}
+	return tlvExtension(data[token2022AccountTypeOffset+1:], extensionType)
+}
+
+// tlvExtension returns the value of an extension in the TLV-encoded
+// extensions that follow a Token-2022 account type, or nil
+func tlvExtension(data []byte, extensionType uint16) []byte {
+	for offset := 0; offset+4 <= len(data); {
+		typ := binary.LittleEndian.Uint16(data[offset : offset+2])
+		length := int(binary.LittleEndian.Uint16(data[offset+2 : offset+4]))
+		offset += 4
+		if typ == 0 || offset+length > len(data) {
+			// Uninitialized: the rest is padding
+			return nil
+		}
+		if typ == extensionType {
+			return data[offset : offset+length]
+		}
+		offset += length
+	}
+	return nil
+}
+
+// ParseTransferFeeConfig returns the TransferFeeConfig of a mint account, or
+// nil for classic SPL mints and Token-2022 mints without a transfer fee
+func ParseTransferFeeConfig(mintData []byte) (*TransferFeeConfig, error) {
+	value := mintExtension(mintData, extensionTransferFeeConfig)
+	if value == nil {
+		return nil, nil
+	}
+	if len(value) < transferFeeConfigSize {
+		return nil, fmt.Errorf("invalid transfer fee config length %d", len(value))
+	}
+	// Skip the config and withdraw authorities
+	value = value[64:]
+	schedule := func(b []byte) TransferFee {
+		return TransferFee{
+			Epoch:       binary.LittleEndian.Uint64(b[0:8]),
+			MaximumFee:  binary.LittleEndian.Uint64(b[8:16]),
+			BasisPoints: binary.LittleEndian.Uint16(b[16:18]),
+		}
+	}
+	return &TransferFeeConfig{
+		WithheldAmount: binary.LittleEndian.Uint64(value[0:8]),
+		Older:          schedule(value[8:26]),
+		Newer:          schedule(value[26:44]),
+	}, nil
+}
+
+// mints caches mint accounts read for their extensions
+var mints = NewAccountCache(MintCacheTTL)
+
+// GetTransferFeeConfig returns a mint's TransferFeeConfig, or nil when
+// transfers of it pay no fee. Mint accounts are cached for MintCacheTTL.
+func GetTransferFeeConfig(ctx context.Context, client SolClient, mint solana.PublicKey) (*TransferFeeConfig, error) {
+	data, err := mints.Get(ctx, client, mint)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTransferFeeConfig(data)
+}
+
+// TransferFeeAt returns the fee withheld from a transfer of amount of mint
+// now, reading the clock only when the mint's fee schedule is changing
+func TransferFeeAt(ctx context.Context, client SolClient, mint solana.PublicKey, amount uint64) (uint64, error) {
+	config, err := GetTransferFeeConfig(ctx, client, mint)
+	if err != nil || config == nil {
+		return 0, err
+	}
+	if config.Older == config.Newer {
+		return config.Newer.Fee(amount), nil
+	}
+	clock, err := client.GetClock(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read epoch for transfer fee: %w", err)
+	}
+	return config.Fee(clock.Epoch, amount), nil
+}
@@ -2,7 +2,6 @@ package sol
 
 import (
 	"context"
-	"log"
 
 	"github.com/gagliardetto/solana-go"
 	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
@@ -18,7 +17,7 @@ func (t *Client) SelectOrCreateSPLTokenAccount(ctx context.Context, privateKey s
 		},
 	)
 	if err != nil {
-		log.Printf("GetTokenAccountsByOwner err: %v", err)
+		t.log().Error("GetTokenAccountsByOwner failed", "error", err)
 		return solana.PublicKey{}, err
 	}
 	if len(acc.Value) > 0 {
@@ -28,7 +27,7 @@ func (t *Client) SelectOrCreateSPLTokenAccount(ctx context.Context, privateKey s
 	// Find ATA address (this will always return a valid PDA)
 	ataAddress, _, err := solana.FindAssociatedTokenAddress(user, tokenMint)
 	if err != nil {
-		log.Printf("FindAssociatedTokenAddress err: %v", err)
+		t.log().Error("FindAssociatedTokenAddress failed", "error", err)
 		return solana.PublicKey{}, err
 	}
 	instructions := make([]solana.Instruction, 0)
@@ -48,12 +47,12 @@ func (t *Client) SelectOrCreateSPLTokenAccount(ctx context.Context, privateKey s
 		signers := []solana.PrivateKey{privateKey}
 		tx, err := t.SignTransaction(ctx, signers, instructions...)
 		if err != nil {
-			log.Printf("Failed to sign transaction: %v", err)
+			t.log().Error("failed to sign transaction", "error", err)
 			return solana.PublicKey{}, err
 		}
 		_, err = t.SendTx(ctx, tx)
 		if err != nil {
-			log.Printf("Failed to send transaction: %v", err)
+			t.log().Error("failed to send transaction", "error", err)
 			return solana.PublicKey{}, err
 		}
 		return ataAddress, nil
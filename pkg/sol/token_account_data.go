@@ -0,0 +1,95 @@
+package sol
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// token2022AccountTypeAccount marks a Token-2022 token account with extensions
+const token2022AccountTypeAccount = 2
+
+// TokenAccountState is the state of a token account
+type TokenAccountState uint8
+
+const (
+	TokenAccountUninitialized TokenAccountState = iota
+	TokenAccountInitialized
+	TokenAccountFrozen
+)
+
+// TokenAccount is the layout SPL Token and Token-2022 accounts share. Token-2022
+// accounts can be longer, the account type and TLV-encoded extensions following
+// the shared 165 bytes.
+type TokenAccount struct {
+	Mint            solana.PublicKey
+	Owner           solana.PublicKey
+	Amount          uint64
+	Delegate        *solana.PublicKey
+	State           TokenAccountState
+	IsNative        bool
+	NativeReserve   uint64 // rent-exempt lamports of a wrapped SOL account
+	DelegatedAmount uint64
+	CloseAuthority  *solana.PublicKey
+
+	extensions []byte
+}
+
+// ParseTokenAccount decodes an SPL Token or Token-2022 account. Data that is
+// too short, or a Token-2022 account of another type such as a mint, is
+// rejected.
+func ParseTokenAccount(data []byte) (*TokenAccount, error) {
+	if uint64(len(data)) < TokenAccountSize {
+		return nil, fmt.Errorf("token account data too short: %d bytes", len(data))
+	}
+	if uint64(len(data)) > TokenAccountSize && data[token2022AccountTypeOffset] != token2022AccountTypeAccount {
+		return nil, fmt.Errorf("not a token account: account type %d", data[token2022AccountTypeOffset])
+	}
+
+	account := &TokenAccount{
+		Mint:            solana.PublicKeyFromBytes(data[0:32]),
+		Owner:           solana.PublicKeyFromBytes(data[32:64]),
+		Amount:          binary.LittleEndian.Uint64(data[64:72]),
+		Delegate:        optionalPubkey(data[72:108]),
+		State:           TokenAccountState(data[108]),
+		DelegatedAmount: binary.LittleEndian.Uint64(data[121:129]),
+		CloseAuthority:  optionalPubkey(data[129:165]),
+	}
+	if binary.LittleEndian.Uint32(data[109:113]) == 1 {
+		account.IsNative = true
+		account.NativeReserve = binary.LittleEndian.Uint64(data[113:121])
+	}
+	if account.State > TokenAccountFrozen {
+		return nil, fmt.Errorf("invalid token account state %d", account.State)
+	}
+	if uint64(len(data)) > TokenAccountSize {
+		account.extensions = data[token2022AccountTypeOffset+1:]
+	}
+	return account, nil
+}
+
+// TokenAccountAmount returns the amount held by an SPL Token or Token-2022
+// account
+func TokenAccountAmount(data []byte) (uint64, error) {
+	account, err := ParseTokenAccount(data)
+	if err != nil {
+		return 0, err
+	}
+	return account.Amount, nil
+}
+
+// Extension returns the value of a Token-2022 account extension, or nil when
+// the account doesn't have it
+func (a *TokenAccount) Extension(extensionType uint16) []byte {
+	return tlvExtension(a.extensions, extensionType)
+}
+
+// optionalPubkey decodes a COption<Pubkey>
+func optionalPubkey(data []byte) *solana.PublicKey {
+	if binary.LittleEndian.Uint32(data[0:4]) != 1 {
+		return nil
+	}
+	key := solana.PublicKeyFromBytes(data[4:36])
+	return &key
+}
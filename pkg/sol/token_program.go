@@ -0,0 +1,30 @@
+package sol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// MintTokenProgram returns the token program that owns mint: the classic SPL
+// Token program or Token-2022
+func MintTokenProgram(ctx context.Context, client SolClient, mint solana.PublicKey) (solana.PublicKey, error) {
+	if mint.Equals(WSOL) {
+		return solana.TokenProgramID, nil
+	}
+
+	info, err := client.GetAccountInfoWithOpts(ctx, mint)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to get mint account %s: %w", mint, err)
+	}
+	if info == nil || info.Value == nil {
+		return solana.PublicKey{}, fmt.Errorf("mint account %s not found", mint)
+	}
+
+	owner := info.Value.Owner
+	if !owner.Equals(solana.TokenProgramID) && !owner.Equals(solana.Token2022ProgramID) {
+		return solana.PublicKey{}, fmt.Errorf("mint %s is owned by %s, not a token program", mint, owner)
+	}
+	return owner, nil
+}
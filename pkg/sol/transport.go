@@ -0,0 +1,193 @@
+package sol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TransportConfig controls the HTTP transport used for RPC calls and the dialer
+// used for WebSocket subscriptions
+type TransportConfig struct {
+	// DialTimeout bounds TCP connect (and the WebSocket handshake)
+	DialTimeout time.Duration
+	// RequestTimeout bounds a whole RPC request including reading the response
+	RequestTimeout time.Duration
+	// TLSHandshakeTimeout bounds the TLS handshake
+	TLSHandshakeTimeout time.Duration
+	// KeepAlive is the TCP keep-alive period; negative disables keep-alives
+	KeepAlive time.Duration
+	// IdleConnTimeout is how long idle pooled connections are kept
+	IdleConnTimeout time.Duration
+	// MaxIdleConnsPerHost limits pooled connections per endpoint
+	MaxIdleConnsPerHost int
+	// ProxyURL is an http://, https:// or socks5:// proxy. When empty the
+	// HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment variables are used.
+	ProxyURL string
+	// Retries is how many times a request that timed out, failed to connect
+	// or got a 429 or 5xx response is retried. RequestTimeout then bounds
+	// each attempt rather than the whole request.
+	Retries int
+	// RetryBackoff is the delay before the first retry, doubled for each
+	// further one (default 250ms)
+	RetryBackoff time.Duration
+}
+
+// DefaultTransportConfig returns the transport settings used when none are given
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		DialTimeout:         10 * time.Second,
+		RequestTimeout:      30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		KeepAlive:           180 * time.Second,
+		IdleConnTimeout:     5 * time.Minute,
+		MaxIdleConnsPerHost: 9,
+	}
+}
+
+// proxyFunc returns the proxy selector for the configured proxy URL
+func (t TransportConfig) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if t.ProxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	proxyURL, err := url.Parse(t.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", t.ProxyURL, err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// NewHTTPClient builds an HTTP client honouring the transport settings
+func (t TransportConfig) NewHTTPClient() (*http.Client, error) {
+	proxy, err := t.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   t.DialTimeout,
+			KeepAlive: t.KeepAlive,
+		}).DialContext,
+		IdleConnTimeout:     t.IdleConnTimeout,
+		MaxIdleConnsPerHost: t.MaxIdleConnsPerHost,
+		TLSHandshakeTimeout: t.TLSHandshakeTimeout,
+		ForceAttemptHTTP2:   true,
+	}
+
+	if t.Retries > 0 {
+		backoff := t.RetryBackoff
+		if backoff <= 0 {
+			backoff = 250 * time.Millisecond
+		}
+		return &http.Client{
+			Transport: &retryTransport{
+				next:    transport,
+				retries: t.Retries,
+				timeout: t.RequestTimeout,
+				backoff: backoff,
+			},
+		}, nil
+	}
+
+	return &http.Client{
+		Timeout:   t.RequestTimeout,
+		Transport: transport,
+	}, nil
+}
+
+// retryTransport retries failed requests with exponential backoff, bounding
+// each attempt by timeout
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+	timeout time.Duration
+	backoff time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body for retry: %w", err)
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		ctx, cancel := req.Context(), context.CancelFunc(func() {})
+		if t.timeout > 0 {
+			ctx, cancel = context.WithTimeout(req.Context(), t.timeout)
+		}
+		resp, err := t.next.RoundTrip(attemptReq.WithContext(ctx))
+
+		retry := attempt < t.retries && req.Context().Err() == nil &&
+			(req.Body == nil || req.GetBody != nil) &&
+			(err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500)
+		if !retry {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			// The attempt's deadline also covers reading the body
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		cancel()
+
+		timer := time.NewTimer(t.backoff << attempt)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// cancelOnClose releases an attempt's context once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// NewWebSocketDialer builds a WebSocket dialer honouring the timeout and proxy settings
+func (t TransportConfig) NewWebSocketDialer() (*websocket.Dialer, error) {
+	proxy, err := t.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	return &websocket.Dialer{
+		Proxy:            proxy,
+		HandshakeTimeout: t.DialTimeout,
+		NetDialContext: (&net.Dialer{
+			Timeout:   t.DialTimeout,
+			KeepAlive: t.KeepAlive,
+		}).DialContext,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package sol
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const (
+	// MaxTransactionSize is the maximum serialized size of a legacy transaction
+	MaxTransactionSize = 1232
+	// MaxTransactionAccounts is the account lock limit per transaction
+	MaxTransactionAccounts = 64
+)
+
+// TxSizeEstimate describes how large a transaction built from a set of instructions would be
+type TxSizeEstimate struct {
+	Bytes      int `json:"bytes"`
+	Accounts   int `json:"accounts"`
+	Signatures int `json:"signatures"`
+}
+
+// Fits reports whether the estimate is within the limits; zero limits default to
+// the protocol maximums
+func (e TxSizeEstimate) Fits(maxAccounts, maxBytes int) bool {
+	if maxAccounts <= 0 {
+		maxAccounts = MaxTransactionAccounts
+	}
+	if maxBytes <= 0 {
+		maxBytes = MaxTransactionSize
+	}
+	return e.Accounts <= maxAccounts && e.Bytes <= maxBytes
+}
+
+// EstimateTransactionSize compiles the instructions into a legacy transaction
+// paid by payer and returns its serialized size (with placeholder signatures)
+// and the number of unique accounts it references
+func EstimateTransactionSize(payer solana.PublicKey, instructions []solana.Instruction) (TxSizeEstimate, error) {
+	tx, err := solana.NewTransaction(instructions, solana.Hash{}, solana.TransactionPayer(payer))
+	if err != nil {
+		return TxSizeEstimate{}, fmt.Errorf("failed to compile transaction: %w", err)
+	}
+
+	numSigners := int(tx.Message.Header.NumRequiredSignatures)
+	tx.Signatures = make([]solana.Signature, numSigners)
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return TxSizeEstimate{}, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+
+	return TxSizeEstimate{
+		Bytes:      len(raw),
+		Accounts:   len(tx.Message.AccountKeys),
+		Signatures: numSigners,
+	}, nil
+}
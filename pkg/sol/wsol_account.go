@@ -2,7 +2,6 @@ package sol
 
 import (
 	"context"
-	"log"
 
 	"github.com/gagliardetto/solana-go"
 	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
@@ -25,7 +24,7 @@ func (t *Client) CoverWsol(ctx context.Context, privateKey solana.PrivateKey, am
 		},
 	)
 	if err != nil {
-		log.Printf("GetTokenAccountsByOwner err: %v", err)
+		t.log().Error("GetTokenAccountsByOwner failed", "error", err)
 		return err
 	}
 	if len(acc.Value) == 0 {
@@ -42,7 +41,7 @@ func (t *Client) CoverWsol(ctx context.Context, privateKey solana.PrivateKey, am
 
 	wsolAccount, _, err := solana.FindAssociatedTokenAddress(user, WSOL)
 	if err != nil {
-		log.Printf("FindAssociatedTokenAddress err: %v", err)
+		t.log().Error("FindAssociatedTokenAddress failed", "error", err)
 		return err
 	}
 
@@ -52,7 +51,7 @@ func (t *Client) CoverWsol(ctx context.Context, privateKey solana.PrivateKey, am
 		wsolAccount,
 	).ValidateAndBuild()
 	if err != nil {
-		log.Printf("NewTransferInstruction err: %v", err)
+		t.log().Error("NewTransferInstruction failed", "error", err)
 		return err
 	}
 	allInstrs = append(allInstrs, transferInst)
@@ -68,12 +67,12 @@ func (t *Client) CoverWsol(ctx context.Context, privateKey solana.PrivateKey, am
 
 	tx, err := t.SignTransaction(ctx, signers, allInstrs...)
 	if err != nil {
-		log.Printf("Failed to sign transaction: %v", err)
+		t.log().Error("failed to sign transaction", "error", err)
 		return err
 	}
 	_, err = t.SendTx(ctx, tx)
 	if err != nil {
-		log.Printf("Failed to send transaction: %v\n", err)
+		t.log().Error("failed to send transaction", "error", err)
 		return err
 	}
 	return nil
@@ -87,7 +86,7 @@ func (t *Client) CloseWsol(ctx context.Context, privateKey solana.PrivateKey) er
 
 	wsolAccount, _, err := solana.FindAssociatedTokenAddress(user, WSOL)
 	if err != nil {
-		log.Printf("FindAssociatedTokenAddress err: %v", err)
+		t.log().Error("FindAssociatedTokenAddress failed", "error", err)
 		return err
 	}
 	closeInst, err := token.NewCloseAccountInstruction(
@@ -97,18 +96,18 @@ func (t *Client) CloseWsol(ctx context.Context, privateKey solana.PrivateKey) er
 		[]solana.PublicKey{},
 	).ValidateAndBuild()
 	if err != nil {
-		log.Printf("CloseAccountInstruction err: %v\n", err)
+		t.log().Error("CloseAccountInstruction failed", "error", err)
 		return err
 	}
 	insts = append(insts, closeInst)
 	tx, err := t.SignTransaction(ctx, signers, insts...)
 	if err != nil {
-		log.Printf("Failed to sign transaction: %v", err)
+		t.log().Error("failed to sign transaction", "error", err)
 		return err
 	}
 	_, err = t.SendTx(ctx, tx)
 	if err != nil {
-		log.Printf("Failed to send transaction: %v\n", err)
+		t.log().Error("failed to send transaction", "error", err)
 		return err
 	}
 	return nil
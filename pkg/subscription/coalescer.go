@@ -0,0 +1,158 @@
+package subscription
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for pool update coalescing
+const (
+	DefaultCoalesceInterval = 100 * time.Millisecond
+	DefaultUpdateWorkers    = 4
+	DefaultUpdateQueueSize  = 1024
+)
+
+// pendingUpdate is the latest not yet delivered update of a pool
+type pendingUpdate struct {
+	data      []byte
+	slot      uint64
+	scheduled bool // queued or waiting for its interval to pass
+	lastRun   time.Time
+	hasUpdate bool
+}
+
+// updateCoalescer delivers pool updates to a handler at most once per interval
+// per pool, always with the latest data, on a bounded queue served by a fixed
+// number of workers. Updates arriving while a pool is waiting are merged.
+type updateCoalescer struct {
+	deliver  func(poolID string, data []byte, slot uint64)
+	interval time.Duration
+	queue    chan string
+
+	mu      sync.Mutex
+	pending map[string]*pendingUpdate
+
+	received  uint64
+	delivered uint64
+	deferred  uint64
+}
+
+func newUpdateCoalescer(ctx context.Context, interval time.Duration, workers, queueSize int, deliver func(poolID string, data []byte, slot uint64)) *updateCoalescer {
+	if workers <= 0 {
+		workers = DefaultUpdateWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultUpdateQueueSize
+	}
+
+	c := &updateCoalescer{
+		deliver:  deliver,
+		interval: interval,
+		queue:    make(chan string, queueSize),
+		pending:  make(map[string]*pendingUpdate),
+	}
+	for i := 0; i < workers; i++ {
+		go c.work(ctx)
+	}
+	return c
+}
+
+// submit records the latest update of a pool and schedules its delivery
+func (c *updateCoalescer) submit(poolID string, data []byte, slot uint64) {
+	atomic.AddUint64(&c.received, 1)
+
+	c.mu.Lock()
+	p, exists := c.pending[poolID]
+	if !exists {
+		p = &pendingUpdate{}
+		c.pending[poolID] = p
+	}
+	p.data = data
+	p.slot = slot
+	p.hasUpdate = true
+	if p.scheduled {
+		c.mu.Unlock()
+		return
+	}
+	p.scheduled = true
+	wait := c.interval - time.Since(p.lastRun)
+	c.mu.Unlock()
+
+	c.schedule(poolID, wait)
+}
+
+// schedule enqueues poolID after wait. When the queue is full the pool is
+// retried after another interval rather than blocking the caller.
+func (c *updateCoalescer) schedule(poolID string, wait time.Duration) {
+	if wait > 0 {
+		time.AfterFunc(wait, func() { c.schedule(poolID, 0) })
+		return
+	}
+
+	select {
+	case c.queue <- poolID:
+	default:
+		atomic.AddUint64(&c.deferred, 1)
+		c.mu.Lock()
+		retry := c.interval
+		c.mu.Unlock()
+		if retry <= 0 {
+			retry = DefaultCoalesceInterval
+		}
+		time.AfterFunc(retry, func() { c.schedule(poolID, 0) })
+	}
+}
+
+func (c *updateCoalescer) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case poolID := <-c.queue:
+			c.mu.Lock()
+			p, exists := c.pending[poolID]
+			if !exists || !p.hasUpdate {
+				if exists {
+					p.scheduled = false
+				}
+				c.mu.Unlock()
+				continue
+			}
+			data, slot := p.data, p.slot
+			p.data = nil
+			p.hasUpdate = false
+			p.scheduled = false
+			p.lastRun = time.Now()
+			c.mu.Unlock()
+
+			c.deliver(poolID, data, slot)
+			atomic.AddUint64(&c.delivered, 1)
+		}
+	}
+}
+
+// setInterval changes the minimum time between deliveries for a pool
+func (c *updateCoalescer) setInterval(interval time.Duration) {
+	c.mu.Lock()
+	c.interval = interval
+	c.mu.Unlock()
+}
+
+// forget drops pending state of a pool, e.g. after it is unsubscribed
+func (c *updateCoalescer) forget(poolID string) {
+	c.mu.Lock()
+	delete(c.pending, poolID)
+	c.mu.Unlock()
+}
+
+// stats returns counters for monitoring
+func (c *updateCoalescer) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"updatesReceived":  atomic.LoadUint64(&c.received),
+		"updatesDelivered": atomic.LoadUint64(&c.delivered),
+		"queueDepth":       len(c.queue),
+		"queueFullRetries": atomic.LoadUint64(&c.deferred),
+	}
+}
@@ -0,0 +1,127 @@
+package subscription
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"soltrading/pkg"
+)
+
+// DefaultEventBuffer is the channel size used when a subscriber passes zero
+const DefaultEventBuffer = 256
+
+// PoolUpdated is emitted after a subscribed pool's cached state changed
+type PoolUpdated struct {
+	PoolID          string
+	Protocol        pkg.ProtocolName
+	Slot            uint64
+	ChangedAccounts []string // accounts updated since the previous event of this pool
+	Time            time.Time
+}
+
+// eventSubscriber is one consumer of the event bus
+type eventSubscriber struct {
+	ch      chan PoolUpdated
+	pools   map[string]bool // nil receives every pool
+	dropped uint64
+}
+
+// EventBus fans pool events out to channel subscribers. Publishing never
+// blocks: a subscriber whose buffer is full misses the event.
+type EventBus struct {
+	mu      sync.RWMutex
+	subs    map[uint64]*eventSubscriber
+	nextID  uint64
+	closed  bool
+	dropped uint64
+}
+
+// NewEventBus creates an empty event bus
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[uint64]*eventSubscriber)}
+}
+
+// Subscribe returns a channel receiving events for the given pools, or for all
+// pools when none are given, and a function that cancels the subscription and
+// closes the channel.
+func (b *EventBus) Subscribe(buffer int, poolIDs ...string) (<-chan PoolUpdated, func()) {
+	if buffer <= 0 {
+		buffer = DefaultEventBuffer
+	}
+
+	sub := &eventSubscriber{ch: make(chan PoolUpdated, buffer)}
+	if len(poolIDs) > 0 {
+		sub.pools = make(map[string]bool, len(poolIDs))
+		for _, poolID := range poolIDs {
+			sub.pools[poolID] = true
+		}
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	b.nextID++
+	id := b.nextID
+	b.subs[id] = sub
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subs[id]; ok {
+				delete(b.subs, id)
+				close(sub.ch)
+			}
+		})
+	}
+	return sub.ch, cancel
+}
+
+// Publish delivers an event to all interested subscribers
+func (b *EventBus) Publish(event PoolUpdated) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subs {
+		if sub.pools != nil && !sub.pools[event.PoolID] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+			atomic.AddUint64(&b.dropped, 1)
+		}
+	}
+}
+
+// Close closes all subscriber channels; later subscriptions get a closed channel
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for id, sub := range b.subs {
+		close(sub.ch)
+		delete(b.subs, id)
+	}
+}
+
+// stats returns counters for monitoring
+func (b *EventBus) stats() map[string]interface{} {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return map[string]interface{}{
+		"eventSubscribers": len(b.subs),
+		"eventsDropped":    atomic.LoadUint64(&b.dropped),
+	}
+}
@@ -0,0 +1,206 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Reasons passed to EvictionHandler
+const (
+	EvictReasonIdle     = "idle"
+	EvictReasonCapacity = "capacity"
+	EvictReasonCache    = "cache"
+)
+
+// ErrSubscriptionLimit is returned when a pool does not fit under the
+// subscription cap even after evicting every evictable pool
+var ErrSubscriptionLimit = errors.New("subscription limit reached")
+
+// idleCheckInterval is how often idle pools are looked for
+const idleCheckInterval = 30 * time.Second
+
+// EvictionHandler is called after the manager unsubscribed a pool on its own,
+// so callers can drop state that depended on its updates
+type EvictionHandler func(poolID string, reason string)
+
+// Touch marks pools as read now, keeping them from being evicted as idle.
+// Pools that are not subscribed are ignored.
+func (sm *SubscriptionManager) Touch(poolIDs ...string) {
+	now := time.Now()
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for _, poolID := range poolIDs {
+		if _, subscribed := sm.subscriptions[poolID]; subscribed {
+			sm.lastRead[poolID] = now
+		}
+	}
+}
+
+// SetIdleTimeout unsubscribes pools not touched for longer than timeout.
+// Zero disables idle eviction.
+func (sm *SubscriptionManager) SetIdleTimeout(timeout time.Duration) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.idleTimeout = timeout
+}
+
+// OnPoolEvicted registers a handler called for every pool the manager evicts
+func (sm *SubscriptionManager) OnPoolEvicted(handler EvictionHandler) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.evictionHandlers = append(sm.evictionHandlers, handler)
+}
+
+// evictIdleLoop periodically evicts idle pools until the manager is closed
+func (sm *SubscriptionManager) evictIdleLoop() {
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sm.ctx.Done():
+			return
+		case now := <-ticker.C:
+			sm.evictIdlePools(now)
+		}
+	}
+}
+
+// evictIdlePools unsubscribes every pool last read before now - idleTimeout
+func (sm *SubscriptionManager) evictIdlePools(now time.Time) {
+	sm.mu.RLock()
+	timeout := sm.idleTimeout
+	var idle []string
+	if timeout > 0 {
+		for poolID, lastRead := range sm.lastRead {
+			if now.Sub(lastRead) > timeout {
+				idle = append(idle, poolID)
+			}
+		}
+	}
+	sm.mu.RUnlock()
+
+	for _, poolID := range idle {
+		sm.logger.Info("evicting idle pool", "pool", poolID, "idle_timeout", timeout)
+		sm.evictPool(poolID, EvictReasonIdle)
+		atomic.AddUint64(&sm.idleEvictions, 1)
+	}
+}
+
+// evictPool unsubscribes a pool and informs eviction handlers
+func (sm *SubscriptionManager) evictPool(poolID, reason string) {
+	if err := sm.UnsubscribePool(poolID); err != nil {
+		// Already gone, e.g. unsubscribed concurrently
+		return
+	}
+
+	sm.mu.RLock()
+	handlers := append([]EvictionHandler(nil), sm.evictionHandlers...)
+	sm.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(poolID, reason)
+	}
+}
+
+// SetMaxSubscriptions caps the number of account subscriptions, as providers
+// limit concurrent subscriptions per connection. Zero means no limit.
+func (sm *SubscriptionManager) SetMaxSubscriptions(max int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.maxSubscriptions = max
+}
+
+// SetPoolPriority sets a pool's eviction priority. When the subscription cap
+// is hit only pools with priority zero or below are evicted, least recently
+// read first. Callers raise the priority of pools backing requested pairs.
+func (sm *SubscriptionManager) SetPoolPriority(poolID string, priority int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if _, subscribed := sm.subscriptions[poolID]; !subscribed {
+		return
+	}
+	if priority == 0 {
+		delete(sm.priorities, poolID)
+		return
+	}
+	sm.priorities[poolID] = priority
+}
+
+// makeRoom evicts pools until needed more account subscriptions fit under the
+// cap, and reserves that room for poolID until subscribeAccount records the
+// accounts, so concurrent subscribes cannot both claim the same free slots.
+// poolID, the pool asking for room, is never evicted.
+func (sm *SubscriptionManager) makeRoom(poolID string, needed int) error {
+	for {
+		sm.mu.Lock()
+		max := sm.maxSubscriptions
+		used := sm.usedSubscriptionsLocked()
+		if max <= 0 || used+needed <= max {
+			if max > 0 {
+				sm.reserved[poolID] += needed
+			}
+			sm.mu.Unlock()
+			return nil
+		}
+
+		// Evict the least recently read evictable pool, then look again.
+		// Pools still being subscribed hold reserved room and are skipped.
+		victim := ""
+		var oldest time.Time
+		for id := range sm.subscriptions {
+			if id == poolID || sm.priorities[id] > 0 || sm.reserved[id] > 0 {
+				continue
+			}
+			if lastRead := sm.lastRead[id]; victim == "" || lastRead.Before(oldest) {
+				victim, oldest = id, lastRead
+			}
+		}
+		sm.mu.Unlock()
+
+		if victim == "" {
+			atomic.AddUint64(&sm.limitRejections, 1)
+			return fmt.Errorf("%w: %d of %d in use, %d needed", ErrSubscriptionLimit, used, max, needed)
+		}
+		sm.logger.Info("evicting pool to stay under the subscription limit", "pool", victim, "max_subscriptions", max)
+		sm.evictPool(victim, EvictReasonCapacity)
+		atomic.AddUint64(&sm.capacityEvictions, 1)
+	}
+}
+
+// usedSubscriptionsLocked counts subscribed accounts plus the room reserved
+// for accounts being subscribed. Callers hold sm.mu.
+func (sm *SubscriptionManager) usedSubscriptionsLocked() int {
+	used := 0
+	for _, accounts := range sm.subscriptions {
+		used += len(accounts)
+	}
+	for _, n := range sm.reserved {
+		used += n
+	}
+	return used
+}
+
+// releaseRoomLocked gives back room makeRoom reserved for accounts that were not
+// subscribed after all. Callers hold sm.mu.
+func (sm *SubscriptionManager) releaseRoomLocked(poolID string, unused int) {
+	if sm.reserved[poolID] -= unused; sm.reserved[poolID] <= 0 {
+		delete(sm.reserved, poolID)
+	}
+}
+
+// SetPoolCacheLimits bounds the pool cache. Pools evicted from the cache are
+// unsubscribed too, since their updates could no longer be applied.
+func (sm *SubscriptionManager) SetPoolCacheLimits(opts PoolCacheOptions) {
+	sm.poolCache.SetOptions(opts)
+}
+
+// handleCacheEviction unsubscribes a pool the cache dropped. It runs on its own
+// goroutine because the cache may evict from the backend's read loop, which
+// must keep running for the unsubscribe to be answered.
+func (sm *SubscriptionManager) handleCacheEviction(poolID string) {
+	go sm.evictPool(poolID, EvictReasonCache)
+}
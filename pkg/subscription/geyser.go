@@ -0,0 +1,414 @@
+package subscription
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"soltrading/pkg/logging"
+)
+
+// geyserSubscribeMethod is the bidirectional streaming method of the Yellowstone Geyser service
+const geyserSubscribeMethod = "/geyser.Geyser/Subscribe"
+
+// geyserSlotFilter is the filter name used for the slot stream
+const geyserSlotFilter = "slots"
+
+// GeyserOptions configures a Yellowstone gRPC connection
+type GeyserOptions struct {
+	// Token is sent as the x-token header, as most Geyser providers require
+	Token string
+	// Insecure disables TLS, for plaintext endpoints such as a local validator plugin
+	Insecure bool
+	// Commitment is "processed", "confirmed" (default) or "finalized"
+	Commitment string
+	// Reconnect configures backoff after the stream fails
+	Reconnect ReconnectOptions
+	// Logger receives the client's logs, and those of a SubscriptionManager
+	// built on it; nil uses logging.Default()
+	Logger *slog.Logger
+}
+
+// GeyserClient streams account and slot updates from a Yellowstone Geyser gRPC
+// endpoint. It implements Backend, so a SubscriptionManager can use it in place
+// of the WebSocket client.
+type GeyserClient struct {
+	conn           *grpc.ClientConn
+	token          string
+	commitment     int32
+	commitmentName string
+
+	mu            sync.RWMutex
+	sendMu        sync.Mutex
+	stream        grpc.ClientStream
+	subscriptions map[uint64]*Subscription
+	handlers      map[uint64]AccountUpdateHandler
+	slotSubs      map[uint64]*SlotSubscription
+	nextID        uint64
+	connected     bool
+
+	currentSlot uint64
+	reconnect   ReconnectOptions
+	state       stateNotifier
+	ctx         context.Context
+	cancel      context.CancelFunc
+	logger      *slog.Logger
+}
+
+// NewGeyserClient connects to a Yellowstone gRPC endpoint (host:port)
+func NewGeyserClient(ctx context.Context, endpoint string, opts GeyserOptions) (*GeyserClient, error) {
+	creds := credentials.NewTLS(&tls.Config{})
+	if opts.Insecure {
+		creds = insecure.NewCredentials()
+	}
+
+	conn, err := grpc.NewClient(endpoint,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(64*1024*1024)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+
+	commitmentName, err := ParseCommitment(opts.Commitment)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	commitment := int32(geyserCommitmentConfirmed)
+	switch commitmentName {
+	case CommitmentProcessed:
+		commitment = geyserCommitmentProcessed
+	case CommitmentFinalized:
+		commitment = geyserCommitmentFinalized
+	}
+
+	clientCtx, cancel := context.WithCancel(ctx)
+	client := &GeyserClient{
+		conn:           conn,
+		token:          opts.Token,
+		commitment:     commitment,
+		commitmentName: commitmentName,
+		subscriptions:  make(map[uint64]*Subscription),
+		handlers:       make(map[uint64]AccountUpdateHandler),
+		slotSubs:       make(map[uint64]*SlotSubscription),
+		nextID:         1,
+		reconnect:      opts.Reconnect,
+		logger:         logging.Component(opts.Logger, "subscription"),
+		ctx:            clientCtx,
+		cancel:         cancel,
+	}
+
+	if err := client.openStream(); err != nil {
+		cancel()
+		conn.Close()
+		return nil, err
+	}
+
+	go client.run()
+
+	return client, nil
+}
+
+// openStream starts a Subscribe stream and sends the current filters
+func (c *GeyserClient) openStream() error {
+	ctx := c.ctx
+	if c.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-token", c.token)
+	}
+
+	stream, err := c.conn.NewStream(ctx, &grpc.StreamDesc{
+		StreamName:    "Subscribe",
+		ServerStreams: true,
+		ClientStreams: true,
+	}, geyserSubscribeMethod, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		return fmt.Errorf("failed to open Geyser stream: %w", err)
+	}
+
+	c.mu.Lock()
+	c.stream = stream
+	c.connected = true
+	c.mu.Unlock()
+	c.logger.Info("Geyser stream connected")
+
+	return c.sendFilters()
+}
+
+// sendFilters sends the full filter set; Geyser replaces all filters on every request
+func (c *GeyserClient) sendFilters() error {
+	c.mu.RLock()
+	req := geyserSubscribeRequest{
+		Accounts:   make(map[string][]string, len(c.subscriptions)),
+		Commitment: c.commitment,
+	}
+	for id, sub := range c.subscriptions {
+		req.Accounts[strconv.FormatUint(id, 10)] = []string{sub.AccountID}
+	}
+	if len(c.slotSubs) > 0 {
+		req.Slots = []string{geyserSlotFilter}
+	}
+	c.mu.RUnlock()
+
+	return c.send(req)
+}
+
+func (c *GeyserClient) send(req geyserSubscribeRequest) error {
+	c.mu.RLock()
+	stream := c.stream
+	c.mu.RUnlock()
+	if stream == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return stream.SendMsg(&rawMessage{data: req.marshal()})
+}
+
+// run reads updates and reopens the stream with exponential backoff after
+// failures until the client is closed or MaxAttempts is exhausted
+func (c *GeyserClient) run() {
+	b := newBackoff(c.reconnect)
+
+	for {
+		err := c.readUpdates()
+		if c.ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("Geyser stream error", "error", err)
+
+		c.mu.Lock()
+		c.connected = false
+		c.stream = nil
+		c.mu.Unlock()
+		c.state.notify(StateDisconnected, err)
+
+		b.reset()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(b.next()):
+			}
+			c.logger.Info("reconnecting Geyser stream", "attempt", b.attempt)
+			if err := c.openStream(); err != nil {
+				c.logger.Warn("Geyser reconnection failed", "attempt", b.attempt, "error", err)
+				if b.exhausted() {
+					c.logger.Error("giving up on Geyser stream", "attempts", b.attempt)
+					c.state.notify(StateFailed, err)
+					return
+				}
+				continue
+			}
+			c.logger.Info("Geyser stream reconnected")
+			c.state.notify(StateConnected, nil)
+			// openStream re-sent the full filter set
+			c.state.notify(StateResubscribed, nil)
+			break
+		}
+	}
+}
+
+func (c *GeyserClient) readUpdates() error {
+	c.mu.RLock()
+	stream := c.stream
+	c.mu.RUnlock()
+
+	for {
+		msg := &rawMessage{}
+		if err := stream.RecvMsg(msg); err != nil {
+			return err
+		}
+
+		update, err := unmarshalGeyserUpdate(msg.data)
+		if err != nil {
+			c.logger.Warn("failed to decode Geyser update", "error", err)
+			continue
+		}
+		c.handleUpdate(update)
+	}
+}
+
+func (c *GeyserClient) handleUpdate(update *geyserUpdate) {
+	switch {
+	case update.Ping:
+		// Providers drop idle streams behind load balancers unless pings are answered
+		if err := c.send(geyserSubscribeRequest{PingID: 1}); err != nil {
+			c.logger.Warn("failed to answer Geyser ping", "error", err)
+		}
+
+	case update.Slot != nil:
+		c.observeSlot(update.Slot.Slot)
+		c.mu.RLock()
+		handlers := make([]SlotUpdateHandler, 0, len(c.slotSubs))
+		for _, sub := range c.slotSubs {
+			if sub.handler != nil {
+				handlers = append(handlers, sub.handler)
+			}
+		}
+		c.mu.RUnlock()
+		for _, handler := range handlers {
+			handler(update.Slot.Slot, update.Slot.Parent, 0)
+		}
+
+	case update.Account != nil:
+		c.observeSlot(update.Account.Slot)
+		accountID := solana.PublicKeyFromBytes(update.Account.Pubkey).String()
+		for _, filter := range update.Filters {
+			id, err := strconv.ParseUint(filter, 10, 64)
+			if err != nil {
+				continue
+			}
+			c.mu.RLock()
+			handler := c.handlers[id]
+			c.mu.RUnlock()
+			if handler != nil {
+				handler(accountID, update.Account.Data, update.Account.Slot)
+			}
+		}
+	}
+}
+
+// SubscribeAccountWithCommitment subscribes to account updates. A Geyser
+// stream has a single commitment, set by GeyserOptions.Commitment, so a
+// different per-account commitment is rejected.
+func (c *GeyserClient) SubscribeAccountWithCommitment(accountID string, commitment string, handler AccountUpdateHandler) (uint64, error) {
+	if commitment != "" && commitment != c.commitmentName {
+		return 0, fmt.Errorf("geyser stream commitment is %s, cannot subscribe %s at %s", c.commitmentName, accountID, commitment)
+	}
+	return c.SubscribeAccount(accountID, handler)
+}
+
+// SubscribeAccount subscribes to account updates
+func (c *GeyserClient) SubscribeAccount(accountID string, handler AccountUpdateHandler) (uint64, error) {
+	c.mu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.subscriptions[id] = &Subscription{ID: id, AccountID: accountID, SubID: id}
+	c.handlers[id] = handler
+	c.mu.Unlock()
+
+	if err := c.sendFilters(); err != nil {
+		c.mu.Lock()
+		delete(c.subscriptions, id)
+		delete(c.handlers, id)
+		c.mu.Unlock()
+		return 0, err
+	}
+	return id, nil
+}
+
+// Unsubscribe removes an account subscription
+func (c *GeyserClient) Unsubscribe(subID uint64) error {
+	c.mu.Lock()
+	if _, exists := c.subscriptions[subID]; !exists {
+		c.mu.Unlock()
+		return fmt.Errorf("subscription not found: %d", subID)
+	}
+	delete(c.subscriptions, subID)
+	delete(c.handlers, subID)
+	c.mu.Unlock()
+
+	return c.sendFilters()
+}
+
+// SubscribeSlot subscribes to slot updates; a nil handler just enables slot tracking
+func (c *GeyserClient) SubscribeSlot(handler SlotUpdateHandler) (uint64, error) {
+	c.mu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.slotSubs[id] = &SlotSubscription{ID: id, SubID: id, handler: handler}
+	c.mu.Unlock()
+
+	if err := c.sendFilters(); err != nil {
+		c.mu.Lock()
+		delete(c.slotSubs, id)
+		c.mu.Unlock()
+		return 0, err
+	}
+	return id, nil
+}
+
+// CurrentSlot returns the latest slot seen on the stream, zero if none yet
+func (c *GeyserClient) CurrentSlot() uint64 {
+	return atomic.LoadUint64(&c.currentSlot)
+}
+
+func (c *GeyserClient) observeSlot(slot uint64) {
+	for {
+		current := atomic.LoadUint64(&c.currentSlot)
+		if slot <= current || atomic.CompareAndSwapUint64(&c.currentSlot, current, slot) {
+			return
+		}
+	}
+}
+
+// OnStateChange registers a handler for connection state changes
+func (c *GeyserClient) OnStateChange(handler ConnectionStateHandler) {
+	c.state.add(handler)
+}
+
+// Logger returns the logger the client writes to
+func (c *GeyserClient) Logger() *slog.Logger {
+	return c.logger
+}
+
+// State returns the current connection state
+func (c *GeyserClient) State() ConnectionState {
+	return c.state.current()
+}
+
+// IsConnected returns whether the stream is open
+func (c *GeyserClient) IsConnected() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.connected
+}
+
+// Close ends the stream and closes the gRPC connection
+func (c *GeyserClient) Close() error {
+	c.cancel()
+	return c.conn.Close()
+}
+
+// rawMessage carries pre-encoded protobuf bytes through gRPC
+type rawMessage struct {
+	data []byte
+}
+
+// rawCodec passes rawMessage bytes through unchanged, letting the client talk
+// to Geyser without generated protobuf types
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(*rawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return msg.data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(*rawMessage)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	msg.data = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,197 @@
+package subscription
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Minimal protobuf encoding of the Yellowstone geyser.proto messages used by
+// GeyserClient. Only the fields needed for account and slot streams are
+// handled; unknown fields are skipped when decoding.
+
+// Geyser commitment levels (geyser.CommitmentLevel)
+const (
+	geyserCommitmentProcessed = 0
+	geyserCommitmentConfirmed = 1
+	geyserCommitmentFinalized = 2
+)
+
+// geyserSubscribeRequest is geyser.SubscribeRequest restricted to accounts and slots
+type geyserSubscribeRequest struct {
+	// Accounts maps filter names to account addresses
+	Accounts   map[string][]string
+	Slots      []string
+	Commitment int32
+	// PingID, when non-zero, makes this a keepalive ping instead of a filter update
+	PingID int32
+}
+
+func (r geyserSubscribeRequest) marshal() []byte {
+	var b []byte
+	if r.PingID != 0 {
+		// SubscribeRequest.ping = 9 { id = 1 }
+		var ping []byte
+		ping = protowire.AppendTag(ping, 1, protowire.VarintType)
+		ping = protowire.AppendVarint(ping, uint64(r.PingID))
+		b = protowire.AppendTag(b, 9, protowire.BytesType)
+		return protowire.AppendBytes(b, ping)
+	}
+
+	// SubscribeRequest.accounts = 1, map<string, SubscribeRequestFilterAccounts{account = 2}>
+	for name, accounts := range r.Accounts {
+		var filter []byte
+		for _, account := range accounts {
+			filter = protowire.AppendTag(filter, 2, protowire.BytesType)
+			filter = protowire.AppendString(filter, account)
+		}
+		b = appendMapEntry(b, 1, name, filter)
+	}
+
+	// SubscribeRequest.slots = 2, map<string, SubscribeRequestFilterSlots{}>
+	for _, name := range r.Slots {
+		b = appendMapEntry(b, 2, name, nil)
+	}
+
+	// SubscribeRequest.commitment = 6
+	b = protowire.AppendTag(b, 6, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(r.Commitment))
+	return b
+}
+
+// appendMapEntry encodes one map<string, message> entry
+func appendMapEntry(b []byte, field protowire.Number, key string, value []byte) []byte {
+	var entry []byte
+	entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+	entry = protowire.AppendString(entry, key)
+	entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+	entry = protowire.AppendBytes(entry, value)
+
+	b = protowire.AppendTag(b, field, protowire.BytesType)
+	return protowire.AppendBytes(b, entry)
+}
+
+// geyserUpdate is the decoded subset of geyser.SubscribeUpdate
+type geyserUpdate struct {
+	Filters []string
+	Account *geyserAccountUpdate
+	Slot    *geyserSlotUpdate
+	Ping    bool
+}
+
+type geyserAccountUpdate struct {
+	Pubkey []byte
+	Data   []byte
+	Slot   uint64
+}
+
+type geyserSlotUpdate struct {
+	Slot   uint64
+	Parent uint64
+	Status uint64
+}
+
+func unmarshalGeyserUpdate(b []byte) (*geyserUpdate, error) {
+	update := &geyserUpdate{}
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			update.Filters = append(update.Filters, string(value))
+		case num == 2 && typ == protowire.BytesType:
+			account, err := unmarshalGeyserAccount(value)
+			if err != nil {
+				return err
+			}
+			update.Account = account
+		case num == 3 && typ == protowire.BytesType:
+			slot, err := unmarshalGeyserSlot(value)
+			if err != nil {
+				return err
+			}
+			update.Slot = slot
+		case num == 6:
+			update.Ping = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode geyser update: %w", err)
+	}
+	return update, nil
+}
+
+// unmarshalGeyserAccount decodes SubscribeUpdateAccount{account = 1, slot = 2}
+// with SubscribeUpdateAccountInfo{pubkey = 1, data = 6}
+func unmarshalGeyserAccount(b []byte) (*geyserAccountUpdate, error) {
+	account := &geyserAccountUpdate{}
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return walkFields(value, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					account.Pubkey = value
+				case num == 6 && typ == protowire.BytesType:
+					account.Data = value
+				}
+				return nil
+			})
+		case num == 2 && typ == protowire.VarintType:
+			account.Slot = varint
+		}
+		return nil
+	})
+	return account, err
+}
+
+// unmarshalGeyserSlot decodes SubscribeUpdateSlot{slot = 1, parent = 2, status = 3}
+func unmarshalGeyserSlot(b []byte) (*geyserSlotUpdate, error) {
+	slot := &geyserSlotUpdate{}
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, _ []byte, varint uint64) error {
+		if typ != protowire.VarintType {
+			return nil
+		}
+		switch num {
+		case 1:
+			slot.Slot = varint
+		case 2:
+			slot.Parent = varint
+		case 3:
+			slot.Status = varint
+		}
+		return nil
+	})
+	return slot, err
+}
+
+// walkFields calls fn for every field in b. value is set for length-delimited
+// fields and varint for varint fields; other wire types are skipped.
+func walkFields(b []byte, fn func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var value []byte
+		var varint uint64
+		switch typ {
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(num, typ, value, varint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,147 +2,430 @@ package subscription
 
 import (
 	"context"
-	"encoding/base64"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 	"soltrading/pkg"
+	"soltrading/pkg/config"
+	"soltrading/pkg/logging"
 )
 
 // PoolUpdateHandler is called when a pool's state is updated
 type PoolUpdateHandler func(poolID string, data []byte, slot uint64)
 
+// Backend is a source of account and slot updates. *WebSocketClient and
+// *GeyserClient implement it.
+type Backend interface {
+	SubscribeAccount(accountID string, handler AccountUpdateHandler) (uint64, error)
+	SubscribeAccountWithCommitment(accountID string, commitment string, handler AccountUpdateHandler) (uint64, error)
+	Unsubscribe(subID uint64) error
+	SubscribeSlot(handler SlotUpdateHandler) (uint64, error)
+	CurrentSlot() uint64
+	IsConnected() bool
+	OnStateChange(handler ConnectionStateHandler)
+	State() ConnectionState
+	Close() error
+}
+
+var (
+	_ Backend = (*WebSocketClient)(nil)
+	_ Backend = (*GeyserClient)(nil)
+)
+
 // SubscriptionManager manages pool account subscriptions
 type SubscriptionManager struct {
-	wsClient      *WebSocketClient
+	backend       Backend
 	poolCache     *PoolCache
-	subscriptions map[string]uint64 // poolID -> subscription ID
+	subscriptions map[string]map[string]uint64 // poolID -> account -> subscription ID
 	handlers      map[string]PoolUpdateHandler
-	mu            sync.RWMutex
-	ctx           context.Context
-	cancel        context.CancelFunc
+	commitments   map[string]string // poolID -> commitment, absent for the backend default
+	coalescer     *updateCoalescer
+	events        *EventBus
+	changed       map[string]map[string]bool // poolID -> accounts updated since the last event
+	lastRead      map[string]time.Time       // poolID -> last Touch or subscription time
+
+	idleTimeout       time.Duration
+	maxSubscriptions  int
+	priorities        map[string]int // poolID -> eviction priority, absent for zero
+	reserved          map[string]int // poolID -> room makeRoom granted for accounts not subscribed yet
+	evictionHandlers  []EvictionHandler
+	idleEvictions     uint64
+	capacityEvictions uint64
+	limitRejections   uint64
+
+	staleRefreshes       uint64 // accounts re-read over RPC by the stale refresh
+	resubscribedAccounts uint64 // dropped accounts subscribed again by the stale refresh
+
+	logger *slog.Logger
+	mu     sync.RWMutex
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewSubscriptionManager creates a new subscription manager
 func NewSubscriptionManager(ctx context.Context, wsURL string) (*SubscriptionManager, error) {
-	managerCtx, cancel := context.WithCancel(ctx)
+	return NewSubscriptionManagerWithOptions(ctx, wsURL, Options{})
+}
 
+// NewSubscriptionManagerWithOptions creates a new subscription manager whose
+// WebSocket connection uses the given options
+func NewSubscriptionManagerWithOptions(ctx context.Context, wsURL string, opts Options) (*SubscriptionManager, error) {
 	// Create WebSocket client
-	wsClient, err := NewWebSocketClient(managerCtx, wsURL)
+	wsClient, err := NewWebSocketClientWithOptions(ctx, wsURL, opts)
 	if err != nil {
-		cancel()
 		return nil, fmt.Errorf("failed to create WebSocket client: %w", err)
 	}
 
+	return NewSubscriptionManagerWithBackend(ctx, wsClient), nil
+}
+
+// NewSubscriptionManagerWithBackend creates a subscription manager on top of an
+// existing backend, e.g. a GeyserClient. The manager closes the backend on Close,
+// and logs to the backend's logger when it has one, as the built-in ones do.
+func NewSubscriptionManagerWithBackend(ctx context.Context, backend Backend) *SubscriptionManager {
+	managerCtx, cancel := context.WithCancel(ctx)
+
+	logger := logging.Component(nil, "subscription")
+	if b, ok := backend.(interface{ Logger() *slog.Logger }); ok && b.Logger() != nil {
+		logger = b.Logger()
+	}
+
 	// Create pool cache
 	poolCache := NewPoolCache()
+	poolCache.logger = logger
 
 	manager := &SubscriptionManager{
-		wsClient:      wsClient,
+		backend:       backend,
 		poolCache:     poolCache,
-		subscriptions: make(map[string]uint64),
+		subscriptions: make(map[string]map[string]uint64),
 		handlers:      make(map[string]PoolUpdateHandler),
+		commitments:   make(map[string]string),
+		events:        NewEventBus(),
+		changed:       make(map[string]map[string]bool),
+		lastRead:      make(map[string]time.Time),
+		priorities:    make(map[string]int),
+		reserved:      make(map[string]int),
+		logger:        logger,
 		ctx:           managerCtx,
 		cancel:        cancel,
 	}
+	manager.coalescer = newUpdateCoalescer(managerCtx, DefaultCoalesceInterval, DefaultUpdateWorkers, DefaultUpdateQueueSize, manager.deliverUpdate)
+
+	poolCache.OnEvict(manager.handleCacheEviction)
+	go manager.evictIdleLoop()
+
+	// Track the current slot so cached pool state can report its age
+	if _, err := backend.SubscribeSlot(nil); err != nil {
+		logger.Warn("failed to subscribe to slots", "error", err)
+	}
 
-	return manager, nil
+	return manager
 }
 
-// SubscribePool subscribes to updates for a specific pool
+// SubscribePool subscribes to updates for a specific pool at the backend's
+// default commitment
 func (sm *SubscriptionManager) SubscribePool(pool pkg.Pool) error {
+	return sm.SubscribePoolWithCommitment(pool, "")
+}
+
+// SubscribePoolWithCommitment subscribes to updates for a pool at the given
+// commitment ("processed", "confirmed" or "finalized"); empty uses the
+// backend's default. Accounts added later, e.g. tick arrays, use the same level.
+func (sm *SubscriptionManager) SubscribePoolWithCommitment(pool pkg.Pool, commitment string) error {
+	return sm.subscribePool(pool, commitment, 0)
+}
+
+// subscribePool subscribes a pool whose state was read at slot; zero means
+// the state was fetched just now
+func (sm *SubscriptionManager) subscribePool(pool pkg.Pool, commitment string, slot uint64) error {
 	poolID := pool.GetID()
+	if commitment != "" {
+		if _, err := ParseCommitment(commitment); err != nil {
+			return err
+		}
+	}
 
 	sm.mu.Lock()
-	// Check if already subscribed
+	// Check if already subscribed; reserve the pool so concurrent calls don't double subscribe
 	if _, exists := sm.subscriptions[poolID]; exists {
 		sm.mu.Unlock()
 		return nil
 	}
+	sm.subscriptions[poolID] = make(map[string]uint64)
+	sm.lastRead[poolID] = time.Now()
+	if commitment != "" {
+		sm.commitments[poolID] = commitment
+	}
 	sm.mu.Unlock()
 
 	// Get pool account addresses to subscribe to
 	accounts := sm.getPoolAccounts(pool)
 	if len(accounts) == 0 {
+		sm.mu.Lock()
+		delete(sm.subscriptions, poolID)
+		sm.mu.Unlock()
 		return fmt.Errorf("no accounts to subscribe for pool %s", poolID)
 	}
 
-	log.Printf("Subscribing to %d accounts for pool %s", len(accounts), poolID)
+	if err := sm.makeRoom(poolID, len(accounts)); err != nil {
+		sm.mu.Lock()
+		delete(sm.subscriptions, poolID)
+		delete(sm.commitments, poolID)
+		delete(sm.lastRead, poolID)
+		sm.mu.Unlock()
+		return fmt.Errorf("failed to subscribe pool %s: %w", poolID, err)
+	}
+
+	sm.logger.Info("subscribing pool", "pool", poolID, "accounts", len(accounts))
 
-	// Subscribe to each account
+	// Subscribe to each account; a pool with a missing account would quote
+	// from stale state, so roll back on the first failure
 	for _, account := range accounts {
-		handler := func(accountID string, data []byte, slot uint64) {
-			sm.handleAccountUpdate(poolID, accountID, data, slot)
+		if err := sm.subscribeAccount(poolID, account); err != nil {
+			sm.UnsubscribePool(poolID)
+			return fmt.Errorf("failed to subscribe pool %s: %w", poolID, err)
 		}
+	}
 
-		subID, err := sm.wsClient.SubscribeAccount(account, handler)
-		if err != nil {
-			log.Printf("Failed to subscribe to account %s for pool %s: %v", account, poolID, err)
-			continue
-		}
+	// Initialize pool in cache at the slot its state was read
+	if slot == 0 {
+		slot = sm.backend.CurrentSlot()
+	}
+	sm.poolCache.SetPoolAtSlot(poolID, pool, slot)
 
-		sm.mu.Lock()
-		sm.subscriptions[account] = subID
-		sm.mu.Unlock()
+	return nil
+}
+
+// subscribeAccount subscribes to one account on behalf of a pool
+func (sm *SubscriptionManager) subscribeAccount(poolID, account string) error {
+	handler := func(accountID string, data []byte, slot uint64) {
+		sm.handleAccountUpdate(poolID, accountID, data, slot)
+	}
 
-		log.Printf("Subscribed to account %s (subID: %d) for pool %s", account, subID, poolID)
+	sm.mu.RLock()
+	commitment := sm.commitments[poolID]
+	sm.mu.RUnlock()
+
+	subID, err := sm.backend.SubscribeAccountWithCommitment(account, commitment, handler)
+	if err != nil {
+		return err
 	}
 
-	// Initialize pool in cache
-	sm.poolCache.SetPool(poolID, pool)
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	accounts, exists := sm.subscriptions[poolID]
+	if !exists {
+		// The pool was unsubscribed meanwhile
+		sm.backend.Unsubscribe(subID)
+		return nil
+	}
+	accounts[account] = subID
+	if sm.reserved[poolID] > 0 {
+		sm.releaseRoomLocked(poolID, 1)
+	}
 
+	sm.logger.Debug("subscribed to account", "account", account, "subscription", subID, "pool", poolID)
 	return nil
 }
 
-// UnsubscribePool unsubscribes from a pool's updates
-func (sm *SubscriptionManager) UnsubscribePool(poolID string) error {
+// syncPoolAccounts re-reads the accounts a pool depends on, e.g. after its price
+// moved into other tick or bin arrays, and adjusts the subscriptions to match
+func (sm *SubscriptionManager) syncPoolAccounts(poolID string) {
+	pool, exists := sm.poolCache.GetPool(poolID)
+	if !exists {
+		return
+	}
+	if _, ok := pool.(AuxiliaryAccountsPool); !ok {
+		return
+	}
+
+	wanted := make(map[string]bool)
+	for _, account := range sm.getPoolAccounts(pool) {
+		wanted[account] = true
+	}
+
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	current, subscribed := sm.subscriptions[poolID]
+	if !subscribed {
+		sm.mu.Unlock()
+		return
+	}
+	var added []string
+	for account := range wanted {
+		if _, ok := current[account]; !ok {
+			added = append(added, account)
+		}
+	}
+	removed := make(map[string]uint64)
+	for account, subID := range current {
+		if !wanted[account] {
+			removed[account] = subID
+			delete(current, account)
+		}
+	}
+	sm.mu.Unlock()
 
-	// Find all subscriptions for this pool
-	var subsToRemove []string
-	for account, subID := range sm.subscriptions {
-		// Unsubscribe
-		if err := sm.wsClient.Unsubscribe(subID); err != nil {
-			log.Printf("Failed to unsubscribe from %s: %v", account, err)
+	for account, subID := range removed {
+		if err := sm.backend.Unsubscribe(subID); err != nil {
+			sm.logger.Warn("failed to unsubscribe from account", "account", account, "pool", poolID, "error", err)
+		}
+	}
+	if len(added) > 0 {
+		if err := sm.makeRoom(poolID, len(added)); err != nil {
+			sm.logger.Warn("not following new pool accounts", "pool", poolID, "accounts", len(added), "error", err)
+			return
 		}
-		subsToRemove = append(subsToRemove, account)
+	}
+	for _, account := range added {
+		if err := sm.subscribeAccount(poolID, account); err != nil {
+			sm.logger.Warn("failed to subscribe to account", "account", account, "pool", poolID, "error", err)
+			sm.mu.Lock()
+			sm.releaseRoomLocked(poolID, 1)
+			sm.mu.Unlock()
+		}
+	}
+}
+
+// UnsubscribePool unsubscribes from a pool's updates, leaving other pools untouched
+func (sm *SubscriptionManager) UnsubscribePool(poolID string) error {
+	sm.mu.Lock()
+	accounts, exists := sm.subscriptions[poolID]
+	delete(sm.subscriptions, poolID)
+	delete(sm.handlers, poolID)
+	delete(sm.commitments, poolID)
+	delete(sm.changed, poolID)
+	delete(sm.lastRead, poolID)
+	delete(sm.priorities, poolID)
+	delete(sm.reserved, poolID)
+	sm.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("pool %s is not subscribed", poolID)
 	}
 
-	// Remove from tracking
-	for _, account := range subsToRemove {
-		delete(sm.subscriptions, account)
+	for account, subID := range accounts {
+		if err := sm.backend.Unsubscribe(subID); err != nil {
+			sm.logger.Warn("failed to unsubscribe from account", "account", account, "pool", poolID, "error", err)
+		}
 	}
 
 	// Remove from cache
 	sm.poolCache.RemovePool(poolID)
+	sm.coalescer.forget(poolID)
 
 	return nil
 }
 
-// handleAccountUpdate processes account updates from WebSocket
-func (sm *SubscriptionManager) handleAccountUpdate(poolID, accountID string, base64Data []byte, slot uint64) {
-	// Decode base64 data
-	data, err := base64.StdEncoding.DecodeString(string(base64Data))
-	if err != nil {
-		log.Printf("Failed to decode account data for %s: %v", accountID, err)
-		return
+// IsSubscribed reports whether the pool has active subscriptions
+func (sm *SubscriptionManager) IsSubscribed(poolID string) bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	_, exists := sm.subscriptions[poolID]
+	return exists
+}
+
+// PoolAccounts returns the accounts subscribed for a pool
+func (sm *SubscriptionManager) PoolAccounts(poolID string) []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	accounts := make([]string, 0, len(sm.subscriptions[poolID]))
+	for account := range sm.subscriptions[poolID] {
+		accounts = append(accounts, account)
 	}
+	return accounts
+}
 
+// handleAccountUpdate processes account updates from the backend
+func (sm *SubscriptionManager) handleAccountUpdate(poolID, accountID string, data []byte, slot uint64) {
 	// Update pool cache with new data
 	if err := sm.poolCache.UpdatePoolAccount(poolID, accountID, data, slot); err != nil {
-		log.Printf("Failed to update pool %s account %s: %v", poolID, accountID, err)
+		if errors.Is(err, ErrStaleUpdate) {
+			return
+		}
+		sm.logger.Warn("failed to update pool account", "pool", poolID, "account", accountID, "error", err)
 		return
 	}
 
-	// Call custom handler if registered
-	sm.mu.RLock()
-	if handler, exists := sm.handlers[poolID]; exists {
+	sm.mu.Lock()
+	if _, subscribed := sm.subscriptions[poolID]; subscribed {
+		if sm.changed[poolID] == nil {
+			sm.changed[poolID] = make(map[string]bool)
+		}
+		sm.changed[poolID][accountID] = true
+	}
+	sm.mu.Unlock()
+
+	// Hand off to the coalescer so bursts of vault updates trigger one handler call
+	sm.coalescer.submit(poolID, data, slot)
+}
+
+// deliverUpdate follows tick/bin array changes, publishes a PoolUpdated event
+// and calls the pool's custom handler, if registered, from a coalescer worker
+func (sm *SubscriptionManager) deliverUpdate(poolID string, data []byte, slot uint64) {
+	sm.syncPoolAccounts(poolID)
+
+	sm.mu.Lock()
+	handler, exists := sm.handlers[poolID]
+	changed := sm.changed[poolID]
+	delete(sm.changed, poolID)
+	sm.mu.Unlock()
+
+	event := PoolUpdated{
+		PoolID:          poolID,
+		Slot:            slot,
+		ChangedAccounts: make([]string, 0, len(changed)),
+		Time:            time.Now(),
+	}
+	for account := range changed {
+		event.ChangedAccounts = append(event.ChangedAccounts, account)
+	}
+	if pool, ok := sm.poolCache.GetPool(poolID); ok {
+		event.Protocol = pool.ProtocolName()
+	}
+	sm.events.Publish(event)
+
+	if exists {
 		handler(poolID, data, slot)
 	}
-	sm.mu.RUnlock()
+}
+
+// Events returns the bus on which PoolUpdated events are published
+func (sm *SubscriptionManager) Events() *EventBus {
+	return sm.events
+}
+
+// SubscribeEvents returns a channel of PoolUpdated events for the given pools,
+// or for every subscribed pool when none are given, and a cancel function.
+// Events are dropped for a subscriber whose buffer is full.
+func (sm *SubscriptionManager) SubscribeEvents(buffer int, poolIDs ...string) (<-chan PoolUpdated, func()) {
+	return sm.events.Subscribe(buffer, poolIDs...)
+}
+
+// ApplyConfig applies the runtime subscription settings
+func (sm *SubscriptionManager) ApplyConfig(cfg config.SubscriptionConfig) {
+	if cfg.CoalesceInterval > 0 {
+		sm.SetCoalesceInterval(time.Duration(cfg.CoalesceInterval))
+	}
+	if cfg.IdleTimeout > 0 {
+		sm.SetIdleTimeout(time.Duration(cfg.IdleTimeout))
+	}
+	if cfg.MaxSubscriptions > 0 {
+		sm.SetMaxSubscriptions(cfg.MaxSubscriptions)
+	}
+}
+
+// SetCoalesceInterval sets the minimum time between handler calls for one pool.
+// Updates arriving in between are merged and the handler sees the latest one.
+// Zero delivers every update, still off the read loop.
+func (sm *SubscriptionManager) SetCoalesceInterval(interval time.Duration) {
+	sm.coalescer.setInterval(interval)
 }
 
 // RegisterHandler registers a custom handler for pool updates
@@ -162,9 +445,46 @@ func (sm *SubscriptionManager) GetAllPools() []pkg.Pool {
 	return sm.poolCache.GetAllPools()
 }
 
-// IsConnected returns whether the WebSocket is connected
+// CurrentSlot returns the latest slot seen by the backend, zero if unknown
+func (sm *SubscriptionManager) CurrentSlot() uint64 {
+	return sm.backend.CurrentSlot()
+}
+
+// PoolSlotAge returns how many slots old the cached state of a pool is. ok is
+// false when the pool is not cached or either slot is unknown.
+func (sm *SubscriptionManager) PoolSlotAge(poolID string) (age uint64, ok bool) {
+	entry, exists := sm.poolCache.GetPoolEntry(poolID)
+	if !exists {
+		return 0, false
+	}
+	return slotAge(sm.backend.CurrentSlot(), entry.LastSlot)
+}
+
+// slotAge returns current - updated when both slots are known
+func slotAge(current, updated uint64) (uint64, bool) {
+	if current == 0 || updated == 0 {
+		return 0, false
+	}
+	if updated >= current {
+		return 0, true
+	}
+	return current - updated, true
+}
+
+// OnConnectionStateChange registers a handler called when the backend
+// disconnects, reconnects, finishes resubscribing or gives up
+func (sm *SubscriptionManager) OnConnectionStateChange(handler ConnectionStateHandler) {
+	sm.backend.OnStateChange(handler)
+}
+
+// ConnectionState returns the backend's current connection state
+func (sm *SubscriptionManager) ConnectionState() ConnectionState {
+	return sm.backend.State()
+}
+
+// IsConnected returns whether the backend is connected
 func (sm *SubscriptionManager) IsConnected() bool {
-	return sm.wsClient.IsConnected()
+	return sm.backend.IsConnected()
 }
 
 // Close closes the subscription manager
@@ -174,8 +494,8 @@ func (sm *SubscriptionManager) Close() error {
 	// Unsubscribe from all pools
 	sm.mu.RLock()
 	poolIDs := make([]string, 0, len(sm.subscriptions))
-	for account := range sm.subscriptions {
-		poolIDs = append(poolIDs, account)
+	for poolID := range sm.subscriptions {
+		poolIDs = append(poolIDs, poolID)
 	}
 	sm.mu.RUnlock()
 
@@ -183,8 +503,17 @@ func (sm *SubscriptionManager) Close() error {
 		sm.UnsubscribePool(poolID)
 	}
 
-	// Close WebSocket client
-	return sm.wsClient.Close()
+	sm.events.Close()
+
+	// Close the backend connection
+	return sm.backend.Close()
+}
+
+// AuxiliaryAccountsPool is implemented by pools whose quotes depend on accounts
+// besides the pool state and vaults, e.g. CLMM tick arrays or DLMM bin arrays.
+// The set may change as the price moves and is re-read after pool updates.
+type AuxiliaryAccountsPool interface {
+	GetAuxiliaryAccounts() []string
 }
 
 // getPoolAccounts extracts account addresses from a pool that need to be monitored
@@ -209,6 +538,20 @@ func (sm *SubscriptionManager) getPoolAccounts(pool pkg.Pool) []string {
 		}
 	}
 
+	// Tick arrays, bin arrays and similar
+	if auxPool, ok := pool.(AuxiliaryAccountsPool); ok {
+		seen := make(map[string]bool, len(accounts))
+		for _, account := range accounts {
+			seen[account] = true
+		}
+		for _, account := range auxPool.GetAuxiliaryAccounts() {
+			if account != "" && !seen[account] {
+				seen[account] = true
+				accounts = append(accounts, account)
+			}
+		}
+	}
+
 	return accounts
 }
 
@@ -217,10 +560,59 @@ func (sm *SubscriptionManager) Stats() map[string]interface{} {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 
-	return map[string]interface{}{
-		"subscriptions": len(sm.subscriptions),
-		"cachedPools":   sm.poolCache.Size(),
-		"connected":     sm.wsClient.IsConnected(),
-		"timestamp":     time.Now().Format(time.RFC3339),
+	accounts := 0
+	for _, poolAccounts := range sm.subscriptions {
+		accounts += len(poolAccounts)
+	}
+
+	stats := map[string]interface{}{
+		"subscribedPools":   len(sm.subscriptions),
+		"subscriptions":     accounts,
+		"connected":         sm.backend.IsConnected(),
+		"connectionState":   sm.backend.State().String(),
+		"currentSlot":       sm.backend.CurrentSlot(),
+		"idleEvictions":     atomic.LoadUint64(&sm.idleEvictions),
+		"capacityEvictions": atomic.LoadUint64(&sm.capacityEvictions),
+		"limitRejections":   atomic.LoadUint64(&sm.limitRejections),
+		"staleRefreshes":    atomic.LoadUint64(&sm.staleRefreshes),
+		"resubscribed":      atomic.LoadUint64(&sm.resubscribedAccounts),
+		"maxSubscriptions":  sm.maxSubscriptions,
+		"timestamp":         time.Now().Format(time.RFC3339),
+	}
+	for k, v := range sm.coalescer.stats() {
+		stats[k] = v
+	}
+	for k, v := range sm.poolCache.Stats() {
+		stats[k] = v
+	}
+	for k, v := range sm.events.stats() {
+		stats[k] = v
+	}
+	return stats
+}
+
+// PoolCacheStats returns the statistics of the manager's pool cache
+func (sm *SubscriptionManager) PoolCacheStats() map[string]interface{} {
+	return sm.poolCache.Stats()
+}
+
+// WaitForConfirmation waits for a transaction signature over the manager's
+// WebSocket connection. Backends without signature subscriptions return an error.
+func (sm *SubscriptionManager) WaitForConfirmation(ctx context.Context, sig solana.Signature, commitment rpc.CommitmentType) (*SignatureResult, error) {
+	wsClient, ok := sm.backend.(*WebSocketClient)
+	if !ok {
+		return nil, fmt.Errorf("subscription backend does not support signature subscriptions")
+	}
+	return wsClient.WaitForConfirmation(ctx, sig, commitment)
+}
+
+// SubscribeProgram subscribes to a program's account changes over the
+// manager's WebSocket connection. Backends without program subscriptions
+// return an error.
+func (sm *SubscriptionManager) SubscribeProgram(program string, opts ProgramOptions, handler AccountUpdateHandler) (uint64, error) {
+	wsClient, ok := sm.backend.(*WebSocketClient)
+	if !ok {
+		return 0, fmt.Errorf("subscription backend does not support program subscriptions")
 	}
+	return wsClient.SubscribeProgram(program, opts, handler)
 }
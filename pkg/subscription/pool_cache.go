@@ -1,60 +1,187 @@
 package subscription
 
 import (
+	"container/list"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
 	"soltrading/pkg"
+	"soltrading/pkg/logging"
 )
 
+// ErrStaleUpdate is returned for an account update older than the one
+// already applied, e.g. a notification replayed around a reconnect
+var ErrStaleUpdate = fmt.Errorf("account update is older than cached state: %w", pkg.ErrStalePoolData)
+
 // PoolCacheEntry represents a cached pool with metadata
 type PoolCacheEntry struct {
 	Pool        pkg.Pool
 	LastUpdate  time.Time
 	LastSlot    uint64
 	AccountData map[string][]byte // account address -> raw data
+
+	elem         *list.Element     // position in the LRU list
+	bytes        int               // total length of AccountData
+	accountSlots map[string]uint64 // account address -> slot of the last applied update
+}
+
+// PoolCacheOptions limits the size of a PoolCache
+type PoolCacheOptions struct {
+	// MaxEntries evicts the least recently used pool beyond this many; zero is unlimited
+	MaxEntries int
+	// MaxBytes evicts least recently used pools while the retained raw account
+	// data exceeds this size; zero is unlimited
+	MaxBytes int
+	// DropAccountData discards raw account data once it has been applied to a
+	// pool implementing PoolStateUpdater
+	DropAccountData bool
 }
 
 // PoolCache manages cached pool state
 type PoolCache struct {
-	pools map[string]*PoolCacheEntry
-	mu    sync.RWMutex
+	pools   map[string]*PoolCacheEntry
+	lru     *list.List // front is most recently used; values are pool IDs
+	bytes   int
+	opts    PoolCacheOptions
+	onEvict func(poolID string)
+	evicted uint64
+	stale   uint64 // updates ignored by the slot-ordering guard
+	logger  *slog.Logger
+	mu      sync.RWMutex
 }
 
 // NewPoolCache creates a new pool cache
 func NewPoolCache() *PoolCache {
+	return NewPoolCacheWithOptions(PoolCacheOptions{})
+}
+
+// NewPoolCacheWithOptions creates a pool cache with size limits
+func NewPoolCacheWithOptions(opts PoolCacheOptions) *PoolCache {
 	return &PoolCache{
-		pools: make(map[string]*PoolCacheEntry),
+		pools:  make(map[string]*PoolCacheEntry),
+		lru:    list.New(),
+		opts:   opts,
+		logger: logging.Component(nil, "subscription"),
 	}
 }
 
-// SetPool adds or updates a pool in the cache
-func (pc *PoolCache) SetPool(poolID string, pool pkg.Pool) {
+// SetOptions changes the cache limits, evicting pools that no longer fit
+func (pc *PoolCache) SetOptions(opts PoolCacheOptions) {
+	pc.mu.Lock()
+	pc.opts = opts
+	evicted := pc.evictLocked()
+	pc.mu.Unlock()
+
+	pc.notifyEvicted(evicted)
+}
+
+// OnEvict registers a function called with every pool evicted by the size
+// limits, outside the cache lock
+func (pc *PoolCache) OnEvict(fn func(poolID string)) {
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
+	pc.onEvict = fn
+}
 
+// touchLocked marks an entry as most recently used
+func (pc *PoolCache) touchLocked(entry *PoolCacheEntry) {
+	if entry.elem != nil {
+		pc.lru.MoveToFront(entry.elem)
+	}
+}
+
+// removeLocked drops an entry and its accounting
+func (pc *PoolCache) removeLocked(poolID string) {
+	entry, exists := pc.pools[poolID]
+	if !exists {
+		return
+	}
+	if entry.elem != nil {
+		pc.lru.Remove(entry.elem)
+	}
+	pc.bytes -= entry.bytes
+	delete(pc.pools, poolID)
+}
+
+// evictLocked removes least recently used pools until the limits hold and
+// returns their IDs. The most recently used pool is always kept.
+func (pc *PoolCache) evictLocked() []string {
+	var evicted []string
+	for pc.lru.Len() > 1 {
+		overEntries := pc.opts.MaxEntries > 0 && len(pc.pools) > pc.opts.MaxEntries
+		overBytes := pc.opts.MaxBytes > 0 && pc.bytes > pc.opts.MaxBytes
+		if !overEntries && !overBytes {
+			break
+		}
+		poolID := pc.lru.Back().Value.(string)
+		pc.removeLocked(poolID)
+		evicted = append(evicted, poolID)
+	}
+	pc.evicted += uint64(len(evicted))
+	return evicted
+}
+
+func (pc *PoolCache) notifyEvicted(poolIDs []string) {
+	if len(poolIDs) == 0 {
+		return
+	}
+	pc.mu.RLock()
+	onEvict := pc.onEvict
+	pc.mu.RUnlock()
+
+	for _, poolID := range poolIDs {
+		pc.logger.Info("evicted pool from cache", "pool", poolID)
+		if onEvict != nil {
+			onEvict(poolID)
+		}
+	}
+}
+
+// SetPool adds or updates a pool in the cache
+func (pc *PoolCache) SetPool(poolID string, pool pkg.Pool) {
+	pc.mu.Lock()
 	if entry, exists := pc.pools[poolID]; exists {
 		// Update existing entry
 		entry.Pool = pool
 		entry.LastUpdate = time.Now()
+		pc.touchLocked(entry)
 	} else {
 		// Create new entry
-		pc.pools[poolID] = &PoolCacheEntry{
-			Pool:        pool,
-			LastUpdate:  time.Now(),
-			AccountData: make(map[string][]byte),
+		entry := &PoolCacheEntry{
+			Pool:         pool,
+			LastUpdate:   time.Now(),
+			AccountData:  make(map[string][]byte),
+			accountSlots: make(map[string]uint64),
 		}
+		entry.elem = pc.lru.PushFront(poolID)
+		pc.pools[poolID] = entry
+	}
+	evicted := pc.evictLocked()
+	pc.mu.Unlock()
+
+	pc.notifyEvicted(evicted)
+}
+
+// SetPoolAtSlot adds or updates a pool whose state is known to be current as of slot
+func (pc *PoolCache) SetPoolAtSlot(poolID string, pool pkg.Pool, slot uint64) {
+	pc.SetPool(poolID, pool)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if entry, exists := pc.pools[poolID]; exists && slot > entry.LastSlot {
+		entry.LastSlot = slot
 	}
 }
 
-// GetPool retrieves a pool from the cache
+// GetPool retrieves a pool from the cache and marks it as recently used
 func (pc *PoolCache) GetPool(poolID string) (pkg.Pool, bool) {
-	pc.mu.RLock()
-	defer pc.mu.RUnlock()
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
 
 	if entry, exists := pc.pools[poolID]; exists {
+		pc.touchLocked(entry)
 		return entry.Pool, true
 	}
 	return nil, false
@@ -77,38 +204,77 @@ func (pc *PoolCache) RemovePool(poolID string) {
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
 
-	delete(pc.pools, poolID)
+	pc.removeLocked(poolID)
 }
 
-// UpdatePoolAccount updates account data for a pool
+// UpdatePoolAccount updates account data for a pool. Updates older than the
+// last one applied to the same account return ErrStaleUpdate and are ignored,
+// so the cached state never moves backwards. A zero slot is always applied.
 func (pc *PoolCache) UpdatePoolAccount(poolID, accountID string, data []byte, slot uint64) error {
 	pc.mu.Lock()
-	defer pc.mu.Unlock()
+	err := pc.updatePoolAccountLocked(poolID, accountID, data, slot)
+	evicted := pc.evictLocked()
+	pc.mu.Unlock()
+
+	pc.notifyEvicted(evicted)
+	return err
+}
 
+func (pc *PoolCache) updatePoolAccountLocked(poolID, accountID string, data []byte, slot uint64) error {
 	entry, exists := pc.pools[poolID]
 	if !exists {
 		return fmt.Errorf("pool %s not found in cache", poolID)
 	}
 
+	if slot != 0 && slot < entry.accountSlots[accountID] {
+		pc.stale++
+		return ErrStaleUpdate
+	}
+	if slot != 0 {
+		entry.accountSlots[accountID] = slot
+	}
+
 	// Store raw account data
-	entry.AccountData[accountID] = data
+	pc.setAccountDataLocked(entry, accountID, data)
 	entry.LastUpdate = time.Now()
-	entry.LastSlot = slot
+	if slot > entry.LastSlot {
+		entry.LastSlot = slot
+	}
+	pc.touchLocked(entry)
 
 	// Try to update the pool with the new data
 	if updater, ok := entry.Pool.(PoolStateUpdater); ok {
 		if err := updater.UpdateFromAccountData(accountID, data); err != nil {
-			log.Printf("Failed to update pool %s state from account %s: %v", poolID, accountID, err)
+			pc.logger.Warn("failed to update pool state", "pool", poolID, "account", accountID, "error", err)
 			return err
 		}
-		log.Printf("Updated pool %s from account %s at slot %d", poolID, accountID, slot)
+		if recorder, ok := entry.Pool.(SlotRecorder); ok && slot != 0 {
+			recorder.SetLastSlot(slot)
+		}
+		pc.logger.Debug("updated pool", "pool", poolID, "account", accountID, "slot", slot)
+		if pc.opts.DropAccountData {
+			pc.setAccountDataLocked(entry, accountID, nil)
+		}
 	} else {
-		log.Printf("Pool %s does not implement PoolStateUpdater interface", poolID)
+		pc.logger.Debug("pool does not implement PoolStateUpdater", "pool", poolID)
 	}
 
 	return nil
 }
 
+// setAccountDataLocked stores or, for nil data, deletes an account's raw data
+// and keeps the byte accounting in sync
+func (pc *PoolCache) setAccountDataLocked(entry *PoolCacheEntry, accountID string, data []byte) {
+	old := len(entry.AccountData[accountID])
+	if data == nil {
+		delete(entry.AccountData, accountID)
+	} else {
+		entry.AccountData[accountID] = data
+	}
+	entry.bytes += len(data) - old
+	pc.bytes += len(data) - old
+}
+
 // GetPoolEntry returns the full cache entry for a pool
 func (pc *PoolCache) GetPoolEntry(poolID string) (*PoolCacheEntry, bool) {
 	pc.mu.RLock()
@@ -132,6 +298,21 @@ func (pc *PoolCache) Clear() {
 	defer pc.mu.Unlock()
 
 	pc.pools = make(map[string]*PoolCacheEntry)
+	pc.lru.Init()
+	pc.bytes = 0
+}
+
+// Stats returns size and eviction counters for monitoring
+func (pc *PoolCache) Stats() map[string]interface{} {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	return map[string]interface{}{
+		"cachedPools":        len(pc.pools),
+		"cachedAccountBytes": pc.bytes,
+		"cacheEvictions":     pc.evicted,
+		"staleUpdates":       pc.stale,
+	}
 }
 
 // GetStalePoolIDs returns pool IDs that haven't been updated recently
@@ -155,3 +336,9 @@ func (pc *PoolCache) GetStalePoolIDs(maxAge time.Duration) []string {
 type PoolStateUpdater interface {
 	UpdateFromAccountData(accountID string, data []byte) error
 }
+
+// SlotRecorder is implemented by pools that report the slot of their last
+// update in GetReserves
+type SlotRecorder interface {
+	SetLastSlot(slot uint64)
+}
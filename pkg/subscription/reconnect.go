@@ -0,0 +1,130 @@
+package subscription
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Defaults for reconnection backoff
+const (
+	DefaultReconnectBaseDelay = 500 * time.Millisecond
+	DefaultReconnectMaxDelay  = 30 * time.Second
+)
+
+// ReconnectOptions configures how a backend reconnects after losing its connection
+type ReconnectOptions struct {
+	// BaseDelay is the wait before the first attempt; it doubles after every
+	// failed attempt. Zero uses DefaultReconnectBaseDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts. Zero uses DefaultReconnectMaxDelay.
+	MaxDelay time.Duration
+	// MaxAttempts gives up after this many consecutive failures and reports
+	// StateFailed. Zero retries forever.
+	MaxAttempts int
+}
+
+// ConnectionState is the state of a backend's connection
+type ConnectionState int
+
+const (
+	StateConnected ConnectionState = iota
+	StateDisconnected
+	StateResubscribed // reconnected and the node answered every re-sent subscription
+	StateFailed       // gave up after ReconnectOptions.MaxAttempts
+	StateDegraded     // reconnected, but some subscriptions are still being re-sent
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateResubscribed:
+		return "resubscribed"
+	case StateFailed:
+		return "failed"
+	case StateDegraded:
+		return "degraded"
+	default:
+		return "unknown"
+	}
+}
+
+// ConnectionStateHandler is called on every connection state change. err is
+// the cause for StateDisconnected, StateFailed and StateDegraded, nil otherwise.
+type ConnectionStateHandler func(state ConnectionState, err error)
+
+// backoff yields exponentially growing reconnect delays with jitter
+type backoff struct {
+	opts    ReconnectOptions
+	attempt int
+}
+
+func newBackoff(opts ReconnectOptions) *backoff {
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = DefaultReconnectBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = DefaultReconnectMaxDelay
+	}
+	if opts.MaxDelay < opts.BaseDelay {
+		opts.MaxDelay = opts.BaseDelay
+	}
+	return &backoff{opts: opts}
+}
+
+// next returns the delay before the next attempt and counts the attempt
+func (b *backoff) next() time.Duration {
+	delay := b.opts.BaseDelay
+	for i := 0; i < b.attempt && delay < b.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > b.opts.MaxDelay {
+		delay = b.opts.MaxDelay
+	}
+	b.attempt++
+
+	// Up to 20% jitter so many clients don't reconnect in lockstep
+	return delay - time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// exhausted reports whether MaxAttempts attempts have been made
+func (b *backoff) exhausted() bool {
+	return b.opts.MaxAttempts > 0 && b.attempt >= b.opts.MaxAttempts
+}
+
+func (b *backoff) reset() {
+	b.attempt = 0
+}
+
+// stateNotifier tracks a backend's connection state and informs handlers of changes
+type stateNotifier struct {
+	mu       sync.RWMutex
+	state    ConnectionState
+	handlers []ConnectionStateHandler
+}
+
+func (n *stateNotifier) add(handler ConnectionStateHandler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers = append(n.handlers, handler)
+}
+
+func (n *stateNotifier) notify(state ConnectionState, err error) {
+	n.mu.Lock()
+	n.state = state
+	handlers := append([]ConnectionStateHandler(nil), n.handlers...)
+	n.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(state, err)
+	}
+}
+
+func (n *stateNotifier) current() ConnectionState {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.state
+}
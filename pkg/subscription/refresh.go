@@ -0,0 +1,146 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/sol"
+)
+
+// refreshBatchSize is the getMultipleAccounts limit per request
+const refreshBatchSize = 100
+
+// DefaultStaleRefreshInterval is how often stale pools are looked for
+const DefaultStaleRefreshInterval = 30 * time.Second
+
+// StartStaleRefresh periodically re-reads, over RPC, the accounts of pools
+// that have not received an update for maxAge, and re-subscribes any account
+// whose subscription was dropped. It keeps the cache trustworthy while the
+// update stream stalls. It runs until the manager is closed.
+func (sm *SubscriptionManager) StartStaleRefresh(client sol.SolClient, maxAge, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultStaleRefreshInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sm.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := sm.RefreshStalePools(sm.ctx, client, maxAge); err != nil {
+					sm.logger.Warn("stale pool refresh failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// poolAccount is one account of a subscribed pool
+type poolAccount struct {
+	poolID  string
+	account string
+}
+
+// RefreshStalePools refreshes the subscribed pools not updated for maxAge once
+func (sm *SubscriptionManager) RefreshStalePools(ctx context.Context, client sol.SolClient, maxAge time.Duration) error {
+	var targets []poolAccount
+	var missing []poolAccount
+	for _, poolID := range sm.poolCache.GetStalePoolIDs(maxAge) {
+		pool, exists := sm.poolCache.GetPool(poolID)
+		if !exists {
+			continue
+		}
+		accounts := sm.getPoolAccounts(pool)
+
+		sm.mu.RLock()
+		subscribed, ok := sm.subscriptions[poolID]
+		if ok {
+			for _, account := range accounts {
+				targets = append(targets, poolAccount{poolID, account})
+				if _, has := subscribed[account]; !has {
+					missing = append(missing, poolAccount{poolID, account})
+				}
+			}
+		}
+		sm.mu.RUnlock()
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	refreshed, err := sm.refreshAccounts(ctx, client, targets)
+	if err != nil {
+		return err
+	}
+
+	resubscribed := 0
+	for _, target := range missing {
+		if err := sm.subscribeAccount(target.poolID, target.account); err != nil {
+			sm.logger.Warn("failed to resubscribe account", "account", target.account, "pool", target.poolID, "error", err)
+			continue
+		}
+		resubscribed++
+	}
+
+	atomic.AddUint64(&sm.staleRefreshes, uint64(refreshed))
+	atomic.AddUint64(&sm.resubscribedAccounts, uint64(resubscribed))
+	sm.logger.Info("refreshed stale pools over RPC", "accounts", refreshed, "resubscribed", resubscribed)
+	return nil
+}
+
+// RefreshPool re-reads all accounts of a cached pool over RPC and applies
+// them as if they arrived on the stream. It returns the number of accounts
+// refreshed.
+func (sm *SubscriptionManager) RefreshPool(ctx context.Context, client sol.SolClient, poolID string) (int, error) {
+	pool, exists := sm.poolCache.GetPool(poolID)
+	if !exists {
+		return 0, fmt.Errorf("pool %s is not cached", poolID)
+	}
+
+	var targets []poolAccount
+	for _, account := range sm.getPoolAccounts(pool) {
+		targets = append(targets, poolAccount{poolID, account})
+	}
+	return sm.refreshAccounts(ctx, client, targets)
+}
+
+// refreshAccounts fetches accounts in batches and applies them as updates
+func (sm *SubscriptionManager) refreshAccounts(ctx context.Context, client sol.SolClient, targets []poolAccount) (int, error) {
+	refreshed := 0
+	for start := 0; start < len(targets); start += refreshBatchSize {
+		end := start + refreshBatchSize
+		if end > len(targets) {
+			end = len(targets)
+		}
+		batch := targets[start:end]
+
+		keys := make([]solana.PublicKey, len(batch))
+		for i, target := range batch {
+			key, err := solana.PublicKeyFromBase58(target.account)
+			if err != nil {
+				return refreshed, fmt.Errorf("invalid account %s of pool %s: %w", target.account, target.poolID, err)
+			}
+			keys[i] = key
+		}
+
+		result, err := client.GetMultipleAccountsWithOpts(ctx, keys)
+		if err != nil {
+			return refreshed, fmt.Errorf("failed to fetch pool accounts: %w", err)
+		}
+		slot := result.Context.Slot
+		for i, account := range result.Value {
+			if i >= len(batch) || account == nil || account.Data == nil {
+				continue
+			}
+			sm.handleAccountUpdate(batch[i].poolID, batch[i].account, account.Data.GetBinary(), slot)
+			refreshed++
+		}
+	}
+	return refreshed, nil
+}
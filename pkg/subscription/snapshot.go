@@ -0,0 +1,176 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"soltrading/pkg"
+)
+
+// SnapshotVersion is bumped when the snapshot format changes incompatibly
+const SnapshotVersion = 1
+
+// PoolSnapshot identifies a cached pool so it can be reloaded after a restart.
+// Accounts, filled by RecordAccounts, holds the raw data the pool was decoded
+// from at Slot so a SnapshotClient can restore it without RPC.
+type PoolSnapshot struct {
+	Protocol  pkg.ProtocolName       `json:"protocol"`
+	PoolID    string                 `json:"poolId"`
+	BaseMint  string                 `json:"baseMint"`
+	QuoteMint string                 `json:"quoteMint"`
+	Slot      uint64                 `json:"slot"`
+	Priority  int                    `json:"priority,omitempty"`
+	Accounts  map[string]AccountData `json:"accounts,omitempty"`
+}
+
+// Snapshot is the persisted set of pools a SubscriptionManager was following
+type Snapshot struct {
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Slot      uint64         `json:"slot"`
+	Pools     []PoolSnapshot `json:"pools"`
+}
+
+// PoolLoader fetches the current state of a pool, e.g. with the protocol's
+// FetchPoolByID, which is far cheaper than rediscovering pools by pair
+type PoolLoader func(ctx context.Context, protocol pkg.ProtocolName, poolID string) (pkg.Pool, error)
+
+// Snapshot returns the subscribed pools and their metadata
+func (sm *SubscriptionManager) Snapshot() Snapshot {
+	sm.mu.RLock()
+	priorities := make(map[string]int, len(sm.priorities))
+	for poolID, priority := range sm.priorities {
+		priorities[poolID] = priority
+	}
+	sm.mu.RUnlock()
+
+	snap := Snapshot{
+		Version:   SnapshotVersion,
+		CreatedAt: time.Now(),
+		Slot:      sm.backend.CurrentSlot(),
+	}
+	for _, pool := range sm.poolCache.GetAllPools() {
+		poolID := pool.GetID()
+		baseMint, quoteMint := pool.GetTokens()
+		entry := PoolSnapshot{
+			Protocol:  pool.ProtocolName(),
+			PoolID:    poolID,
+			BaseMint:  baseMint,
+			QuoteMint: quoteMint,
+			Priority:  priorities[poolID],
+		}
+		if cached, ok := sm.poolCache.GetPoolEntry(poolID); ok {
+			entry.Slot = cached.LastSlot
+		}
+		snap.Pools = append(snap.Pools, entry)
+	}
+	return snap
+}
+
+// RestoreSnapshot reloads and subscribes the pools of a snapshot using up to
+// workers concurrent loads. Pools that fail to load are logged and skipped.
+// Pools with recorded accounts are cached at the slot they were recorded, so
+// loading them through a SnapshotClient leaves the first update to replace
+// them.
+func (sm *SubscriptionManager) RestoreSnapshot(ctx context.Context, snap Snapshot, load PoolLoader, workers int) []pkg.Pool {
+	var (
+		mu       sync.Mutex
+		restored []pkg.Pool
+	)
+	eachSnapshotPool(ctx, len(snap.Pools), workers, func(i int) {
+		entry := snap.Pools[i]
+		pool, err := load(ctx, entry.Protocol, entry.PoolID)
+		if err != nil {
+			sm.logger.Warn("failed to restore pool", "protocol", entry.Protocol, "pool", entry.PoolID, "error", err)
+			return
+		}
+		var slot uint64
+		if len(entry.Accounts) > 0 {
+			slot = entry.Slot
+		}
+		if err := sm.subscribePool(pool, "", slot); err != nil {
+			sm.logger.Warn("failed to subscribe restored pool", "pool", entry.PoolID, "error", err)
+		} else if entry.Priority != 0 {
+			sm.SetPoolPriority(entry.PoolID, entry.Priority)
+		}
+		mu.Lock()
+		restored = append(restored, pool)
+		mu.Unlock()
+	})
+
+	sm.logger.Info("restored pools from snapshot", "restored", len(restored), "pools", len(snap.Pools), "slot", snap.Slot)
+	return restored
+}
+
+// eachSnapshotPool calls fn with each index below n from up to workers
+// goroutines, stopping early when ctx is done
+func eachSnapshotPool(ctx context.Context, n, workers int, fn func(i int)) {
+	if workers <= 0 {
+		workers = 8
+	}
+
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// WriteSnapshotFile writes v as JSON to path, replacing the file atomically so
+// a crash mid-write never leaves a truncated snapshot
+func WriteSnapshotFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// ReadSnapshotFile decodes a snapshot written by WriteSnapshotFile into v
+func ReadSnapshotFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode snapshot %s: %w", path, err)
+	}
+	return nil
+}
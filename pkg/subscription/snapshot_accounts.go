@@ -0,0 +1,249 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg/sol"
+)
+
+// ErrNotInSnapshot is returned by a SnapshotClient without a fallback for
+// reads the snapshot cannot answer
+var ErrNotInSnapshot = errors.New("not in snapshot")
+
+// AccountData is the raw state of one account as read over RPC
+type AccountData struct {
+	Owner solana.PublicKey `json:"owner"`
+	Data  []byte           `json:"data"`
+}
+
+// AccountRecorder is a sol.SolClient that keeps the data of every account it
+// reads, including those an AccountCache serves in its place, and the newest
+// slot it read at. Other calls pass through unrecorded.
+type AccountRecorder struct {
+	sol.SolClient
+
+	mu       sync.Mutex
+	accounts map[string]AccountData
+	slot     uint64
+}
+
+var _ sol.AccountObserver = (*AccountRecorder)(nil)
+
+// NewAccountRecorder records the account reads made through client
+func NewAccountRecorder(client sol.SolClient) *AccountRecorder {
+	return &AccountRecorder{SolClient: client, accounts: make(map[string]AccountData)}
+}
+
+func (r *AccountRecorder) record(slot uint64, key solana.PublicKey, account *rpc.Account) {
+	if account == nil || account.Data == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accounts[key.String()] = AccountData{Owner: account.Owner, Data: account.Data.GetBinary()}
+	if slot > r.slot {
+		r.slot = slot
+	}
+}
+
+// ObserveAccount records an account served from a cache; its owner is unknown
+func (r *AccountRecorder) ObserveAccount(address solana.PublicKey, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.accounts[address.String()]; !ok {
+		r.accounts[address.String()] = AccountData{Data: data}
+	}
+}
+
+func (r *AccountRecorder) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	result, err := r.SolClient.GetAccountInfoWithOpts(ctx, account)
+	if err == nil && result != nil {
+		r.record(result.Context.Slot, account, result.Value)
+	}
+	return result, err
+}
+
+func (r *AccountRecorder) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey) (*rpc.GetMultipleAccountsResult, error) {
+	result, err := r.SolClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err == nil && result != nil {
+		for i, account := range result.Value {
+			if i < len(accounts) {
+				r.record(result.Context.Slot, accounts[i], account)
+			}
+		}
+	}
+	return result, err
+}
+
+// Accounts returns the recorded accounts by address and the newest slot read
+func (r *AccountRecorder) Accounts() (map[string]AccountData, uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	accounts := make(map[string]AccountData, len(r.accounts))
+	for key, account := range r.accounts {
+		accounts[key] = account
+	}
+	return accounts, r.slot
+}
+
+// RecordAccounts reloads each pool of snap through load, which creates a
+// loader on the client it is given, and stores the accounts read so the pool
+// can be restored without RPC. Pools that fail to load keep no accounts and
+// are fetched again on restore. It returns the number of pools recorded.
+func RecordAccounts(ctx context.Context, snap *Snapshot, client sol.SolClient, load func(sol.SolClient) PoolLoader, workers int) int {
+	var recorded int64
+	eachSnapshotPool(ctx, len(snap.Pools), workers, func(i int) {
+		entry := &snap.Pools[i]
+		recorder := NewAccountRecorder(client)
+		if _, err := load(recorder)(ctx, entry.Protocol, entry.PoolID); err != nil {
+			return
+		}
+		accounts, slot := recorder.Accounts()
+		if len(accounts) == 0 {
+			return
+		}
+		entry.Accounts = accounts
+		if slot > 0 {
+			entry.Slot = slot
+		}
+		atomic.AddInt64(&recorded, 1)
+	})
+	return int(recorded)
+}
+
+// snapshotAccount is a recorded account and the slot of its pool's entry
+type snapshotAccount struct {
+	AccountData
+	slot uint64
+}
+
+// SnapshotClient is a sol.SolClient that answers account reads from the
+// accounts recorded in a snapshot. Accounts it does not hold, and all other
+// calls, go to the fallback client.
+type SnapshotClient struct {
+	sol.SolClient
+
+	accounts map[solana.PublicKey]snapshotAccount
+	misses   uint64
+}
+
+// NewSnapshotClient serves the accounts recorded in snap, falling back to
+// fallback; a nil fallback fails every other read with ErrNotInSnapshot
+func NewSnapshotClient(snap Snapshot, fallback sol.SolClient) *SnapshotClient {
+	if fallback == nil {
+		fallback = offlineClient{}
+	}
+	c := &SnapshotClient{SolClient: fallback, accounts: make(map[solana.PublicKey]snapshotAccount)}
+	for _, entry := range snap.Pools {
+		for address, account := range entry.Accounts {
+			key, err := solana.PublicKeyFromBase58(address)
+			if err != nil {
+				continue
+			}
+			c.accounts[key] = snapshotAccount{AccountData: account, slot: entry.Slot}
+		}
+	}
+	return c
+}
+
+// Misses returns how many account reads had to go to the fallback client
+func (c *SnapshotClient) Misses() uint64 {
+	return atomic.LoadUint64(&c.misses)
+}
+
+func (c *SnapshotClient) account(key solana.PublicKey) (*rpc.Account, uint64, bool) {
+	recorded, ok := c.accounts[key]
+	if !ok {
+		return nil, 0, false
+	}
+	return &rpc.Account{Owner: recorded.Owner, Data: rpc.DataBytesOrJSONFromBytes(recorded.Data)}, recorded.slot, true
+}
+
+func (c *SnapshotClient) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	if value, slot, ok := c.account(account); ok {
+		return &rpc.GetAccountInfoResult{RPCContext: rpc.RPCContext{Context: rpc.Context{Slot: slot}}, Value: value}, nil
+	}
+	atomic.AddUint64(&c.misses, 1)
+	return c.SolClient.GetAccountInfoWithOpts(ctx, account)
+}
+
+func (c *SnapshotClient) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey) (*rpc.GetMultipleAccountsResult, error) {
+	result := &rpc.GetMultipleAccountsResult{Value: make([]*rpc.Account, len(accounts))}
+	var missing []int
+	for i, key := range accounts {
+		value, slot, ok := c.account(key)
+		if !ok {
+			missing = append(missing, i)
+			continue
+		}
+		result.Value[i] = value
+		if slot > result.Context.Slot {
+			result.Context.Slot = slot
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	atomic.AddUint64(&c.misses, uint64(len(missing)))
+	keys := make([]solana.PublicKey, len(missing))
+	for i, index := range missing {
+		keys[i] = accounts[index]
+	}
+	fetched, err := c.SolClient.GetMultipleAccountsWithOpts(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for i, account := range fetched.Value {
+		if i < len(missing) {
+			result.Value[missing[i]] = account
+		}
+	}
+	if fetched.Context.Slot > result.Context.Slot {
+		result.Context.Slot = fetched.Context.Slot
+	}
+	return result, nil
+}
+
+// offlineClient fails every call; it stands in for a missing fallback
+type offlineClient struct{}
+
+var _ sol.SolClient = offlineClient{}
+
+func (offlineClient) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	return nil, fmt.Errorf("account %s: %w", account, ErrNotInSnapshot)
+}
+
+func (offlineClient) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey) (*rpc.GetMultipleAccountsResult, error) {
+	return nil, fmt.Errorf("%d accounts: %w", len(accounts), ErrNotInSnapshot)
+}
+
+func (offlineClient) GetProgramAccountsWithOpts(ctx context.Context, programID solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (rpc.GetProgramAccountsResult, error) {
+	return nil, fmt.Errorf("program accounts of %s: %w", programID, ErrNotInSnapshot)
+}
+
+func (offlineClient) GetTokenAccountsByOwner(ctx context.Context, owner solana.PublicKey, config *rpc.GetTokenAccountsConfig, opts *rpc.GetTokenAccountsOpts) (*rpc.GetTokenAccountsResult, error) {
+	return nil, fmt.Errorf("token accounts of %s: %w", owner, ErrNotInSnapshot)
+}
+
+func (offlineClient) GetTokenAccountBalance(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetTokenAccountBalanceResult, error) {
+	return nil, fmt.Errorf("token balance of %s: %w", account, ErrNotInSnapshot)
+}
+
+func (offlineClient) GetBalance(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetBalanceResult, error) {
+	return nil, fmt.Errorf("balance of %s: %w", account, ErrNotInSnapshot)
+}
+
+func (offlineClient) GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error) {
+	return nil, fmt.Errorf("latest blockhash: %w", ErrNotInSnapshot)
+}
+
+func (offlineClient) GetClock(ctx context.Context) (*sol.Clock, error) {
+	return nil, fmt.Errorf("clock: %w", ErrNotInSnapshot)
+}
@@ -2,39 +2,138 @@ package subscription
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/gorilla/websocket"
+
+	"soltrading/pkg/logging"
+	"soltrading/pkg/sol"
 )
 
 // WebSocketClient manages WebSocket connection to Solana
 type WebSocketClient struct {
 	url            string
+	dialer         *websocket.Dialer
+	headers        http.Header
 	conn           *websocket.Conn
 	mu             sync.RWMutex
 	subscriptions  map[uint64]*Subscription
 	nextID         uint64
 	handlers       map[uint64]AccountUpdateHandler
-	reconnectDelay time.Duration
+	sigSubs        map[uint64]*SignatureSubscription
+	slotSubs       map[uint64]*SlotSubscription
+	programSubs    map[uint64]*ProgramSubscription
+	currentSlot    uint64
+	pending        map[uint64]chan rpcResult // request ID -> waiting caller
+	sendQueue      chan outgoingMessage
+	requestTimeout time.Duration
+	commitment     string // default for account subscriptions
+	reconnect      ReconnectOptions
+	lost           chan error // signalled by the reader when the connection drops
+	generation     uint64     // counts reconnects, so stale retries stop
+	state          stateNotifier
 	ctx            context.Context
 	cancel         context.CancelFunc
 	connected      bool
+	logger         *slog.Logger
+}
+
+// Defaults for request handling on the WebSocket connection
+const (
+	DefaultRequestTimeout = 10 * time.Second
+	DefaultSendQueueSize  = 256
+)
+
+// rpcResult is the outcome of a request awaited by call
+type rpcResult struct {
+	result json.RawMessage
+	err    error
+}
+
+// outgoingMessage is a frame waiting for the writer goroutine
+type outgoingMessage struct {
+	data   []byte
+	result chan error
+}
+
+// Commitment levels accepted for account subscriptions
+const (
+	CommitmentProcessed = "processed"
+	CommitmentConfirmed = "confirmed"
+	CommitmentFinalized = "finalized"
+)
+
+// ParseCommitment validates a commitment level; empty means CommitmentConfirmed
+func ParseCommitment(commitment string) (string, error) {
+	switch commitment {
+	case "":
+		return CommitmentConfirmed, nil
+	case CommitmentProcessed, CommitmentConfirmed, CommitmentFinalized:
+		return commitment, nil
+	default:
+		return "", fmt.Errorf("invalid commitment %q: want processed, confirmed or finalized", commitment)
+	}
 }
 
 // Subscription represents an account subscription
 type Subscription struct {
-	ID        uint64
-	AccountID string
-	SubID     uint64 // Solana subscription ID
+	ID         uint64
+	AccountID  string
+	Commitment string
+	SubID      uint64 // Solana subscription ID
 }
 
-// AccountUpdateHandler is called when an account is updated
+// AccountUpdateHandler is called with the raw (decoded) account data when an account is updated
 type AccountUpdateHandler func(accountID string, data []byte, slot uint64)
 
+// SignatureSubscription represents a pending signatureSubscribe
+type SignatureSubscription struct {
+	ID         uint64
+	Signature  string
+	Commitment string
+	SubID      uint64 // Solana subscription ID
+	handler    SignatureUpdateHandler
+}
+
+// SignatureUpdateHandler is called once when a transaction reaches the subscribed
+// commitment. txErr is the on-chain error, nil on success.
+type SignatureUpdateHandler func(signature string, txErr interface{}, slot uint64)
+
+// SlotSubscription represents a slotSubscribe
+type SlotSubscription struct {
+	ID      uint64
+	SubID   uint64 // Solana subscription ID
+	handler SlotUpdateHandler
+}
+
+// SlotUpdateHandler is called for every slot the node starts processing
+type SlotUpdateHandler func(slot, parent, root uint64)
+
+// ProgramOptions narrows a program subscription
+type ProgramOptions struct {
+	Commitment string        // empty uses the client's default
+	DataSize   uint64        // only accounts of this size; zero matches any size
+	Slice      sol.DataSlice // part of each account sent; a zero Length sends all
+}
+
+// ProgramSubscription represents a programSubscribe
+type ProgramSubscription struct {
+	ID      uint64
+	Program string
+	Options ProgramOptions
+	SubID   uint64 // Solana subscription ID
+	handler AccountUpdateHandler
+}
+
 // RPCRequest represents a JSON-RPC request
 type RPCRequest struct {
 	JSONRPC string        `json:"jsonrpc"`
@@ -81,6 +180,51 @@ type Context struct {
 	Slot uint64 `json:"slot"`
 }
 
+// SignatureNotificationMessage represents a signatureNotification
+type SignatureNotificationMessage struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  struct {
+		Result struct {
+			Context Context `json:"context"`
+			Value   struct {
+				Err interface{} `json:"err"`
+			} `json:"value"`
+		} `json:"result"`
+		Subscription uint64 `json:"subscription"`
+	} `json:"params"`
+}
+
+// SlotNotificationMessage represents a slotNotification
+type SlotNotificationMessage struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  struct {
+		Result struct {
+			Parent uint64 `json:"parent"`
+			Root   uint64 `json:"root"`
+			Slot   uint64 `json:"slot"`
+		} `json:"result"`
+		Subscription uint64 `json:"subscription"`
+	} `json:"params"`
+}
+
+// ProgramNotificationMessage represents a programNotification
+type ProgramNotificationMessage struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  struct {
+		Result struct {
+			Context Context `json:"context"`
+			Value   struct {
+				Pubkey  string       `json:"pubkey"`
+				Account AccountValue `json:"account"`
+			} `json:"value"`
+		} `json:"result"`
+		Subscription uint64 `json:"subscription"`
+	} `json:"params"`
+}
+
 // AccountValue contains account data
 type AccountValue struct {
 	Data       []interface{} `json:"data"` // [base64_data, encoding]
@@ -90,15 +234,64 @@ type AccountValue struct {
 	RentEpoch  uint64        `json:"rentEpoch"`
 }
 
+// Options configures the WebSocket connection
+type Options struct {
+	// Dialer is used to establish the connection; nil uses websocket.DefaultDialer
+	Dialer *websocket.Dialer
+	// Headers are sent with the WebSocket handshake, e.g. provider auth tokens
+	Headers http.Header
+	// RequestTimeout bounds how long a request waits to be written and
+	// answered; zero uses DefaultRequestTimeout
+	RequestTimeout time.Duration
+	// Reconnect configures backoff after the connection drops
+	Reconnect ReconnectOptions
+	// Commitment is the default for account subscriptions: "processed" for the
+	// lowest latency, "confirmed" (default) or "finalized"
+	Commitment string
+	// Logger receives the client's logs, and those of a SubscriptionManager
+	// built on it; nil uses logging.Default()
+	Logger *slog.Logger
+}
+
 // NewWebSocketClient creates a new WebSocket client
 func NewWebSocketClient(ctx context.Context, wsURL string) (*WebSocketClient, error) {
+	return NewWebSocketClientWithOptions(ctx, wsURL, Options{})
+}
+
+// NewWebSocketClientWithOptions creates a new WebSocket client with a custom dialer
+func NewWebSocketClientWithOptions(ctx context.Context, wsURL string, opts Options) (*WebSocketClient, error) {
 	clientCtx, cancel := context.WithCancel(ctx)
 
+	dialer := opts.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	requestTimeout := opts.RequestTimeout
+	if requestTimeout <= 0 {
+		requestTimeout = DefaultRequestTimeout
+	}
+	commitment, err := ParseCommitment(opts.Commitment)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
 	client := &WebSocketClient{
 		url:            wsURL,
+		dialer:         dialer,
+		headers:        opts.Headers,
 		subscriptions:  make(map[uint64]*Subscription),
 		handlers:       make(map[uint64]AccountUpdateHandler),
-		reconnectDelay: 5 * time.Second,
+		sigSubs:        make(map[uint64]*SignatureSubscription),
+		slotSubs:       make(map[uint64]*SlotSubscription),
+		programSubs:    make(map[uint64]*ProgramSubscription),
+		pending:        make(map[uint64]chan rpcResult),
+		sendQueue:      make(chan outgoingMessage, DefaultSendQueueSize),
+		requestTimeout: requestTimeout,
+		commitment:     commitment,
+		reconnect:      opts.Reconnect,
+		logger:         logging.Component(opts.Logger, "subscription"),
+		lost:           make(chan error, 1),
 		ctx:            clientCtx,
 		cancel:         cancel,
 		nextID:         1,
@@ -109,8 +302,9 @@ func NewWebSocketClient(ctx context.Context, wsURL string) (*WebSocketClient, er
 		return nil, err
 	}
 
-	// Start message reader
+	// Start message reader and the single writer
 	go client.readMessages()
+	go client.writeMessages()
 
 	// Start reconnection handler
 	go client.handleReconnection()
@@ -123,27 +317,236 @@ func (c *WebSocketClient) connect() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
+	conn, _, err := c.dialer.Dial(c.url, c.headers)
 	if err != nil {
 		return fmt.Errorf("failed to connect to WebSocket: %w", err)
 	}
 
 	c.conn = conn
 	c.connected = true
-	log.Printf("WebSocket connected to %s", c.url)
+	c.logger.Info("WebSocket connected", "url", c.url)
 
 	return nil
 }
 
-// SubscribeAccount subscribes to account updates
+// OnStateChange registers a handler for connection state changes
+func (c *WebSocketClient) OnStateChange(handler ConnectionStateHandler) {
+	c.state.add(handler)
+}
+
+// Logger returns the logger the client writes to
+func (c *WebSocketClient) Logger() *slog.Logger {
+	return c.logger
+}
+
+// State returns the current connection state
+func (c *WebSocketClient) State() ConnectionState {
+	return c.state.current()
+}
+
+// SubscribeAccount subscribes to account updates at the client's default
+// commitment. It returns once the node confirmed the subscription, or with the
+// node's error or a timeout.
 func (c *WebSocketClient) SubscribeAccount(accountID string, handler AccountUpdateHandler) (uint64, error) {
+	return c.SubscribeAccountWithCommitment(accountID, "", handler)
+}
+
+// SubscribeAccountWithCommitment subscribes to account updates at the given
+// commitment; empty uses the client's default
+func (c *WebSocketClient) SubscribeAccountWithCommitment(accountID string, commitment string, handler AccountUpdateHandler) (uint64, error) {
+	if commitment == "" {
+		commitment = c.commitment
+	} else if _, err := ParseCommitment(commitment); err != nil {
+		return 0, err
+	}
+
+	// Register the handler first so notifications right after the
+	// confirmation are not lost
 	c.mu.Lock()
 	id := c.nextID
 	c.nextID++
+	c.handlers[id] = handler
+	c.subscriptions[id] = &Subscription{
+		ID:         id,
+		AccountID:  accountID,
+		Commitment: commitment,
+	}
 	c.mu.Unlock()
 
-	// Send subscription request
-	req := RPCRequest{
+	if _, err := c.call(accountSubscribeRequest(id, accountID, commitment)); err != nil {
+		c.mu.Lock()
+		delete(c.subscriptions, id)
+		delete(c.handlers, id)
+		c.mu.Unlock()
+		return 0, fmt.Errorf("failed to subscribe to account %s: %w", accountID, err)
+	}
+
+	return id, nil
+}
+
+// SubscribeSignature subscribes to a transaction signature. The handler is called
+// once when the transaction reaches commitment, after which the node drops the
+// subscription automatically.
+func (c *WebSocketClient) SubscribeSignature(signature string, commitment string, handler SignatureUpdateHandler) (uint64, error) {
+	if commitment == "" {
+		commitment = "confirmed"
+	}
+
+	c.mu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.sigSubs[id] = &SignatureSubscription{
+		ID:         id,
+		Signature:  signature,
+		Commitment: commitment,
+		handler:    handler,
+	}
+	c.mu.Unlock()
+
+	if _, err := c.call(signatureSubscribeRequest(id, signature, commitment)); err != nil {
+		c.mu.Lock()
+		delete(c.sigSubs, id)
+		c.mu.Unlock()
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// UnsubscribeSignature cancels a pending signature subscription
+func (c *WebSocketClient) UnsubscribeSignature(id uint64) error {
+	c.mu.Lock()
+	sub, exists := c.sigSubs[id]
+	delete(c.sigSubs, id)
+	c.mu.Unlock()
+
+	if !exists || sub.SubID == 0 {
+		return nil
+	}
+
+	_, err := c.call(c.unsubscribeRequest("signatureUnsubscribe", sub.SubID))
+	return err
+}
+
+// SignatureResult is the outcome reported by WaitForConfirmation
+type SignatureResult struct {
+	Slot uint64
+	Err  interface{}
+}
+
+// WaitForConfirmation blocks until the transaction reaches commitment or ctx ends.
+// An on-chain failure is returned in SignatureResult.Err with a nil error.
+func (c *WebSocketClient) WaitForConfirmation(ctx context.Context, sig solana.Signature, commitment rpc.CommitmentType) (*SignatureResult, error) {
+	done := make(chan SignatureResult, 1)
+	id, err := c.SubscribeSignature(sig.String(), string(commitment), func(_ string, txErr interface{}, slot uint64) {
+		done <- SignatureResult{Slot: slot, Err: txErr}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to signature: %w", err)
+	}
+
+	select {
+	case result := <-done:
+		return &result, nil
+	case <-ctx.Done():
+		c.UnsubscribeSignature(id)
+		return nil, ctx.Err()
+	}
+}
+
+// SubscribeSlot subscribes to slot notifications. Every notification also
+// advances CurrentSlot, so a nil handler just enables slot tracking.
+func (c *WebSocketClient) SubscribeSlot(handler SlotUpdateHandler) (uint64, error) {
+	c.mu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.slotSubs[id] = &SlotSubscription{ID: id, handler: handler}
+	c.mu.Unlock()
+
+	if _, err := c.call(slotSubscribeRequest(id)); err != nil {
+		c.mu.Lock()
+		delete(c.slotSubs, id)
+		c.mu.Unlock()
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// UnsubscribeSlot cancels a slot subscription
+func (c *WebSocketClient) UnsubscribeSlot(id uint64) error {
+	c.mu.Lock()
+	sub, exists := c.slotSubs[id]
+	delete(c.slotSubs, id)
+	c.mu.Unlock()
+
+	if !exists || sub.SubID == 0 {
+		return nil
+	}
+
+	_, err := c.call(c.unsubscribeRequest("slotUnsubscribe", sub.SubID))
+	return err
+}
+
+// SubscribeProgram subscribes to changes of the accounts owned by program.
+// The handler gets each changed account's address and data, cut to
+// opts.Slice when set.
+func (c *WebSocketClient) SubscribeProgram(program string, opts ProgramOptions, handler AccountUpdateHandler) (uint64, error) {
+	if opts.Commitment == "" {
+		opts.Commitment = c.commitment
+	} else if _, err := ParseCommitment(opts.Commitment); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.programSubs[id] = &ProgramSubscription{ID: id, Program: program, Options: opts, handler: handler}
+	c.mu.Unlock()
+
+	if _, err := c.call(programSubscribeRequest(id, program, opts)); err != nil {
+		c.mu.Lock()
+		delete(c.programSubs, id)
+		c.mu.Unlock()
+		return 0, fmt.Errorf("failed to subscribe to program %s: %w", program, err)
+	}
+
+	return id, nil
+}
+
+// UnsubscribeProgram cancels a program subscription
+func (c *WebSocketClient) UnsubscribeProgram(id uint64) error {
+	c.mu.Lock()
+	sub, exists := c.programSubs[id]
+	delete(c.programSubs, id)
+	c.mu.Unlock()
+
+	if !exists || sub.SubID == 0 {
+		return nil
+	}
+
+	_, err := c.call(c.unsubscribeRequest("programUnsubscribe", sub.SubID))
+	return err
+}
+
+// CurrentSlot returns the latest slot seen via slot or account notifications,
+// zero if none has arrived yet
+func (c *WebSocketClient) CurrentSlot() uint64 {
+	return atomic.LoadUint64(&c.currentSlot)
+}
+
+// observeSlot advances the current slot, ignoring older slots
+func (c *WebSocketClient) observeSlot(slot uint64) {
+	for {
+		current := atomic.LoadUint64(&c.currentSlot)
+		if slot <= current || atomic.CompareAndSwapUint64(&c.currentSlot, current, slot) {
+			return
+		}
+	}
+}
+
+func accountSubscribeRequest(id uint64, accountID, commitment string) RPCRequest {
+	return RPCRequest{
 		JSONRPC: "2.0",
 		ID:      id,
 		Method:  "accountSubscribe",
@@ -151,25 +554,52 @@ func (c *WebSocketClient) SubscribeAccount(accountID string, handler AccountUpda
 			accountID,
 			map[string]interface{}{
 				"encoding":   "base64",
-				"commitment": "confirmed",
+				"commitment": commitment,
 			},
 		},
 	}
+}
 
-	if err := c.sendRequest(req); err != nil {
-		return 0, err
+func programSubscribeRequest(id uint64, program string, opts ProgramOptions) RPCRequest {
+	config := map[string]interface{}{
+		"encoding":   "base64",
+		"commitment": opts.Commitment,
+	}
+	if opts.DataSize > 0 {
+		config["filters"] = []interface{}{map[string]interface{}{"dataSize": opts.DataSize}}
 	}
+	if opts.Slice.Length > 0 {
+		config["dataSlice"] = map[string]interface{}{"offset": opts.Slice.Offset, "length": opts.Slice.Length}
+	}
+	return RPCRequest{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  "programSubscribe",
+		Params:  []interface{}{program, config},
+	}
+}
 
-	// Store handler
-	c.mu.Lock()
-	c.handlers[id] = handler
-	c.subscriptions[id] = &Subscription{
-		ID:        id,
-		AccountID: accountID,
+func slotSubscribeRequest(id uint64) RPCRequest {
+	return RPCRequest{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  "slotSubscribe",
+		Params:  []interface{}{},
 	}
-	c.mu.Unlock()
+}
 
-	return id, nil
+func signatureSubscribeRequest(id uint64, signature, commitment string) RPCRequest {
+	return RPCRequest{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  "signatureSubscribe",
+		Params: []interface{}{
+			signature,
+			map[string]interface{}{
+				"commitment": commitment,
+			},
+		},
+	}
 }
 
 // Unsubscribe removes an account subscription
@@ -193,15 +623,8 @@ func (c *WebSocketClient) Unsubscribe(subID uint64) error {
 	c.mu.Unlock()
 
 	// Send unsubscribe request
-	req := RPCRequest{
-		JSONRPC: "2.0",
-		ID:      subID,
-		Method:  "accountUnsubscribe",
-		Params:  []interface{}{solanaSubID},
-	}
-
-	if err := c.sendRequest(req); err != nil {
-		return err
+	if _, err := c.call(c.unsubscribeRequest("accountUnsubscribe", solanaSubID)); err != nil {
+		return fmt.Errorf("failed to unsubscribe from account %s: %w", sub.AccountID, err)
 	}
 
 	c.mu.Lock()
@@ -212,22 +635,122 @@ func (c *WebSocketClient) Unsubscribe(subID uint64) error {
 	return nil
 }
 
-// sendRequest sends a JSON-RPC request
-func (c *WebSocketClient) sendRequest(req RPCRequest) error {
-	c.mu.RLock()
-	conn := c.conn
-	c.mu.RUnlock()
+// unsubscribeRequest builds an unsubscribe request under a fresh request ID,
+// so its response is not mistaken for a subscription confirmation
+func (c *WebSocketClient) unsubscribeRequest(method string, solanaSubID uint64) RPCRequest {
+	c.mu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.mu.Unlock()
+
+	return RPCRequest{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  []interface{}{solanaSubID},
+	}
+}
+
+// call sends a JSON-RPC request and waits for its response
+func (c *WebSocketClient) call(req RPCRequest) (json.RawMessage, error) {
+	respCh := make(chan rpcResult, 1)
+	c.mu.Lock()
+	c.pending[req.ID] = respCh
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
 
-	if conn == nil {
-		return fmt.Errorf("not connected")
+	if err := c.sendRequest(req); err != nil {
+		return nil, err
 	}
 
+	timer := time.NewTimer(c.requestTimeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-respCh:
+		return res.result, res.err
+	case <-timer.C:
+		return nil, fmt.Errorf("%s request %d timed out after %s", req.Method, req.ID, c.requestTimeout)
+	case <-c.ctx.Done():
+		return nil, c.ctx.Err()
+	}
+}
+
+// sendRequest queues a JSON-RPC request for the writer and waits until it is
+// written. Responses are not awaited; use call for that.
+func (c *WebSocketClient) sendRequest(req RPCRequest) error {
 	data, err := json.Marshal(req)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to encode %s request: %w", req.Method, err)
+	}
+
+	msg := outgoingMessage{data: data, result: make(chan error, 1)}
+	timer := time.NewTimer(c.requestTimeout)
+	defer timer.Stop()
+
+	select {
+	case c.sendQueue <- msg:
+	case <-timer.C:
+		return fmt.Errorf("send queue full, dropped %s request", req.Method)
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+
+	select {
+	case err := <-msg.result:
+		if err != nil {
+			return fmt.Errorf("failed to write %s request: %w", req.Method, err)
+		}
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("timed out writing %s request", req.Method)
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+}
+
+// writeMessages is the only goroutine writing to the connection, since
+// gorilla/websocket connections support a single concurrent writer
+func (c *WebSocketClient) writeMessages() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case msg := <-c.sendQueue:
+			c.mu.RLock()
+			conn := c.conn
+			connected := c.connected
+			c.mu.RUnlock()
+
+			if conn == nil || !connected {
+				msg.result <- fmt.Errorf("not connected")
+				continue
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(c.requestTimeout))
+			msg.result <- conn.WriteMessage(websocket.TextMessage, msg.data)
+		}
 	}
+}
+
+// failPending fails every request still waiting for a response, e.g. after
+// the connection dropped and their responses will never arrive
+func (c *WebSocketClient) failPending(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	return conn.WriteMessage(websocket.TextMessage, data)
+	for id, respCh := range c.pending {
+		select {
+		case respCh <- rpcResult{err: err}:
+		default:
+		}
+		delete(c.pending, id)
+	}
 }
 
 // readMessages reads incoming messages
@@ -250,10 +773,26 @@ func (c *WebSocketClient) readMessages() {
 
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			log.Printf("WebSocket read error: %v", err)
+			if c.ctx.Err() != nil {
+				return
+			}
+			c.logger.Warn("WebSocket read error", "error", err)
+
+			// Drop the broken connection; reads on it would fail forever
 			c.mu.Lock()
 			c.connected = false
+			if c.conn == conn {
+				c.conn = nil
+			}
 			c.mu.Unlock()
+			conn.Close()
+
+			c.failPending(fmt.Errorf("connection lost: %w", err))
+			c.state.notify(StateDisconnected, err)
+			select {
+			case c.lost <- err:
+			default:
+			}
 			continue
 		}
 
@@ -264,45 +803,160 @@ func (c *WebSocketClient) readMessages() {
 // handleMessage processes incoming messages
 func (c *WebSocketClient) handleMessage(data []byte) {
 	// Try to parse as notification first
-	var notification NotificationMessage
-	if err := json.Unmarshal(data, &notification); err == nil && notification.Method == "accountNotification" {
-		c.handleAccountNotification(notification)
-		return
+	var envelope struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(data, &envelope); err == nil {
+		switch envelope.Method {
+		case "accountNotification":
+			var notification NotificationMessage
+			if err := json.Unmarshal(data, &notification); err == nil {
+				c.handleAccountNotification(notification)
+			}
+			return
+		case "slotNotification":
+			var notification SlotNotificationMessage
+			if err := json.Unmarshal(data, &notification); err == nil {
+				c.handleSlotNotification(notification)
+			}
+			return
+		case "signatureNotification":
+			var notification SignatureNotificationMessage
+			if err := json.Unmarshal(data, &notification); err == nil {
+				c.handleSignatureNotification(notification)
+			}
+			return
+		case "programNotification":
+			var notification ProgramNotificationMessage
+			if err := json.Unmarshal(data, &notification); err == nil {
+				c.handleProgramNotification(notification)
+			}
+			return
+		}
 	}
 
 	// Parse as response
 	var response RPCResponse
 	if err := json.Unmarshal(data, &response); err != nil {
-		log.Printf("Failed to parse WebSocket message: %v", err)
+		c.logger.Warn("failed to parse WebSocket message", "error", err)
 		return
 	}
 
 	c.handleResponse(response)
 }
 
-// handleResponse processes RPC responses
+// handleResponse records subscription IDs and hands the response to the
+// caller waiting for it, if any
 func (c *WebSocketClient) handleResponse(response RPCResponse) {
+	c.mu.Lock()
+	respCh, waiting := c.pending[response.ID]
+	delete(c.pending, response.ID)
+
+	// Update subscription with Solana subscription ID before the caller
+	// resumes, so no notification is dropped for an unknown ID
+	var subID uint64
+	if response.Error == nil && json.Unmarshal(response.Result, &subID) == nil {
+		if sub, exists := c.subscriptions[response.ID]; exists {
+			sub.SubID = subID
+		} else if sigSub, exists := c.sigSubs[response.ID]; exists {
+			sigSub.SubID = subID
+		} else if slotSub, exists := c.slotSubs[response.ID]; exists {
+			slotSub.SubID = subID
+		} else if programSub, exists := c.programSubs[response.ID]; exists {
+			programSub.SubID = subID
+		}
+	}
+	c.mu.Unlock()
+
+	var err error
 	if response.Error != nil {
-		log.Printf("RPC error: %s", response.Error.Message)
-		return
+		err = fmt.Errorf("RPC error %d: %s", response.Error.Code, response.Error.Message)
 	}
 
-	// Parse subscription ID from result
-	var subID uint64
-	if err := json.Unmarshal(response.Result, &subID); err != nil {
+	if !waiting {
+		if err != nil {
+			c.logger.Warn("WebSocket request failed", "request", response.ID, "error", err)
+		}
 		return
 	}
+	respCh <- rpcResult{result: response.Result, err: err}
+}
 
-	// Update subscription with Solana subscription ID
+// handleSignatureNotification delivers a signature result and drops the subscription
+func (c *WebSocketClient) handleSignatureNotification(notification SignatureNotificationMessage) {
 	c.mu.Lock()
-	if sub, exists := c.subscriptions[response.ID]; exists {
-		sub.SubID = subID
+	var sub *SignatureSubscription
+	for id, s := range c.sigSubs {
+		if s.SubID == notification.Params.Subscription {
+			sub = s
+			delete(c.sigSubs, id)
+			break
+		}
 	}
 	c.mu.Unlock()
+
+	if sub == nil || sub.handler == nil {
+		return
+	}
+	sub.handler(sub.Signature, notification.Params.Result.Value.Err, notification.Params.Result.Context.Slot)
+}
+
+// handleSlotNotification records the slot and calls the subscription's handler
+func (c *WebSocketClient) handleSlotNotification(notification SlotNotificationMessage) {
+	result := notification.Params.Result
+	c.observeSlot(result.Slot)
+
+	c.mu.RLock()
+	var handler SlotUpdateHandler
+	for _, sub := range c.slotSubs {
+		if sub.SubID == notification.Params.Subscription {
+			handler = sub.handler
+			break
+		}
+	}
+	c.mu.RUnlock()
+
+	if handler != nil {
+		handler(result.Slot, result.Parent, result.Root)
+	}
+}
+
+// handleProgramNotification calls the program subscription's handler with
+// the changed account
+func (c *WebSocketClient) handleProgramNotification(notification ProgramNotificationMessage) {
+	result := notification.Params.Result
+	c.observeSlot(result.Context.Slot)
+
+	c.mu.RLock()
+	var handler AccountUpdateHandler
+	for _, sub := range c.programSubs {
+		if sub.SubID == notification.Params.Subscription {
+			handler = sub.handler
+			break
+		}
+	}
+	c.mu.RUnlock()
+
+	if handler == nil || len(result.Value.Account.Data) < 1 {
+		return
+	}
+	dataStr, ok := result.Value.Account.Data[0].(string)
+	if !ok {
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(dataStr)
+	if err != nil {
+		c.logger.Warn("failed to decode account data", "account", result.Value.Pubkey, "error", err)
+		return
+	}
+
+	handler(result.Value.Pubkey, data, result.Context.Slot)
 }
 
 // handleAccountNotification processes account notifications
 func (c *WebSocketClient) handleAccountNotification(notification NotificationMessage) {
+	c.observeSlot(notification.Params.Result.Context.Slot)
+
 	// Find handler by Solana subscription ID
 	c.mu.RLock()
 	var handler AccountUpdateHandler
@@ -332,79 +986,208 @@ func (c *WebSocketClient) handleAccountNotification(notification NotificationMes
 	if !ok {
 		return
 	}
+	data, err := base64.StdEncoding.DecodeString(dataStr)
+	if err != nil {
+		c.logger.Warn("failed to decode account data", "account", accountID, "error", err)
+		return
+	}
 
 	// Call handler with decoded data
-	handler(accountID, []byte(dataStr), notification.Params.Result.Context.Slot)
+	handler(accountID, data, notification.Params.Result.Context.Slot)
 }
 
-// handleReconnection manages reconnection logic
+// handleReconnection reconnects with exponential backoff after the reader
+// reports a dropped connection, giving up after MaxAttempts failures
 func (c *WebSocketClient) handleReconnection() {
-	ticker := time.NewTicker(c.reconnectDelay)
-	defer ticker.Stop()
+	b := newBackoff(c.reconnect)
 
 	for {
 		select {
 		case <-c.ctx.Done():
 			return
-		case <-ticker.C:
-			c.mu.RLock()
-			connected := c.connected
-			c.mu.RUnlock()
+		case <-c.lost:
+		}
+
+		b.reset()
+		for {
+			delay := b.next()
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			c.logger.Info("reconnecting WebSocket", "attempt", b.attempt)
+			err := c.reconnectAndResubscribe()
+			if err == nil {
+				c.logger.Info("WebSocket reconnected")
+				break
+			}
+			c.logger.Warn("WebSocket reconnection failed", "attempt", b.attempt, "error", err)
 
-			if !connected {
-				log.Printf("Attempting to reconnect WebSocket...")
-				if err := c.reconnect(); err != nil {
-					log.Printf("Reconnection failed: %v", err)
-				} else {
-					log.Printf("WebSocket reconnected successfully")
-				}
+			if b.exhausted() {
+				c.logger.Error("giving up on WebSocket", "attempts", b.attempt)
+				c.state.notify(StateFailed, err)
+				return
 			}
 		}
 	}
 }
 
-// reconnect attempts to reconnect and resubscribe
-func (c *WebSocketClient) reconnect() error {
+// reconnectAndResubscribe reconnects and re-sends every subscription. It waits
+// for the node to answer each one before reporting StateResubscribed, so
+// the state means notifications flow again. Subscriptions still unconfirmed
+// after resubscribeRounds attempts are reported with StateDegraded and
+// retried in the background until they are.
+func (c *WebSocketClient) reconnectAndResubscribe() error {
 	if err := c.connect(); err != nil {
 		return err
 	}
+	c.state.notify(StateConnected, nil)
 
-	// Resubscribe to all accounts
+	// The old connection's subscription IDs are void; notifications for them
+	// must not reach a handler until the node assigns new ones
 	c.mu.Lock()
-	subs := make([]*Subscription, 0, len(c.subscriptions))
-	handlers := make(map[uint64]AccountUpdateHandler)
-
-	for id, sub := range c.subscriptions {
-		subs = append(subs, sub)
-		handlers[id] = c.handlers[id]
+	c.generation++
+	generation := c.generation
+	pending := make([]resubscription, 0, len(c.slotSubs)+len(c.sigSubs)+len(c.subscriptions)+len(c.programSubs))
+	for _, sub := range c.slotSubs {
+		sub.SubID = 0
+		pending = append(pending, resubscription{slotSubscribeRequest(sub.ID), []any{"subscription", "slot"}})
+	}
+	for _, sub := range c.sigSubs {
+		sub.SubID = 0
+		pending = append(pending, resubscription{signatureSubscribeRequest(sub.ID, sub.Signature, sub.Commitment), []any{"signature", sub.Signature}})
+	}
+	for _, sub := range c.subscriptions {
+		sub.SubID = 0
+		pending = append(pending, resubscription{accountSubscribeRequest(sub.ID, sub.AccountID, sub.Commitment), []any{"account", sub.AccountID}})
+	}
+	for _, sub := range c.programSubs {
+		sub.SubID = 0
+		pending = append(pending, resubscription{programSubscribeRequest(sub.ID, sub.Program, sub.Options), []any{"program", sub.Program}})
 	}
 	c.mu.Unlock()
-
-	for _, sub := range subs {
-		req := RPCRequest{
-			JSONRPC: "2.0",
-			ID:      sub.ID,
-			Method:  "accountSubscribe",
-			Params: []interface{}{
-				sub.AccountID,
-				map[string]interface{}{
-					"encoding":   "base64",
-					"commitment": "confirmed",
-				},
-			},
+	total := len(pending)
+
+	// Subscriptions the node fails to confirm are re-sent a few times before
+	// the client settles for StateDegraded
+	b := newBackoff(c.reconnect)
+	for round := 1; ; round++ {
+		pending = c.resubscribe(pending)
+		if len(pending) == 0 || round == resubscribeRounds || !c.IsConnected() {
+			break
+		}
+		select {
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		case <-time.After(b.next()):
 		}
+	}
 
-		if err := c.sendRequest(req); err != nil {
-			log.Printf("Failed to resubscribe to %s: %v", sub.AccountID, err)
+	if !c.IsConnected() {
+		// The reader already reported the drop; retry here rather than
+		// reconnect a second time for the same loss
+		select {
+		case <-c.lost:
+		default:
 		}
+		return fmt.Errorf("connection lost while resubscribing")
+	}
+
+	if len(pending) > 0 {
+		err := fmt.Errorf("%d of %d subscriptions not confirmed after %d attempts", len(pending), total, resubscribeRounds)
+		c.logger.Warn("resubscribed partially, retrying the rest", "error", err)
+		c.state.notify(StateDegraded, err)
+		go c.retryResubscribe(generation, pending, b)
+		return nil
 	}
 
+	c.logger.Info("resubscribed", "subscriptions", total)
+	c.state.notify(StateResubscribed, nil)
 	return nil
 }
 
+// resubscribeRounds is how many times a reconnect sends each subscription
+// before reporting StateDegraded
+const resubscribeRounds = 3
+
+// resubscription is a subscribe request re-sent after a reconnect, with the
+// attributes logged when it fails
+type resubscription struct {
+	req   RPCRequest
+	attrs []any
+}
+
+// resubscribe sends every request concurrently and returns those the node
+// did not confirm. Subscriptions removed since the reconnect are dropped.
+func (c *WebSocketClient) resubscribe(pending []resubscription) []resubscription {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed []resubscription
+	inflight := make(chan struct{}, DefaultSendQueueSize)
+	for _, r := range pending {
+		if !c.registered(r.req.ID) {
+			continue
+		}
+		wg.Add(1)
+		inflight <- struct{}{}
+		go func(r resubscription) {
+			defer func() { <-inflight; wg.Done() }()
+			if _, err := c.call(r.req); err != nil {
+				c.logger.Warn("failed to resubscribe", append(r.attrs, "error", err)...)
+				mu.Lock()
+				failed = append(failed, r)
+				mu.Unlock()
+			}
+		}(r)
+	}
+	wg.Wait()
+	return failed
+}
+
+// registered reports whether a subscription with request ID id still exists
+func (c *WebSocketClient) registered(id uint64) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, account := c.subscriptions[id]
+	_, signature := c.sigSubs[id]
+	_, slot := c.slotSubs[id]
+	_, program := c.programSubs[id]
+	return account || signature || slot || program
+}
+
+// retryResubscribe keeps re-sending the subscriptions a reconnect could not
+// restore and reports StateResubscribed once all are confirmed. It stops when
+// the connection is lost, since the next reconnect re-sends everything.
+func (c *WebSocketClient) retryResubscribe(generation uint64, pending []resubscription, b *backoff) {
+	current := func() bool {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.connected && c.generation == generation
+	}
+
+	for len(pending) > 0 {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(b.next()):
+		}
+		if !current() {
+			return
+		}
+		pending = c.resubscribe(pending)
+	}
+	if current() {
+		c.logger.Info("resubscribed remaining subscriptions")
+		c.state.notify(StateResubscribed, nil)
+	}
+}
+
 // Close closes the WebSocket connection
 func (c *WebSocketClient) Close() error {
 	c.cancel()
+	c.failPending(fmt.Errorf("client closed"))
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -0,0 +1,56 @@
+package swap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/sol"
+)
+
+// ensureTokenAccounts resolves the user's token account for each mint and
+// returns CreateIdempotent instructions for those that do not exist yet.
+// A zero account is replaced with the user's associated token account.
+func (b *Builder) ensureTokenAccounts(ctx context.Context, user solana.PublicKey, mints []solana.PublicKey, accounts []solana.PublicKey) ([]solana.Instruction, []solana.PublicKey, error) {
+	resolved := make([]solana.PublicKey, len(accounts))
+	copy(resolved, accounts)
+
+	createIxs := make(map[int]solana.Instruction)
+	for i, mint := range mints {
+		tokenProgram, err := sol.MintTokenProgram(ctx, b.client, mint)
+		if err != nil {
+			return nil, nil, err
+		}
+		createIx, ata, err := createATAIdempotentInstruction(user, user, mint, tokenProgram)
+		if err != nil {
+			return nil, nil, err
+		}
+		if resolved[i].IsZero() {
+			resolved[i] = ata
+		}
+		if resolved[i].Equals(ata) {
+			createIxs[i] = createIx
+		}
+	}
+	if len(createIxs) == 0 {
+		return nil, resolved, nil
+	}
+
+	existing, err := b.client.GetMultipleAccountsWithOpts(ctx, resolved)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check user token accounts: %w", err)
+	}
+
+	var instructions []solana.Instruction
+	for i := range resolved {
+		createIx, ok := createIxs[i]
+		if !ok {
+			continue
+		}
+		if i < len(existing.Value) && existing.Value[i] != nil {
+			continue
+		}
+		instructions = append(instructions, createIx)
+	}
+	return instructions, resolved, nil
+}
@@ -0,0 +1,184 @@
+package swap
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg"
+	"soltrading/pkg/router"
+	"soltrading/pkg/sol"
+)
+
+// Options controls what the Builder adds around a pool's swap instructions
+type Options struct {
+	ComputeBudget ComputeBudgetOptions
+	// WrapSOL treats WSOL legs as native SOL: the input is wrapped from the
+	// user's SOL balance and WSOL output is unwrapped by closing the WSOL
+	// account at the end. Any WSOL already held in that account is unwrapped too.
+	WrapSOL bool
+	// CreateATAs prepends CreateIdempotent instructions for user token accounts
+	// that do not exist yet, using Token-2022 where the mint requires it. Zero
+	// user accounts are resolved to the user's associated token accounts.
+	CreateATAs bool
+}
+
+// Builder assembles complete swap instruction sets around a pool's own
+// BuildSwapInstructions output
+type Builder struct {
+	client sol.SolClient
+	opts   Options
+}
+
+// NewBuilder creates a swap builder. Deriving the compute budget from the
+// fee market or a simulation needs a client that is also a BudgetClient, such
+// as *sol.Client; any sol.SolClient, e.g. a mock, serves the rest.
+func NewBuilder(client sol.SolClient, opts Options) *Builder {
+	return &Builder{
+		client: client,
+		opts:   opts,
+	}
+}
+
+// BuildSwapInstructions builds the pool swap instructions and surrounds them with
+// the extras enabled in the builder options. An inputMint of native SOL is
+// always wrapped.
+func (b *Builder) BuildSwapInstructions(
+	ctx context.Context,
+	pool pkg.Pool,
+	user solana.PublicKey,
+	inputMint string,
+	amountIn math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	return b.build(ctx, pool, user, inputMint, amountIn, minOut, userBaseAccount, userQuoteAccount, false, false)
+}
+
+// BuildRouteInstructions builds the swap instructions of a router route,
+// wrapping and unwrapping the legs the route marks as native SOL on top of
+// what the builder options enable
+func (b *Builder) BuildRouteInstructions(
+	ctx context.Context,
+	route router.Route,
+	user solana.PublicKey,
+	inputMint string,
+	amountIn math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	return b.build(ctx, route.Pool, user, inputMint, amountIn, minOut, userBaseAccount, userQuoteAccount, route.WrapInput, route.UnwrapOutput)
+}
+
+// build assembles the instructions; wrapInput and unwrapOutput force native
+// SOL handling of the input or a WSOL output
+func (b *Builder) build(
+	ctx context.Context,
+	pool pkg.Pool,
+	user solana.PublicKey,
+	inputMint string,
+	amountIn math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+	wrapInput, unwrapOutput bool,
+) ([]solana.Instruction, error) {
+	var pre, post []solana.Instruction
+
+	// Pools trade native SOL as WSOL
+	if router.IsNativeSOL(inputMint) {
+		wrapInput = true
+	}
+	inputMint = router.PoolMint(inputMint)
+
+	wrapped := false
+	if b.opts.WrapSOL || wrapInput || unwrapOutput {
+		baseMint, quoteMint := pool.GetTokens()
+		inputIsSOL := wrapInput || (b.opts.WrapSOL && isSOLMint(inputMint))
+		outputMint := baseMint
+		if inputMint == baseMint {
+			outputMint = quoteMint
+		}
+		outputIsSOL := (b.opts.WrapSOL || unwrapOutput) && isSOLMint(outputMint)
+
+		if inputIsSOL || outputIsSOL {
+			wrapped = true
+			wrapAmount := uint64(0)
+			if inputIsSOL {
+				wrapAmount = amountIn.Uint64()
+			}
+			wrapIxs, wsolAccount, err := wrapSOLInstructions(user, wrapAmount)
+			if err != nil {
+				return nil, err
+			}
+			unwrapIx, err := unwrapSOLInstruction(user, wsolAccount)
+			if err != nil {
+				return nil, err
+			}
+			pre = append(pre, wrapIxs...)
+			post = append(post, unwrapIx)
+
+			if baseMint == sol.WSOL.String() {
+				userBaseAccount = wsolAccount
+			} else {
+				userQuoteAccount = wsolAccount
+			}
+		}
+	}
+
+	if b.opts.CreateATAs {
+		baseMint, quoteMint := pool.GetTokens()
+		var mints, accounts []solana.PublicKey
+		var targets []*solana.PublicKey
+		for _, side := range []struct {
+			mint    string
+			account *solana.PublicKey
+		}{
+			{baseMint, &userBaseAccount},
+			{quoteMint, &userQuoteAccount},
+		} {
+			// The WSOL account is already created by the wrap instructions
+			if wrapped && isSOLMint(side.mint) {
+				continue
+			}
+			mint, err := solana.PublicKeyFromBase58(side.mint)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pool mint %s: %w", side.mint, err)
+			}
+			mints = append(mints, mint)
+			accounts = append(accounts, *side.account)
+			targets = append(targets, side.account)
+		}
+
+		createIxs, resolved, err := b.ensureTokenAccounts(ctx, user, mints, accounts)
+		if err != nil {
+			return nil, err
+		}
+		for i, target := range targets {
+			*target = resolved[i]
+		}
+		pre = append(createIxs, pre...)
+	}
+
+	swapInstructions, err := pool.BuildSwapInstructions(ctx, b.client, user, inputMint, amountIn, minOut, userBaseAccount, userQuoteAccount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s swap instructions: %w", pool.ProtocolName(), err)
+	}
+
+	instructions := make([]solana.Instruction, 0, len(pre)+len(swapInstructions)+len(post))
+	instructions = append(instructions, pre...)
+	instructions = append(instructions, swapInstructions...)
+	instructions = append(instructions, post...)
+	if b.opts.ComputeBudget.Enabled {
+		budget, err := b.computeBudgetInstructions(ctx, user, instructions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute budget: %w", err)
+		}
+		instructions = append(budget, instructions...)
+	}
+
+	return instructions, nil
+}
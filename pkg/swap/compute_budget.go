@@ -0,0 +1,130 @@
+package swap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"soltrading/pkg/sol"
+)
+
+const (
+	// MaxComputeUnitLimit is the per-transaction compute unit ceiling
+	MaxComputeUnitLimit uint32 = 1_400_000
+	// DefaultComputeUnitMarginBps pads simulated compute units by 20%
+	DefaultComputeUnitMarginBps = 2000
+)
+
+// BudgetClient simulates transactions and reads the priority fee market, which
+// compute budget estimation needs beyond sol.SolClient
+type BudgetClient interface {
+	Simulate(ctx context.Context, tx *solana.Transaction, outputTokenAccount solana.PublicKey) (*sol.SimulationResult, error)
+	SuggestPriorityFee(ctx context.Context, writableAccounts []solana.PublicKey) (*sol.PriorityFeeEstimate, error)
+}
+
+var _ BudgetClient = (*sol.Client)(nil)
+
+// budgetClient returns the builder's client as a BudgetClient
+func (b *Builder) budgetClient() (BudgetClient, error) {
+	client, ok := b.client.(BudgetClient)
+	if !ok {
+		return nil, fmt.Errorf("client %T cannot simulate or price transactions; set ComputeUnitLimit and ComputeUnitPrice", b.client)
+	}
+	return client, nil
+}
+
+// ComputeBudgetOptions controls the compute budget instructions prepended to a swap
+type ComputeBudgetOptions struct {
+	// Enabled turns compute budget injection on
+	Enabled bool
+	// ComputeUnitLimit is a fixed CU limit; zero derives it from simulation
+	ComputeUnitLimit uint32
+	// ComputeUnitMarginBps pads the simulated CU usage
+	ComputeUnitMarginBps int
+	// ComputeUnitPrice is a fixed price in micro-lamports per CU; zero uses the fee market
+	ComputeUnitPrice uint64
+	// PriorityFeePercentile selects the fee-market percentile (default 75)
+	PriorityFeePercentile int
+	// MaxComputeUnitPrice caps the fee-market price; zero means no cap
+	MaxComputeUnitPrice uint64
+}
+
+// computeBudgetInstructions returns SetComputeUnitLimit/SetComputeUnitPrice
+// instructions for the given swap instructions
+func (b *Builder) computeBudgetInstructions(ctx context.Context, payer solana.PublicKey, instructions []solana.Instruction) ([]solana.Instruction, error) {
+	opts := b.opts.ComputeBudget
+
+	limit := opts.ComputeUnitLimit
+	if limit == 0 {
+		units, err := b.simulateComputeUnits(ctx, payer, instructions)
+		if err != nil {
+			return nil, err
+		}
+		margin := opts.ComputeUnitMarginBps
+		if margin == 0 {
+			margin = DefaultComputeUnitMarginBps
+		}
+		padded := units * uint64(10000+margin) / 10000
+		if padded > uint64(MaxComputeUnitLimit) {
+			padded = uint64(MaxComputeUnitLimit)
+		}
+		limit = uint32(padded)
+	}
+
+	price := opts.ComputeUnitPrice
+	if price == 0 {
+		percentile := opts.PriorityFeePercentile
+		if percentile == 0 {
+			percentile = 75
+		}
+		client, err := b.budgetClient()
+		if err != nil {
+			return nil, err
+		}
+		estimate, err := client.SuggestPriorityFee(ctx, sol.WritableAccounts(instructions))
+		if err != nil {
+			return nil, err
+		}
+		price = estimate.Percentile(percentile)
+		if opts.MaxComputeUnitPrice > 0 && price > opts.MaxComputeUnitPrice {
+			price = opts.MaxComputeUnitPrice
+		}
+	}
+
+	budget := []solana.Instruction{
+		computebudget.NewSetComputeUnitLimitInstruction(limit).Build(),
+	}
+	if price > 0 {
+		budget = append(budget, computebudget.NewSetComputeUnitPriceInstruction(price).Build())
+	}
+	return budget, nil
+}
+
+// simulateComputeUnits simulates the instructions under the maximum CU limit and
+// returns the units consumed. Signatures are left blank; the node replaces the
+// blockhash and does not verify signatures.
+func (b *Builder) simulateComputeUnits(ctx context.Context, payer solana.PublicKey, instructions []solana.Instruction) (uint64, error) {
+	withLimit := append([]solana.Instruction{
+		computebudget.NewSetComputeUnitLimitInstruction(MaxComputeUnitLimit).Build(),
+	}, instructions...)
+
+	tx, err := solana.NewTransaction(withLimit, solana.Hash{}, solana.TransactionPayer(payer))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create simulation transaction: %w", err)
+	}
+	tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+
+	client, err := b.budgetClient()
+	if err != nil {
+		return 0, err
+	}
+	result, err := client.Simulate(ctx, tx, solana.PublicKey{})
+	if err != nil {
+		return 0, err
+	}
+	if !result.Success {
+		return 0, fmt.Errorf("swap simulation failed: %v", result.Err)
+	}
+	return result.UnitsConsumed, nil
+}
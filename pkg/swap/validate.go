@@ -0,0 +1,181 @@
+package swap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg/sol"
+)
+
+// Validation errors. Use errors.Is to distinguish funding problems from routing failures.
+var (
+	ErrInsufficientFunds         = errors.New("insufficient token balance")
+	ErrInsufficientSOL           = errors.New("insufficient SOL for fees and rent")
+	ErrTokenAccountNotFound      = errors.New("token account not found")
+	ErrTokenAccountFrozen        = errors.New("token account is frozen")
+	ErrTokenAccountMismatch      = errors.New("token account does not match mint or owner")
+	ErrTokenAccountUninitialized = errors.New("token account is not initialized")
+)
+
+const (
+	// DefaultFeeReserveLamports covers signature and priority fees for a swap
+	DefaultFeeReserveLamports uint64 = 10_000_000
+	// TokenAccountRentLamports is the rent-exempt minimum for a 165-byte token account
+	TokenAccountRentLamports uint64 = 2_039_280
+)
+
+// InsufficientBalanceError reports how much of an asset was needed and available
+type InsufficientBalanceError struct {
+	Mint      string
+	Required  uint64
+	Available uint64
+	sentinel  error
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("%v: %s requires %d, have %d", e.sentinel, e.Mint, e.Required, e.Available)
+}
+
+func (e *InsufficientBalanceError) Unwrap() error {
+	return e.sentinel
+}
+
+// TokenAccountError reports a problem with a specific token account
+type TokenAccountError struct {
+	Account solana.PublicKey
+	Err     error
+}
+
+func (e *TokenAccountError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Err, e.Account)
+}
+
+func (e *TokenAccountError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationParams describes a swap about to be built
+type ValidationParams struct {
+	User      solana.PublicKey
+	InputMint solana.PublicKey
+	AmountIn  uint64
+	// InputAccount is the user's input token account; zero means the ATA
+	InputAccount solana.PublicKey
+	// OutputMint and OutputAccount describe the receiving side; OutputAccount may
+	// be zero or missing when CreateATAs is used
+	OutputMint    solana.PublicKey
+	OutputAccount solana.PublicKey
+	// WrapSOL means a SOL input is paid from the native balance. A mint of
+	// native SOL always is.
+	WrapSOL bool
+	// FeeReserveLamports is SOL kept aside for fees; zero uses DefaultFeeReserveLamports
+	FeeReserveLamports uint64
+}
+
+// Validate checks that the user can pay for the swap and that their token
+// accounts are usable. Errors wrap the sentinel values above.
+func Validate(ctx context.Context, client sol.SolClient, params ValidationParams) error {
+	feeReserve := params.FeeReserveLamports
+	if feeReserve == 0 {
+		feeReserve = DefaultFeeReserveLamports
+	}
+	requiredLamports := feeReserve
+
+	inputIsNative := (params.WrapSOL && isSOLMint(params.InputMint.String())) || params.InputMint.Equals(sol.NativeSOL)
+	if inputIsNative {
+		requiredLamports += params.AmountIn
+	} else {
+		inputAccount, err := resolveTokenAccount(ctx, client, params.User, params.InputMint, params.InputAccount)
+		if err != nil {
+			return err
+		}
+		info, err := client.GetAccountInfoWithOpts(ctx, inputAccount)
+		if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+			return fmt.Errorf("failed to fetch input token account: %w", err)
+		}
+		if info == nil || info.Value == nil {
+			return &TokenAccountError{Account: inputAccount, Err: ErrTokenAccountNotFound}
+		}
+		amount, err := checkTokenAccount(inputAccount, info.Value.Data.GetBinary(), params.InputMint, params.User)
+		if err != nil {
+			return err
+		}
+		if amount < params.AmountIn {
+			return &InsufficientBalanceError{
+				Mint:      params.InputMint.String(),
+				Required:  params.AmountIn,
+				Available: amount,
+				sentinel:  ErrInsufficientFunds,
+			}
+		}
+	}
+
+	if !params.OutputMint.IsZero() {
+		if (params.WrapSOL && isSOLMint(params.OutputMint.String())) || params.OutputMint.Equals(sol.NativeSOL) {
+			requiredLamports += TokenAccountRentLamports
+		} else {
+			outputAccount, err := resolveTokenAccount(ctx, client, params.User, params.OutputMint, params.OutputAccount)
+			if err != nil {
+				return err
+			}
+			info, err := client.GetAccountInfoWithOpts(ctx, outputAccount)
+			if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+				return fmt.Errorf("failed to fetch output token account: %w", err)
+			}
+			if info == nil || info.Value == nil {
+				// Will be created by the builder; the user pays its rent
+				requiredLamports += TokenAccountRentLamports
+			} else if _, err := checkTokenAccount(outputAccount, info.Value.Data.GetBinary(), params.OutputMint, params.User); err != nil {
+				return err
+			}
+		}
+	}
+	if inputIsNative {
+		requiredLamports += TokenAccountRentLamports
+	}
+
+	balance, err := client.GetBalance(ctx, params.User, rpc.CommitmentConfirmed)
+	if err != nil {
+		return fmt.Errorf("failed to fetch SOL balance: %w", err)
+	}
+	if balance.Value < requiredLamports {
+		return &InsufficientBalanceError{
+			Mint:      sol.NativeSOL.String(),
+			Required:  requiredLamports,
+			Available: balance.Value,
+			sentinel:  ErrInsufficientSOL,
+		}
+	}
+
+	return nil
+}
+
+// resolveTokenAccount returns account, or the user's ATA for mint when account is zero
+func resolveTokenAccount(ctx context.Context, client sol.SolClient, user, mint, account solana.PublicKey) (solana.PublicKey, error) {
+	if !account.IsZero() {
+		return account, nil
+	}
+	tokenProgram, err := sol.MintTokenProgram(ctx, client, mint)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return associatedTokenAddress(user, mint, tokenProgram)
+}
+
+// checkTokenAccount verifies mint, owner and state and returns the balance
+func checkTokenAccount(account solana.PublicKey, data []byte, mint, owner solana.PublicKey) (uint64, error) {
+	parsed, err := sol.ParseTokenAccount(data)
+	if err != nil || !parsed.Mint.Equals(mint) || !parsed.Owner.Equals(owner) {
+		return 0, &TokenAccountError{Account: account, Err: ErrTokenAccountMismatch}
+	}
+	switch parsed.State {
+	case sol.TokenAccountUninitialized:
+		return 0, &TokenAccountError{Account: account, Err: ErrTokenAccountUninitialized}
+	case sol.TokenAccountFrozen:
+		return 0, &TokenAccountError{Account: account, Err: ErrTokenAccountFrozen}
+	}
+	return parsed.Amount, nil
+}
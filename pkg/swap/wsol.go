@@ -0,0 +1,80 @@
+package swap
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"soltrading/pkg/sol"
+)
+
+// isSOLMint reports whether mint is WSOL or the native SOL placeholder
+func isSOLMint(mint string) bool {
+	return mint == sol.WSOL.String() || mint == sol.NativeSOL.String()
+}
+
+// associatedTokenAddress derives the ATA for owner and mint under tokenProgram
+func associatedTokenAddress(owner, mint, tokenProgram solana.PublicKey) (solana.PublicKey, error) {
+	ata, _, err := solana.FindProgramAddress(
+		[][]byte{owner[:], tokenProgram[:], mint[:]},
+		solana.SPLAssociatedTokenAccountProgramID,
+	)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive associated token account: %w", err)
+	}
+	return ata, nil
+}
+
+// createATAIdempotentInstruction builds the associated token account program's
+// CreateIdempotent instruction, which succeeds if the account already exists
+func createATAIdempotentInstruction(payer, owner, mint, tokenProgram solana.PublicKey) (solana.Instruction, solana.PublicKey, error) {
+	ata, err := associatedTokenAddress(owner, mint, tokenProgram)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.NewAccountMeta(payer, true, true),
+		solana.NewAccountMeta(ata, true, false),
+		solana.NewAccountMeta(owner, false, false),
+		solana.NewAccountMeta(mint, false, false),
+		solana.NewAccountMeta(solana.SystemProgramID, false, false),
+		solana.NewAccountMeta(tokenProgram, false, false),
+	}
+	// Instruction index 1 is CreateIdempotent
+	return solana.NewInstruction(solana.SPLAssociatedTokenAccountProgramID, accounts, []byte{1}), ata, nil
+}
+
+// wrapSOLInstructions creates the user's WSOL account if needed and funds it
+// with amount lamports
+func wrapSOLInstructions(user solana.PublicKey, amount uint64) ([]solana.Instruction, solana.PublicKey, error) {
+	createIx, wsolAccount, err := createATAIdempotentInstruction(user, user, sol.WSOL, solana.TokenProgramID)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+
+	instructions := []solana.Instruction{createIx}
+	if amount > 0 {
+		transferIx, err := system.NewTransferInstruction(amount, user, wsolAccount).ValidateAndBuild()
+		if err != nil {
+			return nil, solana.PublicKey{}, fmt.Errorf("failed to build wrap transfer: %w", err)
+		}
+		syncIx, err := token.NewSyncNativeInstruction(wsolAccount).ValidateAndBuild()
+		if err != nil {
+			return nil, solana.PublicKey{}, fmt.Errorf("failed to build syncNative: %w", err)
+		}
+		instructions = append(instructions, transferIx, syncIx)
+	}
+	return instructions, wsolAccount, nil
+}
+
+// unwrapSOLInstruction closes the user's WSOL account, returning all of its
+// lamports (wrapped balance plus rent) to the user
+func unwrapSOLInstruction(user, wsolAccount solana.PublicKey) (solana.Instruction, error) {
+	closeIx, err := token.NewCloseAccountInstruction(wsolAccount, user, user, []solana.PublicKey{}).ValidateAndBuild()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build WSOL close: %w", err)
+	}
+	return closeIx, nil
+}
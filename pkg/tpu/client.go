@@ -0,0 +1,200 @@
+package tpu
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/quic-go/quic-go"
+	"soltrading/pkg/logging"
+	"soltrading/pkg/sol"
+)
+
+// Config configures direct TPU submission
+type Config struct {
+	// FanoutSlots is how many upcoming slots' leaders receive each transaction
+	FanoutSlots int
+	// LookaheadSlots is how many slots of leader schedule are fetched per refresh
+	LookaheadSlots uint64
+	// RefreshInterval is how often the leader schedule is refreshed
+	RefreshInterval time.Duration
+	// DialTimeout bounds QUIC handshakes with a leader
+	DialTimeout time.Duration
+	// Identity signs the client certificate; a random key is used when empty
+	Identity solana.PrivateKey
+}
+
+// DefaultConfig sends to the leaders of the next 12 slots (three leader rotations)
+func DefaultConfig() Config {
+	return Config{
+		FanoutSlots:     12,
+		LookaheadSlots:  100,
+		RefreshInterval: 10 * time.Second,
+		DialTimeout:     2 * time.Second,
+	}
+}
+
+// Client submits transactions directly to upcoming leaders over QUIC. It
+// satisfies sol.TPUSender so a sol.Sender can use it alongside RPC.
+type Client struct {
+	cfg     Config
+	tracker *LeaderTracker
+	tlsConf *tls.Config
+
+	mu    sync.Mutex
+	conns map[string]*quic.Conn
+}
+
+// NewClient creates a TPU client and loads the initial leader schedule
+func NewClient(ctx context.Context, client *sol.Client, cfg Config) (*Client, error) {
+	defaults := DefaultConfig()
+	if cfg.FanoutSlots <= 0 {
+		cfg.FanoutSlots = defaults.FanoutSlots
+	}
+	if cfg.LookaheadSlots == 0 {
+		cfg.LookaheadSlots = defaults.LookaheadSlots
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaults.RefreshInterval
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = defaults.DialTimeout
+	}
+
+	tlsConf, err := newTLSConfig(cfg.Identity)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := NewLeaderTracker(client, cfg.LookaheadSlots)
+	if err := tracker.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load leader schedule: %w", err)
+	}
+
+	return &Client{
+		cfg:     cfg,
+		tracker: tracker,
+		tlsConf: tlsConf,
+		conns:   make(map[string]*quic.Conn),
+	}, nil
+}
+
+// Start keeps the leader schedule fresh until ctx is cancelled
+func (c *Client) Start(ctx context.Context) {
+	go c.tracker.Run(ctx, c.cfg.RefreshInterval)
+}
+
+// SendTransaction serializes tx and sends it to the upcoming leaders
+func (c *Client) SendTransaction(ctx context.Context, tx *solana.Transaction) error {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	return c.SendRaw(ctx, raw)
+}
+
+// SendRaw sends a serialized transaction to the upcoming leaders concurrently.
+// It succeeds if at least one leader accepted the stream.
+func (c *Client) SendRaw(ctx context.Context, raw []byte) error {
+	addresses := c.tracker.UpcomingAddresses(c.cfg.FanoutSlots)
+	if len(addresses) == 0 {
+		return fmt.Errorf("no upcoming leader with a TPU QUIC address")
+	}
+
+	errs := make(chan error, len(addresses))
+	for _, address := range addresses {
+		go func(address string) {
+			errs <- c.sendTo(ctx, address, raw)
+		}(address)
+	}
+
+	var lastErr error
+	delivered := 0
+	for range addresses {
+		if err := <-errs; err != nil {
+			lastErr = err
+		} else {
+			delivered++
+		}
+	}
+	if delivered == 0 {
+		return fmt.Errorf("failed to send to %d leaders: %w", len(addresses), lastErr)
+	}
+	return nil
+}
+
+// sendTo writes raw on a new unidirectional stream, redialing once if the
+// cached connection has gone away
+func (c *Client) sendTo(ctx context.Context, address string, raw []byte) error {
+	for attempt := 0; attempt < 2; attempt++ {
+		conn, err := c.connection(ctx, address)
+		if err != nil {
+			return err
+		}
+		stream, err := conn.OpenUniStream()
+		if err != nil {
+			c.drop(address, conn)
+			continue
+		}
+		if _, err := stream.Write(raw); err != nil {
+			c.drop(address, conn)
+			continue
+		}
+		return stream.Close()
+	}
+	return fmt.Errorf("failed to open stream to %s", address)
+}
+
+// connection returns the cached connection to address, dialing when needed
+func (c *Client) connection(ctx context.Context, address string) (*quic.Conn, error) {
+	c.mu.Lock()
+	conn, ok := c.conns[address]
+	c.mu.Unlock()
+	if ok && conn.Context().Err() == nil {
+		return conn, nil
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, c.cfg.DialTimeout)
+	defer cancel()
+	conn, err := quic.DialAddr(dialCtx, address, c.tlsConf, &quic.Config{
+		MaxIdleTimeout:  30 * time.Second,
+		KeepAlivePeriod: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial leader %s: %w", address, err)
+	}
+
+	c.mu.Lock()
+	if existing, ok := c.conns[address]; ok && existing.Context().Err() == nil {
+		c.mu.Unlock()
+		conn.CloseWithError(0, "")
+		return existing, nil
+	}
+	c.conns[address] = conn
+	c.mu.Unlock()
+	return conn, nil
+}
+
+func (c *Client) drop(address string, conn *quic.Conn) {
+	c.mu.Lock()
+	if c.conns[address] == conn {
+		delete(c.conns, address)
+	}
+	c.mu.Unlock()
+	conn.CloseWithError(0, "")
+}
+
+// Close closes all leader connections
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for address, conn := range c.conns {
+		if err := conn.CloseWithError(0, ""); err != nil {
+			logging.Component(nil, "tpu").Warn("failed to close connection", "address", address, "error", err)
+		}
+		delete(c.conns, address)
+	}
+}
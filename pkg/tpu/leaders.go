@@ -0,0 +1,120 @@
+package tpu
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg/logging"
+	"soltrading/pkg/sol"
+)
+
+// slotDuration is the target slot time used to extrapolate the current slot
+// between leader schedule refreshes
+const slotDuration = 400 * time.Millisecond
+
+// clusterNodesTTL is how long the gossip view of TPU addresses is reused
+const clusterNodesTTL = 5 * time.Minute
+
+// LeaderTracker keeps the upcoming leader schedule and the TPU QUIC address of
+// every validator
+type LeaderTracker struct {
+	client    *sol.Client
+	lookahead uint64
+
+	mu          sync.RWMutex
+	addresses   map[solana.PublicKey]string
+	nodesAt     time.Time
+	startSlot   uint64
+	leaders     []solana.PublicKey
+	refreshedAt time.Time
+}
+
+// NewLeaderTracker creates a tracker fetching lookahead slots of leaders per refresh
+func NewLeaderTracker(client *sol.Client, lookahead uint64) *LeaderTracker {
+	return &LeaderTracker{
+		client:    client,
+		lookahead: lookahead,
+		addresses: make(map[solana.PublicKey]string),
+	}
+}
+
+// Refresh reloads the slot leaders from the current slot and, when stale, the
+// cluster nodes
+func (t *LeaderTracker) Refresh(ctx context.Context) error {
+	t.mu.RLock()
+	stale := time.Since(t.nodesAt) > clusterNodesTTL
+	t.mu.RUnlock()
+
+	if stale {
+		nodes, err := t.client.GetClusterNodes(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get cluster nodes: %w", err)
+		}
+		addresses := make(map[solana.PublicKey]string, len(nodes))
+		for _, node := range nodes {
+			if node.TPUQUIC != nil && *node.TPUQUIC != "" {
+				addresses[node.Pubkey] = *node.TPUQUIC
+			}
+		}
+		t.mu.Lock()
+		t.addresses = addresses
+		t.nodesAt = time.Now()
+		t.mu.Unlock()
+	}
+
+	slot, err := t.client.GetSlot(ctx, rpc.CommitmentProcessed)
+	if err != nil {
+		return fmt.Errorf("failed to get slot: %w", err)
+	}
+	leaders, err := t.client.GetSlotLeaders(ctx, slot, t.lookahead)
+	if err != nil {
+		return fmt.Errorf("failed to get slot leaders: %w", err)
+	}
+
+	t.mu.Lock()
+	t.startSlot = slot
+	t.leaders = leaders
+	t.refreshedAt = time.Now()
+	t.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the schedule every interval until ctx is cancelled
+func (t *LeaderTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Refresh(ctx); err != nil {
+				logging.Component(nil, "tpu").Warn("leader schedule refresh failed", "error", err)
+			}
+		}
+	}
+}
+
+// UpcomingAddresses returns the distinct TPU QUIC addresses of the leaders of
+// the next slots, starting at the estimated current slot
+func (t *LeaderTracker) UpcomingAddresses(slots int) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	offset := int(time.Since(t.refreshedAt) / slotDuration)
+	seen := make(map[string]bool)
+	var addresses []string
+	for i := offset; i < offset+slots && i < len(t.leaders); i++ {
+		address, ok := t.addresses[t.leaders[i]]
+		if !ok || seen[address] {
+			continue
+		}
+		seen[address] = true
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
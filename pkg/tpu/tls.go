@@ -0,0 +1,51 @@
+package tpu
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// alpnProtocol is the ALPN identifier validators expect on TPU QUIC connections
+const alpnProtocol = "solana-tpu"
+
+// newTLSConfig builds the client TLS config. Validators identify clients by an
+// ed25519 self-signed certificate; using a staked identity key earns stake-weighted
+// QoS, any other key is treated as unstaked.
+func newTLSConfig(identity solana.PrivateKey) (*tls.Config, error) {
+	var key ed25519.PrivateKey
+	if len(identity) == ed25519.PrivateKeySize {
+		key = ed25519.PrivateKey(identity)
+	} else {
+		_, generated, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate identity key: %w", err)
+		}
+		key = generated
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Solana node"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client certificate: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		// Validator certificates are self-signed
+		InsecureSkipVerify: true,
+		NextProtos:         []string{alpnProtocol},
+	}, nil
+}
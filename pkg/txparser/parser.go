@@ -0,0 +1,286 @@
+package txparser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+)
+
+// SPL token instruction tags carrying an amount
+const (
+	tokenInstructionTransfer        = 3
+	tokenInstructionTransferChecked = 12
+)
+
+// SwapLeg is one DEX swap found in a transaction
+type SwapLeg struct {
+	Protocol   pkg.ProtocolName `json:"protocol"`
+	Program    string           `json:"program"`
+	Pool       string           `json:"pool"`
+	Trader     string           `json:"trader"`
+	InputMint  string           `json:"inputMint"`
+	OutputMint string           `json:"outputMint"`
+	AmountIn   uint64           `json:"amountIn"`
+	AmountOut  uint64           `json:"amountOut"`
+	// Inner is true when the DEX was invoked through CPI (e.g. by an aggregator)
+	Inner bool `json:"inner"`
+}
+
+// TokenDelta is the net token balance change of an owner for a mint
+type TokenDelta struct {
+	Owner string `json:"owner"`
+	Mint  string `json:"mint"`
+	Delta int64  `json:"delta"`
+}
+
+// ParsedTransaction is the swap-relevant content of a confirmed transaction
+type ParsedTransaction struct {
+	Signature       string       `json:"signature"`
+	Slot            uint64       `json:"slot"`
+	Signer          string       `json:"signer"`
+	Fee             uint64       `json:"fee"`
+	Err             interface{}  `json:"err,omitempty"`
+	Legs            []SwapLeg    `json:"legs"`
+	TokenDeltas     []TokenDelta `json:"tokenDeltas"`
+	InvokedPrograms []string     `json:"invokedPrograms"`
+}
+
+// tokenAccountInfo is what the metadata tells us about a token account
+type tokenAccountInfo struct {
+	mint  string
+	owner string
+}
+
+// transfer is an SPL token transfer observed in inner instructions
+type transfer struct {
+	source      solana.PublicKey
+	destination solana.PublicKey
+	authority   solana.PublicKey
+	amount      uint64
+}
+
+// Parse extracts swap legs and balance deltas from a transaction fetched with
+// base64 encoding (as sol.Client.GetTransaction does)
+func Parse(result *rpc.GetTransactionResult) (*ParsedTransaction, error) {
+	if result == nil || result.Transaction == nil || result.Meta == nil {
+		return nil, fmt.Errorf("transaction result has no body or metadata")
+	}
+
+	tx, err := result.Transaction.GetTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	meta := result.Meta
+
+	// Static keys followed by keys loaded from lookup tables
+	keys := append(solana.PublicKeySlice{}, tx.Message.AccountKeys...)
+	keys = append(keys, meta.LoadedAddresses.Writable...)
+	keys = append(keys, meta.LoadedAddresses.ReadOnly...)
+
+	parsed := &ParsedTransaction{
+		Slot:            result.Slot,
+		Fee:             meta.Fee,
+		Err:             meta.Err,
+		InvokedPrograms: InvokedPrograms(meta.LogMessages),
+	}
+	if len(tx.Signatures) > 0 {
+		parsed.Signature = tx.Signatures[0].String()
+	}
+	if len(keys) > 0 {
+		parsed.Signer = keys[0].String()
+	}
+
+	accounts := tokenAccounts(meta, keys)
+	parsed.TokenDeltas = tokenDeltas(meta)
+
+	innerByIndex := make(map[uint16][]solana.CompiledInstruction)
+	for _, inner := range meta.InnerInstructions {
+		innerByIndex[inner.Index] = inner.Instructions
+	}
+
+	for i, ix := range tx.Message.Instructions {
+		inner := innerByIndex[uint16(i)]
+		if dex, ok := KnownPrograms[keyAt(keys, ix.ProgramIDIndex)]; ok {
+			parsed.Legs = append(parsed.Legs, buildLeg(dex, ix, inner, keys, accounts, false))
+			continue
+		}
+
+		// Look for DEX programs invoked by CPI, e.g. through an aggregator. Token
+		// transfers following a DEX instruction are attributed to it.
+		for j, innerIx := range inner {
+			dex, ok := KnownPrograms[keyAt(keys, innerIx.ProgramIDIndex)]
+			if !ok {
+				continue
+			}
+			end := j + 1
+			for end < len(inner) {
+				if _, next := KnownPrograms[keyAt(keys, inner[end].ProgramIDIndex)]; next {
+					break
+				}
+				end++
+			}
+			parsed.Legs = append(parsed.Legs, buildLeg(dex, innerIx, inner[j+1:end], keys, accounts, true))
+		}
+	}
+
+	return parsed, nil
+}
+
+// buildLeg derives pool, mints and amounts for one DEX instruction
+func buildLeg(dex DexProgram, ix solana.CompiledInstruction, following []solana.CompiledInstruction, keys solana.PublicKeySlice, accounts map[solana.PublicKey]tokenAccountInfo, inner bool) SwapLeg {
+	leg := SwapLeg{
+		Protocol: dex.Protocol,
+		Program:  keyAt(keys, ix.ProgramIDIndex).String(),
+		Inner:    inner,
+	}
+	if dex.PoolAccountIndex < len(ix.Accounts) {
+		leg.Pool = keyAt(keys, ix.Accounts[dex.PoolAccountIndex]).String()
+	}
+
+	transfers := tokenTransfers(following, keys)
+	if len(transfers) == 0 {
+		return leg
+	}
+
+	// The first transfer is signed by the trader (funds in); the transfer whose
+	// destination belongs to that trader is the output
+	in := transfers[0]
+	leg.AmountIn = in.amount
+	if info, ok := accounts[in.source]; ok {
+		leg.InputMint = info.mint
+		leg.Trader = info.owner
+	} else if info, ok := accounts[in.destination]; ok {
+		leg.InputMint = info.mint
+	}
+	if leg.Trader == "" {
+		leg.Trader = in.authority.String()
+	}
+
+	for _, t := range transfers[1:] {
+		info, ok := accounts[t.destination]
+		if !ok || info.owner != leg.Trader {
+			continue
+		}
+		leg.AmountOut += t.amount
+		leg.OutputMint = info.mint
+	}
+	return leg
+}
+
+// tokenTransfers decodes Transfer/TransferChecked instructions of both token programs
+func tokenTransfers(instructions []solana.CompiledInstruction, keys solana.PublicKeySlice) []transfer {
+	var transfers []transfer
+	for _, ix := range instructions {
+		program := keyAt(keys, ix.ProgramIDIndex)
+		if !program.Equals(solana.TokenProgramID) && !program.Equals(solana.Token2022ProgramID) {
+			continue
+		}
+		data := []byte(ix.Data)
+		if len(data) < 9 {
+			continue
+		}
+		amount := binary.LittleEndian.Uint64(data[1:9])
+		switch data[0] {
+		case tokenInstructionTransfer:
+			if len(ix.Accounts) < 3 {
+				continue
+			}
+			transfers = append(transfers, transfer{
+				source:      keyAt(keys, ix.Accounts[0]),
+				destination: keyAt(keys, ix.Accounts[1]),
+				authority:   keyAt(keys, ix.Accounts[2]),
+				amount:      amount,
+			})
+		case tokenInstructionTransferChecked:
+			if len(ix.Accounts) < 4 {
+				continue
+			}
+			transfers = append(transfers, transfer{
+				source:      keyAt(keys, ix.Accounts[0]),
+				destination: keyAt(keys, ix.Accounts[2]),
+				authority:   keyAt(keys, ix.Accounts[3]),
+				amount:      amount,
+			})
+		}
+	}
+	return transfers
+}
+
+// tokenAccounts maps token accounts to their mint and owner using the balance metadata
+func tokenAccounts(meta *rpc.TransactionMeta, keys solana.PublicKeySlice) map[solana.PublicKey]tokenAccountInfo {
+	accounts := make(map[solana.PublicKey]tokenAccountInfo)
+	for _, balances := range [][]rpc.TokenBalance{meta.PreTokenBalances, meta.PostTokenBalances} {
+		for _, balance := range balances {
+			info := tokenAccountInfo{mint: balance.Mint.String()}
+			if balance.Owner != nil {
+				info.owner = balance.Owner.String()
+			}
+			accounts[keyAt(keys, balance.AccountIndex)] = info
+		}
+	}
+	return accounts
+}
+
+// tokenDeltas nets post minus pre token balances per owner and mint
+func tokenDeltas(meta *rpc.TransactionMeta) []TokenDelta {
+	type key struct{ owner, mint string }
+	totals := make(map[key]int64)
+	var order []key
+
+	add := func(balances []rpc.TokenBalance, sign int64) {
+		for _, balance := range balances {
+			if balance.Owner == nil || balance.UiTokenAmount == nil {
+				continue
+			}
+			amount, err := strconv.ParseInt(balance.UiTokenAmount.Amount, 10, 64)
+			if err != nil {
+				continue
+			}
+			k := key{balance.Owner.String(), balance.Mint.String()}
+			if _, seen := totals[k]; !seen {
+				order = append(order, k)
+			}
+			totals[k] += sign * amount
+		}
+	}
+	add(meta.PreTokenBalances, -1)
+	add(meta.PostTokenBalances, 1)
+
+	deltas := make([]TokenDelta, 0, len(order))
+	for _, k := range order {
+		if totals[k] != 0 {
+			deltas = append(deltas, TokenDelta{Owner: k.owner, Mint: k.mint, Delta: totals[k]})
+		}
+	}
+	return deltas
+}
+
+// InvokedPrograms lists the programs invoked according to the log messages, in order
+func InvokedPrograms(logs []string) []string {
+	var programs []string
+	seen := make(map[string]bool)
+	for _, line := range logs {
+		if !strings.HasPrefix(line, "Program ") || !strings.Contains(line, " invoke [") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || seen[fields[1]] {
+			continue
+		}
+		seen[fields[1]] = true
+		programs = append(programs, fields[1])
+	}
+	return programs
+}
+
+func keyAt(keys solana.PublicKeySlice, index uint16) solana.PublicKey {
+	if int(index) >= len(keys) {
+		return solana.PublicKey{}
+	}
+	return keys[index]
+}
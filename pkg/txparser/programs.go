@@ -0,0 +1,50 @@
+package txparser
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg"
+	"soltrading/pkg/pool/aldrin"
+	"soltrading/pkg/pool/byreal"
+	"soltrading/pkg/pool/fluxbeam"
+	"soltrading/pkg/pool/goosefx"
+	"soltrading/pkg/pool/lifinity"
+	"soltrading/pkg/pool/meteora"
+	"soltrading/pkg/pool/meteoradbc"
+	"soltrading/pkg/pool/orca"
+	"soltrading/pkg/pool/pump"
+	"soltrading/pkg/pool/raydium"
+	"soltrading/pkg/pool/saber"
+	"soltrading/pkg/pool/saros"
+	"soltrading/pkg/pool/splswap"
+	"soltrading/pkg/pool/whirlpool"
+	"soltrading/pkg/pool/woofi"
+)
+
+// DexProgram describes how to recognise a swap for one protocol
+type DexProgram struct {
+	Protocol pkg.ProtocolName
+	// PoolAccountIndex is the position of the pool state account in the swap instruction
+	PoolAccountIndex int
+}
+
+// KnownPrograms maps DEX program IDs to their protocol. Pool account indexes
+// follow each program's swap instruction layout.
+var KnownPrograms = map[solana.PublicKey]DexProgram{
+	raydium.RAYDIUM_AMM_PROGRAM_ID:  {pkg.ProtocolNameRaydiumAmm, 1},
+	raydium.RAYDIUM_CPMM_PROGRAM_ID: {pkg.ProtocolNameRaydiumCpmm, 3},
+	raydium.RAYDIUM_CLMM_PROGRAM_ID: {pkg.ProtocolNameRaydiumClmm, 2},
+	meteora.MeteoraProgramID:        {pkg.ProtocolNameMeteoraDlmm, 0},
+	pump.PumpSwapProgramID:          {pkg.ProtocolNamePumpAmm, 0},
+	whirlpool.WhirlpoolProgramID:    {pkg.ProtocolNameWhirlpool, 2},
+	orca.OrcaAmmProgramID:           {pkg.ProtocolNameOrca, 0},
+	splswap.SplTokenSwapProgramID:   {pkg.ProtocolNameSplTokenSwap, 0},
+	aldrin.AldrinAmmProgramID:       {pkg.ProtocolNameAldrin, 0},
+	saros.SarosProgramID:            {pkg.ProtocolNameSaros, 0},
+	fluxbeam.FluxbeamProgramID:      {pkg.ProtocolNameFluxbeam, 0},
+	goosefx.GooseFXProgramID:        {pkg.ProtocolNameGooseFX, 0},
+	saber.SaberSwapProgramID:        {pkg.ProtocolNameSaber, 0},
+	lifinity.LifinityProgramID:      {pkg.ProtocolNameLifinity, 1},
+	woofi.WooFiProgramID:            {pkg.ProtocolNameWooFi, 0},
+	byreal.ByrealProgramID:          {pkg.ProtocolNameByreal, 2},
+	meteoradbc.MeteoraDBCProgramID:  {pkg.ProtocolNameMeteoraDBC, 2},
+}
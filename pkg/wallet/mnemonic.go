@@ -0,0 +1,124 @@
+package wallet
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"golang.org/x/text/unicode/norm"
+)
+
+// hardenedOffset marks a BIP32 index as hardened
+const hardenedOffset uint32 = 0x80000000
+
+// ErrInvalidMnemonic is returned for a phrase that is not a valid BIP39
+// English mnemonic: a word outside the wordlist, a wrong word count or a bad
+// checksum, as a mistyped or reordered word produces
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+//go:embed bip39_english.txt
+var englishWordlist string
+
+// englishIndex maps each BIP39 English word to its 11-bit index
+var englishIndex = func() map[string]int {
+	words := strings.Fields(englishWordlist)
+	index := make(map[string]int, len(words))
+	for i, w := range words {
+		index[w] = i
+	}
+	return index
+}()
+
+// FromMnemonic derives a keypair from a BIP39 seed phrase using the Solana
+// derivation path m/44'/501'/<account>'/0' (as used by Phantom and Solflare).
+// The phrase and passphrase are NFKD-normalized as BIP39 specifies, and the
+// phrase must be an English mnemonic with a valid checksum.
+func FromMnemonic(mnemonic, passphrase string, account uint32) (*Wallet, error) {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	if err := validateMnemonic(words); err != nil {
+		return nil, err
+	}
+
+	seed, err := pbkdf2.Key(sha512.New, strings.Join(words, " "), []byte("mnemonic"+norm.NFKD.String(passphrase)), 2048, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive seed: %w", err)
+	}
+
+	path := []uint32{44, 501, account, 0}
+	key, err := deriveEd25519(seed, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(solana.PrivateKey(ed25519.NewKeyFromSeed(key)))
+}
+
+// validateMnemonic checks every word is in the English wordlist and the
+// trailing checksum bits match the SHA-256 of the entropy
+func validateMnemonic(words []string) error {
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return fmt.Errorf("%w: expected 12, 15, 18, 21 or 24 words, got %d", ErrInvalidMnemonic, len(words))
+	}
+
+	// Each word carries 11 bits: the entropy followed by len(words)/3 checksum bits
+	bits := make([]byte, len(words)*11/8+1)
+	for i, w := range words {
+		idx, ok := englishIndex[w]
+		if !ok {
+			return fmt.Errorf("%w: word %d %q is not in the BIP39 English wordlist", ErrInvalidMnemonic, i+1, w)
+		}
+		for b := 0; b < 11; b++ {
+			if idx&(1<<(10-b)) != 0 {
+				pos := i*11 + b
+				bits[pos/8] |= 0x80 >> (pos % 8)
+			}
+		}
+	}
+
+	checksumBits := len(words) / 3
+	entropy := bits[:(len(words)*11-checksumBits)/8]
+	hash := sha256.Sum256(entropy)
+	for b := 0; b < checksumBits; b++ {
+		pos := len(entropy)*8 + b
+		got := bits[pos/8] & (0x80 >> (pos % 8))
+		want := hash[b/8] & (0x80 >> (b % 8))
+		if (got != 0) != (want != 0) {
+			return fmt.Errorf("%w: checksum mismatch, check the words and their order", ErrInvalidMnemonic)
+		}
+	}
+	return nil
+}
+
+// deriveEd25519 implements SLIP-0010 ed25519 derivation, where every level is hardened
+func deriveEd25519(seed []byte, path []uint32) ([]byte, error) {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	for _, index := range path {
+		if index >= hardenedOffset {
+			return nil, fmt.Errorf("derivation index %d out of range", index)
+		}
+		data := make([]byte, 0, 37)
+		data = append(data, 0)
+		data = append(data, key...)
+		data = binary.BigEndian.AppendUint32(data, index+hardenedOffset)
+
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+		key, chainCode = sum[:32], sum[32:]
+	}
+	return key, nil
+}
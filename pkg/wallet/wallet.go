@@ -0,0 +1,134 @@
+package wallet
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Wallet holds a signing keypair
+type Wallet struct {
+	privateKey solana.PrivateKey
+}
+
+// New wraps an existing private key
+func New(privateKey solana.PrivateKey) (*Wallet, error) {
+	if len(privateKey) != 64 {
+		return nil, fmt.Errorf("invalid private key length: %d", len(privateKey))
+	}
+	return &Wallet{privateKey: privateKey}, nil
+}
+
+// FromFile loads a keypair from a solana-keygen JSON file (array of 64 bytes)
+func FromFile(path string) (*Wallet, error) {
+	privateKey, err := solana.PrivateKeyFromSolanaKeygenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keypair file %s: %w", path, err)
+	}
+	return New(privateKey)
+}
+
+// FromBase58 parses a base58-encoded private key
+func FromBase58(encoded string) (*Wallet, error) {
+	privateKey, err := solana.PrivateKeyFromBase58(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base58 private key: %w", err)
+	}
+	return New(privateKey)
+}
+
+// FromEnv reads a base58-encoded private key from an environment variable
+func FromEnv(name string) (*Wallet, error) {
+	encoded := os.Getenv(name)
+	if encoded == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	return FromBase58(encoded)
+}
+
+// Load resolves a wallet from a source string:
+//
+//	file:<path>       solana-keygen JSON keypair file
+//	env:<VAR>         base58 private key in an environment variable
+//	mnemonic:<VAR>    seed phrase in an environment variable (account 0)
+//	<path>            treated as a keypair file if it exists
+func Load(source string) (*Wallet, error) {
+	switch {
+	case strings.HasPrefix(source, "file:"):
+		return FromFile(strings.TrimPrefix(source, "file:"))
+	case strings.HasPrefix(source, "env:"):
+		return FromEnv(strings.TrimPrefix(source, "env:"))
+	case strings.HasPrefix(source, "mnemonic:"):
+		name := strings.TrimPrefix(source, "mnemonic:")
+		phrase := os.Getenv(name)
+		if phrase == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+		return FromMnemonic(phrase, "", 0)
+	}
+
+	if _, err := os.Stat(source); err == nil {
+		return FromFile(source)
+	}
+	return nil, fmt.Errorf("unrecognised wallet source %q (use file:, env: or mnemonic:)", source)
+}
+
+// PublicKey returns the wallet address
+func (w *Wallet) PublicKey() solana.PublicKey {
+	return w.privateKey.PublicKey()
+}
+
+// PrivateKey returns the raw private key
+func (w *Wallet) PrivateKey() solana.PrivateKey {
+	return w.privateKey
+}
+
+// Signers returns the wallet key in the form sol.Client.SignTransaction expects
+func (w *Wallet) Signers() []solana.PrivateKey {
+	return []solana.PrivateKey{w.privateKey}
+}
+
+// KeyGetter returns the signer lookup used by solana.Transaction.Sign
+func (w *Wallet) KeyGetter() func(solana.PublicKey) *solana.PrivateKey {
+	return func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(w.PublicKey()) {
+			return &w.privateKey
+		}
+		return nil
+	}
+}
+
+// SignTransaction signs tx with the wallet key. Additional signers, such as
+// freshly created accounts, may be supplied.
+func (w *Wallet) SignTransaction(tx *solana.Transaction, extraSigners ...solana.PrivateKey) error {
+	_, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(w.PublicKey()) {
+			return &w.privateKey
+		}
+		for i := range extraSigners {
+			if extraSigners[i].PublicKey().Equals(key) {
+				return &extraSigners[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	return nil
+}
+
+// NewSignedTransaction compiles instructions into a transaction paid by the
+// wallet and signs it
+func (w *Wallet) NewSignedTransaction(instructions []solana.Instruction, blockhash solana.Hash, extraSigners ...solana.PrivateKey) (*solana.Transaction, error) {
+	tx, err := solana.NewTransaction(instructions, blockhash, solana.TransactionPayer(w.PublicKey()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	if err := w.SignTransaction(tx, extraSigners...); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg/sol"
+)
+
+// countingSolClient counts account reads and can be made to fail them
+type countingSolClient struct {
+	*mockSolClient
+	reads atomic.Int32
+	fail  atomic.Bool
+}
+
+func (c *countingSolClient) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	c.reads.Add(1)
+	if c.fail.Load() {
+		return nil, errors.New("rpc unavailable")
+	}
+	return c.mockSolClient.GetAccountInfoWithOpts(ctx, account)
+}
+
+func TestAccountCacheFetchesOncePerTTL(t *testing.T) {
+	client := &countingSolClient{mockSolClient: newMockSolClient()}
+	address := solana.NewWallet().PublicKey()
+	client.accounts[address] = []byte{1, 2, 3}
+	cache := sol.NewAccountCache(time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := cache.Get(context.Background(), client, address)
+			if err != nil || len(data) != 3 {
+				t.Errorf("got %v, %v", data, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if reads := client.reads.Load(); reads != 1 {
+		t.Fatalf("read the account %d times, want 1", reads)
+	}
+
+	cache.Invalidate(address)
+	if _, err := cache.Get(context.Background(), client, address); err != nil {
+		t.Fatal(err)
+	}
+	if reads := client.reads.Load(); reads != 2 {
+		t.Fatalf("read the account %d times after Invalidate, want 2", reads)
+	}
+}
+
+func TestAccountCacheKeepsDataWhenRefreshFails(t *testing.T) {
+	client := &countingSolClient{mockSolClient: newMockSolClient()}
+	address := solana.NewWallet().PublicKey()
+	client.accounts[address] = []byte{7}
+	cache := sol.NewAccountCache(time.Nanosecond)
+
+	if _, err := cache.Get(context.Background(), client, address); err != nil {
+		t.Fatal(err)
+	}
+	client.fail.Store(true)
+	time.Sleep(time.Millisecond)
+	data, err := cache.Get(context.Background(), client, address)
+	if err != nil || len(data) != 1 || data[0] != 7 {
+		t.Fatalf("got %v, %v; want the cached data", data, err)
+	}
+
+	if _, err := cache.Get(context.Background(), client, solana.NewWallet().PublicKey()); err == nil {
+		t.Fatal("got no error for an account never fetched")
+	}
+}
@@ -0,0 +1,288 @@
+package test
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"lukechampine.com/uint128"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/pool/whirlpool"
+)
+
+// whirlpoolAccount lays out a Whirlpool account at the byte offsets of
+// whirlpool.rs, independently of the IDL, with a distinct value in every field
+func whirlpoolAccount(t *testing.T) ([]byte, *whirlpool.WhirlpoolPool) {
+	t.Helper()
+	key := func(b byte) solana.PublicKey {
+		var k solana.PublicKey
+		for i := range k {
+			k[i] = b + byte(i)
+		}
+		return k
+	}
+	u128 := func(hi, lo uint64) uint128.Uint128 { return uint128.New(lo, hi) }
+	want := &whirlpool.WhirlpoolPool{
+		WhirlpoolsConfig:           key(1),
+		WhirlpoolBump:              [1]uint8{254},
+		TickSpacing:                64,
+		TickSpacingSeed:            [2]uint8{64, 0},
+		FeeRate:                    3000,
+		ProtocolFeeRate:            1300,
+		Liquidity:                  u128(0x01, 0x23456789abcdef01),
+		SqrtPrice:                  u128(0x0000000a, 0xbcdef01234567890),
+		TickCurrentIndex:           -19_355,
+		ProtocolFeeOwedA:           11_111,
+		ProtocolFeeOwedB:           22_222,
+		TokenMintA:                 key(40),
+		TokenVaultA:                key(80),
+		FeeGrowthGlobalA:           u128(0xffffffffffffffff, 1),
+		TokenMintB:                 key(120),
+		TokenVaultB:                key(160),
+		FeeGrowthGlobalB:           u128(7, 0xfffffffffffffffe),
+		RewardLastUpdatedTimestamp: 1_760_000_000,
+	}
+	for i := range want.RewardInfos {
+		want.RewardInfos[i] = whirlpool.RewardInfo{
+			Mint:                  key(byte(200 + 3*i)),
+			Vault:                 key(byte(201 + 3*i)),
+			Authority:             key(byte(202 + 3*i)),
+			EmissionsPerSecondX64: u128(uint64(i), uint64(1000+i)),
+			GrowthGlobalX64:       u128(uint64(10+i), uint64(2000+i)),
+		}
+	}
+
+	data := make([]byte, 653)
+	putU128 := func(off int, v uint128.Uint128) {
+		binary.LittleEndian.PutUint64(data[off:], v.Lo)
+		binary.LittleEndian.PutUint64(data[off+8:], v.Hi)
+	}
+	copy(data, anchor.GetDiscriminator("account", "Whirlpool"))
+	copy(data[8:40], want.WhirlpoolsConfig[:])
+	data[40] = want.WhirlpoolBump[0]
+	binary.LittleEndian.PutUint16(data[41:], want.TickSpacing)
+	copy(data[43:45], want.TickSpacingSeed[:])
+	binary.LittleEndian.PutUint16(data[45:], want.FeeRate)
+	binary.LittleEndian.PutUint16(data[47:], want.ProtocolFeeRate)
+	putU128(49, want.Liquidity)
+	putU128(65, want.SqrtPrice)
+	binary.LittleEndian.PutUint32(data[81:], uint32(want.TickCurrentIndex))
+	binary.LittleEndian.PutUint64(data[85:], want.ProtocolFeeOwedA)
+	binary.LittleEndian.PutUint64(data[93:], want.ProtocolFeeOwedB)
+	copy(data[101:133], want.TokenMintA[:])
+	copy(data[133:165], want.TokenVaultA[:])
+	putU128(165, want.FeeGrowthGlobalA)
+	copy(data[181:213], want.TokenMintB[:])
+	copy(data[213:245], want.TokenVaultB[:])
+	putU128(245, want.FeeGrowthGlobalB)
+	binary.LittleEndian.PutUint64(data[261:], want.RewardLastUpdatedTimestamp)
+	for i, r := range want.RewardInfos {
+		off := 269 + 128*i
+		copy(data[off:off+32], r.Mint[:])
+		copy(data[off+32:off+64], r.Vault[:])
+		copy(data[off+64:off+96], r.Authority[:])
+		putU128(off+96, r.EmissionsPerSecondX64)
+		putU128(off+112, r.GrowthGlobalX64)
+	}
+	copy(want.Discriminator[:], data[:8])
+	return data, want
+}
+
+func TestWhirlpoolDecodeGolden(t *testing.T) {
+	data, want := whirlpoolAccount(t)
+
+	var pool whirlpool.WhirlpoolPool
+	if err := pool.Decode(data); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if pool.TickArrayCache == nil {
+		t.Error("tick array cache not reset")
+	}
+	pool.TickArrayCache = nil
+	if !reflect.DeepEqual(pool, *want) {
+		t.Fatalf("decoded\n%+v\nwant\n%+v", pool, *want)
+	}
+
+	// Live accounts carry trailing bytes past the decoded fields
+	if err := pool.Decode(append(data, make([]byte, 96)...)); err != nil {
+		t.Fatalf("decode with trailing bytes failed: %v", err)
+	}
+
+	if err := pool.Decode(data[:652]); !errors.Is(err, anchor.ErrAccountMismatch) {
+		t.Fatalf("short account: got %v, want ErrAccountMismatch", err)
+	}
+	data[0] ^= 0xff
+	if err := pool.Decode(data); !errors.Is(err, anchor.ErrAccountMismatch) {
+		t.Fatalf("foreign account: got %v, want ErrAccountMismatch", err)
+	}
+}
+
+// testIDL is a 0.30+ IDL exercising every composite type
+const testIDL = `{
+  "address": "11111111111111111111111111111111",
+  "metadata": {"name": "test", "spec": "0.1.0"},
+  "accounts": [{"name": "State", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8]}],
+  "types": [
+    {"name": "State", "type": {"kind": "struct", "fields": [
+      {"name": "authority", "type": "pubkey"},
+      {"name": "status", "type": {"defined": {"name": "Status"}}},
+      {"name": "mode", "type": {"defined": {"name": "Mode"}}},
+      {"name": "limit", "type": {"option": "u64"}},
+      {"name": "cap", "type": {"option": "u64"}},
+      {"name": "delegate", "type": {"coption": "pubkey"}},
+      {"name": "delta", "type": "i128"},
+      {"name": "weights", "type": {"vec": "u16"}},
+      {"name": "memo", "type": "string"},
+      {"name": "seed", "type": "bytes"}
+    ]}},
+    {"name": "Status", "type": {"kind": "enum", "variants": [
+      {"name": "Inactive"},
+      {"name": "Active"}
+    ]}},
+    {"name": "Mode", "type": {"kind": "enum", "variants": [
+      {"name": "Fixed", "fields": ["u32"]},
+      {"name": "Ranged", "fields": [{"name": "low", "type": "i32"}, {"name": "high", "type": "i32"}]}
+    ]}}
+  ]
+}`
+
+func testStateAccount() (data []byte, authority solana.PublicKey) {
+	authority = solana.NewWallet().PublicKey()
+	data = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	data = append(data, authority[:]...)
+	data = append(data, 1)                                    // status: Active
+	data = append(data, 1)                                    // mode: Ranged
+	data = binary.LittleEndian.AppendUint32(data, 0xfffffff6) // low: -10
+	data = binary.LittleEndian.AppendUint32(data, 20)         // high
+	data = append(data, 1)                                    // limit: Some
+	data = binary.LittleEndian.AppendUint64(data, 500)
+	data = append(data, 0)                           // cap: None
+	data = binary.LittleEndian.AppendUint32(data, 0) // delegate: None, still 32 bytes
+	data = append(data, make([]byte, 32)...)
+	for i := 0; i < 16; i++ { // delta: -2
+		b := byte(0xff)
+		if i == 0 {
+			b = 0xfe
+		}
+		data = append(data, b)
+	}
+	data = binary.LittleEndian.AppendUint32(data, 3) // weights
+	for _, w := range []uint16{10, 20, 30} {
+		data = binary.LittleEndian.AppendUint16(data, w)
+	}
+	data = binary.LittleEndian.AppendUint32(data, 5)
+	data = append(data, "hello"...)
+	data = binary.LittleEndian.AppendUint32(data, 2)
+	data = append(data, 0xaa, 0xbb)
+	return data, authority
+}
+
+func TestDecodeAccountComposite(t *testing.T) {
+	idl, err := anchor.ParseIDL([]byte(testIDL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, authority := testStateAccount()
+
+	s, err := idl.DecodeAccount("State", data)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if s["authority"] != authority {
+		t.Errorf("authority = %v", s["authority"])
+	}
+	if e, ok := s["status"].(anchor.Enum); !ok || e.Variant != "Active" || e.Index != 1 || e.Fields != nil {
+		t.Errorf("status = %#v", s["status"])
+	}
+	if e, ok := s["mode"].(anchor.Enum); !ok || e.Variant != "Ranged" || e.Fields["low"] != int32(-10) || e.Fields["high"] != int32(20) {
+		t.Errorf("mode = %#v", s["mode"])
+	}
+	if s["limit"] != uint64(500) || s["cap"] != nil || s["delegate"] != nil {
+		t.Errorf("options: limit %v, cap %v, delegate %v", s["limit"], s["cap"], s["delegate"])
+	}
+	if d, ok := s["delta"].(*big.Int); !ok || d.Cmp(big.NewInt(-2)) != 0 {
+		t.Errorf("delta = %v", s["delta"])
+	}
+	if w, ok := s["weights"].([]interface{}); !ok || len(w) != 3 || w[2] != uint16(30) {
+		t.Errorf("weights = %#v", s["weights"])
+	}
+	if s["memo"] != "hello" || string(s["seed"].([]byte)) != "\xaa\xbb" {
+		t.Errorf("memo %v, seed %v", s["memo"], s["seed"])
+	}
+
+	var state struct {
+		Authority solana.PublicKey
+		Status    string
+		Mode      struct{ Low, High int32 }
+		Limit     *uint64
+		Cap       *uint64
+		Delta     big.Int
+		Weights   []uint16
+		Memo      string `idl:"memo"`
+	}
+	if err := idl.DecodeAccountInto("State", data, &state); err != nil {
+		t.Fatalf("DecodeAccountInto failed: %v", err)
+	}
+	if state.Status != "Active" || state.Mode.Low != -10 || state.Limit == nil || *state.Limit != 500 || state.Cap != nil ||
+		state.Delta.Int64() != -2 || len(state.Weights) != 3 || state.Memo != "hello" {
+		t.Errorf("decoded into %+v", state)
+	}
+
+	// Variable-size layouts are checked against the data as they decode
+	if _, err := idl.DecodeAccount("State", data[:len(data)-1]); err == nil {
+		t.Error("truncated account decoded")
+	}
+	data[9+32] = 2
+	if _, err := idl.DecodeAccount("State", data); err == nil {
+		t.Error("out of range enum variant decoded")
+	}
+}
+
+// legacyIDL uses the pre-0.30 format: inline account layouts, "publicKey",
+// "defined" as a plain name and no discriminators
+const legacyIDL = `{
+  "version": "0.1.0",
+  "name": "legacy",
+  "accounts": [{"name": "Vault", "type": {"kind": "struct", "fields": [
+    {"name": "owner", "type": "publicKey"},
+    {"name": "side", "type": {"defined": "Side"}},
+    {"name": "amounts", "type": {"array": ["u64", 2]}}
+  ]}}],
+  "types": [{"name": "Side", "type": {"kind": "enum", "variants": [{"name": "Bid"}, {"name": "Ask"}]}}]
+}`
+
+func TestDecodeLegacyIDL(t *testing.T) {
+	idl, err := anchor.ParseIDL([]byte(legacyIDL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := solana.NewWallet().PublicKey()
+	data := append(anchor.GetDiscriminator("account", "Vault"), owner[:]...)
+	data = append(data, 1)
+	data = binary.LittleEndian.AppendUint64(data, 7)
+	data = binary.LittleEndian.AppendUint64(data, 9)
+
+	s, err := idl.DecodeAccount("Vault", data)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	amounts, _ := s["amounts"].([]interface{})
+	if s["owner"] != owner || s["side"].(anchor.Enum).Variant != "Ask" || len(amounts) != 2 || amounts[1] != uint64(9) {
+		t.Errorf("decoded %#v", s)
+	}
+	if off, err := idl.FieldOffset("Vault", "amounts"); err != nil || off != 8+32+1 {
+		t.Errorf("amounts offset = %d, %v", off, err)
+	}
+	if account, err := idl.AccountFor(data); err != nil || account.Name != "Vault" {
+		t.Errorf("AccountFor = %v, %v", account, err)
+	}
+
+	// Fixed-size layouts are checked up front
+	var mismatch *anchor.AccountMismatchError
+	if _, err := idl.DecodeAccount("Vault", data[:len(data)-1]); !errors.As(err, &mismatch) || mismatch.MinSize != len(data) {
+		t.Errorf("short account: got %v", err)
+	}
+}
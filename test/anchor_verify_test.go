@@ -0,0 +1,40 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"soltrading/pkg/anchor"
+)
+
+func TestVerifyAccount(t *testing.T) {
+	disc := anchor.GetDiscriminator("account", "Whirlpool")
+	data := append(append([]byte(nil), disc...), make([]byte, 100)...)
+
+	if err := anchor.VerifyAccount("Whirlpool", data, 108); err != nil {
+		t.Fatalf("valid account: %v", err)
+	}
+
+	var mismatch *anchor.AccountMismatchError
+	err := anchor.VerifyAccount("Whirlpool", data, 200)
+	if !errors.Is(err, anchor.ErrAccountMismatch) || !errors.As(err, &mismatch) {
+		t.Fatalf("short account: got %v", err)
+	}
+	if mismatch.Account != "Whirlpool" || mismatch.MinSize != 200 || mismatch.Size != 108 || mismatch.Want != nil {
+		t.Errorf("short account error %+v", mismatch)
+	}
+
+	other := append(anchor.GetDiscriminator("account", "PoolState"), make([]byte, 100)...)
+	err = anchor.VerifyAccount("Whirlpool", other, 108)
+	if !errors.As(err, &mismatch) || string(mismatch.Want) != string(disc) || string(mismatch.Got) != string(other[:8]) {
+		t.Fatalf("foreign account: got %v", err)
+	}
+	if err.Error() == "" || !errors.Is(err, anchor.ErrAccountMismatch) {
+		t.Errorf("foreign account error %q", err)
+	}
+
+	// Data too short for a discriminator reports the size
+	if err := anchor.VerifyAccount("Whirlpool", disc[:4], 0); !errors.As(err, &mismatch) || mismatch.MinSize != 8 || mismatch.Size != 4 {
+		t.Errorf("truncated discriminator: got %v", err)
+	}
+}
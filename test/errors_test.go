@@ -0,0 +1,64 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cosmossdk.io/math"
+	"soltrading/pkg"
+	"soltrading/pkg/router"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/subscription"
+)
+
+// emptyPool quotes nothing out, as a pool drained of the output token does
+type emptyPool struct {
+	pairPool
+}
+
+func (p *emptyPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, inputAmount math.Int) (math.Int, error) {
+	return math.ZeroInt(), nil
+}
+
+// failingProtocol fails every pool lookup with err
+type failingProtocol struct {
+	pkg.Protocol
+	err error
+}
+
+func (p *failingProtocol) ProtocolName() pkg.ProtocolName { return "failing" }
+
+func (p *failingProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+	return nil, p.err
+}
+
+func TestRouterErrors(t *testing.T) {
+	ctx := context.Background()
+
+	r := router.NewSimpleRouter(&failingProtocol{err: fmt.Errorf("getProgramAccounts: %w", pkg.ErrRateLimited)})
+	err := r.QueryAllPools(ctx, WSOL.String(), USDC.String())
+	if !errors.Is(err, pkg.ErrNoPoolsFound) || !errors.Is(err, pkg.ErrRateLimited) || !pkg.Retryable(err) {
+		t.Fatalf("rate limited discovery: got %v", err)
+	}
+
+	pool := &emptyPool{pairPool{base: WSOL.String(), quote: USDC.String()}}
+	r = router.NewSimpleRouter(&pairProtocol{pool: &pool.pairPool})
+	r.Pools = []pkg.Pool{pool}
+	_, _, err = r.GetBestPool(ctx, nil, WSOL.String(), math.NewInt(1000))
+	if !errors.Is(err, pkg.ErrInsufficientLiquidity) || pkg.Retryable(err) {
+		t.Fatalf("empty pool: got %v", err)
+	}
+
+	_, _, err = r.GetBestPoolWithFilter(ctx, nil, WSOL.String(), math.NewInt(1000), []string{"raydium_amm"}, nil, 0)
+	if !errors.Is(err, pkg.ErrNoPoolsFound) {
+		t.Fatalf("filtered out pools: got %v", err)
+	}
+}
+
+func TestStaleUpdateIsStalePoolData(t *testing.T) {
+	if !errors.Is(subscription.ErrStaleUpdate, pkg.ErrStalePoolData) || !pkg.Retryable(subscription.ErrStaleUpdate) {
+		t.Fatalf("ErrStaleUpdate %v does not match pkg.ErrStalePoolData", subscription.ErrStaleUpdate)
+	}
+}
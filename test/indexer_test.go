@@ -0,0 +1,261 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/indexer"
+	"soltrading/pkg/pool/whirlpool"
+	"soltrading/pkg/protocol"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/subscription"
+)
+
+// programClient serves getProgramAccounts from a fixed set of accounts,
+// applying the requested data slice like an RPC node
+type programClient struct {
+	*mockSolClient
+	program  solana.PublicKey
+	accounts rpc.GetProgramAccountsResult
+	sliced   uint64 // bytes returned
+	scans    int64
+}
+
+func (c *programClient) GetProgramAccountsWithOpts(ctx context.Context, programID solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (rpc.GetProgramAccountsResult, error) {
+	if !programID.Equals(c.program) {
+		return nil, errors.New("getProgramAccounts is disabled")
+	}
+	atomic.AddInt64(&c.scans, 1)
+	var result rpc.GetProgramAccountsResult
+	for _, account := range c.accounts {
+		data := account.Account.Data.GetBinary()
+		if opts != nil && !matchesFilters(data, opts.Filters) {
+			continue
+		}
+		if opts != nil && opts.DataSlice != nil {
+			offset, length := *opts.DataSlice.Offset, *opts.DataSlice.Length
+			data = data[min(offset, uint64(len(data))):min(offset+length, uint64(len(data)))]
+			atomic.AddUint64(&c.sliced, uint64(len(data)))
+		}
+		result = append(result, &rpc.KeyedAccount{Pubkey: account.Pubkey, Account: &rpc.Account{Data: rpc.DataBytesOrJSONFromBytes(data)}})
+	}
+	return result, nil
+}
+
+func matchesFilters(data []byte, filters []rpc.RPCFilter) bool {
+	for _, filter := range filters {
+		if filter.DataSize != 0 && uint64(len(data)) != filter.DataSize {
+			return false
+		}
+		if m := filter.Memcmp; m != nil {
+			end := m.Offset + uint64(len(m.Bytes))
+			if end > uint64(len(data)) || !bytes.Equal(data[m.Offset:end], m.Bytes) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestDataSlice(t *testing.T) {
+	slice := sol.PubkeySlice(181, 101)
+	if slice.Offset != 101 || slice.Length != 112 {
+		t.Fatalf("slice = %+v, want offset 101 length 112", slice)
+	}
+
+	data := make([]byte, slice.Length)
+	copy(data[80:], USDC[:])
+	if got, ok := slice.Pubkey(data, 181); !ok || !got.Equals(USDC) {
+		t.Errorf("pubkey at 181 = %s, %v; want USDC", got, ok)
+	}
+	if _, ok := slice.Field(data, 100, 8); ok {
+		t.Error("field before the slice was returned")
+	}
+	if _, ok := slice.Field(data, 200, 32); ok {
+		t.Error("field past the slice was returned")
+	}
+}
+
+func TestIndexerScanAndServe(t *testing.T) {
+	program := solana.NewWallet().PublicKey()
+	source := indexer.Source{Protocol: "test_amm", Program: &program, DataSize: 96, MintA: 8, MintB: 40}
+
+	poolAccount := func(mintA, mintB solana.PublicKey) *rpc.KeyedAccount {
+		data := make([]byte, 96)
+		copy(data[8:], mintA[:])
+		copy(data[40:], mintB[:])
+		return &rpc.KeyedAccount{
+			Pubkey:  solana.NewWallet().PublicKey(),
+			Account: &rpc.Account{Data: rpc.DataBytesOrJSONFromBytes(data)},
+		}
+	}
+	solUSDC := poolAccount(WSOL, USDC)
+	usdcSOL := poolAccount(USDC, WSOL)
+	other := poolAccount(WSOL, solana.NewWallet().PublicKey())
+	client := &programClient{mockSolClient: newMockSolClient(), program: program,
+		accounts: rpc.GetProgramAccountsResult{solUSDC, usdcSOL, other}}
+
+	path := filepath.Join(t.TempDir(), "index.db")
+	index, err := indexer.OpenIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	inner := &stubProtocol{name: "test_amm", pairErr: errors.New("scan not allowed"), pools: map[string]pkg.Pool{
+		solUSDC.Pubkey.String(): &stubPool{id: solUSDC.Pubkey.String()},
+		usdcSOL.Pubkey.String(): &stubPool{id: usdcSOL.Pubkey.String()},
+	}}
+	protocol := index.Wrap([]pkg.Protocol{inner})[0]
+
+	// Until the program is scanned, lookups go to the protocol
+	if _, err := protocol.FetchPoolsByPair(context.Background(), WSOL.String(), USDC.String()); err == nil {
+		t.Fatal("lookup before the first scan did not reach the protocol")
+	}
+
+	if err := indexer.NewIndexer(client, index, source).Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if client.sliced != 3*64 {
+		t.Errorf("scan downloaded %d bytes, want only the mints (%d)", client.sliced, 3*64)
+	}
+	pools, err := protocol.FetchPoolsByPair(context.Background(), USDC.String(), WSOL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 2 {
+		t.Fatalf("found %d pools, want 2", len(pools))
+	}
+
+	// The saved index serves the same lookups after a restart
+	want := index.PoolIDs("test_amm", USDC.String(), WSOL.String())
+	if err := index.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := indexer.OpenIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if got := reopened.PoolIDs("test_amm", WSOL.String(), USDC.String()); !reflect.DeepEqual(got, want) || len(got) != 2 {
+		t.Errorf("reopened index has %v, want %v", got, want)
+	}
+	if reopened.Len("test_amm") != 3 {
+		t.Errorf("reopened index has %d pools, want 3", reopened.Len("test_amm"))
+	}
+}
+
+func TestIndexerWatchFollowsNewPools(t *testing.T) {
+	program := solana.NewWallet().PublicKey()
+	source := indexer.Source{Protocol: "test_amm", Program: &program, DataSize: 96, MintA: 8, MintB: 40}
+	existing := solana.NewWallet().PublicKey()
+
+	path := filepath.Join(t.TempDir(), "index.db")
+	index, err := indexer.OpenIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	if err := index.Replace("test_amm", []indexer.Entry{{Pool: existing.String(), MintA: WSOL.String(), MintB: USDC.String()}}); err != nil {
+		t.Fatal(err)
+	}
+
+	node := newFakeNode(t)
+	client, err := subscription.NewWebSocketClient(context.Background(), node.url())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	conn := node.accept(t)
+	if err := indexer.NewIndexer(nil, index, source).Watch(client); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor := func(want int) []string {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			ids := index.PoolIDs("test_amm", USDC.String(), WSOL.String())
+			if len(ids) == want {
+				return ids
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("index has %d SOL/USDC pools, want %d", len(ids), want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// A pool created after the scan arrives as its sliced mints
+	created := solana.NewWallet().PublicKey()
+	conn.notifyProgram(1, created, append(USDC.Bytes(), WSOL.Bytes()...), 10)
+	waitFor(2)
+
+	// A closed pool arrives without data
+	conn.notifyProgram(1, existing, nil, 11)
+	if ids := waitFor(1); ids[0] != created.String() {
+		t.Fatalf("index kept %s, want the new pool %s", ids[0], created)
+	}
+
+	if err := index.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := indexer.OpenIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if got := reopened.PoolIDs("test_amm", WSOL.String(), USDC.String()); len(got) != 1 || got[0] != created.String() {
+		t.Errorf("reopened index has %v, want only %s", got, created)
+	}
+}
+
+func TestFetchAllPoolsPages(t *testing.T) {
+	client := &programClient{mockSolClient: newMockSolClient(), program: whirlpool.WhirlpoolProgramID}
+	want := map[string]bool{}
+	for _, firstByte := range []byte{0x00, 0x7f, 0xff} {
+		mintA := solana.NewWallet().PublicKey()
+		mintA[0] = firstByte
+		data := make([]byte, 653)
+		copy(data, anchor.GetDiscriminator("account", "Whirlpool"))
+		copy(data[101:], mintA[:])
+		account := &rpc.KeyedAccount{Pubkey: solana.NewWallet().PublicKey(), Account: &rpc.Account{Data: rpc.DataBytesOrJSONFromBytes(data)}}
+		client.accounts = append(client.accounts, account)
+		want[account.Pubkey.String()] = true
+	}
+
+	whirlpools := protocol.NewWhirlpool(client)
+	opts := pkg.FetchAllOptions{PageShards: 100}
+	got := map[string]bool{}
+	pages := 0
+	for {
+		page, err := whirlpools.FetchAllPools(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, pool := range page.Pools {
+			got[pool.GetID()] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	if pages != 3 || !reflect.DeepEqual(got, want) {
+		t.Errorf("%d pages with pools %v, want 3 pages with %v", pages, got, want)
+	}
+
+	if _, err := whirlpools.FetchAllPools(context.Background(), pkg.FetchAllOptions{Cursor: "256"}); err == nil {
+		t.Error("cursor past the last shard was accepted")
+	}
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"soltrading/pkg/jito"
+)
+
+// blockEngine stubs the block engine JSON-RPC paths the jito client calls
+func blockEngine(t *testing.T, statuses, inflight string) *httptest.Server {
+	t.Helper()
+	results := map[string]string{
+		"/bundles":                   `["96gYZGLnJYVFmbjzopPSU6QiEV5fGqZNyN9nmNhvrZU5"]`,
+		"/getBundleStatuses":         statuses,
+		"/getInflightBundleStatuses": inflight,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, ok := results[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newJitoClient(t *testing.T, srv *httptest.Server) *jito.Client {
+	t.Helper()
+	c, err := jito.NewClient(context.Background(), jito.Config{Endpoint: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func TestJitoBundleStatusError(t *testing.T) {
+	srv := blockEngine(t,
+		`{"context":{"slot":10},"value":[{"bundle_id":"b","transactions":["sig"],"slot":9,"confirmation_status":"confirmed","err":{"Err":{"InstructionError":[0,{"Custom":6001}]}}}]}`,
+		`{"context":{"slot":10},"value":[]}`)
+	c := newJitoClient(t, srv)
+
+	status, err := c.GetBundleStatus(context.Background(), "b")
+	if err != nil || status == nil {
+		t.Fatalf("GetBundleStatus: %v %v", status, err)
+	}
+	detail, ok := status.Err.(map[string]interface{})
+	if !ok || detail["InstructionError"] == nil {
+		t.Fatalf("bundle error not decoded: %#v", status.Err)
+	}
+
+	_, err = c.WaitForBundle(context.Background(), "b", time.Millisecond, time.Second)
+	if !errors.Is(err, jito.ErrBundleFailed) {
+		t.Fatalf("WaitForBundle: got %v, want ErrBundleFailed", err)
+	}
+}
+
+func TestJitoBundleStatusOk(t *testing.T) {
+	srv := blockEngine(t,
+		`{"context":{"slot":10},"value":[{"bundle_id":"b","transactions":["sig"],"slot":9,"confirmation_status":"finalized","err":{"Ok":null}}]}`,
+		`{"context":{"slot":10},"value":[]}`)
+	c := newJitoClient(t, srv)
+
+	status, err := c.WaitForBundle(context.Background(), "b", time.Millisecond, time.Second)
+	if err != nil || status.Err != nil || status.Slot != 9 {
+		t.Fatalf("WaitForBundle: %+v %v", status, err)
+	}
+}
+
+func TestJitoInflightBundle(t *testing.T) {
+	cases := map[string]struct {
+		inflight string
+		want     error
+	}{
+		"failed":  {`{"context":{"slot":10},"value":[{"bundle_id":"b","status":"Failed","landed_slot":null}]}`, jito.ErrBundleFailed},
+		"invalid": {`{"context":{"slot":10},"value":[null]}`, jito.ErrBundleDropped},
+		"pending": {`{"context":{"slot":10},"value":[{"bundle_id":"b","status":"Pending","landed_slot":null}]}`, context.DeadlineExceeded},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := newJitoClient(t, blockEngine(t, `{"context":{"slot":10},"value":[]}`, tc.inflight))
+			_, err := c.WaitForBundle(context.Background(), "b", time.Millisecond, 100*time.Millisecond)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("got %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestJitoTipAccountsConcurrent(t *testing.T) {
+	c := newJitoClient(t, blockEngine(t, `{"value":[]}`, `{"value":[]}`))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := c.RefreshTipAccounts(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c.RandomTipAccount()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestJitoLogger(t *testing.T) {
+	var buf bytes.Buffer
+	srv := blockEngine(t, `"not a status"`, `{"context":{"slot":10},"value":[]}`)
+	c, err := jito.NewClient(context.Background(), jito.Config{Endpoint: srv.URL, Logger: slog.New(slog.NewTextHandler(&buf, nil))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.WaitForBundle(context.Background(), "b", time.Millisecond, 50*time.Millisecond); err == nil {
+		t.Fatal("bundle landed without a status")
+	}
+	if !strings.Contains(buf.String(), "component=jito") || !strings.Contains(buf.String(), "failed to get bundle status") {
+		t.Fatalf("client logger not used: %q", buf.String())
+	}
+}
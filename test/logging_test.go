@@ -0,0 +1,51 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"soltrading/pkg/logging"
+	"soltrading/pkg/router"
+)
+
+func TestRouterLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	r := router.NewSimpleRouterWithLogger(logger, &failingProtocol{err: errors.New("endpoint down")})
+	r.QueryAllPools(context.Background(), WSOL.String(), USDC.String())
+
+	var record map[string]any
+	if err := json.Unmarshal(bytes.Split(buf.Bytes(), []byte("\n"))[0], &record); err != nil {
+		t.Fatalf("no JSON record logged: %q", buf.String())
+	}
+	if record[logging.ComponentKey] != "router" || record["level"] != "WARN" || record["protocol"] != "failing" {
+		t.Fatalf("unexpected record %v", record)
+	}
+	if !strings.Contains(record["error"].(string), "endpoint down") {
+		t.Fatalf("error attribute missing: %v", record)
+	}
+}
+
+func TestDefaultLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logging.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer logging.SetDefault(nil)
+
+	router.NewSimpleRouter(&failingProtocol{err: errors.New("endpoint down")}).
+		QueryAllPools(context.Background(), WSOL.String(), USDC.String())
+	if !strings.Contains(buf.String(), "component=router") {
+		t.Fatalf("default logger not used: %q", buf.String())
+	}
+
+	buf.Reset()
+	router.NewSimpleRouterWithLogger(logging.Discard(), &failingProtocol{err: errors.New("endpoint down")}).
+		QueryAllPools(context.Background(), WSOL.String(), USDC.String())
+	if buf.Len() != 0 {
+		t.Fatalf("discarded logger wrote %q", buf.String())
+	}
+}
@@ -0,0 +1,202 @@
+package test
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/pool/meteora"
+)
+
+// dlmmFees are the LbPair fee parameters a test pool is built with
+type dlmmFees struct {
+	baseFactor         uint16
+	variableFeeControl uint32
+	protocolShare      uint16
+	// volatilityReference, when set, is carried over from a swap just now
+	// at the active bin, so the first bin already pays a variable fee
+	volatilityReference uint32
+}
+
+// newDlmmPool builds a pool around active bin 35 of bin array 0, whose every
+// bin holds 1_000_000 of each token
+func newDlmmPool(t *testing.T, fees dlmmFees) (*meteora.MeteoraDlmmPool, solana.PublicKey) {
+	t.Helper()
+	const binStep = 10
+	oracle := solana.NewWallet().PublicKey()
+
+	data := make([]byte, meteora.LbPairSize)
+	copy(data, anchor.GetDiscriminator("account", "LbPair"))
+	binary.LittleEndian.PutUint16(data[8:], fees.baseFactor)
+	binary.LittleEndian.PutUint16(data[10:], 30)  // filter period
+	binary.LittleEndian.PutUint16(data[12:], 600) // decay period
+	binary.LittleEndian.PutUint16(data[14:], 5000)
+	binary.LittleEndian.PutUint32(data[16:], fees.variableFeeControl)
+	binary.LittleEndian.PutUint32(data[20:], 350_000) // max volatility accumulator
+	binary.LittleEndian.PutUint16(data[32:], fees.protocolShare)
+	if fees.volatilityReference > 0 {
+		binary.LittleEndian.PutUint32(data[44:], fees.volatilityReference)
+		binary.LittleEndian.PutUint32(data[48:], 35) // index reference
+		binary.LittleEndian.PutUint64(data[56:], uint64(time.Now().Unix()))
+	}
+	binary.LittleEndian.PutUint32(data[76:], 35) // active bin
+	binary.LittleEndian.PutUint16(data[80:], binStep)
+	copy(data[88:120], WSOL[:])
+	copy(data[120:152], USDC[:])
+	copy(data[552:584], oracle[:])
+	// Bin array 0 is bit 512 of the internal bitmap
+	binary.LittleEndian.PutUint64(data[584+8*8:], 1)
+
+	pool := &meteora.MeteoraDlmmPool{PoolId: solana.NewWallet().PublicKey()}
+	if err := pool.Decode(data); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	const binSize = 144
+	binArray := make([]byte, 56+70*binSize)
+	copy(binArray[24:56], pool.PoolId[:])
+	for i := 0; i < 70; i++ {
+		binary.LittleEndian.PutUint64(binArray[56+i*binSize:], 1_000_000)
+		binary.LittleEndian.PutUint64(binArray[64+i*binSize:], 1_000_000)
+		// Bins store their Q64.64 price, (1 + binStep/10000)^id
+		price := new(big.Rat).SetFrac64(10000+binStep, 10000)
+		q64 := new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 64))
+		for j := 0; j < i; j++ {
+			q64.Mul(q64, price)
+		}
+		p := new(big.Int).Quo(q64.Num(), q64.Denom())
+		binary.LittleEndian.PutUint64(binArray[72+i*binSize:], p.Uint64())
+		binary.LittleEndian.PutUint64(binArray[80+i*binSize:], new(big.Int).Rsh(p, 64).Uint64())
+	}
+	key, _ := meteora.DeriveBinArrayPDA(pool.PoolId, 0)
+	if err := pool.UpdateFromAccountData(key.String(), binArray); err != nil {
+		t.Fatalf("bin array update failed: %v", err)
+	}
+	return pool, oracle
+}
+
+func TestDlmmQuoteLeavesPoolUntouched(t *testing.T) {
+	pool, _ := newDlmmPool(t, dlmmFees{baseFactor: 10_000, variableFeeControl: 40_000, protocolShare: 2000})
+	reserves := pool.GetReserves()
+	feeRate := pool.GetFeeRate()
+
+	first, err := pool.QuoteWithFees(context.Background(), nil, WSOL.String(), math.NewInt(3_500_000))
+	if err != nil {
+		t.Fatalf("quote failed: %v", err)
+	}
+	if !first.AmountOut.IsPositive() {
+		t.Fatalf("quote out %s", first.AmountOut)
+	}
+	if got := pool.GetReserves(); !got.Base.Equal(reserves.Base) || !got.Quote.Equal(reserves.Quote) {
+		t.Fatalf("quote changed the cached bins: %+v, was %+v", got, reserves)
+	}
+	if pool.GetFeeRate() != feeRate {
+		t.Fatalf("quote changed the volatility parameters: fee rate %v, was %v", pool.GetFeeRate(), feeRate)
+	}
+
+	// Concurrent quotes see the same pool; run with -race to check they
+	// don't share simulation state
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			quote, err := pool.QuoteWithFees(context.Background(), nil, WSOL.String(), math.NewInt(3_500_000))
+			if err != nil || !quote.AmountOut.Equal(first.AmountOut) || !quote.Fee.Equal(first.Fee) {
+				t.Errorf("concurrent quote %+v, %v; want %+v", quote, err, first)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDlmmQuoteFeeSplit(t *testing.T) {
+	ctx := context.Background()
+
+	// Without variable fees the whole fee is the 0.1% base fee
+	pool, _ := newDlmmPool(t, dlmmFees{baseFactor: 10_000, protocolShare: 2000})
+	quote, err := pool.QuoteWithFees(ctx, nil, WSOL.String(), math.NewInt(500_000))
+	if err != nil {
+		t.Fatalf("quote failed: %v", err)
+	}
+	// ceil(500_000 * 1_000_000 / 1e9) charged in the active bin
+	if !quote.Fee.Equal(math.NewInt(500)) || !quote.BaseFee.Equal(quote.Fee) || !quote.VariableFee.IsZero() {
+		t.Errorf("single bin fees %+v", quote)
+	}
+	if !quote.ProtocolFee.Equal(math.NewInt(100)) {
+		t.Errorf("protocol fee %s, want 20%% of %s", quote.ProtocolFee, quote.Fee)
+	}
+
+	// Crossing bins raises the volatility accumulator, adding a variable fee
+	pool, _ = newDlmmPool(t, dlmmFees{baseFactor: 10_000, variableFeeControl: 40_000, protocolShare: 2000})
+	quote, err = pool.QuoteWithFees(ctx, nil, WSOL.String(), math.NewInt(3_500_000))
+	if err != nil {
+		t.Fatalf("quote failed: %v", err)
+	}
+	if !quote.VariableFee.IsPositive() || !quote.BaseFee.IsPositive() || !quote.BaseFee.Add(quote.VariableFee).Equal(quote.Fee) {
+		t.Errorf("multi-bin fees %+v", quote)
+	}
+	if quote.ProtocolFee.GT(quote.Fee.QuoRaw(5)) || !quote.ProtocolFee.IsPositive() {
+		t.Errorf("protocol fee %s of %s", quote.ProtocolFee, quote.Fee)
+	}
+
+	// The same swap without the variable fee pays only the base part
+	flat, _ := newDlmmPool(t, dlmmFees{baseFactor: 10_000, protocolShare: 2000})
+	flatQuote, err := flat.QuoteWithFees(ctx, nil, WSOL.String(), math.NewInt(3_500_000))
+	if err != nil {
+		t.Fatalf("quote failed: %v", err)
+	}
+	if !flatQuote.Fee.LT(quote.Fee) || !flatQuote.AmountOut.GT(quote.AmountOut) {
+		t.Errorf("variable fee did not cost: %+v vs %+v", flatQuote, quote)
+	}
+
+	// A volatility reference of 10_000 makes the active bin's variable fee rate
+	// 40_000 * (10_000 * 10)^2 / 1e11 = 4_000 on top of the 1_000_000 base
+	// rate (1e9 precision). The 502 fee, ceil(500_000 * 1_004_000 / 1e9),
+	// splits 1_000_000:4_000 into 500 base and 2 variable, and the 20%
+	// protocol share is floor(502 / 5) = 100.
+	volatilePool, _ := newDlmmPool(t, dlmmFees{baseFactor: 10_000, variableFeeControl: 40_000, protocolShare: 2000, volatilityReference: 10_000})
+	volatile, err := volatilePool.QuoteWithFees(ctx, nil, WSOL.String(), math.NewInt(500_000))
+	if err != nil {
+		t.Fatalf("quote failed: %v", err)
+	}
+	if !volatile.Fee.Equal(math.NewInt(502)) || !volatile.BaseFee.Equal(math.NewInt(500)) || !volatile.VariableFee.Equal(math.NewInt(2)) ||
+		!volatile.ProtocolFee.Equal(math.NewInt(100)) {
+		t.Errorf("volatile bin fees %s = %s base + %s variable, protocol %s; want 502 = 500 + 2, protocol 100",
+			volatile.Fee, volatile.BaseFee, volatile.VariableFee, volatile.ProtocolFee)
+	}
+
+	// Without a base fee every fee is variable
+	variablePool, _ := newDlmmPool(t, dlmmFees{variableFeeControl: 40_000, protocolShare: 2000, volatilityReference: 10_000})
+	variable, err := variablePool.QuoteWithFees(ctx, nil, WSOL.String(), math.NewInt(500_000))
+	if err != nil {
+		t.Fatalf("quote failed: %v", err)
+	}
+	if !variable.Fee.Equal(math.NewInt(2)) || !variable.BaseFee.IsZero() || !variable.VariableFee.Equal(variable.Fee) {
+		t.Errorf("variable-only fees %+v, want 2 all variable", variable)
+	}
+}
+
+func TestDlmmAuxiliaryAccounts(t *testing.T) {
+	pool, oracle := newDlmmPool(t, dlmmFees{baseFactor: 10_000})
+
+	// Only bin arrays are followed besides the pool; the oracle is not
+	accounts := pool.GetAuxiliaryAccounts()
+	cached, _ := meteora.DeriveBinArrayPDA(pool.PoolId, 0)
+	found := false
+	for _, account := range accounts {
+		if account == oracle.String() {
+			t.Fatalf("oracle %s subscribed", oracle)
+		}
+		found = found || account == cached.String()
+	}
+	if !found {
+		t.Fatalf("cached bin array %s not in auxiliary accounts %v", cached, accounts)
+	}
+}
@@ -0,0 +1,64 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"soltrading/pkg/wallet"
+)
+
+func TestFromMnemonicValid(t *testing.T) {
+	// BIP39 reference vectors
+	phrases := []string{
+		strings.Repeat("abandon ", 11) + "about",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		strings.Repeat("zoo ", 11) + "wrong",
+		strings.Repeat("abandon ", 23) + "art",
+	}
+	for _, phrase := range phrases {
+		if _, err := wallet.FromMnemonic(phrase, "", 0); err != nil {
+			t.Errorf("%q: %v", phrase, err)
+		}
+	}
+}
+
+func TestFromMnemonicInvalid(t *testing.T) {
+	phrases := map[string]string{
+		"unknown word":   strings.Repeat("abandon ", 11) + "abuot",
+		"bad checksum":   strings.Repeat("abandon ", 12),
+		"reordered":      "winner legal thank year wave sausage worth useful legal winner thank yellow",
+		"word count":     strings.Repeat("abandon ", 10) + "about",
+		"non-English":    strings.Repeat("abandon ", 11) + "abändon",
+		"wrong 24-words": strings.Repeat("abandon ", 23) + "about",
+	}
+	for name, phrase := range phrases {
+		if _, err := wallet.FromMnemonic(phrase, "", 0); !errors.Is(err, wallet.ErrInvalidMnemonic) {
+			t.Errorf("%s: got %v, want ErrInvalidMnemonic", name, err)
+		}
+	}
+}
+
+func TestFromMnemonicNormalizesPassphrase(t *testing.T) {
+	phrase := strings.Repeat("abandon ", 11) + "about"
+	composed, err := wallet.FromMnemonic(phrase, "caf\u00e9", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decomposed, err := wallet.FromMnemonic(phrase, "cafe\u0301", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if composed.PublicKey() != decomposed.PublicKey() {
+		t.Fatalf("NFC and NFD passphrases derived different keys: %s != %s", composed.PublicKey(), decomposed.PublicKey())
+	}
+
+	plain, err := wallet.FromMnemonic(phrase, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.PublicKey() == composed.PublicKey() {
+		t.Fatal("passphrase ignored")
+	}
+}
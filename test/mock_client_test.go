@@ -0,0 +1,102 @@
+package test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg/pool/pump"
+	"soltrading/pkg/sol"
+)
+
+// mockSolClient serves account data from memory so pools can be quoted without RPC
+type mockSolClient struct {
+	accounts map[solana.PublicKey][]byte
+	owners   map[solana.PublicKey]solana.PublicKey // programs owning accounts, when set
+}
+
+func newMockSolClient() *mockSolClient {
+	return &mockSolClient{accounts: make(map[solana.PublicKey][]byte), owners: make(map[solana.PublicKey]solana.PublicKey)}
+}
+
+// setTokenAccount stores a minimal SPL token account with the given amount
+func (m *mockSolClient) setTokenAccount(account solana.PublicKey, amount uint64) {
+	data := make([]byte, sol.TokenAccountSize)
+	binary.LittleEndian.PutUint64(data[64:72], amount)
+	m.accounts[account] = data
+}
+
+func (m *mockSolClient) account(key solana.PublicKey) *rpc.Account {
+	data, ok := m.accounts[key]
+	if !ok {
+		return nil
+	}
+	return &rpc.Account{Owner: m.owners[key], Data: rpc.DataBytesOrJSONFromBytes(data)}
+}
+
+func (m *mockSolClient) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	acc := m.account(account)
+	if acc == nil {
+		return nil, rpc.ErrNotFound
+	}
+	return &rpc.GetAccountInfoResult{Value: acc}, nil
+}
+
+func (m *mockSolClient) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey) (*rpc.GetMultipleAccountsResult, error) {
+	result := &rpc.GetMultipleAccountsResult{}
+	for _, key := range accounts {
+		result.Value = append(result.Value, m.account(key))
+	}
+	return result, nil
+}
+
+func (m *mockSolClient) GetProgramAccountsWithOpts(ctx context.Context, programID solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (rpc.GetProgramAccountsResult, error) {
+	return nil, nil
+}
+
+func (m *mockSolClient) GetTokenAccountsByOwner(ctx context.Context, owner solana.PublicKey, config *rpc.GetTokenAccountsConfig, opts *rpc.GetTokenAccountsOpts) (*rpc.GetTokenAccountsResult, error) {
+	return &rpc.GetTokenAccountsResult{}, nil
+}
+
+func (m *mockSolClient) GetTokenAccountBalance(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetTokenAccountBalanceResult, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockSolClient) GetBalance(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetBalanceResult, error) {
+	return &rpc.GetBalanceResult{}, nil
+}
+
+func (m *mockSolClient) GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockSolClient) GetClock(ctx context.Context) (*sol.Clock, error) {
+	return &sol.Clock{}, nil
+}
+
+var _ sol.SolClient = (*mockSolClient)(nil)
+
+// TestPumpQuoteWithMockClient quotes a Pump AMM pool entirely from mocked vault data
+func TestPumpQuoteWithMockClient(t *testing.T) {
+	client := newMockSolClient()
+	pool := &pump.PumpAMMPool{
+		BaseMint:              solana.NewWallet().PublicKey(),
+		QuoteMint:             WSOL,
+		PoolBaseTokenAccount:  solana.NewWallet().PublicKey(),
+		PoolQuoteTokenAccount: solana.NewWallet().PublicKey(),
+	}
+	client.setTokenAccount(pool.PoolBaseTokenAccount, 1_000_000_000_000)
+	client.setTokenAccount(pool.PoolQuoteTokenAccount, 100_000_000_000)
+
+	out, err := pool.Quote(context.Background(), client, WSOL.String(), math.NewInt(1_000_000_000))
+	if err != nil {
+		t.Fatalf("quote failed: %v", err)
+	}
+	if !out.IsPositive() || out.GTE(math.NewInt(10_000_000_000)) {
+		t.Fatalf("unexpected quote output: %s", out)
+	}
+}
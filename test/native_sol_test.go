@@ -0,0 +1,130 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg"
+	"soltrading/pkg/router"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/swap"
+)
+
+// pairPool quotes one unit out per unit in and records the mints it is
+// asked to trade
+type pairPool struct {
+	pkg.Pool
+	base, quote string
+	inputs      []string
+	baseAccount solana.PublicKey
+}
+
+func (p *pairPool) ProtocolName() pkg.ProtocolName { return "pair_pool" }
+func (p *pairPool) GetID() string                  { return "pair-pool" }
+func (p *pairPool) GetTokens() (string, string)    { return p.base, p.quote }
+func (p *pairPool) GetProgramID() solana.PublicKey { return solana.SystemProgramID }
+func (p *pairPool) GetReserves() pkg.Reserves      { return pkg.Reserves{} }
+
+func (p *pairPool) Quote(ctx context.Context, solClient sol.SolClient, inputMint string, inputAmount math.Int) (math.Int, error) {
+	p.inputs = append(p.inputs, inputMint)
+	if inputMint != p.base && inputMint != p.quote {
+		return math.ZeroInt(), fmt.Errorf("pool does not trade %s", inputMint)
+	}
+	return inputAmount, nil
+}
+
+func (p *pairPool) BuildSwapInstructions(ctx context.Context, solClient sol.SolClient, user solana.PublicKey, inputMint string, inputAmount, minOut math.Int, userBaseAccount, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
+	p.inputs = append(p.inputs, inputMint)
+	p.baseAccount = userBaseAccount
+	return []solana.Instruction{solana.NewInstruction(solana.MemoProgramID, nil, nil)}, nil
+}
+
+// pairProtocol returns its pool for any pair and records the pairs asked for
+type pairProtocol struct {
+	pkg.Protocol
+	pool  *pairPool
+	pairs []string
+}
+
+func (p *pairProtocol) ProtocolName() pkg.ProtocolName { return "pair_pool" }
+
+func (p *pairProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+	p.pairs = append(p.pairs, baseMint+"/"+quoteMint)
+	return []pkg.Pool{p.pool}, nil
+}
+
+func TestRouterNativeSOL(t *testing.T) {
+	ctx := context.Background()
+	pool := &pairPool{base: WSOL.String(), quote: USDC.String()}
+	protocol := &pairProtocol{pool: pool}
+	r := router.NewSimpleRouter(protocol)
+	native := sol.NativeSOL.String()
+
+	if err := r.QueryAllPools(ctx, native, USDC.String()); err != nil {
+		t.Fatal(err)
+	}
+	if want := WSOL.String() + "/" + USDC.String(); len(protocol.pairs) != 1 || protocol.pairs[0] != want {
+		t.Fatalf("pools queried for %v, want %s", protocol.pairs, want)
+	}
+
+	routes, err := r.GetTopRoutesWithFilter(ctx, nil, native, math.NewInt(1000), nil, nil, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !routes[0].WrapInput || routes[0].UnwrapOutput || pool.inputs[0] != WSOL.String() {
+		t.Fatalf("native SOL input: route %+v, pool quoted %v", routes[0], pool.inputs)
+	}
+
+	user := solana.NewWallet().PublicKey()
+	instructions, err := swap.NewBuilder(nil, swap.Options{}).BuildRouteInstructions(ctx, routes[0], user, native, math.NewInt(1000), math.NewInt(990), solana.PublicKey{}, solana.PublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsolAccount, _, _ := solana.FindAssociatedTokenAddress(user, sol.WSOL)
+	// Create, fund and sync the WSOL account, swap, then close it
+	if len(instructions) != 5 || !instructions[4].ProgramID().Equals(solana.TokenProgramID) {
+		t.Fatalf("expected wrap, swap and unwrap instructions, got %d", len(instructions))
+	}
+	if pool.inputs[len(pool.inputs)-1] != WSOL.String() || !pool.baseAccount.Equals(wsolAccount) {
+		t.Errorf("swap built for input %s from %s, want WSOL from %s", pool.inputs[len(pool.inputs)-1], pool.baseAccount, wsolAccount)
+	}
+
+	routes, err = r.GetTopRoutesWithFilter(ctx, nil, USDC.String(), math.NewInt(1000), nil, nil, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if routes[0].WrapInput || !routes[0].UnwrapOutput {
+		t.Errorf("native SOL output: route %+v", routes[0])
+	}
+}
+
+func TestBuilderWithMockClient(t *testing.T) {
+	ctx := context.Background()
+	client := newMockSolClient()
+	user := solana.NewWallet().PublicKey()
+	client.accounts[USDC] = make([]byte, 82)
+	client.owners[USDC] = solana.TokenProgramID
+	usdcAccount, _, _ := solana.FindAssociatedTokenAddress(user, USDC)
+	client.setTokenAccount(usdcAccount, 0)
+
+	// Only the missing WSOL account is created
+	pool := &pairPool{base: WSOL.String(), quote: USDC.String()}
+	builder := swap.NewBuilder(client, swap.Options{CreateATAs: true})
+	instructions, err := builder.BuildSwapInstructions(ctx, pool, user, USDC.String(), math.NewInt(1000), math.NewInt(990), solana.PublicKey{}, solana.PublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsolAccount, _, _ := solana.FindAssociatedTokenAddress(user, WSOL)
+	if len(instructions) != 2 || !instructions[0].ProgramID().Equals(solana.SPLAssociatedTokenAccountProgramID) || !pool.baseAccount.Equals(wsolAccount) {
+		t.Fatalf("got %d instructions, base account %s", len(instructions), pool.baseAccount)
+	}
+
+	// Estimating the compute budget needs a client that can simulate
+	builder = swap.NewBuilder(client, swap.Options{ComputeBudget: swap.ComputeBudgetOptions{Enabled: true}})
+	if _, err := builder.BuildSwapInstructions(ctx, pool, user, USDC.String(), math.NewInt(1000), math.NewInt(990), usdcAccount, usdcAccount); err == nil {
+		t.Fatal("estimated a compute budget without a simulating client")
+	}
+}
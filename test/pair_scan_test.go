@@ -0,0 +1,81 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/pool/orca"
+	"soltrading/pkg/pool/pump"
+	"soltrading/pkg/protocol"
+)
+
+// orcaPoolAccount is an Orca pool account holding mintA and mintB
+func orcaPoolAccount(mintA, mintB solana.PublicKey) *rpc.KeyedAccount {
+	data := make([]byte, 324)
+	copy(data[104:], mintA.Bytes())
+	copy(data[136:], mintB.Bytes())
+	return &rpc.KeyedAccount{Pubkey: solana.NewWallet().PublicKey(), Account: &rpc.Account{Data: rpc.DataBytesOrJSONFromBytes(data)}}
+}
+
+func TestFetchPoolsByPairScansBothOrders(t *testing.T) {
+	solUSDC := orcaPoolAccount(WSOL, USDC)
+	usdcSOL := orcaPoolAccount(USDC, WSOL)
+	other := orcaPoolAccount(WSOL, solana.NewWallet().PublicKey())
+	client := &programClient{mockSolClient: newMockSolClient(), program: orca.OrcaAmmProgramID,
+		accounts: rpc.GetProgramAccountsResult{solUSDC, usdcSOL, other}}
+
+	pools, err := protocol.NewOrca(client).FetchPoolsByPair(context.Background(), WSOL.String(), USDC.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[string]int)
+	for _, pool := range pools {
+		found[pool.GetID()]++
+	}
+	if len(found) != 2 || found[solUSDC.Pubkey.String()] != 1 || found[usdcSOL.Pubkey.String()] != 1 {
+		t.Fatalf("expected both orders of the pair once each, got %v", found)
+	}
+}
+
+func TestPairScanDownloadsDecoderSlice(t *testing.T) {
+	client := &programClient{mockSolClient: newMockSolClient(), program: orca.OrcaAmmProgramID,
+		accounts: rpc.GetProgramAccountsResult{orcaPoolAccount(WSOL, USDC), orcaPoolAccount(USDC, WSOL)}}
+
+	pools, err := protocol.NewOrca(client).FetchPoolsByPair(context.Background(), WSOL.String(), USDC.String())
+	if err != nil || len(pools) != 2 {
+		t.Fatalf("got %d pools, %v", len(pools), err)
+	}
+	// The 324-byte accounts are cut to the 256 bytes OrcaPool.Decode reads
+	if client.sliced != 2*256 {
+		t.Fatalf("downloaded %d bytes, want %d", client.sliced, 2*256)
+	}
+	if tokenA, tokenB := pools[0].GetTokens(); tokenA != WSOL.String() && tokenB != WSOL.String() {
+		t.Fatalf("sliced pool decoded mints %s/%s", tokenA, tokenB)
+	}
+}
+
+func TestPumpPoolSliceKeepsCoinCreator(t *testing.T) {
+	creator := solana.NewWallet().PublicKey()
+	data := make([]byte, pump.DefaultSpan)
+	copy(data, anchor.GetDiscriminator("account", "Pool"))
+	copy(data[pump.BaseMintOffset:], WSOL[:])
+	copy(data[pump.QuoteMintOffset:], USDC[:])
+	copy(data[pump.PoolDataSize:], creator[:])
+	client := &programClient{mockSolClient: newMockSolClient(), program: pump.PumpSwapProgramID,
+		accounts: rpc.GetProgramAccountsResult{{Pubkey: solana.NewWallet().PublicKey(), Account: &rpc.Account{Data: rpc.DataBytesOrJSONFromBytes(data)}}}}
+
+	page, err := protocol.NewPumpAmm(client).FetchAllPools(context.Background(), pkg.FetchAllOptions{PageShards: pkg.PoolShards})
+	if err != nil || len(page.Pools) != 1 {
+		t.Fatalf("got %d pools, %v", len(page.Pools), err)
+	}
+	if client.sliced != pump.CoinCreatorEnd {
+		t.Errorf("downloaded %d bytes, want %d", client.sliced, pump.CoinCreatorEnd)
+	}
+	if got := page.Pools[0].(*pump.PumpAMMPool).CoinCreator; !got.Equals(creator) {
+		t.Errorf("coin creator %s, want %s", got, creator)
+	}
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/pool/raydium"
+	"soltrading/pkg/poolapi"
+	"soltrading/pkg/protocol"
+)
+
+// stubProtocol fails pair lookups and loads pools by ID from a set
+type stubProtocol struct {
+	pkg.Protocol
+	name    pkg.ProtocolName
+	pairErr error
+	pools   map[string]pkg.Pool
+}
+
+func (p *stubProtocol) ProtocolName() pkg.ProtocolName {
+	return p.name
+}
+
+func (p *stubProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+	return nil, p.pairErr
+}
+
+func (p *stubProtocol) FetchPoolByID(ctx context.Context, poolID string) (pkg.Pool, error) {
+	if pool, ok := p.pools[poolID]; ok {
+		return pool, nil
+	}
+	return nil, fmt.Errorf("pool %s not found", poolID)
+}
+
+// stubPool is a pool known only by its ID
+type stubPool struct {
+	pkg.Pool
+	id string
+}
+
+func (p *stubPool) GetID() string {
+	return p.id
+}
+
+func TestRaydiumPoolAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pools/info/mint" || r.URL.Query().Get("mint1") != WSOL.String() {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"success":true,"data":{"data":[
+			{"id":"cpmm-pool","programId":%q,"mintA":{"address":%q},"mintB":{"address":%q}},
+			{"id":"clmm-pool","programId":%q,"mintA":{"address":%q},"mintB":{"address":%q}},
+			{"id":"other-pair","programId":%q,"mintA":{"address":%q},"mintB":{"address":"x"}}
+		]}}`,
+			raydium.RAYDIUM_CPMM_PROGRAM_ID, USDC, WSOL,
+			raydium.RAYDIUM_CLMM_PROGRAM_ID, WSOL, USDC,
+			raydium.RAYDIUM_CPMM_PROGRAM_ID, WSOL)
+	}))
+	defer server.Close()
+
+	backend := poolapi.NewRaydium(server.URL)
+	got, err := backend.PoolAddresses(context.Background(), pkg.ProtocolNameRaydiumCpmm, WSOL.String(), USDC.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{"cpmm-pool"}) {
+		t.Errorf("addresses = %v, want [cpmm-pool]", got)
+	}
+	if backend.Supports(pkg.ProtocolNamePumpAmm) {
+		t.Error("raydium backend claims Pump AMM pools")
+	}
+}
+
+func TestFallbackHydratesAPIPools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"groups":[{"pairs":[
+			{"address":"listed","mint_x":%q,"mint_y":%q},
+			{"address":"missing","mint_x":%q,"mint_y":%q}
+		]}]}`, USDC, WSOL, WSOL, USDC)
+	}))
+	defer server.Close()
+
+	gpaErr := errors.New("getProgramAccounts is disabled")
+	listed := &stubPool{id: "listed"}
+	inner := &stubProtocol{name: pkg.ProtocolNameMeteoraDlmm, pairErr: gpaErr, pools: map[string]pkg.Pool{"listed": listed}}
+
+	protocols := poolapi.WithFallback([]pkg.Protocol{inner}, poolapi.NewMeteora(server.URL), poolapi.NewRaydium(server.URL))
+	pools, err := protocols[0].FetchPoolsByPair(context.Background(), WSOL.String(), USDC.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 1 || pools[0] != listed {
+		t.Fatalf("pools = %v, want the listed pool", pools)
+	}
+
+	// Protocols no backend lists keep their own error
+	other := &stubProtocol{name: pkg.ProtocolNamePumpAmm, pairErr: gpaErr}
+	protocols = poolapi.WithFallback([]pkg.Protocol{other}, poolapi.DefaultBackends()...)
+	if protocols[0] != other {
+		t.Fatal("protocol without a backend was wrapped")
+	}
+}
+
+func TestFetchEachPairDedupes(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	fail := errors.New("rate limited")
+	fetch := func(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+		mu.Lock()
+		calls[baseMint+"/"+quoteMint]++
+		mu.Unlock()
+		if baseMint == "C" || quoteMint == "C" {
+			return nil, fail
+		}
+		return []pkg.Pool{&stubPool{id: baseMint + quoteMint}}, nil
+	}
+
+	pairs := []pkg.Pair{{BaseMint: "A", QuoteMint: "B"}, {BaseMint: "B", QuoteMint: "A"}, {BaseMint: "A", QuoteMint: "C"}}
+	found, err := pkg.FetchEachPair(context.Background(), pairs, 2, fetch)
+	if !errors.Is(err, fail) {
+		t.Fatalf("expected the failed pair's error, got %v", err)
+	}
+	if len(calls) != 2 || calls["A/B"]+calls["B/A"] != 1 {
+		t.Fatalf("expected each pair fetched once in either direction, got %v", calls)
+	}
+	for _, pair := range pairs[:2] {
+		if len(found[pair]) != 1 {
+			t.Errorf("expected one pool for %v, got %v", pair, found[pair])
+		}
+	}
+	if _, ok := found[pairs[2]]; ok {
+		t.Error("expected the failed pair to be missing")
+	}
+}
+
+func TestFetchPoolsByPairsScansSharedMintOnce(t *testing.T) {
+	mockClient := newMockSolClient()
+	client := &programClient{mockSolClient: mockClient, program: raydium.RAYDIUM_CPMM_PROGRAM_ID}
+	ammConfig := solana.NewWallet().PublicKey()
+	mockClient.accounts[ammConfig] = cpmmAmmConfigData(2500)
+	addPool := func(mint0, mint1 solana.PublicKey) solana.PublicKey {
+		id := solana.NewWallet().PublicKey()
+		data := cpmmPoolData(&raydium.CPMMPool{AmmConfig: ammConfig, Token0Mint: mint0, Token1Mint: mint1})
+		client.accounts = append(client.accounts, &rpc.KeyedAccount{Pubkey: id, Account: &rpc.Account{Data: rpc.DataBytesOrJSONFromBytes(data)}})
+		mockClient.accounts[id] = data
+		return id
+	}
+	x, y, z, w := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	xPool := addPool(x, WSOL)
+	yPool := addPool(WSOL, y)
+	zPool := addPool(z, w)
+	addPool(solana.NewWallet().PublicKey(), WSOL) // shares WSOL but was not asked for
+
+	pairs := []pkg.Pair{
+		{BaseMint: x.String(), QuoteMint: WSOL.String()},
+		{BaseMint: y.String(), QuoteMint: WSOL.String()},
+		{BaseMint: z.String(), QuoteMint: w.String()},
+	}
+	found, err := protocol.NewRaydiumCpmm(client).FetchPoolsByPairs(context.Background(), pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both WSOL pairs share WSOL's two scans; the other pair scans both orders
+	if client.scans != 4 {
+		t.Fatalf("%d scans, want 4", client.scans)
+	}
+	// The WSOL scans download only the mints of its three pools, and the
+	// pools asked for are then fetched whole; the other pair's scan
+	// downloads the decoded prefix of its pool
+	if client.sliced != 3*64+584 {
+		t.Fatalf("scans downloaded %d bytes, want %d", client.sliced, 3*64+584)
+	}
+	for i, want := range []solana.PublicKey{xPool, yPool, zPool} {
+		pools := found[pairs[i]]
+		if len(pools) != 1 || pools[0].GetID() != want.String() {
+			t.Errorf("pools of pair %d: %v, want %s", i, pools, want)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/pool/pump"
+)
+
+// pumpSwapCases are swaps against the default 20 bps LP and 5 bps protocol
+// fees, plus the 5 bps creator fee for pools with a coin creator. Each row
+// carries the steps of the program's integer math worked out by hand: a sell
+// swaps to the constant-product output, rounded down, and pays each fee on
+// it rounded up; a buy swaps quoteIn * 10000 / (10000 + fees), rounded down,
+// for the constant-product output, rounded down. TestPumpSwapCases checks
+// the steps against those rules with multiplications alone, so the
+// expectations do not come from the formulas under test.
+var pumpSwapCases = []struct {
+	name         string
+	baseReserve  int64
+	quoteReserve int64
+	sell         bool
+	creator      bool
+	amountIn     int64
+	swapped      int64    // sells: quote out before fees; buys: quote into the pool
+	fees         [3]int64 // sells: LP, protocol and creator fees
+	amountOut    int64
+}{
+	{"sell small", 206_184_295_611_234, 85_417_325_112, true, false, 1_000_000_000, 414_274, [3]int64{829, 208, 0}, 413_237},
+	{"sell large", 206_184_295_611_234, 85_417_325_112, true, false, 123_456_789_012, 51_114_650, [3]int64{102_230, 25_558, 0}, 50_986_862},
+	{"sell dust", 206_184_295_611_234, 85_417_325_112, true, false, 7, 0, [3]int64{0, 0, 0}, 0},
+	{"buy small", 206_184_295_611_234, 85_417_325_112, false, false, 100_000_000, 99_750_623, [3]int64{0, 0, 0}, 240_501_815_143},
+	{"buy large", 206_184_295_611_234, 85_417_325_112, false, false, 5_000_000_000, 4_987_531_172, [3]int64{0, 0, 0}, 11_374_948_689_785},
+	{"sell even pool", 1_000_000_000_000, 100_000_000_000, true, false, 1_000_000_000, 99_900_099, [3]int64{199_801, 49_951, 0}, 99_650_347},
+	{"sell deep", 1_000_000_000_000, 100_000_000_000, true, false, 123_456_789_012, 10_989_010_900, [3]int64{21_978_022, 5_494_506, 0}, 10_961_538_372},
+	{"buy even pool", 1_000_000_000_000, 100_000_000_000, false, false, 100_000_000, 99_750_623, [3]int64{0, 0, 0}, 996_512_202},
+	{"buy deep", 1_000_000_000_000, 100_000_000_000, false, false, 5_000_000_000, 4_987_531_172, [3]int64{0, 0, 0}, 47_505_938_241},
+	{"buy one lamport", 1_000_000_000_000, 100_000_000_000, false, false, 1, 0, [3]int64{0, 0, 0}, 0},
+	{"creator sell small", 206_184_295_611_234, 85_417_325_112, true, true, 1_000_000_000, 414_274, [3]int64{829, 208, 208}, 413_029},
+	{"creator buy large", 206_184_295_611_234, 85_417_325_112, false, true, 5_000_000_000, 4_985_044_865, [3]int64{0, 0, 0}, 11_369_590_911_631},
+	{"creator sell even pool", 1_000_000_000_000, 100_000_000_000, true, true, 1_000_000_000, 99_900_099, [3]int64{199_801, 49_951, 49_951}, 99_600_396},
+	{"creator buy even pool", 1_000_000_000_000, 100_000_000_000, false, true, 100_000_000, 99_700_897, [3]int64{0, 0, 0}, 996_015_933},
+}
+
+// ratioDown reports whether q is num / den rounded down: q*den <= num < (q+1)*den
+func ratioDown(q, num, den math.Int) bool {
+	return q.Mul(den).LTE(num) && q.AddRaw(1).Mul(den).GT(num)
+}
+
+// ratioUp reports whether q is num / den rounded up: (q-1)*den < num <= q*den
+func ratioUp(q, num, den math.Int) bool {
+	return q.Mul(den).GTE(num) && q.SubRaw(1).Mul(den).LT(num)
+}
+
+func TestPumpSwapCases(t *testing.T) {
+	for _, tc := range pumpSwapCases {
+		t.Run(tc.name, func(t *testing.T) {
+			baseReserve, quoteReserve := math.NewInt(tc.baseReserve), math.NewInt(tc.quoteReserve)
+			amountIn, swapped, amountOut := math.NewInt(tc.amountIn), math.NewInt(tc.swapped), math.NewInt(tc.amountOut)
+			if tc.sell {
+				if !ratioDown(swapped, quoteReserve.Mul(amountIn), baseReserve.Add(amountIn)) {
+					t.Fatalf("constant-product output %d is not rounded down", tc.swapped)
+				}
+				bps := [3]int64{20, 5, 0}
+				if tc.creator {
+					bps[2] = 5
+				}
+				out := swapped
+				for i, fee := range tc.fees {
+					if !ratioUp(math.NewInt(fee), swapped.MulRaw(bps[i]), math.NewInt(10000)) {
+						t.Fatalf("fee %d of %d bps is not rounded up", fee, bps[i])
+					}
+					out = out.SubRaw(fee)
+				}
+				if !out.Equal(amountOut) {
+					t.Fatalf("output %d, want %s after fees", tc.amountOut, out)
+				}
+				return
+			}
+
+			feeBps := int64(10025)
+			if tc.creator {
+				feeBps += 5
+			}
+			if !ratioDown(swapped, amountIn.MulRaw(10000), math.NewInt(feeBps)) {
+				t.Fatalf("quote swapped %d is not rounded down", tc.swapped)
+			}
+			if !ratioDown(amountOut, baseReserve.Mul(swapped), quoteReserve.Add(swapped)) {
+				t.Fatalf("constant-product output %d is not rounded down", tc.amountOut)
+			}
+		})
+	}
+}
+
+func TestPumpSwapMath(t *testing.T) {
+	for _, tc := range pumpSwapCases {
+		t.Run(tc.name, func(t *testing.T) {
+			baseReserve, quoteReserve := math.NewInt(tc.baseReserve), math.NewInt(tc.quoteReserve)
+			fees := pump.DefaultFees
+			if !tc.creator {
+				fees.CoinCreatorFeeBps = 0
+			}
+			var out math.Int
+			if tc.sell {
+				out = pump.SellQuote(math.NewInt(tc.amountIn), baseReserve, quoteReserve, fees)
+			} else {
+				out = pump.BuyQuote(math.NewInt(tc.amountIn), baseReserve, quoteReserve, fees)
+			}
+			if !out.Equal(math.NewInt(tc.amountOut)) {
+				t.Fatalf("got %s, want %d", out, tc.amountOut)
+			}
+		})
+	}
+}
+
+// pumpSwapRecord is a swap recorded from a mainnet PumpSwap transaction, see
+// testdata/README.md
+type pumpSwapRecord struct {
+	Signature      string `json:"signature"`
+	Kind           string `json:"kind"`
+	BaseReserve    uint64 `json:"base_reserve"`
+	QuoteReserve   uint64 `json:"quote_reserve"`
+	LpFeeBps       uint64 `json:"lp_fee_bps"`
+	ProtocolFeeBps uint64 `json:"protocol_fee_bps"`
+	CreatorFeeBps  uint64 `json:"creator_fee_bps"`
+	AmountIn       uint64 `json:"amount_in"`
+	AmountOut      uint64 `json:"amount_out"`
+}
+
+// TestPumpSwapRecorded replays swaps recorded on mainnet, which pin the
+// quote to the program rather than to the rounding worked out above
+func TestPumpSwapRecorded(t *testing.T) {
+	data, err := os.ReadFile("testdata/pumpswap_swaps.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []pumpSwapRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("bad fixture file: %v", err)
+	}
+	if len(records) == 0 {
+		t.Skip("no PumpSwap swaps recorded in testdata/pumpswap_swaps.json")
+	}
+
+	for _, rec := range records {
+		t.Run(rec.Signature, func(t *testing.T) {
+			baseReserve, quoteReserve := math.NewIntFromUint64(rec.BaseReserve), math.NewIntFromUint64(rec.QuoteReserve)
+			fees := pump.Fees{LpFeeBps: rec.LpFeeBps, ProtocolFeeBps: rec.ProtocolFeeBps, CoinCreatorFeeBps: rec.CreatorFeeBps}
+			amountIn, want := math.NewIntFromUint64(rec.AmountIn), math.NewIntFromUint64(rec.AmountOut)
+			switch rec.Kind {
+			case "sell":
+				if got := pump.SellQuote(amountIn, baseReserve, quoteReserve, fees); !got.Equal(want) {
+					t.Fatalf("sell: got %s, want %s", got, want)
+				}
+			case "buy_exact_quote_in":
+				if got := pump.BuyQuote(amountIn, baseReserve, quoteReserve, fees); !got.Equal(want) {
+					t.Fatalf("buy: got %s, want %s", got, want)
+				}
+			case "buy":
+				// The buyer fixed the base amount and paid at most amount_in for it
+				if got := pump.BuyQuote(amountIn, baseReserve, quoteReserve, fees); got.LT(want) {
+					t.Fatalf("buy: got %s, want at least %s", got, want)
+				}
+			default:
+				t.Fatalf("unknown swap kind %q", rec.Kind)
+			}
+		})
+	}
+}
+
+// TestPumpQuoteMatchesSwapMath quotes the same swaps through the pool with
+// mocked vault balances
+func TestPumpQuoteMatchesSwapMath(t *testing.T) {
+	for _, tc := range pumpSwapCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newMockSolClient()
+			pool := &pump.PumpAMMPool{
+				BaseMint:              solana.NewWallet().PublicKey(),
+				QuoteMint:             WSOL,
+				PoolBaseTokenAccount:  solana.NewWallet().PublicKey(),
+				PoolQuoteTokenAccount: solana.NewWallet().PublicKey(),
+			}
+			if tc.creator {
+				pool.CoinCreator = solana.NewWallet().PublicKey()
+			}
+			client.setTokenAccount(pool.PoolBaseTokenAccount, uint64(tc.baseReserve))
+			client.setTokenAccount(pool.PoolQuoteTokenAccount, uint64(tc.quoteReserve))
+
+			inputMint := WSOL.String()
+			if tc.sell {
+				inputMint = pool.BaseMint.String()
+			}
+			out, err := pool.Quote(context.Background(), client, inputMint, math.NewInt(tc.amountIn))
+			if err != nil {
+				t.Fatalf("quote failed: %v", err)
+			}
+			if !out.Equal(math.NewInt(tc.amountOut)) {
+				t.Fatalf("got %s, want %d", out, tc.amountOut)
+			}
+		})
+	}
+}
+
+func TestPumpFeeRate(t *testing.T) {
+	pool := &pump.PumpAMMPool{}
+	if fee := pool.GetFeeRate(); fee != 25 {
+		t.Fatalf("default fee rate = %v bps, want 25", fee)
+	}
+	pool.Fees = pump.Fees{LpFeeBps: 25, ProtocolFeeBps: 5, CoinCreatorFeeBps: 10}
+	if fee := pool.GetFeeRate(); fee != 30 {
+		t.Fatalf("fee rate = %v bps, want 30", fee)
+	}
+	pool.CoinCreator = solana.NewWallet().PublicKey()
+	if fee := pool.GetFeeRate(); fee != 40 {
+		t.Fatalf("fee rate with a coin creator = %v bps, want 40", fee)
+	}
+}
+
+func TestDecodeGlobalConfig(t *testing.T) {
+	recipient := solana.NewWallet().PublicKey()
+	data := make([]byte, pump.GlobalConfigMinSize+8+32)
+	copy(data, anchor.GetDiscriminator("account", "GlobalConfig"))
+	binary.LittleEndian.PutUint64(data[40:48], 25)
+	binary.LittleEndian.PutUint64(data[48:56], 5)
+	// The first recipient slot is empty, the second is set
+	copy(data[57+32:57+64], recipient[:])
+	binary.LittleEndian.PutUint64(data[pump.GlobalConfigMinSize:], 5)
+
+	config, err := pump.DecodeGlobalConfig(data)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if fees := config.Fees(); fees != (pump.Fees{LpFeeBps: 25, ProtocolFeeBps: 5, CoinCreatorFeeBps: 5}) {
+		t.Errorf("got fees %+v", fees)
+	}
+	if got := config.ProtocolFeeRecipient(); !got.Equals(recipient) {
+		t.Errorf("got recipient %s, want %s", got, recipient)
+	}
+
+	// Configs from before creator fees end at the recipients
+	if config, err = pump.DecodeGlobalConfig(data[:pump.GlobalConfigMinSize]); err != nil || config.CoinCreatorFeeBps != 0 {
+		t.Errorf("short config: %+v, %v", config, err)
+	}
+
+	data[0]++
+	if _, err := pump.DecodeGlobalConfig(data); !errors.Is(err, anchor.ErrAccountMismatch) {
+		t.Errorf("got %v for a foreign account, want ErrAccountMismatch", err)
+	}
+}
@@ -0,0 +1,47 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cosmossdk.io/math"
+	"soltrading/pkg/pool/raydium"
+)
+
+func TestRaydiumAMMSwapEnabled(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cases := []struct {
+		name     string
+		status   uint64
+		openTime uint64
+		tradable bool
+		enabled  bool
+	}{
+		{"initialized", raydium.AmmStatusInitialized, 0, true, true},
+		{"swap only", raydium.AmmStatusSwapOnly, 0, true, true},
+		{"disabled", raydium.AmmStatusDisabled, 0, false, false},
+		{"withdraw only", raydium.AmmStatusWithdrawOnly, 0, false, false},
+		{"liquidity only", raydium.AmmStatusLiquidityOnly, 0, false, false},
+		{"order book only", raydium.AmmStatusOrderBookOnly, 0, false, false},
+		{"waiting, opened", raydium.AmmStatusWaitingTrade, uint64(now.Unix()), true, true},
+		{"waiting, not open", raydium.AmmStatusWaitingTrade, uint64(now.Unix()) + 60, true, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := &raydium.AMMPool{Status: tc.status, PoolOpenTime: tc.openTime}
+			if got := pool.Tradable(); got != tc.tradable {
+				t.Errorf("Tradable() = %v, want %v", got, tc.tradable)
+			}
+			if got := pool.SwapEnabled(now); got != tc.enabled {
+				t.Errorf("SwapEnabled() = %v, want %v", got, tc.enabled)
+			}
+		})
+	}
+
+	// Quote refuses before touching the RPC
+	pool := &raydium.AMMPool{Status: raydium.AmmStatusWithdrawOnly}
+	if _, err := pool.Quote(context.Background(), newMockSolClient(), "", math.NewInt(1)); err == nil {
+		t.Fatal("quoted a withdraw-only pool")
+	}
+}
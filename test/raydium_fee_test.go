@@ -0,0 +1,72 @@
+package test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/pool/raydium"
+)
+
+// cpmmAmmConfigData builds a CPMM AmmConfig account with the given trade fee
+func cpmmAmmConfigData(tradeFeeRate uint64) []byte {
+	data := make([]byte, raydium.CPMMAmmConfigSize)
+	copy(data, anchor.GetDiscriminator("account", "AmmConfig"))
+	binary.LittleEndian.PutUint64(data[12:20], tradeFeeRate)
+	return data
+}
+
+// TestCPMMQuoteUsesAmmConfigFee quotes a CPMM pool whose AmmConfig is on the
+// 1% tier, with the fee rounded up as the program does
+func TestCPMMQuoteUsesAmmConfigFee(t *testing.T) {
+	cases := []struct {
+		name         string
+		tradeFeeRate uint64
+		amountIn     int64
+		amountOut    int64
+	}{
+		{"1% tier", 10_000, 1_000_000_000, 98_902_086},
+		{"1% tier dust", 10_000, 12_345, 1_222},
+		{"0.25% tier", 2_500, 1_000_000_000, 99_650_598},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newMockSolClient()
+			pool := &raydium.CPMMPool{
+				AmmConfig:   solana.NewWallet().PublicKey(),
+				Token0Mint:  solana.NewWallet().PublicKey(),
+				Token1Mint:  WSOL,
+				Token0Vault: solana.NewWallet().PublicKey(),
+				Token1Vault: solana.NewWallet().PublicKey(),
+			}
+			client.accounts[pool.AmmConfig] = cpmmAmmConfigData(tc.tradeFeeRate)
+			client.setTokenAccount(pool.Token0Vault, 1_000_000_000_000)
+			client.setTokenAccount(pool.Token1Vault, 100_000_000_000)
+
+			out, err := pool.Quote(context.Background(), client, pool.Token0Mint.String(), math.NewInt(tc.amountIn))
+			if err != nil {
+				t.Fatalf("quote failed: %v", err)
+			}
+			if !out.Equal(math.NewInt(tc.amountOut)) {
+				t.Fatalf("got %s, want %d", out, tc.amountOut)
+			}
+			if fee := pool.GetFeeRate(); fee != float64(tc.tradeFeeRate)/100 {
+				t.Fatalf("fee rate = %v bps, want %v", fee, float64(tc.tradeFeeRate)/100)
+			}
+		})
+	}
+}
+
+func TestDecodeAmmConfigRejectsOtherAccounts(t *testing.T) {
+	data := cpmmAmmConfigData(2_500)
+	if _, err := raydium.DecodeCPMMAmmConfig(data[:raydium.CLMMAmmConfigSize]); err == nil {
+		t.Fatal("decoded a CLMM-sized account as a CPMM AmmConfig")
+	}
+	data[0]++
+	if _, err := raydium.DecodeCPMMAmmConfig(data); err == nil {
+		t.Fatal("decoded an account with a foreign discriminator")
+	}
+}
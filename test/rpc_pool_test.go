@@ -0,0 +1,100 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"soltrading/pkg/sol"
+)
+
+// rpcEndpoint answers every request with status and body
+func rpcEndpoint(t *testing.T, status int, body string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// signedTransfer returns a signed one-lamport transfer
+func signedTransfer(t *testing.T) *solana.Transaction {
+	t.Helper()
+	payer := solana.NewWallet()
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build()},
+		solana.Hash{},
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey { return &payer.PrivateKey }); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestSimulateReportsRateLimit(t *testing.T) {
+	client, err := sol.NewClient(context.Background(), rpcEndpoint(t, http.StatusTooManyRequests, "Too Many Requests"), "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Simulate(context.Background(), signedTransfer(t), solana.PublicKey{}); !errors.Is(err, sol.ErrRateLimited) {
+		t.Fatalf("simulating against a throttled endpoint: %v", err)
+	}
+}
+
+func TestBroadcastMarksOnlyFailingEndpointsUnhealthy(t *testing.T) {
+	rejecting := rpcEndpoint(t, http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32002,"message":"Transaction simulation failed: insufficient funds"}}`)
+	behind := rpcEndpoint(t, http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"Node is behind by 120 slots"}}`)
+	throttled := rpcEndpoint(t, http.StatusTooManyRequests, "Too Many Requests")
+	broken := rpcEndpoint(t, http.StatusBadGateway, "Bad Gateway")
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	pool, err := sol.NewRPCPool(context.Background(), []string{rejecting, behind, throttled, broken, down.URL}, "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pool.SendTransaction(context.Background(), signedTransfer(t), sol.SendOptions{Broadcast: true}); err == nil {
+		t.Fatal("broadcast succeeded with every endpoint failing")
+	}
+
+	// Only the endpoint that rejected the transaction itself stays healthy
+	healthy := pool.HealthyClients()
+	if len(healthy) != 1 || healthy[0].Endpoint() != rejecting {
+		var endpoints []string
+		for _, client := range healthy {
+			endpoints = append(endpoints, client.Endpoint())
+		}
+		t.Fatalf("healthy endpoints %v, want only %s", endpoints, rejecting)
+	}
+}
+
+func TestUnhealthyMarkExpires(t *testing.T) {
+	pool, err := sol.NewRPCPool(context.Background(), []string{"http://127.0.0.1:1", "http://127.0.0.1:2"}, "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.SetUnhealthyDuration(50 * time.Millisecond)
+
+	pool.MarkUnhealthy(pool.GetAllClients()[0])
+	if healthy := pool.HealthyClients(); len(healthy) != 1 || healthy[0] != pool.GetAllClients()[1] {
+		t.Fatalf("%d healthy endpoints right after the mark, want only the second", len(healthy))
+	}
+
+	// Once the mark expires the endpoint is tried again
+	time.Sleep(60 * time.Millisecond)
+	if healthy := len(pool.HealthyClients()); healthy != 2 {
+		t.Fatalf("%d healthy endpoints after the mark expired, want 2", healthy)
+	}
+}
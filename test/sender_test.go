@@ -0,0 +1,121 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"soltrading/pkg/sol"
+)
+
+// expiredNode is an RPC node whose blockhashes have always expired and whose
+// recent status cache never holds the signature; searching the full history
+// answers with the next of history, repeating the last one
+type expiredNode struct {
+	mu      sync.Mutex
+	history []string // JSON signature statuses, "null" for unknown
+	builds  int
+}
+
+func (n *expiredNode) serve(t *testing.T) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result := "null"
+		switch req.Method {
+		case "getLatestBlockhash":
+			result = `{"context":{"slot":1},"value":{"blockhash":"` + solana.Hash{}.String() + `","lastValidBlockHeight":100}}`
+		case "sendTransaction":
+			result = `"` + solana.Signature{}.String() + `"`
+		case "getBlockHeight":
+			result = "101"
+		case "getSignatureStatuses":
+			status := "null"
+			if len(req.Params) > 1 {
+				n.mu.Lock()
+				status = n.history[0]
+				if len(n.history) > 1 {
+					n.history = n.history[1:]
+				}
+				n.mu.Unlock()
+			}
+			result = `{"context":{"slot":1},"value":[` + status + `]}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":` + result + `}`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// send drives a transfer through a Sender on the node
+func (n *expiredNode) send(t *testing.T, maxResigns int) (*sol.SendResult, error) {
+	client, err := sol.NewClient(context.Background(), n.serve(t), "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := sol.DefaultSenderOptions()
+	opts.RebroadcastInterval = 10 * time.Millisecond
+	opts.MaxResigns = maxResigns
+
+	payer := solana.NewWallet()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return sol.NewSender(client, opts).Send(ctx, func(ctx context.Context, blockhash solana.Hash) (*solana.Transaction, error) {
+		n.mu.Lock()
+		n.builds++
+		n.mu.Unlock()
+		tx, err := solana.NewTransaction(
+			[]solana.Instruction{system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build()},
+			blockhash,
+			solana.TransactionPayer(payer.PublicKey()),
+		)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey { return &payer.PrivateKey })
+		return tx, err
+	})
+}
+
+func TestSenderWaitsForTransactionLandedAtExpiry(t *testing.T) {
+	// The transaction landed just as its blockhash expired: history first
+	// knows it as processed, then confirmed
+	node := &expiredNode{history: []string{
+		`{"slot":95,"confirmations":1,"err":null,"confirmationStatus":"processed"}`,
+		`{"slot":95,"confirmations":2,"err":null,"confirmationStatus":"confirmed"}`,
+	}}
+	result, err := node.send(t, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.builds != 1 || result.Resigns != 0 || result.Slot != 95 {
+		t.Fatalf("built %d times with %d re-signs, landed in slot %d; want one build landing in slot 95", node.builds, result.Resigns, result.Slot)
+	}
+}
+
+func TestSenderResignsUnknownTransaction(t *testing.T) {
+	node := &expiredNode{history: []string{"null"}}
+	result, err := node.send(t, 1)
+	if !errors.Is(err, sol.ErrBlockhashExpired) {
+		t.Fatalf("sending a transaction that never lands: %v", err)
+	}
+	if node.builds != 2 || result.Resigns != 1 {
+		t.Fatalf("built %d times with %d re-signs, want 2 builds and 1 re-sign", node.builds, result.Resigns)
+	}
+}
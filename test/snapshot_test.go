@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"soltrading/pkg"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/pool/raydium"
+	"soltrading/pkg/protocol"
+	"soltrading/pkg/sol"
+	"soltrading/pkg/subscription"
+)
+
+// slotClient answers account reads at a fixed slot
+type slotClient struct {
+	*mockSolClient
+	slot uint64
+}
+
+func (c *slotClient) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	result, err := c.mockSolClient.GetAccountInfoWithOpts(ctx, account)
+	if err == nil {
+		result.Context.Slot = c.slot
+	}
+	return result, err
+}
+
+// cpmmPoolData builds a CPMM PoolState account with pool's keys
+func cpmmPoolData(pool *raydium.CPMMPool) []byte {
+	data := make([]byte, 637)
+	copy(data, anchor.GetDiscriminator("account", "PoolState"))
+	copy(data[8:], pool.AmmConfig[:])
+	copy(data[72:], pool.Token0Vault[:])
+	copy(data[104:], pool.Token1Vault[:])
+	copy(data[168:], pool.Token0Mint[:])
+	copy(data[200:], pool.Token1Mint[:])
+	return data
+}
+
+// cpmmLoader loads CPMM pools through client
+func cpmmLoader(client sol.SolClient) subscription.PoolLoader {
+	return func(ctx context.Context, name pkg.ProtocolName, poolID string) (pkg.Pool, error) {
+		return protocol.NewRaydiumCpmm(client).FetchPoolByID(ctx, poolID)
+	}
+}
+
+func TestSnapshotRestoresWithoutRPC(t *testing.T) {
+	ctx := context.Background()
+	client := &slotClient{mockSolClient: newMockSolClient(), slot: 500}
+	poolID := solana.NewWallet().PublicKey()
+	layout := &raydium.CPMMPool{
+		AmmConfig:   solana.NewWallet().PublicKey(),
+		Token0Vault: solana.NewWallet().PublicKey(),
+		Token1Vault: solana.NewWallet().PublicKey(),
+		Token0Mint:  solana.NewWallet().PublicKey(),
+		Token1Mint:  WSOL,
+	}
+	client.accounts[poolID] = cpmmPoolData(layout)
+	client.accounts[layout.AmmConfig] = cpmmAmmConfigData(10_000)
+
+	// The first load leaves the AmmConfig cached, so recording sees it only
+	// through the cache
+	original, err := cpmmLoader(client)(ctx, pkg.ProtocolNameRaydiumCpmm, poolID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := newTestManager(t, newFakeBackend())
+	if err := sm.SubscribePool(original); err != nil {
+		t.Fatal(err)
+	}
+
+	snap := sm.Snapshot()
+	if recorded := subscription.RecordAccounts(ctx, &snap, client, cpmmLoader, 0); recorded != 1 {
+		t.Fatalf("recorded %d pools, want 1", recorded)
+	}
+	entry := snap.Pools[0]
+	if entry.Slot != 500 || len(entry.Accounts) != 2 {
+		t.Fatalf("recorded slot %d with %d accounts, want slot 500 with the pool and its AmmConfig", entry.Slot, len(entry.Accounts))
+	}
+	if _, ok := entry.Accounts[layout.AmmConfig.String()]; !ok {
+		t.Fatal("the cached AmmConfig was not recorded")
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := subscription.WriteSnapshotFile(path, snap); err != nil {
+		t.Fatal(err)
+	}
+	var saved subscription.Snapshot
+	if err := subscription.ReadSnapshotFile(path, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a fallback any read the snapshot cannot answer fails
+	offline := subscription.NewSnapshotClient(saved, nil)
+	restoredMgr := newTestManager(t, newFakeBackend())
+	pools := restoredMgr.RestoreSnapshot(ctx, saved, cpmmLoader(offline), 0)
+	if len(pools) != 1 || offline.Misses() != 0 {
+		t.Fatalf("restored %d pools with %d reads missing from the snapshot", len(pools), offline.Misses())
+	}
+	restored := pools[0].(*raydium.CPMMPool)
+	want := original.(*raydium.CPMMPool)
+	if restored.PoolId != want.PoolId || restored.Token0Mint != want.Token0Mint || restored.Token1Vault != want.Token1Vault ||
+		restored.TradeFeeRate != want.TradeFeeRate {
+		t.Fatalf("restored pool %+v, want %+v", restored, want)
+	}
+	if !restoredMgr.IsSubscribed(poolID.String()) {
+		t.Fatal("restored pool is not subscribed")
+	}
+	// The pool is cached at the slot its accounts were read
+	if slot := restoredMgr.Snapshot().Pools[0].Slot; slot != 500 {
+		t.Fatalf("restored pool cached at slot %d, want 500", slot)
+	}
+
+	if _, err := cpmmLoader(offline)(ctx, pkg.ProtocolNameRaydiumCpmm, solana.NewWallet().PublicKey().String()); !errors.Is(err, subscription.ErrNotInSnapshot) {
+		t.Fatalf("loading a pool missing from the snapshot: %v", err)
+	}
+}
@@ -0,0 +1,382 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg"
+	"soltrading/pkg/subscription"
+)
+
+// fakeBackend is an update stream that records account subscriptions and
+// lets tests push updates to their handlers
+type fakeBackend struct {
+	mu        sync.Mutex
+	nextID    uint64
+	handlers  map[uint64]subscription.AccountUpdateHandler
+	accounts  map[uint64]string
+	fail      map[string]bool // accounts whose subscription is refused
+	delay     time.Duration   // time a subscription takes to be confirmed
+	maxActive int
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		handlers: make(map[uint64]subscription.AccountUpdateHandler),
+		accounts: make(map[uint64]string),
+		fail:     make(map[string]bool),
+	}
+}
+
+func (b *fakeBackend) SubscribeAccount(accountID string, handler subscription.AccountUpdateHandler) (uint64, error) {
+	return b.SubscribeAccountWithCommitment(accountID, "", handler)
+}
+
+func (b *fakeBackend) SubscribeAccountWithCommitment(accountID string, commitment string, handler subscription.AccountUpdateHandler) (uint64, error) {
+	time.Sleep(b.delay)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fail[accountID] {
+		return 0, fmt.Errorf("subscription to %s refused", accountID)
+	}
+	b.nextID++
+	b.handlers[b.nextID] = handler
+	b.accounts[b.nextID] = accountID
+	if len(b.accounts) > b.maxActive {
+		b.maxActive = len(b.accounts)
+	}
+	return b.nextID, nil
+}
+
+func (b *fakeBackend) Unsubscribe(subID uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.handlers, subID)
+	delete(b.accounts, subID)
+	return nil
+}
+
+func (b *fakeBackend) SubscribeSlot(handler subscription.SlotUpdateHandler) (uint64, error) {
+	return 0, nil
+}
+
+func (b *fakeBackend) CurrentSlot() uint64                                       { return 0 }
+func (b *fakeBackend) IsConnected() bool                                         { return true }
+func (b *fakeBackend) OnStateChange(handler subscription.ConnectionStateHandler) {}
+func (b *fakeBackend) State() subscription.ConnectionState                       { return subscription.StateConnected }
+func (b *fakeBackend) Close() error                                              { return nil }
+
+// push delivers an account update to every subscription of the account
+func (b *fakeBackend) push(account string, data []byte, slot uint64) {
+	b.mu.Lock()
+	var handlers []subscription.AccountUpdateHandler
+	for id, subscribed := range b.accounts {
+		if subscribed == account {
+			handlers = append(handlers, b.handlers[id])
+		}
+	}
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(account, data, slot)
+	}
+}
+
+// active returns the number of live subscriptions
+func (b *fakeBackend) active() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.accounts)
+}
+
+// streamPool is a pool with two vaults and optional auxiliary accounts that
+// records the account data applied to it
+type streamPool struct {
+	pkg.Pool
+	id, baseVault, quoteVault string
+
+	mu      sync.Mutex
+	aux     []string
+	applied map[string][]byte
+}
+
+func newStreamPool() *streamPool {
+	return &streamPool{
+		id:         solana.NewWallet().PublicKey().String(),
+		baseVault:  solana.NewWallet().PublicKey().String(),
+		quoteVault: solana.NewWallet().PublicKey().String(),
+		applied:    make(map[string][]byte),
+	}
+}
+
+func (p *streamPool) ProtocolName() pkg.ProtocolName { return "stream_pool" }
+func (p *streamPool) GetID() string                  { return p.id }
+func (p *streamPool) GetBaseVault() string           { return p.baseVault }
+func (p *streamPool) GetQuoteVault() string          { return p.quoteVault }
+
+func (p *streamPool) GetAuxiliaryAccounts() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.aux...)
+}
+
+func (p *streamPool) UpdateFromAccountData(accountID string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.applied[accountID] = data
+	return nil
+}
+
+func (p *streamPool) data(account string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return string(p.applied[account])
+}
+
+func newTestManager(t *testing.T, backend *fakeBackend) *subscription.SubscriptionManager {
+	sm := subscription.NewSubscriptionManagerWithBackend(context.Background(), backend)
+	t.Cleanup(func() { sm.Close() })
+	return sm
+}
+
+func TestSubscriptionCoalescesUpdates(t *testing.T) {
+	backend := newFakeBackend()
+	sm := newTestManager(t, backend)
+	sm.SetCoalesceInterval(100 * time.Millisecond)
+
+	pool := newStreamPool()
+	if err := sm.SubscribePool(pool); err != nil {
+		t.Fatal(err)
+	}
+	slots := make(chan uint64, 16)
+	sm.RegisterHandler(pool.id, func(poolID string, data []byte, slot uint64) {
+		slots <- slot
+	})
+
+	for slot := uint64(1); slot <= 10; slot++ {
+		backend.push(pool.baseVault, []byte{byte(slot)}, slot)
+	}
+
+	// The burst is delivered at most twice: once right away and once merged
+	var delivered []uint64
+	timeout := time.After(2 * time.Second)
+	for len(delivered) == 0 || delivered[len(delivered)-1] != 10 {
+		select {
+		case slot := <-slots:
+			delivered = append(delivered, slot)
+		case <-timeout:
+			t.Fatalf("the latest update never arrived, got slots %v", delivered)
+		}
+	}
+	if len(delivered) > 2 {
+		t.Fatalf("burst of 10 updates delivered %d times: %v", len(delivered), delivered)
+	}
+	if got := sm.Stats()["updatesReceived"]; got != uint64(10) {
+		t.Fatalf("updatesReceived = %v", got)
+	}
+	if pool.data(pool.baseVault) != string([]byte{10}) {
+		t.Fatal("pool state is not the latest update")
+	}
+}
+
+func TestPoolCacheSlotGuard(t *testing.T) {
+	cache := subscription.NewPoolCache()
+	pool := newStreamPool()
+	cache.SetPool(pool.id, pool)
+
+	if err := cache.UpdatePoolAccount(pool.id, pool.baseVault, []byte("slot 10"), 10); err != nil {
+		t.Fatal(err)
+	}
+	err := cache.UpdatePoolAccount(pool.id, pool.baseVault, []byte("slot 5"), 5)
+	if !errors.Is(err, subscription.ErrStaleUpdate) || !errors.Is(err, pkg.ErrStalePoolData) {
+		t.Fatalf("replayed update returned %v", err)
+	}
+	if got := pool.data(pool.baseVault); got != "slot 10" {
+		t.Fatalf("state moved back to %q", got)
+	}
+
+	// Other accounts and slotless updates are not held back
+	if err := cache.UpdatePoolAccount(pool.id, pool.quoteVault, []byte("slot 5"), 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.UpdatePoolAccount(pool.id, pool.baseVault, []byte("no slot"), 0); err != nil || pool.data(pool.baseVault) != "no slot" {
+		t.Fatalf("slotless update: %v, state %q", err, pool.data(pool.baseVault))
+	}
+	entry, _ := cache.GetPoolEntry(pool.id)
+	if entry.LastSlot != 10 || cache.Stats()["staleUpdates"] != uint64(1) {
+		t.Fatalf("last slot %d, stats %v", entry.LastSlot, cache.Stats())
+	}
+}
+
+func TestPoolCacheLRU(t *testing.T) {
+	cache := subscription.NewPoolCacheWithOptions(subscription.PoolCacheOptions{MaxEntries: 2})
+	var evicted []string
+	cache.OnEvict(func(poolID string) { evicted = append(evicted, poolID) })
+
+	a, b, c := newStreamPool(), newStreamPool(), newStreamPool()
+	cache.SetPool(a.id, a)
+	cache.SetPool(b.id, b)
+	cache.GetPool(a.id) // a is now more recently used than b
+	cache.SetPool(c.id, c)
+
+	if len(evicted) != 1 || evicted[0] != b.id {
+		t.Fatalf("evicted %v, want the least recently used pool", evicted)
+	}
+	if _, ok := cache.GetPool(a.id); !ok || cache.Size() != 2 {
+		t.Fatalf("cache holds %d pools, a cached %v", cache.Size(), ok)
+	}
+
+	// A byte limit evicts by the retained account data
+	cache.SetOptions(subscription.PoolCacheOptions{MaxBytes: 10})
+	cache.UpdatePoolAccount(a.id, a.baseVault, make([]byte, 8), 1)
+	cache.UpdatePoolAccount(c.id, c.baseVault, make([]byte, 8), 1)
+	if cache.Size() != 1 || evicted[len(evicted)-1] != a.id {
+		t.Fatalf("byte limit left %d pools, evicted %v", cache.Size(), evicted)
+	}
+	if got := cache.Stats()["cachedAccountBytes"]; got != 8 {
+		t.Fatalf("cachedAccountBytes = %v", got)
+	}
+
+	// Dropping applied data keeps the byte accounting at zero
+	cache.SetOptions(subscription.PoolCacheOptions{MaxBytes: 10, DropAccountData: true})
+	cache.UpdatePoolAccount(c.id, c.quoteVault, make([]byte, 8), 2)
+	entry, _ := cache.GetPoolEntry(c.id)
+	if _, kept := entry.AccountData[c.quoteVault]; kept || c.data(c.quoteVault) == "" {
+		t.Fatal("applied account data was kept")
+	}
+}
+
+func TestSubscriptionCapacityEviction(t *testing.T) {
+	backend := newFakeBackend()
+	sm := newTestManager(t, backend)
+	sm.SetMaxSubscriptions(6) // two pools of three accounts
+	var evicted []string
+	sm.OnPoolEvicted(func(poolID, reason string) {
+		if reason == subscription.EvictReasonCapacity {
+			evicted = append(evicted, poolID)
+		}
+	})
+
+	a, b, c, d := newStreamPool(), newStreamPool(), newStreamPool(), newStreamPool()
+	for _, pool := range []*streamPool{a, b} {
+		if err := sm.SubscribePool(pool); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	sm.Touch(a.id)
+
+	// b was read least recently
+	if err := sm.SubscribePool(c); err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != 1 || evicted[0] != b.id || sm.IsSubscribed(b.id) {
+		t.Fatalf("evicted %v, want b", evicted)
+	}
+
+	// Prioritized pools are kept even when read least recently
+	sm.SetPoolPriority(a.id, 1)
+	if err := sm.SubscribePool(d); err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != 2 || evicted[1] != c.id || !sm.IsSubscribed(a.id) {
+		t.Fatalf("evicted %v, want c after b", evicted)
+	}
+
+	sm.SetPoolPriority(d.id, 1)
+	if err := sm.SubscribePool(newStreamPool()); !errors.Is(err, subscription.ErrSubscriptionLimit) {
+		t.Fatalf("subscribing past the cap returned %v", err)
+	}
+	if backend.maxActive > 6 || backend.active() != 6 {
+		t.Fatalf("backend peaked at %d subscriptions, holds %d", backend.maxActive, backend.active())
+	}
+}
+
+func TestSubscriptionCapacityConcurrent(t *testing.T) {
+	backend := newFakeBackend()
+	backend.delay = 5 * time.Millisecond
+	sm := newTestManager(t, backend)
+	sm.SetMaxSubscriptions(7) // room for two pools of three accounts
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sm.SubscribePool(newStreamPool())
+		}()
+	}
+	wg.Wait()
+
+	if backend.maxActive > 7 {
+		t.Fatalf("concurrent subscribes reached %d subscriptions over a cap of 7", backend.maxActive)
+	}
+	if got := sm.Stats()["subscriptions"]; got != backend.active() {
+		t.Fatalf("manager counts %v subscriptions, backend holds %d", got, backend.active())
+	}
+}
+
+func TestRefreshStalePools(t *testing.T) {
+	backend := newFakeBackend()
+	sm := newTestManager(t, backend)
+	sm.SetCoalesceInterval(0)
+
+	pool := newStreamPool()
+	if err := sm.SubscribePool(pool); err != nil {
+		t.Fatal(err)
+	}
+	updated := make(chan struct{}, 16)
+	sm.RegisterHandler(pool.id, func(poolID string, data []byte, slot uint64) {
+		updated <- struct{}{}
+	})
+
+	// The pool moves to a new auxiliary account whose subscription fails
+	aux := solana.NewWallet().PublicKey().String()
+	pool.mu.Lock()
+	pool.aux = []string{aux}
+	pool.mu.Unlock()
+	backend.mu.Lock()
+	backend.fail[aux] = true
+	backend.mu.Unlock()
+	backend.push(pool.id, []byte("moved"), 1)
+	select {
+	case <-updated:
+	case <-time.After(2 * time.Second):
+		t.Fatal("update not delivered")
+	}
+	if len(sm.PoolAccounts(pool.id)) != 3 {
+		t.Fatalf("accounts %v, want the refused one missing", sm.PoolAccounts(pool.id))
+	}
+
+	client := newMockSolClient()
+	for _, account := range []string{pool.id, pool.baseVault, pool.quoteVault, aux} {
+		client.accounts[solana.MustPublicKeyFromBase58(account)] = []byte("rpc " + account[:4])
+	}
+	backend.mu.Lock()
+	delete(backend.fail, aux)
+	backend.mu.Unlock()
+
+	if err := sm.RefreshStalePools(context.Background(), client, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range []string{pool.id, pool.baseVault, pool.quoteVault, aux} {
+		if got := pool.data(account); got != "rpc "+account[:4] {
+			t.Fatalf("account %s holds %q after the refresh", account, got)
+		}
+	}
+	stats := sm.Stats()
+	if len(sm.PoolAccounts(pool.id)) != 4 || stats["resubscribed"] != uint64(1) || stats["staleRefreshes"] != uint64(4) {
+		t.Fatalf("accounts %v, stats %v", sm.PoolAccounts(pool.id), stats)
+	}
+
+	n, err := sm.RefreshPool(context.Background(), client, pool.id)
+	if err != nil || n != 4 {
+		t.Fatalf("RefreshPool refreshed %d accounts, %v", n, err)
+	}
+}
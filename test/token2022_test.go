@@ -0,0 +1,149 @@
+package test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/pool/raydium"
+	"soltrading/pkg/sol"
+)
+
+// token2022MintData builds a Token-2022 mint with a TransferFeeConfig whose
+// older and newer schedules are both bps capped at maxFee
+func token2022MintData(bps uint16, maxFee uint64) []byte {
+	data := make([]byte, 166+4+108)
+	data[165] = 1 // mint account type
+	binary.LittleEndian.PutUint16(data[166:168], 1)
+	binary.LittleEndian.PutUint16(data[168:170], 108)
+	value := data[170:]
+	for _, schedule := range [][]byte{value[72:90], value[90:108]} {
+		binary.LittleEndian.PutUint64(schedule[8:16], maxFee)
+		binary.LittleEndian.PutUint16(schedule[16:18], bps)
+	}
+	return data
+}
+
+func TestTransferFee(t *testing.T) {
+	config, err := sol.ParseTransferFeeConfig(token2022MintData(100, 1_000))
+	if err != nil || config == nil {
+		t.Fatalf("got %v, %v", config, err)
+	}
+	cases := []struct{ amount, fee uint64 }{
+		{0, 0},
+		{1, 1},        // rounded up
+		{10_000, 100}, // 1%
+		{99_999, 1_000},
+		{1 << 63, 1_000}, // capped, without overflowing
+	}
+	for _, tc := range cases {
+		if fee := config.Fee(0, tc.amount); fee != tc.fee {
+			t.Errorf("fee on %d = %d, want %d", tc.amount, fee, tc.fee)
+		}
+	}
+
+	if config, err := sol.ParseTransferFeeConfig(make([]byte, sol.MintSize)); config != nil || err != nil {
+		t.Errorf("classic mint: got %v, %v", config, err)
+	}
+}
+
+// TestCPMMQuoteWithTransferFees quotes a CPMM pool on the default fee tier
+// with a 1% Token-2022 transfer fee on one side
+func TestCPMMQuoteWithTransferFees(t *testing.T) {
+	cases := []struct {
+		name      string
+		feeOnIn   bool
+		amountOut int64
+	}{
+		// 1% of the input is withheld before the swap
+		{"input mint", true, 98_655_075},
+		// 1% of the output, capped at 1000, is withheld from the user
+		{"output mint", false, 99_649_598},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newMockSolClient()
+			pool := &raydium.CPMMPool{
+				Token0Mint:    solana.NewWallet().PublicKey(),
+				Token1Mint:    solana.NewWallet().PublicKey(),
+				Token0Vault:   solana.NewWallet().PublicKey(),
+				Token1Vault:   solana.NewWallet().PublicKey(),
+				Token0Program: solana.TokenProgramID,
+				Token1Program: solana.TokenProgramID,
+			}
+			maxFee := uint64(1_000)
+			if tc.feeOnIn {
+				pool.Token0Program = solana.Token2022ProgramID
+				maxFee = 1 << 62
+				client.accounts[pool.Token0Mint] = token2022MintData(100, maxFee)
+			} else {
+				pool.Token1Program = solana.Token2022ProgramID
+				client.accounts[pool.Token1Mint] = token2022MintData(100, maxFee)
+			}
+			client.setTokenAccount(pool.Token0Vault, 1_000_000_000_000)
+			client.setTokenAccount(pool.Token1Vault, 100_000_000_000)
+
+			out, err := pool.Quote(context.Background(), client, pool.Token0Mint.String(), math.NewInt(1_000_000_000))
+			if err != nil {
+				t.Fatalf("quote failed: %v", err)
+			}
+			if !out.Equal(math.NewInt(tc.amountOut)) {
+				t.Fatalf("got %s, want %d", out, tc.amountOut)
+			}
+
+			instrs, err := pool.BuildSwapInstructions(context.Background(), client, solana.NewWallet().PublicKey(),
+				pool.Token0Mint.String(), math.NewInt(1), math.NewInt(0), solana.PublicKey{}, solana.PublicKey{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			accounts := instrs[0].Accounts()
+			if !accounts[8].PublicKey.Equals(pool.Token0Program) || !accounts[9].PublicKey.Equals(pool.Token1Program) {
+				t.Fatalf("token programs %s, %s; want %s, %s", accounts[8].PublicKey, accounts[9].PublicKey, pool.Token0Program, pool.Token1Program)
+			}
+		})
+	}
+}
+
+func TestParseTokenAccount(t *testing.T) {
+	mint, owner, delegate := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	classic := make([]byte, sol.TokenAccountSize)
+	copy(classic[0:32], mint.Bytes())
+	copy(classic[32:64], owner.Bytes())
+	binary.LittleEndian.PutUint64(classic[64:72], 42)
+	binary.LittleEndian.PutUint32(classic[72:76], 1)
+	copy(classic[76:108], delegate.Bytes())
+	classic[108] = byte(sol.TokenAccountInitialized)
+	binary.LittleEndian.PutUint64(classic[121:129], 7)
+
+	account, err := sol.ParseTokenAccount(classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !account.Mint.Equals(mint) || !account.Owner.Equals(owner) || account.Amount != 42 ||
+		account.Delegate == nil || !account.Delegate.Equals(delegate) || account.DelegatedAmount != 7 ||
+		account.State != sol.TokenAccountInitialized || account.IsNative || account.CloseAuthority != nil {
+		t.Errorf("classic account decoded as %+v", account)
+	}
+
+	// A Token-2022 account with the ImmutableOwner extension
+	extended := append(append([]byte(nil), classic...), 2, 7, 0, 0, 0)
+	account, err = sol.ParseTokenAccount(extended)
+	if err != nil || account.Amount != 42 {
+		t.Fatalf("Token-2022 account: got %+v, %v", account, err)
+	}
+	if value := account.Extension(7); value == nil || len(value) != 0 {
+		t.Errorf("ImmutableOwner extension = %v", value)
+	}
+	if account.Extension(1) != nil {
+		t.Error("found an extension the account doesn't have")
+	}
+
+	if _, err := sol.ParseTokenAccount(token2022MintData(100, 1_000)); err == nil {
+		t.Error("a Token-2022 mint parsed as a token account")
+	}
+	if _, err := sol.TokenAccountAmount(classic[:72]); err == nil {
+		t.Error("truncated account parsed")
+	}
+}
@@ -0,0 +1,260 @@
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gorilla/websocket"
+	"soltrading/pkg/subscription"
+)
+
+// fakeNode is a WebSocket RPC node that confirms subscriptions with fresh IDs.
+// While hold is set, it keeps its answers until hold is closed; while refuse
+// is positive, it fails that many subscriptions.
+type fakeNode struct {
+	server *httptest.Server
+	conns  chan *nodeConn
+
+	mu      sync.Mutex
+	nextSub uint64
+	hold    chan struct{}
+	refuse  int
+}
+
+// nodeConn is one client connection to a fakeNode
+type nodeConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex // gorilla connections allow one writer
+}
+
+func (c *nodeConn) write(v interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.WriteJSON(v)
+}
+
+// notifyAccount sends an accountNotification for a subscription ID
+func (c *nodeConn) notifyAccount(subID uint64, data []byte, slot uint64) {
+	c.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "accountNotification",
+		"params": map[string]interface{}{
+			"subscription": subID,
+			"result": map[string]interface{}{
+				"context": map[string]interface{}{"slot": slot},
+				"value":   map[string]interface{}{"data": []string{base64.StdEncoding.EncodeToString(data), "base64"}},
+			},
+		},
+	})
+}
+
+// notifyProgram sends a programNotification for a subscription ID
+func (c *nodeConn) notifyProgram(subID uint64, account solana.PublicKey, data []byte, slot uint64) {
+	c.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "programNotification",
+		"params": map[string]interface{}{
+			"subscription": subID,
+			"result": map[string]interface{}{
+				"context": map[string]interface{}{"slot": slot},
+				"value": map[string]interface{}{
+					"pubkey":  account.String(),
+					"account": map[string]interface{}{"data": []string{base64.StdEncoding.EncodeToString(data), "base64"}},
+				},
+			},
+		},
+	})
+}
+
+func newFakeNode(t *testing.T) *fakeNode {
+	node := &fakeNode{conns: make(chan *nodeConn, 4), nextSub: 1}
+	upgrader := websocket.Upgrader{}
+	node.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		nc := &nodeConn{conn: conn}
+		node.conns <- nc
+		for {
+			var req subscription.RPCRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			node.mu.Lock()
+			response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": true}
+			if strings.HasSuffix(req.Method, "Subscribe") {
+				if node.refuse > 0 {
+					node.refuse--
+					delete(response, "result")
+					response["error"] = map[string]interface{}{"code": -32603, "message": "Internal error"}
+				} else {
+					response["result"] = node.nextSub
+					node.nextSub++
+				}
+			}
+			hold := node.hold
+			node.mu.Unlock()
+
+			go func() {
+				if hold != nil {
+					<-hold
+				}
+				nc.write(response)
+			}()
+		}
+	}))
+	t.Cleanup(node.server.Close)
+	return node
+}
+
+func (n *fakeNode) url() string {
+	return "ws" + strings.TrimPrefix(n.server.URL, "http")
+}
+
+func (n *fakeNode) accept(t *testing.T) *nodeConn {
+	t.Helper()
+	select {
+	case conn := <-n.conns:
+		return conn
+	case <-time.After(2 * time.Second):
+		t.Fatal("client did not connect")
+		return nil
+	}
+}
+
+func TestWebSocketResubscribesAfterReconnect(t *testing.T) {
+	node := newFakeNode(t)
+	client, err := subscription.NewWebSocketClientWithOptions(context.Background(), node.url(),
+		subscription.Options{Reconnect: subscription.ReconnectOptions{BaseDelay: 10 * time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	first := node.accept(t)
+
+	states := make(chan subscription.ConnectionState, 8)
+	client.OnStateChange(func(state subscription.ConnectionState, err error) { states <- state })
+	updates := make(chan string, 8)
+	if _, err := client.SubscribeAccount("pool", func(accountID string, data []byte, slot uint64) {
+		updates <- string(data)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Subscription 1 on the first connection
+	first.notifyAccount(1, []byte("first"), 10)
+	if got := <-updates; got != "first" {
+		t.Fatalf("got update %q", got)
+	}
+
+	hold := make(chan struct{})
+	node.mu.Lock()
+	node.hold = hold
+	node.mu.Unlock()
+	first.conn.Close()
+	second := node.accept(t)
+
+	// Until the node answers the re-sent subscription, the old ID routes nothing
+	// and the client does not claim to be resubscribed
+	second.notifyAccount(1, []byte("stale"), 11)
+	deadline := time.After(200 * time.Millisecond)
+	for waiting := true; waiting; {
+		select {
+		case state := <-states:
+			if state == subscription.StateResubscribed {
+				t.Fatal("StateResubscribed before the node answered")
+			}
+		case got := <-updates:
+			t.Fatalf("notification for a void subscription ID delivered: %q", got)
+		case <-deadline:
+			waiting = false
+		}
+	}
+
+	close(hold)
+	deadline = time.After(2 * time.Second)
+	for resubscribed := false; !resubscribed; {
+		select {
+		case state := <-states:
+			resubscribed = state == subscription.StateResubscribed
+		case <-deadline:
+			t.Fatal("no StateResubscribed after the node answered")
+		}
+	}
+
+	// The node assigned subscription 2 on the new connection
+	second.notifyAccount(2, []byte("second"), 12)
+	select {
+	case got := <-updates:
+		if got != "second" {
+			t.Fatalf("got update %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("update on the new subscription ID not delivered")
+	}
+}
+
+func TestWebSocketRetriesFailedResubscribes(t *testing.T) {
+	node := newFakeNode(t)
+	client, err := subscription.NewWebSocketClientWithOptions(context.Background(), node.url(),
+		subscription.Options{Reconnect: subscription.ReconnectOptions{BaseDelay: 10 * time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	first := node.accept(t)
+
+	states := make(chan subscription.ConnectionState, 8)
+	client.OnStateChange(func(state subscription.ConnectionState, err error) { states <- state })
+	updates := make(chan string, 8)
+	if _, err := client.SubscribeAccount("pool", func(accountID string, data []byte, slot uint64) {
+		updates <- string(data)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The node fails the re-sent subscription more often than the reconnect
+	// retries it, so only the background retry restores it
+	node.mu.Lock()
+	node.refuse = 4
+	node.mu.Unlock()
+	first.conn.Close()
+	second := node.accept(t)
+
+	var seen []subscription.ConnectionState
+	deadline := time.After(2 * time.Second)
+	for len(seen) == 0 || seen[len(seen)-1] != subscription.StateResubscribed {
+		select {
+		case state := <-states:
+			seen = append(seen, state)
+		case <-deadline:
+			t.Fatalf("states %v, want degraded then resubscribed", seen)
+		}
+	}
+	degraded := false
+	for _, state := range seen {
+		degraded = degraded || state == subscription.StateDegraded
+	}
+	if !degraded {
+		t.Fatalf("states %v, want StateDegraded before StateResubscribed", seen)
+	}
+
+	// The retried subscription got ID 2 on the new connection
+	second.notifyAccount(2, []byte("second"), 12)
+	select {
+	case got := <-updates:
+		if got != "second" {
+			t.Fatalf("got update %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("update on the retried subscription not delivered")
+	}
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"soltrading/pkg/anchor"
+	"soltrading/pkg/pool/whirlpool"
+	"soltrading/pkg/protocol"
+)
+
+func TestDeriveWhirlpoolAddress(t *testing.T) {
+	mintA, mintB := whirlpool.SortMints(USDC, WSOL)
+	if !mintA.Equals(WSOL) {
+		t.Fatalf("token A = %s, want WSOL", mintA)
+	}
+
+	// The SOL/USDC pools at tick spacings 2 and 4
+	for spacing, want := range map[uint16]string{
+		2: "FpCMFDFGYotvufJ7HrFHsWEiiQCGbkLCtwHiDnh7o28Q",
+		4: "Czfq3xZZDmsdGdUyrNLtRhGc47cXcZtLG4crryfu44zE",
+	} {
+		got, err := whirlpool.DeriveWhirlpoolAddress(whirlpool.WhirlpoolsConfigs[0], mintA, mintB, spacing)
+		if err != nil {
+			t.Fatalf("tick spacing %d: %v", spacing, err)
+		}
+		if got.String() != want {
+			t.Errorf("tick spacing %d: address = %s, want %s", spacing, got, want)
+		}
+	}
+}
+
+func TestWhirlpoolFetchPoolsByPDA(t *testing.T) {
+	mintA, mintB := whirlpool.SortMints(USDC, WSOL)
+	address, err := whirlpool.DeriveWhirlpoolAddress(whirlpool.WhirlpoolsConfigs[0], mintA, mintB, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 653)
+	copy(data, anchor.GetDiscriminator("account", "Whirlpool"))
+
+	client := newMockSolClient()
+	client.accounts[address] = data
+
+	// Either mint order finds the pool without scanning program accounts,
+	// which the mock doesn't serve
+	for _, pair := range [][2]solana.PublicKey{{USDC, WSOL}, {WSOL, USDC}} {
+		pools, err := protocol.NewWhirlpool(client).FetchPoolsByPair(context.Background(), pair[0].String(), pair[1].String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pools) != 1 || pools[0].GetID() != address.String() {
+			t.Fatalf("pools = %v, want the pool at %s", pools, address)
+		}
+	}
+}